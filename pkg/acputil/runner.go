@@ -0,0 +1,107 @@
+package acputil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/coder/acp-go-sdk"
+)
+
+// Agent 可以创建会话和进行对话的 Agent
+type Agent interface {
+	// NewSession 创建会话
+	NewSession(ctx context.Context, params acp.NewSessionRequest) (acp.NewSessionResponse, error)
+	// Prompt 进行一轮对话
+	Prompt(ctx context.Context, params acp.PromptRequest) (acp.PromptResponse, error)
+	// CloseSession 关闭会话，释放内存中的会话数据，返回会话是否已保存到文件
+	CloseSession(ctx context.Context, sessionID acp.SessionId) bool
+}
+
+// NewSessionRunner 创建在独立会话中执行提示的执行器
+func NewSessionRunner(agent Agent, cwd string) *SessionRunner {
+	return &SessionRunner{
+		agent:   agent,
+		cwd:     cwd,
+		outputs: make(map[acp.SessionId]*strings.Builder),
+	}
+}
+
+// SessionRunner 在独立会话中执行提示并收集回复，用于定时任务、提醒等后台任务
+//
+// 执行器作为 Agent 的客户端之一，客户端收到会话更新时应先调用 HandleUpdate ，
+// 返回 true 的更新属于后台任务会话，不应再显示给用户
+type SessionRunner struct {
+	agent Agent
+	cwd   string
+
+	lock    sync.Mutex
+	outputs map[acp.SessionId]*strings.Builder
+}
+
+// HandleUpdate 处理会话更新，属于执行中的会话时收集回复内容并返回 true
+func (r *SessionRunner) HandleUpdate(params acp.SessionNotification) bool {
+	if r == nil {
+		return false
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	output, ok := r.outputs[params.SessionId]
+	if !ok {
+		return false
+	}
+	if chunk := params.Update.AgentMessageChunk; chunk != nil && chunk.Content.Text != nil {
+		output.WriteString(chunk.Content.Text.Text)
+	}
+	return true
+}
+
+// Run 在新会话中执行提示，返回回复内容和会话 ID ， meta 随提示请求发送
+//
+// 执行结束后关闭会话，会话未保存到文件时返回的会话 ID 为空
+func (r *SessionRunner) Run(ctx context.Context, prompt string, meta map[string]any) (
+	_ string,
+	sessionID acp.SessionId,
+	_ error,
+) {
+	sessionResp, err := r.agent.NewSession(ctx, acp.NewSessionRequest{
+		Cwd:        r.cwd,
+		McpServers: []acp.McpServer{},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("new session error: %w", err)
+	}
+	sessionID = sessionResp.SessionId
+	defer func() {
+		if !r.agent.CloseSession(context.WithoutCancel(ctx), sessionID) {
+			sessionID = ""
+		}
+	}()
+
+	output := &strings.Builder{}
+	r.lock.Lock()
+	r.outputs[sessionID] = output
+	r.lock.Unlock()
+	defer func() {
+		r.lock.Lock()
+		delete(r.outputs, sessionID)
+		r.lock.Unlock()
+	}()
+
+	resp, err := r.agent.Prompt(ctx, acp.PromptRequest{
+		SessionId: sessionID,
+		Meta:      meta,
+		Prompt:    []acp.ContentBlock{acp.TextBlock(prompt)},
+	})
+	if err != nil {
+		return "", sessionID, fmt.Errorf("prompt error: %w", err)
+	}
+	if resp.StopReason != acp.StopReasonEndTurn {
+		return "", sessionID, fmt.Errorf("prompt stopped: %s", resp.StopReason)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return output.String(), sessionID, nil
+}
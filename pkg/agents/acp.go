@@ -9,19 +9,27 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/coder/acp-go-sdk"
 	"github.com/firebase/genkit/go/ai"
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/yhlooo/nfa/pkg/acputil"
 	"github.com/yhlooo/nfa/pkg/agents/flows"
+	"github.com/yhlooo/nfa/pkg/charts"
 	"github.com/yhlooo/nfa/pkg/ctxutil"
 	"github.com/yhlooo/nfa/pkg/i18n"
 	i18nutil "github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/metrics"
+	"github.com/yhlooo/nfa/pkg/ratelimit"
+	"github.com/yhlooo/nfa/pkg/reports"
 	"github.com/yhlooo/nfa/pkg/skills"
+	"github.com/yhlooo/nfa/pkg/telemetry"
 	"github.com/yhlooo/nfa/pkg/tokentracker"
+	"github.com/yhlooo/nfa/pkg/tools/chart"
 	"github.com/yhlooo/nfa/pkg/version"
 )
 
@@ -57,6 +65,9 @@ func (a *NFAAgent) Initialize(ctx context.Context, _ acp.InitializeRequest) (acp
 	// 初始化 genkit
 	a.InitGenkit(ctx)
 
+	// 初始化预算守卫
+	a.initBudgetGuard(ctx)
+
 	return acp.InitializeResponse{
 		AgentCapabilities: acp.AgentCapabilities{
 			LoadSession: true,
@@ -92,7 +103,7 @@ func (a *NFAAgent) NewSession(ctx context.Context, _ acp.NewSessionRequest) (acp
 	a.sessions[sessionID] = &Session{
 		id:            sessionID,
 		currentModels: curModels,
-		tokenTracker:  tokentracker.NewTracker(a.availableModels),
+		tokenTracker:  a.newTokenTracker(),
 	}
 
 	go a.sendAvailableCommands(ctx, sessionID)
@@ -130,7 +141,8 @@ func (a *NFAAgent) LoadSession(ctx context.Context, params acp.LoadSessionReques
 		id:            params.SessionId,
 		history:       data.Messages,
 		currentModels: curModels,
-		tokenTracker:  tokentracker.NewTracker(a.availableModels),
+		tokenTracker:  a.newTokenTracker(),
+		saved:         true,
 	}
 
 	// 回放历史消息
@@ -172,6 +184,13 @@ func (a *NFAAgent) sendAvailableCommands(ctx context.Context, sessionID acp.Sess
 			Name:        "clear",
 			Description: i18nutil.TContext(ctx, MsgCmdDescClear),
 		},
+		{
+			Name:        ReportCommandName,
+			Description: i18nutil.TContext(ctx, MsgCmdDescReport),
+			Input: &acp.AvailableCommandInput{
+				UnstructuredCommandInput: &acp.AvailableCommandUnstructuredCommandInput{Hint: i18nutil.TContext(ctx, MsgCmdHintReport)},
+			},
+		},
 	}
 	for _, skill := range a.skillLoader.ListMeta() {
 		commands = append(commands, acp.AvailableCommand{
@@ -188,7 +207,7 @@ func (a *NFAAgent) sendAvailableCommands(ctx context.Context, sessionID acp.Sess
 			},
 		},
 	}); err != nil {
-		a.logger.Error(err, "send available commands error")
+		a.logger.Error(err, "send available commands error", "sessionID", sessionID)
 	}
 }
 
@@ -200,6 +219,19 @@ func (a *NFAAgent) SetSessionMode(_ context.Context, _ acp.SetSessionModeRequest
 
 // Prompt 对话
 func (a *NFAAgent) Prompt(ctx context.Context, params acp.PromptRequest) (acp.PromptResponse, error) {
+	ctx, span := telemetry.Start(ctx, "nfa.prompt",
+		telemetry.AttrSessionID.String(string(params.SessionId)),
+		telemetry.AttrChannel.String(GetMetaStringValue(params.Meta, MetaKeyChannel)),
+		telemetry.AttrUserID.String(GetMetaStringValue(params.Meta, MetaKeyUserID)),
+	)
+	resp, err := a.prompt(ctx, params)
+	span.SetAttributes(telemetry.AttrStopReason.String(string(resp.StopReason)))
+	telemetry.End(span, err)
+	return resp, err
+}
+
+// prompt 进行一轮对话
+func (a *NFAAgent) prompt(ctx context.Context, params acp.PromptRequest) (acp.PromptResponse, error) {
 	a.lock.RLock()
 	session, ok := a.sessions[params.SessionId]
 	a.lock.RUnlock()
@@ -224,7 +256,9 @@ func (a *NFAAgent) Prompt(ctx context.Context, params acp.PromptRequest) (acp.Pr
 	session.cancelPrompt = cancel
 	messages := session.history
 	lastContextWindow := session.lastContextWindow
+	metrics.ActiveSessions.Inc()
 	defer func() {
+		metrics.ActiveSessions.Dec()
 		session.lock.Lock()
 		session.cancelPrompt = nil
 		session.history = messages
@@ -248,19 +282,46 @@ func (a *NFAAgent) Prompt(ctx context.Context, params acp.PromptRequest) (acp.Pr
 	if prompt == "" {
 		return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
 	}
+	trace.SpanFromContext(ctx).SetAttributes(
+		telemetry.AttrModel.String(session.currentModels.GetPrimary()),
+		telemetry.AttrPromptSize.Int(len(prompt)),
+	)
 	ctx = ctxutil.ContextWithModels(ctx, session.currentModels)
 	ctx = tokentracker.ContextWithTokenTracker(ctx, session.tokenTracker)
-	ctx = logr.NewContext(ctx, a.logger)
+	callInfo := tokentracker.CallInfo{
+		SessionID: string(params.SessionId),
+		Channel:   GetMetaStringValue(params.Meta, MetaKeyChannel),
+		UserID:    GetMetaStringValue(params.Meta, MetaKeyUserID),
+	}
+	ctx = tokentracker.ContextWithCallInfo(ctx, callInfo)
+	ctx = ratelimit.ContextWithRegistry(ctx, a.rateLimiters)
+	logger := a.logger.WithValues("sessionID", params.SessionId)
+	if callInfo.Channel != "" {
+		logger = logger.WithValues("channel", callInfo.Channel, "userID", callInfo.UserID)
+	}
+	ctx = logr.NewContext(ctx, logger)
 
-	a.logger.Info("prompt turn start")
+	logger.Info("prompt turn start")
 
 	extraMeta, _ := params.Meta.(map[string]any)
 	handleStreamFn := a.handleStreamChunk(params.SessionId, extraMeta)
 	ctx = ctxutil.ContextWithHandleStreamFn(ctx, handleStreamFn)
 	resp := acp.PromptResponse{Meta: map[string]any{}, StopReason: acp.StopReasonEndTurn}
 
+	// 报告模式
+	reportMode := false
+	if rest, ok := strings.CutPrefix(prompt, "/"+ReportCommandName); ok && (rest == "" || unicode.IsSpace(rune(rest[0]))) {
+		reportMode = true
+		prompt = strings.TrimSpace(rest)
+		if prompt == "" {
+			var text strings.Builder
+			text.WriteString(i18nutil.TContext(i18nutil.ContextWithLocalizer(ctx, a.localizer), MsgReportUsage))
+			return resp, a.flushBufferText(ctx, params.SessionId, extraMeta, acp.UpdateAgentMessageText, text)
+		}
+	}
+
 	// 斜杠命令
-	if strings.HasPrefix(prompt, "/") {
+	if !reportMode && strings.HasPrefix(prompt, "/") {
 		switch strings.TrimSpace(prompt) {
 		case "/clear":
 			_ = a.client.SessionUpdate(ctx, acp.SessionNotification{
@@ -324,34 +385,110 @@ func (a *NFAAgent) Prompt(ctx context.Context, params acp.PromptRequest) (acp.Pr
 		Prompt:           prompt,
 		History:          history,
 		MaxContextWindow: a.opts.MaxContextWindow,
+		Report:           reportMode,
+		Verification:     a.opts.Verification,
 	})
 	if err != nil {
-		if errors.Is(err, context.Canceled) {
+		var budgetErr *tokentracker.BudgetExceededError
+		switch {
+		case errors.Is(err, context.Canceled):
 			resp.StopReason = acp.StopReasonCancelled
 			err = nil
-		} else {
+		case errors.As(err, &budgetErr):
+			// 超出预算，告知用户而不是作为错误返回，未得到回复的提示不计入上下文
+			logger.Info("prompt refused", "reason", budgetErr.Error())
+			resp.StopReason = acp.StopReasonRefusal
+			messages = history
+			var text strings.Builder
+			text.WriteString(budgetExceededMessage(i18nutil.ContextWithLocalizer(ctx, a.localizer), budgetErr.Status))
+			err = a.flushBufferText(ctx, params.SessionId, extraMeta, acp.UpdateAgentMessageText, text)
+		default:
 			resp.StopReason = acp.StopReasonRefusal
 			messages = append(messages, ai.NewModelTextMessage("Error: "+err.Error()))
 		}
 		SetMetaCurrentModelUsage(resp.Meta, session.tokenTracker.Summary())
+		SetMetaBudgetWarnings(resp.Meta, session.tokenTracker.BudgetWarnings(callInfo))
 		return resp, err
 	}
 
 	messages = append(messages, chatOut.Messages...)
 	lastContextWindow = chatOut.LastContextWindow
+	if len(chatOut.UnverifiedClaims) > 0 {
+		var text strings.Builder
+		text.WriteString("\n\n")
+		text.WriteString(unverifiedClaimsMessage(i18nutil.ContextWithLocalizer(ctx, a.localizer), chatOut.UnverifiedClaims))
+		if err := a.flushBufferText(ctx, params.SessionId, extraMeta, acp.UpdateAgentMessageText, text); err != nil {
+			logger.Error(err, "send unverified claims warning error")
+		}
+	}
+	if chatOut.Report != nil {
+		if err := a.handleReport(ctx, params.SessionId, extraMeta, chatOut.Report); err != nil {
+			logger.Error(err, "handle report error")
+		}
+	}
 	if lastContextWindow > a.opts.MaxContextWindow {
 		resp.StopReason = acp.StopReasonMaxTokens
 	}
 
 	// 保存会话
 	if err := SaveSession(filepath.Join(a.opts.DataRoot, SessionsDirName), params.SessionId, messages); err != nil {
-		a.logger.Error(err, "save session error")
+		logger.Error(err, "save session error")
+	} else {
+		session.lock.Lock()
+		session.saved = true
+		session.lock.Unlock()
 	}
 
 	SetMetaCurrentModelUsage(resp.Meta, session.tokenTracker.Summary())
+	SetMetaBudgetWarnings(resp.Meta, session.tokenTracker.BudgetWarnings(callInfo))
 	return resp, nil
 }
 
+// handleReport 保存报告，并将来源列表、待核实问题和报告路径附加在回答之后
+func (a *NFAAgent) handleReport(
+	ctx context.Context,
+	sessionID acp.SessionId,
+	extraMeta map[string]any,
+	report *reports.Report,
+) error {
+	report.CreatedAt = time.Now()
+	var text strings.Builder
+	text.WriteString("\n\n")
+	text.WriteString(reports.MarkdownAppendix(report))
+
+	paths, err := reports.Save(filepath.Join(a.opts.DataRoot, reports.DirName), report)
+	if err != nil {
+		a.logger.Error(err, "save report error", "sessionID", sessionID)
+	} else {
+		text.WriteString("\n")
+		text.WriteString(i18nutil.TContextWithData(i18nutil.ContextWithLocalizer(ctx, a.localizer), MsgReportSaved, map[string]any{
+			"Paths": strings.Join(paths, "\n"),
+		}))
+		text.WriteString("\n")
+	}
+	return a.flushBufferText(ctx, sessionID, extraMeta, acp.UpdateAgentMessageText, text)
+}
+
+// CloseSession 关闭会话，释放内存中的会话数据，返回会话是否已保存到文件
+//
+// 已保存的会话之后仍可以通过 LoadSession 加载
+func (a *NFAAgent) CloseSession(_ context.Context, sessionID acp.SessionId) bool {
+	a.lock.Lock()
+	session, ok := a.sessions[sessionID]
+	delete(a.sessions, sessionID)
+	a.lock.Unlock()
+
+	if !ok {
+		return false
+	}
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	if session.cancelPrompt != nil {
+		session.cancelPrompt()
+	}
+	return session.saved
+}
+
 // Cancel 取消
 func (a *NFAAgent) Cancel(_ context.Context, params acp.CancelNotification) error {
 	a.lock.RLock()
@@ -383,7 +520,11 @@ func (a *NFAAgent) handleStreamChunk(sessionID acp.SessionId, extraMeta map[stri
 		}
 
 		raw, _ := json.Marshal(chunk)
-		a.logger.V(1).Info(fmt.Sprintf("model chunk: %s", string(raw)))
+		logger := a.logger
+		if l, err := logr.FromContext(ctx); err == nil {
+			logger = l
+		}
+		logger.V(1).Info(fmt.Sprintf("model chunk: %s", string(raw)))
 
 		var reasoning strings.Builder
 		var text strings.Builder
@@ -460,6 +601,19 @@ func (a *NFAAgent) handleStreamChunk(sessionID acp.SessionId, extraMeta map[stri
 				}); err != nil {
 					return fmt.Errorf("session update error: %w", err)
 				}
+
+				// 图表工具的结果以图表块的形式展示给用户
+				if part.ToolResponse.Name == chart.ChartToolName {
+					if c, ok := chart.ChartFromOutput(part.ToolResponse.Output); ok {
+						var block strings.Builder
+						block.WriteString(charts.Block(c))
+						if err := a.flushBufferText(
+							ctx, sessionID, extraMeta, acp.UpdateAgentMessageText, block,
+						); err != nil {
+							return err
+						}
+					}
+				}
 			}
 		}
 
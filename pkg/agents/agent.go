@@ -2,7 +2,10 @@ package agents
 
 import (
 	"context"
+	"path/filepath"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/coder/acp-go-sdk"
 	"github.com/firebase/genkit/go/ai"
@@ -11,14 +14,21 @@ import (
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 
 	"github.com/yhlooo/nfa/pkg/agents/flows"
+	"github.com/yhlooo/nfa/pkg/calendar"
+	"github.com/yhlooo/nfa/pkg/fx"
+	"github.com/yhlooo/nfa/pkg/logs"
 	"github.com/yhlooo/nfa/pkg/models"
+	"github.com/yhlooo/nfa/pkg/portfolio"
+	"github.com/yhlooo/nfa/pkg/ratelimit"
 	"github.com/yhlooo/nfa/pkg/skills"
 	"github.com/yhlooo/nfa/pkg/tokentracker"
 	"github.com/yhlooo/nfa/pkg/tools/alphavantage"
+	"github.com/yhlooo/nfa/pkg/tools/marketdata"
 	"github.com/yhlooo/nfa/pkg/tools/websearch"
+	"github.com/yhlooo/nfa/pkg/verify"
 )
 
-const loggerName = "agent"
+const loggerName = logs.ComponentAgent
 
 // Options Agent 运行选项
 type Options struct {
@@ -29,12 +39,23 @@ type Options struct {
 	DefaultModels    models.Models
 	DataRoot         string
 	MaxContextWindow int64
+	Pricing          tokentracker.Options
+	Budgets          tokentracker.Budgets
+	Portfolio        portfolio.Options
+	// 关注的市场，其交易状态会注入系统提示
+	Markets []string
+	// 汇率
+	FX fx.Options
+	// 回答中数值的核对
+	Verification verify.Options
 }
 
 // DataProviders 数据供应商配置
 type DataProviders struct {
 	AlphaVantage    *alphavantage.Options             `json:"alphaVantage,omitempty"`
 	TencentCloudWSA *websearch.TencentCloudWSAOptions `json:"tcloudWSA,omitempty"`
+	// 行情数据提供商，按顺序尝试
+	MarketData []marketdata.ProviderOptions `json:"marketData,omitempty"`
 }
 
 // Complete 使用默认值补全选项
@@ -48,16 +69,28 @@ func (opts *Options) Complete() {
 	if opts.MaxContextWindow == 0 {
 		opts.MaxContextWindow = 200000
 	}
+	if len(opts.Markets) == 0 {
+		opts.Markets = calendar.DefaultMarkets
+	}
+	// 未单独设置货币时使用汇率配置的基准货币
+	if opts.Pricing.Currency == "" {
+		opts.Pricing.Currency = opts.FX.BaseCurrency
+	}
+	if opts.Portfolio.BaseCurrency == "" {
+		opts.Portfolio.BaseCurrency = opts.FX.BaseCurrency
+	}
+	opts.Verification.Complete()
 }
 
 // NewNFA 创建 NFA Agent
 func NewNFA(opts Options) *NFAAgent {
 	opts.Complete()
 	return &NFAAgent{
-		opts:      opts,
-		logger:    opts.Logger.WithName(loggerName),
-		localizer: opts.Localizer,
-		sessions:  map[acp.SessionId]*Session{},
+		opts:        opts,
+		logger:      opts.Logger.WithName(loggerName),
+		localizer:   opts.Localizer,
+		sessions:    map[acp.SessionId]*Session{},
+		usageLedger: tokentracker.NewLedger(filepath.Join(opts.DataRoot, tokentracker.LedgerDirName)),
 	}
 }
 
@@ -75,6 +108,10 @@ type NFAAgent struct {
 
 	availableModels []models.ModelConfig
 	availableTools  []ai.ToolRef
+	rateLimiters    *ratelimit.Registry
+	usageLedger     *tokentracker.Ledger
+	budgetGuard     *tokentracker.BudgetGuard
+	pricingRates    *fx.Snapshot
 
 	chatFlow flows.ChatFlow
 
@@ -92,4 +129,50 @@ type Session struct {
 	history           []*ai.Message
 	tokenTracker      *tokentracker.TokenTracker
 	lastContextWindow int64
+	// 是否已保存到文件
+	saved bool
+}
+
+// newTokenTracker 创建会话的 Token 跟踪器
+func (a *NFAAgent) newTokenTracker() *tokentracker.TokenTracker {
+	tracker := tokentracker.NewTracker(a.availableModels, a.opts.Pricing)
+	tracker.SetCurrencyConverter(a.pricingConverter())
+	tracker.SetLedger(a.usageLedger)
+	tracker.SetBudgetGuard(a.budgetGuard)
+	return tracker
+}
+
+// pricingConverter 费用统计使用的货币转换器，即汇率快照，未配置汇率来源时为空
+func (a *NFAAgent) pricingConverter() fx.AmountConverter {
+	if a.pricingRates == nil {
+		return nil
+	}
+	return a.pricingRates
+}
+
+// pricingCurrencies 模型价格使用的货币
+func (a *NFAAgent) pricingCurrencies() []string {
+	var ret []string
+	for _, m := range a.availableModels {
+		if c := m.Prices.Currency; c != "" && !slices.Contains(ret, c) {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// initBudgetGuard 初始化预算守卫，并从用量账本恢复本月花费
+func (a *NFAAgent) initBudgetGuard(ctx context.Context) {
+	if a.opts.Budgets.IsZero() || a.budgetGuard != nil {
+		return
+	}
+	a.budgetGuard = tokentracker.NewBudgetGuard(a.opts.Budgets, a.opts.Pricing.Currency, a.pricingConverter())
+
+	now := time.Now()
+	records, err := a.usageLedger.Read(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local), time.Time{})
+	if err != nil {
+		a.logger.Error(err, "load usage ledger for budgets error")
+		return
+	}
+	a.budgetGuard.Load(ctx, records)
 }
@@ -2,25 +2,43 @@ package flows
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"slices"
+	"strings"
+	"time"
 
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
+	"github.com/go-logr/logr"
 
 	"github.com/yhlooo/nfa/pkg/ctxutil"
 	"github.com/yhlooo/nfa/pkg/genkitplugins/oai"
+	"github.com/yhlooo/nfa/pkg/logs"
+	"github.com/yhlooo/nfa/pkg/metrics"
+	"github.com/yhlooo/nfa/pkg/ratelimit"
+	"github.com/yhlooo/nfa/pkg/reports"
+	"github.com/yhlooo/nfa/pkg/skills"
+	"github.com/yhlooo/nfa/pkg/telemetry"
 	"github.com/yhlooo/nfa/pkg/tokentracker"
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/verify"
 )
 
+// maxReportRequests 报告模式下最多要求模型组织报告的次数
+const maxReportRequests = 2
+
 // DefineSimpleChatFlow 定义简单对话流程
 func DefineSimpleChatFlow(g *genkit.Genkit, name string, genOpts ...ai.GenerateOption) ChatFlow {
 	return genkit.DefineFlow(g, name,
-		func(ctx context.Context, in ChatInput) (ChatOutput, error) {
-			output := ChatOutput{}
+		func(ctx context.Context, in ChatInput) (output ChatOutput, err error) {
+			in.Verification.Complete()
 			messages := slices.Clone(in.History)
 			promptMsg := ai.NewUserTextMessage(in.Prompt)
 			messages = append(messages, promptMsg)
+			// 收集工具调用结果，供引用前序结果的工具使用
+			results := tools.NewResultStore(messages...)
+			ctx = tools.ContextWithResultStore(ctx, results)
 
 			modelName := ""
 			reasoningLevel := 0
@@ -29,9 +47,21 @@ func DefineSimpleChatFlow(g *genkit.Genkit, name string, genOpts ...ai.GenerateO
 				reasoningLevel = m.GetReasoningLevel()
 			}
 
+			turn := 0
+			ctx, span := telemetry.Start(ctx, "nfa.chat", telemetry.AttrModel.String(modelName))
+			defer func() {
+				span.SetAttributes(telemetry.AttrTurns.Int(turn))
+				telemetry.End(span, err)
+			}()
+
 			opts := []ai.GenerateOption{
 				ai.WithReturnToolRequests(true),
-				ai.WithMiddleware(tokentracker.ModelMiddlewareFromContext(ctx, modelName)),
+				// 先检查预算，再等待限流配额，请求耗时不含排队时间
+				ai.WithMiddleware(
+					tokentracker.ModelMiddlewareFromContext(ctx, modelName),
+					ratelimit.ModelMiddlewareFromContext(ctx, modelName),
+					metrics.ModelMiddleware(modelName),
+				),
 			}
 			if modelName != "" {
 				opts = append(opts,
@@ -46,6 +76,8 @@ func DefineSimpleChatFlow(g *genkit.Genkit, name string, genOpts ...ai.GenerateO
 			}
 
 			reflected := 0
+			reportRequested, reportSources := 0, 0
+			corrected := 0
 
 			for {
 				curTurnOpts := append([]ai.GenerateOption{ai.WithMessages(messages...)}, opts...)
@@ -57,7 +89,8 @@ func DefineSimpleChatFlow(g *genkit.Genkit, name string, genOpts ...ai.GenerateO
 				}
 
 				// 进行一轮生成
-				resp, err := genkit.Generate(ctx, g, curTurnOpts...)
+				turn++
+				resp, err := generate(ctx, g, modelName, turn, curTurnOpts...)
 				if err != nil {
 					return output, err
 				}
@@ -68,8 +101,22 @@ func DefineSimpleChatFlow(g *genkit.Genkit, name string, genOpts ...ai.GenerateO
 
 				toolRequests := resp.ToolRequests()
 				if len(toolRequests) == 0 {
-					// 反思一轮
-					if reflected < 1 {
+					var sources []reports.Source
+					switch {
+					case in.Report:
+						// 报告模式下要求模型基于工具调用结果组织报告，之后有新的工具调用结果时再要求一次
+						sources = reports.CollectSources(messages, skills.LoadSkillToolName)
+						if reportRequested < maxReportRequests && (reportRequested == 0 || len(sources) > reportSources) {
+							messages = append(messages, ai.NewUserTextMessage(reports.Instruction(sources)))
+							if err := streamReasoning(ctx, handleStream, "[report] "); err != nil {
+								return output, err
+							}
+							reportRequested++
+							reportSources = len(sources)
+							continue
+						}
+					case reflected < 1:
+						// 反思一轮
 						messages = append(messages, ai.NewUserTextMessage(`[SystemPrompt] 请根据以下检查项反思你的回答是否正确解决了用户的问题，并在确认无误后重新组织回答：
 1. 形式：检查回答在形式上是否真正回答了用户的问题？
 2. 广度和深度：回顾自己的之前的思考是否已经充分考虑了问题的广度和深度，是否有关键遗漏？
@@ -78,21 +125,31 @@ func DefineSimpleChatFlow(g *genkit.Genkit, name string, genOpts ...ai.GenerateO
 如果回答存在缺陷请调整或继续思考、探索，如果确认无误则重新组织回答。
 **注意：新组织的回答应当作给用户的第一个回答，不应该向用户透露反思结果等额外信息**
 `))
-
-						if handleStream != nil {
-							if err := handleStream(ctx, &ai.ModelResponseChunk{
-								Content: []*ai.Part{ai.NewReasoningPart("[reflection] ", nil)},
-								Role:    ai.RoleModel,
-							}); err != nil {
-								return output, fmt.Errorf("handle stream error: %w", err)
-							}
+						if err := streamReasoning(ctx, handleStream, "[reflection] "); err != nil {
+							return output, err
 						}
 						reflected++
 						continue
 					}
 
+					// 核对回答中的数值，无法追溯到工具调用结果时要求模型修正，仍无法追溯时提示用户
+					if unverified := verifyAnswer(in.Verification, messages, resp.Text()); len(unverified) > 0 {
+						if in.Verification.Mode == verify.ModeCorrect && corrected < in.Verification.MaxCorrections {
+							messages = append(messages, ai.NewUserTextMessage(verify.CorrectionPrompt(unverified)))
+							if err := streamReasoning(ctx, handleStream, "[verification] "); err != nil {
+								return output, err
+							}
+							corrected++
+							continue
+						}
+						output.UnverifiedClaims = unverified
+					}
+
 					// 结束对话
 					output.Messages = append(output.Messages, resp.Message)
+					if in.Report {
+						output.Report = reports.Parse(resp.Text(), in.Prompt, sources)
+					}
 					return output, nil
 				}
 
@@ -112,6 +169,7 @@ func DefineSimpleChatFlow(g *genkit.Genkit, name string, genOpts ...ai.GenerateO
 
 					toolResp := handleToolCall(ctx, g, toolReq)
 					parts = append(parts, toolResp)
+					results.Add(toolResp.ToolResponse.Ref, toolResp.ToolResponse.Name, toolResp.ToolResponse.Output)
 
 					if handleStream != nil {
 						if err := handleStream(ctx, &ai.ModelResponseChunk{
@@ -130,6 +188,71 @@ func DefineSimpleChatFlow(g *genkit.Genkit, name string, genOpts ...ai.GenerateO
 	)
 }
 
+// streamReasoning 向流输出一段思考过程，用于提示反思、核对等额外步骤的开始
+func streamReasoning(ctx context.Context, handleStream ai.ModelStreamCallback, text string) error {
+	if handleStream == nil {
+		return nil
+	}
+	if err := handleStream(ctx, &ai.ModelResponseChunk{
+		Content: []*ai.Part{ai.NewReasoningPart(text, nil)},
+		Role:    ai.RoleModel,
+	}); err != nil {
+		return fmt.Errorf("handle stream error: %w", err)
+	}
+	return nil
+}
+
+// verifyAnswer 将回答中的数值与对话中的工具调用结果和用户提供的数据核对，返回无法追溯的数值陈述
+//
+// 对话中没有工具调用结果时不核对
+func verifyAnswer(opts verify.Options, messages []*ai.Message, answer string) []verify.Claim {
+	if opts.Mode == verify.ModeOff {
+		return nil
+	}
+	v := verify.NewVerifier(opts)
+	hasOutputs := false
+	for _, msg := range messages {
+		for _, part := range msg.Content {
+			switch {
+			case part.IsToolResponse() && part.ToolResponse != nil:
+				v.AddOutput(part.ToolResponse.Output)
+				hasOutputs = true
+			case msg.Role == ai.RoleUser && part.IsText() && !strings.HasPrefix(part.Text, "[SystemPrompt]"):
+				v.AddText(part.Text)
+			}
+		}
+	}
+	if !hasOutputs {
+		return nil
+	}
+	return v.Verify(answer)
+}
+
+// generate 进行一轮生成并记录 span
+func generate(
+	ctx context.Context,
+	g *genkit.Genkit,
+	modelName string,
+	turn int,
+	opts ...ai.GenerateOption,
+) (resp *ai.ModelResponse, err error) {
+	ctx, span := telemetry.Start(ctx, "nfa.generate",
+		telemetry.AttrModel.String(modelName),
+		telemetry.AttrTurn.Int(turn),
+	)
+	defer func() { telemetry.End(span, err) }()
+
+	logger := logr.FromContextOrDiscard(ctx).WithName(logs.ComponentModels).WithValues("model", modelName)
+	ctx = logr.NewContext(ctx, logger)
+
+	resp, err = genkit.Generate(ctx, g, opts...)
+	if resp != nil {
+		span.SetAttributes(telemetry.UsageAttributes(resp.Usage)...)
+		span.SetAttributes(telemetry.AttrToolRequests.Int(len(resp.ToolRequests())))
+	}
+	return resp, err
+}
+
 // handleTextStream 处理文本流
 func handleTextStream(handler ai.ModelStreamCallback, reasoning, text bool) ai.ModelStreamCallback {
 	return func(ctx context.Context, chunk *ai.ModelResponseChunk) error {
@@ -151,18 +274,32 @@ func handleTextStream(handler ai.ModelStreamCallback, reasoning, text bool) ai.M
 
 // handleToolCall 处理工具调用
 func handleToolCall(ctx context.Context, g *genkit.Genkit, req *ai.ToolRequest) *ai.Part {
+	inputRaw, _ := json.Marshal(req.Input)
+	ctx, span := telemetry.Start(ctx, "nfa.tool",
+		telemetry.AttrToolName.String(req.Name),
+		telemetry.AttrToolInputSize.Int(len(inputRaw)),
+	)
+	logger := logr.FromContextOrDiscard(ctx).WithName(logs.ComponentTools).WithValues("tool", req.Name)
+	ctx = logr.NewContext(ctx, logger)
+
 	tool := genkit.LookupTool(g, req.Name)
 	if tool == nil {
 		// 找不到工具
+		metrics.ObserveToolCall(req.Name, 0, true)
+		err := ToolCallError{Err: fmt.Sprintf("tool %q not found", req.Name)}
+		telemetry.End(span, err)
 		return ai.NewToolResponsePart(&ai.ToolResponse{
 			Name:   req.Name,
 			Ref:    req.Ref,
-			Output: ToolCallError{Err: fmt.Sprintf("tool %q not found", req.Name)},
+			Output: err,
 		})
 	}
 
+	start := time.Now()
 	output, err := tool.RunRaw(ctx, req.Input)
+	metrics.ObserveToolCall(req.Name, time.Since(start), err != nil)
 	if err != nil {
+		telemetry.End(span, err)
 		return ai.NewToolResponsePart(&ai.ToolResponse{
 			Name:   req.Name,
 			Ref:    req.Ref,
@@ -170,6 +307,10 @@ func handleToolCall(ctx context.Context, g *genkit.Genkit, req *ai.ToolRequest)
 		})
 	}
 
+	outputRaw, _ := json.Marshal(output)
+	span.SetAttributes(telemetry.AttrToolOutputSize.Int(len(outputRaw)))
+	telemetry.End(span, nil)
+
 	return ai.NewToolResponsePart(&ai.ToolResponse{
 		Name:   req.Name,
 		Ref:    req.Ref,
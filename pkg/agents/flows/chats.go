@@ -3,6 +3,9 @@ package flows
 import (
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/core"
+
+	"github.com/yhlooo/nfa/pkg/reports"
+	"github.com/yhlooo/nfa/pkg/verify"
 )
 
 // ChatInput 对话输入
@@ -10,12 +13,20 @@ type ChatInput struct {
 	Prompt           string        `json:"prompt"`
 	History          []*ai.Message `json:"history,omitempty"`
 	MaxContextWindow int64         `json:"maxContextWindow,omitempty"`
+	// 报告模式，最终回答组织为引用工具调用结果的结构化研究报告
+	Report bool `json:"report,omitempty"`
+	// 回答中数值的核对选项
+	Verification verify.Options `json:"verification,omitempty"`
 }
 
 // ChatOutput 对话输出
 type ChatOutput struct {
 	Messages          []*ai.Message `json:"messages"`
 	LastContextWindow int64         `json:"lastContextWindow,omitempty"`
+	// 报告模式下解析最终回答得到的报告
+	Report *reports.Report `json:"report,omitempty"`
+	// 无法追溯到工具调用结果的数值陈述
+	UnverifiedClaims []verify.Claim `json:"unverifiedClaims,omitempty"`
 }
 
 // ChatFlow 对话流程
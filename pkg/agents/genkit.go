@@ -3,6 +3,7 @@ package agents
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/core/api"
@@ -10,8 +11,25 @@ import (
 	"github.com/go-logr/logr"
 
 	"github.com/yhlooo/nfa/pkg/agents/flows"
+	"github.com/yhlooo/nfa/pkg/alerts"
+	"github.com/yhlooo/nfa/pkg/calendar"
+	"github.com/yhlooo/nfa/pkg/fx"
 	"github.com/yhlooo/nfa/pkg/models"
+	"github.com/yhlooo/nfa/pkg/portfolio"
+	"github.com/yhlooo/nfa/pkg/ratelimit"
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/backtest"
+	"github.com/yhlooo/nfa/pkg/tools/chart"
+	"github.com/yhlooo/nfa/pkg/tools/currency"
 	"github.com/yhlooo/nfa/pkg/tools/fs"
+	"github.com/yhlooo/nfa/pkg/tools/holdings"
+	"github.com/yhlooo/nfa/pkg/tools/indicators"
+	"github.com/yhlooo/nfa/pkg/tools/marketclock"
+	"github.com/yhlooo/nfa/pkg/tools/marketdata"
+	"github.com/yhlooo/nfa/pkg/tools/options"
+	"github.com/yhlooo/nfa/pkg/tools/symbols"
+	"github.com/yhlooo/nfa/pkg/tools/valuation"
+	"github.com/yhlooo/nfa/pkg/tools/watch"
 	"github.com/yhlooo/nfa/pkg/tools/webbrowse"
 )
 
@@ -33,6 +51,22 @@ func (a *NFAAgent) InitGenkit(ctx context.Context) {
 		a.logger.Info(fmt.Sprintf("registered model: %s", m.Name))
 	}
 
+	// 初始化限流器
+	a.rateLimiters = ratelimit.NewRegistry()
+	for _, p := range a.opts.ModelProviders {
+		limits := p.RateLimit()
+		reg := p.Register()
+		if limits == nil || reg == nil {
+			continue
+		}
+		a.rateLimiters.SetProviderLimits(reg.GenkitPlugin().Name(), *limits)
+	}
+	for _, m := range a.availableModels {
+		if m.RateLimit != nil {
+			a.rateLimiters.SetModelLimits(m.Name, *m.RateLimit)
+		}
+	}
+
 	// 注册工具
 	if a.opts.DataProviders.AlphaVantage != nil {
 		alphaVantageTools, err := a.opts.DataProviders.AlphaVantage.RegisterTools(ctx, a.g)
@@ -50,6 +84,26 @@ func (a *NFAAgent) InitGenkit(ctx context.Context) {
 			a.availableTools = append(a.availableTools, searchTool)
 		}
 	}
+	var symbolSearch symbols.SearchFunc
+	if len(a.opts.DataProviders.MarketData) > 0 {
+		avKey := ""
+		if av := a.opts.DataProviders.AlphaVantage; av != nil {
+			avKey = av.APIKey
+		}
+		router, err := marketdata.NewRouterFromOptions(a.opts.DataProviders.MarketData, a.opts.DataRoot, avKey, a.logger)
+		if err != nil {
+			a.logger.Error(err, "init market data providers error")
+		} else {
+			a.availableTools = append(a.availableTools, router.RegisterTools(a.g)...)
+			symbolSearch = func(ctx context.Context, query string) ([]tools.SymbolMatch, error) {
+				return router.SearchSymbols(ctx, query, "")
+			}
+		}
+	}
+	// 证券代码解析工具
+	symbolMaster := symbols.NewMaster(filepath.Join(a.opts.DataRoot, symbols.DirName), symbolSearch)
+	a.availableTools = append(a.availableTools, symbolMaster.DefineTool(a.g))
+
 	// 网页浏览工具
 	wb := webbrowse.NewWebBrowser()
 	a.availableTools = append(a.availableTools, wb.RegisterTools(a.g)...)
@@ -57,6 +111,68 @@ func (a *NFAAgent) InitGenkit(ctx context.Context) {
 	// 文件读取工具
 	a.availableTools = append(a.availableTools, fs.DefineReadTool(a.g))
 
+	// 技术指标计算工具
+	a.availableTools = append(a.availableTools, indicators.DefineTool(a.g))
+
+	// 价格图表工具
+	a.availableTools = append(a.availableTools, chart.DefineTool(a.g))
+
+	// 回测工具
+	a.availableTools = append(a.availableTools, backtest.DefineTool(a.g, filepath.Join(a.opts.DataRoot, backtest.DirName)))
+
+	// 期权计算工具
+	a.availableTools = append(a.availableTools, options.DefineTool(a.g))
+
+	// 估值工具
+	a.availableTools = append(a.availableTools, valuation.DefineTool(a.g))
+
+	// 汇率换算工具
+	fxConverter, err := fx.NewConverterFromOptions(a.opts.FX, a.opts.DataRoot, a.logger)
+	if err != nil {
+		a.logger.Error(err, "init fx rate sources error")
+		fxConverter = fx.NewConverter()
+	}
+	if len(fxConverter.Sources()) > 0 {
+		a.availableTools = append(a.availableTools, currency.DefineTool(a.g, fxConverter))
+
+		// 费用统计使用汇率快照，统计模型调用的费用时不等待汇率查询
+		if a.opts.Pricing.Currency != "" {
+			a.pricingRates = fx.NewSnapshot(fxConverter, fx.SnapshotOptions{
+				To:         a.opts.Pricing.Currency,
+				Currencies: a.pricingCurrencies(),
+				Logger:     a.logger,
+			})
+			if a.opts.Budgets.IsZero() {
+				go a.pricingRates.Refresh(context.WithoutCancel(ctx))
+			} else {
+				// 预算需要从用量账本恢复各货币的花费，先获取汇率再恢复
+				a.pricingRates.Refresh(ctx)
+			}
+		}
+	}
+
+	// 投资组合工具
+	portfolioStore := portfolio.NewStore(filepath.Join(a.opts.DataRoot, portfolio.DirName))
+	holdingsTools := holdings.NewTools(portfolioStore, a.opts.Portfolio)
+	holdingsTools.SetConverter(fxConverter)
+	a.availableTools = append(a.availableTools, holdingsTools.RegisterTools(a.g)...)
+
+	// 自选股和提醒工具
+	watchTools := watch.NewTools(alerts.NewStore(filepath.Join(a.opts.DataRoot, alerts.DirName)))
+	a.availableTools = append(a.availableTools, watchTools.RegisterTools(a.g)...)
+
+	// 市场时钟工具
+	a.availableTools = append(a.availableTools, marketclock.DefineTool(a.g))
+	var markets []*calendar.Calendar
+	for _, m := range a.opts.Markets {
+		c, err := calendar.Get(m)
+		if err != nil {
+			a.logger.Error(err, "unknown market in config, ignored")
+			continue
+		}
+		markets = append(markets, c)
+	}
+
 	// 注册 Skill 工具
 	a.availableTools = append(a.availableTools, a.skillLoader.DefineSkillTool(a.g))
 
@@ -66,7 +182,7 @@ func (a *NFAAgent) InitGenkit(ctx context.Context) {
 
 	// 注册 flows
 	a.chatFlow = flows.DefineSimpleChatFlow(a.g, ChatFlowName,
-		ai.WithSystemFn(AnalystSystemPrompt(a.skillLoader)),
+		ai.WithSystemFn(AnalystSystemPrompt(a.skillLoader, markets...)),
 		ai.WithTools(a.availableTools...),
 	)
 }
@@ -130,3 +246,8 @@ func (a *NFAAgent) AvailableModels() []models.ModelConfig {
 	copy(ret, a.availableModels)
 	return ret
 }
+
+// Genkit 获取 genkit 对象
+func (a *NFAAgent) Genkit() *genkit.Genkit {
+	return a.g
+}
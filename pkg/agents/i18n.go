@@ -1,10 +1,83 @@
 package agents
 
-import "github.com/nicksnyder/go-i18n/v2/i18n"
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+
+	i18nutil "github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/tokentracker"
+	"github.com/yhlooo/nfa/pkg/verify"
+)
+
+// ReportCommandName 报告模式的斜杠命令名
+const ReportCommandName = "report"
 
 var (
 	MsgCmdDescClear = &i18n.Message{
 		ID:    "ui.chat.CmdDescClear",
 		Other: "Start a fresh conversation",
 	}
+	MsgCmdDescReport = &i18n.Message{
+		ID:    "ui.chat.CmdDescReport",
+		Other: "Answer as a structured research report citing tool results",
+	}
+	MsgCmdHintReport = &i18n.Message{ID: "ui.chat.CmdHintReport", Other: "question"}
+
+	MsgReportUsage = &i18n.Message{
+		ID:    "agents.ReportUsage",
+		Other: "Usage: /report <question>. The answer is organized as a research report (summary, thesis, risks, data tables and sources), and every numeric claim must cite a tool result.",
+	}
+	MsgReportSaved = &i18n.Message{ID: "agents.ReportSaved", Other: "Report saved to:\n{{ .Paths }}"}
+
+	MsgUnverifiedClaims = &i18n.Message{
+		ID:    "agents.UnverifiedClaims",
+		Other: "Note: the following figures could not be traced to the tool results of this conversation, please verify them before use:",
+	}
+
+	MsgBudgetExceeded = &i18n.Message{
+		ID:    "agents.BudgetExceeded",
+		Other: "Sorry, the {{ .Scope }} spending budget has been used up ({{ .Spent }} / {{ .Limit }} {{ .Currency }}), so this request was not sent to the model. Please try again later or contact the administrator.",
+	}
+	MsgBudgetScopeSession = &i18n.Message{ID: "agents.BudgetScopeSession", Other: "session"}
+	MsgBudgetScopeDaily   = &i18n.Message{ID: "agents.BudgetScopeDaily", Other: "daily"}
+	MsgBudgetScopeUser    = &i18n.Message{ID: "agents.BudgetScopeUser", Other: "per-user daily"}
+	MsgBudgetScopeGlobal  = &i18n.Message{ID: "agents.BudgetScopeGlobal", Other: "monthly"}
 )
+
+// BudgetScopeName 获取本地化的预算范围名
+func BudgetScopeName(ctx context.Context, scope tokentracker.BudgetScope) string {
+	switch scope {
+	case tokentracker.BudgetScopeSession:
+		return i18nutil.TContext(ctx, MsgBudgetScopeSession)
+	case tokentracker.BudgetScopeDaily:
+		return i18nutil.TContext(ctx, MsgBudgetScopeDaily)
+	case tokentracker.BudgetScopeUser:
+		return i18nutil.TContext(ctx, MsgBudgetScopeUser)
+	case tokentracker.BudgetScopeGlobal:
+		return i18nutil.TContext(ctx, MsgBudgetScopeGlobal)
+	}
+	return string(scope)
+}
+
+// unverifiedClaimsMessage 获取回答中有无法追溯的数值时提示用户的消息
+func unverifiedClaimsMessage(ctx context.Context, claims []verify.Claim) string {
+	var b strings.Builder
+	b.WriteString(i18nutil.TContext(ctx, MsgUnverifiedClaims))
+	for _, c := range claims {
+		fmt.Fprintf(&b, "\n- %s (%s)", c.Text, c.Context)
+	}
+	return b.String()
+}
+
+// budgetExceededMessage 获取超出预算时回复用户的消息
+func budgetExceededMessage(ctx context.Context, status tokentracker.BudgetStatus) string {
+	return i18nutil.TContextWithData(ctx, MsgBudgetExceeded, map[string]any{
+		"Scope":    BudgetScopeName(ctx, status.Scope),
+		"Spent":    status.Spent.StringFixed(2),
+		"Limit":    status.Limit.StringFixed(2),
+		"Currency": status.Currency,
+	})
+}
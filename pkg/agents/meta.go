@@ -8,6 +8,12 @@ import (
 
 const (
 	MetaKeyCurrentModelUsage = "currentModelUsage"
+	// MetaKeyChannel 提示所来自的信道，如 wecomAIBot 、 yuanbaoBot
+	MetaKeyChannel = "channel"
+	// MetaKeyUserID 提示所来自的信道用户 ID
+	MetaKeyUserID = "userID"
+	// MetaKeyBudgetWarnings 达到预警阈值的预算
+	MetaKeyBudgetWarnings = "budgetWarnings"
 )
 
 // GetMetaValue 从 _meta 中获取指定 key 的值
@@ -68,3 +74,31 @@ func GetMetaCurrentModelUsageValue(meta any) (tokentracker.Summary, bool) {
 
 	return usage, true
 }
+
+// SetMetaBudgetWarnings 往 _meta 设置达到预警阈值的预算
+func SetMetaBudgetWarnings(meta any, warnings []tokentracker.BudgetStatus) {
+	mapMeta, ok := meta.(map[string]any)
+	if !ok {
+		return
+	}
+	if mapMeta == nil {
+		return
+	}
+	raw, _ := json.Marshal(warnings)
+	mapMeta[MetaKeyBudgetWarnings] = string(raw)
+}
+
+// GetMetaBudgetWarningsValue 从 _meta 中获取达到预警阈值的预算
+func GetMetaBudgetWarningsValue(meta any) ([]tokentracker.BudgetStatus, bool) {
+	v := GetMetaStringValue(meta, MetaKeyBudgetWarnings)
+	if v == "" {
+		return nil, false
+	}
+
+	var warnings []tokentracker.BudgetStatus
+	if err := json.Unmarshal([]byte(v), &warnings); err != nil {
+		return nil, false
+	}
+
+	return warnings, true
+}
@@ -6,13 +6,19 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/yhlooo/nfa/pkg/calendar"
 	"github.com/yhlooo/nfa/pkg/skills"
 )
 
-// AnalystSystemPrompt 分析师系统提示
-func AnalystSystemPrompt(sl *skills.SkillLoader) func(context.Context, any) (string, error) {
-	now := time.Now().Format(time.RFC3339)
+// AnalystSystemPrompt 分析师系统提示，每次生成时注入当前时间和 markets 各市场的交易状态
+func AnalystSystemPrompt(sl *skills.SkillLoader, markets ...*calendar.Calendar) func(context.Context, any) (string, error) {
 	return func(_ context.Context, _ any) (string, error) {
+		// 精确到分钟，避免系统提示频繁变化
+		now := time.Now().Truncate(time.Minute)
+		marketStatus := make([]string, len(markets))
+		for i, c := range markets {
+			marketStatus[i] = c.Status(now).Describe()
+		}
 		return NewAgentSystemPrompt(AgentSystemPromptData{
 			Overview: "你是一个专业的金融分析师，为用户提供专业的金融咨询服务。",
 			Goal:     "你的目标是回答用户咨询的问题。",
@@ -30,9 +36,21 @@ func AnalystSystemPrompt(sl *skills.SkillLoader) func(context.Context, any) (str
 			},
 			Extra: `## 部分工具说明
 - alpha-vantage_ 开头的工具是由 AlphaVantage MCP 提供的，可用于查询美股市场的行情、咨询，不能用于查询港股、 A 股 ，港股、 A 股相关数据不要尝试通过该工具查询
+- 用户提到的证券名称或代码不是标准代码（如“腾讯”、“0700”、“贵州茅台”）时，先通过 ResolveSymbol 工具解析为标准代码（如 0700.HK 、 600519.SS ），再用标准代码调用其它工具；结果存在多个候选时应说明或向用户确认
+- Market 开头的工具通过用户配置的行情数据提供商查询报价、 K 线、基本面、分红拆股和证券代码，如果可用，查询行情（包括港股、 A 股）时应优先使用，失败时再通过 WebBrowse 查询网页
 - WebBrowse 比 WebFetch 要好得多， WebBrowse 使用视觉方式理解页面内容，如果需要访问网页应该首先使用 WebBrowse ，只有当 WebBrowse 失败时才使用 WebFetch
+- 均线、 RSI 、 MACD 、布林带、 ATR 、枢轴点、回撤等技术指标必须通过 Indicators 工具基于已查询到的 K 线数据计算，不要自行心算，回答时引用工具输出的数值
+- 需要展示价格走势时，使用 Chart 工具基于已查询到的 K 线数据绘制图表，图表会直接显示给用户，回答中不要再列出大段 K 线表格
+- 提出基于技术指标的交易规则（如 RSI 超卖买入）时，应先通过 Backtest 工具在历史 K 线上回测，并如实说明收益、回撤、胜率等结果，不要在未回测的情况下声称规则有效
+- 期权价格、希腊值、隐含波动率和期权组合的到期盈亏必须通过 Options 工具计算，不要凭经验估算
+- 给出内在价值、目标价或判断估值高低时，应先查询财务数据，再通过 Valuation 工具进行现金流折现、可比公司或股利折现估值，并在回答中说明工具输出回显的估值假设
+- 涉及“今天收盘价”、“最新行情”、“N 个交易日前”等与交易日相关的问题时，先根据下方市场状态或 MarketClock 工具确认交易日和交易时段，非交易日应使用最近已收盘交易日的数据，并注意各市场时区不同
+- 不同货币金额的换算或比较必须通过 ConvertCurrency 工具获取汇率（如果可用），不要使用记忆中的汇率；历史金额应使用对应日期的汇率
+- Portfolio 开头的工具用于查询用户本地记录的持仓、盈亏、暴露和集中度，用户询问自己的持仓或“我的组合在某行业暴露多少”等问题时应使用这些工具，不要猜测用户的持仓
+- 用户提到“自选股”时通过 Watchlist 工具读取；用户要求在价格、涨跌幅、成交量、技术指标达到条件或出现相关新闻时提醒，应通过 CreateAlert 工具创建提醒，不要回答无法主动提醒
 `,
-			Time: now,
+			Time:    now.Format(time.RFC3339),
+			Markets: marketStatus,
 		})
 	}
 }
@@ -53,6 +71,8 @@ type AgentSystemPromptData struct {
 	Extra string
 	// 当前时间
 	Time string
+	// 各市场交易状态
+	Markets []string
 }
 
 // AgentSystemPromptTpl Agent 系统提示模版
@@ -93,7 +113,13 @@ var AgentSystemPromptTpl = template.Must(template.New("AgentSystemPrompt").
 {{- end }}
 
 ## 其它信息
-- 当前日期： {{ .Time }}
+- 当前时间： {{ .Time }}
+{{- if .Markets }}
+- 市场状态：
+{{- range .Markets }}
+  - {{ . }}
+{{- end }}
+{{- end }}
 `))
 
 // NewAgentSystemPrompt 创建 Agent 系统提示
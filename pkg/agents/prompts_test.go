@@ -56,4 +56,13 @@ func TestNewAgentSystemPrompt(t *testing.T) {
 额外信息
 
 ## 其它信息`, "Result:\n"+ret)
+
+	data.Markets = []string{"美股：已收盘", "港股：交易中"}
+	ret, err = NewAgentSystemPrompt(data)
+	a.NoError(err)
+	a.Contains(ret, `
+- 市场状态：
+  - 美股：已收盘
+  - 港股：交易中
+`, "Result:\n"+ret)
 }
@@ -0,0 +1,378 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/channels"
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/websearch"
+)
+
+// nyseOpen 纽交所交易时段内的时刻
+var nyseOpen = time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+
+// fakeMarketData 测试用行情数据
+type fakeMarketData struct {
+	prices  map[string]float64
+	changes map[string]float64
+	bars    []tools.Bar
+	quotes  int
+}
+
+// Quote 获取最新报价
+func (m *fakeMarketData) Quote(_ context.Context, symbol, _ string) (tools.Quote, string, error) {
+	m.quotes++
+	price, ok := m.prices[symbol]
+	if !ok {
+		return tools.Quote{}, "", fmt.Errorf("symbol %q not found", symbol)
+	}
+	q := tools.Quote{Symbol: symbol, Price: decimal.NewFromFloat(price)}
+	if change, ok := m.changes[symbol]; ok {
+		q.ChangePercent = decimal.NewNullDecimal(decimal.NewFromFloat(change))
+	}
+	return q, "fake", nil
+}
+
+// History 获取历史 K 线
+func (m *fakeMarketData) History(_ context.Context, _ tools.HistoryRequest, _ string) ([]tools.Bar, string, error) {
+	return m.bars, "fake", nil
+}
+
+// fakePusher 测试用可推送通道
+type fakePusher struct {
+	channels.Channel
+	targets  []channels.Target
+	contents []string
+}
+
+// Push 推送消息
+func (p *fakePusher) Push(_ context.Context, target channels.Target, content string) error {
+	p.targets = append(p.targets, target)
+	p.contents = append(p.contents, content)
+	return nil
+}
+
+// bars 返回收盘价为 closes 、成交量为 volumes 的日线
+func bars(closes []float64, volumes []float64) []tools.Bar {
+	ret := make([]tools.Bar, len(closes))
+	for i, c := range closes {
+		ret[i] = tools.Bar{
+			Time:   time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i),
+			Open:   decimal.NewFromFloat(c),
+			High:   decimal.NewFromFloat(c),
+			Low:    decimal.NewFromFloat(c),
+			Close:  decimal.NewFromFloat(c),
+			Volume: decimal.NewFromFloat(volumes[i]),
+		}
+	}
+	return ret
+}
+
+// TestRuleValidate 测试校验规则和规则描述
+func TestRuleValidate(t *testing.T) {
+	valid := []struct {
+		rule Rule
+		desc string
+	}{
+		{Rule{ID: "a", Type: RulePrice, Symbol: "NVDA", Operator: OpBelow, Value: decimal.NewFromInt(100)}, "NVDA price ≤ 100"},
+		{Rule{ID: "a", Type: RulePrice, Symbol: "NVDA", Operator: OpBelow, Indicator: "sma:200"}, "NVDA price ≤ SMA(200)"},
+		{Rule{ID: "a", Type: RuleChange, Watchlist: "tech", Value: decimal.NewFromInt(-5)}, "@tech |change| ≥ 5%"},
+		{Rule{ID: "a", Type: RuleChange, Symbol: "NVDA", Operator: OpAbove, Value: decimal.NewFromInt(3)}, "NVDA change ≥ +3%"},
+		{Rule{ID: "a", Type: RuleVolume, Symbol: "NVDA", Value: decimal.NewFromInt(2)}, "NVDA volume ≥ 2x avg(20)"},
+		{Rule{ID: "a", Type: RuleIndicator, Symbol: "NVDA", Operator: OpBelow, Indicator: "rsi:14", Value: decimal.NewFromInt(30)}, "NVDA RSI(14) ≤ 30"},
+		{Rule{ID: "a", Type: RuleNews, Keyword: "NVIDIA earnings"}, `news "NVIDIA earnings"`},
+	}
+	for _, c := range valid {
+		assert.NoError(t, c.rule.Validate(), c.desc)
+		assert.Equal(t, c.desc, c.rule.String())
+	}
+
+	invalid := []Rule{
+		{Type: RulePrice, Symbol: "NVDA", Operator: OpBelow, Value: decimal.NewFromInt(100)},
+		{ID: "a", Type: "crash", Symbol: "NVDA"},
+		{ID: "a", Type: RulePrice, Operator: OpBelow, Value: decimal.NewFromInt(100)},
+		{ID: "a", Type: RulePrice, Symbol: "NVDA", Watchlist: "tech", Operator: OpBelow, Value: decimal.NewFromInt(100)},
+		{ID: "a", Type: RulePrice, Symbol: "NVDA", Value: decimal.NewFromInt(100)},
+		{ID: "a", Type: RulePrice, Symbol: "NVDA", Operator: "under", Value: decimal.NewFromInt(100)},
+		{ID: "a", Type: RuleChange, Symbol: "NVDA"},
+		{ID: "a", Type: RuleVolume, Symbol: "NVDA", Value: decimal.NewFromInt(1)},
+		{ID: "a", Type: RuleVolume, Symbol: "NVDA", Operator: OpBelow, Value: decimal.NewFromInt(2)},
+		{ID: "a", Type: RuleIndicator, Symbol: "NVDA", Operator: OpAbove, Value: decimal.NewFromInt(1)},
+		{ID: "a", Type: RuleIndicator, Symbol: "NVDA", Operator: OpAbove, Indicator: "macd:12,26,9 foo"},
+		{ID: "a", Type: RuleIndicator, Symbol: "NVDA", Operator: OpAbove, Indicator: "bb:20,2"},
+		{ID: "a", Type: RuleNews},
+		{ID: "a", Type: RuleNews, Keyword: "x", Delivery: channels.Target{Channel: "wecomAIBot"}},
+	}
+	for _, r := range invalid {
+		assert.Error(t, r.Validate(), "%+v", r)
+	}
+}
+
+// TestIndicatorValue 测试计算指标表达式
+func TestIndicatorValue(t *testing.T) {
+	closes := make([]float64, 30)
+	volumes := make([]float64, 30)
+	for i := range closes {
+		closes[i] = float64(i + 1)
+		volumes[i] = 1
+	}
+	b := bars(closes, volumes)
+
+	name, v, err := indicatorValue(b, "sma:5")
+	require.NoError(t, err)
+	assert.Equal(t, "SMA(5)", name)
+	assert.True(t, v.Valid)
+	assert.Equal(t, "28", v.Decimal.String())
+
+	name, v, err = indicatorValue(b, "bb:20,2 middle")
+	require.NoError(t, err)
+	assert.Equal(t, "BB(20,2) middle", name)
+	assert.Equal(t, "20.5", v.Decimal.String())
+
+	// 数据不足时值无效
+	_, v, err = indicatorValue(b, "sma:200")
+	require.NoError(t, err)
+	assert.False(t, v.Valid)
+
+	_, _, err = indicatorValue(b, "bb:20,2")
+	assert.ErrorContains(t, err, "lower")
+	_, _, err = indicatorValue(b, "")
+	assert.Error(t, err)
+}
+
+// newTestPoller 创建时间为 now 的测试轮询器
+func newTestPoller(t *testing.T, opts PollerOptions, now *time.Time) *Poller {
+	opts.Store = NewStore(t.TempDir())
+	p, err := NewPoller(opts)
+	require.NoError(t, err)
+	p.now = func() time.Time { return *now }
+	return p
+}
+
+// addRules 添加规则
+func addRules(t *testing.T, store *Store, rules ...Rule) {
+	require.NoError(t, store.UpdateRules(func(list *RuleList) error {
+		for _, r := range rules {
+			require.NoError(t, r.Validate())
+			list.Rules = append(list.Rules, r)
+		}
+		return nil
+	}))
+}
+
+// TestPollPrice 测试价格规则的边沿触发、只提醒一次和重复提醒
+func TestPollPrice(t *testing.T) {
+	ctx := context.Background()
+	md := &fakeMarketData{prices: map[string]float64{"NVDA": 105, "AAPL": 230, "MSFT": 400}}
+	pusher := &fakePusher{}
+	now := nyseOpen
+	var shown []Event
+	p := newTestPoller(t, PollerOptions{
+		MarketData: md,
+		Channels:   map[string]channels.Channel{"wecomAIBot": pusher},
+		OnAlert:    func(e Event) { shown = append(shown, e) },
+	}, &now)
+	require.NoError(t, p.Store().UpdateWatchlists(func(list *WatchlistList) error {
+		list.Add("", "tech", "AAPL", "MSFT")
+		return nil
+	}))
+	addRules(t, p.Store(),
+		Rule{ID: "once", Type: RulePrice, Symbol: "NVDA", Operator: OpBelow, Value: decimal.NewFromInt(100),
+			Delivery: channels.Target{Channel: "wecomAIBot", UserID: "alice"}, Note: "buy more"},
+		Rule{ID: "repeat", Type: RulePrice, Symbol: "NVDA", Operator: OpBelow, Value: decimal.NewFromInt(100), Repeat: true},
+		Rule{ID: "list", Type: RulePrice, Watchlist: "tech", Operator: OpAbove, Value: decimal.NewFromInt(300)},
+	)
+
+	// 自选股列表中满足条件的证券分别提醒
+	events := p.Poll(ctx)
+	require.Len(t, events, 1)
+	assert.Equal(t, "list", events[0].RuleID)
+	assert.Equal(t, "MSFT", events[0].Symbol)
+	assert.Equal(t, 3, md.quotes, "quotes should be cached within a poll")
+
+	// 跌破后两条规则都提醒
+	md.prices["NVDA"] = 98.5
+	now = now.Add(5 * time.Minute)
+	events = p.Poll(ctx)
+	require.Len(t, events, 2)
+	assert.Equal(t, "🔔 NVDA: price ≤ 100, now 98.5\nNote: buy more", events[0].Message)
+	assert.Equal(t, []channels.Target{{Channel: "wecomAIBot", UserID: "alice"}}, pusher.targets)
+	assert.Equal(t, []string{events[0].Message}, pusher.contents)
+
+	// 条件持续满足时不重复提醒
+	now = now.Add(5 * time.Minute)
+	assert.Empty(t, p.Poll(ctx))
+
+	// 条件恢复后再次满足时，只有重复提醒的规则提醒
+	md.prices["NVDA"] = 101
+	now = now.Add(5 * time.Minute)
+	assert.Empty(t, p.Poll(ctx))
+	md.prices["NVDA"] = 99
+	now = now.Add(5 * time.Minute)
+	events = p.Poll(ctx)
+	require.Len(t, events, 1)
+	assert.Equal(t, "repeat", events[0].RuleID)
+
+	// 休市时不检查
+	md.prices["NVDA"] = 101
+	now = time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	quotes := md.quotes
+	assert.Empty(t, p.Poll(ctx))
+	assert.Equal(t, quotes, md.quotes)
+
+	state, err := p.Store().State()
+	require.NoError(t, err)
+	assert.Len(t, state.Events, 4)
+	assert.Len(t, shown, 4)
+	assert.True(t, state.Rules["repeat"].Targets["NVDA"].Active)
+	assert.Equal(t, "99", state.Rules["repeat"].Targets["NVDA"].Value)
+
+	// 删除规则后清理状态
+	require.NoError(t, p.Store().UpdateRules(func(list *RuleList) error {
+		list.Rules = list.Rules[:1]
+		return nil
+	}))
+	now = nyseOpen.Add(24 * time.Hour)
+	p.Poll(ctx)
+	state, err = p.Store().State()
+	require.NoError(t, err)
+	assert.Len(t, state.Rules, 1)
+}
+
+// TestPollMarketRules 测试涨跌幅、放量和指标规则
+func TestPollMarketRules(t *testing.T) {
+	ctx := context.Background()
+	closes := make([]float64, 21)
+	volumes := make([]float64, 21)
+	for i := range closes {
+		closes[i] = 100 - float64(i)
+		volumes[i] = 1000
+	}
+	volumes[20] = 2500
+	md := &fakeMarketData{
+		prices:  map[string]float64{"NVDA": 95},
+		changes: map[string]float64{"NVDA": -5.234},
+		bars:    bars(closes, volumes),
+	}
+	now := nyseOpen
+	p := newTestPoller(t, PollerOptions{MarketData: md}, &now)
+	addRules(t, p.Store(),
+		Rule{ID: "change", Type: RuleChange, Symbol: "NVDA", Value: decimal.NewFromInt(5)},
+		Rule{ID: "up", Type: RuleChange, Symbol: "NVDA", Operator: OpAbove, Value: decimal.NewFromInt(5)},
+		Rule{ID: "volume", Type: RuleVolume, Symbol: "NVDA", Value: decimal.NewFromInt(2)},
+		Rule{ID: "rsi", Type: RuleIndicator, Symbol: "NVDA", Operator: OpBelow, Indicator: "rsi:14", Value: decimal.NewFromInt(30)},
+		Rule{ID: "sma", Type: RulePrice, Symbol: "NVDA", Operator: OpBelow, Indicator: "sma:200"},
+	)
+
+	events := p.Poll(ctx)
+	messages := make(map[string]string)
+	for _, e := range events {
+		messages[e.RuleID] = e.Message
+	}
+	assert.Equal(t, map[string]string{
+		"change": "🔔 NVDA: |change| ≥ 5%, now -5.23%",
+		"volume": "🔔 NVDA: volume ≥ 2x avg(20), now 2.5x",
+		"rsi":    "🔔 NVDA: RSI(14) ≤ 30, now 0",
+	}, messages)
+
+	// 数据不足时记录错误
+	state, err := p.Store().State()
+	require.NoError(t, err)
+	assert.Contains(t, state.Rules["sma"].Error, "not enough bars")
+	assert.Empty(t, state.Rules["up"].Error)
+}
+
+// TestPollNews 测试新闻规则首次只记录已有结果，之后提醒新结果并总结
+func TestPollNews(t *testing.T) {
+	ctx := context.Background()
+	results := []websearch.SearchResultItem{{Title: "old", URL: "https://example.com/old"}}
+	now := nyseOpen
+	var prompts []string
+	p := newTestPoller(t, PollerOptions{
+		Search: func(_ context.Context, query string) (websearch.SearchOutput, error) {
+			return websearch.SearchOutput{Items: results}, nil
+		},
+		Summarize: func(_ context.Context, prompt string, ruleID string) (string, error) {
+			prompts = append(prompts, prompt)
+			return " Earnings beat expectations. ", nil
+		},
+	}, &now)
+	addRules(t, p.Store(), Rule{ID: "news", Type: RuleNews, Keyword: "NVIDIA", Summarize: true})
+
+	assert.Empty(t, p.Poll(ctx))
+
+	// 未到新闻检查间隔时不检查
+	results = append(results, websearch.SearchResultItem{Title: "new", URL: "https://example.com/new"})
+	now = now.Add(5 * time.Minute)
+	assert.Empty(t, p.Poll(ctx))
+
+	now = now.Add(25 * time.Minute)
+	events := p.Poll(ctx)
+	require.Len(t, events, 1)
+	assert.Equal(t, "🔔 New search results for NVIDIA:\n- [new](https://example.com/new)\n\nEarnings beat expectations.",
+		events[0].Message)
+	require.Len(t, prompts, 1)
+	assert.Contains(t, prompts[0], "[new](https://example.com/new)")
+
+	now = now.Add(30 * time.Minute)
+	assert.Empty(t, p.Poll(ctx))
+}
+
+// TestPollNewsEmptyBaseline 测试首次检查没有搜索结果时，之后出现的结果会提醒
+func TestPollNewsEmptyBaseline(t *testing.T) {
+	ctx := context.Background()
+	var results []websearch.SearchResultItem
+	now := nyseOpen
+	p := newTestPoller(t, PollerOptions{
+		Search: func(_ context.Context, query string) (websearch.SearchOutput, error) {
+			return websearch.SearchOutput{Items: results}, nil
+		},
+	}, &now)
+	addRules(t, p.Store(), Rule{ID: "news", Type: RuleNews, Keyword: "NVIDIA"})
+
+	assert.Empty(t, p.Poll(ctx))
+
+	results = []websearch.SearchResultItem{{Title: "first", URL: "https://example.com/first"}}
+	now = now.Add(30 * time.Minute)
+	events := p.Poll(ctx)
+	require.Len(t, events, 1)
+	assert.Contains(t, events[0].Message, "https://example.com/first")
+}
+
+// TestPollPushError 测试推送失败时记录错误
+func TestPollPushError(t *testing.T) {
+	now := nyseOpen
+	p := newTestPoller(t, PollerOptions{
+		MarketData: &fakeMarketData{prices: map[string]float64{"NVDA": 98}},
+	}, &now)
+	addRules(t, p.Store(), Rule{ID: "a", Type: RulePrice, Symbol: "NVDA", Operator: OpBelow,
+		Value: decimal.NewFromInt(100), Delivery: channels.Target{Channel: "wecomAIBot", UserID: "alice"}})
+
+	events := p.Poll(context.Background())
+	require.Len(t, events, 1)
+	assert.Contains(t, events[0].Error, "not enabled")
+}
+
+// TestOptions 测试检查间隔配置
+func TestOptions(t *testing.T) {
+	interval, newsInterval, err := Options{}.intervals()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultInterval, interval)
+	assert.Equal(t, DefaultNewsInterval, newsInterval)
+
+	interval, _, err = Options{Interval: "1m"}.intervals()
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, interval)
+
+	_, _, err = Options{Interval: "10s"}.intervals()
+	assert.Error(t, err)
+	_, _, err = Options{NewsInterval: "soon"}.intervals()
+	assert.Error(t, err)
+}
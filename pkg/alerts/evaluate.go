@@ -0,0 +1,290 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/calendar"
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/indicators"
+	"github.com/yhlooo/nfa/pkg/tools/websearch"
+)
+
+// historyDays 指标和放量规则获取日线的自然日数，足够计算 200 日均线
+const historyDays = 400
+
+// MarketData 行情数据来源
+type MarketData interface {
+	// Quote 获取最新报价，返回报价和提供商名
+	Quote(ctx context.Context, symbol, provider string) (tools.Quote, string, error)
+	// History 获取历史 K 线，返回 K 线和提供商名
+	History(ctx context.Context, req tools.HistoryRequest, provider string) ([]tools.Bar, string, error)
+}
+
+// observation 规则对一只证券或一个关键词的一次检查结果
+type observation struct {
+	// 条件是否满足
+	met bool
+	// 当前值的显示文本，如 98.5 、 -5.2% 、 2.35x
+	value string
+	// 新闻规则的新结果
+	items []websearch.SearchResultItem
+}
+
+// evaluator 一轮检查中共享的数据来源，按证券缓存行情，避免多条规则重复请求
+type evaluator struct {
+	marketData MarketData
+	search     websearch.SearchFunc
+	now        time.Time
+
+	quotes map[string]tools.Quote
+	bars   map[string][]tools.Bar
+}
+
+// newEvaluator 创建一轮检查的数据来源
+func newEvaluator(marketData MarketData, search websearch.SearchFunc, now time.Time) *evaluator {
+	return &evaluator{
+		marketData: marketData,
+		search:     search,
+		now:        now,
+		quotes:     make(map[string]tools.Quote),
+		bars:       make(map[string][]tools.Bar),
+	}
+}
+
+// quote 获取最新报价
+func (e *evaluator) quote(ctx context.Context, rule *Rule, symbol string) (tools.Quote, error) {
+	key := rule.Provider + "/" + symbol
+	if q, ok := e.quotes[key]; ok {
+		return q, nil
+	}
+	if e.marketData == nil {
+		return tools.Quote{}, fmt.Errorf("no market data provider configured")
+	}
+	q, _, err := e.marketData.Quote(ctx, symbol, rule.Provider)
+	if err != nil {
+		return tools.Quote{}, err
+	}
+	q.Complete()
+	e.quotes[key] = q
+	return q, nil
+}
+
+// history 获取最近的日线
+func (e *evaluator) history(ctx context.Context, rule *Rule, symbol string) ([]tools.Bar, error) {
+	key := rule.Provider + "/" + symbol
+	if bars, ok := e.bars[key]; ok {
+		return bars, nil
+	}
+	if e.marketData == nil {
+		return nil, fmt.Errorf("no market data provider configured")
+	}
+	bars, _, err := e.marketData.History(ctx, tools.HistoryRequest{
+		Symbol:   symbol,
+		From:     e.now.AddDate(0, 0, -historyDays),
+		To:       e.now,
+		Adjusted: true,
+	}, rule.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no bars for %s", symbol)
+	}
+	e.bars[key] = bars
+	return bars, nil
+}
+
+// evaluate 检查行情类规则对一只证券的条件
+func (e *evaluator) evaluate(ctx context.Context, rule *Rule, symbol string) (observation, error) {
+	switch rule.Type {
+	case RulePrice:
+		q, err := e.quote(ctx, rule, symbol)
+		if err != nil {
+			return observation{}, err
+		}
+		if rule.Indicator == "" {
+			return observation{met: compare(q.Price, rule.Operator, rule.Value), value: q.Price.String()}, nil
+		}
+		bars, err := e.history(ctx, rule, symbol)
+		if err != nil {
+			return observation{}, err
+		}
+		name, level, err := indicatorValue(bars, rule.Indicator)
+		if err != nil {
+			return observation{}, err
+		}
+		if !level.Valid {
+			return observation{}, fmt.Errorf("not enough bars to compute %s", name)
+		}
+		return observation{
+			met:   compare(q.Price, rule.Operator, level.Decimal),
+			value: fmt.Sprintf("%s (%s %s)", q.Price, name, level.Decimal),
+		}, nil
+
+	case RuleChange:
+		q, err := e.quote(ctx, rule, symbol)
+		if err != nil {
+			return observation{}, err
+		}
+		if !q.ChangePercent.Valid {
+			return observation{}, fmt.Errorf("change percent of %s is unavailable", symbol)
+		}
+		change := q.ChangePercent.Decimal
+		met := change.Abs().GreaterThanOrEqual(rule.Value.Abs())
+		if rule.Operator != "" {
+			met = compare(change, rule.Operator, rule.Value)
+		}
+		return observation{met: met, value: signed(change.Round(2)) + "%"}, nil
+
+	case RuleVolume:
+		bars, err := e.history(ctx, rule, symbol)
+		if err != nil {
+			return observation{}, err
+		}
+		period := rule.volumePeriod()
+		if len(bars) < period+1 {
+			return observation{}, fmt.Errorf("not enough bars to compute %d-day average volume", period)
+		}
+		sum := decimal.Zero
+		for _, b := range bars[len(bars)-1-period : len(bars)-1] {
+			sum = sum.Add(b.Volume)
+		}
+		if !sum.IsPositive() {
+			return observation{}, fmt.Errorf("average volume of %s is zero", symbol)
+		}
+		ratio := bars[len(bars)-1].Volume.Mul(decimal.NewFromInt(int64(period))).Div(sum)
+		return observation{met: ratio.GreaterThanOrEqual(rule.Value), value: ratio.Round(2).String() + "x"}, nil
+
+	case RuleIndicator:
+		bars, err := e.history(ctx, rule, symbol)
+		if err != nil {
+			return observation{}, err
+		}
+		name, v, err := indicatorValue(bars, rule.Indicator)
+		if err != nil {
+			return observation{}, err
+		}
+		if !v.Valid {
+			return observation{}, fmt.Errorf("not enough bars to compute %s", name)
+		}
+		return observation{met: compare(v.Decimal, rule.Operator, rule.Value), value: v.Decimal.String()}, nil
+	}
+	return observation{}, fmt.Errorf("rule type %q is not a market rule", rule.Type)
+}
+
+// evaluateNews 检查新闻规则，返回 state 中未见过的搜索结果，首次检查时只记录不提醒
+func (e *evaluator) evaluateNews(ctx context.Context, rule *Rule, state *TargetState) (observation, error) {
+	if e.search == nil {
+		return observation{}, fmt.Errorf("web search is not configured")
+	}
+	out, err := e.search(ctx, rule.Keyword)
+	if err != nil {
+		return observation{}, err
+	}
+
+	first := state.SeededAt.IsZero()
+	var (
+		items []websearch.SearchResultItem
+		keys  []string
+	)
+	for _, item := range out.Items {
+		key := item.URL
+		if key == "" {
+			key = item.Title
+		}
+		if key == "" || slices.Contains(state.Seen, key) || slices.Contains(keys, key) {
+			continue
+		}
+		keys = append(keys, key)
+		items = append(items, item)
+	}
+	state.markSeen(keys...)
+	if first {
+		state.SeededAt = e.now
+		return observation{}, nil
+	}
+	return observation{met: len(items) > 0, value: fmt.Sprintf("%d", len(items)), items: items}, nil
+}
+
+// compare 比较 value 与阈值
+func compare(value decimal.Decimal, op Operator, threshold decimal.Decimal) bool {
+	if op == OpBelow {
+		return value.LessThanOrEqual(threshold)
+	}
+	return value.GreaterThanOrEqual(threshold)
+}
+
+// marketOpen 证券所在市场在 now 时是否交易中，无法确定市场时视为交易中
+func marketOpen(symbol string, now time.Time) bool {
+	cal, err := calendar.Get(symbol)
+	if err != nil || cal.AlwaysOpen() {
+		return true
+	}
+	return cal.Status(now).Phase == calendar.PhaseOpen
+}
+
+// indicatorValue 计算指标表达式的最新值，返回指标名和值
+//
+// 表达式为指标规格和可选的字段名，如 rsi:14 、 macd:12,26,9 hist 、 bb:20,2 lower ，
+// 有多个值的指标（ MACD 不指定字段时为 MACD 线）需要通过字段名选择其中之一
+func indicatorValue(bars []tools.Bar, expr string) (string, decimal.NullDecimal, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", decimal.NullDecimal{}, fmt.Errorf("invalid indicator %q", expr)
+	}
+	out, err := indicators.Compute(bars, "", fields[:1], 1)
+	if err != nil {
+		return "", decimal.NullDecimal{}, err
+	}
+
+	names := make([]string, 0, len(out.Latest))
+	for name := range out.Latest {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var candidates []string
+	for _, name := range names {
+		_, field, hasField := strings.Cut(name, " ")
+		switch {
+		case len(fields) == 2 && hasField && strings.EqualFold(field, fields[1]):
+			candidates = append(candidates, name)
+		case len(fields) == 1 && !hasField:
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) != 1 {
+		var available []string
+		for _, name := range names {
+			if _, field, ok := strings.Cut(name, " "); ok {
+				available = append(available, field)
+			}
+		}
+		if len(available) == 0 {
+			return "", decimal.NullDecimal{}, fmt.Errorf("indicator %q has no single value", expr)
+		}
+		return "", decimal.NullDecimal{}, fmt.Errorf("indicator %q has multiple values, specify one of: %s",
+			expr, strings.Join(available, ", "))
+	}
+
+	name := candidates[0]
+	v, err := decimal.NewFromString(out.Latest[name])
+	if err != nil {
+		return name, decimal.NullDecimal{}, nil
+	}
+	return name, decimal.NewNullDecimal(v), nil
+}
+
+// indicatorName 返回指标表达式的显示名，如 RSI(14)
+func indicatorName(expr string) string {
+	name, _, err := indicatorValue(testBars, expr)
+	if err != nil {
+		return expr
+	}
+	return name
+}
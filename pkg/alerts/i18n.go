@@ -0,0 +1,24 @@
+package alerts
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+var (
+	MsgAlertTriggered = &i18n.Message{
+		ID:    "alerts.AlertTriggered",
+		Other: "🔔 {{.Symbol}}: {{.Condition}}, now {{.Value}}",
+	}
+	MsgNewsAlert = &i18n.Message{
+		ID:    "alerts.NewsAlert",
+		Other: "🔔 New search results for {{.Keyword}}:",
+	}
+	MsgAlertNote = &i18n.Message{
+		ID:    "alerts.AlertNote",
+		Other: "Note: {{.Note}}",
+	}
+	MsgSummarizePrompt = &i18n.Message{
+		ID: "alerts.SummarizePrompt",
+		Other: "The following price or news alert has just fired:\n\n{{.Alert}}\n\n" +
+			"Use the available tools to check the latest quotes and news, " +
+			"and explain in no more than three sentences why it most likely fired. Reply with the explanation only.",
+	}
+)
@@ -0,0 +1,397 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/yhlooo/nfa/pkg/channels"
+	"github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/tools/websearch"
+)
+
+const (
+	// ChannelName 提醒总结提示的来源信道名，用于用量统计和预算
+	ChannelName = channels.AlertsChannelName
+
+	// DefaultInterval 默认的行情类规则检查间隔
+	DefaultInterval = 5 * time.Minute
+	// DefaultNewsInterval 默认的新闻规则检查间隔
+	DefaultNewsInterval = 30 * time.Minute
+
+	// leaseInterval 续约租约的间隔，需小于租约有效期
+	leaseInterval = 30 * time.Second
+	// maxNewsItems 新闻提醒中最多列出的结果数
+	maxNewsItems = 5
+)
+
+// Options 提醒配置
+type Options struct {
+	// 行情类规则的检查间隔，如 1m 、 5m ，默认 5m
+	Interval string `json:"interval,omitempty"`
+	// 新闻规则的检查间隔，默认 30m
+	NewsInterval string `json:"newsInterval,omitempty"`
+}
+
+// intervals 解析检查间隔，未设置时使用默认值
+func (opts Options) intervals() (time.Duration, time.Duration, error) {
+	parse := func(name, value string, def time.Duration) (time.Duration, error) {
+		if value == "" {
+			return def, nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < time.Minute {
+			return 0, fmt.Errorf("invalid alerts %s %q: must be a duration of at least 1m", name, value)
+		}
+		return d, nil
+	}
+	interval, err := parse("interval", opts.Interval, DefaultInterval)
+	if err != nil {
+		return 0, 0, err
+	}
+	newsInterval, err := parse("newsInterval", opts.NewsInterval, DefaultNewsInterval)
+	if err != nil {
+		return 0, 0, err
+	}
+	return interval, newsInterval, nil
+}
+
+// SummarizeFunc 在独立会话中执行提示 prompt 并返回回复， ruleID 用于用量统计
+type SummarizeFunc func(ctx context.Context, prompt string, ruleID string) (string, error)
+
+// PollerOptions 轮询器选项
+type PollerOptions struct {
+	// 规则存储
+	Store *Store
+	// 行情数据来源，未配置时行情类规则检查失败
+	MarketData MarketData
+	// 网络搜索，未配置时新闻规则检查失败
+	Search websearch.SearchFunc
+	// 由模型总结提醒原因，为 nil 时不总结
+	Summarize SummarizeFunc
+	// 通道名到通道，用于推送提醒
+	Channels map[string]channels.Channel
+	// 提醒配置
+	Options Options
+	// 每次提醒后调用，用于在界面中显示提醒
+	OnAlert func(event Event)
+}
+
+// NewPoller 创建轮询器
+func NewPoller(opts PollerOptions) (*Poller, error) {
+	interval, newsInterval, err := opts.Options.intervals()
+	if err != nil {
+		return nil, err
+	}
+	return &Poller{
+		store:        opts.Store,
+		marketData:   opts.MarketData,
+		search:       opts.Search,
+		summarize:    opts.Summarize,
+		channels:     opts.Channels,
+		onAlert:      opts.OnAlert,
+		interval:     interval,
+		newsInterval: newsInterval,
+		owner:        NewRuleID() + NewRuleID(),
+		now:          time.Now,
+	}, nil
+}
+
+// Poller 轮询器，定期检查提醒规则，条件满足时推送提醒
+type Poller struct {
+	store        *Store
+	marketData   MarketData
+	search       websearch.SearchFunc
+	summarize    SummarizeFunc
+	channels     map[string]channels.Channel
+	onAlert      func(event Event)
+	interval     time.Duration
+	newsInterval time.Duration
+	owner        string
+	now          func() time.Time
+
+	lock    sync.Mutex
+	running bool
+	wg      sync.WaitGroup
+}
+
+// Store 返回规则存储
+func (p *Poller) Store() *Store {
+	return p.store
+}
+
+// SetOnAlert 设置每次提醒后调用的函数
+func (p *Poller) SetOnAlert(fn func(event Event)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.onAlert = fn
+}
+
+// Run 运行轮询器，直到 ctx 结束
+//
+// 同一数据目录下只有持有租约的进程检查规则，其它进程定期尝试接管
+func (p *Poller) Run(ctx context.Context) {
+	logger := logr.FromContextOrDiscard(ctx).WithName("alerts")
+	ctx = logr.NewContext(ctx, logger)
+	logger.Info("alert poller started", "dir", p.store.Dir(), "interval", p.interval)
+
+	defer func() {
+		p.wg.Wait()
+		if err := p.store.Lease().Release(p.owner); err != nil {
+			logger.Error(err, "release lease error")
+		}
+	}()
+
+	var lastCheck time.Time
+	ticker := time.NewTicker(leaseInterval)
+	defer ticker.Stop()
+	for {
+		now := p.now()
+		ok, err := p.store.Lease().Acquire(p.owner, now)
+		switch {
+		case err != nil:
+			logger.Error(err, "acquire lease error")
+		case !ok:
+			logger.V(1).Info("lease held by another process, skip")
+		case now.Sub(lastCheck) >= p.interval && p.start():
+			lastCheck = now
+			p.wg.Add(1)
+			go func() {
+				defer p.wg.Done()
+				defer p.finish()
+				p.Poll(ctx)
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll 检查一轮规则并推送触发的提醒，返回触发的提醒
+func (p *Poller) Poll(ctx context.Context) []Event {
+	logger := logr.FromContextOrDiscard(ctx)
+	triggered, err := p.check(ctx)
+	if err != nil {
+		logger.Error(err, "check alert rules error")
+		return nil
+	}
+
+	events := make([]Event, 0, len(triggered))
+	for _, t := range triggered {
+		event := p.notify(ctx, t)
+		logger.Info("alert triggered", "rule", event.RuleID, "symbol", event.Symbol, "error", event.Error)
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	if err := p.store.UpdateState(func(state *State) error {
+		state.AddEvents(events...)
+		return nil
+	}); err != nil {
+		logger.Error(err, "save alert events error")
+	}
+	return events
+}
+
+// trigger 一次触发
+type trigger struct {
+	rule   Rule
+	symbol string
+	obs    observation
+	at     time.Time
+}
+
+// check 检查所有到期的规则，更新规则状态，返回触发的规则
+func (p *Poller) check(ctx context.Context) ([]trigger, error) {
+	now := p.now()
+	rules, err := p.store.Rules()
+	if err != nil {
+		return nil, err
+	}
+	watchlists, err := p.store.Watchlists()
+	if err != nil {
+		return nil, err
+	}
+	state, err := p.store.State()
+	if err != nil {
+		return nil, err
+	}
+
+	eval := newEvaluator(p.marketData, p.search, now)
+	checked := make(map[string]*RuleState)
+	var triggered []trigger
+	for _, rule := range rules.Rules {
+		if rule.Disabled || ctx.Err() != nil {
+			continue
+		}
+		rs := state.Rule(rule.ID)
+
+		if rule.Type == RuleNews {
+			// 留出半个检查间隔的余量，避免因轮询时间抖动推迟一整个间隔
+			if !rs.CheckedAt.IsZero() && now.Sub(rs.CheckedAt)+p.interval/2 < p.newsInterval {
+				continue
+			}
+			ts := rs.Target(rule.Keyword)
+			obs, err := eval.evaluateNews(ctx, &rule, ts)
+			rs.CheckedAt, rs.Error = now, errorString(err)
+			checked[rule.ID] = rs
+			if err == nil && obs.met {
+				ts.FiredAt = now
+				triggered = append(triggered, trigger{rule: rule, obs: obs, at: now})
+			}
+			continue
+		}
+
+		symbols, err := rule.Symbols(watchlists)
+		if err != nil {
+			rs.CheckedAt, rs.Error = now, err.Error()
+			checked[rule.ID] = rs
+			continue
+		}
+		var errs []error
+		evaluated := false
+		for _, symbol := range symbols {
+			ts := rs.Target(symbol)
+			if !rule.Repeat && !ts.FiredAt.IsZero() {
+				continue
+			}
+			// 休市时行情不变，不检查
+			if !marketOpen(symbol, now) {
+				continue
+			}
+			evaluated = true
+			obs, err := eval.evaluate(ctx, &rule, symbol)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", symbol, err))
+				continue
+			}
+			ts.Value = obs.value
+			if obs.met && !ts.Active {
+				ts.FiredAt = now
+				triggered = append(triggered, trigger{rule: rule, symbol: symbol, obs: obs, at: now})
+			}
+			ts.Active = obs.met
+		}
+		if evaluated {
+			rs.CheckedAt, rs.Error = now, errorString(errors.Join(errs...))
+			checked[rule.ID] = rs
+		}
+	}
+
+	err = p.store.UpdateState(func(s *State) error {
+		for id := range s.Rules {
+			if rules.Find(id) < 0 {
+				delete(s.Rules, id)
+			}
+		}
+		for id, rs := range checked {
+			*s.Rule(id) = *rs
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return triggered, nil
+}
+
+// notify 生成提醒内容，按规则总结触发原因并推送
+func (p *Poller) notify(ctx context.Context, t trigger) Event {
+	event := Event{
+		Time:     t.at,
+		RuleID:   t.rule.ID,
+		Symbol:   t.symbol,
+		Message:  message(ctx, &t.rule, t.symbol, t.obs),
+		Delivery: t.rule.Delivery,
+	}
+
+	var errs []error
+	if t.rule.Summarize && p.summarize != nil {
+		prompt := i18n.TContextWithData(ctx, MsgSummarizePrompt, map[string]any{"Alert": event.Message})
+		output, err := p.summarize(ctx, prompt, t.rule.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("summarize alert error: %w", err))
+		} else if summary := strings.TrimSpace(output); summary != "" {
+			event.Message += "\n\n" + summary
+		}
+	}
+	if t.rule.Delivery.Channel != "" {
+		if err := channels.Push(ctx, p.channels, t.rule.Delivery, event.Message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	event.Error = errorString(errors.Join(errs...))
+
+	p.lock.Lock()
+	onAlert := p.onAlert
+	p.lock.Unlock()
+	if onAlert != nil {
+		onAlert(event)
+	}
+	return event
+}
+
+// message 生成提醒内容
+func message(ctx context.Context, rule *Rule, symbol string, obs observation) string {
+	b := &strings.Builder{}
+	if rule.Type == RuleNews {
+		b.WriteString(i18n.TContextWithData(ctx, MsgNewsAlert, map[string]any{"Keyword": rule.Keyword}))
+		for i, item := range obs.items {
+			if i >= maxNewsItems {
+				_, _ = fmt.Fprintf(b, "\n- … (+%d)", len(obs.items)-maxNewsItems)
+				break
+			}
+			if item.URL != "" {
+				_, _ = fmt.Fprintf(b, "\n- [%s](%s)", item.Title, item.URL)
+			} else {
+				_, _ = fmt.Fprintf(b, "\n- %s", item.Title)
+			}
+		}
+	} else {
+		b.WriteString(i18n.TContextWithData(ctx, MsgAlertTriggered, map[string]any{
+			"Symbol":    symbol,
+			"Condition": rule.Condition(),
+			"Value":     obs.value,
+		}))
+	}
+	if rule.Note != "" {
+		b.WriteString("\n" + i18n.TContextWithData(ctx, MsgAlertNote, map[string]any{"Note": rule.Note}))
+	}
+	return b.String()
+}
+
+// start 标记开始一轮检查，上一轮未结束时返回 false
+func (p *Poller) start() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.running {
+		return false
+	}
+	p.running = true
+	return true
+}
+
+// finish 标记一轮检查结束
+func (p *Poller) finish() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.running = false
+}
+
+// errorString 返回错误信息， err 为 nil 时返回空字符串
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,227 @@
+package alerts
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/channels"
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// RuleType 提醒规则类型
+type RuleType string
+
+const (
+	// RulePrice 价格高于或低于某一价位，价位可以是技术指标值
+	RulePrice RuleType = "price"
+	// RuleChange 当日涨跌幅达到阈值
+	RuleChange RuleType = "change"
+	// RuleVolume 成交量达到近期平均成交量的倍数
+	RuleVolume RuleType = "volume"
+	// RuleIndicator 技术指标高于或低于阈值
+	RuleIndicator RuleType = "indicator"
+	// RuleNews 关键词出现新的搜索结果
+	RuleNews RuleType = "news"
+)
+
+// Operator 比较方向
+type Operator string
+
+const (
+	// OpAbove 大于等于阈值
+	OpAbove Operator = "above"
+	// OpBelow 小于等于阈值
+	OpBelow Operator = "below"
+)
+
+// DefaultVolumePeriod 放量规则默认的平均成交量天数
+const DefaultVolumePeriod = 20
+
+// Rule 提醒规则
+type Rule struct {
+	ID   string   `json:"id"`
+	Type RuleType `json:"type"`
+	// 证券代码，与 Watchlist 二选一
+	Symbol string `json:"symbol,omitempty"`
+	// 自选股列表名，规则对列表中的每只证券生效
+	Watchlist string `json:"watchlist,omitempty"`
+	// 比较方向，涨跌幅规则为空时表示任一方向
+	Operator Operator `json:"operator,omitempty"`
+	// 阈值：价格规则为价位，涨跌幅规则为百分比，放量规则为倍数，指标规则为指标值
+	Value decimal.Decimal `json:"value,omitzero"`
+	// 技术指标，如 rsi:14 、 sma:200 、 macd:12,26,9 hist ，价格规则指定时以指标值作为价位
+	Indicator string `json:"indicator,omitempty"`
+	// 放量规则的平均成交量天数，默认 20
+	Period int `json:"period,omitempty"`
+	// 新闻规则的搜索关键词
+	Keyword string `json:"keyword,omitempty"`
+	// 行情数据提供商，为空时按配置顺序尝试
+	Provider string `json:"provider,omitempty"`
+	// 条件不再满足后再次满足时重复提醒，默认只提醒一次。新闻规则总是提醒每一批新结果
+	Repeat bool `json:"repeat,omitempty"`
+	// 提醒时由模型总结触发原因
+	Summarize bool `json:"summarize,omitempty"`
+	// 备注，随提醒发送
+	Note string `json:"note,omitempty"`
+	// 推送目标，为空时只记录在提醒历史中
+	Delivery channels.Target `json:"delivery,omitzero"`
+	// 所有者，同 Watchlist.Owner ，规则只使用所有者的自选股列表
+	Owner string `json:"owner,omitempty"`
+	// 是否暂停
+	Disabled  bool      `json:"disabled,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+}
+
+// NewRuleID 生成随机的规则 ID
+func NewRuleID() string {
+	return fmt.Sprintf("%08x", rand.Uint32())
+}
+
+// Validate 校验规则
+func (r *Rule) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule id is required")
+	}
+	switch r.Type {
+	case RulePrice, RuleChange, RuleVolume, RuleIndicator:
+		if (r.Symbol == "") == (r.Watchlist == "") {
+			return fmt.Errorf("exactly one of symbol and watchlist is required for %s rule", r.Type)
+		}
+	case RuleNews:
+		if strings.TrimSpace(r.Keyword) == "" {
+			return fmt.Errorf("keyword is required for news rule")
+		}
+	default:
+		return fmt.Errorf("invalid rule type %q (available: %s, %s, %s, %s, %s)",
+			r.Type, RulePrice, RuleChange, RuleVolume, RuleIndicator, RuleNews)
+	}
+
+	switch r.Operator {
+	case OpAbove, OpBelow:
+	case "":
+		if r.Type != RuleChange && r.Type != RuleNews && r.Type != RuleVolume {
+			return fmt.Errorf("operator is required for %s rule (available: %s, %s)", r.Type, OpAbove, OpBelow)
+		}
+	default:
+		return fmt.Errorf("invalid operator %q (available: %s, %s)", r.Operator, OpAbove, OpBelow)
+	}
+
+	switch r.Type {
+	case RulePrice:
+		if r.Indicator == "" && !r.Value.IsPositive() {
+			return fmt.Errorf("price level must be positive")
+		}
+	case RuleChange:
+		if r.Value.IsZero() {
+			return fmt.Errorf("percent change threshold is required")
+		}
+	case RuleVolume:
+		if r.Operator == OpBelow {
+			return fmt.Errorf("operator %s is not supported for volume rule", OpBelow)
+		}
+		if r.Value.LessThanOrEqual(decimal.NewFromInt(1)) {
+			return fmt.Errorf("volume multiple must be greater than 1")
+		}
+		if r.Period < 0 {
+			return fmt.Errorf("volume period must be positive")
+		}
+	case RuleIndicator:
+		if r.Indicator == "" {
+			return fmt.Errorf("indicator is required for indicator rule")
+		}
+	}
+	if r.Indicator != "" {
+		if _, _, err := indicatorValue(testBars, r.Indicator); err != nil {
+			return err
+		}
+	}
+
+	if r.Delivery.Channel != "" && r.Delivery.UserID == "" && r.Delivery.GroupID == "" {
+		return fmt.Errorf("user or group is required when delivering to channel %q", r.Delivery.Channel)
+	}
+	return nil
+}
+
+// Symbols 返回规则适用的证券代码
+func (r *Rule) Symbols(watchlists *WatchlistList) ([]string, error) {
+	if r.Symbol != "" {
+		return []string{r.Symbol}, nil
+	}
+	i := watchlists.Find(r.Owner, r.Watchlist)
+	if i < 0 {
+		return nil, fmt.Errorf("watchlist %q not found", r.Watchlist)
+	}
+	return watchlists.Watchlists[i].Symbols, nil
+}
+
+// Subject 返回规则的对象：证券代码、自选股列表或新闻关键词
+func (r *Rule) Subject() string {
+	switch {
+	case r.Type == RuleNews:
+		return fmt.Sprintf("%q", r.Keyword)
+	case r.Watchlist != "":
+		return "@" + r.Watchlist
+	default:
+		return r.Symbol
+	}
+}
+
+// Condition 返回规则条件的简短描述，如 price ≤ 100 、 RSI(14) ≥ 70
+func (r *Rule) Condition() string {
+	cmp := func(threshold string) string {
+		if r.Operator == OpBelow {
+			return "≤ " + threshold
+		}
+		return "≥ " + threshold
+	}
+	switch r.Type {
+	case RulePrice:
+		if r.Indicator != "" {
+			return "price " + cmp(indicatorName(r.Indicator))
+		}
+		return "price " + cmp(r.Value.String())
+	case RuleChange:
+		if r.Operator == "" {
+			return "|change| ≥ " + r.Value.Abs().String() + "%"
+		}
+		return "change " + cmp(signed(r.Value)+"%")
+	case RuleVolume:
+		return fmt.Sprintf("volume ≥ %sx avg(%d)", r.Value.String(), r.volumePeriod())
+	case RuleIndicator:
+		return indicatorName(r.Indicator) + " " + cmp(r.Value.String())
+	case RuleNews:
+		return "news"
+	}
+	return string(r.Type)
+}
+
+// String 返回规则的简短描述
+func (r *Rule) String() string {
+	if r.Type == RuleNews {
+		return "news " + r.Subject()
+	}
+	return r.Subject() + " " + r.Condition()
+}
+
+// volumePeriod 放量规则的平均成交量天数
+func (r *Rule) volumePeriod() int {
+	if r.Period > 0 {
+		return r.Period
+	}
+	return DefaultVolumePeriod
+}
+
+// signed 返回带正负号的数值
+func signed(d decimal.Decimal) string {
+	if d.IsPositive() {
+		return "+" + d.String()
+	}
+	return d.String()
+}
+
+// testBars 用于校验指标参数的 K 线
+var testBars = []tools.Bar{{Close: decimal.NewFromInt(1), Volume: decimal.NewFromInt(1)}}
@@ -0,0 +1,311 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yhlooo/nfa/pkg/channels"
+	"github.com/yhlooo/nfa/pkg/lease"
+)
+
+const (
+	// DirName 提醒数据目录名
+	DirName = "alerts"
+	// WatchlistsFileName 自选股列表文件名
+	WatchlistsFileName = "watchlists.json"
+	// RulesFileName 提醒规则文件名
+	RulesFileName = "rules.json"
+	// StateFileName 提醒状态文件名
+	StateFileName = "state.json"
+	// LeaseFileName 轮询租约文件名
+	LeaseFileName = "lease.json"
+
+	// MaxEvents 提醒历史最多保留的条数
+	MaxEvents = 100
+	// maxSeen 新闻规则最多记录的已见结果数
+	maxSeen = 200
+)
+
+// Watchlist 自选股列表
+type Watchlist struct {
+	Name    string   `json:"name"`
+	Symbols []string `json:"symbols"`
+	// 所有者，在消息通道中创建时为通道用户，如 wecomAIBot:user:alice ，为空时属于本地用户
+	Owner string `json:"owner,omitempty"`
+}
+
+// WatchlistList 自选股列表文件内容
+type WatchlistList struct {
+	Watchlists []Watchlist `json:"watchlists"`
+}
+
+// Find 根据所有者和名称查找自选股列表，返回下标，不存在时返回 -1
+func (l *WatchlistList) Find(owner, name string) int {
+	for i, w := range l.Watchlists {
+		if w.Owner == owner && strings.EqualFold(w.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add 向所有者的自选股列表添加证券，列表不存在时创建，返回实际添加的证券
+func (l *WatchlistList) Add(owner, name string, symbols ...string) []string {
+	i := l.Find(owner, name)
+	if i < 0 {
+		l.Watchlists = append(l.Watchlists, Watchlist{Name: name, Owner: owner})
+		i = len(l.Watchlists) - 1
+	}
+	w := &l.Watchlists[i]
+	var added []string
+	for _, symbol := range symbols {
+		if slices.ContainsFunc(w.Symbols, func(s string) bool { return strings.EqualFold(s, symbol) }) {
+			continue
+		}
+		w.Symbols = append(w.Symbols, symbol)
+		added = append(added, symbol)
+	}
+	if w.Symbols == nil {
+		w.Symbols = []string{}
+	}
+	return added
+}
+
+// Remove 从所有者的自选股列表移除证券，未指定证券时删除整个列表，返回列表是否存在
+func (l *WatchlistList) Remove(owner, name string, symbols ...string) bool {
+	i := l.Find(owner, name)
+	if i < 0 {
+		return false
+	}
+	if len(symbols) == 0 {
+		l.Watchlists = append(l.Watchlists[:i], l.Watchlists[i+1:]...)
+		return true
+	}
+	w := &l.Watchlists[i]
+	w.Symbols = slices.DeleteFunc(w.Symbols, func(s string) bool {
+		return slices.ContainsFunc(symbols, func(symbol string) bool { return strings.EqualFold(s, symbol) })
+	})
+	return true
+}
+
+// RuleList 提醒规则文件内容
+type RuleList struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Find 根据 ID 查找规则，返回下标，不存在时返回 -1
+func (l *RuleList) Find(id string) int {
+	for i, r := range l.Rules {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// State 提醒状态文件内容
+type State struct {
+	// 规则 ID 到规则状态
+	Rules map[string]*RuleState `json:"rules"`
+	// 最近的提醒，按时间顺序
+	Events []Event `json:"events,omitempty"`
+}
+
+// Rule 获取规则状态，不存在时创建
+func (s *State) Rule(id string) *RuleState {
+	if s.Rules == nil {
+		s.Rules = make(map[string]*RuleState)
+	}
+	state, ok := s.Rules[id]
+	if !ok {
+		state = &RuleState{}
+		s.Rules[id] = state
+	}
+	return state
+}
+
+// AddEvents 记录提醒，只保留最近 MaxEvents 条
+func (s *State) AddEvents(events ...Event) {
+	s.Events = append(s.Events, events...)
+	if len(s.Events) > MaxEvents {
+		s.Events = slices.Clone(s.Events[len(s.Events)-MaxEvents:])
+	}
+}
+
+// RuleState 规则状态
+type RuleState struct {
+	// 最近一次检查时间
+	CheckedAt time.Time `json:"checkedAt,omitzero"`
+	// 最近一次检查的错误
+	Error string `json:"error,omitempty"`
+	// 证券代码（新闻规则为关键词）到条件状态
+	Targets map[string]*TargetState `json:"targets,omitempty"`
+}
+
+// Target 获取证券的条件状态，不存在时创建
+func (s *RuleState) Target(key string) *TargetState {
+	if s.Targets == nil {
+		s.Targets = make(map[string]*TargetState)
+	}
+	state, ok := s.Targets[key]
+	if !ok {
+		state = &TargetState{}
+		s.Targets[key] = state
+	}
+	return state
+}
+
+// TargetState 规则对一只证券或一个关键词的条件状态
+type TargetState struct {
+	// 条件当前是否满足，满足后需先不满足才会再次提醒
+	Active bool `json:"active,omitempty"`
+	// 最近一次检查的值
+	Value string `json:"value,omitempty"`
+	// 最近一次提醒时间
+	FiredAt time.Time `json:"firedAt,omitzero"`
+	// 新闻规则已见过的结果
+	Seen []string `json:"seen,omitempty"`
+	// 新闻规则首次检查的时间，首次检查只记录已有结果，不提醒
+	SeededAt time.Time `json:"seededAt,omitzero"`
+}
+
+// markSeen 记录已见过的新闻结果，只保留最近 maxSeen 条
+func (s *TargetState) markSeen(keys ...string) {
+	s.Seen = append(s.Seen, keys...)
+	if len(s.Seen) > maxSeen {
+		s.Seen = slices.Clone(s.Seen[len(s.Seen)-maxSeen:])
+	}
+}
+
+// Event 一次提醒
+type Event struct {
+	Time   time.Time `json:"time"`
+	RuleID string    `json:"ruleID"`
+	// 触发的证券代码，新闻规则为空
+	Symbol string `json:"symbol,omitempty"`
+	// 提醒内容
+	Message string `json:"message"`
+	// 推送目标
+	Delivery channels.Target `json:"delivery,omitzero"`
+	// 总结或推送错误
+	Error string `json:"error,omitempty"`
+}
+
+// NewStore 创建提醒存储
+//
+// 自选股列表和提醒规则分别保存在 dir 目录下的 watchlists.json 和 rules.json 文件中，
+// 由命令行和 Agent 工具修改；提醒状态保存在 state.json 文件中，由轮询器修改
+func NewStore(dir string) *Store {
+	return &Store{dir: dir, lease: lease.NewFile(filepath.Join(dir, LeaseFileName))}
+}
+
+// Store 提醒存储
+type Store struct {
+	lock  sync.Mutex
+	dir   string
+	lease *lease.File
+}
+
+// Dir 数据目录
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Lease 轮询租约，同一数据目录下只有持有租约的进程检查提醒规则
+func (s *Store) Lease() *lease.File {
+	return s.lease
+}
+
+// Watchlists 加载自选股列表，文件不存在时返回空列表
+func (s *Store) Watchlists() (*WatchlistList, error) {
+	return load[WatchlistList](s, WatchlistsFileName)
+}
+
+// UpdateWatchlists 加载自选股列表，调用 fn 修改后保存， fn 返回错误时不保存
+func (s *Store) UpdateWatchlists(fn func(list *WatchlistList) error) error {
+	return update(s, WatchlistsFileName, fn)
+}
+
+// Rules 加载提醒规则，文件不存在时返回空列表
+func (s *Store) Rules() (*RuleList, error) {
+	return load[RuleList](s, RulesFileName)
+}
+
+// UpdateRules 加载提醒规则，调用 fn 修改后保存， fn 返回错误时不保存
+func (s *Store) UpdateRules(fn func(list *RuleList) error) error {
+	return update(s, RulesFileName, fn)
+}
+
+// State 加载提醒状态，文件不存在时返回空状态
+func (s *Store) State() (*State, error) {
+	return load[State](s, StateFileName)
+}
+
+// UpdateState 加载提醒状态，调用 fn 修改后保存， fn 返回错误时不保存
+func (s *Store) UpdateState(fn func(state *State) error) error {
+	return update(s, StateFileName, fn)
+}
+
+// load 加载数据目录下的 JSON 文件，文件不存在时返回零值
+func load[T any](s *Store, name string) (*T, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	v := new(T)
+	return v, loadJSON(filepath.Join(s.dir, name), v)
+}
+
+// update 加载数据目录下的 JSON 文件，调用 fn 修改后保存， fn 返回错误时不保存
+func update[T any](s *Store, name string, fn func(v *T) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	path := filepath.Join(s.dir, name)
+	v := new(T)
+	if err := loadJSON(path, v); err != nil {
+		return err
+	}
+	if err := fn(v); err != nil {
+		return err
+	}
+	return saveJSON(path, v)
+}
+
+// loadJSON 从 JSON 文件加载数据，文件不存在时不修改 v
+func loadJSON(path string, v any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("read file %q error: %w", path, err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("unmarshal file %q error: %w", path, err)
+	}
+	return nil
+}
+
+// saveJSON 保存数据到 JSON 文件，先写入临时文件再重命名，避免写入中断导致文件损坏
+func saveJSON(path string, v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal to json error: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create directory error: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("write file %q error: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename %q to %q error: %w", tmp, path, err)
+	}
+	return nil
+}
@@ -120,6 +120,11 @@ func (chat *Chat) RequestPermission(
 
 // SessionUpdate 更新会话
 func (chat *Chat) SessionUpdate(ctx context.Context, params acp.SessionNotification) error {
+	// 定时任务和提醒总结会话的更新不显示
+	if chat.runner.HandleUpdate(params) {
+		return nil
+	}
+
 	if params.Update.AvailableCommandsUpdate != nil {
 		commands := make([]SelectorOption, len(params.Update.AvailableCommandsUpdate.AvailableCommands))
 		for i, command := range params.Update.AvailableCommandsUpdate.AvailableCommands {
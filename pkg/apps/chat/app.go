@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sync"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -13,10 +14,12 @@ import (
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 
 	"github.com/yhlooo/nfa/pkg/acputil"
+	"github.com/yhlooo/nfa/pkg/alerts"
 	"github.com/yhlooo/nfa/pkg/channels"
 	"github.com/yhlooo/nfa/pkg/configs"
 	"github.com/yhlooo/nfa/pkg/history"
 	i18nutil "github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/schedule"
 	"github.com/yhlooo/nfa/pkg/skills"
 	"github.com/yhlooo/nfa/pkg/tokentracker"
 )
@@ -30,13 +33,21 @@ type Options struct {
 	AutoExitAfterResponse bool
 	ResumeSessionID       string
 	Channels              []channels.Channel
+	// 执行定时任务和总结提醒的会话执行器，其会话的更新不显示
+	Runner    *acputil.SessionRunner
+	Scheduler *schedule.Scheduler
+	Alerts    *alerts.Poller
 }
 
 // NewChat 创建对话应用
 func NewChat(opts Options) *Chat {
 	ui := &Chat{
 		channels:              opts.Channels,
+		runner:                opts.Runner,
+		scheduler:             opts.Scheduler,
+		alerts:                opts.Alerts,
 		modelUsageStyle:       lipgloss.NewStyle().Faint(true).Align(lipgloss.Right).PaddingRight(2),
+		budgetWarningStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Align(lipgloss.Right).PaddingRight(2),
 		initialPrompt:         opts.InitialPrompt,
 		autoExitAfterResponse: opts.AutoExitAfterResponse,
 		resumeSessionID:       opts.ResumeSessionID,
@@ -64,10 +75,14 @@ type Chat struct {
 
 	acputil.NopFS
 	acputil.NopTerminal
-	modelUsageStyle lipgloss.Style
+	modelUsageStyle    lipgloss.Style
+	budgetWarningStyle lipgloss.Style
 
-	agent    ACPAgent
-	channels []channels.Channel
+	agent     ACPAgent
+	channels  []channels.Channel
+	runner    *acputil.SessionRunner
+	scheduler *schedule.Scheduler
+	alerts    *alerts.Poller
 
 	cwd                   string
 	initialPrompt         string
@@ -77,6 +92,7 @@ type Chat struct {
 	sessionID       acp.SessionId
 	curPrimaryModel string
 	modelUsage      tokentracker.Summary
+	budgetWarnings  []tokentracker.BudgetStatus
 	skills          []skills.SkillMeta
 	history         *history.History
 	historyPath     string
@@ -124,6 +140,31 @@ func (chat *Chat) Run(ctx context.Context) error {
 		go chat.handleChannel(ctx, i+1, ch)
 	}
 
+	// 执行定时任务和检查提醒，退出时等待释放租约
+	var background []func(ctx context.Context)
+	if chat.scheduler != nil {
+		background = append(background, chat.scheduler.Run)
+	}
+	if chat.alerts != nil {
+		chat.alerts.SetOnAlert(func(event alerts.Event) { p.Send(event) })
+		background = append(background, chat.alerts.Run)
+	}
+	if len(background) > 0 {
+		backgroundCtx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		for _, run := range background {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run(backgroundCtx)
+			}()
+		}
+		defer func() {
+			cancel()
+			wg.Wait()
+		}()
+	}
+
 	_, err = p.Run()
 
 	// 打印会话恢复提示
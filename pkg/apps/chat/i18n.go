@@ -28,6 +28,7 @@ var (
 	}
 
 	MsgTokenUsage    = &i18n.Message{ID: "ui.chat.TokenUsage", Other: "Token Usage:"}
+	MsgBudgetWarning = &i18n.Message{ID: "ui.chat.BudgetWarning", Other: "Budget:"}
 	MsgSkills        = &i18n.Message{ID: "ui.chat.Skills", Other: "Skills"}
 	MsgBuiltinSkills = &i18n.Message{ID: "ui.chat.BuiltinSkills", Other: "Builtin skills"}
 	MsgLocalSkills   = &i18n.Message{ID: "ui.chat.LocalSkills", Other: "Local skills"}
@@ -9,8 +9,10 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/coder/acp-go-sdk"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/shopspring/decimal"
 
 	"github.com/yhlooo/nfa/pkg/agents"
+	"github.com/yhlooo/nfa/pkg/alerts"
 	"github.com/yhlooo/nfa/pkg/history"
 	i18nutil "github.com/yhlooo/nfa/pkg/i18n"
 	"github.com/yhlooo/nfa/pkg/otter"
@@ -57,6 +59,7 @@ func (chat *Chat) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		chat.width = typedMsg.Width
 		chat.modelUsageStyle = chat.modelUsageStyle.Width(typedMsg.Width)
+		chat.budgetWarningStyle = chat.budgetWarningStyle.Width(typedMsg.Width)
 		chat.logger.Info(fmt.Sprintf("resize message: width: %d, height: %d", typedMsg.Width, typedMsg.Height))
 
 	case tea.KeyMsg:
@@ -100,6 +103,9 @@ func (chat *Chat) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if usage, ok := agents.GetMetaCurrentModelUsageValue(typedMsg.Meta); ok {
 			chat.modelUsage = usage
 		}
+		if warnings, ok := agents.GetMetaBudgetWarningsValue(typedMsg.Meta); ok {
+			chat.budgetWarnings = warnings
+		}
 		cmds = append(cmds, chat.vp.Flush())
 		if chat.autoExitAfterResponse {
 			cmds = append(cmds, tea.Quit)
@@ -112,6 +118,9 @@ func (chat *Chat) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case acp.PromptRequest:
 		cmds = append(cmds, chat.vp.Flush())
 
+	case alerts.Event:
+		cmds = append(cmds, tea.Println("\033[33m"+typedMsg.Message+"\033[0m"))
+
 	case QuitError:
 		logger.Error(typedMsg.Error, "error")
 		cmds = append(cmds, tea.Quit)
@@ -163,13 +172,18 @@ func (chat *Chat) View() string {
 	if out := chat.modelUsage.TotalUsage.OutputTokens; out != 0 {
 		modelUsageView += fmt.Sprintf(" | ↓ %s", intWithSeparator(out))
 	}
-	if cost := chat.modelUsage.TotalCost; !cost.IsZero() {
-		modelUsageView += fmt.Sprintf(" | 💰 %s", cost.StringFixed(2))
+	if cost := chat.modelUsage.CostText(); cost != "" {
+		modelUsageView += " | 💰 " + cost
 	}
 	if modelUsageView != "" {
 		modelUsageView = i18nutil.TContext(chat.ctx, MsgTokenUsage) + " " + strings.TrimPrefix(modelUsageView, " | ")
 	}
 
+	statusView := chat.modelUsageStyle.Render(modelUsageView)
+	if budgetView := chat.budgetWarningView(); budgetView != "" {
+		statusView = chat.budgetWarningStyle.Render(budgetView) + "\n" + statusView
+	}
+
 	return fmt.Sprintf(
 		`%s
 %s%s
@@ -177,10 +191,30 @@ func (chat *Chat) View() string {
 `,
 		vpView,
 		bottomView,
-		chat.modelUsageStyle.Render(modelUsageView),
+		statusView,
 	)
 }
 
+// budgetWarningView 预算预警视图
+func (chat *Chat) budgetWarningView() string {
+	if len(chat.budgetWarnings) == 0 {
+		return ""
+	}
+	items := make([]string, 0, len(chat.budgetWarnings))
+	for _, w := range chat.budgetWarnings {
+		percent := 0
+		if !w.Limit.IsZero() {
+			percent = int(w.Spent.Div(w.Limit).Mul(decimal.NewFromInt(100)).IntPart())
+		}
+		items = append(items, fmt.Sprintf(
+			"%s %d%% (%s / %s %s)",
+			agents.BudgetScopeName(chat.ctx, w.Scope), percent,
+			w.Spent.StringFixed(2), w.Limit.StringFixed(2), w.Currency,
+		))
+	}
+	return "⚠️ " + i18nutil.TContext(chat.ctx, MsgBudgetWarning) + " " + strings.Join(items, " | ")
+}
+
 // printHello 输出欢迎信息
 func (chat *Chat) printHello() tea.Cmd {
 	return func() tea.Msg {
@@ -14,6 +14,7 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 
+	"github.com/yhlooo/nfa/pkg/charts"
 	i18nutil "github.com/yhlooo/nfa/pkg/i18n"
 )
 
@@ -38,6 +39,7 @@ type MessageViewport struct {
 	messages        MessagesList
 	viewStyle       lipgloss.Style
 	mdRenderer      *glamour.TermRenderer
+	width           int
 	logger          logr.Logger
 }
 
@@ -51,6 +53,7 @@ func (vp MessageViewport) Update(msg tea.Msg) (MessageViewport, tea.Cmd) {
 	switch typedMsg := msg.(type) {
 	case tea.WindowSizeMsg:
 		vp.viewStyle = vp.viewStyle.Width(typedMsg.Width)
+		vp.width = typedMsg.Width
 
 	case acp.PromptRequest:
 		// 对话请求
@@ -137,7 +140,7 @@ func (vp MessageViewport) viewMessages(messages MessagesList, renderMD bool) str
 			}
 		case MessageTypeAgent:
 			if renderMD {
-				ret.WriteString(vp.renderMarkdown(msg.Text) + "\n")
+				ret.WriteString(vp.renderAgentMessage(msg.Text) + "\n")
 			} else {
 				ret.WriteString(msg.Text + "\n")
 			}
@@ -222,6 +225,32 @@ func withIndent(content string, indent int) string {
 	return strings.ReplaceAll(content, "\n", "\n"+indentStr)
 }
 
+// renderAgentMessage 渲染 Agent 回复，其中的图表块渲染为字符图表，其余部分按 Markdown 渲染
+func (vp MessageViewport) renderAgentMessage(text string) string {
+	segments := charts.Split(text)
+	if len(segments) == 1 && segments[0].Chart == nil {
+		return vp.renderMarkdown(text)
+	}
+
+	// 左边框和缩进占用的宽度
+	width := charts.DefaultTerminalWidth
+	if vp.width > 0 {
+		width = vp.width - 4
+	}
+	var ret []string
+	for _, seg := range segments {
+		if seg.Chart != nil {
+			ret = append(ret, withIndent("  "+charts.Terminal(*seg.Chart, width, 0), 2))
+			continue
+		}
+		if strings.TrimSpace(seg.Text) == "" {
+			continue
+		}
+		ret = append(ret, vp.renderMarkdown(seg.Text))
+	}
+	return strings.Join(ret, "\n\n")
+}
+
 // renderMarkdown 使用 glamour 渲染 Markdown 文本
 // 如果渲染器未初始化或渲染失败，返回原始文本
 func (vp MessageViewport) renderMarkdown(text string) string {
@@ -0,0 +1,218 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/coder/acp-go-sdk"
+	"github.com/go-logr/logr"
+
+	"github.com/yhlooo/nfa/pkg/acputil"
+	"github.com/yhlooo/nfa/pkg/agents"
+	"github.com/yhlooo/nfa/pkg/alerts"
+	"github.com/yhlooo/nfa/pkg/channels"
+	"github.com/yhlooo/nfa/pkg/schedule"
+	"github.com/yhlooo/nfa/pkg/version"
+)
+
+const (
+	channelIDMetaKey = "channelID"
+)
+
+// Options 后台服务运行选项
+type Options struct {
+	Agent    acp.Agent
+	Channels []channels.Channel
+	// 执行定时任务和总结提醒的会话执行器，其会话的更新不发送到通道
+	Runner    *acputil.SessionRunner
+	Scheduler *schedule.Scheduler
+	Alerts    *alerts.Poller
+}
+
+// NewDaemon 创建后台服务
+func NewDaemon(opts Options) *Daemon {
+	return &Daemon{
+		agent:     opts.Agent,
+		channels:  opts.Channels,
+		runner:    opts.Runner,
+		scheduler: opts.Scheduler,
+		alerts:    opts.Alerts,
+		sessions:  make(map[string]acp.SessionId),
+	}
+}
+
+// Daemon 无界面的后台服务，处理消息通道的用户消息，执行定时任务并检查提醒
+//
+// 与终端界面不同，每个通道用户使用独立的会话
+type Daemon struct {
+	acputil.NopFS
+	acputil.NopTerminal
+
+	logger    logr.Logger
+	agent     acp.Agent
+	channels  []channels.Channel
+	runner    *acputil.SessionRunner
+	scheduler *schedule.Scheduler
+	alerts    *alerts.Poller
+	cwd       string
+
+	lock     sync.Mutex
+	sessions map[string]acp.SessionId
+}
+
+var _ acp.Client = (*Daemon)(nil)
+
+// Run 运行，直到 ctx 结束
+func (d *Daemon) Run(ctx context.Context) error {
+	if err := d.init(ctx); err != nil {
+		return err
+	}
+	d.logger.Info("daemon started", "channels", len(d.channels),
+		"scheduler", d.scheduler != nil, "alerts", d.alerts != nil)
+
+	var wg sync.WaitGroup
+	for i, ch := range d.channels {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.handleChannel(ctx, i+1, ch)
+		}()
+	}
+	if d.scheduler != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.scheduler.Run(ctx)
+		}()
+	}
+	if d.alerts != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.alerts.Run(ctx)
+		}()
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+	d.logger.Info("daemon stopped")
+	return nil
+}
+
+// RunJob 立即执行指定 ID 或名称的定时任务，执行期间同时处理消息通道的用户消息
+func (d *Daemon) RunJob(ctx context.Context, idOrName string) (*schedule.Run, error) {
+	if d.scheduler == nil {
+		return nil, fmt.Errorf("scheduler is not enabled")
+	}
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	for i, ch := range d.channels {
+		go d.handleChannel(ctx, i+1, ch)
+	}
+	return d.scheduler.RunNow(ctx, idOrName)
+}
+
+// init 初始化 Agent
+func (d *Daemon) init(ctx context.Context) error {
+	d.logger = logr.FromContextOrDiscard(ctx)
+
+	_, err := d.agent.Initialize(ctx, acp.InitializeRequest{
+		ClientCapabilities: acp.ClientCapabilities{},
+		ClientInfo: &acp.Implementation{
+			Name:    "NFA",
+			Title:   acp.Ptr("NFA (Not Financial Advice)"),
+			Version: version.Version,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("initialize agent error: %w", err)
+	}
+
+	d.cwd, err = os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get current working directory error: %w", err)
+	}
+	return nil
+}
+
+// handleChannel 处理信道
+func (d *Daemon) handleChannel(ctx context.Context, id int, ch channels.Channel) {
+	for msg := range ch.Receive() {
+		meta := map[string]any{
+			channelIDMetaKey: id,
+		}
+		for k, v := range msg.Meta {
+			meta[k] = v
+		}
+
+		sessionID, err := d.session(ctx, id, meta)
+		if err != nil {
+			d.logger.Error(err, "new session error")
+			continue
+		}
+		if _, err := d.agent.Prompt(ctx, acp.PromptRequest{
+			SessionId: sessionID,
+			Meta:      meta,
+			Prompt:    msg.Prompt,
+		}); err != nil {
+			d.logger.Error(err, "prompt error", "sessionID", sessionID)
+		}
+		if err := ch.Send(ctx, meta, nil, true); err != nil {
+			d.logger.Error(err, "send notification to channel error")
+		}
+	}
+	if err := ch.Err(); err != nil {
+		d.logger.Error(err, "channel stopped")
+	}
+}
+
+// session 获取信道用户的会话，不存在时创建
+func (d *Daemon) session(ctx context.Context, channelID int, meta map[string]any) (acp.SessionId, error) {
+	key := fmt.Sprintf("%d/%s", channelID, agents.GetMetaStringValue(meta, agents.MetaKeyUserID))
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if sessionID, ok := d.sessions[key]; ok {
+		return sessionID, nil
+	}
+	resp, err := d.agent.NewSession(ctx, acp.NewSessionRequest{
+		Cwd:        d.cwd,
+		McpServers: []acp.McpServer{},
+	})
+	if err != nil {
+		return "", err
+	}
+	d.sessions[key] = resp.SessionId
+	return resp.SessionId, nil
+}
+
+// RequestPermission 请求授权
+func (d *Daemon) RequestPermission(
+	_ context.Context,
+	params acp.RequestPermissionRequest,
+) (acp.RequestPermissionResponse, error) {
+	if len(params.Options) == 0 {
+		return acp.RequestPermissionResponse{Outcome: acp.NewRequestPermissionOutcomeCancelled()}, nil
+	}
+	// 无人值守，总是选第一个
+	return acp.RequestPermissionResponse{
+		Outcome: acp.NewRequestPermissionOutcomeSelected(params.Options[0].OptionId),
+	}, nil
+}
+
+// SessionUpdate 更新会话
+func (d *Daemon) SessionUpdate(ctx context.Context, params acp.SessionNotification) error {
+	if d.runner.HandleUpdate(params) {
+		return nil
+	}
+	if channelID := agents.GetMetaIntValue(params.Meta, channelIDMetaKey); channelID > 0 &&
+		channelID <= len(d.channels) {
+		if err := d.channels[channelID-1].Send(ctx, params.Meta, &params, false); err != nil {
+			d.logger.Error(err, "send notification to channel error")
+		}
+	}
+	return nil
+}
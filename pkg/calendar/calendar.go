@@ -0,0 +1,300 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	// 内置时区数据，避免依赖系统时区数据库
+	_ "time/tzdata"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// 交易日历 ID ，交易所使用 ISO 10383 MIC 代码
+const (
+	NYSE   = tools.CalendarNYSE
+	HKEX   = tools.CalendarHKEX
+	SSE    = tools.CalendarSSE
+	SZSE   = tools.CalendarSZSE
+	BSE    = tools.CalendarBSE
+	Crypto = "CRYPTO"
+)
+
+// DefaultMarkets 默认关注的市场
+var DefaultMarkets = []string{NYSE, HKEX, SSE}
+
+// dateLayout 日期格式
+const dateLayout = time.DateOnly
+
+// Holiday 休市日或半日市
+type Holiday struct {
+	// 日期，格式 YYYY-MM-DD
+	Date string `json:"date"`
+	Name string `json:"name"`
+	// 是否为半日市（提前收市）
+	HalfDay bool `json:"halfDay,omitempty"`
+}
+
+// Session 交易时段
+type Session struct {
+	Open  time.Time `json:"open"`
+	Close time.Time `json:"close"`
+}
+
+// clock 一天中的时刻，自零点起的分钟数
+type clock int
+
+// hm 返回 h 时 m 分
+func hm(h, m int) clock {
+	return clock(h*60 + m)
+}
+
+// on 返回 date 当天该时刻
+func (c clock) on(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), int(c)/60, int(c)%60, 0, 0, date.Location())
+}
+
+// sessionTime 交易时段的开始和结束时刻
+type sessionTime struct {
+	open, close clock
+}
+
+// yearHolidays 一年的休市日
+type yearHolidays struct {
+	byDate map[string]Holiday
+	list   []Holiday
+	// 是否有该年的节假日数据
+	known bool
+}
+
+// Calendar 交易所交易日历
+type Calendar struct {
+	// 交易所 MIC 代码或 CRYPTO
+	ID string
+	// 名称
+	Name string
+	// 时区
+	Location *time.Location
+
+	// 常规交易时段
+	sessions []sessionTime
+	// 半日市交易时段
+	halfDaySessions []sessionTime
+	// 全天候交易，没有休市日
+	alwaysOpen bool
+	// 返回指定年份的休市日和是否有该年数据
+	holidays func(year int) ([]Holiday, bool)
+
+	cache sync.Map
+}
+
+// newCalendar 创建交易日历
+func newCalendar(
+	id, name, location string,
+	sessions, halfDaySessions []sessionTime,
+	holidays func(year int) ([]Holiday, bool),
+) *Calendar {
+	loc, err := time.LoadLocation(location)
+	if err != nil {
+		panic(fmt.Sprintf("load location %q error: %v", location, err))
+	}
+	return &Calendar{
+		ID:              id,
+		Name:            name,
+		Location:        loc,
+		sessions:        sessions,
+		halfDaySessions: halfDaySessions,
+		holidays:        holidays,
+	}
+}
+
+// AlwaysOpen 是否全天候交易
+func (c *Calendar) AlwaysOpen() bool {
+	return c.alwaysOpen
+}
+
+// Date 返回 t 在交易所时区的日期（当天零点）
+func (c *Calendar) Date(t time.Time) time.Time {
+	t = t.In(c.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.Location)
+}
+
+// Known 是否有指定年份的节假日数据，没有时只排除周末
+func (c *Calendar) Known(year int) bool {
+	return c.yearHolidays(year).known
+}
+
+// Holidays 返回指定年份的休市日和半日市，按日期排序
+func (c *Calendar) Holidays(year int) []Holiday {
+	return append([]Holiday(nil), c.yearHolidays(year).list...)
+}
+
+// Holiday 返回 t 当天的休市日或半日市信息
+func (c *Calendar) Holiday(t time.Time) (Holiday, bool) {
+	date := c.Date(t)
+	h, ok := c.yearHolidays(date.Year()).byDate[date.Format(dateLayout)]
+	return h, ok
+}
+
+// IsTradingDay t 当天（交易所时区）是否为交易日
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	if c.alwaysOpen {
+		return true
+	}
+	date := c.Date(t)
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return false
+	}
+	h, ok := c.Holiday(date)
+	return !ok || h.HalfDay
+}
+
+// Sessions 返回 t 当天（交易所时区）的交易时段，非交易日返回空
+func (c *Calendar) Sessions(t time.Time) []Session {
+	if c.alwaysOpen || !c.IsTradingDay(t) {
+		return nil
+	}
+	date := c.Date(t)
+	times := c.sessions
+	if h, ok := c.Holiday(date); ok && h.HalfDay {
+		times = c.halfDaySessions
+	}
+	ret := make([]Session, len(times))
+	for i, s := range times {
+		ret[i] = Session{Open: s.open.on(date), Close: s.close.on(date)}
+	}
+	return ret
+}
+
+// NextTradingDay 返回 t 之后（不含当天）的第一个交易日
+func (c *Calendar) NextTradingDay(t time.Time) time.Time {
+	date := c.Date(t)
+	for {
+		date = date.AddDate(0, 0, 1)
+		if c.IsTradingDay(date) {
+			return date
+		}
+	}
+}
+
+// PrevTradingDay 返回 t 之前（不含当天）的最后一个交易日
+func (c *Calendar) PrevTradingDay(t time.Time) time.Time {
+	return c.TradingDaysBefore(t, 1)
+}
+
+// TradingDaysBefore 返回 t 之前（不含当天）的第 n 个交易日， n 小于 1 时视为 1
+func (c *Calendar) TradingDaysBefore(t time.Time, n int) time.Time {
+	date := c.Date(t)
+	for n = max(n, 1); n > 0; {
+		date = date.AddDate(0, 0, -1)
+		if c.IsTradingDay(date) {
+			n--
+		}
+	}
+	return date
+}
+
+// TradingDaysBetween 返回 (from, to] 之间的交易日数
+func (c *Calendar) TradingDaysBetween(from, to time.Time) int {
+	n := 0
+	for date, end := c.Date(from), c.Date(to); date.Before(end); {
+		date = date.AddDate(0, 0, 1)
+		if c.IsTradingDay(date) {
+			n++
+		}
+	}
+	return n
+}
+
+// yearHolidays 返回指定年份的休市日，带缓存
+func (c *Calendar) yearHolidays(year int) *yearHolidays {
+	if v, ok := c.cache.Load(year); ok {
+		return v.(*yearHolidays)
+	}
+	ret := &yearHolidays{byDate: map[string]Holiday{}, known: c.alwaysOpen}
+	if c.holidays != nil {
+		ret.list, ret.known = c.holidays(year)
+		sort.Slice(ret.list, func(i, j int) bool { return ret.list[i].Date < ret.list[j].Date })
+		for _, h := range ret.list {
+			ret.byDate[h.Date] = h
+		}
+	}
+	v, _ := c.cache.LoadOrStore(year, ret)
+	return v.(*yearHolidays)
+}
+
+// 所有交易日历
+var (
+	calendars = []*Calendar{
+		newCalendar(NYSE, "美股（纽交所、纳斯达克）", "America/New_York",
+			[]sessionTime{{hm(9, 30), hm(16, 0)}},
+			[]sessionTime{{hm(9, 30), hm(13, 0)}},
+			usHolidays,
+		),
+		newCalendar(HKEX, "港股（港交所）", "Asia/Hong_Kong",
+			[]sessionTime{{hm(9, 30), hm(12, 0)}, {hm(13, 0), hm(16, 0)}},
+			[]sessionTime{{hm(9, 30), hm(12, 0)}},
+			tableHolidays(hkHolidays),
+		),
+		newCalendar(SSE, "A 股（上交所）", "Asia/Shanghai",
+			[]sessionTime{{hm(9, 30), hm(11, 30)}, {hm(13, 0), hm(15, 0)}},
+			nil,
+			tableHolidays(cnHolidays),
+		),
+		newCalendar(SZSE, "A 股（深交所）", "Asia/Shanghai",
+			[]sessionTime{{hm(9, 30), hm(11, 30)}, {hm(13, 0), hm(15, 0)}},
+			nil,
+			tableHolidays(cnHolidays),
+		),
+		newCalendar(BSE, "A 股（北交所）", "Asia/Shanghai",
+			[]sessionTime{{hm(9, 30), hm(11, 30)}, {hm(13, 0), hm(15, 0)}},
+			nil,
+			tableHolidays(cnHolidays),
+		),
+		func() *Calendar {
+			c := newCalendar(Crypto, "加密货币（主要交易所全天候交易）", "UTC", nil, nil, nil)
+			c.alwaysOpen = true
+			return c
+		}(),
+	}
+
+	// aliases 交易日历别名
+	aliases = map[string]string{
+		"XNAS": NYSE, "NYSE": NYSE, "NASDAQ": NYSE, "AMEX": NYSE, "ARCA": NYSE, "US": NYSE,
+		"HKEX": HKEX, "SEHK": HKEX, "HK": HKEX,
+		"SSE": SSE, "SH": SSE, "SS": SSE, "CN": SSE,
+		"SZSE": SZSE, "SZ": SZSE,
+		"BSE": BSE, "BJ": BSE,
+		"BINANCE": Crypto, "COINBASE": Crypto, "OKX": Crypto, "BYBIT": Crypto, "KRAKEN": Crypto,
+		"BTC": Crypto, "ETH": Crypto,
+	}
+)
+
+// All 返回所有交易日历
+func All() []*Calendar {
+	return append([]*Calendar(nil), calendars...)
+}
+
+// Get 根据 ID 、别名（如 NASDAQ 、 HK 、 CN ）或证券代码（如 0700.HK ）获取交易日历
+func Get(name string) (*Calendar, error) {
+	id := strings.ToUpper(strings.TrimSpace(name))
+	if alias, ok := aliases[id]; ok {
+		id = alias
+	}
+	for _, c := range calendars {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	if symbol, ok := tools.NormalizeSymbol(name); ok {
+		return Get(tools.CalendarOfSymbol(symbol))
+	}
+	ids := make([]string, len(calendars))
+	for i, c := range calendars {
+		ids[i] = c.ID
+	}
+	return nil, fmt.Errorf("unknown market or trading calendar %q (available: %s)", name, strings.Join(ids, ", "))
+}
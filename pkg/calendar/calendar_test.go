@@ -0,0 +1,145 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mustGet 获取交易日历
+func mustGet(t *testing.T, name string) *Calendar {
+	c, err := Get(name)
+	require.NoError(t, err)
+	return c
+}
+
+// at 返回 c 交易所当地时间
+func at(c *Calendar, value string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", value, c.Location)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// TestGet 测试获取交易日历
+func TestGet(t *testing.T) {
+	cases := map[string]string{
+		"XNYS":     NYSE,
+		"nasdaq":   NYSE,
+		"hk":       HKEX,
+		"SSE":      SSE,
+		"sz":       SZSE,
+		"binance":  Crypto,
+		"0700.HK":  HKEX,
+		"600519":   SSE,
+		"000001":   SZSE,
+		"430047":   BSE,
+		"BRK.B":    NYSE,
+		" crypto ": Crypto,
+	}
+	for name, expect := range cases {
+		assert.Equal(t, expect, mustGet(t, name).ID, name)
+	}
+
+	_, err := Get("腾讯")
+	assert.Error(t, err)
+}
+
+// TestUSHolidays 测试美股休市日规则
+func TestUSHolidays(t *testing.T) {
+	c := mustGet(t, NYSE)
+
+	var dates []string
+	for _, h := range c.Holidays(2025) {
+		if !h.HalfDay {
+			dates = append(dates, h.Date)
+		}
+	}
+	assert.Equal(t, []string{
+		"2025-01-01", "2025-01-09", "2025-01-20", "2025-02-17", "2025-04-18", "2025-05-26",
+		"2025-06-19", "2025-07-04", "2025-09-01", "2025-11-27", "2025-12-25",
+	}, dates)
+
+	// 周六的独立日提前到周五
+	assert.False(t, c.IsTradingDay(at(c, "2026-07-03 10:00")))
+	// 周六的元旦不调休
+	assert.True(t, c.IsTradingDay(at(c, "2021-12-31 10:00")))
+	// 很远的年份也能按规则计算
+	assert.True(t, c.Known(2040))
+	assert.False(t, c.IsTradingDay(at(c, "2040-03-30 10:00")))
+
+	// 感恩节次日提前收市
+	sessions := c.Sessions(at(c, "2025-11-28 10:00"))
+	require.Len(t, sessions, 1)
+	assert.Equal(t, at(c, "2025-11-28 13:00"), sessions[0].Close)
+}
+
+// TestTradingDays 测试交易日计算
+func TestTradingDays(t *testing.T) {
+	cn := mustGet(t, SSE)
+	// 国庆节后第一个交易日
+	assert.Equal(t, "2025-10-09", cn.NextTradingDay(at(cn, "2025-09-30 16:00")).Format(dateLayout))
+	// 国庆节期间的上一个交易日
+	assert.Equal(t, "2025-09-30", cn.PrevTradingDay(at(cn, "2025-10-05 12:00")).Format(dateLayout))
+	assert.Equal(t, "2025-09-26", cn.TradingDaysBefore(at(cn, "2025-10-09 12:00"), 3).Format(dateLayout))
+	assert.Equal(t, 1, cn.TradingDaysBetween(at(cn, "2025-09-30 00:00"), at(cn, "2025-10-09 00:00")))
+	// 没有节假日数据的年份只排除周末
+	assert.False(t, cn.Known(2030))
+	assert.True(t, cn.IsTradingDay(at(cn, "2030-01-01 10:00")))
+
+	hk := mustGet(t, HKEX)
+	assert.False(t, hk.IsTradingDay(at(hk, "2026-02-17 10:00")))
+	// 农历新年除夕半日市
+	assert.True(t, hk.IsTradingDay(at(hk, "2026-02-16 10:00")))
+	assert.Len(t, hk.Sessions(at(hk, "2026-02-16 10:00")), 1)
+	assert.Len(t, hk.Sessions(at(hk, "2026-02-13 10:00")), 2)
+
+	crypto := mustGet(t, Crypto)
+	assert.True(t, crypto.IsTradingDay(at(crypto, "2026-10-18 10:00")))
+	assert.Equal(t, "2026-10-17", crypto.PrevTradingDay(at(crypto, "2026-10-18 10:00")).Format(dateLayout))
+}
+
+// TestStatus 测试交易状态
+func TestStatus(t *testing.T) {
+	hk := mustGet(t, HKEX)
+	cases := []struct {
+		now            string
+		phase          string
+		nextOpen       string
+		lastTradingDay string
+	}{
+		{now: "2026-10-16 09:00", phase: PhasePreOpen, nextOpen: "2026-10-16 09:30", lastTradingDay: "2026-10-15"},
+		{now: "2026-10-16 10:00", phase: PhaseOpen, nextOpen: "2026-10-16 13:00", lastTradingDay: "2026-10-15"},
+		{now: "2026-10-16 12:30", phase: PhaseBreak, nextOpen: "2026-10-16 13:00", lastTradingDay: "2026-10-15"},
+		{now: "2026-10-16 16:00", phase: PhaseClosed, nextOpen: "2026-10-20 09:30", lastTradingDay: "2026-10-16"},
+		{now: "2026-10-17 10:00", phase: PhaseWeekend, nextOpen: "2026-10-20 09:30", lastTradingDay: "2026-10-16"},
+		{now: "2026-10-19 10:00", phase: PhaseHoliday, nextOpen: "2026-10-20 09:30", lastTradingDay: "2026-10-16"},
+	}
+	for _, c := range cases {
+		s := hk.Status(at(hk, c.now))
+		assert.Equal(t, c.phase, s.Phase, c.now)
+		assert.Equal(t, at(hk, c.nextOpen), s.NextOpen, c.now)
+		assert.Equal(t, c.lastTradingDay, s.LastTradingDay, c.now)
+		assert.True(t, s.HolidaysKnown, c.now)
+	}
+
+	s := hk.Status(at(hk, "2026-10-16 10:00"))
+	assert.Equal(t, at(hk, "2026-10-16 12:00"), s.NextClose)
+
+	// 不同时区的同一时刻
+	us := mustGet(t, NYSE)
+	s = us.Status(at(hk, "2026-10-19 22:00"))
+	assert.Equal(t, PhaseOpen, s.Phase)
+	assert.Equal(t, "2026-10-19 10:00", s.LocalTime.Format("2006-01-02 15:04"))
+	assert.Equal(t, "2026-10-16", s.LastTradingDay)
+
+	s = hk.Status(at(hk, "2026-10-19 10:00"))
+	assert.Equal(t,
+		"港股（港交所）：当地时间 2026-10-19 10:00 周一（Asia/Hong_Kong），重阳节翌日休市，"+
+			"下次开盘 2026-10-20 09:30 周二；最近已收盘交易日 2026-10-16",
+		s.Describe(),
+	)
+}
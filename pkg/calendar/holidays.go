@@ -0,0 +1,234 @@
+package calendar
+
+import (
+	"strconv"
+	"time"
+)
+
+// day 返回 year 年 month 月 d 日
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// nthWeekday 返回 year 年 month 月的第 n 个 weekday ， n 为 -1 时返回最后一个
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	if n < 0 {
+		last := day(year, month+1, 0)
+		return last.AddDate(0, 0, -((int(last.Weekday()) - int(weekday) + 7) % 7))
+	}
+	first := day(year, month, 1)
+	return first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7+(n-1)*7)
+}
+
+// easter 返回 year 年复活节（公历）日期
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	return day(year, time.Month(month), (h+l-7*m+114)%31+1)
+}
+
+// usObserved 返回美国联邦假日的调休日期：周六提前到周五，周日顺延到周一
+func usObserved(t time.Time) time.Time {
+	switch t.Weekday() {
+	case time.Saturday:
+		return t.AddDate(0, 0, -1)
+	case time.Sunday:
+		return t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// usSpecialClosures 美股临时休市
+var usSpecialClosures = []Holiday{
+	{Date: "2018-12-05", Name: "老布什国葬日"},
+	{Date: "2025-01-09", Name: "卡特国葬日"},
+}
+
+// usHolidays 按 NYSE 规则计算美股休市日和提前收市日，所有年份均可计算
+func usHolidays(year int) ([]Holiday, bool) {
+	var ret []Holiday
+	add := func(t time.Time, name string, halfDay bool) {
+		if t.Year() == year {
+			ret = append(ret, Holiday{Date: t.Format(dateLayout), Name: name, HalfDay: halfDay})
+		}
+	}
+
+	// 元旦落在周六时不提前到上一年 12 月 31 日
+	if newYear := day(year, time.January, 1); newYear.Weekday() != time.Saturday {
+		add(usObserved(newYear), "元旦", false)
+	}
+	add(nthWeekday(year, time.January, time.Monday, 3), "马丁·路德·金纪念日", false)
+	add(nthWeekday(year, time.February, time.Monday, 3), "总统日", false)
+	add(easter(year).AddDate(0, 0, -2), "耶稣受难日", false)
+	add(nthWeekday(year, time.May, time.Monday, -1), "阵亡将士纪念日", false)
+	if year >= 2022 {
+		add(usObserved(day(year, time.June, 19)), "六月节", false)
+	}
+	add(usObserved(day(year, time.July, 4)), "独立日", false)
+	add(nthWeekday(year, time.September, time.Monday, 1), "劳动节", false)
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	add(thanksgiving, "感恩节", false)
+	add(usObserved(day(year, time.December, 25)), "圣诞节", false)
+
+	// 提前收市（ 13:00 ）
+	if jul3 := day(year, time.July, 3); jul3.Weekday() >= time.Monday && jul3.Weekday() <= time.Thursday {
+		add(jul3, "独立日前夕（提前收市）", true)
+	}
+	add(thanksgiving.AddDate(0, 0, 1), "感恩节次日（提前收市）", true)
+	if dec24 := day(year, time.December, 24); dec24.Weekday() >= time.Monday && dec24.Weekday() <= time.Thursday {
+		add(dec24, "平安夜（提前收市）", true)
+	}
+
+	for _, h := range usSpecialClosures {
+		if h.Date[:4] == strconv.Itoa(year) {
+			ret = append(ret, h)
+		}
+	}
+	return ret, true
+}
+
+// tableHolidays 返回根据节假日表查询休市日的函数，表中没有的年份只排除周末
+func tableHolidays(table map[int][]Holiday) func(year int) ([]Holiday, bool) {
+	return func(year int) ([]Holiday, bool) {
+		holidays, ok := table[year]
+		return append([]Holiday(nil), holidays...), ok
+	}
+}
+
+// closed 返回休市日
+func closed(name string, dates ...string) []Holiday {
+	ret := make([]Holiday, len(dates))
+	for i, d := range dates {
+		ret[i] = Holiday{Date: d, Name: name}
+	}
+	return ret
+}
+
+// halfDay 返回半日市
+func halfDay(name string, dates ...string) []Holiday {
+	ret := closed(name, dates...)
+	for i := range ret {
+		ret[i].HalfDay = true
+	}
+	return ret
+}
+
+// concat 拼接休市日
+func concat(groups ...[]Holiday) []Holiday {
+	var ret []Holiday
+	for _, g := range groups {
+		ret = append(ret, g...)
+	}
+	return ret
+}
+
+// hkHolidays 港交所工作日休市日和半日市（只有上午交易时段），来自港交所公布的交易日历
+var hkHolidays = map[int][]Holiday{
+	2024: concat(
+		closed("元旦", "2024-01-01"),
+		halfDay("农历新年除夕（半日市）", "2024-02-09"),
+		closed("农历新年", "2024-02-12", "2024-02-13"),
+		closed("耶稣受难节", "2024-03-29"),
+		closed("复活节星期一", "2024-04-01"),
+		closed("清明节", "2024-04-04"),
+		closed("劳动节", "2024-05-01"),
+		closed("佛诞", "2024-05-15"),
+		closed("端午节", "2024-06-10"),
+		closed("香港特别行政区成立纪念日", "2024-07-01"),
+		closed("中秋节翌日", "2024-09-18"),
+		closed("国庆日", "2024-10-01"),
+		closed("重阳节", "2024-10-11"),
+		halfDay("圣诞节前夕（半日市）", "2024-12-24"),
+		closed("圣诞节", "2024-12-25", "2024-12-26"),
+		halfDay("除夕（半日市）", "2024-12-31"),
+	),
+	2025: concat(
+		closed("元旦", "2025-01-01"),
+		halfDay("农历新年除夕（半日市）", "2025-01-28"),
+		closed("农历新年", "2025-01-29", "2025-01-30", "2025-01-31"),
+		closed("清明节", "2025-04-04"),
+		closed("耶稣受难节", "2025-04-18"),
+		closed("复活节星期一", "2025-04-21"),
+		closed("劳动节", "2025-05-01"),
+		closed("佛诞", "2025-05-05"),
+		closed("香港特别行政区成立纪念日", "2025-07-01"),
+		closed("国庆日", "2025-10-01"),
+		closed("中秋节翌日", "2025-10-07"),
+		closed("重阳节", "2025-10-29"),
+		halfDay("圣诞节前夕（半日市）", "2025-12-24"),
+		closed("圣诞节", "2025-12-25", "2025-12-26"),
+		halfDay("除夕（半日市）", "2025-12-31"),
+	),
+	2026: concat(
+		closed("元旦", "2026-01-01"),
+		halfDay("农历新年除夕（半日市）", "2026-02-16"),
+		closed("农历新年", "2026-02-17", "2026-02-18", "2026-02-19"),
+		closed("耶稣受难节", "2026-04-03"),
+		closed("复活节星期一", "2026-04-06"),
+		closed("清明节翌日", "2026-04-07"),
+		closed("劳动节", "2026-05-01"),
+		closed("佛诞翌日", "2026-05-25"),
+		closed("端午节", "2026-06-19"),
+		closed("香港特别行政区成立纪念日", "2026-07-01"),
+		closed("国庆日", "2026-10-01"),
+		closed("重阳节翌日", "2026-10-19"),
+		halfDay("圣诞节前夕（半日市）", "2026-12-24"),
+		closed("圣诞节", "2026-12-25"),
+		halfDay("除夕（半日市）", "2026-12-31"),
+	),
+	2027: concat(
+		closed("元旦", "2027-01-01"),
+		halfDay("农历新年除夕（半日市）", "2027-02-05"),
+		closed("农历新年", "2027-02-08", "2027-02-09"),
+		closed("耶稣受难节", "2027-03-26"),
+		closed("复活节星期一", "2027-03-29"),
+		closed("清明节", "2027-04-05"),
+		closed("佛诞", "2027-05-13"),
+		closed("端午节", "2027-06-09"),
+		closed("香港特别行政区成立纪念日", "2027-07-01"),
+		closed("中秋节翌日", "2027-09-16"),
+		closed("国庆日", "2027-10-01"),
+		closed("重阳节", "2027-10-08"),
+		halfDay("圣诞节前夕（半日市）", "2027-12-24"),
+		closed("圣诞节后第一个工作日", "2027-12-27"),
+		halfDay("除夕（半日市）", "2027-12-31"),
+	),
+}
+
+// cnHolidays 沪深北交易所工作日休市日，来自交易所公布的休市安排（调休的周末不交易）
+var cnHolidays = map[int][]Holiday{
+	2024: concat(
+		closed("元旦", "2024-01-01"),
+		closed("春节", "2024-02-09", "2024-02-12", "2024-02-13", "2024-02-14", "2024-02-15", "2024-02-16"),
+		closed("清明节", "2024-04-04", "2024-04-05"),
+		closed("劳动节", "2024-05-01", "2024-05-02", "2024-05-03"),
+		closed("端午节", "2024-06-10"),
+		closed("中秋节", "2024-09-16", "2024-09-17"),
+		closed("国庆节", "2024-10-01", "2024-10-02", "2024-10-03", "2024-10-04", "2024-10-07"),
+	),
+	2025: concat(
+		closed("元旦", "2025-01-01"),
+		closed("春节", "2025-01-28", "2025-01-29", "2025-01-30", "2025-01-31", "2025-02-03", "2025-02-04"),
+		closed("清明节", "2025-04-04"),
+		closed("劳动节", "2025-05-01", "2025-05-02", "2025-05-05"),
+		closed("端午节", "2025-06-02"),
+		closed("国庆节、中秋节", "2025-10-01", "2025-10-02", "2025-10-03", "2025-10-06", "2025-10-07", "2025-10-08"),
+	),
+	2026: concat(
+		closed("元旦", "2026-01-01", "2026-01-02"),
+		closed("春节", "2026-02-16", "2026-02-17", "2026-02-18", "2026-02-19", "2026-02-20", "2026-02-23"),
+		closed("清明节", "2026-04-06"),
+		closed("劳动节", "2026-05-01", "2026-05-04", "2026-05-05"),
+		closed("端午节", "2026-06-19"),
+		closed("中秋节", "2026-09-25"),
+		closed("国庆节", "2026-10-01", "2026-10-02", "2026-10-05", "2026-10-06", "2026-10-07"),
+	),
+}
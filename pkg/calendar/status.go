@@ -0,0 +1,163 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 市场交易状态
+const (
+	// PhaseOpen 交易中
+	PhaseOpen = "open"
+	// PhaseBreak 午间休市
+	PhaseBreak = "break"
+	// PhasePreOpen 交易日开盘前
+	PhasePreOpen = "preOpen"
+	// PhaseClosed 交易日已收盘
+	PhaseClosed = "closed"
+	// PhaseHoliday 节假日休市
+	PhaseHoliday = "holiday"
+	// PhaseWeekend 周末休市
+	PhaseWeekend = "weekend"
+)
+
+// maxSearchDays 查找下一交易时段时最多向后查找的天数
+const maxSearchDays = 366
+
+// Status 市场在某一时刻的交易状态
+type Status struct {
+	Calendar string `json:"calendar"`
+	Name     string `json:"name"`
+	TimeZone string `json:"timeZone"`
+	// 交易所当地时间
+	LocalTime time.Time `json:"localTime"`
+	// 交易状态，见 Phase* 常量
+	Phase string `json:"phase"`
+	// 当天的休市日或半日市信息
+	Holiday *Holiday `json:"holiday,omitempty"`
+	// 当天的交易时段
+	Sessions []Session `json:"sessions,omitempty"`
+	// 下一次开盘时间，交易中时为下一个交易时段的开盘时间
+	NextOpen time.Time `json:"nextOpen,omitzero"`
+	// 下一次收盘时间，交易中时为当前交易时段的收盘时间
+	NextClose time.Time `json:"nextClose,omitzero"`
+	// 最近一个已收盘的交易日，“最新收盘价”对应该交易日
+	LastTradingDay string `json:"lastTradingDay"`
+	// 下一个交易日（不含当天）
+	NextTradingDay string `json:"nextTradingDay"`
+	// 是否有当年的节假日数据，没有时只排除周末
+	HolidaysKnown bool `json:"holidaysKnown"`
+}
+
+// Status 返回市场在 now 时刻的交易状态
+func (c *Calendar) Status(now time.Time) Status {
+	now = now.In(c.Location)
+	date := c.Date(now)
+	s := Status{
+		Calendar:       c.ID,
+		Name:           c.Name,
+		TimeZone:       c.Location.String(),
+		LocalTime:      now,
+		HolidaysKnown:  c.Known(date.Year()),
+		NextTradingDay: c.NextTradingDay(date).Format(dateLayout),
+	}
+	if h, ok := c.Holiday(date); ok {
+		s.Holiday = &h
+	}
+
+	if c.alwaysOpen {
+		s.Phase = PhaseOpen
+		s.LastTradingDay = c.PrevTradingDay(date).Format(dateLayout)
+		return s
+	}
+
+	s.Sessions = c.Sessions(date)
+	switch {
+	case len(s.Sessions) == 0 && (date.Weekday() == time.Saturday || date.Weekday() == time.Sunday):
+		s.Phase = PhaseWeekend
+	case len(s.Sessions) == 0:
+		s.Phase = PhaseHoliday
+	case now.Before(s.Sessions[0].Open):
+		s.Phase = PhasePreOpen
+	case !now.Before(s.Sessions[len(s.Sessions)-1].Close):
+		s.Phase = PhaseClosed
+	default:
+		s.Phase = PhaseBreak
+		for _, session := range s.Sessions {
+			if !now.Before(session.Open) && now.Before(session.Close) {
+				s.Phase = PhaseOpen
+				s.NextClose = session.Close
+				break
+			}
+		}
+	}
+
+	if s.Phase == PhaseClosed {
+		s.LastTradingDay = date.Format(dateLayout)
+	} else {
+		s.LastTradingDay = c.PrevTradingDay(date).Format(dateLayout)
+	}
+
+	// 查找下一个交易时段
+	for d, i := date, 0; i < maxSearchDays && s.NextOpen.IsZero(); d, i = d.AddDate(0, 0, 1), i+1 {
+		for _, session := range c.Sessions(d) {
+			if session.Open.After(now) {
+				s.NextOpen = session.Open
+				if s.NextClose.IsZero() {
+					s.NextClose = session.Close
+				}
+				break
+			}
+		}
+	}
+
+	return s
+}
+
+// weekdayNames 星期的中文名
+var weekdayNames = [...]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+// Describe 返回交易状态的中文描述，用于系统提示
+func (s Status) Describe() string {
+	b := &strings.Builder{}
+	_, _ = fmt.Fprintf(b, "%s：当地时间 %s %s（%s）",
+		s.Name, s.LocalTime.Format("2006-01-02 15:04"), weekdayNames[s.LocalTime.Weekday()], s.TimeZone)
+
+	nextOpen := func() string {
+		if s.NextOpen.IsZero() {
+			return ""
+		}
+		if s.NextOpen.Format(dateLayout) == s.LocalTime.Format(dateLayout) {
+			return fmt.Sprintf("，%s 开盘", s.NextOpen.Format("15:04"))
+		}
+		return fmt.Sprintf("，下次开盘 %s %s", s.NextOpen.Format("2006-01-02 15:04"), weekdayNames[s.NextOpen.Weekday()])
+	}
+
+	switch s.Phase {
+	case PhaseOpen:
+		if s.NextClose.IsZero() {
+			b.WriteString("，全天候交易")
+		} else {
+			_, _ = fmt.Fprintf(b, "，交易中， %s 收盘", s.NextClose.Format("15:04"))
+		}
+	case PhaseBreak:
+		_, _ = fmt.Fprintf(b, "，午间休市%s", nextOpen())
+	case PhasePreOpen:
+		_, _ = fmt.Fprintf(b, "，未开盘%s", nextOpen())
+	case PhaseClosed:
+		_, _ = fmt.Fprintf(b, "，已收盘%s", nextOpen())
+	case PhaseHoliday:
+		_, _ = fmt.Fprintf(b, "，%s休市%s", s.Holiday.Name, nextOpen())
+	case PhaseWeekend:
+		_, _ = fmt.Fprintf(b, "，周末休市%s", nextOpen())
+	}
+	if s.Holiday != nil && s.Holiday.HalfDay {
+		_, _ = fmt.Fprintf(b, "，今日%s", s.Holiday.Name)
+	}
+	_, _ = fmt.Fprintf(b, "；最近已收盘交易日 %s", s.LastTradingDay)
+	if !s.HolidaysKnown {
+		b.WriteString("（缺少当年节假日数据，仅排除了周末）")
+	}
+	return b.String()
+}
@@ -2,10 +2,29 @@ package channels
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/coder/acp-go-sdk"
+	"github.com/go-logr/logr"
 )
 
+// 后台任务发起对话时使用的信道名，用于用量统计和预算，不对应实际的通道
+const (
+	// ScheduleChannelName 定时任务
+	ScheduleChannelName = "schedule"
+	// AlertsChannelName 提醒总结
+	AlertsChannelName = "alerts"
+)
+
+// IsBackground 信道名是否为后台任务使用的信道名
+func IsBackground(name string) bool {
+	return name == ScheduleChannelName || name == AlertsChannelName
+}
+
 // Channel 通道
 type Channel interface {
 	// Receive 获取接收用户消息的信道
@@ -21,3 +40,174 @@ type UserMessage struct {
 	Meta   map[string]any
 	Prompt []acp.ContentBlock
 }
+
+// Target 主动推送消息的目标
+type Target struct {
+	// 通道名，如 wecomAIBot 、 yuanbaoBot
+	Channel string `json:"channel,omitempty"`
+	// 用户 ID
+	UserID string `json:"userID,omitempty"`
+	// 群 ID
+	GroupID string `json:"groupID,omitempty"`
+}
+
+// String 返回目标的字符串表示
+func (t Target) String() string {
+	switch {
+	case t.GroupID != "":
+		return t.Channel + ":group:" + t.GroupID
+	case t.UserID != "":
+		return t.Channel + ":user:" + t.UserID
+	default:
+		return t.Channel
+	}
+}
+
+// Pusher 支持在没有用户消息时主动推送消息的通道
+type Pusher interface {
+	// Push 向目标推送一条 Markdown 消息，内容过长时可能拆分为多条发送
+	Push(ctx context.Context, target Target, content string) error
+}
+
+var (
+	// ErrPushNotSupported 通道不支持主动推送
+	ErrPushNotSupported = errors.New("channel does not support push")
+	// ErrInvalidTarget 推送目标无效
+	ErrInvalidTarget = errors.New("invalid push target")
+)
+
+// DeliveryError 通道拒绝投递消息时返回的错误
+type DeliveryError struct {
+	// 通道返回的错误码和错误信息
+	Code    int
+	Message string
+	// 是否为临时错误，如限流、服务端繁忙，临时错误会重试
+	Temporary bool
+}
+
+// Error 返回错误信息
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("delivery failed with code %d: %s", e.Code, e.Message)
+}
+
+// NotSentError 消息确定没有发出时返回的错误，如未连接、写入连接失败
+//
+// 这类错误重试不会导致消息重复投递
+type NotSentError struct {
+	Err error
+}
+
+// Error 返回错误信息
+func (e *NotSentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *NotSentError) Unwrap() error {
+	return e.Err
+}
+
+// deliverTimeout 单次投递的超时时间
+const deliverTimeout = 30 * time.Second
+
+// deliverRetryDelays 投递失败后每次重试前的等待时间
+var deliverRetryDelays = []time.Duration{
+	2 * time.Second, 10 * time.Second, 30 * time.Second,
+}
+
+// Deliver 调用 send 投递一条消息，失败时按退避间隔重试
+//
+// 只重试消息确定没有发出的 NotSentError 和临时的 DeliveryError ，
+// 请求已发出后等待响应超时等结果未知的错误直接返回，避免重复投递
+func Deliver(ctx context.Context, send func(ctx context.Context) error) error {
+	logger := logr.FromContextOrDiscard(ctx)
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, deliverTimeout)
+		err := send(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		if attempt >= len(deliverRetryDelays) {
+			return fmt.Errorf("%w (after %d attempts)", err, attempt+1)
+		}
+
+		delay := deliverRetryDelays[attempt]
+		logger.Info("deliver message error, will retry", "error", err.Error(), "attempt", attempt+1, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryable 投递错误是否可以重试
+func retryable(err error) bool {
+	var notSentErr *NotSentError
+	if errors.As(err, &notSentErr) {
+		return true
+	}
+	var deliveryErr *DeliveryError
+	if errors.As(err, &deliveryErr) {
+		return deliveryErr.Temporary
+	}
+	return false
+}
+
+// SplitMessage 将消息按行拆分为不超过 maxBytes 字节的多段，单行过长时在字符边界处截断
+func SplitMessage(content string, maxBytes int) []string {
+	if len(content) <= maxBytes {
+		return []string{content}
+	}
+
+	var (
+		parts []string
+		cur   strings.Builder
+	)
+	flush := func() {
+		if part := strings.TrimSpace(cur.String()); part != "" {
+			parts = append(parts, part)
+		}
+		cur.Reset()
+	}
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if cur.Len()+len(line) > maxBytes {
+			flush()
+		}
+		for len(line) > maxBytes {
+			i := maxBytes
+			for i > 0 && !utf8.RuneStart(line[i]) {
+				i--
+			}
+			cur.WriteString(line[:i])
+			flush()
+			line = line[i:]
+		}
+		cur.WriteString(line)
+	}
+	flush()
+	return parts
+}
+
+// Push 通过 chs 中名为 target.Channel 的通道向目标推送消息
+func Push(ctx context.Context, chs map[string]Channel, target Target, content string) error {
+	if target.UserID == "" && target.GroupID == "" {
+		return fmt.Errorf("push to %s error: %w: user or group is required", target, ErrInvalidTarget)
+	}
+	ch, ok := chs[target.Channel]
+	if !ok {
+		return fmt.Errorf("push to %s error: channel %q is not enabled", target, target.Channel)
+	}
+	pusher, ok := ch.(Pusher)
+	if !ok {
+		return fmt.Errorf("push to %s error: %w", target, ErrPushNotSupported)
+	}
+	if err := pusher.Push(ctx, target, content); err != nil {
+		return fmt.Errorf("push to %s error: %w", target, err)
+	}
+	return nil
+}
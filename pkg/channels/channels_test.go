@@ -0,0 +1,94 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePusher 测试用可推送通道，依次返回 errs 中的错误
+type fakePusher struct {
+	Channel
+	errs     []error
+	attempts int
+	contents []string
+}
+
+// Push 推送消息
+func (p *fakePusher) Push(ctx context.Context, _ Target, content string) error {
+	return Deliver(ctx, func(context.Context) error {
+		p.attempts++
+		if len(p.errs) > 0 {
+			err := p.errs[0]
+			p.errs = p.errs[1:]
+			return err
+		}
+		p.contents = append(p.contents, content)
+		return nil
+	})
+}
+
+// TestPush 测试推送、重试和投递错误
+func TestPush(t *testing.T) {
+	delays := deliverRetryDelays
+	deliverRetryDelays = []time.Duration{0, 0}
+	defer func() { deliverRetryDelays = delays }()
+
+	ctx := context.Background()
+	target := Target{Channel: "wecomAIBot", UserID: "alice"}
+
+	// 未发出的错误和临时错误重试
+	p := &fakePusher{errs: []error{
+		&NotSentError{Err: errors.New("not connected")},
+		&DeliveryError{Code: 45009, Message: "api freq out of limit", Temporary: true},
+	}}
+	require.NoError(t, Push(ctx, map[string]Channel{"wecomAIBot": p}, target, "hello"))
+	assert.Equal(t, 3, p.attempts)
+	assert.Equal(t, []string{"hello"}, p.contents)
+
+	// 超过重试次数
+	p = &fakePusher{errs: []error{
+		&NotSentError{Err: errors.New("a")},
+		&NotSentError{Err: errors.New("b")},
+		&NotSentError{Err: errors.New("c")},
+	}}
+	err := Push(ctx, map[string]Channel{"wecomAIBot": p}, target, "hello")
+	assert.ErrorContains(t, err, "after 3 attempts")
+	assert.Equal(t, 3, p.attempts)
+
+	// 请求发出后结果未知的错误不重试
+	for _, e := range []error{context.DeadlineExceeded, errors.New("connection closed")} {
+		p = &fakePusher{errs: []error{e}}
+		assert.ErrorIs(t, Push(ctx, map[string]Channel{"wecomAIBot": p}, target, "hello"), e)
+		assert.Equal(t, 1, p.attempts)
+	}
+
+	// 非临时错误不重试
+	p = &fakePusher{errs: []error{&DeliveryError{Code: 93006, Message: "invalid chatid"}}}
+	err = Push(ctx, map[string]Channel{"wecomAIBot": p}, target, "hello")
+	var deliveryErr *DeliveryError
+	require.ErrorAs(t, err, &deliveryErr)
+	assert.Equal(t, 93006, deliveryErr.Code)
+	assert.Equal(t, 1, p.attempts)
+
+	assert.ErrorIs(t, Push(ctx, map[string]Channel{"wecomAIBot": p}, Target{Channel: "wecomAIBot"}, "hello"),
+		ErrInvalidTarget)
+	assert.ErrorContains(t, Push(ctx, nil, target, "hello"), "not enabled")
+	assert.ErrorIs(t, Push(ctx, map[string]Channel{"wecomAIBot": struct{ Channel }{}}, target, "hello"),
+		ErrPushNotSupported)
+}
+
+// TestSplitMessage 测试拆分过长的消息
+func TestSplitMessage(t *testing.T) {
+	assert.Equal(t, []string{"short"}, SplitMessage("short", 10))
+	assert.Equal(t, []string{"line 1\nline 2", "line 3"}, SplitMessage("line 1\nline 2\nline 3", 14))
+
+	// 单行过长时在字符边界处截断
+	parts := SplitMessage(strings.Repeat("涨", 5), 7)
+	assert.Equal(t, []string{"涨涨", "涨涨", "涨"}, parts)
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/gorilla/websocket"
+
+	"github.com/yhlooo/nfa/pkg/channels"
 )
 
 // Dial 建立连接
@@ -178,7 +180,7 @@ func (c *Connection) Send(ctx context.Context, req Request) (*Response, error) {
 
 	// 发送请求
 	if err := c.conn.WriteMessage(websocket.TextMessage, reqRaw); err != nil {
-		return nil, fmt.Errorf("write message to websocket error: %w", err)
+		return nil, &channels.NotSentError{Err: fmt.Errorf("write message to websocket error: %w", err)}
 	}
 
 	// 等待响应
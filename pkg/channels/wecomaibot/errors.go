@@ -5,3 +5,10 @@ import "errors"
 var (
 	ErrSubscriptionError = errors.New("SubscriptionError")
 )
+
+// TemporaryErrorCodes 可以重试的错误码
+var TemporaryErrorCodes = map[int]bool{
+	-1:    true, // 系统繁忙
+	45009: true, // 接口调用超过限制
+	45033: true, // 接口并发调用超过限制
+}
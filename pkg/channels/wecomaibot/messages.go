@@ -137,7 +137,8 @@ const (
 
 	EventMessage MessageType = "event"
 
-	StreamMessage MessageType = "stream"
+	StreamMessage   MessageType = "stream"
+	MarkdownMessage MessageType = "markdown"
 )
 
 // MessageContent 消息内容
@@ -241,11 +242,57 @@ type StreamMessageContent struct {
 	Finish bool `json:"finish"`
 	// 内容
 	Content string `json:"content"`
+	// 图文混排内容，仅在流结束时有效，目前只支持图片
+	MsgItem []StreamMessageItem `json:"msg_item,omitempty"`
 	// 反馈
 	Feedback Feedback `json:"feedback,omitempty"`
 }
 
+// StreamMessageItem 流式消息的图文混排内容项
+type StreamMessageItem struct {
+	// 类型（固定为 image ）
+	MsgType MessageType `json:"msgtype"`
+	// 图片内容
+	Image *ImageMessageItem `json:"image,omitempty"`
+}
+
+// ImageMessageItem 图片内容项
+type ImageMessageItem struct {
+	// 图片内容的 base64 编码
+	Base64 string `json:"base64"`
+	// 图片内容（ base64 编码前）的 md5 值
+	MD5 string `json:"md5"`
+}
+
 // Feedback 反馈
 type Feedback struct {
 	ID string `json:"id"`
 }
+
+// SendMessageRequest 主动推送消息请求
+type SendMessageRequest struct {
+	RequestMeta
+	Body SendMessageRequestBody `json:"body"`
+}
+
+// SendMessageRequestBody 主动推送消息请求体
+type SendMessageRequestBody struct {
+	// 会话 ID ，单聊时为用户 ID ，群聊时为群 ID
+	ChatID string `json:"chatid"`
+	// 会话类型
+	ChatType SendChatType `json:"chat_type,omitempty"`
+	// 消息类型（目前只使用 markdown ）
+	MsgType MessageType `json:"msgtype"`
+	// Markdown 消息内容
+	Markdown *TextMessageContent `json:"markdown,omitempty"`
+}
+
+// SendChatType 主动推送的会话类型
+type SendChatType int
+
+const (
+	// SendChatTypeSingle 单聊
+	SendChatTypeSingle SendChatType = 1
+	// SendChatTypeGroup 群聊
+	SendChatTypeGroup SendChatType = 2
+)
@@ -2,6 +2,9 @@ package wecomaibot
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/rand/v2"
@@ -13,12 +16,23 @@ import (
 
 	"github.com/yhlooo/nfa/pkg/agents"
 	"github.com/yhlooo/nfa/pkg/channels"
+	"github.com/yhlooo/nfa/pkg/charts"
+	"github.com/yhlooo/nfa/pkg/metrics"
 )
 
 const (
-	DefaultURL        = "wss://openws.work.weixin.qq.com"
+	DefaultURL = "wss://openws.work.weixin.qq.com"
+
+	// ChannelName 信道名
+	ChannelName = "wecomAIBot"
+
 	replyReqIDMetaKey = "wecomAIBotReplyRequestID"
 	replyMsgIDMetaKey = "wecomAIBotReplyMessageID"
+
+	// maxImageItems 一条回复最多附带的图片数
+	maxImageItems = 10
+	// maxPushBytes 一条主动推送的 Markdown 消息最大字节数
+	maxPushBytes = 20480
 )
 
 // WeComAIBot 企业微信智能机器人
@@ -35,11 +49,13 @@ type WeComAIBot struct {
 }
 
 var _ channels.Channel = (*WeComAIBot)(nil)
+var _ channels.Pusher = (*WeComAIBot)(nil)
 var _ Handler = (*WeComAIBot)(nil)
 
 // Start 开始运行
 func (ch *WeComAIBot) Start(ctx context.Context) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := logr.FromContextOrDiscard(ctx).WithValues("channel", ChannelName, "botID", ch.BotID)
+	ctx = logr.NewContext(ctx, logger)
 	logger.Info("start connecting wecom aibot")
 
 	u := ch.URL
@@ -58,20 +74,25 @@ func (ch *WeComAIBot) Start(ctx context.Context) {
 			if ch.conn != nil {
 				_ = ch.conn.Close()
 			}
+			metrics.SetChannelConnected(ChannelName, ch.BotID, false)
 			close(receiveChan)
 		}()
 
-		for {
+		metrics.SetChannelConnected(ChannelName, ch.BotID, false)
+		for attempt := 0; ; attempt++ {
 			select {
 			case <-ctx.Done():
 				return
 			default:
 			}
+			if attempt > 0 {
+				metrics.IncChannelReconnects(ChannelName, ch.BotID)
+			}
 
 			// 连接
 			conn, err := Dial(ctx, u, ch)
 			if err != nil {
-				logger.Error(err, fmt.Sprintf("connect websocket %q error", u))
+				logger.Error(err, "connect websocket error", "url", u)
 				time.Sleep(time.Second)
 				continue
 			}
@@ -82,7 +103,7 @@ func (ch *WeComAIBot) Start(ctx context.Context) {
 
 			// 订阅
 			if err := ch.Subscribe(ctx); err != nil {
-				logger.Error(err, fmt.Sprintf("subscribe wecom ai bot %q error", ch.BotID))
+				logger.Error(err, "subscribe wecom ai bot error")
 				if errors.Is(err, ErrSubscriptionError) {
 					ch.err = err
 					return
@@ -91,6 +112,8 @@ func (ch *WeComAIBot) Start(ctx context.Context) {
 				continue
 			}
 
+			metrics.SetChannelConnected(ChannelName, ch.BotID, true)
+
 			// 等待连接断开
 			select {
 			case <-ctx.Done():
@@ -98,6 +121,7 @@ func (ch *WeComAIBot) Start(ctx context.Context) {
 			case <-conn.Done():
 				logger.Error(err, "connection unexpected closed")
 			}
+			metrics.SetChannelConnected(ChannelName, ch.BotID, false)
 		}
 	}()
 }
@@ -151,6 +175,13 @@ func (ch *WeComAIBot) Send(ctx context.Context, meta any, notification *acp.Sess
 	}
 	ch.lock.Unlock()
 
+	// 图表块替换为摘要，流结束时以图片发送
+	content, chartList := charts.ReplaceBlocks(content, charts.Summary)
+	var items []StreamMessageItem
+	if end {
+		items = chartImageItems(logger, chartList)
+	}
+
 	resp, err := conn.Send(ctx, RespondMessageRequest{
 		RequestMeta: RequestMeta{
 			Cmd: CmdRespondMessage,
@@ -164,6 +195,7 @@ func (ch *WeComAIBot) Send(ctx context.Context, meta any, notification *acp.Sess
 				ID:      msgID,
 				Finish:  end,
 				Content: content,
+				MsgItem: items,
 			},
 		},
 	})
@@ -177,9 +209,90 @@ func (ch *WeComAIBot) Send(ctx context.Context, meta any, notification *acp.Sess
 	return nil
 }
 
+// Push 向用户或群主动推送 Markdown 消息，内容过长时拆分为多条
+//
+// 图表块替换为摘要，主动推送只支持 Markdown 和模板卡片消息，不能附带图片
+func (ch *WeComAIBot) Push(ctx context.Context, target channels.Target, content string) error {
+	body := SendMessageRequestBody{MsgType: MarkdownMessage}
+	switch {
+	case target.GroupID != "":
+		body.ChatID, body.ChatType = target.GroupID, SendChatTypeGroup
+	case target.UserID != "":
+		body.ChatID, body.ChatType = target.UserID, SendChatTypeSingle
+	default:
+		return channels.ErrInvalidTarget
+	}
+
+	content, _ = charts.ReplaceBlocks(content, charts.Summary)
+	for _, part := range channels.SplitMessage(content, maxPushBytes) {
+		body.Markdown = &TextMessageContent{Content: part}
+		err := channels.Deliver(ctx, func(ctx context.Context) error {
+			return ch.sendMessage(ctx, body)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendMessage 发送一条主动推送消息
+func (ch *WeComAIBot) sendMessage(ctx context.Context, body SendMessageRequestBody) error {
+	conn, err := ch.getConn()
+	if err != nil {
+		return err
+	}
+
+	resp, err := conn.Send(ctx, SendMessageRequest{
+		RequestMeta: RequestMeta{
+			Cmd: CmdSendMessage,
+			Headers: Headers{
+				RequestID: fmt.Sprintf("%x", rand.Uint64()),
+			},
+		},
+		Body: body,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.ErrorCode != 0 {
+		return &channels.DeliveryError{
+			Code:      resp.ErrorCode,
+			Message:   resp.ErrorMessage,
+			Temporary: TemporaryErrorCodes[resp.ErrorCode],
+		}
+	}
+	return nil
+}
+
+// chartImageItems 将图表渲染为 PNG 图片消息项，最多 maxImageItems 张
+func chartImageItems(logger logr.Logger, chartList []charts.Chart) []StreamMessageItem {
+	var items []StreamMessageItem
+	for _, c := range chartList {
+		if len(items) == maxImageItems {
+			logger.Info("too many charts, ignored", "count", len(chartList))
+			break
+		}
+		data, err := charts.PNG(c, 0, 0)
+		if err != nil {
+			logger.Error(err, "render chart error")
+			continue
+		}
+		sum := md5.Sum(data)
+		items = append(items, StreamMessageItem{
+			MsgType: ImageMessage,
+			Image: &ImageMessageItem{
+				Base64: base64.StdEncoding.EncodeToString(data),
+				MD5:    hex.EncodeToString(sum[:]),
+			},
+		})
+	}
+	return items
+}
+
 // MessageCallback 处理消息回调
 func (ch *WeComAIBot) MessageCallback(ctx context.Context, req *MessageCallbackRequest) error {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := logr.FromContextOrDiscard(ctx).WithValues("userID", req.Body.From.UserID, "msgID", req.Body.MsgID)
 	logger.Info("received message callback")
 
 	content := ""
@@ -188,14 +301,16 @@ func (ch *WeComAIBot) MessageCallback(ctx context.Context, req *MessageCallbackR
 		content = req.Body.Text.Content
 	default:
 		// TODO: 其它类型暂不支持
-		logger.Info(fmt.Sprintf("unsupported message type: %s", req.Body.MsgType))
+		logger.Info("unsupported message type", "msgType", req.Body.MsgType)
 		return nil
 	}
 
 	ch.receiveChan <- channels.UserMessage{
 		Meta: map[string]any{
-			replyReqIDMetaKey: req.RequestMeta.Headers.RequestID,
-			replyMsgIDMetaKey: req.Body.MsgID,
+			replyReqIDMetaKey:     req.RequestMeta.Headers.RequestID,
+			replyMsgIDMetaKey:     req.Body.MsgID,
+			agents.MetaKeyChannel: ChannelName,
+			agents.MetaKeyUserID:  req.Body.From.UserID,
 		},
 		Prompt: []acp.ContentBlock{
 			acp.TextBlock(content),
@@ -208,7 +323,7 @@ func (ch *WeComAIBot) MessageCallback(ctx context.Context, req *MessageCallbackR
 // EventCallback 处理事件回调
 func (ch *WeComAIBot) EventCallback(ctx context.Context, req *EventCallbackRequest) error {
 	logger := logr.FromContextOrDiscard(ctx)
-	logger.Info(fmt.Sprintf("received event callback: %s", req.Body.Event.EventType))
+	logger.Info("received event callback", "eventType", req.Body.Event.EventType)
 	// TODO: ...
 	return nil
 }
@@ -256,7 +371,7 @@ func (ch *WeComAIBot) getConn() (*Connection, error) {
 	ch.lock.Lock()
 	defer ch.lock.Unlock()
 	if ch.conn == nil {
-		return nil, fmt.Errorf("not connected")
+		return nil, &channels.NotSentError{Err: errors.New("not connected")}
 	}
 	return ch.conn, nil
 }
@@ -0,0 +1,140 @@
+package wecomaibot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/channels"
+)
+
+// fakeServer 测试用企业微信长连接服务，依次以 codes 中的错误码响应主动推送请求
+type fakeServer struct {
+	lock     sync.Mutex
+	codes    []int
+	requests []SendMessageRequest
+}
+
+// ServeHTTP 处理 WebSocket 连接
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		req := SendMessageRequest{}
+		if err := json.Unmarshal(data, &req); err != nil || req.Cmd != CmdSendMessage {
+			continue
+		}
+
+		s.lock.Lock()
+		s.requests = append(s.requests, req)
+		resp := Response{Headers: req.Headers}
+		if len(s.codes) > 0 {
+			resp.ErrorCode, resp.ErrorMessage = s.codes[0], "error"
+			s.codes = s.codes[1:]
+		}
+		s.lock.Unlock()
+
+		raw, _ := json.Marshal(resp)
+		if err := conn.WriteMessage(websocket.TextMessage, raw); err != nil {
+			return
+		}
+	}
+}
+
+// Requests 返回收到的主动推送请求
+func (s *fakeServer) Requests() []SendMessageRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]SendMessageRequest(nil), s.requests...)
+}
+
+// newTestBot 创建连接到测试服务的机器人
+func newTestBot(t *testing.T, server *fakeServer) *WeComAIBot {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	ch := &WeComAIBot{}
+	conn, err := Dial(ctx, "ws"+strings.TrimPrefix(httpServer.URL, "http"), ch)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	ch.conn = conn
+	return ch
+}
+
+// TestPush 测试主动推送消息
+func TestPush(t *testing.T) {
+	ctx := context.Background()
+	server := &fakeServer{}
+	ch := newTestBot(t, server)
+
+	// 群和用户分别对应群聊和单聊
+	require.NoError(t, ch.Push(ctx, channels.Target{GroupID: "group1", UserID: "alice"}, "hello group"))
+	require.NoError(t, ch.Push(ctx, channels.Target{UserID: "alice"}, "hello alice"))
+	requests := server.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "group1", requests[0].Body.ChatID)
+	assert.Equal(t, SendChatTypeGroup, requests[0].Body.ChatType)
+	assert.Equal(t, MarkdownMessage, requests[0].Body.MsgType)
+	assert.Equal(t, "hello group", requests[0].Body.Markdown.Content)
+	assert.Equal(t, "alice", requests[1].Body.ChatID)
+	assert.Equal(t, SendChatTypeSingle, requests[1].Body.ChatType)
+
+	assert.ErrorIs(t, ch.Push(ctx, channels.Target{Channel: ChannelName}, "hello"), channels.ErrInvalidTarget)
+}
+
+// TestPushSplit 测试主动推送过长的消息时拆分为多条
+func TestPushSplit(t *testing.T) {
+	server := &fakeServer{}
+	ch := newTestBot(t, server)
+
+	line := strings.Repeat("a", 1023) + "\n"
+	content := strings.Repeat(line, maxPushBytes/len(line)+1)
+	require.NoError(t, ch.Push(context.Background(), channels.Target{UserID: "alice"}, content))
+
+	requests := server.Requests()
+	require.Len(t, requests, 2)
+	var parts []string
+	for _, req := range requests {
+		assert.LessOrEqual(t, len(req.Body.Markdown.Content), maxPushBytes)
+		parts = append(parts, req.Body.Markdown.Content)
+	}
+	assert.Equal(t, strings.TrimSpace(content), strings.Join(parts, "\n"))
+}
+
+// TestPushDeliveryError 测试主动推送的错误码转为投递错误
+func TestPushDeliveryError(t *testing.T) {
+	server := &fakeServer{codes: []int{93006}}
+	ch := newTestBot(t, server)
+
+	err := ch.Push(context.Background(), channels.Target{UserID: "alice"}, "hello")
+	var deliveryErr *channels.DeliveryError
+	require.ErrorAs(t, err, &deliveryErr)
+	assert.Equal(t, 93006, deliveryErr.Code)
+	assert.False(t, deliveryErr.Temporary)
+	assert.Len(t, server.Requests(), 1)
+
+	// 限流为临时错误，重试后成功
+	server = &fakeServer{codes: []int{45009}}
+	ch = newTestBot(t, server)
+	require.NoError(t, ch.Push(context.Background(), channels.Target{UserID: "alice"}, "hello"))
+	assert.Len(t, server.Requests(), 2)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gorilla/websocket"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/yhlooo/nfa/pkg/channels"
 	pb "github.com/yhlooo/nfa/pkg/channels/yuanbaobot/proto"
 )
 
@@ -223,7 +224,7 @@ func (c *Connection) SendPB(ctx context.Context, cmd, module string, innerMsg pr
 
 	// 发送二进制 Protobuf
 	if err := c.conn.WriteMessage(websocket.BinaryMessage, connData); err != nil {
-		return nil, fmt.Errorf("write message error: %w", err)
+		return nil, &channels.NotSentError{Err: fmt.Errorf("write message error: %w", err)}
 	}
 
 	// 等待响应
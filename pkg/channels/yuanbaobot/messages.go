@@ -58,6 +58,14 @@ var TokenExpiredCodes = map[int]bool{
 	41108: true, // AUTH_TOKEN_FORCED_EXPIRATION
 }
 
+// 可以重试的业务错误码
+var TemporaryErrorCodes = map[int]bool{
+	50400: true, // INNER_SVR_FAIL
+	50503: true, // OVERLOAD_CONTROL
+	90001: true, // NET_FAIL
+	90003: true, // BACKEND_RETURN_FAIL
+}
+
 // InboundMessageJSON 入站消息 JSON 结构（服务端推送的 inbound_message）
 type InboundMessageJSON struct {
 	CallbackCommand string               `json:"callback_command"`
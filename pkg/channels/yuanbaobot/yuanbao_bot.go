@@ -15,12 +15,17 @@ import (
 	"github.com/yhlooo/nfa/pkg/agents"
 	"github.com/yhlooo/nfa/pkg/channels"
 	pb "github.com/yhlooo/nfa/pkg/channels/yuanbaobot/proto"
+	"github.com/yhlooo/nfa/pkg/charts"
+	"github.com/yhlooo/nfa/pkg/metrics"
 )
 
 const (
 	DefaultBaseURL      = "https://bot.yuanbao.tencent.com"
 	DefaultWebSocketURL = "wss://bot-wss.yuanbao.tencent.com/wss/connection"
 
+	// ChannelName 信道名
+	ChannelName = "yuanbaoBot"
+
 	replyToAccountMetaKey = "yuanbaoBotReplyToAccount"
 	replyMsgIDMetaKey     = "yuanbaoBotReplyMsgID"
 	botIDMetaKey          = "yuanbaoBotBotID"
@@ -29,6 +34,9 @@ const (
 	replyHeartbeatInterval = 2 * time.Second
 	// 回复心跳最大空闲时间
 	replyHeartbeatMaxIdle = 30 * time.Second
+
+	// maxPushBytes 一条主动推送消息的最大字节数
+	maxPushBytes = 8000
 )
 
 // 重连退避延迟
@@ -58,11 +66,13 @@ type YuanbaoBot struct {
 }
 
 var _ channels.Channel = (*YuanbaoBot)(nil)
+var _ channels.Pusher = (*YuanbaoBot)(nil)
 var _ Handler = (*YuanbaoBot)(nil)
 
 // Start 开始运行
 func (ch *YuanbaoBot) Start(ctx context.Context) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := logr.FromContextOrDiscard(ctx).WithValues("channel", ChannelName, "appID", ch.AppKey)
+	ctx = logr.NewContext(ctx, logger)
 	logger.Info("start connecting yuanbao bot")
 
 	baseURL := ch.BaseURL
@@ -92,16 +102,21 @@ func (ch *YuanbaoBot) connectLoop(ctx context.Context, u string, receiveChan cha
 		if ch.conn != nil {
 			_ = ch.conn.Close()
 		}
+		metrics.SetChannelConnected(ChannelName, ch.AppKey, false)
 		close(receiveChan)
 	}()
 
+	metrics.SetChannelConnected(ChannelName, ch.AppKey, false)
 	reconnectAttempts := 0
-	for {
+	for attempt := 0; ; attempt++ {
 		select {
 		case <-ctx.Done():
 			return
 		default:
 		}
+		if attempt > 0 {
+			metrics.IncChannelReconnects(ChannelName, ch.AppKey)
+		}
 
 		// 1. 获取 Token
 		tokenCache, err := ch.auth.SignToken(ctx)
@@ -118,7 +133,7 @@ func (ch *YuanbaoBot) connectLoop(ctx context.Context, u string, receiveChan cha
 		// 2. 建立 WebSocket 连接
 		conn, err := Dial(ctx, u, ch)
 		if err != nil {
-			logger.Error(err, fmt.Sprintf("connect websocket %q error", u))
+			logger.Error(err, "connect websocket error", "url", u)
 			ch.sleepWithBackoff(ctx, reconnectAttempts)
 			reconnectAttempts++
 			continue
@@ -142,6 +157,7 @@ func (ch *YuanbaoBot) connectLoop(ctx context.Context, u string, receiveChan cha
 		}
 
 		logger.Info("yuanbao bot connected and authenticated", "botID", tokenCache.BotID)
+		metrics.SetChannelConnected(ChannelName, ch.AppKey, true)
 
 		// 4. 启动 Token 刷新
 		refreshDone := make(chan struct{})
@@ -156,6 +172,7 @@ func (ch *YuanbaoBot) connectLoop(ctx context.Context, u string, receiveChan cha
 			close(refreshDone)
 			logger.Info("connection closed, will reconnect")
 		}
+		metrics.SetChannelConnected(ChannelName, ch.AppKey, false)
 
 		// 检查是否为不可重连的关闭码
 		if closeErr := conn.Err(); closeErr != nil {
@@ -303,6 +320,9 @@ func (ch *YuanbaoBot) Send(ctx context.Context, meta any, notification *acp.Sess
 		return nil
 	}
 
+	// 图表块替换为摘要，发送图片需要先上传到元宝的存储，尚未接入上传接口
+	content, _ = charts.ReplaceBlocks(content, charts.Summary)
+
 	// 发送完整消息
 	respConnMsg, err := conn.SendPB(ctx, CmdSendC2CMessage, ModuleBiz, &pb.SendC2CMessageReq{
 		MsgId:       msgID,
@@ -310,14 +330,7 @@ func (ch *YuanbaoBot) Send(ctx context.Context, meta any, notification *acp.Sess
 		FromAccount: botID,
 		MsgRandom:   rand.Uint32(),
 		MsgSeq:      uint64(time.Now().UnixMilli()),
-		MsgBody: []*pb.MsgBodyElement{
-			{
-				MsgType: MsgTypeText,
-				MsgContent: &pb.MsgContent{
-					Text: content,
-				},
-			},
-		},
+		MsgBody:     textMsgBody(content),
 	})
 	if err != nil {
 		return err
@@ -335,15 +348,112 @@ func (ch *YuanbaoBot) Send(ctx context.Context, meta any, notification *acp.Sess
 	return nil
 }
 
+// Push 向用户（私聊）或群主动推送消息，内容过长时拆分为多条
+//
+// 图表块替换为摘要，原因同 Send
+func (ch *YuanbaoBot) Push(ctx context.Context, target channels.Target, content string) error {
+	if target.UserID == "" && target.GroupID == "" {
+		return channels.ErrInvalidTarget
+	}
+
+	content, _ = charts.ReplaceBlocks(content, charts.Summary)
+	for _, part := range channels.SplitMessage(content, maxPushBytes) {
+		err := channels.Deliver(ctx, func(ctx context.Context) error {
+			if target.GroupID != "" {
+				return ch.sendGroupMessage(ctx, target.GroupID, part)
+			}
+			return ch.sendC2CMessage(ctx, target.UserID, part)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendC2CMessage 不回复入站消息，直接向用户发送私聊消息
+func (ch *YuanbaoBot) sendC2CMessage(ctx context.Context, toAccount, content string) error {
+	conn, err := ch.getConn()
+	if err != nil {
+		return err
+	}
+
+	respConnMsg, err := conn.SendPB(ctx, CmdSendC2CMessage, ModuleBiz, &pb.SendC2CMessageReq{
+		ToAccount:   toAccount,
+		FromAccount: ch.getBotID(),
+		MsgRandom:   rand.Uint32(),
+		MsgSeq:      uint64(time.Now().UnixMilli()),
+		MsgBody:     textMsgBody(content),
+	})
+	if err != nil {
+		return err
+	}
+
+	sendRsp := &pb.SendC2CMessageRsp{}
+	if err := proto.Unmarshal(respConnMsg.Data, sendRsp); err != nil {
+		return fmt.Errorf("unmarshal SendC2CMessageRsp error: %w", err)
+	}
+	return deliveryError(sendRsp.Code, sendRsp.Message)
+}
+
+// sendGroupMessage 向群发送消息
+func (ch *YuanbaoBot) sendGroupMessage(ctx context.Context, groupCode, content string) error {
+	conn, err := ch.getConn()
+	if err != nil {
+		return err
+	}
+
+	respConnMsg, err := conn.SendPB(ctx, CmdSendGroupMessage, ModuleBiz, &pb.SendGroupMessageReq{
+		GroupCode:   groupCode,
+		FromAccount: ch.getBotID(),
+		Random:      fmt.Sprintf("%d", rand.Uint32()),
+		MsgSeq:      uint64(time.Now().UnixMilli()),
+		MsgBody:     textMsgBody(content),
+	})
+	if err != nil {
+		return err
+	}
+
+	sendRsp := &pb.SendGroupMessageRsp{}
+	if err := proto.Unmarshal(respConnMsg.Data, sendRsp); err != nil {
+		return fmt.Errorf("unmarshal SendGroupMessageRsp error: %w", err)
+	}
+	return deliveryError(sendRsp.Code, sendRsp.Message)
+}
+
+// textMsgBody 返回文本消息体
+func textMsgBody(content string) []*pb.MsgBodyElement {
+	return []*pb.MsgBodyElement{
+		{
+			MsgType: MsgTypeText,
+			MsgContent: &pb.MsgContent{
+				Text: content,
+			},
+		},
+	}
+}
+
+// deliveryError 将业务错误码转为投递错误，成功时返回 nil
+func deliveryError(code int32, message string) error {
+	if code == 0 {
+		return nil
+	}
+	return &channels.DeliveryError{
+		Code:      int(code),
+		Message:   message,
+		Temporary: TemporaryErrorCodes[int(code)],
+	}
+}
+
 // OnMessage 处理入站消息
 func (ch *YuanbaoBot) OnMessageJSON(ctx context.Context, msg *InboundMessageJSON) error {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := logr.FromContextOrDiscard(ctx).WithValues("userID", msg.FromAccount, "msgID", msg.MsgID)
 
 	switch msg.CallbackCommand {
 	case CallbackC2CSendMsg:
 		// 私聊消息
 	default:
-		logger.Info(fmt.Sprintf("ignore callback command: %s", msg.CallbackCommand))
+		logger.Info("ignore callback command", "callbackCommand", msg.CallbackCommand)
 		return nil
 	}
 
@@ -371,6 +481,8 @@ func (ch *YuanbaoBot) OnMessageJSON(ctx context.Context, msg *InboundMessageJSON
 			replyToAccountMetaKey: toAccount,
 			replyMsgIDMetaKey:     msgID,
 			botIDMetaKey:          ch.getBotID(),
+			agents.MetaKeyChannel: ChannelName,
+			agents.MetaKeyUserID:  toAccount,
 		},
 		Prompt: []acp.ContentBlock{
 			acp.TextBlock(text),
@@ -392,7 +504,7 @@ func (ch *YuanbaoBot) getConn() (*Connection, error) {
 	ch.lock.Lock()
 	defer ch.lock.Unlock()
 	if ch.conn == nil {
-		return nil, ErrNotConnected
+		return nil, &channels.NotSentError{Err: ErrNotConnected}
 	}
 	return ch.conn, nil
 }
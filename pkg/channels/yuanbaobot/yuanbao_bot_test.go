@@ -0,0 +1,167 @@
+package yuanbaobot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/yhlooo/nfa/pkg/channels"
+	pb "github.com/yhlooo/nfa/pkg/channels/yuanbaobot/proto"
+)
+
+// sentMessage 测试服务收到的消息
+type sentMessage struct {
+	Cmd     string
+	To      string
+	Content string
+}
+
+// fakeServer 测试用元宝长连接服务，依次以 codes 中的错误码响应发送消息请求
+type fakeServer struct {
+	lock     sync.Mutex
+	codes    []int32
+	messages []sentMessage
+}
+
+// ServeHTTP 处理 WebSocket 连接
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		connMsg := &pb.ConnMsg{}
+		if err := proto.Unmarshal(data, connMsg); err != nil || connMsg.Head == nil {
+			continue
+		}
+
+		var msg sentMessage
+		switch connMsg.Head.Cmd {
+		case CmdSendC2CMessage:
+			req := &pb.SendC2CMessageReq{}
+			if err := proto.Unmarshal(connMsg.Data, req); err != nil {
+				continue
+			}
+			msg = sentMessage{Cmd: CmdSendC2CMessage, To: req.ToAccount, Content: req.MsgBody[0].MsgContent.Text}
+		case CmdSendGroupMessage:
+			req := &pb.SendGroupMessageReq{}
+			if err := proto.Unmarshal(connMsg.Data, req); err != nil {
+				continue
+			}
+			msg = sentMessage{Cmd: CmdSendGroupMessage, To: req.GroupCode, Content: req.MsgBody[0].MsgContent.Text}
+		default:
+			continue
+		}
+
+		s.lock.Lock()
+		s.messages = append(s.messages, msg)
+		var code int32
+		if len(s.codes) > 0 {
+			code = s.codes[0]
+			s.codes = s.codes[1:]
+		}
+		s.lock.Unlock()
+
+		// 两种响应的编码相同
+		rspData, _ := proto.Marshal(&pb.SendC2CMessageRsp{Code: code, Message: "error"})
+		rsp, _ := proto.Marshal(&pb.ConnMsg{
+			Head: &pb.Head{CmdType: 1, Cmd: connMsg.Head.Cmd, MsgId: connMsg.Head.MsgId},
+			Data: rspData,
+		})
+		if err := conn.WriteMessage(websocket.BinaryMessage, rsp); err != nil {
+			return
+		}
+	}
+}
+
+// Messages 返回收到的消息
+func (s *fakeServer) Messages() []sentMessage {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]sentMessage(nil), s.messages...)
+}
+
+// newTestBot 创建连接到测试服务的机器人
+func newTestBot(t *testing.T, server *fakeServer) *YuanbaoBot {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	ch := &YuanbaoBot{botID: "bot"}
+	conn, err := Dial(ctx, "ws"+strings.TrimPrefix(httpServer.URL, "http"), ch)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	ch.conn = conn
+	return ch
+}
+
+// TestPush 测试主动推送消息
+func TestPush(t *testing.T) {
+	ctx := context.Background()
+	server := &fakeServer{}
+	ch := newTestBot(t, server)
+
+	// 有群时发群消息，否则发私聊消息
+	require.NoError(t, ch.Push(ctx, channels.Target{GroupID: "group1", UserID: "alice"}, "hello group"))
+	require.NoError(t, ch.Push(ctx, channels.Target{UserID: "alice"}, "hello alice"))
+	assert.Equal(t, []sentMessage{
+		{Cmd: CmdSendGroupMessage, To: "group1", Content: "hello group"},
+		{Cmd: CmdSendC2CMessage, To: "alice", Content: "hello alice"},
+	}, server.Messages())
+
+	assert.ErrorIs(t, ch.Push(ctx, channels.Target{Channel: ChannelName}, "hello"), channels.ErrInvalidTarget)
+}
+
+// TestPushSplit 测试主动推送过长的消息时拆分为多条
+func TestPushSplit(t *testing.T) {
+	server := &fakeServer{}
+	ch := newTestBot(t, server)
+
+	line := strings.Repeat("a", 999) + "\n"
+	content := strings.Repeat(line, maxPushBytes/len(line)+1)
+	require.NoError(t, ch.Push(context.Background(), channels.Target{UserID: "alice"}, content))
+
+	messages := server.Messages()
+	require.Len(t, messages, 2)
+	var parts []string
+	for _, msg := range messages {
+		assert.LessOrEqual(t, len(msg.Content), maxPushBytes)
+		parts = append(parts, msg.Content)
+	}
+	assert.Equal(t, strings.TrimSpace(content), strings.Join(parts, "\n"))
+}
+
+// TestPushDeliveryError 测试发送消息的错误码转为投递错误
+func TestPushDeliveryError(t *testing.T) {
+	server := &fakeServer{codes: []int32{40001}}
+	ch := newTestBot(t, server)
+
+	err := ch.Push(context.Background(), channels.Target{GroupID: "group1"}, "hello")
+	var deliveryErr *channels.DeliveryError
+	require.ErrorAs(t, err, &deliveryErr)
+	assert.Equal(t, 40001, deliveryErr.Code)
+	assert.False(t, deliveryErr.Temporary)
+	assert.Len(t, server.Messages(), 1)
+
+	// 服务过载为临时错误，重试后成功
+	server = &fakeServer{codes: []int32{50503}}
+	ch = newTestBot(t, server)
+	require.NoError(t, ch.Push(context.Background(), channels.Target{UserID: "alice"}, "hello"))
+	assert.Len(t, server.Messages(), 2)
+}
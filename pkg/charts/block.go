@@ -0,0 +1,103 @@
+package charts
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// BlockLang 图表块的代码块语言标识
+const BlockLang = "chart"
+
+// Block 将图表编码为 Markdown 图表块，即语言标识为 chart 、内容为图表 JSON 的代码块
+func Block(c Chart) string {
+	raw, _ := json.Marshal(c)
+	return "\n```" + BlockLang + "\n" + string(raw) + "\n```\n"
+}
+
+// Segment 文本片段，是 Markdown 文本或图表
+type Segment struct {
+	// Markdown 文本
+	Text string
+	// 图表，不为 nil 时 Text 为空
+	Chart *Chart
+}
+
+// Split 将文本拆分为 Markdown 文本和图表片段
+//
+// 无法解析的图表块和未闭合的图表块（如仍在流式输出中）作为 Markdown 文本保留
+func Split(text string) []Segment {
+	var (
+		ret   []Segment
+		buf   strings.Builder
+		block *strings.Builder
+		fence string
+	)
+	flushText := func() {
+		if buf.Len() > 0 {
+			ret = append(ret, Segment{Text: buf.String()})
+			buf.Reset()
+		}
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if block == nil {
+			if f, ok := chartFence(trimmed); ok {
+				block, fence = &strings.Builder{}, f
+				block.WriteString(line)
+				continue
+			}
+			buf.WriteString(line)
+			continue
+		}
+
+		block.WriteString(line)
+		if trimmed != fence {
+			continue
+		}
+		raw := block.String()
+		content := strings.TrimSuffix(strings.TrimRight(raw, "\n"), fence)
+		content = content[strings.Index(content, "\n")+1:]
+		block = nil
+		var c Chart
+		if err := json.Unmarshal([]byte(content), &c); err != nil || c.Complete() != nil {
+			buf.WriteString(raw)
+			continue
+		}
+		flushText()
+		ret = append(ret, Segment{Chart: &c})
+	}
+	if block != nil {
+		buf.WriteString(block.String())
+	}
+	flushText()
+	return ret
+}
+
+// ReplaceBlocks 将文本中的图表块替换为 fn 返回的文本，返回替换后的文本和其中的图表
+func ReplaceBlocks(text string, fn func(c Chart) string) (string, []Chart) {
+	var (
+		ret    strings.Builder
+		charts []Chart
+	)
+	for _, seg := range Split(text) {
+		if seg.Chart == nil {
+			ret.WriteString(seg.Text)
+			continue
+		}
+		charts = append(charts, *seg.Chart)
+		ret.WriteString(fn(*seg.Chart) + "\n")
+	}
+	return ret.String(), charts
+}
+
+// chartFence 判断是否为图表块的开始行，返回对应的结束行
+func chartFence(line string) (string, bool) {
+	for _, fence := range []string{"```", "~~~"} {
+		if rest, ok := strings.CutPrefix(line, fence); ok && strings.TrimSpace(rest) == BlockLang {
+			return fence, true
+		}
+	}
+	return "", false
+}
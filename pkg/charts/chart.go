@@ -0,0 +1,199 @@
+package charts
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// Type 图表类型
+type Type string
+
+const (
+	// TypeLine 折线图，使用收盘价
+	TypeLine Type = "line"
+	// TypeCandlestick K 线（蜡烛）图
+	TypeCandlestick Type = "candlestick"
+)
+
+// MaxPoints 图表最多包含的数据点数
+const MaxPoints = 500
+
+// Chart 价格序列图表
+type Chart struct {
+	// 类型，未指定时有开高低价的序列为 K 线图，否则为折线图
+	Type Type `json:"type,omitempty"`
+	// 标题，如证券代码
+	Title string `json:"title,omitempty"`
+	// 数据点，按时间升序排列
+	Points []Point `json:"points"`
+}
+
+// Point 图表的一个数据点
+type Point struct {
+	// 时间
+	Time string `json:"time"`
+	// 开盘价、最高价、最低价，折线图可以省略
+	Open float64 `json:"open,omitempty"`
+	High float64 `json:"high,omitempty"`
+	Low  float64 `json:"low,omitempty"`
+	// 收盘价，折线图的值
+	Close float64 `json:"close"`
+}
+
+// hasOHLC 是否有完整的开高低收价
+func (p Point) hasOHLC() bool {
+	return p.Open != 0 && p.High != 0 && p.Low != 0
+}
+
+// high 数据点的最高值
+func (p Point) high() float64 {
+	if p.hasOHLC() {
+		return max(p.High, p.Open, p.Close)
+	}
+	return p.Close
+}
+
+// low 数据点的最低值
+func (p Point) low() float64 {
+	if p.hasOHLC() {
+		return min(p.Low, p.Open, p.Close)
+	}
+	return p.Close
+}
+
+// FromBars 使用 K 线创建图表，折线图只包含收盘价
+func FromBars(bars []tools.Bar, typ Type, title string) Chart {
+	c := Chart{Type: typ, Title: title, Points: make([]Point, len(bars))}
+	for i, bar := range bars {
+		c.Points[i] = Point{
+			Time:  tools.FormatBarTime(bar.Time),
+			Close: bar.Close.InexactFloat64(),
+		}
+		if typ != TypeLine {
+			c.Points[i].Open = bar.Open.InexactFloat64()
+			c.Points[i].High = bar.High.InexactFloat64()
+			c.Points[i].Low = bar.Low.InexactFloat64()
+		}
+	}
+	return c
+}
+
+// Complete 校验图表并补全类型，数据点超过 MaxPoints 时只保留最近的数据点
+func (c *Chart) Complete() error {
+	if len(c.Points) == 0 {
+		return fmt.Errorf("chart has no points")
+	}
+	if len(c.Points) > MaxPoints {
+		c.Points = c.Points[len(c.Points)-MaxPoints:]
+	}
+	candlestick := true
+	for i, p := range c.Points {
+		for _, v := range []float64{p.Open, p.High, p.Low, p.Close} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("invalid value of point %d", i)
+			}
+		}
+		if !p.hasOHLC() {
+			candlestick = false
+		}
+	}
+	switch c.Type {
+	case "":
+		c.Type = TypeLine
+		if candlestick {
+			c.Type = TypeCandlestick
+		}
+	case TypeLine:
+	case TypeCandlestick:
+		if !candlestick {
+			return fmt.Errorf("candlestick chart requires open, high, low and close of every point")
+		}
+	default:
+		return fmt.Errorf("unknown chart type %q", c.Type)
+	}
+	return nil
+}
+
+// Summary 图表的一行文本摘要，包括标题、起止时间、最新值和区间涨跌幅
+func Summary(c Chart) string {
+	if len(c.Points) == 0 {
+		return "📈 " + c.Title
+	}
+	first, last := c.Points[0], c.Points[len(c.Points)-1]
+	ret := "📈 "
+	if c.Title != "" {
+		ret += c.Title + " "
+	}
+	ret += fmt.Sprintf("%s ~ %s: %s", first.Time, last.Time, strconv.FormatFloat(last.Close, 'f', -1, 64))
+	base := first.Close
+	if first.hasOHLC() {
+		base = first.Open
+	}
+	if base != 0 {
+		ret += fmt.Sprintf(" (%+.2f%%)", (last.Close-base)/math.Abs(base)*100)
+	}
+	return ret
+}
+
+// bounds 数据点的最小值和最大值，折线图只使用收盘价，两者相等时适当扩展
+func bounds(typ Type, points []Point) (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		if typ == TypeLine {
+			lo, hi = min(lo, p.Close), max(hi, p.Close)
+			continue
+		}
+		lo = min(lo, p.low())
+		hi = max(hi, p.high())
+	}
+	if lo == hi {
+		pad := math.Abs(lo) * 0.01
+		if pad == 0 {
+			pad = 1
+		}
+		lo, hi = lo-pad, hi+pad
+	}
+	return lo, hi
+}
+
+// resample 将数据点合并为最多 n 个，每个数据点合并连续的若干数据点的开高低收价
+func resample(points []Point, n int) []Point {
+	if n <= 0 || len(points) <= n {
+		return points
+	}
+	ret := make([]Point, n)
+	for i := range ret {
+		group := points[i*len(points)/n : (i+1)*len(points)/n]
+		p := group[len(group)-1]
+		p.Open, p.High, p.Low = group[0].Open, p.high(), p.low()
+		if !group[0].hasOHLC() {
+			p.Open = group[0].Close
+		}
+		for _, q := range group {
+			p.High = max(p.High, q.high())
+			p.Low = min(p.Low, q.low())
+		}
+		ret[i] = p
+	}
+	return ret
+}
+
+// formatValue 按数值范围 span 选择精度格式化数值
+func formatValue(v, span float64) string {
+	if span == 0 {
+		span = math.Abs(v)
+	}
+	decimals := 2
+	switch {
+	case span >= 1000:
+		decimals = 0
+	case span >= 100:
+		decimals = 1
+	case span > 0 && span < 1:
+		decimals = min(int(math.Ceil(-math.Log10(span)))+2, 8)
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
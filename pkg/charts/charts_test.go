@@ -0,0 +1,113 @@
+package charts
+
+import (
+	"bytes"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ansiPattern ANSI 控制序列
+var ansiPattern = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// testChart 测试用图表
+func testChart() Chart {
+	return Chart{
+		Title: "0700.HK",
+		Points: []Point{
+			{Time: "2025-10-06", Open: 380, High: 386, Low: 378, Close: 385},
+			{Time: "2025-10-07", Open: 385, High: 390, Low: 383, Close: 388},
+			{Time: "2025-10-08", Open: 388, High: 389, Low: 376, Close: 379.5},
+			{Time: "2025-10-09", Open: 379.5, High: 387, Low: 379, Close: 385.17},
+		},
+	}
+}
+
+// TestComplete 测试补全和校验图表
+func TestComplete(t *testing.T) {
+	c := testChart()
+	require.NoError(t, c.Complete())
+	assert.Equal(t, TypeCandlestick, c.Type)
+
+	line := Chart{Points: []Point{{Time: "2025-10-08", Close: 379.5}, {Time: "2025-10-09", Close: 385.17}}}
+	require.NoError(t, line.Complete())
+	assert.Equal(t, TypeLine, line.Type)
+
+	line.Type = TypeCandlestick
+	assert.Error(t, line.Complete())
+	assert.Error(t, (&Chart{}).Complete())
+	assert.Error(t, (&Chart{Type: "pie", Points: line.Points}).Complete())
+
+	assert.Equal(t, "📈 0700.HK 2025-10-06 ~ 2025-10-09: 385.17 (+1.36%)", Summary(c))
+}
+
+// TestSplit 测试拆分图表块
+func TestSplit(t *testing.T) {
+	text := "走势如下：\n" + Block(testChart()) + "近期震荡。\n```chart\nnot json\n```\n"
+	segments := Split(text)
+	require.Len(t, segments, 3)
+	assert.Equal(t, "走势如下：\n\n", segments[0].Text)
+	require.NotNil(t, segments[1].Chart)
+	assert.Equal(t, testChart().Points, segments[1].Chart.Points)
+	// 无法解析的图表块保留为文本
+	assert.Equal(t, "近期震荡。\n```chart\nnot json\n```\n", segments[2].Text)
+
+	// 未闭合的图表块保留为文本
+	partial := "走势如下：\n```chart\n{\"points\":"
+	assert.Equal(t, []Segment{{Text: partial}}, Split(partial))
+
+	replaced, chartList := ReplaceBlocks(text, func(c Chart) string { return "[" + c.Title + "]" })
+	assert.Equal(t, "走势如下：\n\n[0700.HK]\n近期震荡。\n```chart\nnot json\n```\n", replaced)
+	assert.Len(t, chartList, 1)
+}
+
+// TestTerminal 测试渲染终端图表
+func TestTerminal(t *testing.T) {
+	for _, typ := range []Type{TypeCandlestick, TypeLine} {
+		c := testChart()
+		c.Type = typ
+		out := ansiPattern.ReplaceAllString(Terminal(c, 60, 8), "")
+		lines := strings.Split(out, "\n")
+		// 标题行、 8 行绘图区、横轴和时间标签
+		require.Len(t, lines, 11, out)
+		assert.Equal(t, Summary(testChart()), lines[0])
+		for _, line := range lines[1:10] {
+			assert.Equal(t, 60, utf8.RuneCountInString(line), line)
+		}
+		assert.Contains(t, lines[1], "┤")
+		assert.Contains(t, lines[9], "└")
+		assert.Equal(t, 3, strings.Count(lines[9], "┬"), lines[9])
+		assert.Contains(t, lines[10], "2025-10-06")
+		assert.True(t, strings.HasSuffix(lines[10], "2025-10-09"), lines[10])
+	}
+
+	// 数据点多于绘图区宽度时合并
+	c := Chart{}
+	for i := range 200 {
+		c.Points = append(c.Points, Point{Time: "2025-10-09", Open: 1, High: 3, Low: 1, Close: float64(2 + i%2)})
+	}
+	out := ansiPattern.ReplaceAllString(Terminal(c, 40, 4), "")
+	for _, line := range strings.Split(out, "\n")[1:6] {
+		assert.LessOrEqual(t, utf8.RuneCountInString(line), 40, line)
+	}
+}
+
+// TestPNG 测试渲染 PNG 图片
+func TestPNG(t *testing.T) {
+	data, err := PNG(testChart(), 0, 0)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultImageWidth, img.Bounds().Dx())
+	assert.Equal(t, DefaultImageHeight, img.Bounds().Dy())
+
+	_, err = PNG(testChart(), 30, 20)
+	assert.Error(t, err)
+	_, err = PNG(Chart{}, 0, 0)
+	assert.Error(t, err)
+}
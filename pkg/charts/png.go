@@ -0,0 +1,220 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// DefaultImageWidth 图片默认宽度（像素）
+	DefaultImageWidth = 800
+	// DefaultImageHeight 图片默认高度（像素）
+	DefaultImageHeight = 450
+	// imageYTicks 图片纵轴刻度数
+	imageYTicks = 5
+	// imagePadding 图片边距
+	imagePadding = 12
+)
+
+// 图片颜色
+var (
+	colorBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	colorText       = color.RGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+	colorAxis       = color.RGBA{R: 0x99, G: 0x99, B: 0x99, A: 0xff}
+	colorGrid       = color.RGBA{R: 0xe8, G: 0xe8, B: 0xe8, A: 0xff}
+	colorRise       = color.RGBA{R: 0x26, G: 0xa6, B: 0x9a, A: 0xff}
+	colorFall       = color.RGBA{R: 0xef, G: 0x53, B: 0x50, A: 0xff}
+)
+
+// Image 将图表绘制为图片，包括标题、纵轴数值、横轴时间和网格线
+//
+// width 、 height 为图片像素尺寸，小于等于 0 时使用 DefaultImageWidth 、 DefaultImageHeight 。
+// 内置字体只包含 ASCII 字符，标题中的其它字符会被省略
+func Image(c Chart, width, height int) (image.Image, error) {
+	if err := c.Complete(); err != nil {
+		return nil, err
+	}
+	if width <= 0 {
+		width = DefaultImageWidth
+	}
+	if height <= 0 {
+		height = DefaultImageHeight
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(colorBackground), image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	lineHeight := face.Metrics().Height.Ceil()
+
+	// 纵轴刻度
+	lo, hi := bounds(c.Type, c.Points)
+	pad := (hi - lo) * 0.05
+	lo, hi = lo-pad, hi+pad
+	labels := make([]string, imageYTicks)
+	labelWidth := 0
+	for i := range labels {
+		labels[i] = formatValue(lo+float64(i)*(hi-lo)/(imageYTicks-1), hi-lo)
+		labelWidth = max(labelWidth, font.MeasureString(face, labels[i]).Ceil())
+	}
+
+	plot := image.Rectangle{
+		Min: image.Pt(imagePadding+labelWidth+6, imagePadding+lineHeight+8),
+		Max: image.Pt(width-imagePadding, height-imagePadding-lineHeight-6),
+	}
+	if plot.Dx() < minPlotWidth || plot.Dy() < minPlotWidth {
+		return nil, fmt.Errorf("image size %dx%d too small", width, height)
+	}
+	yOf := func(v float64) int {
+		return plot.Max.Y - 1 - int(math.Round((v-lo)/(hi-lo)*float64(plot.Dy()-1)))
+	}
+
+	drawText(img, face, colorText, imagePadding, imagePadding+lineHeight-3, asciiOnly(strings.TrimPrefix(Summary(c), "📈 ")))
+	for i, label := range labels {
+		y := yOf(lo + float64(i)*(hi-lo)/(imageYTicks-1))
+		hLine(img, plot.Min.X, plot.Max.X, y, colorGrid)
+		drawText(img, face, colorText, plot.Min.X-6-font.MeasureString(face, label).Ceil(), y+lineHeight/2-3, label)
+	}
+
+	// 绘图
+	points := c.Points
+	var xs []int
+	switch c.Type {
+	case TypeCandlestick:
+		points = resample(points, plot.Dx()/3)
+		slot := float64(plot.Dx()) / float64(len(points))
+		bodyWidth := max(int(slot*0.7), 1)
+		xs = make([]int, len(points))
+		for i, p := range points {
+			x := plot.Min.X + int(slot*(float64(i)+0.5))
+			xs[i] = x
+			col := colorRise
+			if p.Close < p.Open {
+				col = colorFall
+			}
+			vLine(img, x, yOf(p.High), yOf(p.Low), col)
+			top, bottom := yOf(max(p.Open, p.Close)), yOf(min(p.Open, p.Close))
+			draw.Draw(img, image.Rect(x-bodyWidth/2, top, x-bodyWidth/2+bodyWidth, bottom+1), image.NewUniform(col), image.Point{}, draw.Src)
+		}
+	default:
+		col := colorRise
+		if points[len(points)-1].Close < points[0].Close {
+			col = colorFall
+		}
+		xs = make([]int, len(points))
+		for i, p := range points {
+			x := plot.Min.X
+			if len(points) > 1 {
+				x += int(math.Round(float64(i) * float64(plot.Dx()-1) / float64(len(points)-1)))
+			}
+			xs[i] = x
+			if i > 0 {
+				thickLine(img, xs[i-1], yOf(points[i-1].Close), x, yOf(p.Close), col)
+			}
+		}
+		if len(points) == 1 {
+			thickLine(img, xs[0], yOf(points[0].Close), xs[0], yOf(points[0].Close), col)
+		}
+	}
+
+	// 坐标轴
+	vLine(img, plot.Min.X, plot.Min.Y, plot.Max.Y, colorAxis)
+	hLine(img, plot.Min.X, plot.Max.X, plot.Max.Y, colorAxis)
+	charWidth := font.MeasureString(face, "0").Ceil()
+	columns := make([]int, len(xs))
+	for i, x := range xs {
+		columns[i] = (x - plot.Min.X) / charWidth
+	}
+	for _, t := range xTicks(points, columns, plot.Dx()/charWidth) {
+		vLine(img, xs[t.index], plot.Max.Y, plot.Max.Y+4, colorAxis)
+		drawText(img, face, colorText, plot.Min.X+t.start*charWidth, plot.Max.Y+lineHeight+2, t.text)
+	}
+
+	return img, nil
+}
+
+// PNG 将图表绘制为 PNG 图片，参数参考 Image
+func PNG(c Chart, width, height int) ([]byte, error) {
+	img, err := Image(c, width, height)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, fmt.Errorf("encode png error: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawText 以 (x, y) 为基线起点绘制文本
+func drawText(img draw.Image, face font.Face, col color.Color, x, y int, text string) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+// hLine 绘制水平线
+func hLine(img draw.Image, x0, x1, y int, col color.Color) {
+	for x := x0; x <= x1; x++ {
+		img.Set(x, y, col)
+	}
+}
+
+// vLine 绘制竖直线
+func vLine(img draw.Image, x, y0, y1 int, col color.Color) {
+	for y := min(y0, y1); y <= max(y0, y1); y++ {
+		img.Set(x, y, col)
+	}
+}
+
+// thickLine 使用 Bresenham 算法绘制 2 像素宽的线段
+func thickLine(img draw.Image, x0, y0, x1, y1 int, col color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+	for {
+		img.Set(x0, y0, col)
+		img.Set(x0+1, y0, col)
+		img.Set(x0, y0+1, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// asciiOnly 省略文本中的非 ASCII 字符并合并多余的空白
+func asciiOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,302 @@
+package charts
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultTerminalWidth 终端宽度未知时图表的默认宽度（字符数）
+	DefaultTerminalWidth = 80
+	// DefaultTerminalHeight 终端图表绘图区的默认高度（行数）
+	DefaultTerminalHeight = 12
+	// minPlotWidth 绘图区的最小宽度
+	minPlotWidth = 10
+	// yLabelEvery 纵轴每隔多少行标注一次数值
+	yLabelEvery = 3
+)
+
+// ANSI 颜色
+const (
+	colorUp    = "\033[32m"
+	colorDown  = "\033[31m"
+	colorReset = "\033[39m"
+	styleBold  = "\033[1m"
+	styleReset = "\033[22m"
+)
+
+// candleGlyphs K 线图字符，按上、下半格的内容（ 0 空白， 1 影线， 2 实体）索引
+var candleGlyphs = [3][3]rune{
+	{' ', '╷', '╻'},
+	{'╵', '│', '╽'},
+	{'╹', '╿', '┃'},
+}
+
+// brailleDots 盲文字符中各点对应的位，按 [y][x] 索引
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// Terminal 将图表渲染为终端中显示的 Unicode 文本，包括标题行、纵轴数值和横轴时间
+//
+// 折线图使用盲文字符绘制，每个字符 2x4 个点；
+// K 线图每根 K 线占一列，上涨为绿色、下跌为红色，数据点多于绘图区宽度时合并相邻的 K 线。
+// width 为图表总宽度（字符数），小于等于 0 时使用 DefaultTerminalWidth ；
+// height 为绘图区高度（行数），小于等于 0 时使用 DefaultTerminalHeight
+func Terminal(c Chart, width, height int) string {
+	if err := c.Complete(); err != nil {
+		return ""
+	}
+	if width <= 0 {
+		width = DefaultTerminalWidth
+	}
+	if height <= 0 {
+		height = DefaultTerminalHeight
+	}
+
+	points := c.Points
+	lo, hi := bounds(c.Type, points)
+	labels := make([]string, height)
+	labelWidth := 0
+	for i := range labels {
+		if i%yLabelEvery != 0 && i != height-1 {
+			continue
+		}
+		labels[i] = formatValue(hi-(float64(i)+0.5)/float64(height)*(hi-lo), hi-lo)
+		labelWidth = max(labelWidth, len(labels[i]))
+	}
+	plotWidth := max(width-labelWidth-2, minPlotWidth)
+
+	// 绘图
+	var (
+		cells [][]string
+		// 各数据点所在的列
+		columns []int
+	)
+	switch c.Type {
+	case TypeCandlestick:
+		points = resample(points, plotWidth)
+		cells, columns = drawCandles(points, lo, hi, plotWidth, height)
+	default:
+		cells, columns = drawLine(points, lo, hi, plotWidth, height)
+	}
+
+	var b strings.Builder
+	b.WriteString(styleBold + Summary(c) + styleReset + "\n")
+	for i, row := range cells {
+		axis := "│"
+		if labels[i] != "" {
+			axis = "┤"
+		}
+		fmt.Fprintf(&b, "%*s %s", labelWidth, labels[i], axis)
+		b.WriteString(strings.Join(row, "") + "\n")
+	}
+
+	// 横轴
+	ticks := xTicks(points, columns, plotWidth)
+	axis := []rune(strings.Repeat("─", plotWidth))
+	timeLine := []rune(strings.Repeat(" ", plotWidth))
+	for _, t := range ticks {
+		axis[t.column] = '┬'
+		copy(timeLine[t.start:], []rune(t.text))
+	}
+	b.WriteString(strings.Repeat(" ", labelWidth+1) + "└" + string(axis) + "\n")
+	b.WriteString(strings.Repeat(" ", labelWidth+2) + strings.TrimRight(string(timeLine), " "))
+	return b.String()
+}
+
+// drawLine 使用盲文字符绘制折线，返回各单元格内容和各数据点所在的列
+func drawLine(points []Point, lo, hi float64, width, height int) ([][]string, []int) {
+	dotsW, dotsH := width*2, height*4
+	dots := make([][]bool, dotsH)
+	for i := range dots {
+		dots[i] = make([]bool, dotsW)
+	}
+	columns := make([]int, len(points))
+	px, py := -1, -1
+	for i, p := range points {
+		x := 0
+		if len(points) > 1 {
+			x = int(math.Round(float64(i) * float64(dotsW-1) / float64(len(points)-1)))
+		}
+		y := scale(p.Close, lo, hi, dotsH)
+		columns[i] = x / 2
+		if px < 0 {
+			dots[y][x] = true
+		} else {
+			drawSegment(dots, px, py, x, y)
+		}
+		px, py = x, y
+	}
+
+	color := colorUp
+	if points[len(points)-1].Close < points[0].Close {
+		color = colorDown
+	}
+	cells := make([][]string, height)
+	for row := range cells {
+		cells[row] = make([]string, width)
+		for col := range cells[row] {
+			r := rune(0x2800)
+			for dy := range 4 {
+				for dx := range 2 {
+					if dots[row*4+dy][col*2+dx] {
+						r |= brailleDots[dy][dx]
+					}
+				}
+			}
+			if r == 0x2800 {
+				cells[row][col] = " "
+			} else {
+				cells[row][col] = color + string(r) + colorReset
+			}
+		}
+	}
+	return cells, columns
+}
+
+// drawSegment 使用 Bresenham 算法在点阵上绘制线段
+func drawSegment(dots [][]bool, x0, y0, x1, y1 int) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+	for {
+		dots[y0][x0] = true
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawCandles 绘制 K 线，每根 K 线占一列并均匀分布，每行分为上下两个半格，返回各单元格内容和各数据点所在的列
+func drawCandles(points []Point, lo, hi float64, width, height int) ([][]string, []int) {
+	cells := make([][]string, height)
+	for row := range cells {
+		cells[row] = make([]string, width)
+		for col := range cells[row] {
+			cells[row][col] = " "
+		}
+	}
+	columns := make([]int, len(points))
+	halves := height * 2
+	for i, p := range points {
+		col := (2*i + 1) * width / (2 * len(points))
+		columns[i] = col
+		state := make([]int, halves)
+		for y := scale(p.High, lo, hi, halves); y <= scale(p.Low, lo, hi, halves); y++ {
+			state[y] = 1
+		}
+		for y := scale(max(p.Open, p.Close), lo, hi, halves); y <= scale(min(p.Open, p.Close), lo, hi, halves); y++ {
+			state[y] = 2
+		}
+		color := colorUp
+		if p.Close < p.Open {
+			color = colorDown
+		}
+		for row := range height {
+			if g := candleGlyphs[state[row*2]][state[row*2+1]]; g != ' ' {
+				cells[row][col] = color + string(g) + colorReset
+			}
+		}
+	}
+	return cells, columns
+}
+
+// tick 横轴刻度
+type tick struct {
+	// 数据点序号
+	index int
+	// 刻度所在列
+	column int
+	// 时间标签的起始列
+	start int
+	// 时间标签
+	text string
+}
+
+// xTicks 横轴刻度，标注首、尾和中间数据点的时间，放不下时省略
+func xTicks(points []Point, columns []int, width int) []tick {
+	newTick := func(i int) tick {
+		text := shortTime(points[i].Time)
+		n := utf8.RuneCountInString(text)
+		start := 0
+		switch i {
+		case 0:
+		case len(points) - 1:
+			start = width - n
+		default:
+			start = min(max(columns[i]-n/2, 0), width-n)
+		}
+		return tick{index: i, column: columns[i], start: start, text: text}
+	}
+	// end 标签结束的列（不含）
+	end := func(t tick) int {
+		return t.start + utf8.RuneCountInString(t.text)
+	}
+
+	first := newTick(0)
+	if end(first) > width {
+		return nil
+	}
+	ret := []tick{first}
+	if len(points) == 1 {
+		return ret
+	}
+	last := newTick(len(points) - 1)
+	if last.start <= end(first) {
+		return ret
+	}
+	if mid := newTick(len(points) / 2); len(points) > 2 && mid.start > end(first) && end(mid) < last.start {
+		ret = append(ret, mid)
+	}
+	return append(ret, last)
+}
+
+// shortTime 缩短时间标签，只有日期的时间保持不变，带时刻的时间省略年份和时区
+func shortTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return t.Format("01-02 15:04")
+}
+
+// scale 将数值映射到 [0, n) 的行或点，最大值对应 0
+func scale(v, lo, hi float64, n int) int {
+	i := int((hi - v) / (hi - lo) * float64(n))
+	return min(max(i, 0), n-1)
+}
+
+// abs 整数绝对值
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// sign 整数符号
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
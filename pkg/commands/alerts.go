@@ -0,0 +1,411 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yhlooo/nfa/pkg/acputil"
+	"github.com/yhlooo/nfa/pkg/agents"
+	"github.com/yhlooo/nfa/pkg/alerts"
+	"github.com/yhlooo/nfa/pkg/channels"
+	"github.com/yhlooo/nfa/pkg/configs"
+	"github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/watch"
+)
+
+// newAlertsCommand 创建 alerts 子命令
+func newAlertsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "alerts",
+		Aliases: []string{"alert"},
+		Short:   i18n.T(MsgCmdShortDescAlerts),
+		Long:    i18n.T(MsgCmdLongDescAlerts),
+	}
+
+	cmd.AddCommand(
+		newAlertsAddCommand(),
+		newAlertsListCommand(),
+		newAlertsRemoveCommand(),
+		newAlertsHistoryCommand(),
+	)
+
+	return cmd
+}
+
+// alertStoreFromContext 获取数据目录下的提醒存储
+func alertStoreFromContext(ctx context.Context) *alerts.Store {
+	dataRoot := filepath.Dir(configs.ConfigPathFromContext(ctx))
+	return alerts.NewStore(filepath.Join(dataRoot, alerts.DirName))
+}
+
+// newAlertPoller 创建检查提醒规则、推送提醒到 chs 的轮询器，使用 runner 总结提醒原因
+func newAlertPoller(
+	ctx context.Context,
+	runner *acputil.SessionRunner,
+	chs map[string]channels.Channel,
+) (*alerts.Poller, error) {
+	cfg := configs.ConfigFromContext(ctx)
+	opts := alerts.PollerOptions{
+		Store:    alertStoreFromContext(ctx),
+		Channels: chs,
+		Options:  cfg.Alerts,
+		Summarize: func(ctx context.Context, prompt string, ruleID string) (string, error) {
+			output, _, err := runner.Run(ctx, prompt, map[string]any{
+				agents.MetaKeyChannel: alerts.ChannelName,
+				agents.MetaKeyUserID:  ruleID,
+			})
+			return output, err
+		},
+	}
+
+	router, err := marketDataRouterFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("init market data providers error: %w", err)
+	}
+	if router != nil {
+		opts.MarketData = router
+	}
+	if wsa := cfg.DataProviders.TencentCloudWSA; wsa != nil {
+		if opts.Search, err = wsa.NewSearchFunc(); err != nil {
+			return nil, fmt.Errorf("init web search error: %w", err)
+		}
+	}
+
+	return alerts.NewPoller(opts)
+}
+
+// AlertsAddOptions alerts add 子命令选项
+type AlertsAddOptions struct {
+	Rule  alerts.Rule
+	Value string
+}
+
+// AddPFlags 将选项绑定到命令行参数
+func (opts *AlertsAddOptions) AddPFlags(fs *pflag.FlagSet) {
+	rule := &opts.Rule
+	fs.StringVar((*string)(&rule.Type), "type", string(rule.Type), i18n.T(MsgAlertsOptsTypeDesc))
+	fs.StringVar(&rule.Symbol, "symbol", rule.Symbol, i18n.T(MsgAlertsOptsSymbolDesc))
+	fs.StringVar(&rule.Watchlist, "watchlist", rule.Watchlist, i18n.T(MsgAlertsOptsWatchlistDesc))
+	fs.StringVar((*string)(&rule.Operator), "op", string(rule.Operator), i18n.T(MsgAlertsOptsOperatorDesc))
+	fs.StringVar(&opts.Value, "value", opts.Value, i18n.T(MsgAlertsOptsValueDesc))
+	fs.StringVar(&rule.Indicator, "indicator", rule.Indicator, i18n.T(MsgAlertsOptsIndicatorDesc))
+	fs.IntVar(&rule.Period, "period", rule.Period, i18n.T(MsgAlertsOptsPeriodDesc))
+	fs.StringVar(&rule.Keyword, "keyword", rule.Keyword, i18n.T(MsgAlertsOptsKeywordDesc))
+	fs.StringVar(&rule.Provider, "provider", rule.Provider, i18n.T(MsgAlertsOptsProviderDesc))
+	fs.BoolVar(&rule.Repeat, "repeat", rule.Repeat, i18n.T(MsgAlertsOptsRepeatDesc))
+	fs.BoolVar(&rule.Summarize, "summarize", rule.Summarize, i18n.T(MsgAlertsOptsSummarizeDesc))
+	fs.StringVar(&rule.Note, "note", rule.Note, i18n.T(MsgAlertsOptsNoteDesc))
+	fs.StringVar(&rule.Delivery.Channel, "channel", rule.Delivery.Channel, i18n.T(MsgAlertsOptsChannelDesc))
+	fs.StringVar(&rule.Delivery.UserID, "user", rule.Delivery.UserID, i18n.T(MsgScheduleOptsUserDesc))
+	fs.StringVar(&rule.Delivery.GroupID, "group", rule.Delivery.GroupID, i18n.T(MsgScheduleOptsGroupDesc))
+	fs.BoolVar(&rule.Disabled, "disabled", rule.Disabled, i18n.T(MsgAlertsOptsDisabledDesc))
+}
+
+// newAlertsAddCommand 创建 alerts add 子命令
+func newAlertsAddCommand() *cobra.Command {
+	opts := AlertsAddOptions{}
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: i18n.T(MsgCmdShortDescAlertsAdd),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			rule := opts.Rule
+			rule.ID = alerts.NewRuleID()
+			rule.CreatedAt = time.Now()
+			if opts.Value != "" {
+				v, err := decimal.NewFromString(opts.Value)
+				if err != nil {
+					return fmt.Errorf("invalid value %q: %w", opts.Value, err)
+				}
+				rule.Value = v
+			}
+			if rule.Symbol != "" {
+				symbol, ok := tools.NormalizeSymbol(rule.Symbol)
+				if !ok {
+					return fmt.Errorf("invalid symbol %q", rule.Symbol)
+				}
+				rule.Symbol = symbol
+			}
+			if err := rule.Validate(); err != nil {
+				return err
+			}
+
+			store := alertStoreFromContext(ctx)
+			if rule.Watchlist != "" {
+				watchlists, err := store.Watchlists()
+				if err != nil {
+					return err
+				}
+				if watchlists.Find("", rule.Watchlist) < 0 {
+					return fmt.Errorf("watchlist %q not found", rule.Watchlist)
+				}
+			}
+			err := store.UpdateRules(func(list *alerts.RuleList) error {
+				list.Rules = append(list.Rules, rule)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(i18n.TContextWithData(ctx, MsgAlertAdded, map[string]any{
+				"ID":   rule.ID,
+				"Rule": rule.String(),
+			}))
+			return nil
+		},
+	}
+
+	opts.AddPFlags(cmd.Flags())
+	_ = cmd.MarkFlagRequired("type")
+
+	return cmd
+}
+
+// alertsListItem alerts list 子命令 JSON 输出项
+type alertsListItem struct {
+	alerts.Rule
+	Status string            `json:"status"`
+	State  *alerts.RuleState `json:"state,omitempty"`
+}
+
+// newAlertsListCommand 创建 alerts list 子命令
+func newAlertsListCommand() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   i18n.T(MsgCmdShortDescAlertsList),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json":
+			default:
+				return fmt.Errorf("invalid output format: %s", outputFormat)
+			}
+			return runAlertsList(cmd.Context(), outputFormat)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output-format", "f", outputFormat, i18n.T(MsgScheduleOptsOutputFormatDesc))
+
+	return cmd
+}
+
+// runAlertsList 列出提醒规则
+func runAlertsList(ctx context.Context, outputFormat string) error {
+	store := alertStoreFromContext(ctx)
+	list, err := store.Rules()
+	if err != nil {
+		return err
+	}
+	state, err := store.State()
+	if err != nil {
+		return err
+	}
+
+	items := make([]alertsListItem, len(list.Rules))
+	for i, rule := range list.Rules {
+		rs := state.Rules[rule.ID]
+		items[i] = alertsListItem{Rule: rule, Status: watch.Status(&rule, rs), State: rs}
+	}
+	if outputFormat == "json" {
+		return outputJSON(items)
+	}
+
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		lastCheck := ""
+		if item.State != nil && !item.State.CheckedAt.IsZero() {
+			lastCheck = formatRunTime(item.State.CheckedAt) + " ✓"
+			if item.State.Error != "" {
+				lastCheck = formatRunTime(item.State.CheckedAt) + " ✗ " + item.State.Error
+			}
+		}
+		rows = append(rows, []string{
+			item.ID,
+			item.Rule.String(),
+			item.Delivery.String(),
+			item.Status,
+			lastCheck,
+		})
+	}
+	return renderTable([]string{
+		"ID",
+		i18n.TContext(ctx, MsgRuleTag),
+		i18n.TContext(ctx, MsgTargetTag),
+		i18n.TContext(ctx, MsgStatusTag),
+		i18n.TContext(ctx, MsgLastCheckTag),
+	}, []tw.Align{tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft}, rows)
+}
+
+// newAlertsRemoveCommand 创建 alerts rm 子命令
+func newAlertsRemoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rm <id>",
+		Aliases: []string{"remove"},
+		Short:   i18n.T(MsgCmdShortDescAlertsRemove),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := watch.DeleteRule(alertStoreFromContext(cmd.Context()), "", args[0])
+			return err
+		},
+	}
+
+	return cmd
+}
+
+// newAlertsHistoryCommand 创建 alerts history 子命令
+func newAlertsHistoryCommand() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: i18n.T(MsgCmdShortDescAlertsHistory),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json":
+			default:
+				return fmt.Errorf("invalid output format: %s", outputFormat)
+			}
+			ctx := cmd.Context()
+			state, err := alertStoreFromContext(ctx).State()
+			if err != nil {
+				return err
+			}
+			if outputFormat == "json" {
+				return outputJSON(state.Events)
+			}
+
+			rows := make([][]string, 0, len(state.Events))
+			for _, e := range state.Events {
+				msg := strings.Join(strings.Fields(e.Message), " ")
+				if e.Error != "" {
+					msg += " ✗ " + e.Error
+				}
+				rows = append(rows, []string{formatRunTime(e.Time), e.RuleID, e.Delivery.String(), msg})
+			}
+			return renderTable([]string{
+				i18n.TContext(ctx, MsgTimeTag),
+				"ID",
+				i18n.TContext(ctx, MsgTargetTag),
+				i18n.TContext(ctx, MsgMessageTag),
+			}, []tw.Align{tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft}, rows)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output-format", "f", outputFormat, i18n.T(MsgScheduleOptsOutputFormatDesc))
+
+	return cmd
+}
+
+// newWatchlistCommand 创建 watchlist 子命令
+func newWatchlistCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "watchlist",
+		Aliases: []string{"wl"},
+		Short:   i18n.T(MsgCmdShortDescWatchlist),
+	}
+
+	cmd.AddCommand(
+		newWatchlistListCommand(),
+		newWatchlistAddCommand(),
+		newWatchlistRemoveCommand(),
+	)
+
+	return cmd
+}
+
+// newWatchlistListCommand 创建 watchlist list 子命令
+func newWatchlistListCommand() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "list [NAME]",
+		Aliases: []string{"ls"},
+		Short:   i18n.T(MsgCmdShortDescWatchlistList),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json":
+			default:
+				return fmt.Errorf("invalid output format: %s", outputFormat)
+			}
+			in := watch.WatchlistInput{}
+			if len(args) > 0 {
+				in.Name = args[0]
+			}
+			return runWatchlist(cmd.Context(), in, outputFormat)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output-format", "f", outputFormat, i18n.T(MsgScheduleOptsOutputFormatDesc))
+
+	return cmd
+}
+
+// newWatchlistAddCommand 创建 watchlist add 子命令
+func newWatchlistAddCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <name> <symbol>...",
+		Short: i18n.T(MsgCmdShortDescWatchlistAdd),
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatchlist(cmd.Context(), watch.WatchlistInput{
+				Action:  "add",
+				Name:    args[0],
+				Symbols: args[1:],
+			}, "")
+		},
+	}
+
+	return cmd
+}
+
+// newWatchlistRemoveCommand 创建 watchlist rm 子命令
+func newWatchlistRemoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rm <name> [symbol]...",
+		Aliases: []string{"remove"},
+		Short:   i18n.T(MsgCmdShortDescWatchlistRemove),
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatchlist(cmd.Context(), watch.WatchlistInput{
+				Action:  "remove",
+				Name:    args[0],
+				Symbols: args[1:],
+			}, "")
+		},
+	}
+
+	return cmd
+}
+
+// runWatchlist 列出或修改自选股列表并输出结果
+func runWatchlist(ctx context.Context, in watch.WatchlistInput, outputFormat string) error {
+	out, err := watch.NewTools(alertStoreFromContext(ctx)).Watchlist(ctx, in)
+	if err != nil {
+		return err
+	}
+	if outputFormat == "json" {
+		return outputJSON(out.Watchlists)
+	}
+
+	rows := make([][]string, 0, len(out.Watchlists))
+	for _, w := range out.Watchlists {
+		rows = append(rows, []string{w.Name, strings.Join(w.Symbols, ", ")})
+	}
+	return renderTable([]string{
+		i18n.TContext(ctx, MsgNameTag),
+		i18n.TContext(ctx, MsgSymbolsTag),
+	}, []tw.Align{tw.AlignLeft, tw.AlignLeft}, rows)
+}
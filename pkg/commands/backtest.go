@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/portfolio"
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/backtest"
+)
+
+// NewBacktestOptions 创建默认 BacktestOptions
+func NewBacktestOptions() BacktestOptions {
+	return BacktestOptions{
+		Trades: backtest.DefaultTrades,
+	}
+}
+
+// BacktestOptions backtest 子命令选项
+type BacktestOptions struct {
+	// K 线数据文件
+	Data string
+	// 起始日期（含）
+	From string
+	// 结束日期（含）
+	To string
+	// 输出最近多少笔交易， 0 表示全部
+	Trades int
+	// 输出格式
+	OutputFormat string
+}
+
+// Validate 校验选项
+func (opts *BacktestOptions) Validate() error {
+	switch opts.OutputFormat {
+	case "", "json":
+	default:
+		return fmt.Errorf("invalid output format: %s", opts.OutputFormat)
+	}
+	if opts.Data == "" {
+		return fmt.Errorf("--data is required")
+	}
+	if opts.Trades < 0 {
+		return fmt.Errorf("--trades must not be negative")
+	}
+	for _, s := range []string{opts.From, opts.To} {
+		if s == "" {
+			continue
+		}
+		if _, err := portfolio.ParseTime(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddPFlags 将选项绑定到命令行参数
+func (opts *BacktestOptions) AddPFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&opts.Data, "data", "d", opts.Data, i18n.T(MsgBacktestOptsDataDesc))
+	fs.StringVar(&opts.From, "from", opts.From, i18n.T(MsgBacktestOptsFromDesc))
+	fs.StringVar(&opts.To, "to", opts.To, i18n.T(MsgBacktestOptsToDesc))
+	fs.IntVar(&opts.Trades, "trades", opts.Trades, i18n.T(MsgBacktestOptsTradesDesc))
+	fs.StringVarP(&opts.OutputFormat, "output-format", "f", opts.OutputFormat, i18n.T(MsgBacktestOptsOutputFormatDesc))
+}
+
+// newBacktestCommand 创建 backtest 子命令
+func newBacktestCommand() *cobra.Command {
+	opts := NewBacktestOptions()
+	cmd := &cobra.Command{
+		Use:   "backtest <strategy-file>",
+		Short: i18n.T(MsgCmdShortDescBacktest),
+		Long:  i18n.T(MsgCmdLongDescBacktest),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runBacktest(cmd.Context(), args[0], opts)
+		},
+	}
+
+	opts.AddPFlags(cmd.Flags())
+
+	return cmd
+}
+
+// runBacktest 执行 backtest 命令
+func runBacktest(ctx context.Context, strategyFile string, opts BacktestOptions) error {
+	strategy, err := backtest.LoadStrategy(strategyFile)
+	if err != nil {
+		return err
+	}
+	raw, err := os.ReadFile(opts.Data)
+	if err != nil {
+		return fmt.Errorf("read data file %q error: %w", opts.Data, err)
+	}
+	bars, err := tools.ParseBars(string(raw))
+	if err != nil {
+		return fmt.Errorf("parse data file %q error: %w", opts.Data, err)
+	}
+	bars, err = filterBars(bars, opts.From, opts.To)
+	if err != nil {
+		return err
+	}
+
+	result, err := backtest.Run(bars, strategy)
+	if err != nil {
+		return err
+	}
+	if opts.Trades > 0 && len(result.Trades) > opts.Trades {
+		result.Trades = result.Trades[len(result.Trades)-opts.Trades:]
+	}
+	result.Metrics = result.Metrics.Round(2)
+
+	if opts.OutputFormat == "json" {
+		return outputJSON(result)
+	}
+
+	fmt.Println(i18n.TContextWithData(ctx, MsgBacktestSummary, map[string]any{
+		"Name": result.Strategy.Name,
+		"Bars": result.Bars,
+		"From": tools.FormatBarTime(result.From),
+		"To":   tools.FormatBarTime(result.To),
+	}))
+	fmt.Println()
+	if err := renderBacktestMetrics(ctx, result.Metrics); err != nil {
+		return err
+	}
+	if len(result.Trades) == 0 {
+		return nil
+	}
+	fmt.Println()
+	return renderBacktestTrades(ctx, result.Trades)
+}
+
+// filterBars 过滤 [from, to] 范围内的 K 线，仅日期时包含当天
+func filterBars(bars []tools.Bar, from, to string) ([]tools.Bar, error) {
+	if from == "" && to == "" {
+		return bars, nil
+	}
+	var start, end time.Time
+	if from != "" {
+		start, _ = portfolio.ParseTime(from)
+	}
+	if to != "" {
+		end, _ = portfolio.ParseTime(to)
+		if len(to) == len(time.DateOnly) {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+
+	var ret []tools.Bar
+	for _, bar := range bars {
+		if (!start.IsZero() && bar.Time.Before(start)) || (!end.IsZero() && !bar.Time.Before(end)) {
+			continue
+		}
+		ret = append(ret, bar)
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no bars between %q and %q", from, to)
+	}
+	return ret, nil
+}
+
+// renderBacktestMetrics 输出回测指标
+func renderBacktestMetrics(ctx context.Context, m backtest.Metrics) error {
+	nullable := func(d decimal.NullDecimal, suffix string) string {
+		if !d.Valid {
+			return ""
+		}
+		return d.Decimal.String() + suffix
+	}
+	rows := [][]string{
+		{i18n.TContext(ctx, MsgBacktestInitialCapital), m.InitialCapital.String()},
+		{i18n.TContext(ctx, MsgBacktestFinalEquity), m.FinalEquity.String()},
+		{i18n.TContext(ctx, MsgBacktestTotalReturn), m.TotalReturn.String() + "%"},
+		{i18n.TContext(ctx, MsgBacktestCAGR), nullable(m.CAGR, "%")},
+		{i18n.TContext(ctx, MsgBacktestSharpe), nullable(m.Sharpe, "")},
+		{i18n.TContext(ctx, MsgBacktestMaxDrawdown), m.MaxDrawdown.String() + "%"},
+		{i18n.TContext(ctx, MsgBacktestTrades), strconv.Itoa(m.Trades)},
+		{i18n.TContext(ctx, MsgBacktestWinRate), nullable(m.WinRate, "%")},
+		{i18n.TContext(ctx, MsgBacktestAvgTradeReturn), nullable(m.AvgTradeReturn, "%")},
+		{i18n.TContext(ctx, MsgBacktestProfitFactor), nullable(m.ProfitFactor, "")},
+		{i18n.TContext(ctx, MsgBacktestExposure), m.Exposure.String() + "%"},
+		{i18n.TContext(ctx, MsgBacktestFees), m.Fees.String()},
+		{i18n.TContext(ctx, MsgBacktestBuyAndHoldReturn), m.BuyAndHoldReturn.String() + "%"},
+	}
+	return renderTable(
+		[]string{i18n.TContext(ctx, MsgMetricTag), i18n.TContext(ctx, MsgValueTag)},
+		[]tw.Align{tw.AlignLeft, tw.AlignRight},
+		rows,
+	)
+}
+
+// renderBacktestTrades 输出交易列表
+func renderBacktestTrades(ctx context.Context, trades []backtest.Trade) error {
+	rows := make([][]string, 0, len(trades))
+	for _, t := range trades {
+		rows = append(rows, []string{
+			tools.FormatBarTime(t.EntryTime),
+			t.EntryPrice.Round(4).String(),
+			tools.FormatBarTime(t.ExitTime),
+			t.ExitPrice.Round(4).String(),
+			t.Quantity.String(),
+			t.PnL.StringFixed(2),
+			t.Return.StringFixed(2) + "%",
+			strconv.Itoa(t.Bars),
+			t.ExitReason,
+		})
+	}
+	return renderTable(
+		[]string{
+			i18n.TContext(ctx, MsgEntryTag),
+			i18n.TContext(ctx, MsgEntryPriceTag),
+			i18n.TContext(ctx, MsgExitTag),
+			i18n.TContext(ctx, MsgExitPriceTag),
+			i18n.TContext(ctx, MsgQuantityTag),
+			i18n.TContext(ctx, MsgProfitTag),
+			i18n.TContext(ctx, MsgReturnTag),
+			i18n.TContext(ctx, MsgBarsTag),
+			i18n.TContext(ctx, MsgExitReasonTag),
+		},
+		[]tw.Align{
+			tw.AlignLeft, tw.AlignRight, tw.AlignLeft, tw.AlignRight, tw.AlignRight,
+			tw.AlignRight, tw.AlignRight, tw.AlignRight, tw.AlignLeft,
+		},
+		rows,
+	)
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"path/filepath"
+
+	"github.com/chromedp/chromedp"
+	"github.com/spf13/cobra"
+
+	"github.com/yhlooo/nfa/pkg/apps/daemon"
+	"github.com/yhlooo/nfa/pkg/configs"
+	"github.com/yhlooo/nfa/pkg/i18n"
+)
+
+// newDaemonCommand 创建 daemon 子命令
+func newDaemonCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: i18n.T(MsgCmdShortDescDaemon),
+		Long:  i18n.T(MsgCmdLongDescDaemon),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			cfg := configs.ConfigFromContext(ctx)
+
+			agent, shutdown, err := newAgent(ctx, filepath.Dir(configs.ConfigPathFromContext(ctx)), cfg.DefaultModels)
+			if err != nil {
+				return err
+			}
+			defer shutdown()
+
+			chs, namedChs := startChannels(ctx)
+			runner := newSessionRunner(agent)
+			poller, err := newAlertPoller(ctx, runner, namedChs)
+			if err != nil {
+				return err
+			}
+			app := daemon.NewDaemon(daemon.Options{
+				Agent:     agent,
+				Channels:  chs,
+				Runner:    runner,
+				Scheduler: newScheduler(ctx, runner, namedChs),
+				Alerts:    poller,
+			})
+			agent.SetClient(app)
+
+			ctx, cancel := chromedp.NewContext(ctx)
+			defer cancel()
+
+			return app.Run(ctx)
+		},
+	}
+
+	return cmd
+}
@@ -49,6 +49,7 @@ var (
 	MsgCmdShortDescModels     = &i18n.Message{ID: "commands.CmdShortDescModels", Other: "Manage LLMs used by the agent"}
 	MsgCmdShortDescModelsList = &i18n.Message{ID: "commands.CmdShortDescModelsList", Other: "List available models"}
 	MsgCmdShortDescModelsAdd  = &i18n.Message{ID: "commands.CmdShortDescModelsAdd", Other: "Add a model provider configuration"}
+	MsgCmdShortDescModelsTest = &i18n.Message{ID: "commands.CmdShortDescModelsTest", Other: "Test health and capabilities of models"}
 
 	MsgModelNameTag    = &i18n.Message{ID: "commands.ModelNameTag", Other: "Name"}
 	MsgReasoningTag    = &i18n.Message{ID: "commands.ReasoningTag", Other: "Reasoning"}
@@ -57,10 +58,36 @@ var (
 
 	MsgScoreTag = &i18n.Message{ID: "commands.ScoreTag", Other: "Score"}
 
+	MsgLatencyTag = &i18n.Message{ID: "commands.LatencyTag", Other: "Latency"}
+	MsgTTFTTag    = &i18n.Message{ID: "commands.TTFTTag", Other: "TTFT"}
+	MsgUsageTag   = &i18n.Message{ID: "commands.UsageTag", Other: "Usage"}
+	MsgBasicTag   = &i18n.Message{ID: "commands.BasicTag", Other: "Basic"}
+	MsgToolsTag   = &i18n.Message{ID: "commands.ToolsTag", Other: "Tools"}
+
+	MsgModelsTestOptsOutputFormatDesc = &i18n.Message{ID: "commands.ModelsTestOptsOutputFormatDesc", Other: "Output format. One of (json)"}
+	MsgModelsTestOptsTimeoutDesc      = &i18n.Message{ID: "commands.ModelsTestOptsTimeoutDesc", Other: "Timeout for testing each model"}
+
 	MsgModelsAddOptAPIKeyDesc  = &i18n.Message{ID: "commands.ModelsAddOptAPIKeyDesc", Other: "API key for the provider"}
 	MsgModelsAddOptBaseURLDesc = &i18n.Message{ID: "commands.ModelsAddOptBaseURLDesc", Other: "Base URL for the provider API"}
 	MsgModelsAddOptNameDesc    = &i18n.Message{ID: "commands.ModelsAddOptNameDesc", Other: "Display name for the provider (required for openai-compatible)"}
 
+	MsgCmdShortDescUsage = &i18n.Message{ID: "commands.CmdShortDescUsage", Other: "Report model usage and cost from the usage ledger"}
+
+	MsgUsageOptsSinceDesc        = &i18n.Message{ID: "commands.UsageOptsSinceDesc", Other: "Only include usage since this time (YYYY-MM-DD or RFC3339)"}
+	MsgUsageOptsUntilDesc        = &i18n.Message{ID: "commands.UsageOptsUntilDesc", Other: "Only include usage before this time (YYYY-MM-DD includes the whole day, or RFC3339)"}
+	MsgUsageOptsGroupByDesc      = &i18n.Message{ID: "commands.UsageOptsGroupByDesc", Other: "Comma-separated dimensions to group by. Any of (model, channel, user, session, day, month)"}
+	MsgUsageOptsCurrencyDesc     = &i18n.Message{ID: "commands.UsageOptsCurrencyDesc", Other: "Currency to convert costs into (defaults to pricing.currency or fx.baseCurrency in config)"}
+	MsgUsageOptsOutputFormatDesc = &i18n.Message{ID: "commands.UsageOptsOutputFormatDesc", Other: "Output format. One of (csv, json)"}
+
+	MsgCallsTag            = &i18n.Message{ID: "commands.CallsTag", Other: "Calls"}
+	MsgInputTokensTag      = &i18n.Message{ID: "commands.InputTokensTag", Other: "Input"}
+	MsgOutputTokensTag     = &i18n.Message{ID: "commands.OutputTokensTag", Other: "Output"}
+	MsgCacheReadTokensTag  = &i18n.Message{ID: "commands.CacheReadTokensTag", Other: "Cache Read"}
+	MsgCacheWriteTokensTag = &i18n.Message{ID: "commands.CacheWriteTokensTag", Other: "Cache Write"}
+	MsgReasoningTokensTag  = &i18n.Message{ID: "commands.ReasoningTokensTag", Other: "Reasoning"}
+	MsgCostTag             = &i18n.Message{ID: "commands.CostTag", Other: "Cost"}
+	MsgCurrencyTag         = &i18n.Message{ID: "commands.CurrencyTag", Other: "Currency"}
+
 	MsgCmdShortDescOtter = &i18n.Message{ID: "commands.CmdShortDescOtter", Other: "Print Otter image"}
 
 	MsgOtterOptsColorDesc      = &i18n.Message{ID: "commands.OtterOptsColorDesc", Other: "Print with color"}
@@ -69,4 +96,160 @@ var (
 
 	MsgCmdShortDescVersion         = &i18n.Message{ID: "commands.CmdShortDescVersion", Other: "Print the version information"}
 	MsgVersionOptsOutputFormatDesc = &i18n.Message{ID: "commands.VersionOptsOutputFormatDesc", Other: "Output format. One of (json)"}
+
+	MsgCmdShortDescPortfolio                = &i18n.Message{ID: "commands.CmdShortDescPortfolio", Other: "Manage the local portfolio used by the agent"}
+	MsgCmdShortDescPortfolioShow            = &i18n.Message{ID: "commands.CmdShortDescPortfolioShow", Other: "Show holdings, cash and P&L"}
+	MsgCmdShortDescPortfolioExposure        = &i18n.Message{ID: "commands.CmdShortDescPortfolioExposure", Other: "Show exposure grouped by sector, market, currency, asset class, account or symbol"}
+	MsgCmdShortDescPortfolioImport          = &i18n.Message{ID: "commands.CmdShortDescPortfolioImport", Other: "Import positions or transactions from a CSV file"}
+	MsgCmdLongDescPortfolioImport           = &i18n.Message{ID: "commands.CmdLongDescPortfolioImport", Other: "Import positions or transactions from a CSV file with a header row.\n\npositions: replaces all positions of the accounts in the file. Columns: symbol, quantity, cost (total) or avg cost, and optionally account, name, currency, price, market, sector, asset class.\n\ntransactions: applies trades to positions and cash using average cost. Columns: date, type (buy, sell, dividend, interest, fee, deposit, withdrawal), and optionally account, symbol, quantity, price, fee, amount, currency, note. Transactions already imported are skipped."}
+	MsgCmdShortDescPortfolioAccounts        = &i18n.Message{ID: "commands.CmdShortDescPortfolioAccounts", Other: "List portfolio accounts"}
+	MsgCmdShortDescPortfolioAccountsAdd     = &i18n.Message{ID: "commands.CmdShortDescPortfolioAccountsAdd", Other: "Add or update a portfolio account"}
+	MsgCmdShortDescPortfolioAccountsRemove  = &i18n.Message{ID: "commands.CmdShortDescPortfolioAccountsRemove", Other: "Remove a portfolio account with its positions, cash and transactions"}
+	MsgCmdShortDescPortfolioTransactions    = &i18n.Message{ID: "commands.CmdShortDescPortfolioTransactions", Other: "List imported transactions"}
+	MsgCmdShortDescPortfolioTag             = &i18n.Message{ID: "commands.CmdShortDescPortfolioTag", Other: "Set name, sector, market, asset class or currency of a security"}
+	MsgCmdShortDescPortfolioPrice           = &i18n.Message{ID: "commands.CmdShortDescPortfolioPrice", Other: "Set the latest price of a security"}
+	MsgCmdShortDescPortfolioCash            = &i18n.Message{ID: "commands.CmdShortDescPortfolioCash", Other: "Set the cash balance of an account"}
+	MsgPortfolioOptsAccountDesc             = &i18n.Message{ID: "commands.PortfolioOptsAccountDesc", Other: "Only include the specified account"}
+	MsgPortfolioOptsCurrencyDesc            = &i18n.Message{ID: "commands.PortfolioOptsCurrencyDesc", Other: "Base currency to convert totals into (defaults to portfolio.baseCurrency or fx.baseCurrency in config)"}
+	MsgPortfolioOptsOutputFormatDesc        = &i18n.Message{ID: "commands.PortfolioOptsOutputFormatDesc", Other: "Output format. One of (json)"}
+	MsgPortfolioExposureOptsByDesc          = &i18n.Message{ID: "commands.PortfolioExposureOptsByDesc", Other: "Dimension to group by. One of (sector, market, currency, assetClass, account, symbol)"}
+	MsgPortfolioExposureOptsIncludeCashDesc = &i18n.Message{ID: "commands.PortfolioExposureOptsIncludeCashDesc", Other: "Include cash in exposure"}
+	MsgPortfolioImportOptsAccountDesc       = &i18n.Message{ID: "commands.PortfolioImportOptsAccountDesc", Other: "Account for rows without an account column"}
+	MsgPortfolioAccountsOptsNameDesc        = &i18n.Message{ID: "commands.PortfolioAccountsOptsNameDesc", Other: "Display name of the account"}
+	MsgPortfolioAccountsOptsBrokerDesc      = &i18n.Message{ID: "commands.PortfolioAccountsOptsBrokerDesc", Other: "Broker of the account"}
+	MsgPortfolioAccountsOptsCurrencyDesc    = &i18n.Message{ID: "commands.PortfolioAccountsOptsCurrencyDesc", Other: "Default currency of the account"}
+	MsgPortfolioTransactionsOptsSymbolDesc  = &i18n.Message{ID: "commands.PortfolioTransactionsOptsSymbolDesc", Other: "Only include transactions of the specified symbol"}
+	MsgPortfolioTagOptsNameDesc             = &i18n.Message{ID: "commands.PortfolioTagOptsNameDesc", Other: "Name of the security"}
+	MsgPortfolioTagOptsSectorDesc           = &i18n.Message{ID: "commands.PortfolioTagOptsSectorDesc", Other: "Sector of the security, e.g. Semiconductors"}
+	MsgPortfolioTagOptsMarketDesc           = &i18n.Message{ID: "commands.PortfolioTagOptsMarketDesc", Other: "Market of the security, e.g. US, HK, CN"}
+	MsgPortfolioTagOptsAssetClassDesc       = &i18n.Message{ID: "commands.PortfolioTagOptsAssetClassDesc", Other: "Asset class of the security, e.g. stock, etf, bond, fund"}
+	MsgPortfolioTagOptsCurrencyDesc         = &i18n.Message{ID: "commands.PortfolioTagOptsCurrencyDesc", Other: "Trading currency of the security"}
+	MsgPortfolioSummary                     = &i18n.Message{ID: "commands.PortfolioSummary", Other: "Market value: {{ .MarketValue }} {{ .Currency }}  Cash: {{ .Cash }} {{ .Currency }}  Total: {{ .TotalValue }} {{ .Currency }}\nUnrealized P&L: {{ .UnrealizedPnL }} {{ .Currency }}  Realized P&L: {{ .RealizedPnL }} {{ .Currency }}  Income: {{ .Income }} {{ .Currency }}"}
+	MsgPortfolioMissingPrices               = &i18n.Message{ID: "commands.PortfolioMissingPrices", Other: "No price for {{ .Symbols }}, valued at cost. Set prices with `nfa portfolio price`."}
+	MsgPortfolioUnconverted                 = &i18n.Message{ID: "commands.PortfolioUnconverted", Other: "No exchange rate from {{ .Currencies }} to {{ .Currency }}, excluded from totals. Configure exchange rate sources in fx.sources."}
+	MsgPortfolioImportedPositions           = &i18n.Message{ID: "commands.PortfolioImportedPositions", Other: "Imported {{ .Count }} positions."}
+	MsgPortfolioImportedTransactions        = &i18n.Message{ID: "commands.PortfolioImportedTransactions", Other: "Imported {{ .Count }} transactions, skipped {{ .Skipped }} duplicates."}
+	MsgAccountTag                           = &i18n.Message{ID: "commands.AccountTag", Other: "Account"}
+	MsgSymbolTag                            = &i18n.Message{ID: "commands.SymbolTag", Other: "Symbol"}
+	MsgNameTag                              = &i18n.Message{ID: "commands.NameTag", Other: "Name"}
+	MsgQuantityTag                          = &i18n.Message{ID: "commands.QuantityTag", Other: "Quantity"}
+	MsgAvgCostTag                           = &i18n.Message{ID: "commands.AvgCostTag", Other: "Avg Cost"}
+	MsgPriceTag                             = &i18n.Message{ID: "commands.PriceTag", Other: "Price"}
+	MsgMarketValueTag                       = &i18n.Message{ID: "commands.MarketValueTag", Other: "Market Value"}
+	MsgUnrealizedPnLTag                     = &i18n.Message{ID: "commands.UnrealizedPnLTag", Other: "Unrealized Gain"}
+	MsgWeightTag                            = &i18n.Message{ID: "commands.WeightTag", Other: "Weight"}
+	MsgCashTag                              = &i18n.Message{ID: "commands.CashTag", Other: "Cash"}
+	MsgBrokerTag                            = &i18n.Message{ID: "commands.BrokerTag", Other: "Broker"}
+	MsgDateTag                              = &i18n.Message{ID: "commands.DateTag", Other: "Date"}
+	MsgTypeTag                              = &i18n.Message{ID: "commands.TypeTag", Other: "Type"}
+	MsgFeeTag                               = &i18n.Message{ID: "commands.FeeTag", Other: "Fee"}
+	MsgAmountTag                            = &i18n.Message{ID: "commands.AmountTag", Other: "Amount"}
+	MsgNoteTag                              = &i18n.Message{ID: "commands.NoteTag", Other: "Note"}
+
+	MsgCmdShortDescBacktest          = &i18n.Message{ID: "commands.CmdShortDescBacktest", Other: "Backtest a rule-based trading strategy over historical OHLCV data"}
+	MsgCmdLongDescBacktest           = &i18n.Message{ID: "commands.CmdLongDescBacktest", Other: "Backtest a rule-based long-only trading strategy over historical OHLCV data.\n\nThe strategy file is JSON or YAML, e.g.\n\n  {\"name\": \"rsi-reversal\", \"entry\": [\"rsi:14 < 30\"], \"exit\": [\"rsi:14 > 70\"], \"stopLoss\": 8, \"commissionBps\": 3, \"slippageBps\": 5}\n\nThe data file is a CSV with time, open, high, low, close and volume columns, or JSON in any format accepted by the Indicators tool. Strategies and data of backtests run by the agent are saved under the backtests directory of the data root."}
+	MsgBacktestOptsDataDesc          = &i18n.Message{ID: "commands.BacktestOptsDataDesc", Other: "OHLCV data file (CSV or JSON)"}
+	MsgBacktestOptsFromDesc          = &i18n.Message{ID: "commands.BacktestOptsFromDesc", Other: "Only use bars since the specified date (inclusive)"}
+	MsgBacktestOptsToDesc            = &i18n.Message{ID: "commands.BacktestOptsToDesc", Other: "Only use bars until the specified date (inclusive)"}
+	MsgBacktestOptsTradesDesc        = &i18n.Message{ID: "commands.BacktestOptsTradesDesc", Other: "Number of most recent trades to show, 0 for all"}
+	MsgBacktestOptsOutputFormatDesc  = &i18n.Message{ID: "commands.BacktestOptsOutputFormatDesc", Other: "Output format. One of (json)"}
+	MsgBacktestSummary               = &i18n.Message{ID: "commands.BacktestSummary", Other: "Strategy: {{ .Name }}  Bars: {{ .Bars }} ({{ .From }} ~ {{ .To }})"}
+	MsgBacktestInitialCapital        = &i18n.Message{ID: "commands.BacktestInitialCapital", Other: "Initial capital"}
+	MsgBacktestFinalEquity           = &i18n.Message{ID: "commands.BacktestFinalEquity", Other: "Final equity"}
+	MsgBacktestTotalReturn           = &i18n.Message{ID: "commands.BacktestTotalReturn", Other: "Total return"}
+	MsgBacktestCAGR                  = &i18n.Message{ID: "commands.BacktestCAGR", Other: "CAGR"}
+	MsgBacktestSharpe                = &i18n.Message{ID: "commands.BacktestSharpe", Other: "Sharpe ratio"}
+	MsgBacktestMaxDrawdown           = &i18n.Message{ID: "commands.BacktestMaxDrawdown", Other: "Max drawdown"}
+	MsgBacktestTrades                = &i18n.Message{ID: "commands.BacktestTrades", Other: "Trades"}
+	MsgBacktestWinRate               = &i18n.Message{ID: "commands.BacktestWinRate", Other: "Win rate"}
+	MsgBacktestAvgTradeReturn        = &i18n.Message{ID: "commands.BacktestAvgTradeReturn", Other: "Avg trade return"}
+	MsgBacktestProfitFactor          = &i18n.Message{ID: "commands.BacktestProfitFactor", Other: "Profit factor"}
+	MsgBacktestExposure              = &i18n.Message{ID: "commands.BacktestExposure", Other: "Exposure"}
+	MsgBacktestFees                  = &i18n.Message{ID: "commands.BacktestFees", Other: "Fees"}
+	MsgBacktestBuyAndHoldReturn      = &i18n.Message{ID: "commands.BacktestBuyAndHoldReturn", Other: "Buy and hold return"}
+	MsgMetricTag                     = &i18n.Message{ID: "commands.MetricTag", Other: "Metric"}
+	MsgValueTag                      = &i18n.Message{ID: "commands.ValueTag", Other: "Value"}
+	MsgEntryTag                      = &i18n.Message{ID: "commands.EntryTag", Other: "Entry"}
+	MsgEntryPriceTag                 = &i18n.Message{ID: "commands.EntryPriceTag", Other: "Entry Price"}
+	MsgExitTag                       = &i18n.Message{ID: "commands.ExitTag", Other: "Exit"}
+	MsgExitPriceTag                  = &i18n.Message{ID: "commands.ExitPriceTag", Other: "Exit Price"}
+	MsgProfitTag                     = &i18n.Message{ID: "commands.ProfitTag", Other: "Profit"}
+	MsgReturnTag                     = &i18n.Message{ID: "commands.ReturnTag", Other: "Return"}
+	MsgBarsTag                       = &i18n.Message{ID: "commands.BarsTag", Other: "Bars"}
+	MsgExitReasonTag                 = &i18n.Message{ID: "commands.ExitReasonTag", Other: "Exit Reason"}
+	MsgCmdShortDescSymbols           = &i18n.Message{ID: "commands.CmdShortDescSymbols", Other: "Resolve symbols and manage the symbol master"}
+	MsgCmdShortDescSymbolsResolve    = &i18n.Message{ID: "commands.CmdShortDescSymbolsResolve", Other: "Resolve a name, ticker or ISIN to the canonical symbol"}
+	MsgCmdShortDescSymbolsRefresh    = &i18n.Message{ID: "commands.CmdShortDescSymbolsRefresh", Other: "Refresh the symbol master from market data providers"}
+	MsgCmdLongDescSymbolsRefresh     = &i18n.Message{ID: "commands.CmdLongDescSymbolsRefresh", Other: "Refresh the symbol master from the market data providers configured in dataProviders.marketData that can list symbols (alphaVantage and local). Listed symbols are saved under the symbols directory of the data root and merged with the builtin symbol master."}
+	MsgSymbolsOptsOutputFormatDesc   = &i18n.Message{ID: "commands.SymbolsOptsOutputFormatDesc", Other: "Output format. One of (json)"}
+	MsgSymbolsOptsProviderDesc       = &i18n.Message{ID: "commands.SymbolsOptsProviderDesc", Other: "Only use the market data provider with the specified name"}
+	MsgSymbolsCandidates             = &i18n.Message{ID: "commands.SymbolsCandidates", Other: "Other candidates:"}
+	MsgSymbolsRefreshed              = &i18n.Message{ID: "commands.SymbolsRefreshed", Other: "Listed {{ .Total }} symbols, {{ .Added }} new symbols added to the symbol master"}
+	MsgFieldTag                      = &i18n.Message{ID: "commands.FieldTag", Other: "Field"}
+	MsgAliasesTag                    = &i18n.Message{ID: "commands.AliasesTag", Other: "Aliases"}
+	MsgISINTag                       = &i18n.Message{ID: "commands.ISINTag", Other: "ISIN"}
+	MsgExchangeTag                   = &i18n.Message{ID: "commands.ExchangeTag", Other: "Exchange"}
+	MsgMarketTag                     = &i18n.Message{ID: "commands.MarketTag", Other: "Market"}
+	MsgCalendarTag                   = &i18n.Message{ID: "commands.CalendarTag", Other: "Trading Calendar"}
+	MsgSourceTag                     = &i18n.Message{ID: "commands.SourceTag", Other: "Source"}
+	MsgCmdShortDescSchedule          = &i18n.Message{ID: "commands.CmdShortDescSchedule", Other: "Manage scheduled prompts and briefings"}
+	MsgCmdLongDescSchedule           = &i18n.Message{ID: "commands.CmdLongDescSchedule", Other: "Manage scheduled jobs. A job runs a prompt or skill in a dedicated session at the times given by a cron expression (minute hour day month weekday), optionally only on trading days of a market, and saves the answer to a file and optionally pushes it to a channel user or group.\n\nJobs run inside `nfa daemon` or the interactive chat UI. When several processes share the same data root only one of them runs jobs. Runs missed while no process was running are handled by the catch-up policy."}
+	MsgCmdShortDescScheduleAdd       = &i18n.Message{ID: "commands.CmdShortDescScheduleAdd", Other: "Add a scheduled job"}
+	MsgCmdShortDescScheduleList      = &i18n.Message{ID: "commands.CmdShortDescScheduleList", Other: "List scheduled jobs"}
+	MsgCmdShortDescScheduleRemove    = &i18n.Message{ID: "commands.CmdShortDescScheduleRemove", Other: "Remove a scheduled job"}
+	MsgCmdShortDescScheduleRunNow    = &i18n.Message{ID: "commands.CmdShortDescScheduleRunNow", Other: "Run a scheduled job immediately in this process"}
+	MsgCmdShortDescDaemon            = &i18n.Message{ID: "commands.CmdShortDescDaemon", Other: "Run headless, serving channels, scheduled jobs and alerts"}
+	MsgCmdLongDescDaemon             = &i18n.Message{ID: "commands.CmdLongDescDaemon", Other: "Run without the chat UI. Messages from the channels configured in channels are answered, each channel user in a separate session, scheduled jobs managed by `nfa schedule` are run and alert rules managed by `nfa alerts` are checked. Stop with Ctrl+C or SIGTERM."}
+	MsgScheduleOptsNameDesc          = &i18n.Message{ID: "commands.ScheduleOptsNameDesc", Other: "Name of the job, can be used instead of the ID"}
+	MsgScheduleOptsCronDesc          = &i18n.Message{ID: "commands.ScheduleOptsCronDesc", Other: "Cron expression with 5 fields (minute hour day month weekday), e.g. \"30 8 * * *\", or @daily, @hourly, @weekly, @monthly"}
+	MsgScheduleOptsTimeZoneDesc      = &i18n.Message{ID: "commands.ScheduleOptsTimeZoneDesc", Other: "Time zone of the cron expression, e.g. Asia/Shanghai (defaults to the time zone of --market, or local time)"}
+	MsgScheduleOptsMarketDesc        = &i18n.Message{ID: "commands.ScheduleOptsMarketDesc", Other: "Only run on trading days of this market, e.g. SSE, HKEX, NYSE"}
+	MsgScheduleOptsCatchUpDesc       = &i18n.Message{ID: "commands.ScheduleOptsCatchUpDesc", Other: "What to do with runs missed while no process was running. One of (skip, once, all), defaults to once"}
+	MsgScheduleOptsCatchUpWindowDesc = &i18n.Message{ID: "commands.ScheduleOptsCatchUpWindowDesc", Other: "Only catch up runs missed within this duration, e.g. 30m (defaults to 2h)"}
+	MsgScheduleOptsSkillDesc         = &i18n.Message{ID: "commands.ScheduleOptsSkillDesc", Other: "Run this skill, with PROMPT as its arguments"}
+	MsgScheduleOptsChannelDesc       = &i18n.Message{ID: "commands.ScheduleOptsChannelDesc", Other: "Also push the result to this channel. One of (wecomAIBot, yuanbaoBot)"}
+	MsgScheduleOptsUserDesc          = &i18n.Message{ID: "commands.ScheduleOptsUserDesc", Other: "User ID in the channel to push to"}
+	MsgScheduleOptsGroupDesc         = &i18n.Message{ID: "commands.ScheduleOptsGroupDesc", Other: "Group ID in the channel to push to"}
+	MsgScheduleOptsFileDesc          = &i18n.Message{ID: "commands.ScheduleOptsFileDesc", Other: "Result file path, supports {job}, {date} and {time} placeholders; relative paths are relative to the schedules directory of the data root (defaults to runs/{job}/{date}_{time}.md)"}
+	MsgScheduleOptsDisabledDesc      = &i18n.Message{ID: "commands.ScheduleOptsDisabledDesc", Other: "Add the job paused"}
+	MsgScheduleOptsOutputFormatDesc  = &i18n.Message{ID: "commands.ScheduleOptsOutputFormatDesc", Other: "Output format. One of (json)"}
+	MsgScheduleAdded                 = &i18n.Message{ID: "commands.ScheduleAdded", Other: "Added scheduled job {{ .ID }}, next run at {{ .Next }}"}
+	MsgScheduleDisabled              = &i18n.Message{ID: "commands.ScheduleDisabled", Other: "paused"}
+	MsgScheduleRunSaved              = &i18n.Message{ID: "commands.ScheduleRunSaved", Other: "Result saved to {{ .File }}"}
+	MsgScheduleRunSession            = &i18n.Message{ID: "commands.ScheduleRunSession", Other: "Continue the conversation with: nfa --resume {{ .SessionID }}"}
+	MsgNextRunTag                    = &i18n.Message{ID: "commands.NextRunTag", Other: "Next Run"}
+	MsgTargetTag                     = &i18n.Message{ID: "commands.TargetTag", Other: "Target"}
+	MsgLastRunTag                    = &i18n.Message{ID: "commands.LastRunTag", Other: "Last Run"}
+
+	MsgCmdShortDescAlerts          = &i18n.Message{ID: "commands.CmdShortDescAlerts", Other: "Manage price and news alerts"}
+	MsgCmdLongDescAlerts           = &i18n.Message{ID: "commands.CmdLongDescAlerts", Other: "Manage alert rules. A rule fires when a price crosses a level, the daily change reaches a percentage, volume spikes above its recent average, a technical indicator crosses a value, or a keyword gets new web search results. Market rules are checked during trading hours of the symbol's market.\n\nRules are checked inside `nfa daemon` or the interactive chat UI, and alerts are pushed to a channel user or group and shown in the chat UI. Alerts can also be created by asking the agent, e.g. \"tell me if NVDA drops below 100\"."}
+	MsgCmdShortDescAlertsAdd       = &i18n.Message{ID: "commands.CmdShortDescAlertsAdd", Other: "Add an alert rule"}
+	MsgCmdShortDescAlertsList      = &i18n.Message{ID: "commands.CmdShortDescAlertsList", Other: "List alert rules"}
+	MsgCmdShortDescAlertsRemove    = &i18n.Message{ID: "commands.CmdShortDescAlertsRemove", Other: "Remove an alert rule"}
+	MsgCmdShortDescAlertsHistory   = &i18n.Message{ID: "commands.CmdShortDescAlertsHistory", Other: "Show recently fired alerts"}
+	MsgCmdShortDescWatchlist       = &i18n.Message{ID: "commands.CmdShortDescWatchlist", Other: "Manage watchlists"}
+	MsgCmdShortDescWatchlistList   = &i18n.Message{ID: "commands.CmdShortDescWatchlistList", Other: "List watchlists"}
+	MsgCmdShortDescWatchlistAdd    = &i18n.Message{ID: "commands.CmdShortDescWatchlistAdd", Other: "Add symbols to a watchlist, creating it if needed"}
+	MsgCmdShortDescWatchlistRemove = &i18n.Message{ID: "commands.CmdShortDescWatchlistRemove", Other: "Remove symbols from a watchlist, or the whole watchlist"}
+	MsgAlertsOptsTypeDesc          = &i18n.Message{ID: "commands.AlertsOptsTypeDesc", Other: "Rule type. One of (price, change, volume, indicator, news)"}
+	MsgAlertsOptsSymbolDesc        = &i18n.Message{ID: "commands.AlertsOptsSymbolDesc", Other: "Symbol to watch"}
+	MsgAlertsOptsWatchlistDesc     = &i18n.Message{ID: "commands.AlertsOptsWatchlistDesc", Other: "Watch every symbol in this watchlist"}
+	MsgAlertsOptsOperatorDesc      = &i18n.Message{ID: "commands.AlertsOptsOperatorDesc", Other: "Direction of the condition. One of (above, below); change rules without it fire on moves in either direction"}
+	MsgAlertsOptsValueDesc         = &i18n.Message{ID: "commands.AlertsOptsValueDesc", Other: "Threshold: price level, percent change, volume multiple or indicator value"}
+	MsgAlertsOptsIndicatorDesc     = &i18n.Message{ID: "commands.AlertsOptsIndicatorDesc", Other: "Technical indicator, e.g. rsi:14, sma:200, \"macd:12,26,9 hist\"; for price rules the price is compared with it"}
+	MsgAlertsOptsPeriodDesc        = &i18n.Message{ID: "commands.AlertsOptsPeriodDesc", Other: "Days of average volume for volume rules (defaults to 20)"}
+	MsgAlertsOptsKeywordDesc       = &i18n.Message{ID: "commands.AlertsOptsKeywordDesc", Other: "Search keyword for news rules"}
+	MsgAlertsOptsProviderDesc      = &i18n.Message{ID: "commands.AlertsOptsProviderDesc", Other: "Market data provider to use (defaults to trying the configured providers in order)"}
+	MsgAlertsOptsRepeatDesc        = &i18n.Message{ID: "commands.AlertsOptsRepeatDesc", Other: "Fire again each time the condition becomes true again, instead of only once"}
+	MsgAlertsOptsSummarizeDesc     = &i18n.Message{ID: "commands.AlertsOptsSummarizeDesc", Other: "Ask the model to explain why the alert fired"}
+	MsgAlertsOptsNoteDesc          = &i18n.Message{ID: "commands.AlertsOptsNoteDesc", Other: "Note sent with the alert"}
+	MsgAlertsOptsChannelDesc       = &i18n.Message{ID: "commands.AlertsOptsChannelDesc", Other: "Push alerts to this channel. One of (wecomAIBot, yuanbaoBot)"}
+	MsgAlertsOptsDisabledDesc      = &i18n.Message{ID: "commands.AlertsOptsDisabledDesc", Other: "Add the rule paused"}
+	MsgAlertAdded                  = &i18n.Message{ID: "commands.AlertAdded", Other: "Added alert rule {{ .ID }}: {{ .Rule }}"}
+	MsgRuleTag                     = &i18n.Message{ID: "commands.RuleTag", Other: "Rule"}
+	MsgStatusTag                   = &i18n.Message{ID: "commands.StatusTag", Other: "Status"}
+	MsgLastCheckTag                = &i18n.Message{ID: "commands.LastCheckTag", Other: "Last Check"}
+	MsgTimeTag                     = &i18n.Message{ID: "commands.TimeTag", Other: "Time"}
+	MsgMessageTag                  = &i18n.Message{ID: "commands.MessageTag", Other: "Message"}
+	MsgSymbolsTag                  = &i18n.Message{ID: "commands.SymbolsTag", Other: "Symbols"}
 )
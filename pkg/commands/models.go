@@ -31,6 +31,7 @@ func newModelsCommand() *cobra.Command {
 	cmd.AddCommand(
 		newModelsListCommand(),
 		newModelsAddCommand(),
+		newModelsTestCommand(),
 	)
 
 	return cmd
@@ -173,8 +174,12 @@ func newModelsAddCommand() *cobra.Command {
 
 // runModelsAdd 执行 models add 命令
 func runModelsAdd(ctx context.Context, providerName string, opts ModelsAddOptions) error {
-	cfg := configs.ConfigFromContext(ctx)
+	// 重新读取原始配置，避免将已解析的密钥写回配置文件
 	cfgPath := configs.ConfigPathFromContext(ctx)
+	cfg, err := configs.LoadConfig(cfgPath)
+	if err != nil {
+		return fmt.Errorf("load config %q error: %w", cfgPath, err)
+	}
 
 	// 查找供应商是否已经配置
 	existingIdx := slices.IndexFunc(cfg.ModelProviders, func(p models.ModelProvider) bool {
@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yhlooo/nfa/pkg/agents"
+	"github.com/yhlooo/nfa/pkg/configs"
+	"github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/models"
+)
+
+// NewModelsTestOptions 创建默认 ModelsTestOptions
+func NewModelsTestOptions() ModelsTestOptions {
+	return ModelsTestOptions{
+		OutputFormat: "",
+		Timeout:      2 * time.Minute,
+	}
+}
+
+// ModelsTestOptions models test 子命令选项
+type ModelsTestOptions struct {
+	// 输出格式
+	OutputFormat string
+	// 单个模型探测超时时间
+	Timeout time.Duration
+}
+
+// Validate 校验选项
+func (opts *ModelsTestOptions) Validate() error {
+	switch opts.OutputFormat {
+	case "", "json":
+	default:
+		return fmt.Errorf("invalid output format: %s", opts.OutputFormat)
+	}
+	return nil
+}
+
+// AddPFlags 将选项绑定到命令行参数
+func (opts *ModelsTestOptions) AddPFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&opts.OutputFormat, "output-format", "f", opts.OutputFormat, i18n.T(MsgModelsTestOptsOutputFormatDesc))
+	fs.DurationVar(&opts.Timeout, "timeout", opts.Timeout, i18n.T(MsgModelsTestOptsTimeoutDesc))
+}
+
+// newModelsTestCommand 创建 models test 子命令
+func newModelsTestCommand() *cobra.Command {
+	opts := NewModelsTestOptions()
+	cmd := &cobra.Command{
+		Use:   "test [MODEL...]",
+		Short: i18n.T(MsgCmdShortDescModelsTest),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runModelsTest(cmd.Context(), args, opts)
+		},
+	}
+
+	opts.AddPFlags(cmd.Flags())
+
+	return cmd
+}
+
+// runModelsTest 执行 models test 命令
+func runModelsTest(ctx context.Context, names []string, opts ModelsTestOptions) error {
+	cfg := configs.ConfigFromContext(ctx)
+	logger := logr.FromContextOrDiscard(ctx)
+
+	agent := agents.NewNFA(agents.Options{
+		Logger:         logger,
+		ModelProviders: cfg.ModelProviders,
+	})
+	agent.InitGenkit(ctx)
+
+	// 筛选待测模型
+	var targets []models.ModelConfig
+	for _, m := range agent.AvailableModels() {
+		if len(names) == 0 || slices.Contains(names, m.Name) {
+			targets = append(targets, m)
+		}
+	}
+	for _, name := range names {
+		if !slices.ContainsFunc(targets, func(m models.ModelConfig) bool { return m.Name == name }) {
+			return fmt.Errorf("model %q not found", name)
+		}
+	}
+
+	prober := models.NewProber(agent.Genkit())
+	results := make([]models.ProbeResult, 0, len(targets))
+	failed := 0
+	for _, m := range targets {
+		logger.Info(fmt.Sprintf("probing model: %s", m.Name))
+		probeCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		result := prober.Probe(probeCtx, m)
+		cancel()
+		if !result.OK {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	switch opts.OutputFormat {
+	case "json":
+		raw, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(raw))
+	default:
+		if err := outputProbeResults(ctx, results); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d models failed the test", failed, len(results))
+	}
+	return nil
+}
+
+// outputProbeResults 以表格形式输出探测结果
+func outputProbeResults(ctx context.Context, results []models.ProbeResult) error {
+	t := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithHeader([]string{
+			i18n.TContext(ctx, MsgModelNameTag),
+			i18n.TContext(ctx, MsgLatencyTag),
+			i18n.TContext(ctx, MsgTTFTTag),
+			i18n.TContext(ctx, MsgUsageTag),
+			i18n.TContext(ctx, MsgBasicTag),
+			i18n.TContext(ctx, MsgToolsTag),
+			i18n.TContext(ctx, MsgVisionTag),
+			i18n.TContext(ctx, MsgReasoningTag),
+		}),
+		tablewriter.WithRendition(tw.Rendition{
+			Borders: tw.BorderNone,
+			Settings: tw.Settings{
+				Separators: tw.Separators{BetweenColumns: tw.Off},
+			},
+		}),
+		tablewriter.WithAlignment([]tw.Align{
+			tw.AlignLeft, tw.AlignRight, tw.AlignRight, tw.AlignCenter,
+			tw.AlignCenter, tw.AlignCenter, tw.AlignCenter, tw.AlignCenter,
+		}),
+	)
+	defer func() { _ = t.Close() }()
+
+	var errs []string
+	for _, r := range results {
+		row := []string{
+			r.Model,
+			fmt.Sprintf("%dms", r.LatencyMS),
+			fmt.Sprintf("%dms", r.TimeToFirstTokenMS),
+			checkMark(r.UsageReported),
+		}
+		for _, name := range []string{models.ProbeBasic, models.ProbeTools, models.ProbeVision, models.ProbeReasoning} {
+			c, ok := r.Check(name)
+			if !ok {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, checkMark(c.OK))
+			if c.Error != "" {
+				errs = append(errs, fmt.Sprintf("%s [%s]: %s", r.Model, c.Name, c.Error))
+			}
+		}
+		_ = t.Append(row)
+	}
+
+	if err := t.Render(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		fmt.Println()
+		for _, e := range errs {
+			fmt.Println(e)
+		}
+	}
+	return nil
+}
+
+// checkMark 将布尔值转为勾叉标记
+func checkMark(ok bool) string {
+	if ok {
+		return "✅"
+	}
+	return "❌"
+}
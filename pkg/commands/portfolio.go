@@ -0,0 +1,567 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yhlooo/nfa/pkg/configs"
+	"github.com/yhlooo/nfa/pkg/fx"
+	"github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/portfolio"
+)
+
+// 导入类型
+const (
+	portfolioImportPositions    = "positions"
+	portfolioImportTransactions = "transactions"
+)
+
+// newPortfolioCommand 创建 portfolio 子命令
+func newPortfolioCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "portfolio",
+		Aliases: []string{"pf"},
+		Short:   i18n.T(MsgCmdShortDescPortfolio),
+	}
+
+	cmd.AddCommand(
+		newPortfolioShowCommand(),
+		newPortfolioExposureCommand(),
+		newPortfolioImportCommand(),
+		newPortfolioAccountsCommand(),
+		newPortfolioTransactionsCommand(),
+		newPortfolioTagCommand(),
+		newPortfolioPriceCommand(),
+		newPortfolioCashCommand(),
+	)
+
+	return cmd
+}
+
+// portfolioStoreFromContext 获取数据目录下的投资组合存储
+func portfolioStoreFromContext(ctx context.Context) *portfolio.Store {
+	dataRoot := filepath.Dir(configs.ConfigPathFromContext(ctx))
+	return portfolio.NewStore(filepath.Join(dataRoot, portfolio.DirName))
+}
+
+// currencyConverterFromContext 根据配置的汇率来源创建按最新汇率换算的货币转换器
+func currencyConverterFromContext(ctx context.Context) fx.AmountConverter {
+	cfg := configs.ConfigFromContext(ctx)
+	dataRoot := filepath.Dir(configs.ConfigPathFromContext(ctx))
+	converter, err := fx.NewConverterFromOptions(cfg.FX, dataRoot, logr.FromContextOrDiscard(ctx))
+	if err != nil {
+		logr.FromContextOrDiscard(ctx).Error(err, "init fx rate sources error")
+		return nil
+	}
+	return converter.AsOf(ctx, time.Time{})
+}
+
+// PortfolioViewOptions portfolio show 和 exposure 子命令的通用选项
+type PortfolioViewOptions struct {
+	// 只显示指定账户
+	Account string
+	// 基准货币
+	Currency string
+	// 输出格式
+	OutputFormat string
+}
+
+// Validate 校验选项
+func (opts *PortfolioViewOptions) Validate() error {
+	switch opts.OutputFormat {
+	case "", "json":
+	default:
+		return fmt.Errorf("invalid output format: %s", opts.OutputFormat)
+	}
+	return nil
+}
+
+// AddPFlags 将选项绑定到命令行参数
+func (opts *PortfolioViewOptions) AddPFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&opts.Account, "account", "a", opts.Account, i18n.T(MsgPortfolioOptsAccountDesc))
+	fs.StringVar(&opts.Currency, "currency", opts.Currency, i18n.T(MsgPortfolioOptsCurrencyDesc))
+	fs.StringVarP(&opts.OutputFormat, "output-format", "f", opts.OutputFormat, i18n.T(MsgPortfolioOptsOutputFormatDesc))
+}
+
+// analyze 加载并分析投资组合
+func (opts *PortfolioViewOptions) analyze(ctx context.Context) (portfolio.Analysis, error) {
+	cfg := configs.ConfigFromContext(ctx)
+	p, err := portfolioStoreFromContext(ctx).Load()
+	if err != nil {
+		return portfolio.Analysis{}, err
+	}
+	if opts.Account != "" {
+		if _, ok := p.Account(opts.Account); !ok {
+			return portfolio.Analysis{}, fmt.Errorf("account %q not found", opts.Account)
+		}
+	}
+	currency := opts.Currency
+	if currency == "" {
+		currency = cfg.Portfolio.BaseCurrency
+	}
+	if currency == "" {
+		currency = cfg.FX.BaseCurrency
+	}
+	return portfolio.Analyze(p, portfolio.AnalyzeOptions{
+		Account:      opts.Account,
+		BaseCurrency: currency,
+		Converter:    currencyConverterFromContext(ctx),
+	}), nil
+}
+
+// newPortfolioShowCommand 创建 portfolio show 子命令
+func newPortfolioShowCommand() *cobra.Command {
+	opts := PortfolioViewOptions{}
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: i18n.T(MsgCmdShortDescPortfolioShow),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runPortfolioShow(cmd.Context(), opts)
+		},
+	}
+
+	opts.AddPFlags(cmd.Flags())
+
+	return cmd
+}
+
+// runPortfolioShow 执行 portfolio show 命令
+func runPortfolioShow(ctx context.Context, opts PortfolioViewOptions) error {
+	a, err := opts.analyze(ctx)
+	if err != nil {
+		return err
+	}
+	if opts.OutputFormat == "json" {
+		return outputJSON(a)
+	}
+
+	rows := make([][]string, 0, len(a.Holdings))
+	for _, h := range a.Holdings {
+		price := h.Price.String()
+		if h.PriceMissing {
+			price = "-"
+		}
+		rows = append(rows, []string{
+			h.Account, h.Symbol, h.Name, h.Currency,
+			h.Quantity.String(), h.AvgCost.StringFixed(2), price, h.MarketValue.StringFixed(2),
+			h.UnrealizedPnL.StringFixed(2), h.UnrealizedPnLPercent.StringFixed(2) + "%", h.Weight.StringFixed(2) + "%",
+		})
+	}
+	for _, c := range a.Cash {
+		rows = append(rows, []string{
+			c.Account, "-", i18n.TContext(ctx, MsgCashTag), c.Currency,
+			"", "", "", c.Amount.StringFixed(2), "", "", c.Weight.StringFixed(2) + "%",
+		})
+	}
+	if err := renderTable([]string{
+		i18n.TContext(ctx, MsgAccountTag),
+		i18n.TContext(ctx, MsgSymbolTag),
+		i18n.TContext(ctx, MsgNameTag),
+		i18n.TContext(ctx, MsgCurrencyTag),
+		i18n.TContext(ctx, MsgQuantityTag),
+		i18n.TContext(ctx, MsgAvgCostTag),
+		i18n.TContext(ctx, MsgPriceTag),
+		i18n.TContext(ctx, MsgMarketValueTag),
+		i18n.TContext(ctx, MsgUnrealizedPnLTag),
+		"%",
+		i18n.TContext(ctx, MsgWeightTag),
+	}, []tw.Align{
+		tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft,
+		tw.AlignRight, tw.AlignRight, tw.AlignRight, tw.AlignRight,
+		tw.AlignRight, tw.AlignRight, tw.AlignRight,
+	}, rows); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println(i18n.TContextWithData(ctx, MsgPortfolioSummary, map[string]any{
+		"Currency":      a.BaseCurrency,
+		"MarketValue":   a.MarketValue.StringFixed(2),
+		"Cash":          a.CashTotal.StringFixed(2),
+		"TotalValue":    a.TotalValue.StringFixed(2),
+		"UnrealizedPnL": a.UnrealizedPnL.StringFixed(2),
+		"RealizedPnL":   a.RealizedPnL.StringFixed(2),
+		"Income":        a.Income.StringFixed(2),
+	}))
+	if len(a.MissingPrices) > 0 {
+		fmt.Println(i18n.TContextWithData(ctx, MsgPortfolioMissingPrices, map[string]any{"Symbols": strings.Join(a.MissingPrices, ", ")}))
+	}
+	if len(a.Unconverted) > 0 {
+		fmt.Println(i18n.TContextWithData(ctx, MsgPortfolioUnconverted, map[string]any{"Currencies": strings.Join(a.Unconverted, ", "), "Currency": a.BaseCurrency}))
+	}
+	return nil
+}
+
+// PortfolioExposureOptions portfolio exposure 子命令选项
+type PortfolioExposureOptions struct {
+	PortfolioViewOptions
+	// 维度
+	By string
+	// 是否包含现金
+	IncludeCash bool
+}
+
+// AddPFlags 将选项绑定到命令行参数
+func (opts *PortfolioExposureOptions) AddPFlags(fs *pflag.FlagSet) {
+	opts.PortfolioViewOptions.AddPFlags(fs)
+	fs.StringVar(&opts.By, "by", opts.By, i18n.T(MsgPortfolioExposureOptsByDesc))
+	fs.BoolVar(&opts.IncludeCash, "include-cash", opts.IncludeCash, i18n.T(MsgPortfolioExposureOptsIncludeCashDesc))
+}
+
+// newPortfolioExposureCommand 创建 portfolio exposure 子命令
+func newPortfolioExposureCommand() *cobra.Command {
+	opts := PortfolioExposureOptions{By: string(portfolio.DimensionSector)}
+	cmd := &cobra.Command{
+		Use:   "exposure",
+		Short: i18n.T(MsgCmdShortDescPortfolioExposure),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			by, err := portfolio.ParseDimension(opts.By)
+			if err != nil {
+				return err
+			}
+			return runPortfolioExposure(cmd.Context(), opts, by)
+		},
+	}
+
+	opts.AddPFlags(cmd.Flags())
+
+	return cmd
+}
+
+// runPortfolioExposure 执行 portfolio exposure 命令
+func runPortfolioExposure(ctx context.Context, opts PortfolioExposureOptions, by portfolio.Dimension) error {
+	a, err := opts.analyze(ctx)
+	if err != nil {
+		return err
+	}
+	rows := a.Exposure(by, opts.IncludeCash)
+	if opts.OutputFormat == "json" {
+		return outputJSON(rows)
+	}
+
+	tableRows := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		tableRows = append(tableRows, []string{
+			row.Key, row.Value.StringFixed(2) + " " + a.BaseCurrency, row.Weight.StringFixed(2) + "%",
+			strings.Join(row.Symbols, ", "),
+		})
+	}
+	return renderTable([]string{
+		string(by),
+		i18n.TContext(ctx, MsgMarketValueTag),
+		i18n.TContext(ctx, MsgWeightTag),
+		i18n.TContext(ctx, MsgSymbolTag),
+	}, []tw.Align{tw.AlignLeft, tw.AlignRight, tw.AlignRight, tw.AlignLeft}, tableRows)
+}
+
+// newPortfolioImportCommand 创建 portfolio import 子命令
+func newPortfolioImportCommand() *cobra.Command {
+	account := portfolio.DefaultAccountID
+	cmd := &cobra.Command{
+		Use:       "import {positions|transactions} <file.csv>",
+		Short:     i18n.T(MsgCmdShortDescPortfolioImport),
+		Long:      i18n.T(MsgCmdLongDescPortfolioImport),
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: []string{portfolioImportPositions, portfolioImportTransactions},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPortfolioImport(cmd.Context(), args[0], args[1], account)
+		},
+	}
+
+	cmd.Flags().StringVarP(&account, "account", "a", account, i18n.T(MsgPortfolioImportOptsAccountDesc))
+
+	return cmd
+}
+
+// runPortfolioImport 执行 portfolio import 命令
+func runPortfolioImport(ctx context.Context, kind, path, account string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %q error: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	store := portfolioStoreFromContext(ctx)
+	switch kind {
+	case portfolioImportPositions:
+		in, err := portfolio.ParsePositionsCSV(f, account)
+		if err != nil {
+			return fmt.Errorf("parse %q error: %w", path, err)
+		}
+		if err := store.Update(func(p *portfolio.Portfolio) error {
+			p.ImportPositions(in)
+			return nil
+		}); err != nil {
+			return err
+		}
+		fmt.Println(i18n.TContextWithData(ctx, MsgPortfolioImportedPositions, map[string]any{"Count": len(in.Positions)}))
+	case portfolioImportTransactions:
+		txs, err := portfolio.ParseTransactionsCSV(f, account)
+		if err != nil {
+			return fmt.Errorf("parse %q error: %w", path, err)
+		}
+		added := 0
+		if err := store.Update(func(p *portfolio.Portfolio) error {
+			added, err = p.AddTransactions(txs)
+			return err
+		}); err != nil {
+			return err
+		}
+		fmt.Println(i18n.TContextWithData(ctx, MsgPortfolioImportedTransactions, map[string]any{"Count": added, "Skipped": len(txs) - added}))
+	default:
+		return fmt.Errorf("unknown import kind %q (expected: %s or %s)",
+			kind, portfolioImportPositions, portfolioImportTransactions)
+	}
+	return nil
+}
+
+// newPortfolioAccountsCommand 创建 portfolio accounts 子命令
+func newPortfolioAccountsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "accounts",
+		Aliases: []string{"account"},
+		Short:   i18n.T(MsgCmdShortDescPortfolioAccounts),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPortfolioAccountsList(cmd.Context())
+		},
+	}
+
+	account := portfolio.Account{}
+	addCmd := &cobra.Command{
+		Use:   "add <id>",
+		Short: i18n.T(MsgCmdShortDescPortfolioAccountsAdd),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			account.ID = args[0]
+			account.Currency = strings.ToUpper(account.Currency)
+			return portfolioStoreFromContext(cmd.Context()).Update(func(p *portfolio.Portfolio) error {
+				p.SetAccount(account)
+				return nil
+			})
+		},
+	}
+	addCmd.Flags().StringVar(&account.Name, "name", "", i18n.T(MsgPortfolioAccountsOptsNameDesc))
+	addCmd.Flags().StringVar(&account.Broker, "broker", "", i18n.T(MsgPortfolioAccountsOptsBrokerDesc))
+	addCmd.Flags().StringVar(&account.Currency, "currency", "", i18n.T(MsgPortfolioAccountsOptsCurrencyDesc))
+
+	removeCmd := &cobra.Command{
+		Use:     "remove <id>",
+		Aliases: []string{"rm"},
+		Short:   i18n.T(MsgCmdShortDescPortfolioAccountsRemove),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return portfolioStoreFromContext(cmd.Context()).Update(func(p *portfolio.Portfolio) error {
+				if !p.RemoveAccount(args[0]) {
+					return fmt.Errorf("account %q not found", args[0])
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.AddCommand(addCmd, removeCmd)
+
+	return cmd
+}
+
+// runPortfolioAccountsList 列出账户
+func runPortfolioAccountsList(ctx context.Context) error {
+	p, err := portfolioStoreFromContext(ctx).Load()
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(p.Accounts))
+	for _, a := range p.Accounts {
+		rows = append(rows, []string{a.ID, a.Name, a.Broker, a.Currency})
+	}
+	return renderTable([]string{
+		"ID",
+		i18n.TContext(ctx, MsgNameTag),
+		i18n.TContext(ctx, MsgBrokerTag),
+		i18n.TContext(ctx, MsgCurrencyTag),
+	}, []tw.Align{tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft}, rows)
+}
+
+// newPortfolioTransactionsCommand 创建 portfolio transactions 子命令
+func newPortfolioTransactionsCommand() *cobra.Command {
+	var account, symbol string
+	cmd := &cobra.Command{
+		Use:     "transactions",
+		Aliases: []string{"tx"},
+		Short:   i18n.T(MsgCmdShortDescPortfolioTransactions),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			p, err := portfolioStoreFromContext(ctx).Load()
+			if err != nil {
+				return err
+			}
+			var rows [][]string
+			for _, t := range p.Transactions {
+				if (account != "" && t.Account != account) || (symbol != "" && !strings.EqualFold(t.Symbol, symbol)) {
+					continue
+				}
+				rows = append(rows, []string{
+					t.Time.Format(time.DateOnly), t.Account, string(t.Type), t.Symbol,
+					decimalOrEmpty(t.Quantity), decimalOrEmpty(t.Price), decimalOrEmpty(t.Fee), decimalOrEmpty(t.Amount),
+					t.Currency, t.Note,
+				})
+			}
+			return renderTable([]string{
+				i18n.TContext(ctx, MsgDateTag),
+				i18n.TContext(ctx, MsgAccountTag),
+				i18n.TContext(ctx, MsgTypeTag),
+				i18n.TContext(ctx, MsgSymbolTag),
+				i18n.TContext(ctx, MsgQuantityTag),
+				i18n.TContext(ctx, MsgPriceTag),
+				i18n.TContext(ctx, MsgFeeTag),
+				i18n.TContext(ctx, MsgAmountTag),
+				i18n.TContext(ctx, MsgCurrencyTag),
+				i18n.TContext(ctx, MsgNoteTag),
+			}, []tw.Align{
+				tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft,
+				tw.AlignRight, tw.AlignRight, tw.AlignRight, tw.AlignRight,
+				tw.AlignLeft, tw.AlignLeft,
+			}, rows)
+		},
+	}
+
+	cmd.Flags().StringVarP(&account, "account", "a", "", i18n.T(MsgPortfolioOptsAccountDesc))
+	cmd.Flags().StringVar(&symbol, "symbol", "", i18n.T(MsgPortfolioTransactionsOptsSymbolDesc))
+
+	return cmd
+}
+
+// newPortfolioTagCommand 创建 portfolio tag 子命令
+func newPortfolioTagCommand() *cobra.Command {
+	sec := portfolio.Security{}
+	cmd := &cobra.Command{
+		Use:   "tag <symbol>",
+		Short: i18n.T(MsgCmdShortDescPortfolioTag),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sec.Market = strings.ToUpper(sec.Market)
+			sec.Currency = strings.ToUpper(sec.Currency)
+			sec.AssetClass = strings.ToLower(sec.AssetClass)
+			return portfolioStoreFromContext(cmd.Context()).Update(func(p *portfolio.Portfolio) error {
+				p.UpdateSecurity(strings.ToUpper(args[0]), sec)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&sec.Name, "name", "", i18n.T(MsgPortfolioTagOptsNameDesc))
+	cmd.Flags().StringVar(&sec.Sector, "sector", "", i18n.T(MsgPortfolioTagOptsSectorDesc))
+	cmd.Flags().StringVar(&sec.Market, "market", "", i18n.T(MsgPortfolioTagOptsMarketDesc))
+	cmd.Flags().StringVar(&sec.AssetClass, "asset-class", "", i18n.T(MsgPortfolioTagOptsAssetClassDesc))
+	cmd.Flags().StringVar(&sec.Currency, "currency", "", i18n.T(MsgPortfolioTagOptsCurrencyDesc))
+
+	return cmd
+}
+
+// newPortfolioPriceCommand 创建 portfolio price 子命令
+func newPortfolioPriceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "price <symbol> <price>",
+		Short: i18n.T(MsgCmdShortDescPortfolioPrice),
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			price, err := portfolio.ParseDecimal(args[1])
+			if err != nil || !price.IsPositive() {
+				return fmt.Errorf("invalid price %q", args[1])
+			}
+			return portfolioStoreFromContext(cmd.Context()).Update(func(p *portfolio.Portfolio) error {
+				p.UpdateSecurity(strings.ToUpper(args[0]), portfolio.Security{Price: price, PriceTime: time.Now()})
+				return nil
+			})
+		},
+	}
+
+	return cmd
+}
+
+// newPortfolioCashCommand 创建 portfolio cash 子命令
+func newPortfolioCashCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cash <account> <currency> <amount>",
+		Short: i18n.T(MsgCmdShortDescPortfolioCash),
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			amount, err := portfolio.ParseDecimal(args[2])
+			if err != nil {
+				return fmt.Errorf("invalid amount %q", args[2])
+			}
+			return portfolioStoreFromContext(cmd.Context()).Update(func(p *portfolio.Portfolio) error {
+				p.SetCash(args[0], strings.ToUpper(args[1]), amount)
+				return nil
+			})
+		},
+	}
+
+	return cmd
+}
+
+// renderTable 以无边框表格形式输出
+func renderTable(header []string, alignment []tw.Align, rows [][]string) error {
+	t := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithHeader(header),
+		tablewriter.WithRendition(tw.Rendition{
+			Borders: tw.BorderNone,
+			Settings: tw.Settings{
+				Separators: tw.Separators{BetweenColumns: tw.Off},
+			},
+		}),
+		tablewriter.WithAlignment(alignment),
+	)
+	defer func() { _ = t.Close() }()
+
+	for _, row := range rows {
+		for i, v := range row {
+			if v == "" {
+				row[i] = "-"
+			}
+		}
+		_ = t.Append(row)
+	}
+
+	return t.Render()
+}
+
+// outputJSON 以 JSON 格式输出
+func outputJSON(v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(raw))
+	return nil
+}
+
+// decimalOrEmpty 格式化数值，为 0 时返回空字符串
+func decimalOrEmpty(d decimal.Decimal) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.String()
+}
@@ -1,23 +1,24 @@
 package commands
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
-	"github.com/bombsimon/logrusr/v4"
 	"github.com/chromedp/chromedp"
 	"github.com/go-logr/logr"
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"gopkg.in/natefinch/lumberjack.v2"
 
+	"github.com/yhlooo/nfa/pkg/acputil"
 	"github.com/yhlooo/nfa/pkg/agents"
+	"github.com/yhlooo/nfa/pkg/alerts"
 	uitty "github.com/yhlooo/nfa/pkg/apps/chat"
 	"github.com/yhlooo/nfa/pkg/channels"
 	"github.com/yhlooo/nfa/pkg/channels/wecomaibot"
@@ -25,6 +26,11 @@ import (
 	"github.com/yhlooo/nfa/pkg/configs"
 	"github.com/yhlooo/nfa/pkg/eula"
 	"github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/logs"
+	"github.com/yhlooo/nfa/pkg/metrics"
+	"github.com/yhlooo/nfa/pkg/models"
+	"github.com/yhlooo/nfa/pkg/schedule"
+	"github.com/yhlooo/nfa/pkg/telemetry"
 	"github.com/yhlooo/nfa/pkg/version"
 )
 
@@ -98,6 +104,7 @@ func NewCommand(name string) *cobra.Command {
 	opts := NewOptions()
 
 	var keylog *os.File
+	var logCloser io.Closer
 	cmd := &cobra.Command{
 		Use:           fmt.Sprintf("%s [PROMPT]", name),
 		Short:         i18n.T(MsgCmdShortDesc),
@@ -113,38 +120,31 @@ func NewCommand(name string) *cobra.Command {
 				return err
 			}
 
-			// 创建日志目录
+			// 创建数据目录
 			if err := os.MkdirAll(globalOpts.DataRoot, 0o755); err != nil {
-				return fmt.Errorf("create log directory %q error: %w", globalOpts.DataRoot, err)
+				return fmt.Errorf("create data directory %q error: %w", globalOpts.DataRoot, err)
 			}
 
-			// 初始化 logger
-			logrusLogger := logrus.New()
-			logrusLogger.SetOutput(&lumberjack.Logger{
-				Filename:   filepath.Join(globalOpts.DataRoot, "nfa.log"),
-				MaxSize:    500, // MB
-				MaxBackups: 3,
-				MaxAge:     28, // 天
-			})
-			switch globalOpts.Verbosity {
-			case 0:
-				logrusLogger.Level = logrus.InfoLevel
-			case 1:
-				logrusLogger.Level = logrus.DebugLevel
-			default:
-				logrusLogger.Level = logrus.TraceLevel
-			}
-			logger := logrusr.New(logrusLogger)
-			ctx = logr.NewContext(ctx, logger)
-
 			// 加载配置
 			cfgPath := filepath.Join(globalOpts.DataRoot, "nfa.json")
 			cfg, err := configs.LoadConfig(cfgPath)
 			if err != nil {
 				return fmt.Errorf("load config %q error: %w", cfgPath, err)
 			}
+			cfg, err = configs.ResolveSecrets(cfg)
+			if err != nil {
+				return fmt.Errorf("resolve secrets in config %q error: %w", cfgPath, err)
+			}
 			ctx = configs.ContextWithConfig(ctx, cfg, cfgPath)
 
+			// 初始化 logger
+			logger, closer, err := logs.New(cfg.Log, globalOpts.DataRoot, int(globalOpts.Verbosity))
+			if err != nil {
+				return fmt.Errorf("init logger error: %w", err)
+			}
+			logCloser = closer
+			ctx = logr.NewContext(ctx, logger)
+
 			// 设置本地化器
 			ctx = i18n.ContextWithLocalizer(ctx, i18n.NewLocalizer(globalOpts.Language, cfg.Language, i18n.GetEnvLanguage()))
 
@@ -169,7 +169,6 @@ func NewCommand(name string) *cobra.Command {
 			ctx := cmd.Context()
 
 			cfg := configs.ConfigFromContext(ctx)
-			logger := logr.FromContextOrDiscard(ctx)
 
 			m := cfg.DefaultModels
 			if opts.Model != "" {
@@ -182,39 +181,26 @@ func NewCommand(name string) *cobra.Command {
 				m.ReasoningLevel = &opts.ReasoningLevel
 			}
 
-			// 创建 Agent
-			agent := agents.NewNFA(agents.Options{
-				Logger:         logger,
-				Localizer:      i18n.LocalizerFromContext(ctx),
-				ModelProviders: cfg.ModelProviders,
-				DataProviders:  cfg.DataProviders,
-				DefaultModels:  m,
-				DataRoot:       globalOpts.DataRoot,
-			})
+			agent, shutdown, err := newAgent(ctx, globalOpts.DataRoot, m)
+			if err != nil {
+				return err
+			}
+			defer shutdown()
 
 			// 连接信道
-			var chs []channels.Channel
-			if cfg.Channels.Enabled {
-				for _, chOpts := range cfg.Channels.Channels {
-					switch {
-					case chOpts.WeComAIBot != nil:
-						ch := &wecomaibot.WeComAIBot{
-							BotID:  chOpts.WeComAIBot.BotID,
-							Secret: chOpts.WeComAIBot.Secret,
-							URL:    chOpts.WeComAIBot.URL,
-						}
-						ch.Start(ctx)
-						chs = append(chs, ch)
-					case chOpts.YuanbaoBot != nil:
-						ch := &yuanbaobot.YuanbaoBot{
-							AppKey:       chOpts.YuanbaoBot.AppID,
-							AppSecret:    chOpts.YuanbaoBot.AppSecret,
-							BaseURL:      chOpts.YuanbaoBot.BaseURL,
-							WebSocketURL: chOpts.YuanbaoBot.WebSocketURL,
-						}
-						ch.Start(ctx)
-						chs = append(chs, ch)
-					}
+			chs, namedChs := startChannels(ctx)
+
+			// 定时任务和提醒，仅交互运行时执行
+			var (
+				runner    *acputil.SessionRunner
+				scheduler *schedule.Scheduler
+				poller    *alerts.Poller
+			)
+			if !opts.PrintAndExit {
+				runner = newSessionRunner(agent)
+				scheduler = newScheduler(ctx, runner, namedChs)
+				if poller, err = newAlertPoller(ctx, runner, namedChs); err != nil {
+					return err
 				}
 			}
 
@@ -229,6 +215,9 @@ func NewCommand(name string) *cobra.Command {
 				AutoExitAfterResponse: opts.PrintAndExit,
 				ResumeSessionID:       opts.Resume,
 				Channels:              chs,
+				Runner:                runner,
+				Scheduler:             scheduler,
+				Alerts:                poller,
 			})
 			agent.SetClient(app)
 
@@ -243,6 +232,9 @@ func NewCommand(name string) *cobra.Command {
 			if keylog != nil {
 				_ = keylog.Close()
 			}
+			if logCloser != nil {
+				_ = logCloser.Close()
+			}
 			return nil
 		},
 	}
@@ -253,6 +245,14 @@ func NewCommand(name string) *cobra.Command {
 	cmd.AddCommand(
 		newOtterCommand(),
 		newModelsCommand(),
+		newUsageCommand(),
+		newPortfolioCommand(),
+		newBacktestCommand(),
+		newScheduleCommand(),
+		newAlertsCommand(),
+		newWatchlistCommand(),
+		newDaemonCommand(),
+		newSymbolsCommand(),
 		newInternalToolsCommand(),
 		newVersionCommand(),
 	)
@@ -260,6 +260,96 @@ func NewCommand(name string) *cobra.Command {
 	return cmd
 }
 
+// newAgent 根据配置初始化追踪、创建 Agent 并启动指标服务，返回的 shutdown 用于退出前关闭追踪
+func newAgent(ctx context.Context, dataRoot string, m models.Models) (*agents.NFAAgent, func(), error) {
+	cfg := configs.ConfigFromContext(ctx)
+	logger := logr.FromContextOrDiscard(ctx)
+
+	// 初始化追踪，需在初始化 genkit 前完成
+	shutdownTracing, err := telemetry.Setup(ctx, cfg.Tracing, dataRoot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("setup tracing error: %w", err)
+	}
+	shutdown := func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Error(err, "shutdown tracing error")
+		}
+	}
+
+	// 创建 Agent
+	agent := agents.NewNFA(agents.Options{
+		Logger:         logger,
+		Localizer:      i18n.LocalizerFromContext(ctx),
+		ModelProviders: cfg.ModelProviders,
+		DataProviders:  cfg.DataProviders,
+		DefaultModels:  m,
+		DataRoot:       dataRoot,
+		Pricing:        cfg.Pricing,
+		Budgets:        cfg.Budgets,
+		Portfolio:      cfg.Portfolio,
+		Markets:        cfg.Markets,
+		FX:             cfg.FX,
+		Verification:   cfg.Verification,
+	})
+
+	// 启动指标服务
+	if err := metrics.Serve(ctx, cfg.Metrics); err != nil {
+		shutdown()
+		return nil, nil, fmt.Errorf("start metrics server error: %w", err)
+	}
+
+	return agent, shutdown, nil
+}
+
+// newSessionRunner 创建在当前目录下以独立会话执行提示的执行器
+func newSessionRunner(agent *agents.NFAAgent) *acputil.SessionRunner {
+	cwd, _ := os.Getwd()
+	return acputil.NewSessionRunner(agent, cwd)
+}
+
+// startChannels 连接配置中启用的信道，返回信道列表和信道名到信道的映射（同名信道取第一个）
+func startChannels(ctx context.Context) ([]channels.Channel, map[string]channels.Channel) {
+	cfg := configs.ConfigFromContext(ctx)
+	if !cfg.Channels.Enabled {
+		return nil, nil
+	}
+
+	var chs []channels.Channel
+	named := make(map[string]channels.Channel)
+	add := func(name string, ch channels.Channel) {
+		chs = append(chs, ch)
+		if _, ok := named[name]; !ok {
+			named[name] = ch
+		}
+	}
+
+	ctx = logr.NewContext(ctx, logr.FromContextOrDiscard(ctx).WithName(logs.ComponentChannels))
+	for _, chOpts := range cfg.Channels.Channels {
+		switch {
+		case chOpts.WeComAIBot != nil:
+			ch := &wecomaibot.WeComAIBot{
+				BotID:  chOpts.WeComAIBot.BotID,
+				Secret: chOpts.WeComAIBot.Secret,
+				URL:    chOpts.WeComAIBot.URL,
+			}
+			ch.Start(ctx)
+			add(wecomaibot.ChannelName, ch)
+		case chOpts.YuanbaoBot != nil:
+			ch := &yuanbaobot.YuanbaoBot{
+				AppKey:       chOpts.YuanbaoBot.AppID,
+				AppSecret:    chOpts.YuanbaoBot.AppSecret,
+				BaseURL:      chOpts.YuanbaoBot.BaseURL,
+				WebSocketURL: chOpts.YuanbaoBot.WebSocketURL,
+			}
+			ch.Start(ctx)
+			add(yuanbaobot.ChannelName, ch)
+		}
+	}
+	return chs, named
+}
+
 // setKeyLog 设置 TLS keylog
 func setKeyLog() (*os.File, error) {
 	keylog := os.Getenv("SSLKEYLOGFILE")
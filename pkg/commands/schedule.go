@@ -0,0 +1,312 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yhlooo/nfa/pkg/acputil"
+	"github.com/yhlooo/nfa/pkg/apps/daemon"
+	"github.com/yhlooo/nfa/pkg/channels"
+	"github.com/yhlooo/nfa/pkg/configs"
+	"github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/schedule"
+)
+
+// newScheduleCommand 创建 schedule 子命令
+func newScheduleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "schedule",
+		Aliases: []string{"sched"},
+		Short:   i18n.T(MsgCmdShortDescSchedule),
+		Long:    i18n.T(MsgCmdLongDescSchedule),
+	}
+
+	cmd.AddCommand(
+		newScheduleAddCommand(),
+		newScheduleListCommand(),
+		newScheduleRemoveCommand(),
+		newScheduleRunNowCommand(),
+	)
+
+	return cmd
+}
+
+// scheduleStoreFromContext 获取数据目录下的定时任务存储
+func scheduleStoreFromContext(ctx context.Context) *schedule.Store {
+	dataRoot := filepath.Dir(configs.ConfigPathFromContext(ctx))
+	return schedule.NewStore(filepath.Join(dataRoot, schedule.DirName))
+}
+
+// newScheduler 创建使用 runner 执行任务、推送结果到 chs 的调度器
+func newScheduler(ctx context.Context, runner *acputil.SessionRunner, chs map[string]channels.Channel) *schedule.Scheduler {
+	return schedule.NewScheduler(schedule.Options{
+		Store:    scheduleStoreFromContext(ctx),
+		Runner:   runner,
+		Channels: chs,
+	})
+}
+
+// ScheduleAddOptions schedule add 子命令选项
+type ScheduleAddOptions struct {
+	Job schedule.Job
+}
+
+// AddPFlags 将选项绑定到命令行参数
+func (opts *ScheduleAddOptions) AddPFlags(fs *pflag.FlagSet) {
+	job := &opts.Job
+	fs.StringVar(&job.Name, "name", job.Name, i18n.T(MsgScheduleOptsNameDesc))
+	fs.StringVar(&job.Cron, "cron", job.Cron, i18n.T(MsgScheduleOptsCronDesc))
+	fs.StringVar(&job.TimeZone, "tz", job.TimeZone, i18n.T(MsgScheduleOptsTimeZoneDesc))
+	fs.StringVar(&job.Market, "market", job.Market, i18n.T(MsgScheduleOptsMarketDesc))
+	fs.StringVar((*string)(&job.CatchUp), "catch-up", string(job.CatchUp), i18n.T(MsgScheduleOptsCatchUpDesc))
+	fs.StringVar(&job.CatchUpWindow, "catch-up-window", job.CatchUpWindow, i18n.T(MsgScheduleOptsCatchUpWindowDesc))
+	fs.StringVar(&job.Skill, "skill", job.Skill, i18n.T(MsgScheduleOptsSkillDesc))
+	fs.StringVar(&job.Delivery.Channel, "channel", job.Delivery.Channel, i18n.T(MsgScheduleOptsChannelDesc))
+	fs.StringVar(&job.Delivery.UserID, "user", job.Delivery.UserID, i18n.T(MsgScheduleOptsUserDesc))
+	fs.StringVar(&job.Delivery.GroupID, "group", job.Delivery.GroupID, i18n.T(MsgScheduleOptsGroupDesc))
+	fs.StringVar(&job.Delivery.File, "file", job.Delivery.File, i18n.T(MsgScheduleOptsFileDesc))
+	fs.BoolVar(&job.Disabled, "disabled", job.Disabled, i18n.T(MsgScheduleOptsDisabledDesc))
+}
+
+// newScheduleAddCommand 创建 schedule add 子命令
+func newScheduleAddCommand() *cobra.Command {
+	opts := ScheduleAddOptions{}
+	cmd := &cobra.Command{
+		Use:   "add [PROMPT]",
+		Short: i18n.T(MsgCmdShortDescScheduleAdd),
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			job := opts.Job
+			if len(args) > 0 {
+				job.Prompt = args[0]
+			}
+			job.ID = schedule.NewJobID()
+			job.CreatedAt = time.Now()
+			if err := job.Validate(); err != nil {
+				return err
+			}
+
+			err := scheduleStoreFromContext(ctx).UpdateJobs(func(list *schedule.JobList) error {
+				if job.Name != "" && list.Find(job.Name) >= 0 {
+					return fmt.Errorf("job %q already exists", job.Name)
+				}
+				list.Jobs = append(list.Jobs, job)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			next, _ := job.Next(job.CreatedAt)
+			fmt.Println(i18n.TContextWithData(ctx, MsgScheduleAdded, map[string]any{
+				"ID":   job.ID,
+				"Next": formatRunTime(next),
+			}))
+			return nil
+		},
+	}
+
+	opts.AddPFlags(cmd.Flags())
+	_ = cmd.MarkFlagRequired("cron")
+
+	return cmd
+}
+
+// scheduleListItem schedule list 子命令 JSON 输出项
+type scheduleListItem struct {
+	schedule.Job
+	NextRun time.Time          `json:"nextRun,omitzero"`
+	State   *schedule.JobState `json:"state,omitempty"`
+}
+
+// newScheduleListCommand 创建 schedule list 子命令
+func newScheduleListCommand() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   i18n.T(MsgCmdShortDescScheduleList),
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json":
+			default:
+				return fmt.Errorf("invalid output format: %s", outputFormat)
+			}
+			return runScheduleList(cmd.Context(), outputFormat)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output-format", "f", outputFormat, i18n.T(MsgScheduleOptsOutputFormatDesc))
+
+	return cmd
+}
+
+// runScheduleList 列出定时任务
+func runScheduleList(ctx context.Context, outputFormat string) error {
+	store := scheduleStoreFromContext(ctx)
+	list, err := store.Jobs()
+	if err != nil {
+		return err
+	}
+	state, err := store.State()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	items := make([]scheduleListItem, len(list.Jobs))
+	for i, job := range list.Jobs {
+		items[i] = scheduleListItem{Job: job, State: state.Jobs[job.ID]}
+		if !job.Disabled {
+			items[i].NextRun, _ = job.Next(now)
+		}
+	}
+	if outputFormat == "json" {
+		return outputJSON(items)
+	}
+
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		target := item.Delivery.Target.String()
+		if target == "" {
+			target = item.Delivery.File
+		}
+		lastRun := ""
+		if item.State != nil && item.State.LastRun != nil {
+			lastRun = formatRunTime(item.State.LastRun.StartedAt) + " ✓"
+			if item.State.LastRun.Error != "" {
+				lastRun = formatRunTime(item.State.LastRun.StartedAt) + " ✗ " + item.State.LastRun.Error
+			}
+		}
+		next := formatRunTime(item.NextRun)
+		if item.Disabled {
+			next = i18n.TContext(ctx, MsgScheduleDisabled)
+		}
+		rows = append(rows, []string{
+			item.ID,
+			item.Name,
+			item.Cron,
+			item.Market,
+			next,
+			target,
+			lastRun,
+		})
+	}
+	return renderTable([]string{
+		"ID",
+		i18n.TContext(ctx, MsgNameTag),
+		"Cron",
+		i18n.TContext(ctx, MsgMarketTag),
+		i18n.TContext(ctx, MsgNextRunTag),
+		i18n.TContext(ctx, MsgTargetTag),
+		i18n.TContext(ctx, MsgLastRunTag),
+	}, []tw.Align{
+		tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft,
+	}, rows)
+}
+
+// newScheduleRemoveCommand 创建 schedule rm 子命令
+func newScheduleRemoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rm <id|name>",
+		Aliases: []string{"remove"},
+		Short:   i18n.T(MsgCmdShortDescScheduleRemove),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store := scheduleStoreFromContext(cmd.Context())
+			var id string
+			err := store.UpdateJobs(func(list *schedule.JobList) error {
+				i := list.Find(args[0])
+				if i < 0 {
+					return fmt.Errorf("job %q not found", args[0])
+				}
+				id = list.Jobs[i].ID
+				list.Jobs = append(list.Jobs[:i], list.Jobs[i+1:]...)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			return store.UpdateState(func(state *schedule.State) error {
+				delete(state.Jobs, id)
+				return nil
+			})
+		},
+	}
+
+	return cmd
+}
+
+// newScheduleRunNowCommand 创建 schedule run-now 子命令
+func newScheduleRunNowCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run-now <id|name>",
+		Short: i18n.T(MsgCmdShortDescScheduleRunNow),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleRunNow(cmd.Context(), args[0])
+		},
+	}
+
+	return cmd
+}
+
+// runScheduleRunNow 在当前进程中立即执行定时任务
+func runScheduleRunNow(ctx context.Context, idOrName string) error {
+	cfg := configs.ConfigFromContext(ctx)
+	dataRoot := filepath.Dir(configs.ConfigPathFromContext(ctx))
+
+	agent, shutdown, err := newAgent(ctx, dataRoot, cfg.DefaultModels)
+	if err != nil {
+		return err
+	}
+	defer shutdown()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	chs, namedChs := startChannels(ctx)
+	runner := newSessionRunner(agent)
+	app := daemon.NewDaemon(daemon.Options{
+		Agent:     agent,
+		Channels:  chs,
+		Runner:    runner,
+		Scheduler: newScheduler(ctx, runner, namedChs),
+	})
+	agent.SetClient(app)
+
+	ctx, cancelChrome := chromedp.NewContext(ctx)
+	defer cancelChrome()
+
+	run, err := app.RunJob(ctx, idOrName)
+	if run != nil {
+		printScheduleRun(ctx, run)
+	}
+	return err
+}
+
+// printScheduleRun 输出执行结果文件和会话
+func printScheduleRun(ctx context.Context, run *schedule.Run) {
+	// 有结果文件说明对话已完成，会话可以继续
+	if run.File == "" {
+		return
+	}
+	fmt.Println(i18n.TContextWithData(ctx, MsgScheduleRunSaved, map[string]any{"File": run.File}))
+	fmt.Println(i18n.TContextWithData(ctx, MsgScheduleRunSession, map[string]any{"SessionID": run.SessionID}))
+}
+
+// formatRunTime 格式化执行时间，零值时返回空字符串
+func formatRunTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04 MST")
+}
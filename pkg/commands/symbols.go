@@ -0,0 +1,210 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yhlooo/nfa/pkg/configs"
+	"github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/marketdata"
+	"github.com/yhlooo/nfa/pkg/tools/symbols"
+)
+
+// newSymbolsCommand 创建 symbols 子命令
+func newSymbolsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "symbols",
+		Short: i18n.T(MsgCmdShortDescSymbols),
+	}
+
+	cmd.AddCommand(
+		newSymbolsResolveCommand(),
+		newSymbolsRefreshCommand(),
+	)
+
+	return cmd
+}
+
+// marketDataRouterFromContext 根据配置创建行情数据 Router ，未配置行情数据提供商时返回 nil
+func marketDataRouterFromContext(ctx context.Context) (*marketdata.Router, error) {
+	cfg := configs.ConfigFromContext(ctx)
+	if len(cfg.DataProviders.MarketData) == 0 {
+		return nil, nil
+	}
+	avKey := ""
+	if av := cfg.DataProviders.AlphaVantage; av != nil {
+		avKey = av.APIKey
+	}
+	dataRoot := filepath.Dir(configs.ConfigPathFromContext(ctx))
+	return marketdata.NewRouterFromOptions(cfg.DataProviders.MarketData, dataRoot, avKey, logr.FromContextOrDiscard(ctx))
+}
+
+// symbolMasterFromContext 获取数据目录下的证券主数据
+func symbolMasterFromContext(ctx context.Context, router *marketdata.Router) *symbols.Master {
+	dataRoot := filepath.Dir(configs.ConfigPathFromContext(ctx))
+	var search symbols.SearchFunc
+	if router != nil {
+		search = func(ctx context.Context, query string) ([]tools.SymbolMatch, error) {
+			return router.SearchSymbols(ctx, query, "")
+		}
+	}
+	return symbols.NewMaster(filepath.Join(dataRoot, symbols.DirName), search)
+}
+
+// SymbolsResolveOptions symbols resolve 子命令选项
+type SymbolsResolveOptions struct {
+	// 输出格式
+	OutputFormat string
+}
+
+// Validate 校验选项
+func (opts *SymbolsResolveOptions) Validate() error {
+	switch opts.OutputFormat {
+	case "", "json":
+	default:
+		return fmt.Errorf("invalid output format: %s", opts.OutputFormat)
+	}
+	return nil
+}
+
+// AddPFlags 将选项绑定到命令行参数
+func (opts *SymbolsResolveOptions) AddPFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&opts.OutputFormat, "output-format", "f", opts.OutputFormat, i18n.T(MsgSymbolsOptsOutputFormatDesc))
+}
+
+// newSymbolsResolveCommand 创建 symbols resolve 子命令
+func newSymbolsResolveCommand() *cobra.Command {
+	opts := SymbolsResolveOptions{}
+	cmd := &cobra.Command{
+		Use:   "resolve <name|symbol|isin>",
+		Short: i18n.T(MsgCmdShortDescSymbolsResolve),
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runSymbolsResolve(cmd.Context(), strings.Join(args, " "), opts)
+		},
+	}
+
+	opts.AddPFlags(cmd.Flags())
+
+	return cmd
+}
+
+// runSymbolsResolve 执行 symbols resolve 命令
+func runSymbolsResolve(ctx context.Context, query string, opts SymbolsResolveOptions) error {
+	router, err := marketDataRouterFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	r, err := symbolMasterFromContext(ctx, router).Resolve(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	if opts.OutputFormat == "json" {
+		return outputJSON(r)
+	}
+
+	m := r.Match
+	if err := renderTable(
+		[]string{i18n.TContext(ctx, MsgFieldTag), i18n.TContext(ctx, MsgValueTag)},
+		[]tw.Align{tw.AlignLeft, tw.AlignLeft},
+		[][]string{
+			{i18n.TContext(ctx, MsgSymbolTag), m.Symbol},
+			{i18n.TContext(ctx, MsgNameTag), m.Name},
+			{i18n.TContext(ctx, MsgAliasesTag), strings.Join(m.Aliases, ", ")},
+			{i18n.TContext(ctx, MsgISINTag), m.ISIN},
+			{i18n.TContext(ctx, MsgExchangeTag), m.Exchange},
+			{i18n.TContext(ctx, MsgTypeTag), m.Type},
+			{i18n.TContext(ctx, MsgMarketTag), m.Market},
+			{i18n.TContext(ctx, MsgCurrencyTag), m.Currency},
+			{i18n.TContext(ctx, MsgCalendarTag), m.Calendar},
+			{i18n.TContext(ctx, MsgSourceTag), r.Source},
+		},
+	); err != nil {
+		return err
+	}
+	if len(r.Candidates) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println(i18n.TContext(ctx, MsgSymbolsCandidates))
+	rows := make([][]string, 0, len(r.Candidates))
+	for _, c := range r.Candidates {
+		rows = append(rows, []string{c.Symbol, c.Name, c.Market, c.Exchange})
+	}
+	return renderTable(
+		[]string{
+			i18n.TContext(ctx, MsgSymbolTag),
+			i18n.TContext(ctx, MsgNameTag),
+			i18n.TContext(ctx, MsgMarketTag),
+			i18n.TContext(ctx, MsgExchangeTag),
+		},
+		[]tw.Align{tw.AlignLeft, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft},
+		rows,
+	)
+}
+
+// SymbolsRefreshOptions symbols refresh 子命令选项
+type SymbolsRefreshOptions struct {
+	// 只使用指定名字的提供商
+	Provider string
+}
+
+// AddPFlags 将选项绑定到命令行参数
+func (opts *SymbolsRefreshOptions) AddPFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&opts.Provider, "provider", "p", opts.Provider, i18n.T(MsgSymbolsOptsProviderDesc))
+}
+
+// newSymbolsRefreshCommand 创建 symbols refresh 子命令
+func newSymbolsRefreshCommand() *cobra.Command {
+	opts := SymbolsRefreshOptions{}
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: i18n.T(MsgCmdShortDescSymbolsRefresh),
+		Long:  i18n.T(MsgCmdLongDescSymbolsRefresh),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSymbolsRefresh(cmd.Context(), opts)
+		},
+	}
+
+	opts.AddPFlags(cmd.Flags())
+
+	return cmd
+}
+
+// runSymbolsRefresh 执行 symbols refresh 命令
+func runSymbolsRefresh(ctx context.Context, opts SymbolsRefreshOptions) error {
+	router, err := marketDataRouterFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if router == nil {
+		return fmt.Errorf("no market data provider configured in dataProviders.marketData")
+	}
+	matches, err := router.ListSymbols(ctx, opts.Provider)
+	if err != nil {
+		return err
+	}
+	added, err := symbolMasterFromContext(ctx, router).Refresh(matches)
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.TContextWithData(ctx, MsgSymbolsRefreshed, map[string]any{
+		"Total": len(matches),
+		"Added": added,
+	}))
+	return nil
+}
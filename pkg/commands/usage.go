@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/yhlooo/nfa/pkg/configs"
+	"github.com/yhlooo/nfa/pkg/i18n"
+	"github.com/yhlooo/nfa/pkg/tokentracker"
+)
+
+// NewUsageOptions 创建默认 UsageOptions
+func NewUsageOptions() UsageOptions {
+	return UsageOptions{
+		GroupBy: string(tokentracker.GroupByModel),
+	}
+}
+
+// UsageOptions usage 子命令选项
+type UsageOptions struct {
+	// 起始时间（含）
+	Since string
+	// 结束时间，仅日期时包含当天
+	Until string
+	// 逗号分隔的分组维度
+	GroupBy string
+	// 报告货币
+	Currency string
+	// 输出格式
+	OutputFormat string
+}
+
+// Validate 校验选项
+func (opts *UsageOptions) Validate() error {
+	switch opts.OutputFormat {
+	case "", "csv", "json":
+	default:
+		return fmt.Errorf("invalid output format: %s", opts.OutputFormat)
+	}
+	if _, err := tokentracker.ParseGroupBy(opts.GroupBy); err != nil {
+		return err
+	}
+	if _, err := parseUsageTime(opts.Since, false); err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	if _, err := parseUsageTime(opts.Until, true); err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+	return nil
+}
+
+// AddPFlags 将选项绑定到命令行参数
+func (opts *UsageOptions) AddPFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&opts.Since, "since", opts.Since, i18n.T(MsgUsageOptsSinceDesc))
+	fs.StringVar(&opts.Until, "until", opts.Until, i18n.T(MsgUsageOptsUntilDesc))
+	fs.StringVarP(&opts.GroupBy, "group-by", "g", opts.GroupBy, i18n.T(MsgUsageOptsGroupByDesc))
+	fs.StringVar(&opts.Currency, "currency", opts.Currency, i18n.T(MsgUsageOptsCurrencyDesc))
+	fs.StringVarP(&opts.OutputFormat, "output-format", "f", opts.OutputFormat, i18n.T(MsgUsageOptsOutputFormatDesc))
+}
+
+// newUsageCommand 创建 usage 子命令
+func newUsageCommand() *cobra.Command {
+	opts := NewUsageOptions()
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: i18n.T(MsgCmdShortDescUsage),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runUsage(cmd.Context(), opts)
+		},
+	}
+
+	opts.AddPFlags(cmd.Flags())
+
+	return cmd
+}
+
+// runUsage 执行 usage 命令
+func runUsage(ctx context.Context, opts UsageOptions) error {
+	cfg := configs.ConfigFromContext(ctx)
+	dataRoot := filepath.Dir(configs.ConfigPathFromContext(ctx))
+
+	since, _ := parseUsageTime(opts.Since, false)
+	until, _ := parseUsageTime(opts.Until, true)
+	groupBy, _ := tokentracker.ParseGroupBy(opts.GroupBy)
+
+	ledger := tokentracker.NewLedger(filepath.Join(dataRoot, tokentracker.LedgerDirName))
+	records, err := ledger.Read(since, until)
+	if err != nil {
+		return fmt.Errorf("read usage ledger error: %w", err)
+	}
+
+	pricing := cfg.Pricing
+	if opts.Currency != "" {
+		pricing.Currency = opts.Currency
+	}
+	if pricing.Currency == "" {
+		pricing.Currency = cfg.FX.BaseCurrency
+	}
+	rows := tokentracker.Aggregate(records, groupBy, pricing.Currency, currencyConverterFromContext(ctx))
+
+	switch opts.OutputFormat {
+	case "json":
+		raw, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(raw))
+		return nil
+	case "csv":
+		return outputUsageCSV(groupBy, rows)
+	default:
+		return outputUsageTable(ctx, groupBy, rows)
+	}
+}
+
+// parseUsageTime 解析时间参数，支持 RFC3339 和 YYYY-MM-DD 格式
+//
+// endOfDay 为 true 且仅指定日期时，返回次日零点，以包含当天
+func parseUsageTime(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC3339 time, got %q", s)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// usageRowValues 用量报告行各列的值
+func usageRowValues(row tokentracker.ReportRow) []string {
+	return []string{
+		strconv.Itoa(row.Calls),
+		strconv.FormatInt(row.InputTokens, 10),
+		strconv.FormatInt(row.OutputTokens, 10),
+		strconv.FormatInt(row.CacheReadTokens, 10),
+		strconv.FormatInt(row.CacheWriteTokens, 10),
+		strconv.FormatInt(row.ReasoningTokens, 10),
+		row.Cost.StringFixed(4),
+		row.Currency,
+	}
+}
+
+// outputUsageCSV 以 CSV 格式输出用量报告
+func outputUsageCSV(groupBy []tokentracker.GroupBy, rows []tokentracker.ReportRow) error {
+	w := csv.NewWriter(os.Stdout)
+	header := make([]string, 0, len(groupBy)+8)
+	for _, g := range groupBy {
+		header = append(header, string(g))
+	}
+	header = append(header,
+		"calls", "input_tokens", "output_tokens", "cache_read_tokens", "cache_write_tokens", "reasoning_tokens",
+		"cost", "currency",
+	)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := w.Write(append(append([]string{}, row.Group...), usageRowValues(row)...)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// outputUsageTable 以表格形式输出用量报告
+func outputUsageTable(ctx context.Context, groupBy []tokentracker.GroupBy, rows []tokentracker.ReportRow) error {
+	header := make([]string, 0, len(groupBy)+8)
+	alignment := make([]tw.Align, 0, len(groupBy)+8)
+	for _, g := range groupBy {
+		header = append(header, string(g))
+		alignment = append(alignment, tw.AlignLeft)
+	}
+	header = append(header,
+		i18n.TContext(ctx, MsgCallsTag),
+		i18n.TContext(ctx, MsgInputTokensTag),
+		i18n.TContext(ctx, MsgOutputTokensTag),
+		i18n.TContext(ctx, MsgCacheReadTokensTag),
+		i18n.TContext(ctx, MsgCacheWriteTokensTag),
+		i18n.TContext(ctx, MsgReasoningTokensTag),
+		i18n.TContext(ctx, MsgCostTag),
+		i18n.TContext(ctx, MsgCurrencyTag),
+	)
+	alignment = append(alignment,
+		tw.AlignRight, tw.AlignRight, tw.AlignRight, tw.AlignRight,
+		tw.AlignRight, tw.AlignRight, tw.AlignRight, tw.AlignLeft,
+	)
+
+	t := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithHeader(header),
+		tablewriter.WithRendition(tw.Rendition{
+			Borders: tw.BorderNone,
+			Settings: tw.Settings{
+				Separators: tw.Separators{BetweenColumns: tw.Off},
+			},
+		}),
+		tablewriter.WithAlignment(alignment),
+	)
+	defer func() { _ = t.Close() }()
+
+	for _, row := range rows {
+		group := make([]string, len(row.Group))
+		for i, v := range row.Group {
+			group[i] = v
+			if v == "" {
+				group[i] = "-"
+			}
+		}
+		_ = t.Append(append(group, usageRowValues(row)...))
+	}
+
+	return t.Render()
+}
@@ -2,7 +2,15 @@ package configs
 
 import (
 	"github.com/yhlooo/nfa/pkg/agents"
+	"github.com/yhlooo/nfa/pkg/alerts"
+	"github.com/yhlooo/nfa/pkg/fx"
+	"github.com/yhlooo/nfa/pkg/logs"
+	"github.com/yhlooo/nfa/pkg/metrics"
 	"github.com/yhlooo/nfa/pkg/models"
+	"github.com/yhlooo/nfa/pkg/portfolio"
+	"github.com/yhlooo/nfa/pkg/telemetry"
+	"github.com/yhlooo/nfa/pkg/tokentracker"
+	"github.com/yhlooo/nfa/pkg/verify"
 )
 
 // Config 配置
@@ -20,6 +28,27 @@ type Config struct {
 	// 最大上下文窗口
 	// 默认 200K
 	MaxContextWindow int64 `json:"maxContextWindow,omitempty"`
+	// 费用统计选项
+	Pricing tokentracker.Options `json:"pricing,omitempty"`
+	// 费用预算
+	Budgets tokentracker.Budgets `json:"budgets,omitempty"`
+	// Prometheus 指标服务
+	Metrics metrics.Options `json:"metrics,omitempty"`
+	// OpenTelemetry 追踪
+	Tracing telemetry.Options `json:"tracing,omitempty"`
+	// 日志
+	Log logs.Options `json:"log,omitempty"`
+	// 投资组合
+	Portfolio portfolio.Options `json:"portfolio,omitempty"`
+	// 汇率
+	FX fx.Options `json:"fx,omitempty"`
+	// 关注的市场（交易日历 ID 、别名或证券代码），其交易状态会注入系统提示
+	// 默认 XNYS 、 XHKG 、 XSHG
+	Markets []string `json:"markets,omitempty"`
+	// 回答中数值的核对
+	Verification verify.Options `json:"verification,omitempty"`
+	// 价格和新闻提醒
+	Alerts alerts.Options `json:"alerts,omitempty"`
 }
 
 // ChannelsConfig 消息通道配置
@@ -28,20 +28,28 @@ func LoadConfig(path string) (Config, error) {
 }
 
 // SaveConfig 保存配置
+//
+// 配置中可能包含密钥，因此仅允许当前用户读写
 func SaveConfig(path string, cfg Config) error {
 	content, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal config to json error: %w", err)
 	}
 
-	if err := os.WriteFile(path, content, 0o644); err != nil {
+	if err := os.WriteFile(path, content, 0o600); err != nil {
 		return fmt.Errorf("write config to %q error: %w", path, err)
 	}
+	// WriteFile 不会修改已存在文件的权限
+	if err := os.Chmod(path, 0o600); err != nil {
+		return fmt.Errorf("chmod config %q error: %w", path, err)
+	}
 
 	return nil
 }
 
 // SaveDefaultModels 仅保存默认模型配置
+//
+// 基于配置文件中的原始配置修改，不会将已解析的密钥写回配置文件
 func SaveDefaultModels(path string, defaultModels models.Models) error {
 	// 读取现有配置
 	cfg, err := LoadConfig(path)
@@ -0,0 +1,191 @@
+package configs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/yhlooo/nfa/pkg/httpclient"
+)
+
+const (
+	// SecretEnvPrefix 从环境变量读取密钥的引用前缀，如 env:DEEPSEEK_API_KEY
+	SecretEnvPrefix = "env:"
+	// SecretFilePrefix 从文件读取密钥的引用前缀，如 file:/run/secrets/deepseek
+	SecretFilePrefix = "file:"
+)
+
+// envVarRefPattern 字符串中的环境变量引用，如 ${DEEPSEEK_API_KEY}
+var envVarRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)}`)
+
+// ResolveSecret 解析密钥引用
+//
+// 支持以下形式：
+//   - env:NAME 读取环境变量 NAME 的值
+//   - file:PATH 读取文件 PATH 的内容，去除首尾空白
+//   - 包含 ${NAME} 的字符串，将其替换为环境变量 NAME 的值
+//
+// 其它值原样返回
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, SecretEnvPrefix):
+		name := strings.TrimPrefix(value, SecretEnvPrefix)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %q not set", name)
+		}
+		return v, nil
+	case strings.HasPrefix(value, SecretFilePrefix):
+		path := strings.TrimPrefix(value, SecretFilePrefix)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read secret file %q error: %w", path, err)
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+
+	var missing []string
+	ret := envVarRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envVarRefPattern.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %q not set", strings.Join(missing, ", "))
+	}
+	return ret, nil
+}
+
+// ResolveSecrets 解析配置中所有密钥引用，返回解析后的配置副本
+//
+// 传入的配置不会被修改，保存配置时应使用原始配置，避免将明文密钥写回配置文件
+func ResolveSecrets(cfg Config) (Config, error) {
+	ret, err := copyConfig(cfg)
+	if err != nil {
+		return Config{}, err
+	}
+
+	for _, field := range secretFields(&ret) {
+		v, err := ResolveSecret(*field.value)
+		if err != nil {
+			return Config{}, fmt.Errorf("resolve %s error: %w", field.path, err)
+		}
+		*field.value = v
+	}
+	for _, h := range headerFields(&ret) {
+		for k, v := range h.headers {
+			resolved, err := ResolveSecret(v)
+			if err != nil {
+				return Config{}, fmt.Errorf("resolve %s.headers[%s] error: %w", h.path, k, err)
+			}
+			h.headers[k] = resolved
+		}
+	}
+
+	return ret, nil
+}
+
+// secretField 配置中的密钥字段
+type secretField struct {
+	path  string
+	value *string
+}
+
+// secretFields 返回配置中所有密钥字段
+func secretFields(cfg *Config) []secretField {
+	var ret []secretField
+	for i, p := range cfg.ModelProviders {
+		if opts := p.CompatibleOptions(); opts != nil {
+			ret = append(ret, secretField{
+				path:  fmt.Sprintf("modelProviders[%d].apiKey", i),
+				value: &opts.APIKey,
+			})
+		}
+	}
+	if av := cfg.DataProviders.AlphaVantage; av != nil {
+		ret = append(ret, secretField{path: "dataProviders.alphaVantage.apiKey", value: &av.APIKey})
+	}
+	if wsa := cfg.DataProviders.TencentCloudWSA; wsa != nil {
+		ret = append(ret,
+			secretField{path: "dataProviders.tcloudWSA.secretID", value: &wsa.SecretID},
+			secretField{path: "dataProviders.tcloudWSA.secretKey", value: &wsa.SecretKey},
+		)
+	}
+	for i, md := range cfg.DataProviders.MarketData {
+		if md.AlphaVantage != nil {
+			ret = append(ret, secretField{
+				path:  fmt.Sprintf("dataProviders.marketData[%d].alphaVantage.apiKey", i),
+				value: &md.AlphaVantage.APIKey,
+			})
+		}
+	}
+	for i, ch := range cfg.Channels.Channels {
+		if ch.WeComAIBot != nil {
+			ret = append(ret, secretField{
+				path:  fmt.Sprintf("channels.channels[%d].wecomAIBot.secret", i),
+				value: &ch.WeComAIBot.Secret,
+			})
+		}
+		if ch.YuanbaoBot != nil {
+			ret = append(ret, secretField{
+				path:  fmt.Sprintf("channels.channels[%d].yuanbaoBot.appSecret", i),
+				value: &ch.YuanbaoBot.AppSecret,
+			})
+		}
+	}
+	return ret
+}
+
+// headersField 配置中的 HTTP 请求头字段，请求头中常携带认证信息
+type headersField struct {
+	path    string
+	headers map[string]string
+}
+
+// headerFields 返回配置中所有 HTTP 请求头字段
+func headerFields(cfg *Config) []headersField {
+	var ret []headersField
+	add := func(i int, opts httpclient.Options) {
+		if len(opts.Headers) > 0 {
+			ret = append(ret, headersField{path: fmt.Sprintf("modelProviders[%d].http", i), headers: opts.Headers})
+		}
+	}
+	for i, p := range cfg.ModelProviders {
+		if p.Ollama != nil {
+			add(i, p.Ollama.HTTP)
+		}
+		if opts := p.CompatibleOptions(); opts != nil {
+			add(i, opts.HTTP)
+		}
+	}
+	for i, md := range cfg.DataProviders.MarketData {
+		if md.HTTP != nil && len(md.HTTP.HTTP.Headers) > 0 {
+			ret = append(ret, headersField{
+				path:    fmt.Sprintf("dataProviders.marketData[%d].http.http", i),
+				headers: md.HTTP.HTTP.Headers,
+			})
+		}
+	}
+	if len(cfg.Tracing.Headers) > 0 {
+		ret = append(ret, headersField{path: "tracing", headers: cfg.Tracing.Headers})
+	}
+	return ret
+}
+
+// copyConfig 深拷贝配置
+func copyConfig(cfg Config) (Config, error) {
+	content, err := json.Marshal(cfg)
+	if err != nil {
+		return Config{}, fmt.Errorf("marshal config to json error: %w", err)
+	}
+	ret := Config{}
+	if err := json.Unmarshal(content, &ret); err != nil {
+		return Config{}, fmt.Errorf("unmarshal config from json error: %w", err)
+	}
+	return ret, nil
+}
@@ -0,0 +1,104 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/agents"
+	"github.com/yhlooo/nfa/pkg/httpclient"
+	"github.com/yhlooo/nfa/pkg/models"
+	"github.com/yhlooo/nfa/pkg/tools/alphavantage"
+	"github.com/yhlooo/nfa/pkg/tools/marketdata"
+)
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("NFA_TEST_KEY", "sk-123")
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("file-secret\n"), 0o600))
+
+	cases := []struct {
+		value    string
+		expected string
+		err      bool
+	}{
+		{value: "plain", expected: "plain"},
+		{value: "", expected: ""},
+		{value: "env:NFA_TEST_KEY", expected: "sk-123"},
+		{value: "env:NFA_TEST_NOT_SET", err: true},
+		{value: "file:" + secretFile, expected: "file-secret"},
+		{value: "file:" + secretFile + ".not-exists", err: true},
+		{value: "Bearer ${NFA_TEST_KEY}", expected: "Bearer sk-123"},
+		{value: "${NFA_TEST_NOT_SET}", err: true},
+		{value: "pa$$word", expected: "pa$$word"},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			v, err := ResolveSecret(c.value)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, v)
+		})
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("NFA_TEST_KEY", "sk-123")
+	t.Setenv("NFA_TEST_TOKEN", "token")
+
+	raw := Config{
+		ModelProviders: []models.ModelProvider{
+			{Deepseek: &models.OpenAICompatibleOptions{
+				APIKey: "env:NFA_TEST_KEY",
+				HTTP:   httpclient.Options{Headers: map[string]string{"X-Token": "${NFA_TEST_TOKEN}"}},
+			}},
+		},
+		DataProviders: agents.DataProviders{
+			AlphaVantage: &alphavantage.Options{APIKey: "plain"},
+			MarketData: []marketdata.ProviderOptions{
+				{AlphaVantage: &alphavantage.RESTOptions{APIKey: "env:NFA_TEST_KEY"}},
+				{HTTP: &marketdata.HTTPOptions{
+					HTTP: httpclient.Options{Headers: map[string]string{"Authorization": "Bearer ${NFA_TEST_TOKEN}"}},
+				}},
+			},
+		},
+		Channels: ChannelsConfig{Channels: []Channel{
+			{WeComAIBot: &WeComAIBotOptions{BotID: "bot", Secret: "${NFA_TEST_KEY}"}},
+		}},
+	}
+
+	resolved, err := ResolveSecrets(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-123", resolved.ModelProviders[0].Deepseek.APIKey)
+	assert.Equal(t, "token", resolved.ModelProviders[0].Deepseek.HTTP.Headers["X-Token"])
+	assert.Equal(t, "plain", resolved.DataProviders.AlphaVantage.APIKey)
+	assert.Equal(t, "sk-123", resolved.DataProviders.MarketData[0].AlphaVantage.APIKey)
+	assert.Equal(t, "Bearer token", resolved.DataProviders.MarketData[1].HTTP.HTTP.Headers["Authorization"])
+	assert.Equal(t, "sk-123", resolved.Channels.Channels[0].WeComAIBot.Secret)
+
+	// 原始配置不被修改
+	assert.Equal(t, "env:NFA_TEST_KEY", raw.ModelProviders[0].Deepseek.APIKey)
+	assert.Equal(t, "${NFA_TEST_TOKEN}", raw.ModelProviders[0].Deepseek.HTTP.Headers["X-Token"])
+	assert.Equal(t, "${NFA_TEST_KEY}", raw.Channels.Channels[0].WeComAIBot.Secret)
+
+	// 引用无法解析时报告字段路径
+	raw.Channels.Channels[0].WeComAIBot.Secret = "env:NFA_TEST_NOT_SET"
+	_, err = ResolveSecrets(raw)
+	assert.ErrorContains(t, err, "channels.channels[0].wecomAIBot.secret")
+}
+
+func TestSaveConfigPermission(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nfa.json")
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0o644))
+
+	require.NoError(t, SaveConfig(path, Config{Language: "zh"}))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
@@ -0,0 +1,198 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// latestTTL 最新汇率的缓存时间
+	latestTTL = 10 * time.Minute
+	// amountPlaces 换算结果保留的小数位数
+	amountPlaces = 4
+)
+
+// pivotCurrencies 计算交叉汇率时使用的中间货币
+var pivotCurrencies = []string{"USD", "EUR"}
+
+// AmountConverter 金额换算器，用于投资组合汇总、费用统计等不便传递错误的场景
+type AmountConverter interface {
+	// Convert 将 from 货币的金额换算为 to 货币，无法换算时返回 false
+	Convert(amount decimal.Decimal, from, to string) (decimal.Decimal, bool)
+}
+
+// Conversion 换算结果
+type Conversion struct {
+	Amount decimal.Decimal `json:"amount"`
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Result decimal.Decimal `json:"result"`
+	Rate   Rate            `json:"rate"`
+}
+
+// Converter 汇率换算器
+//
+// 按顺序从各来源查询汇率，没有直接汇率时经由 USD 或 EUR 计算交叉汇率
+type Converter struct {
+	sources []RateSource
+	now     func() time.Time
+
+	lock  sync.Mutex
+	cache map[cacheKey]cacheEntry
+}
+
+// cacheKey 汇率缓存键
+type cacheKey struct {
+	from, to string
+	date     time.Time
+}
+
+// cacheEntry 汇率缓存项
+type cacheEntry struct {
+	rate    Rate
+	fetched time.Time
+}
+
+// NewConverter 创建汇率换算器
+func NewConverter(sources ...RateSource) *Converter {
+	return &Converter{
+		sources: sources,
+		now:     time.Now,
+		cache:   map[cacheKey]cacheEntry{},
+	}
+}
+
+// Sources 返回汇率来源名
+func (c *Converter) Sources() []string {
+	ret := make([]string, len(c.sources))
+	for i, s := range c.sources {
+		ret[i] = s.Name()
+	}
+	return ret
+}
+
+// Rate 查询 date 当天 1 单位 from 货币折合 to 货币的数量， date 为零值时查询最新汇率
+func (c *Converter) Rate(ctx context.Context, from, to string, date time.Time) (Rate, error) {
+	from, to, date = NormalizeCurrency(from), NormalizeCurrency(to), dateOf(date)
+	for _, currency := range []string{from, to} {
+		if !ValidCurrency(currency) {
+			return Rate{}, fmt.Errorf("invalid currency code %q, expected ISO 4217 code such as USD, HKD, CNY", currency)
+		}
+	}
+	if from == to {
+		return Rate{From: from, To: to, Rate: decimal.NewFromInt(1), Date: date, Source: "identity"}, nil
+	}
+	if len(c.sources) == 0 {
+		return Rate{}, fmt.Errorf("no exchange rate source configured: %w", ErrRateNotFound)
+	}
+
+	direct, err := c.direct(ctx, from, to, date)
+	if err == nil {
+		return direct, nil
+	}
+	errs := []error{err}
+	for _, pivot := range pivotCurrencies {
+		if pivot == from || pivot == to {
+			continue
+		}
+		first, err := c.direct(ctx, from, pivot, date)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		second, err := c.direct(ctx, pivot, to, date)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return first.Cross(second), nil
+	}
+	return Rate{}, errors.Join(errs...)
+}
+
+// Convert 按 date 当天的汇率将 from 货币的金额换算为 to 货币， date 为零值时使用最新汇率
+func (c *Converter) Convert(ctx context.Context, amount decimal.Decimal, from, to string, date time.Time) (Conversion, error) {
+	rate, err := c.Rate(ctx, from, to, date)
+	if err != nil {
+		return Conversion{}, err
+	}
+	return Conversion{
+		Amount: amount,
+		From:   rate.From,
+		To:     rate.To,
+		Result: amount.Mul(rate.Rate).Round(amountPlaces),
+		Rate:   rate,
+	}, nil
+}
+
+// AsOf 返回按 date 当天汇率换算金额的换算器， date 为零值时使用最新汇率
+func (c *Converter) AsOf(ctx context.Context, date time.Time) AmountConverter {
+	return datedConverter{ctx: ctx, converter: c, date: date}
+}
+
+// direct 依次从各来源查询直接汇率
+func (c *Converter) direct(ctx context.Context, from, to string, date time.Time) (Rate, error) {
+	key := cacheKey{from: from, to: to, date: date}
+	c.lock.Lock()
+	entry, ok := c.cache[key]
+	c.lock.Unlock()
+	if ok && (!date.IsZero() || c.now().Sub(entry.fetched) < latestTTL) {
+		return entry.rate, nil
+	}
+
+	var errs []error
+	for _, s := range c.sources {
+		rate, err := s.Rate(ctx, from, to, date)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+			continue
+		}
+		c.lock.Lock()
+		c.cache[key] = cacheEntry{rate: rate, fetched: c.now()}
+		c.lock.Unlock()
+		return rate, nil
+	}
+	return Rate{}, errors.Join(errs...)
+}
+
+// datedConverter 按指定日期汇率换算金额的换算器
+type datedConverter struct {
+	ctx       context.Context
+	converter *Converter
+	date      time.Time
+}
+
+// Convert 将 from 货币的金额换算为 to 货币
+func (c datedConverter) Convert(amount decimal.Decimal, from, to string) (decimal.Decimal, bool) {
+	rate, err := c.converter.Rate(c.ctx, from, to, c.date)
+	if err != nil {
+		return decimal.Zero, false
+	}
+	return amount.Mul(rate.Rate), true
+}
+
+// FirstOf 返回依次尝试各换算器的换算器，忽略 nil
+func FirstOf(converters ...AmountConverter) AmountConverter {
+	return chain(converters)
+}
+
+// chain 依次尝试的换算器
+type chain []AmountConverter
+
+// Convert 将 from 货币的金额换算为 to 货币
+func (c chain) Convert(amount decimal.Decimal, from, to string) (decimal.Decimal, bool) {
+	for _, converter := range c {
+		if converter == nil {
+			continue
+		}
+		if v, ok := converter.Convert(amount, from, to); ok {
+			return v, true
+		}
+	}
+	return decimal.Zero, false
+}
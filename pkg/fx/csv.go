@@ -0,0 +1,212 @@
+package fx
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// CSVSourceName 离线 CSV 汇率来源的默认名
+	CSVSourceName = "csv"
+	// DefaultCSVDir 离线 CSV 汇率文件的默认目录，相对于数据目录
+	DefaultCSVDir = "fx"
+	// DefaultMaxAgeDays 查询历史汇率时允许使用的最旧汇率距查询日期的默认天数
+	DefaultMaxAgeDays = 7
+)
+
+// CSV 文件列名，按顺序尝试
+var (
+	csvDateColumns  = []string{"date", "time"}
+	csvFromColumns  = []string{"from", "base"}
+	csvToColumns    = []string{"to", "quote"}
+	csvRateColumns  = []string{"rate", "close", "value"}
+	csvPairColumns  = []string{"pair", "symbol"}
+	csvPairSuffixes = []string{"=X"}
+)
+
+// CSVOptions 离线 CSV 汇率来源选项
+type CSVOptions struct {
+	// CSV 文件或目录（读取目录下所有 .csv 文件），相对路径相对于数据目录，默认为数据目录下的 fx 目录
+	Path string `json:"path,omitempty"`
+	// 查询历史汇率时允许使用的最旧汇率距查询日期的天数，默认 7
+	MaxAgeDays int `json:"maxAgeDays,omitempty"`
+}
+
+// CSVSource 离线 CSV 汇率来源
+//
+// CSV 文件须包含表头，列为 date 、 from 、 to 、 rate （或 date 、 pair 、 rate ， pair 形如 USDHKD 、 USD/HKD ），
+// 表示 date 当天 1 单位 from 货币折合 to 货币的数量。文件在首次查询时读取
+type CSVSource struct {
+	path   string
+	maxAge time.Duration
+
+	once   sync.Once
+	err    error
+	series map[string][]datedRate
+}
+
+var _ RateSource = (*CSVSource)(nil)
+
+// datedRate 某日的汇率
+type datedRate struct {
+	date time.Time
+	rate decimal.Decimal
+}
+
+// NewCSVSource 创建离线 CSV 汇率来源
+func NewCSVSource(opts CSVOptions) *CSVSource {
+	if opts.MaxAgeDays <= 0 {
+		opts.MaxAgeDays = DefaultMaxAgeDays
+	}
+	return &CSVSource{
+		path:   opts.Path,
+		maxAge: time.Duration(opts.MaxAgeDays) * 24 * time.Hour,
+	}
+}
+
+// Name 来源名
+func (s *CSVSource) Name() string {
+	return CSVSourceName
+}
+
+// Rate 查询汇率
+func (s *CSVSource) Rate(_ context.Context, from, to string, date time.Time) (Rate, error) {
+	s.once.Do(func() {
+		s.err = s.load()
+	})
+	if s.err != nil {
+		return Rate{}, s.err
+	}
+
+	if r, ok := s.lookup(from, to, date); ok {
+		return r, nil
+	}
+	if r, ok := s.lookup(to, from, date); ok {
+		return r.Inverse(), nil
+	}
+	if date.IsZero() {
+		return Rate{}, fmt.Errorf("%s/%s: %w", from, to, ErrRateNotFound)
+	}
+	return Rate{}, fmt.Errorf("%s/%s on %s: %w", from, to, date.Format(time.DateOnly), ErrRateNotFound)
+}
+
+// lookup 查询 from/to 在 date 当天或之前最近的汇率， date 为零值时返回最新汇率
+func (s *CSVSource) lookup(from, to string, date time.Time) (Rate, bool) {
+	series := s.series[from+"/"+to]
+	if len(series) == 0 {
+		return Rate{}, false
+	}
+	i := len(series) - 1
+	if !date.IsZero() {
+		date = dateOf(date)
+		i = sort.Search(len(series), func(i int) bool { return series[i].date.After(date) }) - 1
+		if i < 0 || date.Sub(series[i].date) > s.maxAge {
+			return Rate{}, false
+		}
+	}
+	return Rate{From: from, To: to, Rate: series[i].rate, Date: series[i].date, Source: s.Name()}, true
+}
+
+// load 读取所有 CSV 文件
+func (s *CSVSource) load() error {
+	s.series = map[string][]datedRate{}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("read fx rates from %q error: %w", s.path, err)
+	}
+	files := []string{s.path}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(s.path, "*.csv")); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		if err := s.loadFile(file); err != nil {
+			return fmt.Errorf("read fx rates from %q error: %w", file, err)
+		}
+	}
+
+	for k, series := range s.series {
+		sort.SliceStable(series, func(i, j int) bool { return series[i].date.Before(series[j].date) })
+		s.series[k] = series
+	}
+	return nil
+}
+
+// loadFile 读取一个 CSV 文件
+func (s *CSVSource) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	get := func(row []string, names []string) string {
+		for _, name := range names {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+		}
+		return ""
+	}
+
+	for i, row := range rows[1:] {
+		line := i + 2
+		date, err := time.Parse(time.DateOnly, get(row, csvDateColumns))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid date: %w", line, err)
+		}
+		from, to := NormalizeCurrency(get(row, csvFromColumns)), NormalizeCurrency(get(row, csvToColumns))
+		if from == "" && to == "" {
+			from, to = splitPair(get(row, csvPairColumns))
+		}
+		if !ValidCurrency(from) || !ValidCurrency(to) {
+			return fmt.Errorf("line %d: invalid currency pair %q/%q", line, from, to)
+		}
+		rate, err := decimal.NewFromString(get(row, csvRateColumns))
+		if err != nil || !rate.IsPositive() {
+			return fmt.Errorf("line %d: invalid rate %q", line, get(row, csvRateColumns))
+		}
+		key := from + "/" + to
+		s.series[key] = append(s.series[key], datedRate{date: date, rate: rate})
+	}
+	return nil
+}
+
+// splitPair 拆分货币对，如 USDHKD 、 USD/HKD 、 USD-HKD 、 USDHKD=X
+func splitPair(pair string) (string, string) {
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+	for _, suffix := range csvPairSuffixes {
+		pair = strings.TrimSuffix(pair, suffix)
+	}
+	pair = strings.NewReplacer("/", "", "-", "", "_", "", ".", "").Replace(pair)
+	if len(pair) != 6 {
+		return "", ""
+	}
+	return pair[:3], pair[3:]
+}
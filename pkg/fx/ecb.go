@@ -0,0 +1,113 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/httpclient"
+)
+
+const (
+	// ECBSourceName 欧洲央行参考汇率来源的默认名
+	ECBSourceName = "ecb"
+	// DefaultECBBaseURL 欧洲央行参考汇率接口（ Frankfurter ）的默认地址
+	DefaultECBBaseURL = "https://api.frankfurter.dev/v1"
+	// defaultECBTimeout 默认请求超时时间（秒）
+	defaultECBTimeout = 10
+)
+
+// ECBOptions 欧洲央行参考汇率来源选项
+type ECBOptions struct {
+	// 兼容 Frankfurter 的接口地址，默认 https://api.frankfurter.dev/v1
+	BaseURL string `json:"baseURL,omitempty"`
+	// HTTP 客户端选项，请求超时时间默认 10 秒
+	HTTP httpclient.Options `json:"http,omitempty"`
+}
+
+// ECBSource 通过 Frankfurter 接口查询的欧洲央行每日参考汇率
+//
+// 支持 1999 年以来约 30 种主要货币，非工作日使用之前最近工作日的汇率
+type ECBSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+var _ RateSource = (*ECBSource)(nil)
+
+// NewECBSource 创建欧洲央行参考汇率来源
+func NewECBSource(opts ECBOptions, logger logr.Logger) (*ECBSource, error) {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultECBBaseURL
+	}
+	if opts.HTTP.Timeout == 0 {
+		opts.HTTP.Timeout = defaultECBTimeout
+	}
+	client, err := httpclient.NewClient(opts.HTTP, logger)
+	if err != nil {
+		return nil, fmt.Errorf("new http client error: %w", err)
+	}
+	return &ECBSource{baseURL: strings.TrimSuffix(opts.BaseURL, "/"), client: client}, nil
+}
+
+// Name 来源名
+func (s *ECBSource) Name() string {
+	return ECBSourceName
+}
+
+// ecbResponse Frankfurter 接口响应
+type ecbResponse struct {
+	Base  string                     `json:"base"`
+	Date  string                     `json:"date"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+// Rate 查询汇率
+func (s *ECBSource) Rate(ctx context.Context, from, to string, date time.Time) (Rate, error) {
+	path := "latest"
+	if !date.IsZero() {
+		path = date.Format(time.DateOnly)
+	}
+	u := fmt.Sprintf("%s/%s?%s", s.baseURL, path, url.Values{"base": {from}, "symbols": {to}}.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Rate{}, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Rate{}, fmt.Errorf("request ecb rates error: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Rate{}, fmt.Errorf("read ecb rates response error: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity:
+		return Rate{}, fmt.Errorf("%s/%s: %w", from, to, ErrRateNotFound)
+	case resp.StatusCode != http.StatusOK:
+		return Rate{}, fmt.Errorf("request ecb rates error: status %d: %s", resp.StatusCode, body)
+	}
+
+	data := ecbResponse{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return Rate{}, fmt.Errorf("decode ecb rates response error: %w", err)
+	}
+	rate, ok := data.Rates[to]
+	if !ok {
+		return Rate{}, fmt.Errorf("%s/%s: %w", from, to, ErrRateNotFound)
+	}
+	ret := Rate{From: from, To: to, Rate: rate, Source: s.Name()}
+	if ret.Date, err = time.Parse(time.DateOnly, data.Date); err != nil {
+		return Rate{}, fmt.Errorf("invalid date %q in ecb rates response", data.Date)
+	}
+	return ret, nil
+}
@@ -0,0 +1,103 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrRateNotFound 汇率来源没有所需的汇率，调用方据此尝试其它来源
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// ratePlaces 计算的汇率保留的小数位数
+const ratePlaces = 10
+
+// RateSource 汇率来源
+type RateSource interface {
+	// Name 来源名
+	Name() string
+	// Rate 查询 date 当天 1 单位 from 货币折合 to 货币的数量，当天没有汇率时使用之前最近的汇率
+	//
+	// date 为零值时查询最新汇率。没有所需汇率时返回包装了 ErrRateNotFound 的错误
+	Rate(ctx context.Context, from, to string, date time.Time) (Rate, error)
+}
+
+// Rate 汇率
+type Rate struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// 1 单位 From 货币折合 To 货币的数量
+	Rate decimal.Decimal `json:"rate"`
+	// 汇率日期，固定汇率为零值
+	Date time.Time `json:"date,omitzero"`
+	// 汇率来源，交叉汇率为各来源以 + 连接
+	Source string `json:"source"`
+}
+
+// Inverse 返回反向汇率
+func (r Rate) Inverse() Rate {
+	return Rate{
+		From:   r.To,
+		To:     r.From,
+		Rate:   decimal.NewFromInt(1).DivRound(r.Rate, ratePlaces),
+		Date:   r.Date,
+		Source: r.Source,
+	}
+}
+
+// Cross 返回经由 r.To 货币的交叉汇率 r.From -> other.To
+func (r Rate) Cross(other Rate) Rate {
+	ret := Rate{
+		From:   r.From,
+		To:     other.To,
+		Rate:   r.Rate.Mul(other.Rate).Round(ratePlaces),
+		Date:   r.Date,
+		Source: r.Source,
+	}
+	// 使用较早的日期，避免掩盖过期的汇率
+	if ret.Date.IsZero() || (!other.Date.IsZero() && other.Date.Before(ret.Date)) {
+		ret.Date = other.Date
+	}
+	if other.Source != r.Source {
+		ret.Source += "+" + other.Source
+	}
+	return ret
+}
+
+// NormalizeCurrency 规范化货币代码，如 " usd " 转为 USD ，人民币离岸代码 CNH 保持不变
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	switch currency {
+	case "RMB":
+		return "CNY"
+	case "US$":
+		return "USD"
+	case "HK$":
+		return "HKD"
+	}
+	return currency
+}
+
+// ValidCurrency 是否为 3 个字母的货币代码
+func ValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// dateOf 返回 t 的日期（ UTC 零点），零值保持不变
+func dateOf(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
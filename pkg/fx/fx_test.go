@@ -0,0 +1,246 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// date 解析日期
+func date(value string) time.Time {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// TestCSVSource 测试离线 CSV 汇率来源
+func TestCSVSource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "usd.csv"), []byte(`date,from,to,rate
+2024-01-03,USD,HKD,7.8100
+2024-01-02,USD,HKD,7.8000
+2024-01-05,USD,HKD,7.8200
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cny.csv"), []byte(`Date,Pair,Close
+2024-01-02,USDCNY=X,7.1000
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a csv"), 0o644))
+
+	s := NewCSVSource(CSVOptions{Path: dir, MaxAgeDays: 3})
+	ctx := context.Background()
+
+	r, err := s.Rate(ctx, "USD", "HKD", date("2024-01-03"))
+	require.NoError(t, err)
+	assert.Equal(t, "7.81", r.Rate.String())
+	assert.Equal(t, date("2024-01-03"), r.Date)
+	assert.Equal(t, CSVSourceName, r.Source)
+
+	// 周末使用之前最近的汇率
+	r, err = s.Rate(ctx, "USD", "HKD", date("2024-01-07"))
+	require.NoError(t, err)
+	assert.Equal(t, date("2024-01-05"), r.Date)
+
+	// 最新汇率
+	r, err = s.Rate(ctx, "USD", "HKD", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "7.82", r.Rate.String())
+
+	// 反向汇率
+	r, err = s.Rate(ctx, "CNY", "USD", date("2024-01-02"))
+	require.NoError(t, err)
+	assert.Equal(t, "0.1408450704", r.Rate.String())
+	assert.Equal(t, "CNY", r.From)
+
+	// 超出允许的天数或早于最早的汇率
+	_, err = s.Rate(ctx, "USD", "HKD", date("2024-01-10"))
+	assert.ErrorIs(t, err, ErrRateNotFound)
+	_, err = s.Rate(ctx, "USD", "HKD", date("2023-12-29"))
+	assert.ErrorIs(t, err, ErrRateNotFound)
+	_, err = s.Rate(ctx, "USD", "JPY", time.Time{})
+	assert.ErrorIs(t, err, ErrRateNotFound)
+
+	_, err = NewCSVSource(CSVOptions{Path: filepath.Join(dir, "missing")}).Rate(ctx, "USD", "HKD", time.Time{})
+	assert.Error(t, err)
+}
+
+// TestECBSource 测试欧洲央行参考汇率来源
+func TestECBSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "USD", r.URL.Query().Get("base"))
+		switch r.URL.Path {
+		case "/2024-01-06":
+			_, _ = fmt.Fprintf(w, `{"amount":1.0,"base":"USD","date":"2024-01-05","rates":{"%s":7.8123}}`,
+				r.URL.Query().Get("symbols"))
+		case "/latest":
+			_, _ = fmt.Fprint(w, `{"amount":1.0,"base":"USD","date":"2024-01-08","rates":{}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	s, err := NewECBSource(ECBOptions{BaseURL: server.URL + "/"}, logr.Discard())
+	require.NoError(t, err)
+
+	r, err := s.Rate(context.Background(), "USD", "HKD", date("2024-01-06"))
+	require.NoError(t, err)
+	assert.Equal(t, "7.8123", r.Rate.String())
+	assert.Equal(t, date("2024-01-05"), r.Date)
+
+	_, err = s.Rate(context.Background(), "USD", "XXX", time.Time{})
+	assert.ErrorIs(t, err, ErrRateNotFound)
+	_, err = s.Rate(context.Background(), "USD", "HKD", date("1990-01-01"))
+	assert.ErrorIs(t, err, ErrRateNotFound)
+}
+
+// failingSource 总是失败的汇率来源
+type failingSource struct{}
+
+// Name 来源名
+func (failingSource) Name() string {
+	return "failing"
+}
+
+// Rate 查询汇率
+func (failingSource) Rate(context.Context, string, string, time.Time) (Rate, error) {
+	return Rate{}, errors.New("service unavailable")
+}
+
+// TestConverter 测试汇率换算器
+func TestConverter(t *testing.T) {
+	ctx := context.Background()
+	static := NewStaticSource(StaticOptions{Base: "usd", Rates: map[string]float64{"HKD": 0.128, "cny": 0.14}})
+	c := NewConverter(failingSource{}, static)
+	assert.Equal(t, []string{"failing", StaticSourceName}, c.Sources())
+
+	conv, err := c.Convert(ctx, decimal.NewFromInt(1000), " hkd", "RMB", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "HKD", conv.From)
+	assert.Equal(t, "CNY", conv.To)
+	assert.Equal(t, "914.2857", conv.Result.String())
+	assert.Equal(t, StaticSourceName, conv.Rate.Source)
+
+	r, err := c.Rate(ctx, "USD", "USD", date("2024-01-02"))
+	require.NoError(t, err)
+	assert.True(t, r.Rate.Equal(decimal.NewFromInt(1)))
+
+	_, err = c.Rate(ctx, "USD", "US", time.Time{})
+	assert.Error(t, err)
+	_, err = c.Rate(ctx, "USD", "JPY", time.Time{})
+	assert.ErrorIs(t, err, ErrRateNotFound)
+	_, err = NewConverter().Rate(ctx, "USD", "HKD", time.Time{})
+	assert.ErrorIs(t, err, ErrRateNotFound)
+
+	// 经由 USD 的交叉汇率，日期取较早的一个
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rates.csv"), []byte(`date,pair,rate
+2024-01-02,USD/HKD,7.8
+2024-01-03,USD/CNY,7.1
+`), 0o644))
+	c = NewConverter(NewCSVSource(CSVOptions{Path: dir}))
+	r, err = c.Rate(ctx, "HKD", "CNY", date("2024-01-03"))
+	require.NoError(t, err)
+	assert.Equal(t, "0.9102564102", r.Rate.String())
+	assert.Equal(t, date("2024-01-02"), r.Date)
+
+	// 与固定汇率组合
+	converter := FirstOf(nil, c.AsOf(ctx, date("2024-01-03")), NewConverter(static).AsOf(ctx, time.Time{}))
+	amount, ok := converter.Convert(decimal.NewFromInt(10), "USD", "HKD")
+	assert.True(t, ok)
+	assert.Equal(t, "78", amount.String())
+	amount, ok = converter.Convert(decimal.NewFromInt(10), "USD", "CNY")
+	assert.True(t, ok)
+	assert.Equal(t, "71", amount.String())
+	_, ok = converter.Convert(decimal.NewFromInt(10), "USD", "JPY")
+	assert.False(t, ok)
+}
+
+// countingSource 记录查询次数的汇率来源
+type countingSource struct {
+	RateSource
+	lock  sync.Mutex
+	calls int
+}
+
+// Rate 查询汇率
+func (s *countingSource) Rate(ctx context.Context, from, to string, date time.Time) (Rate, error) {
+	s.lock.Lock()
+	s.calls++
+	s.lock.Unlock()
+	return s.RateSource.Rate(ctx, from, to, date)
+}
+
+// TestSnapshot 测试汇率快照
+func TestSnapshot(t *testing.T) {
+	source := &countingSource{
+		RateSource: NewStaticSource(StaticOptions{Base: "CNY", Rates: map[string]float64{"USD": 7}}),
+	}
+	s := NewSnapshot(NewConverter(source), SnapshotOptions{To: "cny", Currencies: []string{"USD", "EUR", "CNY"}})
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	// 刷新前没有汇率，只能换算相同货币
+	s.refreshing = true
+	_, ok := s.Convert(decimal.NewFromInt(1), "USD", "CNY")
+	assert.False(t, ok)
+	s.refreshing = false
+
+	s.Refresh(context.Background())
+	calls := source.calls
+
+	amount, ok := s.Convert(decimal.NewFromInt(10), "USD", "CNY")
+	assert.True(t, ok)
+	assert.Equal(t, "70", amount.String())
+	amount, ok = s.Convert(decimal.NewFromInt(70), "CNY", "usd")
+	assert.True(t, ok)
+	assert.Equal(t, "10", amount.String())
+
+	// 查询失败的汇率在刷新间隔内不再查询
+	_, ok = s.Convert(decimal.NewFromInt(10), "EUR", "CNY")
+	assert.False(t, ok)
+	_, ok = s.Convert(decimal.NewFromInt(10), "JPY", "CNY")
+	assert.False(t, ok)
+	assert.Equal(t, calls, source.calls)
+}
+
+// TestNewConverterFromOptions 测试根据配置创建汇率换算器
+func TestNewConverterFromOptions(t *testing.T) {
+	dataRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dataRoot, DefaultCSVDir), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataRoot, DefaultCSVDir, "rates.csv"), []byte(`date,from,to,rate
+2024-01-02,USD,HKD,7.8
+`), 0o644))
+
+	c, err := NewConverterFromOptions(Options{Sources: []SourceOptions{
+		{Name: "offline", CSV: &CSVOptions{}},
+		{ECB: &ECBOptions{}},
+		{Static: &StaticOptions{Base: "USD"}},
+	}}, dataRoot, logr.Discard())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"offline", ECBSourceName, StaticSourceName}, c.Sources())
+
+	r, err := c.Rate(context.Background(), "HKD", "USD", date("2024-01-03"))
+	require.NoError(t, err)
+	assert.Equal(t, "offline", r.Source)
+
+	_, err = NewConverterFromOptions(Options{Sources: []SourceOptions{{}}}, dataRoot, logr.Discard())
+	assert.Error(t, err)
+	_, err = NewConverterFromOptions(Options{Sources: []SourceOptions{
+		{CSV: &CSVOptions{}, Static: &StaticOptions{}},
+	}}, dataRoot, logr.Discard())
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Options 汇率配置
+type Options struct {
+	// 基准货币，投资组合、费用统计等未单独设置货币时，汇总金额换算为该货币
+	BaseCurrency string `json:"baseCurrency,omitempty"`
+	// 汇率来源，按顺序尝试
+	Sources []SourceOptions `json:"sources,omitempty"`
+}
+
+// SourceOptions 汇率来源配置，只能设置 csv 、 ecb 、 static 中的一项
+type SourceOptions struct {
+	// 来源名，默认为类型名
+	Name string `json:"name,omitempty"`
+
+	// 离线 CSV 文件
+	CSV *CSVOptions `json:"csv,omitempty"`
+	// 欧洲央行参考汇率
+	ECB *ECBOptions `json:"ecb,omitempty"`
+	// 固定汇率
+	Static *StaticOptions `json:"static,omitempty"`
+}
+
+// NewSource 根据配置创建汇率来源， CSV 文件为相对路径时相对于 dataRoot
+func (opts SourceOptions) NewSource(dataRoot string, logger logr.Logger) (RateSource, error) {
+	var (
+		s   RateSource
+		err error
+	)
+	switch {
+	case opts.CSV != nil && opts.ECB == nil && opts.Static == nil:
+		csvOpts := *opts.CSV
+		if csvOpts.Path == "" {
+			csvOpts.Path = DefaultCSVDir
+		}
+		if !filepath.IsAbs(csvOpts.Path) {
+			csvOpts.Path = filepath.Join(dataRoot, csvOpts.Path)
+		}
+		s = NewCSVSource(csvOpts)
+	case opts.ECB != nil && opts.CSV == nil && opts.Static == nil:
+		if s, err = NewECBSource(*opts.ECB, logger); err != nil {
+			return nil, fmt.Errorf("ecb: %w", err)
+		}
+	case opts.Static != nil && opts.CSV == nil && opts.ECB == nil:
+		s = NewStaticSource(*opts.Static)
+	default:
+		return nil, fmt.Errorf("exactly one of csv, ecb and static must be set")
+	}
+	if opts.Name != "" {
+		s = namedSource{RateSource: s, name: opts.Name}
+	}
+	return s, nil
+}
+
+// NewConverterFromOptions 根据配置创建汇率换算器，参数含义同 SourceOptions.NewSource
+func NewConverterFromOptions(opts Options, dataRoot string, logger logr.Logger) (*Converter, error) {
+	sources := make([]RateSource, 0, len(opts.Sources))
+	for i, o := range opts.Sources {
+		s, err := o.NewSource(dataRoot, logger)
+		if err != nil {
+			return nil, fmt.Errorf("fx.sources[%d]: %w", i, err)
+		}
+		sources = append(sources, s)
+	}
+	return NewConverter(sources...), nil
+}
+
+// namedSource 使用配置的名字的汇率来源
+type namedSource struct {
+	RateSource
+	name string
+}
+
+// Name 来源名
+func (s namedSource) Name() string {
+	return s.name
+}
+
+// Rate 查询汇率
+func (s namedSource) Rate(ctx context.Context, from, to string, date time.Time) (Rate, error) {
+	r, err := s.RateSource.Rate(ctx, from, to, date)
+	if err != nil {
+		return Rate{}, err
+	}
+	r.Source = s.name
+	return r, nil
+}
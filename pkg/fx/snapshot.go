@@ -0,0 +1,127 @@
+package fx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultSnapshotInterval 汇率快照的默认刷新间隔
+const DefaultSnapshotInterval = time.Hour
+
+// SnapshotOptions 汇率快照选项
+type SnapshotOptions struct {
+	// 目标货币
+	To string
+	// 需要换算为目标货币的货币
+	Currencies []string
+	// 刷新间隔，默认 DefaultSnapshotInterval
+	Interval time.Duration
+	Logger   logr.Logger
+}
+
+// NewSnapshot 创建汇率快照
+func NewSnapshot(converter *Converter, opts SnapshotOptions) *Snapshot {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultSnapshotInterval
+	}
+	currencies := make([]string, 0, len(opts.Currencies))
+	for _, currency := range opts.Currencies {
+		currencies = append(currencies, NormalizeCurrency(currency))
+	}
+	return &Snapshot{
+		converter:  converter,
+		to:         NormalizeCurrency(opts.To),
+		currencies: currencies,
+		interval:   opts.Interval,
+		logger:     opts.Logger,
+		now:        time.Now,
+		rates:      map[string]decimal.Decimal{},
+	}
+}
+
+// Snapshot 各货币到目标货币的最新汇率快照，与 AmountConverter 兼容
+//
+// 换算时只使用快照中的汇率，不等待汇率查询，适用于模型调用后统计费用等不能阻塞的场景。
+// 快照过期后在换算时触发后台刷新，查询失败的汇率保留上次的结果，在下次刷新前不再查询
+type Snapshot struct {
+	converter  *Converter
+	to         string
+	currencies []string
+	interval   time.Duration
+	logger     logr.Logger
+	now        func() time.Time
+
+	lock       sync.RWMutex
+	rates      map[string]decimal.Decimal
+	refreshed  time.Time
+	refreshing bool
+}
+
+var _ AmountConverter = (*Snapshot)(nil)
+
+// Refresh 查询各货币到目标货币的最新汇率并更新快照
+func (s *Snapshot) Refresh(ctx context.Context) {
+	s.lock.Lock()
+	if s.refreshing {
+		s.lock.Unlock()
+		return
+	}
+	s.refreshing = true
+	s.lock.Unlock()
+
+	rates := make(map[string]decimal.Decimal, len(s.currencies))
+	for _, currency := range s.currencies {
+		if currency == s.to {
+			continue
+		}
+		rate, err := s.converter.Rate(ctx, currency, s.to, time.Time{})
+		if err != nil {
+			s.logger.Error(err, "refresh exchange rate snapshot error", "from", currency, "to", s.to)
+			continue
+		}
+		rates[currency] = rate.Rate
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for currency, rate := range rates {
+		s.rates[currency] = rate
+	}
+	s.refreshed = s.now()
+	s.refreshing = false
+}
+
+// Convert 将 from 货币的金额换算为 to 货币，快照中没有所需汇率时返回 false
+func (s *Snapshot) Convert(amount decimal.Decimal, from, to string) (decimal.Decimal, bool) {
+	from, to = NormalizeCurrency(from), NormalizeCurrency(to)
+	if from == to {
+		return amount, true
+	}
+
+	s.lock.RLock()
+	fromRate, fromOK := s.rate(from)
+	toRate, toOK := s.rate(to)
+	stale := !s.refreshing && s.now().Sub(s.refreshed) >= s.interval
+	s.lock.RUnlock()
+	if stale {
+		go s.Refresh(context.Background())
+	}
+
+	if !fromOK || !toOK || toRate.IsZero() {
+		return decimal.Zero, false
+	}
+	return amount.Mul(fromRate).DivRound(toRate, 8), true
+}
+
+// rate 获取 1 单位指定货币折合目标货币的数量
+func (s *Snapshot) rate(currency string) (decimal.Decimal, bool) {
+	if currency == s.to {
+		return decimal.NewFromInt(1), true
+	}
+	rate, ok := s.rates[currency]
+	return rate, ok
+}
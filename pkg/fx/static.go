@@ -0,0 +1,69 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StaticSourceName 固定汇率来源的默认名
+const StaticSourceName = "static"
+
+// StaticOptions 固定汇率来源选项
+type StaticOptions struct {
+	// 基准货币代码
+	Base string `json:"base"`
+	// 汇率，键为货币代码，值为 1 单位该货币折合基准货币的数量
+	Rates map[string]float64 `json:"rates"`
+}
+
+// StaticSource 固定汇率来源，不区分日期
+type StaticSource struct {
+	base  string
+	rates map[string]decimal.Decimal
+}
+
+var _ RateSource = (*StaticSource)(nil)
+
+// NewStaticSource 创建固定汇率来源
+func NewStaticSource(opts StaticOptions) *StaticSource {
+	s := &StaticSource{
+		base:  NormalizeCurrency(opts.Base),
+		rates: make(map[string]decimal.Decimal, len(opts.Rates)),
+	}
+	for k, v := range opts.Rates {
+		if v > 0 {
+			s.rates[NormalizeCurrency(k)] = decimal.NewFromFloat(v)
+		}
+	}
+	return s
+}
+
+// Name 来源名
+func (s *StaticSource) Name() string {
+	return StaticSourceName
+}
+
+// Rate 查询汇率，忽略日期
+func (s *StaticSource) Rate(_ context.Context, from, to string, _ time.Time) (Rate, error) {
+	fromRate, ok := s.rate(from)
+	if !ok {
+		return Rate{}, fmt.Errorf("%s/%s: %w", from, to, ErrRateNotFound)
+	}
+	toRate, ok := s.rate(to)
+	if !ok {
+		return Rate{}, fmt.Errorf("%s/%s: %w", from, to, ErrRateNotFound)
+	}
+	return Rate{From: from, To: to, Rate: fromRate.DivRound(toRate, ratePlaces), Source: s.Name()}, nil
+}
+
+// rate 获取 1 单位指定货币折合基准货币的数量
+func (s *StaticSource) rate(currency string) (decimal.Decimal, bool) {
+	if s.base != "" && currency == s.base {
+		return decimal.NewFromInt(1), true
+	}
+	v, ok := s.rates[currency]
+	return v, ok
+}
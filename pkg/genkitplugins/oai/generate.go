@@ -234,6 +234,7 @@ func (g *ModelGenerator) generateStream(ctx context.Context, handleChunk core.St
 		fullResponse.Usage.OutputTokens += int(chunk.Usage.CompletionTokens)
 		fullResponse.Usage.ThoughtsTokens += int(chunk.Usage.CompletionTokensDetails.ReasoningTokens)
 		fullResponse.Usage.CachedContentTokens += int(chunk.Usage.PromptTokensDetails.CachedTokens)
+		addCacheWriteTokens(fullResponse.Usage, chunk.Usage)
 		fullResponse.Usage.TotalTokens += int(chunk.Usage.TotalTokens)
 
 		if len(chunk.Choices) == 0 {
@@ -363,6 +364,7 @@ func (g *ModelGenerator) generateComplete(ctx context.Context, req *ai.ModelRequ
 			Role: ai.RoleModel,
 		},
 	}
+	addCacheWriteTokens(resp.Usage, completion.Usage)
 
 	if len(completion.Choices) == 0 {
 		resp.FinishReason = ai.FinishReasonUnknown
@@ -498,3 +500,26 @@ func anyToJSONString(data any) (string, error) {
 	}
 	return string(jsonBytes), nil
 }
+
+// UsageCacheWriteTokens ai.GenerationUsage.Custom 中记录写入缓存的输入 Token 数的键
+const UsageCacheWriteTokens = "cacheWriteTokens"
+
+// addCacheWriteTokens 从用量的扩展字段中读取写入缓存的 Token 数并累加
+//
+// OpenRouter 等供应商使用 prompt_tokens_details.cache_write_tokens ，
+// Anthropic 兼容接口使用 cache_creation_input_tokens
+func addCacheWriteTokens(usage *ai.GenerationUsage, raw openai.CompletionUsage) {
+	var n int64
+	if f, ok := raw.PromptTokensDetails.JSON.ExtraFields["cache_write_tokens"]; ok {
+		_ = json.Unmarshal([]byte(f.Raw()), &n)
+	} else if f, ok := raw.JSON.ExtraFields["cache_creation_input_tokens"]; ok {
+		_ = json.Unmarshal([]byte(f.Raw()), &n)
+	}
+	if n <= 0 {
+		return
+	}
+	if usage.Custom == nil {
+		usage.Custom = map[string]float64{}
+	}
+	usage.Custom[UsageCacheWriteTokens] += float64(n)
+}
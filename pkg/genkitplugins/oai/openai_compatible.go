@@ -2,6 +2,7 @@ package oai
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"sync"
 
@@ -9,18 +10,24 @@ import (
 	"github.com/firebase/genkit/go/core"
 	"github.com/firebase/genkit/go/core/api"
 	"github.com/firebase/genkit/go/genkit"
+	"github.com/go-logr/logr"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
+
+	"github.com/yhlooo/nfa/pkg/httpclient"
 )
 
 // OpenAICompatible OpenAI 兼容模型插件
 type OpenAICompatible struct {
-	Provider string
-	BaseURL  string
-	APIKey   string
+	Provider    string
+	BaseURL     string
+	APIKey      string
+	HTTPOptions httpclient.Options
 
 	lock    sync.Mutex
 	initted bool
+	// 初始化时产生的错误，调用模型时返回
+	initErr error
 
 	client *openai.Client
 	models []string
@@ -37,7 +44,7 @@ func (d *OpenAICompatible) Name() string {
 }
 
 // Init 初始化插件
-func (d *OpenAICompatible) Init(_ context.Context) []api.Action {
+func (d *OpenAICompatible) Init(ctx context.Context) []api.Action {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
@@ -45,8 +52,17 @@ func (d *OpenAICompatible) Init(_ context.Context) []api.Action {
 		panic("plugin already initialized")
 	}
 
+	// 即使未设置选项也使用 httpclient 创建的客户端，以输出请求、响应调试日志
+	logger := logr.FromContextOrDiscard(ctx)
+	httpClient, err := httpclient.NewClient(d.HTTPOptions, logger)
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("create http client for provider %q error", d.Name()))
+		d.initErr = fmt.Errorf("create http client error: %w", err)
+		httpClient = http.DefaultClient
+	}
+
 	opts := []option.RequestOption{
-		option.WithHTTPClient(http.DefaultClient),
+		option.WithHTTPClient(httpClient),
 		option.WithBaseURL(d.BaseURL),
 	}
 	if d.APIKey != "" {
@@ -119,6 +135,9 @@ func (d *OpenAICompatible) defineModel(opts ModelOptions) ai.Model {
 		req *ai.ModelRequest,
 		cb core.StreamCallback[*ai.ModelResponseChunk],
 	) (*ai.ModelResponse, error) {
+		if d.initErr != nil {
+			return nil, d.initErr
+		}
 		generator := NewModelGenerator(d.client, req, opts).
 			WithMessages(req.Messages).
 			WithTools(req.Tools)
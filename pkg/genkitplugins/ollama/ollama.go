@@ -0,0 +1,330 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+	"github.com/firebase/genkit/go/core/api"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/go-logr/logr"
+
+	"github.com/yhlooo/nfa/pkg/httpclient"
+)
+
+const provider = "ollama"
+
+// Ollama Ollama 模型插件
+//
+// 与 genkit 自带的 ollama 插件相比，使用按 HTTPOptions 创建的独立 HTTP 客户端发送请求
+type Ollama struct {
+	// 服务端地址
+	ServerAddress string
+	// 模型响应超时时间，秒
+	//
+	// HTTPOptions.Timeout 大于 0 时使用 HTTPOptions.Timeout
+	Timeout     int
+	HTTPOptions httpclient.Options
+
+	lock    sync.Mutex
+	initted bool
+	// 初始化时产生的错误，调用模型时返回
+	initErr error
+
+	client *http.Client
+}
+
+var _ api.Plugin = (*Ollama)(nil)
+
+// Name 返回插件名
+func (o *Ollama) Name() string {
+	return provider
+}
+
+// Init 初始化插件
+func (o *Ollama) Init(ctx context.Context) []api.Action {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.initted {
+		panic("plugin already initialized")
+	}
+	if o.ServerAddress == "" {
+		panic("ollama: need ServerAddress")
+	}
+
+	logger := logr.FromContextOrDiscard(ctx)
+	client, err := httpclient.NewClient(o.HTTPOptions, logger)
+	if err != nil {
+		logger.Error(err, "create http client for provider \"ollama\" error")
+		o.initErr = fmt.Errorf("create http client error: %w", err)
+		client = &http.Client{}
+	}
+	if o.HTTPOptions.Timeout <= 0 {
+		client.Timeout = time.Duration(o.Timeout) * time.Second
+	}
+	o.client = client
+	o.initted = true
+
+	return nil
+}
+
+// DefineModel 定义对话模型
+func (o *Ollama) DefineModel(g *genkit.Genkit, name string, opts *ai.ModelOptions) ai.Model {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if !o.initted {
+		panic("plugin not initialized")
+	}
+
+	return genkit.DefineModel(g, api.NewName(provider, name), opts, func(
+		ctx context.Context,
+		req *ai.ModelRequest,
+		cb core.StreamCallback[*ai.ModelResponseChunk],
+	) (*ai.ModelResponse, error) {
+		if o.initErr != nil {
+			return nil, o.initErr
+		}
+		return o.generate(ctx, name, req, cb)
+	})
+}
+
+// chatRequest 对话请求
+type chatRequest struct {
+	Model    string     `json:"model"`
+	Messages []*message `json:"messages"`
+	Tools    []tool     `json:"tools,omitempty"`
+	Stream   bool       `json:"stream"`
+}
+
+// message 对话消息
+type message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content,omitempty"`
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+}
+
+// tool 工具定义
+type tool struct {
+	Type     string   `json:"type"`
+	Function function `json:"function"`
+}
+
+// function 函数定义
+type function struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// toolCall 工具调用
+type toolCall struct {
+	Function functionCall `json:"function"`
+}
+
+// functionCall 函数调用
+type functionCall struct {
+	Name      string `json:"name"`
+	Arguments any    `json:"arguments"`
+}
+
+// chatResponse 对话响应，流式响应的每一行也是该格式
+type chatResponse struct {
+	Message         message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason,omitempty"`
+	PromptEvalCount int     `json:"prompt_eval_count,omitempty"`
+	EvalCount       int     `json:"eval_count,omitempty"`
+}
+
+// roles genkit 角色到 Ollama 角色的映射
+var roles = map[ai.Role]string{
+	ai.RoleUser:   "user",
+	ai.RoleModel:  "assistant",
+	ai.RoleSystem: "system",
+	ai.RoleTool:   "tool",
+}
+
+// generate 调用 Ollama 对话接口生成回复
+func (o *Ollama) generate(
+	ctx context.Context,
+	model string,
+	req *ai.ModelRequest,
+	cb core.StreamCallback[*ai.ModelResponseChunk],
+) (*ai.ModelResponse, error) {
+	chatReq := chatRequest{
+		Model:  model,
+		Stream: cb != nil,
+	}
+	for _, m := range req.Messages {
+		msg, err := convertMessage(m)
+		if err != nil {
+			return nil, err
+		}
+		chatReq.Messages = append(chatReq.Messages, msg)
+	}
+	for _, t := range req.Tools {
+		chatReq.Tools = append(chatReq.Tools, tool{
+			Type: "function",
+			Function: function{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.InputSchema,
+			},
+		})
+	}
+
+	body, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request error: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, strings.TrimSuffix(o.ServerAddress, "/")+"/api/chat", bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create request error: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request error: %w", err)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+	if httpResp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(httpResp.Body)
+		return nil, fmt.Errorf("server returned non-200 status: %d, body: %s", httpResp.StatusCode, data)
+	}
+
+	resp := &ai.ModelResponse{
+		Request:      req,
+		FinishReason: ai.FinishReasonStop,
+		Message:      &ai.Message{Role: ai.RoleModel},
+		Usage:        &ai.GenerationUsage{},
+	}
+	handle := func(line []byte) error {
+		var chunk chatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("unmarshal response error: %w", err)
+		}
+		parts := convertResponseParts(chunk.Message)
+		resp.Message.Content = append(resp.Message.Content, parts...)
+		if chunk.Done {
+			resp.Usage.InputTokens = chunk.PromptEvalCount
+			resp.Usage.OutputTokens = chunk.EvalCount
+			resp.Usage.TotalTokens = chunk.PromptEvalCount + chunk.EvalCount
+			if chunk.DoneReason == "length" {
+				resp.FinishReason = ai.FinishReasonLength
+			}
+		}
+		if cb != nil && len(parts) > 0 {
+			return cb(ctx, &ai.ModelResponseChunk{Role: ai.RoleModel, Content: parts})
+		}
+		return nil
+	}
+
+	if cb == nil {
+		data, err := io.ReadAll(httpResp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read response error: %w", err)
+		}
+		if err := handle(data); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := handle(line); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read response stream error: %w", err)
+	}
+	return resp, nil
+}
+
+// convertMessage 将 genkit 消息转为 Ollama 消息
+func convertMessage(m *ai.Message) (*message, error) {
+	msg := &message{Role: roles[m.Role]}
+	content := &strings.Builder{}
+	for _, part := range m.Content {
+		switch {
+		case part.IsText():
+			content.WriteString(part.Text)
+		case part.IsReasoning():
+			// 思考内容不回传给模型
+		case part.IsMedia():
+			data, err := mediaData(part.Text)
+			if err != nil {
+				return nil, err
+			}
+			msg.Images = append(msg.Images, data)
+		case part.IsToolRequest():
+			msg.ToolCalls = append(msg.ToolCalls, toolCall{Function: functionCall{
+				Name:      part.ToolRequest.Name,
+				Arguments: part.ToolRequest.Input,
+			}})
+		case part.IsToolResponse():
+			output, err := json.Marshal(part.ToolResponse.Output)
+			if err != nil {
+				return nil, fmt.Errorf("marshal tool response error: %w", err)
+			}
+			content.Write(output)
+		default:
+			return nil, fmt.Errorf("unsupported content type %q", part.Kind)
+		}
+	}
+	msg.Content = content.String()
+	return msg, nil
+}
+
+// mediaData 返回 data URL 中 base64 编码的数据
+func mediaData(uri string) (string, error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return "", fmt.Errorf("unsupported media url %q, only data url is supported", uri)
+	}
+	meta, data, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", fmt.Errorf("invalid data url")
+	}
+	if strings.HasSuffix(meta, ";base64") {
+		return data, nil
+	}
+	return base64.StdEncoding.EncodeToString([]byte(data)), nil
+}
+
+// convertResponseParts 将 Ollama 回复消息转为 genkit 消息片段
+func convertResponseParts(msg message) []*ai.Part {
+	var parts []*ai.Part
+	for _, call := range msg.ToolCalls {
+		parts = append(parts, ai.NewToolRequestPart(&ai.ToolRequest{
+			Name:  call.Function.Name,
+			Input: call.Function.Arguments,
+		}))
+	}
+	if msg.Content != "" {
+		parts = append(parts, ai.NewTextPart(msg.Content))
+	}
+	return parts
+}
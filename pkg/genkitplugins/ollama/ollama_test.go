@@ -0,0 +1,80 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/httpclient"
+)
+
+// TestGenerate 测试使用插件自己的 HTTP 客户端调用对话接口
+func TestGenerate(t *testing.T) {
+	var got chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		assert.Equal(t, "token", r.Header.Get("X-Gateway-Auth"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if got.Stream {
+			_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"Hel"},"done":false}` + "\n"))
+			_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"lo"},"done":true,"prompt_eval_count":3,"eval_count":2}` + "\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","tool_calls":[{"function":{"name":"quote","arguments":{"symbol":"NVDA"}}}]},"done":true}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	plugin := &Ollama{
+		ServerAddress: server.URL,
+		Timeout:       10,
+		HTTPOptions:   httpclient.Options{Headers: map[string]string{"X-Gateway-Auth": "token"}},
+	}
+	g := genkit.Init(ctx, genkit.WithPlugins(plugin))
+	m := plugin.DefineModel(g, "llama3.2", &ai.ModelOptions{Supports: &ai.ModelSupports{Multiturn: true, Tools: true}})
+	assert.Equal(t, "ollama/llama3.2", m.Name())
+
+	// 流式
+	var chunks []string
+	resp, err := genkit.Generate(ctx, g,
+		ai.WithModel(m),
+		ai.WithPrompt("hi"),
+		ai.WithStreaming(func(ctx context.Context, chunk *ai.ModelResponseChunk) error {
+			chunks = append(chunks, chunk.Text())
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "llama3.2", got.Model)
+	require.Len(t, got.Messages, 1)
+	assert.Equal(t, "user", got.Messages[0].Role)
+	assert.Equal(t, "hi", got.Messages[0].Content)
+	assert.Equal(t, []string{"Hel", "lo"}, chunks)
+	assert.Equal(t, "Hello", resp.Text())
+	assert.Equal(t, 5, resp.Usage.TotalTokens)
+
+	// 工具调用
+	resp, err = genkit.Generate(ctx, g,
+		ai.WithModel(m),
+		ai.WithPrompt("quote NVDA"),
+		ai.WithTools(genkit.DefineTool(g, "quote", "Get quote", func(ctx *ai.ToolContext, in struct {
+			Symbol string `json:"symbol"`
+		}) (string, error) {
+			return "", nil
+		})),
+		ai.WithReturnToolRequests(true),
+	)
+	require.NoError(t, err)
+	require.Len(t, got.Tools, 1)
+	assert.Equal(t, "quote", got.Tools[0].Function.Name)
+	require.Len(t, resp.ToolRequests(), 1)
+	assert.Equal(t, "quote", resp.ToolRequests()[0].Name)
+	assert.Equal(t, map[string]any{"symbol": "NVDA"}, resp.ToolRequests()[0].Input)
+}
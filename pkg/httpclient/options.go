@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Options HTTP 客户端选项
+type Options struct {
+	// 每个请求附加的请求头
+	Headers map[string]string `json:"headers,omitempty"`
+	// 代理地址，如 http://proxy.example.com:8080
+	//
+	// 为空时使用环境变量 HTTP_PROXY 、 HTTPS_PROXY 、 NO_PROXY
+	Proxy string `json:"proxy,omitempty"`
+	// 额外信任的 CA 证书文件路径（ PEM 格式），追加到系统证书池
+	CAFile string `json:"caFile,omitempty"`
+	// 是否跳过服务端证书校验
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// 请求总超时时间，秒
+	//
+	// 默认 0 表示不超时
+	Timeout int `json:"timeout,omitempty"`
+	// 建立连接超时时间，秒
+	//
+	// 默认 30
+	DialTimeout int `json:"dialTimeout,omitempty"`
+	// 等待响应头超时时间，秒
+	//
+	// 默认 0 表示不超时
+	ResponseHeaderTimeout int `json:"responseHeaderTimeout,omitempty"`
+}
+
+// NewClient 根据选项创建 HTTP 客户端
+//
+// 返回的客户端在日志级别为 2 时输出请求、响应调试日志，其中的密钥会被脱敏
+func NewClient(opts Options, logger logr.Logger) (*http.Client, error) {
+	transport, err := NewRoundTripper(opts, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(opts.Timeout) * time.Second,
+	}, nil
+}
+
+// NewRoundTripper 根据选项创建附加请求头并输出调试日志的 http.RoundTripper
+//
+// 不包括请求总超时时间 Timeout ，由使用方在客户端上设置
+func NewRoundTripper(opts Options, logger logr.Logger) (http.RoundTripper, error) {
+	transport, err := NewTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Transport{
+		Base:    transport,
+		Headers: opts.Headers,
+		Logger:  logger,
+	}, nil
+}
+
+// NewTransport 根据选项创建 *http.Transport
+//
+// 以 http.DefaultClient 的 Transport 为基础，以保留 SSLKEYLOGFILE 等全局设置
+func NewTransport(opts Options) (*http.Transport, error) {
+	var transport *http.Transport
+	if base, ok := http.DefaultClient.Transport.(*http.Transport); ok && base != nil {
+		transport = base.Clone()
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	// 代理
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", opts.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	// 超时
+	dialTimeout := 30 * time.Second
+	if opts.DialTimeout > 0 {
+		dialTimeout = time.Duration(opts.DialTimeout) * time.Second
+	}
+	transport.DialContext = (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.ResponseHeaderTimeout = time.Duration(opts.ResponseHeaderTimeout) * time.Second
+
+	// TLS
+	if opts.CAFile != "" || opts.InsecureSkipVerify {
+		tlsConfig := transport.TLSClientConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		if opts.CAFile != "" {
+			pem, err := os.ReadFile(opts.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("read ca file %q error: %w", opts.CAFile, err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no valid certificate found in ca file %q", opts.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		tlsConfig.InsecureSkipVerify = opts.InsecureSkipVerify
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return transport, nil
+}
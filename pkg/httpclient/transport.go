@@ -0,0 +1,163 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// maxLoggedBodySize 调试日志中最多输出的请求体大小
+const maxLoggedBodySize = 4 << 10
+
+// Transport 附加请求头并输出调试日志的 http.RoundTripper
+type Transport struct {
+	// 实际发送请求的 RoundTripper
+	Base http.RoundTripper
+	// 每个请求附加的请求头
+	Headers map[string]string
+	// 请求上下文中没有 logger 时使用的 logger
+	Logger logr.Logger
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// RoundTrip 发送请求
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.Headers) > 0 {
+		req = req.Clone(req.Context())
+		for k, v := range t.Headers {
+			req.Header.Set(k, v)
+		}
+	}
+
+	logger, err := logr.FromContext(req.Context())
+	if err != nil {
+		logger = t.Logger
+	}
+	logger = logger.V(2)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if !logger.Enabled() {
+		return base.RoundTrip(req)
+	}
+
+	// 输出请求
+	body := ""
+	if req.Body != nil && req.Body != http.NoBody {
+		raw, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read request body error: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(raw))
+		body = truncate(string(raw), maxLoggedBodySize)
+	}
+	logger.Info(fmt.Sprintf(
+		"http request: %s %s, headers: %s, body: %s",
+		req.Method, RedactURL(req.URL), formatHeaders(req.Header), body,
+	))
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		logger.Info(fmt.Sprintf(
+			"http request error: %s %s, duration: %s, error: %s",
+			req.Method, RedactURL(req.URL), time.Since(start), err,
+		))
+		return resp, err
+	}
+
+	// 响应体可能是流式的，仅输出状态和响应头
+	logger.Info(fmt.Sprintf(
+		"http response: %s %s, status: %d, duration: %s, headers: %s",
+		req.Method, RedactURL(req.URL), resp.StatusCode, time.Since(start), formatHeaders(resp.Header),
+	))
+	return resp, nil
+}
+
+// sensitiveKeywords 名称中包含这些关键字的请求头或查询参数被视为敏感信息
+var sensitiveKeywords = []string{"auth", "key", "token", "secret", "cookie", "password", "signature"}
+
+// isSensitive 判断请求头或查询参数名是否敏感
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, kw := range sensitiveKeywords {
+		if strings.Contains(name, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeaders 返回脱敏后的请求头副本
+func RedactHeaders(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, values := range h {
+		if !isSensitive(k) {
+			out[k] = values
+			continue
+		}
+		redacted := make([]string, len(values))
+		for i := range values {
+			redacted[i] = "<redacted>"
+		}
+		out[k] = redacted
+	}
+	return out
+}
+
+// RedactURL 返回查询参数中密钥脱敏后的 URL 字符串
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	query := u.Query()
+	changed := false
+	for k := range query {
+		if isSensitive(k) {
+			query.Set(k, "<redacted>")
+			changed = true
+		}
+	}
+	out := *u
+	out.User = nil
+	if changed {
+		out.RawQuery = query.Encode()
+	}
+	return out.String()
+}
+
+// formatHeaders 格式化输出脱敏后的请求头
+func formatHeaders(h http.Header) string {
+	redacted := RedactHeaders(h)
+	keys := make([]string, 0, len(redacted))
+	for k := range redacted {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+strings.Join(redacted[k], ","))
+	}
+	return "{" + strings.Join(parts, "; ") + "}"
+}
+
+// truncate 截断过长的字符串
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + fmt.Sprintf("...(%d bytes truncated)", len(s)-n)
+}
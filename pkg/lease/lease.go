@@ -0,0 +1,166 @@
+package lease
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TTL 租约有效期，持有者超过该时间未续约时其它进程可以接管
+const TTL = 2 * time.Minute
+
+const (
+	// lockWait 等待其它进程释放锁文件的最长时间
+	lockWait = 5 * time.Second
+	// staleLockAge 锁文件存在超过该时间时视为持有的进程已异常退出
+	staleLockAge = 30 * time.Second
+)
+
+// Lease 租约，同一数据目录下只有持有租约的进程（后台服务或终端界面）执行后台任务
+type Lease struct {
+	// 持有者标识
+	Owner string `json:"owner"`
+	// 持有者进程 ID
+	PID int `json:"pid"`
+	// 持有者主机名
+	Host string `json:"host,omitempty"`
+	// 最近续约时间
+	RenewedAt time.Time `json:"renewedAt"`
+}
+
+// NewFile 创建保存在 path 文件中的租约
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// File 保存在文件中的租约
+type File struct {
+	lock sync.Mutex
+	path string
+}
+
+// Get 加载当前租约，没有租约时返回 nil
+func (f *File) Get() (*Lease, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.load()
+}
+
+// Acquire 获取或续约租约，租约由其它持有者持有且未过期时返回 false
+//
+// 读取和写入租约期间持有锁文件，多个进程同时获取时只有一个成功
+func (f *File) Acquire(owner string, now time.Time) (bool, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	unlock, err := f.lockFile()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	lease, err := f.load()
+	if err != nil {
+		return false, err
+	}
+	if lease != nil && lease.Owner != owner && now.Sub(lease.RenewedAt) < TTL {
+		return false, nil
+	}
+
+	host, _ := os.Hostname()
+	if err := f.save(&Lease{Owner: owner, PID: os.Getpid(), Host: host, RenewedAt: now}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release 释放 owner 持有的租约
+func (f *File) Release(owner string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	unlock, err := f.lockFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	lease, err := f.load()
+	if err != nil || lease == nil || lease.Owner != owner {
+		return err
+	}
+	if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove lease file %q error: %w", f.path, err)
+	}
+	return nil
+}
+
+// lockFile 以 O_EXCL 方式创建锁文件，在进程间互斥地读写租约，返回删除锁文件的函数
+func (f *File) lockFile() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return nil, fmt.Errorf("create directory error: %w", err)
+	}
+	path := f.path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		lockFile, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = lockFile.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create lock file %q error: %w", path, err)
+		}
+		// 持有锁的进程异常退出时不会删除锁文件，过期后删除
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock file %q is held by another process", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// load 加载租约，文件不存在时返回 nil
+func (f *File) load() (*Lease, error) {
+	raw, err := os.ReadFile(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read lease file %q error: %w", f.path, err)
+	}
+	var lease *Lease
+	if err := json.Unmarshal(raw, &lease); err != nil {
+		return nil, fmt.Errorf("unmarshal lease file %q error: %w", f.path, err)
+	}
+	return lease, nil
+}
+
+// save 保存租约，先写入同目录下名称唯一的临时文件再重命名
+func (f *File) save(lease *Lease) error {
+	raw, err := json.MarshalIndent(lease, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal lease error: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file error: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, err = tmp.Write(raw)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write file %q error: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("rename %q to %q error: %w", tmp.Name(), f.path, err)
+	}
+	return nil
+}
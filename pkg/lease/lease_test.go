@@ -0,0 +1,74 @@
+package lease
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFile 测试获取、续约和释放租约
+func TestFile(t *testing.T) {
+	f := NewFile(filepath.Join(t.TempDir(), "lease.json"))
+	now := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+
+	ok, err := f.Acquire("a", now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = f.Acquire("b", now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// 过期后可以接管
+	ok, err = f.Acquire("b", now.Add(TTL))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, f.Release("a"))
+	lease, err := f.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "b", lease.Owner)
+	require.NoError(t, f.Release("b"))
+	lease, err = f.Get()
+	require.NoError(t, err)
+	assert.Nil(t, lease)
+}
+
+// TestFileConcurrent 测试多个进程同时获取租约时只有一个成功
+func TestFileConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	now := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+
+	var (
+		wg       sync.WaitGroup
+		acquired atomic.Int32
+	)
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个 File 模拟一个进程
+			ok, err := NewFile(path).Acquire(fmt.Sprintf("owner-%d", i), now)
+			assert.NoError(t, err)
+			if ok {
+				acquired.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), acquired.Load())
+
+	// 过期的锁文件被删除
+	require.NoError(t, os.WriteFile(path+".lock", nil, 0o600))
+	stale := time.Now().Add(-2 * staleLockAge)
+	require.NoError(t, os.Chtimes(path+".lock", stale, stale))
+	ok, err := NewFile(path).Acquire("late", now.Add(TTL))
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
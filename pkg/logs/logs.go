@@ -0,0 +1,195 @@
+package logs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bombsimon/logrusr/v4"
+	"github.com/go-logr/logr"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// 日志格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// 日志输出
+const (
+	OutputFile   = "file"
+	OutputStderr = "stderr"
+	OutputStdout = "stdout"
+)
+
+// 日志级别
+const (
+	LevelError = "error"
+	LevelInfo  = "info"
+	LevelDebug = "debug"
+	LevelTrace = "trace"
+)
+
+// 组件名，作为对应组件 logger 的名字
+const (
+	ComponentAgent    = "agent"
+	ComponentChannels = "channels"
+	ComponentTools    = "tools"
+	ComponentModels   = "models"
+)
+
+// DefaultFileName 默认日志文件名
+const DefaultFileName = "nfa.log"
+
+// Options 日志选项
+type Options struct {
+	// 格式，可选 text, json ，默认 text
+	Format string `json:"format,omitempty"`
+	// 输出，可选 file, stderr, stdout ，默认 file
+	Output string `json:"output,omitempty"`
+	// 日志文件路径，默认为数据目录下的 nfa.log
+	File string `json:"file,omitempty"`
+	// 日志文件轮转选项
+	Rotation RotationOptions `json:"rotation,omitempty"`
+	// 默认级别，可选 error, info, debug, trace ，默认由 -v 参数决定
+	Level string `json:"level,omitempty"`
+	// 各组件的级别，键为组件名，如 agent, channels, tools, models
+	Components map[string]string `json:"components,omitempty"`
+}
+
+// RotationOptions 日志文件轮转选项
+type RotationOptions struct {
+	// 单个文件最大大小（ MB ），默认 500
+	MaxSize int `json:"maxSize,omitempty"`
+	// 最多保留旧文件数，默认 3
+	MaxBackups int `json:"maxBackups,omitempty"`
+	// 旧文件最多保留天数，默认 28
+	MaxAge int `json:"maxAge,omitempty"`
+	// 是否压缩旧文件
+	Compress bool `json:"compress,omitempty"`
+}
+
+// Complete 使用默认值补全选项
+func (opts *Options) Complete(dataRoot string) {
+	if opts.Format == "" {
+		opts.Format = FormatText
+	}
+	if opts.Output == "" {
+		opts.Output = OutputFile
+	}
+	if opts.File == "" {
+		opts.File = filepath.Join(dataRoot, DefaultFileName)
+	}
+	if opts.Rotation.MaxSize == 0 {
+		opts.Rotation.MaxSize = 500
+	}
+	if opts.Rotation.MaxBackups == 0 {
+		opts.Rotation.MaxBackups = 3
+	}
+	if opts.Rotation.MaxAge == 0 {
+		opts.Rotation.MaxAge = 28
+	}
+}
+
+// Validate 校验选项
+func (opts *Options) Validate() error {
+	switch opts.Format {
+	case "", FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("invalid log format: %q (expected: %s or %s)", opts.Format, FormatText, FormatJSON)
+	}
+	switch opts.Output {
+	case "", OutputFile, OutputStderr, OutputStdout:
+	default:
+		return fmt.Errorf("invalid log output: %q (expected: %s, %s or %s)",
+			opts.Output, OutputFile, OutputStderr, OutputStdout)
+	}
+	if _, err := ParseLevel(opts.Level); err != nil {
+		return err
+	}
+	for name, level := range opts.Components {
+		if _, err := ParseLevel(level); err != nil {
+			return fmt.Errorf("invalid level of component %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ParseLevel 解析日志级别，返回对应的 logr V 级别，error 级别返回 -1 ，空字符串返回 0
+func ParseLevel(level string) (int, error) {
+	switch strings.ToLower(level) {
+	case "", LevelInfo:
+		return 0, nil
+	case LevelError:
+		return -1, nil
+	case LevelDebug:
+		return 1, nil
+	case LevelTrace:
+		return 2, nil
+	}
+	return 0, fmt.Errorf("invalid log level: %q (expected: %s, %s, %s or %s)",
+		level, LevelError, LevelInfo, LevelDebug, LevelTrace)
+}
+
+// New 根据选项创建 logger
+//
+// verbosity 为 -v 参数指定的 V 级别，大于 0 时覆盖默认级别。返回的 io.Closer 用于关闭日志文件
+func New(opts Options, dataRoot string, verbosity int) (logr.Logger, io.Closer, error) {
+	if err := opts.Validate(); err != nil {
+		return logr.Discard(), nil, err
+	}
+	opts.Complete(dataRoot)
+
+	logrusLogger := logrus.New()
+	// 级别由 componentSink 控制
+	logrusLogger.Level = logrus.TraceLevel
+
+	var closer io.Closer = nopCloser{}
+	switch opts.Output {
+	case OutputStderr:
+		logrusLogger.SetOutput(os.Stderr)
+	case OutputStdout:
+		logrusLogger.SetOutput(os.Stdout)
+	default:
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0o755); err != nil {
+			return logr.Discard(), nil, fmt.Errorf("create log directory for %q error: %w", opts.File, err)
+		}
+		w := &lumberjack.Logger{
+			Filename:   opts.File,
+			MaxSize:    opts.Rotation.MaxSize,
+			MaxBackups: opts.Rotation.MaxBackups,
+			MaxAge:     opts.Rotation.MaxAge,
+			Compress:   opts.Rotation.Compress,
+		}
+		logrusLogger.SetOutput(w)
+		closer = w
+	}
+	if opts.Format == FormatJSON {
+		logrusLogger.SetFormatter(&logrus.JSONFormatter{})
+	}
+
+	defaultLevel, _ := ParseLevel(opts.Level)
+	if verbosity > 0 {
+		defaultLevel = verbosity
+	}
+	components := make(map[string]int, len(opts.Components))
+	for name, level := range opts.Components {
+		components[name], _ = ParseLevel(level)
+	}
+
+	return logr.New(&componentSink{
+		sink:       logrusr.New(logrusLogger).GetSink(),
+		components: components,
+		level:      defaultLevel,
+	}), closer, nil
+}
+
+// nopCloser 不做任何事的 io.Closer
+type nopCloser struct{}
+
+// Close 关闭
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,80 @@
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSONWithComponentLevels(t *testing.T) {
+	dataRoot := t.TempDir()
+	logger, closer, err := New(Options{
+		Format: FormatJSON,
+		Components: map[string]string{
+			ComponentChannels: LevelDebug,
+			ComponentTools:    LevelError,
+		},
+	}, dataRoot, 0)
+	require.NoError(t, err)
+
+	agent := logger.WithName(ComponentAgent).WithValues("sessionID", "s1")
+	agent.Info("agent info")
+	agent.V(1).Info("agent debug")
+
+	channel := logger.WithName(ComponentChannels).WithValues("channel", "wecomAIBot")
+	channel.V(1).Info("channel debug")
+	channel.V(2).Info("channel trace")
+
+	// 工具 logger 嵌套在 agent logger 下，使用 tools 组件级别
+	tool := agent.WithName(ComponentTools)
+	tool.Info("tool info")
+	tool.Error(errors.New("boom"), "tool error")
+
+	require.NoError(t, closer.Close())
+
+	f, err := os.Open(filepath.Join(dataRoot, DefaultFileName))
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := map[string]any{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+
+	msgs := make([]string, len(lines))
+	for i, line := range lines {
+		msgs[i], _ = line["msg"].(string)
+	}
+	assert.Equal(t, []string{"agent info", "channel debug", "tool error"}, msgs)
+	assert.Equal(t, "s1", lines[0]["sessionID"])
+	assert.Equal(t, "wecomAIBot", lines[1]["channel"])
+	assert.Equal(t, "s1", lines[2]["sessionID"])
+}
+
+func TestNewVerbosity(t *testing.T) {
+	dataRoot := t.TempDir()
+	logger, closer, err := New(Options{Level: LevelError}, dataRoot, 1)
+	require.NoError(t, err)
+	defer func() { _ = closer.Close() }()
+
+	assert.True(t, logger.V(1).Enabled())
+	assert.False(t, logger.V(2).Enabled())
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, (&Options{}).Validate())
+	assert.Error(t, (&Options{Format: "xml"}).Validate())
+	assert.Error(t, (&Options{Output: "syslog"}).Validate())
+	assert.Error(t, (&Options{Level: "verbose"}).Validate())
+	assert.Error(t, (&Options{Components: map[string]string{ComponentAgent: "loud"}}).Validate())
+}
@@ -0,0 +1,66 @@
+package logs
+
+import (
+	"github.com/go-logr/logr"
+)
+
+// componentSink 按组件控制级别的 logr.LogSink
+//
+// logger 的名字中最近一个配置了级别的组件名决定该 logger 的级别，未配置时使用默认级别
+type componentSink struct {
+	sink       logr.LogSink
+	components map[string]int
+	// 当前生效的级别
+	level int
+}
+
+var _ logr.LogSink = (*componentSink)(nil)
+var _ logr.CallDepthLogSink = (*componentSink)(nil)
+
+// Init 初始化
+func (s *componentSink) Init(info logr.RuntimeInfo) {
+	// logr.Logger 会额外包装一层，调用栈深度需要加 1
+	info.CallDepth++
+	s.sink.Init(info)
+}
+
+// Enabled 判断指定 V 级别的日志是否输出
+func (s *componentSink) Enabled(level int) bool {
+	return level <= s.level
+}
+
+// Info 输出信息日志
+func (s *componentSink) Info(level int, msg string, keysAndValues ...any) {
+	s.sink.Info(level, msg, keysAndValues...)
+}
+
+// Error 输出错误日志，错误日志总是输出
+func (s *componentSink) Error(err error, msg string, keysAndValues ...any) {
+	s.sink.Error(err, msg, keysAndValues...)
+}
+
+// WithValues 返回附加键值对的 LogSink
+func (s *componentSink) WithValues(keysAndValues ...any) logr.LogSink {
+	ret := *s
+	ret.sink = s.sink.WithValues(keysAndValues...)
+	return &ret
+}
+
+// WithName 返回附加名字的 LogSink
+func (s *componentSink) WithName(name string) logr.LogSink {
+	ret := *s
+	ret.sink = s.sink.WithName(name)
+	if level, ok := s.components[name]; ok {
+		ret.level = level
+	}
+	return &ret
+}
+
+// WithCallDepth 返回调整调用栈深度的 LogSink
+func (s *componentSink) WithCallDepth(depth int) logr.LogSink {
+	ret := *s
+	if sink, ok := s.sink.(logr.CallDepthLogSink); ok {
+		ret.sink = sink.WithCallDepth(depth)
+	}
+	return &ret
+}
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "nfa"
+
+// Token 类型标签值
+const (
+	TokenTypeInput      = "input"
+	TokenTypeOutput     = "output"
+	TokenTypeCacheRead  = "cache_read"
+	TokenTypeCacheWrite = "cache_write"
+	TokenTypeReasoning  = "reasoning"
+)
+
+// Registry 指标注册表
+var Registry = prometheus.NewRegistry()
+
+var (
+	// ModelRequests 模型请求数
+	ModelRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_requests_total",
+		Help:      "Total number of model requests.",
+	}, []string{"provider", "model"})
+	// ModelRequestErrors 模型请求失败数
+	ModelRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_request_errors_total",
+		Help:      "Total number of failed model requests.",
+	}, []string{"provider", "model"})
+	// ModelRequestDuration 模型请求耗时
+	ModelRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "model_request_duration_seconds",
+		Help:      "Latency of model requests in seconds.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 40, 60, 120, 300},
+	}, []string{"provider", "model"})
+	// ModelTokens 模型 Token 用量
+	ModelTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_tokens_total",
+		Help:      "Total number of tokens used by model requests.",
+	}, []string{"provider", "model", "type"})
+	// ModelCost 模型费用
+	ModelCost = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_cost_total",
+		Help:      "Total cost of model requests.",
+	}, []string{"provider", "model", "currency"})
+
+	// ToolCalls 工具调用数
+	ToolCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_calls_total",
+		Help:      "Total number of tool calls.",
+	}, []string{"tool"})
+	// ToolCallErrors 工具调用失败数
+	ToolCallErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_call_errors_total",
+		Help:      "Total number of failed tool calls.",
+	}, []string{"tool"})
+	// ToolCallDuration 工具调用耗时
+	ToolCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tool_call_duration_seconds",
+		Help:      "Latency of tool calls in seconds.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"tool"})
+
+	// ActiveSessions 正在处理提示的会话数
+	ActiveSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Number of sessions currently processing a prompt.",
+	})
+	// RateLimitWaiters 等待限流配额的模型请求数
+	RateLimitWaiters = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ratelimit_waiters",
+		Help:      "Number of model requests waiting for rate limit quota.",
+	})
+
+	// ChannelConnected 信道连接状态，已连接为 1
+	ChannelConnected = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "channel_connected",
+		Help:      "Whether the channel is connected (1) or not (0).",
+	}, []string{"channel", "bot"})
+	// ChannelReconnects 信道重连次数
+	ChannelReconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "channel_reconnects_total",
+		Help:      "Total number of channel reconnect attempts.",
+	}, []string{"channel", "bot"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ModelRequests, ModelRequestErrors, ModelRequestDuration, ModelTokens, ModelCost,
+		ToolCalls, ToolCallErrors, ToolCallDuration,
+		ActiveSessions, RateLimitWaiters,
+		ChannelConnected, ChannelReconnects,
+	)
+}
+
+// SplitModelName 将 provider/label 形式的模型全名拆分为供应商和模型
+func SplitModelName(modelName string) (provider, model string) {
+	if modelName == "" {
+		return "unknown", "unknown"
+	}
+	provider, model, ok := strings.Cut(modelName, "/")
+	if !ok {
+		return "unknown", modelName
+	}
+	return provider, model
+}
+
+// ObserveToolCall 记录一次工具调用
+func ObserveToolCall(tool string, duration time.Duration, failed bool) {
+	ToolCalls.WithLabelValues(tool).Inc()
+	ToolCallDuration.WithLabelValues(tool).Observe(duration.Seconds())
+	if failed {
+		ToolCallErrors.WithLabelValues(tool).Inc()
+	}
+}
+
+// AddModelTokens 累加模型 Token 用量
+func AddModelTokens(modelName, tokenType string, n int64) {
+	if n <= 0 {
+		return
+	}
+	provider, model := SplitModelName(modelName)
+	ModelTokens.WithLabelValues(provider, model, tokenType).Add(float64(n))
+}
+
+// AddModelCost 累加模型费用
+func AddModelCost(modelName, currency string, cost float64) {
+	if cost <= 0 {
+		return
+	}
+	provider, model := SplitModelName(modelName)
+	ModelCost.WithLabelValues(provider, model, currency).Add(cost)
+}
+
+// SetChannelConnected 设置信道连接状态
+func SetChannelConnected(channel, bot string, connected bool) {
+	v := 0.0
+	if connected {
+		v = 1
+	}
+	ChannelConnected.WithLabelValues(channel, bot).Set(v)
+}
+
+// IncChannelReconnects 累加信道重连次数
+func IncChannelReconnects(channel, bot string) {
+	ChannelReconnects.WithLabelValues(channel, bot).Inc()
+}
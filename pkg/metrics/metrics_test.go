@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitModelName(t *testing.T) {
+	provider, model := SplitModelName("openrouter/anthropic/claude-sonnet-4.5")
+	assert.Equal(t, "openrouter", provider)
+	assert.Equal(t, "anthropic/claude-sonnet-4.5", model)
+
+	provider, model = SplitModelName("llama2")
+	assert.Equal(t, "unknown", provider)
+	assert.Equal(t, "llama2", model)
+
+	provider, model = SplitModelName("")
+	assert.Equal(t, "unknown", provider)
+	assert.Equal(t, "unknown", model)
+}
+
+func TestModelMiddleware(t *testing.T) {
+	failErr := errors.New("boom")
+	calls := 0
+	modelFn := ModelMiddleware("test/mw")(func(
+		_ context.Context,
+		_ *ai.ModelRequest,
+		_ ai.ModelStreamCallback,
+	) (*ai.ModelResponse, error) {
+		calls++
+		switch calls {
+		case 1:
+			return &ai.ModelResponse{}, nil
+		case 2:
+			return nil, failErr
+		default:
+			return nil, context.Canceled
+		}
+	})
+
+	for range 3 {
+		_, _ = modelFn(t.Context(), &ai.ModelRequest{}, nil)
+	}
+
+	assert.Equal(t, 3.0, testutil.ToFloat64(ModelRequests.WithLabelValues("test", "mw")))
+	// 取消不计为失败
+	assert.Equal(t, 1.0, testutil.ToFloat64(ModelRequestErrors.WithLabelValues("test", "mw")))
+}
+
+func TestHandler(t *testing.T) {
+	ObserveToolCall("test_tool", 0, true)
+	AddModelTokens("test/handler", TokenTypeInput, 100)
+	AddModelCost("test/handler", "USD", 0.5)
+	SetChannelConnected("testChannel", "bot", true)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", DefaultPath, nil))
+	require.Equal(t, 200, rec.Code)
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `nfa_tool_call_errors_total{tool="test_tool"} 1`)
+	assert.Contains(t, body, `nfa_model_tokens_total{model="handler",provider="test",type="input"} 100`)
+	assert.Contains(t, body, `nfa_model_cost_total{currency="USD",model="handler",provider="test"} 0.5`)
+	assert.Contains(t, body, `nfa_channel_connected{bot="bot",channel="testChannel"} 1`)
+	assert.Contains(t, body, "nfa_active_sessions 0")
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// ModelMiddleware 模型中间件，统计模型请求数、耗时和失败数
+//
+// 因取消而中止的请求不计为失败
+func ModelMiddleware(modelName string) ai.ModelMiddleware {
+	provider, model := SplitModelName(modelName)
+	return func(modelFn ai.ModelFunc) ai.ModelFunc {
+		return func(
+			ctx context.Context,
+			req *ai.ModelRequest,
+			streamCallback ai.ModelStreamCallback,
+		) (*ai.ModelResponse, error) {
+			start := time.Now()
+			resp, err := modelFn(ctx, req, streamCallback)
+			ModelRequests.WithLabelValues(provider, model).Inc()
+			ModelRequestDuration.WithLabelValues(provider, model).Observe(time.Since(start).Seconds())
+			if err != nil && !errors.Is(err, context.Canceled) {
+				ModelRequestErrors.WithLabelValues(provider, model).Inc()
+			}
+			return resp, err
+		}
+	}
+}
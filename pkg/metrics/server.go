@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultPath 默认指标路径
+const DefaultPath = "/metrics"
+
+// Options 指标服务选项
+type Options struct {
+	// 监听地址，如 127.0.0.1:9464 ，为空表示不启用
+	Listen string `json:"listen,omitempty"`
+	// 指标路径，默认 /metrics
+	Path string `json:"path,omitempty"`
+}
+
+// Handler 返回指标 HTTP 处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Serve 开始监听并在后台提供指标服务，ctx 结束时关闭服务
+//
+// 未设置监听地址时不做任何事
+func Serve(ctx context.Context, opts Options) error {
+	if opts.Listen == "" {
+		return nil
+	}
+	path := opts.Path
+	if path == "" {
+		path = DefaultPath
+	}
+
+	logger := logr.FromContextOrDiscard(ctx).WithName("metrics")
+
+	l, err := net.Listen("tcp", opts.Listen)
+	if err != nil {
+		return fmt.Errorf("listen %q error: %w", opts.Listen, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, Handler())
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		logger.Info(fmt.Sprintf("serving metrics on http://%s%s", l.Addr().String(), path))
+		if err := server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(err, "serve metrics error")
+		}
+	}()
+
+	return nil
+}
@@ -6,7 +6,10 @@ import (
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/core/api"
 	"github.com/firebase/genkit/go/genkit"
-	"github.com/firebase/genkit/go/plugins/ollama"
+
+	"github.com/yhlooo/nfa/pkg/genkitplugins/ollama"
+	"github.com/yhlooo/nfa/pkg/httpclient"
+	"github.com/yhlooo/nfa/pkg/ratelimit"
 )
 
 // OllamaOptions Ollama 选项
@@ -21,6 +24,12 @@ type OllamaOptions struct {
 	Timeout int `json:"timeout,omitempty"`
 	// 模型列表
 	Models []ModelConfig `json:"models,omitempty"`
+	// 模型价格的货币代码，如 USD 、 CNY
+	Currency string `json:"currency,omitempty"`
+	// HTTP 客户端选项
+	HTTP httpclient.Options `json:"http,omitempty"`
+	// 供应商级别限流配置，该供应商的所有模型共享
+	RateLimit *ratelimit.Limits `json:"rateLimit,omitempty"`
 }
 
 // Complete 使用默认值补全选项
@@ -40,7 +49,10 @@ func NewOllamaRegister(opts OllamaOptions) *OllamaRegister {
 		Plugin: &ollama.Ollama{
 			ServerAddress: opts.BaseURL,
 			Timeout:       opts.Timeout,
+			HTTPOptions:   opts.HTTP,
 		},
+		Models:   opts.Models,
+		Currency: opts.Currency,
 	}
 }
 
@@ -48,6 +60,8 @@ func NewOllamaRegister(opts OllamaOptions) *OllamaRegister {
 type OllamaRegister struct {
 	Plugin *ollama.Ollama
 	Models []ModelConfig
+	// 模型价格的默认货币代码
+	Currency string
 }
 
 var _ ModelRegister = (*OllamaRegister)(nil)
@@ -58,13 +72,10 @@ func (r *OllamaRegister) GenkitPlugin() api.Plugin {
 }
 
 // RegisterModels 注册模型
-func (r *OllamaRegister) RegisterModels(_ context.Context, g *genkit.Genkit) ([]ModelConfig, error) {
+func (r *OllamaRegister) RegisterModels(ctx context.Context, g *genkit.Genkit) ([]ModelConfig, error) {
 	var registeredModels []ModelConfig
 	for _, modelConfig := range r.Models {
-		m := r.Plugin.DefineModel(g, ollama.ModelDefinition{
-			Name: modelConfig.Name,
-			Type: "chat",
-		}, &ai.ModelOptions{
+		m := r.Plugin.DefineModel(g, modelConfig.Name, &ai.ModelOptions{
 			Label: modelConfig.Name,
 			Supports: &ai.ModelSupports{
 				Multiturn:  true,
@@ -75,6 +86,9 @@ func (r *OllamaRegister) RegisterModels(_ context.Context, g *genkit.Genkit) ([]
 
 		registeredModel := modelConfig
 		registeredModel.Name = m.Name()
+		if registeredModel.Prices.Currency == "" {
+			registeredModel.Prices.Currency = r.Currency
+		}
 		registeredModels = append(registeredModels, registeredModel)
 	}
 
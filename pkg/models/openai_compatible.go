@@ -8,6 +8,8 @@ import (
 	"github.com/firebase/genkit/go/genkit"
 
 	"github.com/yhlooo/nfa/pkg/genkitplugins/oai"
+	"github.com/yhlooo/nfa/pkg/httpclient"
+	"github.com/yhlooo/nfa/pkg/ratelimit"
 )
 
 // OpenAICompatibleOptions OpenAI 兼容选项
@@ -20,6 +22,14 @@ type OpenAICompatibleOptions struct {
 	APIKey string `json:"apiKey"`
 	// 模型列表
 	Models []ModelConfig `json:"models,omitempty"`
+	// 模型价格的货币代码，如 USD 、 CNY
+	//
+	// 内置供应商默认使用其计价货币
+	Currency string `json:"currency,omitempty"`
+	// HTTP 客户端选项
+	HTTP httpclient.Options `json:"http,omitempty"`
+	// 供应商级别限流配置，该供应商的所有模型共享
+	RateLimit *ratelimit.Limits `json:"rateLimit,omitempty"`
 }
 
 // NewOpenAICompatibleRegister 创建 OpenAI 兼容模型注册器
@@ -35,15 +45,20 @@ func NewOpenAICompatibleRegister(
 	if opts.BaseURL == "" {
 		opts.BaseURL = defaultBaseURL
 	}
+	if opts.Currency == "" {
+		opts.Currency = ProviderCurrencies[defaultProvider]
+	}
 
 	return &OpenAICompatibleRegister{
 		Plugin: &oai.OpenAICompatible{
-			Provider: opts.Name,
-			BaseURL:  opts.BaseURL,
-			APIKey:   opts.APIKey,
+			Provider:    opts.Name,
+			BaseURL:     opts.BaseURL,
+			APIKey:      opts.APIKey,
+			HTTPOptions: opts.HTTP,
 		},
 		Models:        opts.Models,
 		DefaultModels: defaultModels,
+		Currency:      opts.Currency,
 		Extension:     ext,
 	}
 }
@@ -137,6 +152,8 @@ type OpenAICompatibleRegister struct {
 	DefaultModels []ModelConfig
 	// OpenAI 兼容接口扩展
 	Extension OpenAICompatibleExtension
+	// 模型价格的默认货币代码
+	Currency string
 }
 
 var _ ModelRegister = (*OpenAICompatibleRegister)(nil)
@@ -181,6 +198,9 @@ func (r *OpenAICompatibleRegister) RegisterModels(_ context.Context, g *genkit.G
 
 		registeredModel := cfg
 		registeredModel.Name = m.Name()
+		if registeredModel.Prices.Currency == "" {
+			registeredModel.Prices.Currency = r.Currency
+		}
 		registeredModels = append(registeredModels, registeredModel)
 	}
 
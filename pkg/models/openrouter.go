@@ -13,9 +13,12 @@ var (
 		Reasoning: true,
 		Vision:    true,
 		Prices: ModelPrices{
-			Input:  2, // <= 200K
+			Input:  2,
 			Output: 12,
 			Cached: 0.2,
+			Tiers: []PriceTier{
+				{AboveInputTokens: 200000, Input: 4, Output: 18, Cached: 0.4},
+			},
 		},
 		ContextWindow: 1050000,
 		Score:         9,
@@ -25,9 +28,12 @@ var (
 		Reasoning: true,
 		Vision:    true,
 		Prices: ModelPrices{
-			Input:  2.5, // <= 272K
+			Input:  2.5,
 			Output: 15,
 			Cached: 0.25,
+			Tiers: []PriceTier{
+				{AboveInputTokens: 272000, Input: 5, Output: 22.5, Cached: 0.5},
+			},
 		},
 		ContextWindow: 1050000,
 		Score:         10,
@@ -37,9 +43,10 @@ var (
 		Reasoning: true,
 		Vision:    true,
 		Prices: ModelPrices{
-			Input:  3,
-			Output: 15,
-			Cached: 0.3,
+			Input:      3,
+			Output:     15,
+			Cached:     0.3,
+			CacheWrite: 3.75,
 		},
 		ContextWindow: 1000000,
 		Score:         9,
@@ -49,9 +56,10 @@ var (
 		Reasoning: true,
 		Vision:    true,
 		Prices: ModelPrices{
-			Input:  5,
-			Output: 25,
-			Cached: 0.5,
+			Input:      5,
+			Output:     25,
+			Cached:     0.5,
+			CacheWrite: 6.25,
 		},
 		ContextWindow: 1000000,
 		Score:         10,
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/genkitplugins/oai"
+)
+
+// ModelPrices 价格信息
+type ModelPrices struct {
+	// 每百万输入 Token 价格
+	Input float64 `json:"input,omitempty"`
+	// 每百万输出 Token 价格
+	Output float64 `json:"output,omitempty"`
+	// 每百万缓存 Token 价格
+	Cached float64 `json:"cached,omitempty"`
+	// 每百万缓存写入 Token 价格，未设置时按输入价格计算
+	CacheWrite float64 `json:"cacheWrite,omitempty"`
+	// 每百万推理 Token 价格，未设置时按输出价格计算
+	Reasoning float64 `json:"reasoning,omitempty"`
+
+	// 货币代码，如 USD 、 CNY ，未设置时使用供应商的货币
+	Currency string `json:"currency,omitempty"`
+	// 按上下文长度分档的价格，输入 Token 数超过档位阈值时使用该档价格
+	Tiers []PriceTier `json:"tiers,omitempty"`
+	// 优惠时段，请求时间落在时段内时价格乘以折扣系数
+	OffPeak []OffPeakWindow `json:"offPeak,omitempty"`
+}
+
+// PriceTier 上下文长度分档价格
+type PriceTier struct {
+	// 输入 Token 数超过该值时使用该档价格
+	AboveInputTokens int64 `json:"aboveInputTokens"`
+	// 每百万输入 Token 价格
+	Input float64 `json:"input,omitempty"`
+	// 每百万输出 Token 价格
+	Output float64 `json:"output,omitempty"`
+	// 每百万缓存 Token 价格
+	Cached float64 `json:"cached,omitempty"`
+	// 每百万缓存写入 Token 价格
+	CacheWrite float64 `json:"cacheWrite,omitempty"`
+	// 每百万推理 Token 价格
+	Reasoning float64 `json:"reasoning,omitempty"`
+}
+
+// OffPeakWindow 优惠时段
+type OffPeakWindow struct {
+	// 开始时间，格式 HH:MM
+	Start string `json:"start"`
+	// 结束时间，格式 HH:MM ，早于开始时间时表示跨越零点
+	End string `json:"end"`
+	// 时区，如 Asia/Shanghai ，默认 UTC
+	TimeZone string `json:"timeZone,omitempty"`
+	// 折扣系数，如 0.5 表示半价
+	Discount float64 `json:"discount"`
+}
+
+// Contains 判断指定时间是否落在该时段内
+func (w OffPeakWindow) Contains(t time.Time) (bool, error) {
+	loc := time.UTC
+	if w.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(w.TimeZone)
+		if err != nil {
+			return false, fmt.Errorf("load time zone %q error: %w", w.TimeZone, err)
+		}
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false, err
+	}
+
+	t = t.In(loc)
+	cur := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return cur >= start && cur < end, nil
+	}
+	return cur >= start || cur < end, nil
+}
+
+// parseClock 解析 HH:MM 格式时间，返回距零点的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM: %w", s, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// PriceRates 单次请求适用的每百万 Token 价格
+type PriceRates struct {
+	Input      decimal.Decimal
+	Output     decimal.Decimal
+	Cached     decimal.Decimal
+	CacheWrite decimal.Decimal
+	Reasoning  decimal.Decimal
+}
+
+// RatesFor 获取指定输入 Token 数和请求时间适用的价格
+func (p ModelPrices) RatesFor(inputTokens int64, at time.Time) (PriceRates, error) {
+	input, output, cached, cacheWrite, reasoning := p.Input, p.Output, p.Cached, p.CacheWrite, p.Reasoning
+
+	// 选择满足条件的最高档位
+	tiers := append([]PriceTier(nil), p.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].AboveInputTokens < tiers[j].AboveInputTokens })
+	for _, tier := range tiers {
+		if inputTokens <= tier.AboveInputTokens {
+			break
+		}
+		input, output, cached, cacheWrite, reasoning = tier.Input, tier.Output, tier.Cached, tier.CacheWrite, tier.Reasoning
+	}
+
+	if cached == 0 {
+		cached = input
+	}
+	if cacheWrite == 0 {
+		cacheWrite = input
+	}
+	if reasoning == 0 {
+		reasoning = output
+	}
+
+	rates := PriceRates{
+		Input:      decimal.NewFromFloat(input),
+		Output:     decimal.NewFromFloat(output),
+		Cached:     decimal.NewFromFloat(cached),
+		CacheWrite: decimal.NewFromFloat(cacheWrite),
+		Reasoning:  decimal.NewFromFloat(reasoning),
+	}
+
+	for _, w := range p.OffPeak {
+		ok, err := w.Contains(at)
+		if err != nil {
+			return rates, fmt.Errorf("check off-peak window error: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		discount := decimal.NewFromFloat(w.Discount)
+		rates.Input = rates.Input.Mul(discount)
+		rates.Output = rates.Output.Mul(discount)
+		rates.Cached = rates.Cached.Mul(discount)
+		rates.CacheWrite = rates.CacheWrite.Mul(discount)
+		rates.Reasoning = rates.Reasoning.Mul(discount)
+		break
+	}
+
+	return rates, nil
+}
+
+var million = decimal.New(1, 6)
+
+// Cost 计算单次请求的费用，单位为 Currency
+//
+// 输入 Token 按未命中缓存、命中缓存、写入缓存分别计价，输出 Token 按普通输出和推理分别计价
+func (p ModelPrices) Cost(usage *ai.GenerationUsage, at time.Time) (decimal.Decimal, error) {
+	if usage == nil {
+		return decimal.Zero, nil
+	}
+
+	rates, err := p.RatesFor(int64(usage.InputTokens), at)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	cacheWrite := CacheWriteTokens(usage)
+	cost := decimal.Zero
+	for _, item := range []struct {
+		price  decimal.Decimal
+		tokens int64
+	}{
+		{price: rates.Input, tokens: int64(usage.InputTokens-usage.CachedContentTokens) - cacheWrite},
+		{price: rates.Cached, tokens: int64(usage.CachedContentTokens)},
+		{price: rates.CacheWrite, tokens: cacheWrite},
+		{price: rates.Output, tokens: int64(usage.OutputTokens - usage.ThoughtsTokens)},
+		{price: rates.Reasoning, tokens: int64(usage.ThoughtsTokens)},
+	} {
+		if item.tokens <= 0 {
+			continue
+		}
+		cost = cost.Add(item.price.Mul(decimal.NewFromInt(item.tokens)))
+	}
+
+	return cost.DivRound(million, 8), nil
+}
+
+// CacheWriteTokens 获取写入缓存的输入 Token 数
+func CacheWriteTokens(usage *ai.GenerationUsage) int64 {
+	if usage == nil {
+		return 0
+	}
+	return int64(usage.Custom[oai.UsageCacheWriteTokens])
+}
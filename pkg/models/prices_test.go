@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/genkitplugins/oai"
+)
+
+func TestModelPricesCost(t *testing.T) {
+	prices := ModelPrices{
+		Input:      2,
+		Output:     12,
+		Cached:     0.2,
+		CacheWrite: 2.5,
+		Reasoning:  10,
+		Tiers: []PriceTier{
+			{AboveInputTokens: 200000, Input: 4, Output: 18, Cached: 0.4},
+		},
+		OffPeak: []OffPeakWindow{
+			{Start: "16:30", End: "00:30", TimeZone: "UTC", Discount: 0.5},
+		},
+	}
+	noon := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		usage    *ai.GenerationUsage
+		at       time.Time
+		expected string
+	}{
+		{
+			name:     "basic",
+			usage:    &ai.GenerationUsage{InputTokens: 100000, OutputTokens: 100000},
+			at:       noon,
+			expected: "1.4",
+		},
+		{
+			name: "cache and reasoning",
+			usage: &ai.GenerationUsage{
+				InputTokens:         100000,
+				CachedContentTokens: 50000,
+				OutputTokens:        100000,
+				ThoughtsTokens:      50000,
+				Custom:              map[string]float64{oai.UsageCacheWriteTokens: 20000},
+			},
+			at: noon,
+			// 0.03M*2 + 0.05M*0.2 + 0.02M*2.5 + 0.05M*12 + 0.05M*10
+			expected: "1.22",
+		},
+		{
+			name:  "long context tier",
+			usage: &ai.GenerationUsage{InputTokens: 300000, OutputTokens: 100000},
+			at:    noon,
+			// 高档位未设置缓存写入和推理价格，按该档输入输出价格计算
+			expected: "3",
+		},
+		{
+			name:     "off-peak across midnight",
+			usage:    &ai.GenerationUsage{InputTokens: 100000, OutputTokens: 100000},
+			at:       time.Date(2026, 1, 1, 0, 10, 0, 0, time.UTC),
+			expected: "0.7",
+		},
+		{
+			name:     "off-peak end exclusive",
+			usage:    &ai.GenerationUsage{InputTokens: 100000, OutputTokens: 100000},
+			at:       time.Date(2026, 1, 1, 0, 30, 0, 0, time.UTC),
+			expected: "1.4",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cost, err := prices.Cost(c.usage, c.at)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, cost.String())
+		})
+	}
+}
+
+func TestOffPeakWindowInvalid(t *testing.T) {
+	_, err := OffPeakWindow{Start: "25:00", End: "08:00"}.Contains(time.Now())
+	assert.Error(t, err)
+	_, err = OffPeakWindow{Start: "00:00", End: "08:00", TimeZone: "Nowhere/City"}.Contains(time.Now())
+	assert.Error(t, err)
+}
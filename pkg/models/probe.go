@@ -0,0 +1,252 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/yhlooo/nfa/pkg/genkitplugins/oai"
+)
+
+const (
+	// ProbeToolName 用于探测工具调用能力的工具名
+	ProbeToolName = "ProbeGetSecretNumber"
+
+	// ProbeBasic 基础对话探测
+	ProbeBasic = "basic"
+	// ProbeTools 工具调用探测
+	ProbeTools = "tools"
+	// ProbeVision 视觉理解探测
+	ProbeVision = "vision"
+	// ProbeReasoning 思考模式探测
+	ProbeReasoning = "reasoning"
+)
+
+// ProbeResult 模型探测结果
+type ProbeResult struct {
+	// 模型名
+	Model string `json:"model"`
+	// 是否所有探测均通过
+	OK bool `json:"ok"`
+	// 基础对话耗时，毫秒
+	LatencyMS int64 `json:"latencyMS"`
+	// 基础对话首 Token 耗时，毫秒
+	TimeToFirstTokenMS int64 `json:"timeToFirstTokenMS"`
+	// 是否返回用量信息
+	UsageReported bool `json:"usageReported"`
+	// 各能力探测结果
+	Checks []ProbeCheck `json:"checks"`
+}
+
+// ProbeCheck 单项能力探测结果
+type ProbeCheck struct {
+	// 探测项名
+	Name string `json:"name"`
+	// 是否通过
+	OK bool `json:"ok"`
+	// 耗时，毫秒
+	LatencyMS int64 `json:"latencyMS"`
+	// 错误信息
+	Error string `json:"error,omitempty"`
+}
+
+// Check 获取指定探测项结果
+func (r ProbeResult) Check(name string) (ProbeCheck, bool) {
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ProbeCheck{}, false
+}
+
+// Prober 模型探测器
+type Prober struct {
+	g *genkit.Genkit
+
+	defineToolOnce sync.Once
+	tool           ai.Tool
+}
+
+// NewProber 创建模型探测器
+func NewProber(g *genkit.Genkit) *Prober {
+	return &Prober{g: g}
+}
+
+// Probe 探测模型基础对话能力及 ModelConfig 中声明的各项能力
+func (p *Prober) Probe(ctx context.Context, model ModelConfig) ProbeResult {
+	ret := ProbeResult{Model: model.Name, OK: true}
+
+	basic, ttft, usageReported := p.probeBasic(ctx, model.Name)
+	ret.LatencyMS = basic.LatencyMS
+	ret.TimeToFirstTokenMS = ttft.Milliseconds()
+	ret.UsageReported = usageReported
+	ret.Checks = append(ret.Checks, basic)
+
+	ret.Checks = append(ret.Checks, p.probeTools(ctx, model.Name))
+	if model.Vision {
+		ret.Checks = append(ret.Checks, p.probeVision(ctx, model.Name))
+	}
+	if model.Reasoning {
+		ret.Checks = append(ret.Checks, p.probeReasoning(ctx, model.Name))
+	}
+
+	for _, c := range ret.Checks {
+		if !c.OK {
+			ret.OK = false
+		}
+	}
+	return ret
+}
+
+// probeBasic 探测基础对话能力
+func (p *Prober) probeBasic(ctx context.Context, modelName string) (ProbeCheck, time.Duration, bool) {
+	var (
+		lock       sync.Mutex
+		firstToken time.Duration
+	)
+	start := time.Now()
+	resp, err := genkit.Generate(ctx, p.g,
+		ai.WithModelName(modelName),
+		ai.WithConfig(oai.GenerateConfig{ReasoningLevel: 0}),
+		ai.WithPrompt("Reply with the single word: pong"),
+		ai.WithStreaming(func(_ context.Context, _ *ai.ModelResponseChunk) error {
+			lock.Lock()
+			defer lock.Unlock()
+			if firstToken == 0 {
+				firstToken = time.Since(start)
+			}
+			return nil
+		}),
+	)
+	check := ProbeCheck{Name: ProbeBasic, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Error = probeError(err)
+		return check, 0, false
+	}
+	if strings.TrimSpace(resp.Text()) == "" {
+		check.Error = "empty response"
+		return check, firstToken, resp.Usage != nil && resp.Usage.InputTokens > 0
+	}
+	check.OK = true
+	return check, firstToken, resp.Usage != nil && resp.Usage.InputTokens > 0
+}
+
+// probeTools 探测工具调用能力
+func (p *Prober) probeTools(ctx context.Context, modelName string) ProbeCheck {
+	p.defineToolOnce.Do(func() {
+		p.tool = genkit.DefineTool(p.g, ProbeToolName, "Returns the secret number.",
+			func(_ *ai.ToolContext, _ struct{}) (int, error) {
+				return 42, nil
+			},
+		)
+	})
+
+	start := time.Now()
+	resp, err := genkit.Generate(ctx, p.g,
+		ai.WithModelName(modelName),
+		ai.WithConfig(oai.GenerateConfig{ReasoningLevel: 0}),
+		ai.WithPrompt(fmt.Sprintf("Call the %s tool to get the secret number.", ProbeToolName)),
+		ai.WithTools(p.tool),
+		ai.WithReturnToolRequests(true),
+	)
+	check := ProbeCheck{Name: ProbeTools, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Error = probeError(err)
+		return check
+	}
+	for _, req := range resp.ToolRequests() {
+		if req.Name == ProbeToolName {
+			check.OK = true
+			return check
+		}
+	}
+	check.Error = "model did not request the probe tool"
+	return check
+}
+
+// probeVision 探测视觉理解能力
+func (p *Prober) probeVision(ctx context.Context, modelName string) ProbeCheck {
+	start := time.Now()
+	check := ProbeCheck{Name: ProbeVision}
+
+	img, err := solidColorPNG(color.RGBA{R: 255, A: 255})
+	if err != nil {
+		check.Error = probeError(err)
+		return check
+	}
+	resp, err := genkit.Generate(ctx, p.g,
+		ai.WithModelName(modelName),
+		ai.WithConfig(oai.GenerateConfig{ReasoningLevel: 0}),
+		ai.WithMessages(ai.NewUserMessage(
+			ai.NewTextPart("What color is this image? Answer with a single English word."),
+			ai.NewMediaPart("image/png", img),
+		)),
+	)
+	check.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		check.Error = probeError(err)
+		return check
+	}
+	if !strings.Contains(strings.ToLower(resp.Text()), "red") {
+		check.Error = fmt.Sprintf("unexpected answer: %q", resp.Text())
+		return check
+	}
+	check.OK = true
+	return check
+}
+
+// probeReasoning 探测思考模式
+func (p *Prober) probeReasoning(ctx context.Context, modelName string) ProbeCheck {
+	start := time.Now()
+	resp, err := genkit.Generate(ctx, p.g,
+		ai.WithModelName(modelName),
+		ai.WithConfig(oai.GenerateConfig{ReasoningLevel: 1}),
+		ai.WithPrompt("What is 17 * 23? Think step by step, then answer with the number only."),
+	)
+	check := ProbeCheck{Name: ProbeReasoning, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Error = probeError(err)
+		return check
+	}
+	if resp.Reasoning() == "" {
+		check.Error = "no reasoning content returned"
+		return check
+	}
+	check.OK = true
+	return check
+}
+
+// probeError 返回探测出错的错误信息，超时的错误以 timeout 开头，以便与模型返回的错误区分
+func probeError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout: " + err.Error()
+	}
+	return err.Error()
+}
+
+// solidColorPNG 生成纯色 PNG 图片的 data URL
+func solidColorPNG(c color.Color) (string, error) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return "", fmt.Errorf("encode png error: %w", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/genkitplugins/oai"
+)
+
+// fakeModel 测试用模型的行为
+type fakeModel struct {
+	// 是否调用探测工具
+	callTool bool
+	// 视觉探测的回答
+	color string
+	// 是否返回思考内容
+	reasoning bool
+	// 是否返回空回复
+	empty bool
+	// 返回的错误
+	err error
+	// 是否阻塞直到上下文结束
+	block bool
+}
+
+// generate 按请求内容模拟模型回复
+func (m fakeModel) generate(
+	ctx context.Context,
+	req *ai.ModelRequest,
+	cb func(context.Context, *ai.ModelResponseChunk) error,
+) (*ai.ModelResponse, error) {
+	if m.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	msg := ai.NewModelTextMessage("pong")
+	hasMedia := false
+	for _, part := range req.Messages[len(req.Messages)-1].Content {
+		hasMedia = hasMedia || part.IsMedia()
+	}
+	cfg, _ := req.Config.(oai.GenerateConfig)
+	switch {
+	case m.empty:
+		msg = ai.NewModelTextMessage("")
+	case len(req.Tools) > 0 && m.callTool:
+		msg = ai.NewModelMessage(ai.NewToolRequestPart(&ai.ToolRequest{Name: req.Tools[0].Name}))
+	case hasMedia:
+		msg = ai.NewModelTextMessage(m.color)
+	case cfg.ReasoningLevel > 0 && m.reasoning:
+		msg = ai.NewModelMessage(ai.NewReasoningPart("17 * 23 = 391", nil), ai.NewTextPart("391"))
+	}
+	if cb != nil {
+		if err := cb(ctx, &ai.ModelResponseChunk{Role: ai.RoleModel, Content: msg.Content}); err != nil {
+			return nil, err
+		}
+	}
+	return &ai.ModelResponse{
+		Message:      msg,
+		FinishReason: ai.FinishReasonStop,
+		Usage:        &ai.GenerationUsage{InputTokens: 10, OutputTokens: 1},
+	}, nil
+}
+
+func TestProber(t *testing.T) {
+	cases := []struct {
+		name    string
+		model   fakeModel
+		config  ModelConfig
+		timeout time.Duration
+		ok      bool
+		// 各探测项的错误，空字符串表示通过
+		errors map[string]string
+	}{
+		{
+			name:   "success",
+			model:  fakeModel{callTool: true, color: "Red", reasoning: true},
+			config: ModelConfig{Vision: true, Reasoning: true},
+			ok:     true,
+			errors: map[string]string{ProbeBasic: "", ProbeTools: "", ProbeVision: "", ProbeReasoning: ""},
+		},
+		{
+			name:   "tool call miss",
+			model:  fakeModel{},
+			config: ModelConfig{},
+			errors: map[string]string{ProbeBasic: "", ProbeTools: "model did not request the probe tool"},
+		},
+		{
+			name:    "timeout",
+			model:   fakeModel{block: true},
+			config:  ModelConfig{},
+			timeout: 20 * time.Millisecond,
+			errors:  map[string]string{ProbeBasic: "timeout: ", ProbeTools: "timeout: "},
+		},
+		{
+			name:   "model error",
+			model:  fakeModel{err: errors.New("401 Unauthorized")},
+			config: ModelConfig{},
+			errors: map[string]string{ProbeBasic: "401 Unauthorized", ProbeTools: "401 Unauthorized"},
+		},
+		{
+			name:   "empty response",
+			model:  fakeModel{empty: true},
+			config: ModelConfig{},
+			errors: map[string]string{ProbeBasic: "empty response", ProbeTools: "model did not request the probe tool"},
+		},
+		{
+			name:   "capabilities not working",
+			model:  fakeModel{callTool: true, color: "Blue"},
+			config: ModelConfig{Vision: true, Reasoning: true},
+			errors: map[string]string{
+				ProbeBasic:     "",
+				ProbeTools:     "",
+				ProbeVision:    `unexpected answer: "Blue"`,
+				ProbeReasoning: "no reasoning content returned",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			g := genkit.Init(ctx)
+			genkit.DefineModel(g, api.NewName("fake", "model"), &ai.ModelOptions{
+				Supports: &ai.ModelSupports{Multiturn: true, SystemRole: true, Tools: true, Media: true},
+			}, c.model.generate)
+
+			if c.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, c.timeout)
+				defer cancel()
+			}
+			c.config.Name = "fake/model"
+			result := NewProber(g).Probe(ctx, c.config)
+
+			assert.Equal(t, "fake/model", result.Model)
+			assert.Equal(t, c.ok, result.OK)
+			require.Len(t, result.Checks, len(c.errors))
+			for name, expected := range c.errors {
+				check, ok := result.Check(name)
+				require.True(t, ok, name)
+				if expected == "" {
+					assert.True(t, check.OK, name)
+					assert.Empty(t, check.Error, name)
+					continue
+				}
+				assert.False(t, check.OK, name)
+				assert.Contains(t, check.Error, expected, name)
+			}
+			if result.Checks[0].OK {
+				assert.True(t, result.UsageReported)
+			}
+		})
+	}
+}
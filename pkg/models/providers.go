@@ -5,6 +5,8 @@ import (
 
 	"github.com/firebase/genkit/go/core/api"
 	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/yhlooo/nfa/pkg/ratelimit"
 )
 
 // ModelProvider 模型供应商配置
@@ -23,6 +25,19 @@ type ModelProvider struct {
 	Minimax          *OpenAICompatibleOptions `json:"minimax,omitempty"`
 }
 
+// ProviderCurrencies 内置供应商的计价货币
+var ProviderCurrencies = map[string]string{
+	OpenRouterProviderName:   "USD",
+	OpenCodeProviderName:     "USD",
+	OpenCodeGoProviderName:   "USD",
+	DeepseekProviderName:     "CNY",
+	QwenProviderName:         "CNY",
+	MoonshotProviderName:     "CNY",
+	ZAIProviderName:          "CNY",
+	TencentCloudProviderName: "CNY",
+	MinimaxProviderName:      "CNY",
+}
+
 // Register 返回对应的模型注册器
 func (p ModelProvider) Register() ModelRegister {
 	switch {
@@ -92,6 +107,30 @@ func (p ModelProvider) Register() ModelRegister {
 	return nil
 }
 
+// RateLimit 返回供应商级别限流配置
+func (p ModelProvider) RateLimit() *ratelimit.Limits {
+	if p.Ollama != nil {
+		return p.Ollama.RateLimit
+	}
+	if opts := p.CompatibleOptions(); opts != nil {
+		return opts.RateLimit
+	}
+	return nil
+}
+
+// CompatibleOptions 返回 OpenAI 兼容供应商的选项，非 OpenAI 兼容供应商返回 nil
+func (p ModelProvider) CompatibleOptions() *OpenAICompatibleOptions {
+	for _, opts := range []*OpenAICompatibleOptions{
+		p.OpenAICompatible, p.OpenRouter, p.OpenCode, p.OpenCodeGo, p.Deepseek,
+		p.Qwen, p.MoonshotAI, p.ZAI, p.TencentCloud, p.Minimax,
+	} {
+		if opts != nil {
+			return opts
+		}
+	}
+	return nil
+}
+
 // ModelRegister 模型注册器
 type ModelRegister interface {
 	// GenkitPlugin 获取对应 Genkit 插件
@@ -116,6 +155,8 @@ type ModelConfig struct {
 
 	// 价格信息
 	Prices ModelPrices `json:"prices,omitempty"`
+	// 限流配置
+	RateLimit *ratelimit.Limits `json:"rateLimit,omitempty"`
 
 	// 效果评分，0-10
 	Score int `json:"score,omitempty"`
@@ -134,13 +175,3 @@ func (cfg ModelConfig) WithPrices(prices ModelPrices) ModelConfig {
 	out.Prices = prices
 	return out
 }
-
-// ModelPrices 价格信息
-type ModelPrices struct {
-	// 每百万输入 Token 价格
-	Input float64 `json:"input,omitempty"`
-	// 每百万输出 Token 价格
-	Output float64 `json:"output,omitempty"`
-	// 每百万缓存 Token 价格
-	Cached float64 `json:"cached,omitempty"`
-}
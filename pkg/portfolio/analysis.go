@@ -0,0 +1,424 @@
+package portfolio
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/fx"
+)
+
+// DefaultBaseCurrency 持仓涉及多种货币且未指定基准货币时使用的基准货币
+const DefaultBaseCurrency = "USD"
+
+// Unknown 未设置的行业、市场等分类
+const Unknown = "unknown"
+
+// CashCategory 现金在行业、市场、资产类别维度的分类
+const CashCategory = "cash"
+
+var hundred = decimal.NewFromInt(100)
+
+// Options 投资组合选项
+type Options struct {
+	// 基准货币，汇总金额换算为该货币，默认为持仓的货币，持仓涉及多种货币时默认为 USD
+	BaseCurrency string `json:"baseCurrency,omitempty"`
+}
+
+// AnalyzeOptions 分析选项
+type AnalyzeOptions struct {
+	// 只分析指定账户，为空时分析所有账户
+	Account string
+	// 价格，键为证券代码，覆盖已保存的最近价格
+	Prices map[string]decimal.Decimal
+	// 基准货币
+	BaseCurrency string
+	// 货币转换器，为空时只能换算相同货币
+	Converter fx.AmountConverter
+}
+
+// Holding 持仓分析结果
+type Holding struct {
+	Account    string `json:"account"`
+	Symbol     string `json:"symbol"`
+	Name       string `json:"name,omitempty"`
+	Market     string `json:"market"`
+	Sector     string `json:"sector"`
+	AssetClass string `json:"assetClass"`
+	Currency   string `json:"currency"`
+
+	Quantity  decimal.Decimal `json:"quantity"`
+	AvgCost   decimal.Decimal `json:"avgCost"`
+	CostBasis decimal.Decimal `json:"costBasis"`
+	// 价格，缺少价格时为平均成本
+	Price        decimal.Decimal `json:"price"`
+	PriceTime    time.Time       `json:"priceTime,omitzero"`
+	PriceMissing bool            `json:"priceMissing,omitempty"`
+
+	MarketValue          decimal.Decimal `json:"marketValue"`
+	UnrealizedPnL        decimal.Decimal `json:"unrealizedPnL"`
+	UnrealizedPnLPercent decimal.Decimal `json:"unrealizedPnLPercent"`
+	RealizedPnL          decimal.Decimal `json:"realizedPnL,omitzero"`
+	Income               decimal.Decimal `json:"income,omitzero"`
+
+	// 换算为基准货币的市值
+	MarketValueBase decimal.Decimal `json:"marketValueBase"`
+	// 占总资产（含现金）的百分比
+	Weight decimal.Decimal `json:"weightPercent"`
+}
+
+// CashHolding 现金分析结果
+type CashHolding struct {
+	Account    string          `json:"account"`
+	Currency   string          `json:"currency"`
+	Amount     decimal.Decimal `json:"amount"`
+	AmountBase decimal.Decimal `json:"amountBase"`
+	Weight     decimal.Decimal `json:"weightPercent"`
+}
+
+// Analysis 投资组合分析结果，汇总金额均为基准货币
+type Analysis struct {
+	BaseCurrency string        `json:"baseCurrency"`
+	Holdings     []Holding     `json:"holdings"`
+	Cash         []CashHolding `json:"cashBalances,omitempty"`
+
+	MarketValue   decimal.Decimal `json:"marketValue"`
+	CostBasis     decimal.Decimal `json:"costBasis"`
+	UnrealizedPnL decimal.Decimal `json:"unrealizedPnL"`
+	RealizedPnL   decimal.Decimal `json:"realizedPnL"`
+	Income        decimal.Decimal `json:"income"`
+	CashTotal     decimal.Decimal `json:"cash"`
+	TotalValue    decimal.Decimal `json:"totalValue"`
+
+	// 缺少价格的证券，使用成本估算市值
+	MissingPrices []string `json:"missingPrices,omitempty"`
+	// 无法换算为基准货币的货币，相关金额未计入汇总
+	Unconverted []string `json:"unconverted,omitempty"`
+}
+
+// Analyze 分析投资组合
+func Analyze(p *Portfolio, opts AnalyzeOptions) Analysis {
+	a := Analysis{BaseCurrency: strings.ToUpper(opts.BaseCurrency)}
+	if a.BaseCurrency == "" {
+		a.BaseCurrency = p.baseCurrency(opts.Account)
+	}
+
+	unconverted := map[string]bool{}
+	missingPrices := map[string]bool{}
+	convert := func(amount decimal.Decimal, currency string) decimal.Decimal {
+		if currency == "" || strings.EqualFold(currency, a.BaseCurrency) {
+			return amount
+		}
+		if opts.Converter != nil {
+			if v, ok := opts.Converter.Convert(amount, currency, a.BaseCurrency); ok {
+				return v
+			}
+		}
+		unconverted[strings.ToUpper(currency)] = true
+		return decimal.Zero
+	}
+
+	for _, pos := range p.Positions {
+		if opts.Account != "" && pos.Account != opts.Account {
+			continue
+		}
+		sec := p.Security(pos.Symbol)
+		currency := pos.Currency
+		if currency == "" {
+			currency = sec.Currency
+		}
+
+		// 已清仓的持仓只计入已实现盈亏和收入
+		a.RealizedPnL = a.RealizedPnL.Add(convert(pos.RealizedPnL, currency))
+		a.Income = a.Income.Add(convert(pos.Income, currency))
+		if pos.Quantity.IsZero() {
+			continue
+		}
+
+		h := Holding{
+			Account:     pos.Account,
+			Symbol:      pos.Symbol,
+			Name:        sec.Name,
+			Market:      orUnknown(sec.Market),
+			Sector:      orUnknown(sec.Sector),
+			AssetClass:  orUnknown(sec.AssetClass),
+			Currency:    currency,
+			Quantity:    pos.Quantity,
+			AvgCost:     pos.AverageCost(),
+			CostBasis:   pos.CostBasis,
+			Price:       sec.Price,
+			PriceTime:   sec.PriceTime,
+			RealizedPnL: pos.RealizedPnL,
+			Income:      pos.Income,
+		}
+		if price, ok := lookupPrice(opts.Prices, pos.Symbol); ok {
+			h.Price, h.PriceTime = price, time.Time{}
+		}
+		if h.Price.IsZero() {
+			h.Price = h.AvgCost
+			h.PriceMissing = true
+			missingPrices[pos.Symbol] = true
+		}
+		h.MarketValue = h.Quantity.Mul(h.Price)
+		h.UnrealizedPnL = h.MarketValue.Sub(h.CostBasis)
+		if !h.CostBasis.IsZero() {
+			h.UnrealizedPnLPercent = h.UnrealizedPnL.Div(h.CostBasis.Abs()).Mul(hundred).Round(2)
+		}
+		h.MarketValueBase = convert(h.MarketValue, currency)
+
+		a.MarketValue = a.MarketValue.Add(h.MarketValueBase)
+		a.CostBasis = a.CostBasis.Add(convert(h.CostBasis, currency))
+		a.UnrealizedPnL = a.UnrealizedPnL.Add(convert(h.UnrealizedPnL, currency))
+		a.Holdings = append(a.Holdings, h)
+	}
+
+	for _, c := range p.Cash {
+		if opts.Account != "" && c.Account != opts.Account {
+			continue
+		}
+		if c.Amount.IsZero() {
+			continue
+		}
+		ch := CashHolding{
+			Account:    c.Account,
+			Currency:   c.Currency,
+			Amount:     c.Amount,
+			AmountBase: convert(c.Amount, c.Currency),
+		}
+		a.CashTotal = a.CashTotal.Add(ch.AmountBase)
+		a.Cash = append(a.Cash, ch)
+	}
+
+	a.TotalValue = a.MarketValue.Add(a.CashTotal)
+	for i := range a.Holdings {
+		a.Holdings[i].Weight = percent(a.Holdings[i].MarketValueBase, a.TotalValue)
+	}
+	for i := range a.Cash {
+		a.Cash[i].Weight = percent(a.Cash[i].AmountBase, a.TotalValue)
+	}
+	sort.SliceStable(a.Holdings, func(i, j int) bool {
+		return a.Holdings[i].MarketValueBase.GreaterThan(a.Holdings[j].MarketValueBase)
+	})
+
+	a.MissingPrices = slices.Sorted(maps.Keys(missingPrices))
+	a.Unconverted = slices.Sorted(maps.Keys(unconverted))
+	return a
+}
+
+// baseCurrency 默认基准货币，所有持仓和现金货币相同时为该货币，否则为 DefaultBaseCurrency
+func (p *Portfolio) baseCurrency(account string) string {
+	currencies := map[string]bool{}
+	for _, pos := range p.Positions {
+		if (account == "" || pos.Account == account) && !pos.Quantity.IsZero() {
+			currency := pos.Currency
+			if currency == "" {
+				currency = p.Security(pos.Symbol).Currency
+			}
+			if currency != "" {
+				currencies[strings.ToUpper(currency)] = true
+			}
+		}
+	}
+	for _, c := range p.Cash {
+		if (account == "" || c.Account == account) && !c.Amount.IsZero() && c.Currency != "" {
+			currencies[strings.ToUpper(c.Currency)] = true
+		}
+	}
+	if len(currencies) == 1 {
+		for c := range currencies {
+			return c
+		}
+	}
+	return DefaultBaseCurrency
+}
+
+// Dimension 暴露分析的维度
+type Dimension string
+
+// 暴露分析的维度
+const (
+	DimensionSector     Dimension = "sector"
+	DimensionMarket     Dimension = "market"
+	DimensionCurrency   Dimension = "currency"
+	DimensionAssetClass Dimension = "assetClass"
+	DimensionAccount    Dimension = "account"
+	DimensionSymbol     Dimension = "symbol"
+)
+
+// Dimensions 所有暴露分析的维度
+var Dimensions = []Dimension{
+	DimensionSector, DimensionMarket, DimensionCurrency, DimensionAssetClass, DimensionAccount, DimensionSymbol,
+}
+
+// ParseDimension 解析暴露分析的维度
+func ParseDimension(s string) (Dimension, error) {
+	for _, d := range Dimensions {
+		if strings.EqualFold(s, string(d)) {
+			return d, nil
+		}
+	}
+	switch strings.ToLower(s) {
+	case "industry":
+		return DimensionSector, nil
+	case "asset_class", "asset-class", "class":
+		return DimensionAssetClass, nil
+	}
+	names := make([]string, len(Dimensions))
+	for i, d := range Dimensions {
+		names[i] = string(d)
+	}
+	return "", fmt.Errorf("invalid dimension %q (expected one of: %s)", s, strings.Join(names, ", "))
+}
+
+// ExposureRow 暴露分析结果的一行
+type ExposureRow struct {
+	// 分类
+	Key string `json:"key"`
+	// 基准货币市值
+	Value decimal.Decimal `json:"value"`
+	// 百分比
+	Weight decimal.Decimal `json:"weightPercent"`
+	// 包含的证券代码
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+// Exposure 按维度汇总暴露，按市值降序排列
+//
+// includeCash 为 true 时现金计入分母并单独成行，否则只按持仓市值计算百分比
+func (a Analysis) Exposure(by Dimension, includeCash bool) []ExposureRow {
+	values := map[string]decimal.Decimal{}
+	symbols := map[string][]string{}
+	add := func(key string, value decimal.Decimal, symbol string) {
+		values[key] = values[key].Add(value)
+		if symbol != "" && !slices.Contains(symbols[key], symbol) {
+			symbols[key] = append(symbols[key], symbol)
+		}
+	}
+
+	for _, h := range a.Holdings {
+		add(h.key(by), h.MarketValueBase, h.Symbol)
+	}
+	total := a.MarketValue
+	if includeCash {
+		for _, c := range a.Cash {
+			key := CashCategory
+			switch by {
+			case DimensionCurrency:
+				key = strings.ToUpper(c.Currency)
+			case DimensionAccount:
+				key = c.Account
+			}
+			add(key, c.AmountBase, "")
+		}
+		total = a.TotalValue
+	}
+
+	rows := make([]ExposureRow, 0, len(values))
+	for key, value := range values {
+		rows = append(rows, ExposureRow{
+			Key:     key,
+			Value:   value,
+			Weight:  percent(value, total),
+			Symbols: symbols[key],
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].Value.Equal(rows[j].Value) {
+			return rows[i].Value.GreaterThan(rows[j].Value)
+		}
+		return rows[i].Key < rows[j].Key
+	})
+	return rows
+}
+
+// key 持仓在指定维度的分类
+func (h Holding) key(by Dimension) string {
+	switch by {
+	case DimensionSector:
+		return h.Sector
+	case DimensionMarket:
+		return h.Market
+	case DimensionCurrency:
+		return orUnknown(strings.ToUpper(h.Currency))
+	case DimensionAssetClass:
+		return h.AssetClass
+	case DimensionAccount:
+		return h.Account
+	default:
+		return h.Symbol
+	}
+}
+
+// Concentration 集中度分析结果
+type Concentration struct {
+	// 持仓证券数（跨账户合并）
+	Positions int `json:"positions"`
+	// 市值最大的若干证券
+	Top []ExposureRow `json:"top"`
+	// 前 N 大证券合计占比（%）
+	TopWeight decimal.Decimal `json:"topWeightPercent"`
+	// 赫芬达尔指数，各证券占持仓市值比例的平方和，取值 (0, 1]
+	HHI decimal.Decimal `json:"hhi"`
+	// 有效持仓数， 1/HHI
+	EffectiveN decimal.Decimal `json:"effectiveN"`
+}
+
+// Concentration 分析持仓集中度，不含现金
+func (a Analysis) Concentration(top int) Concentration {
+	rows := a.Exposure(DimensionSymbol, false)
+	c := Concentration{Positions: len(rows)}
+	if len(rows) == 0 || !a.MarketValue.IsPositive() {
+		return c
+	}
+	if top <= 0 || top > len(rows) {
+		top = len(rows)
+	}
+	c.Top = rows[:top]
+	for _, row := range rows[:top] {
+		c.TopWeight = c.TopWeight.Add(row.Weight)
+	}
+	hhi := decimal.Zero
+	for _, row := range rows {
+		w := row.Value.Div(a.MarketValue)
+		hhi = hhi.Add(w.Mul(w))
+	}
+	c.HHI = hhi.Round(4)
+	if hhi.IsPositive() {
+		c.EffectiveN = decimal.NewFromInt(1).Div(hhi).Round(2)
+	}
+	return c
+}
+
+// lookupPrice 忽略大小写查找价格
+func lookupPrice(prices map[string]decimal.Decimal, symbol string) (decimal.Decimal, bool) {
+	if price, ok := prices[symbol]; ok {
+		return price, true
+	}
+	for k, v := range prices {
+		if strings.EqualFold(k, symbol) {
+			return v, true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// percent 计算百分比，保留 2 位小数
+func percent(value, total decimal.Decimal) decimal.Decimal {
+	if total.IsZero() {
+		return decimal.Zero
+	}
+	return value.Div(total).Mul(hundred).Round(2)
+}
+
+// orUnknown 为空时返回 Unknown
+func orUnknown(s string) string {
+	if s == "" {
+		return Unknown
+	}
+	return s
+}
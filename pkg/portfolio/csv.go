@@ -0,0 +1,250 @@
+package portfolio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CSV 各列可能的表头（小写）
+var (
+	csvAccountColumns    = []string{"account", "account id", "account_id"}
+	csvTimeColumns       = []string{"date", "time", "trade date", "trade_date", "datetime"}
+	csvTypeColumns       = []string{"type", "action", "side"}
+	csvSymbolColumns     = []string{"symbol", "ticker", "code"}
+	csvNameColumns       = []string{"name", "security", "description"}
+	csvQuantityColumns   = []string{"quantity", "qty", "shares", "units"}
+	csvPriceColumns      = []string{"price", "last price", "last_price", "market price", "market_price"}
+	csvFeeColumns        = []string{"fee", "fees", "commission"}
+	csvAmountColumns     = []string{"amount", "total", "net amount", "net_amount"}
+	csvCurrencyColumns   = []string{"currency", "ccy"}
+	csvNoteColumns       = []string{"note", "notes", "memo"}
+	csvCostColumns       = []string{"cost", "cost basis", "cost_basis", "total cost", "total_cost"}
+	csvAvgCostColumns    = []string{"avg cost", "avg_cost", "average cost", "average_cost", "cost price", "cost_price", "unit cost", "unit_cost"}
+	csvMarketColumns     = []string{"market", "exchange"}
+	csvSectorColumns     = []string{"sector", "industry"}
+	csvAssetClassColumns = []string{"asset class", "asset_class", "assetclass", "class"}
+)
+
+// csvTable 带表头的 CSV 表
+type csvTable struct {
+	header  map[string]int
+	records [][]string
+}
+
+// readCSV 读取带表头的 CSV
+func readCSV(r io.Reader) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read csv error: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty csv")
+	}
+	t := &csvTable{header: map[string]int{}, records: records[1:]}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		t.header[name] = i
+	}
+	return t, nil
+}
+
+// get 获取记录中第一个存在的列的值
+func (t *csvTable) get(record []string, columns []string) string {
+	for _, c := range columns {
+		if i, ok := t.header[c]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+	}
+	return ""
+}
+
+// has 判断是否包含任一列
+func (t *csvTable) has(columns []string) bool {
+	for _, c := range columns {
+		if _, ok := t.header[c]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTransactionsCSV 解析 CSV 格式的交易记录
+//
+// 表头不区分大小写，需包含 date 、 type 列，买卖交易还需 symbol 、 quantity 、 price 列，
+// 可选 account 、 fee 、 amount 、 currency 、 note 列。未指定账户的交易使用 defaultAccount
+func ParseTransactionsCSV(r io.Reader, defaultAccount string) ([]Transaction, error) {
+	t, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, required := range [][]string{csvTimeColumns, csvTypeColumns} {
+		if !t.has(required) {
+			return nil, fmt.Errorf("missing column %q", required[0])
+		}
+	}
+
+	txs := make([]Transaction, 0, len(t.records))
+	for i, record := range t.records {
+		line := i + 2
+		tx := Transaction{
+			Account:  t.get(record, csvAccountColumns),
+			Symbol:   strings.ToUpper(t.get(record, csvSymbolColumns)),
+			Currency: strings.ToUpper(t.get(record, csvCurrencyColumns)),
+			Note:     t.get(record, csvNoteColumns),
+		}
+		if tx.Account == "" {
+			tx.Account = defaultAccount
+		}
+		if tx.Time, err = ParseTime(t.get(record, csvTimeColumns)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if tx.Type, err = ParseTransactionType(t.get(record, csvTypeColumns)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		for _, f := range []struct {
+			columns []string
+			dst     *decimal.Decimal
+		}{
+			{csvQuantityColumns, &tx.Quantity},
+			{csvPriceColumns, &tx.Price},
+			{csvFeeColumns, &tx.Fee},
+			{csvAmountColumns, &tx.Amount},
+		} {
+			if *f.dst, err = ParseDecimal(t.get(record, f.columns)); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %w", line, f.columns[0], err)
+			}
+		}
+		// 卖出数量可能以负数表示
+		tx.Quantity = tx.Quantity.Abs()
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// PositionsImport CSV 导入的持仓
+type PositionsImport struct {
+	// 持仓
+	Positions []Position
+	// 证券信息，键为证券代码
+	Securities map[string]Security
+}
+
+// ParsePositionsCSV 解析 CSV 格式的持仓
+//
+// 表头不区分大小写，需包含 symbol 、 quantity 列，成本可通过 cost （总成本）或 avg cost （平均成本）列指定，
+// 可选 account 、 name 、 currency 、 price 、 market 、 sector 、 asset class 列。未指定账户的持仓使用 defaultAccount
+func ParsePositionsCSV(r io.Reader, defaultAccount string) (PositionsImport, error) {
+	t, err := readCSV(r)
+	if err != nil {
+		return PositionsImport{}, err
+	}
+	for _, required := range [][]string{csvSymbolColumns, csvQuantityColumns} {
+		if !t.has(required) {
+			return PositionsImport{}, fmt.Errorf("missing column %q", required[0])
+		}
+	}
+
+	ret := PositionsImport{Securities: map[string]Security{}}
+	now := time.Now()
+	for i, record := range t.records {
+		line := i + 2
+		pos := Position{
+			Account:  t.get(record, csvAccountColumns),
+			Symbol:   strings.ToUpper(t.get(record, csvSymbolColumns)),
+			Currency: strings.ToUpper(t.get(record, csvCurrencyColumns)),
+		}
+		if pos.Symbol == "" {
+			return PositionsImport{}, fmt.Errorf("line %d: missing symbol", line)
+		}
+		if pos.Account == "" {
+			pos.Account = defaultAccount
+		}
+		if pos.Quantity, err = ParseDecimal(t.get(record, csvQuantityColumns)); err != nil {
+			return PositionsImport{}, fmt.Errorf("line %d: invalid quantity: %w", line, err)
+		}
+		if pos.CostBasis, err = ParseDecimal(t.get(record, csvCostColumns)); err != nil {
+			return PositionsImport{}, fmt.Errorf("line %d: invalid cost: %w", line, err)
+		}
+		if pos.CostBasis.IsZero() {
+			avgCost, err := ParseDecimal(t.get(record, csvAvgCostColumns))
+			if err != nil {
+				return PositionsImport{}, fmt.Errorf("line %d: invalid avg cost: %w", line, err)
+			}
+			pos.CostBasis = avgCost.Mul(pos.Quantity)
+		}
+
+		sec := Security{
+			Name:       t.get(record, csvNameColumns),
+			Market:     strings.ToUpper(t.get(record, csvMarketColumns)),
+			Sector:     t.get(record, csvSectorColumns),
+			AssetClass: strings.ToLower(t.get(record, csvAssetClassColumns)),
+			Currency:   pos.Currency,
+		}
+		if sec.Price, err = ParseDecimal(t.get(record, csvPriceColumns)); err != nil {
+			return PositionsImport{}, fmt.Errorf("line %d: invalid price: %w", line, err)
+		}
+		if !sec.Price.IsZero() {
+			sec.PriceTime = now
+		}
+		ret.Securities[pos.Symbol] = sec
+		ret.Positions = append(ret.Positions, pos)
+	}
+	return ret, nil
+}
+
+// ImportPositions 导入持仓，替换涉及账户的所有持仓，并更新证券信息
+func (p *Portfolio) ImportPositions(in PositionsImport) {
+	byAccount := map[string][]Position{}
+	var accounts []string
+	for _, pos := range in.Positions {
+		if _, ok := byAccount[pos.Account]; !ok {
+			accounts = append(accounts, pos.Account)
+		}
+		byAccount[pos.Account] = append(byAccount[pos.Account], pos)
+	}
+	for _, account := range accounts {
+		p.SetPositions(account, byAccount[account])
+	}
+	for symbol, sec := range in.Securities {
+		p.UpdateSecurity(symbol, sec)
+	}
+}
+
+// 支持的时间格式
+var timeLayouts = []string{
+	time.RFC3339,
+	time.DateTime,
+	time.DateOnly,
+	"2006-01-02T15:04:05",
+	"2006/01/02 15:04:05",
+	"2006/1/2",
+	"20060102",
+}
+
+// ParseTime 解析时间，支持 RFC3339 、 YYYY-MM-DD 等格式，无时区时使用本地时区
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (expected YYYY-MM-DD or RFC3339)", s)
+}
+
+// ParseDecimal 解析数值，忽略千分位逗号，空字符串返回 0
+func ParseDecimal(s string) (decimal.Decimal, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if s == "" {
+		return decimal.Zero, nil
+	}
+	return decimal.NewFromString(s)
+}
@@ -0,0 +1,454 @@
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultAccountID 未指定账户时使用的账户 ID
+const DefaultAccountID = "default"
+
+// Portfolio 投资组合
+type Portfolio struct {
+	// 账户
+	Accounts []Account `json:"accounts,omitempty"`
+	// 证券信息，键为证券代码
+	Securities map[string]Security `json:"securities,omitempty"`
+	// 持仓
+	Positions []Position `json:"positions,omitempty"`
+	// 现金余额
+	Cash []CashBalance `json:"cash,omitempty"`
+	// 交易记录
+	Transactions []Transaction `json:"transactions,omitempty"`
+}
+
+// Account 账户
+type Account struct {
+	// 账户 ID
+	ID string `json:"id"`
+	// 账户名
+	Name string `json:"name,omitempty"`
+	// 券商
+	Broker string `json:"broker,omitempty"`
+	// 账户货币
+	Currency string `json:"currency,omitempty"`
+	// 最近一次直接设置持仓或现金的时间，此前的交易已体现在持仓和现金中，不能再补录
+	SnapshotTime time.Time `json:"snapshotTime,omitzero"`
+}
+
+// Security 证券信息
+type Security struct {
+	// 名称
+	Name string `json:"name,omitempty"`
+	// 市场，如 US 、 HK 、 CN
+	Market string `json:"market,omitempty"`
+	// 行业，如 Semiconductors
+	Sector string `json:"sector,omitempty"`
+	// 资产类别，如 stock 、 etf 、 bond 、 fund
+	AssetClass string `json:"assetClass,omitempty"`
+	// 计价货币
+	Currency string `json:"currency,omitempty"`
+	// 最近价格
+	Price decimal.Decimal `json:"price,omitzero"`
+	// 最近价格的时间
+	PriceTime time.Time `json:"priceTime,omitzero"`
+}
+
+// Position 持仓
+type Position struct {
+	// 账户 ID
+	Account string `json:"account"`
+	// 证券代码
+	Symbol string `json:"symbol"`
+	// 数量
+	Quantity decimal.Decimal `json:"quantity"`
+	// 总成本（含费用）
+	CostBasis decimal.Decimal `json:"costBasis"`
+	// 计价货币
+	Currency string `json:"currency,omitempty"`
+	// 已实现盈亏
+	RealizedPnL decimal.Decimal `json:"realizedPnL,omitzero"`
+	// 分红等收入
+	Income decimal.Decimal `json:"income,omitzero"`
+}
+
+// AverageCost 平均成本
+func (p Position) AverageCost() decimal.Decimal {
+	if p.Quantity.IsZero() {
+		return decimal.Zero
+	}
+	return p.CostBasis.Div(p.Quantity)
+}
+
+// CashBalance 现金余额
+type CashBalance struct {
+	// 账户 ID
+	Account string `json:"account"`
+	// 货币
+	Currency string `json:"currency"`
+	// 金额
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// TransactionType 交易类型
+type TransactionType string
+
+// 交易类型
+const (
+	TransactionBuy        TransactionType = "buy"
+	TransactionSell       TransactionType = "sell"
+	TransactionDividend   TransactionType = "dividend"
+	TransactionInterest   TransactionType = "interest"
+	TransactionFee        TransactionType = "fee"
+	TransactionDeposit    TransactionType = "deposit"
+	TransactionWithdrawal TransactionType = "withdrawal"
+)
+
+// ParseTransactionType 解析交易类型
+func ParseTransactionType(s string) (TransactionType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "buy", "bought", "b":
+		return TransactionBuy, nil
+	case "sell", "sold", "s":
+		return TransactionSell, nil
+	case "dividend", "div":
+		return TransactionDividend, nil
+	case "interest":
+		return TransactionInterest, nil
+	case "fee", "fees", "commission", "tax":
+		return TransactionFee, nil
+	case "deposit", "transfer in", "transfer_in":
+		return TransactionDeposit, nil
+	case "withdrawal", "withdraw", "transfer out", "transfer_out":
+		return TransactionWithdrawal, nil
+	}
+	return "", fmt.Errorf("unknown transaction type %q (expected: buy, sell, dividend, interest, fee, deposit or withdrawal)", s)
+}
+
+// Transaction 交易记录
+type Transaction struct {
+	// 交易时间
+	Time time.Time `json:"time"`
+	// 账户 ID
+	Account string `json:"account"`
+	// 类型
+	Type TransactionType `json:"type"`
+	// 证券代码，现金类交易可为空
+	Symbol string `json:"symbol,omitempty"`
+	// 数量
+	Quantity decimal.Decimal `json:"quantity,omitzero"`
+	// 成交价
+	Price decimal.Decimal `json:"price,omitzero"`
+	// 费用
+	Fee decimal.Decimal `json:"fee,omitzero"`
+	// 金额，买卖交易为空时为数量乘价格
+	Amount decimal.Decimal `json:"amount,omitzero"`
+	// 货币
+	Currency string `json:"currency,omitempty"`
+	// 备注
+	Note string `json:"note,omitempty"`
+}
+
+// key 用于判断交易是否重复导入的键
+func (t Transaction) key() string {
+	return strings.Join([]string{
+		t.Time.UTC().Format(time.RFC3339), t.Account, string(t.Type), t.Symbol,
+		t.Quantity.String(), t.Price.String(), t.Fee.String(), t.Amount.String(), t.Currency,
+	}, "|")
+}
+
+// Account 获取账户
+func (p *Portfolio) Account(id string) (Account, bool) {
+	for _, a := range p.Accounts {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return Account{}, false
+}
+
+// SetAccount 添加或更新账户
+func (p *Portfolio) SetAccount(account Account) {
+	for i, a := range p.Accounts {
+		if a.ID == account.ID {
+			p.Accounts[i] = account
+			return
+		}
+	}
+	p.Accounts = append(p.Accounts, account)
+}
+
+// RemoveAccount 删除账户及其持仓、现金和交易记录
+func (p *Portfolio) RemoveAccount(id string) bool {
+	found := false
+	accounts := p.Accounts[:0]
+	for _, a := range p.Accounts {
+		if a.ID == id {
+			found = true
+			continue
+		}
+		accounts = append(accounts, a)
+	}
+	p.Accounts = accounts
+	p.Positions = filter(p.Positions, func(pos Position) bool { return pos.Account != id })
+	p.Cash = filter(p.Cash, func(c CashBalance) bool { return c.Account != id })
+	p.Transactions = filter(p.Transactions, func(t Transaction) bool { return t.Account != id })
+	return found
+}
+
+// ensureAccount 确保账户存在
+func (p *Portfolio) ensureAccount(id string) {
+	if _, ok := p.Account(id); !ok {
+		p.Accounts = append(p.Accounts, Account{ID: id})
+	}
+}
+
+// markSnapshot 记录账户直接设置持仓或现金的时间
+func (p *Portfolio) markSnapshot(id string) {
+	for i := range p.Accounts {
+		if p.Accounts[i].ID == id {
+			p.Accounts[i].SnapshotTime = time.Now()
+		}
+	}
+}
+
+// accountCurrency 账户货币
+func (p *Portfolio) accountCurrency(id string) string {
+	a, _ := p.Account(id)
+	return a.Currency
+}
+
+// Security 获取证券信息
+func (p *Portfolio) Security(symbol string) Security {
+	return p.Securities[symbol]
+}
+
+// UpdateSecurity 更新证券信息，只更新 update 中非空的字段
+func (p *Portfolio) UpdateSecurity(symbol string, update Security) {
+	if p.Securities == nil {
+		p.Securities = map[string]Security{}
+	}
+	s := p.Securities[symbol]
+	if update.Name != "" {
+		s.Name = update.Name
+	}
+	if update.Market != "" {
+		s.Market = update.Market
+	}
+	if update.Sector != "" {
+		s.Sector = update.Sector
+	}
+	if update.AssetClass != "" {
+		s.AssetClass = update.AssetClass
+	}
+	if update.Currency != "" {
+		s.Currency = update.Currency
+	}
+	if !update.Price.IsZero() {
+		s.Price = update.Price
+		s.PriceTime = update.PriceTime
+	}
+	p.Securities[symbol] = s
+}
+
+// position 获取持仓的指针，不存在时创建
+func (p *Portfolio) position(account, symbol, currency string) *Position {
+	for i := range p.Positions {
+		if p.Positions[i].Account == account && p.Positions[i].Symbol == symbol {
+			return &p.Positions[i]
+		}
+	}
+	p.Positions = append(p.Positions, Position{Account: account, Symbol: symbol, Currency: currency})
+	return &p.Positions[len(p.Positions)-1]
+}
+
+// SetPositions 替换账户的所有持仓
+func (p *Portfolio) SetPositions(account string, positions []Position) {
+	p.ensureAccount(account)
+	p.markSnapshot(account)
+	p.Positions = filter(p.Positions, func(pos Position) bool { return pos.Account != account })
+	for _, pos := range positions {
+		pos.Account = account
+		p.Positions = append(p.Positions, pos)
+	}
+}
+
+// AddCash 增加账户的现金余额， amount 为负数时减少
+func (p *Portfolio) AddCash(account, currency string, amount decimal.Decimal) {
+	for i := range p.Cash {
+		if p.Cash[i].Account == account && strings.EqualFold(p.Cash[i].Currency, currency) {
+			p.Cash[i].Amount = p.Cash[i].Amount.Add(amount)
+			return
+		}
+	}
+	p.Cash = append(p.Cash, CashBalance{Account: account, Currency: currency, Amount: amount})
+}
+
+// SetCash 设置账户的现金余额
+func (p *Portfolio) SetCash(account, currency string, amount decimal.Decimal) {
+	p.ensureAccount(account)
+	p.markSnapshot(account)
+	for i := range p.Cash {
+		if p.Cash[i].Account == account && strings.EqualFold(p.Cash[i].Currency, currency) {
+			p.Cash[i].Amount = amount
+			return
+		}
+	}
+	p.Cash = append(p.Cash, CashBalance{Account: account, Currency: currency, Amount: amount})
+}
+
+// AddTransactions 按时间顺序应用交易，更新持仓和现金，返回新增的交易数
+//
+// 与已有交易完全相同的交易视为重复导入，会被跳过；同一批中的相同交易按已有交易中的次数计为重复，
+// 其余的都会导入。持仓成本使用移动加权平均法计算，导入早于账户已有交易的交易时，
+// 按时间顺序重放该账户的所有交易，重建其持仓和现金。
+// 直接设置过持仓或现金的账户无法只由交易记录重建，导入早于设置时间或已有交易的交易时返回错误
+func (p *Portfolio) AddTransactions(txs []Transaction) (int, error) {
+	existing := make(map[string]int, len(p.Transactions))
+	latest := map[string]time.Time{}
+	snapshots := map[string]time.Time{}
+	for _, a := range p.Accounts {
+		snapshots[a.ID] = a.SnapshotTime
+	}
+	for _, t := range p.Transactions {
+		existing[t.key()]++
+		if t.Time.After(latest[t.Account]) {
+			latest[t.Account] = t.Time
+		}
+	}
+
+	var added []Transaction
+	rebuild := map[string]bool{}
+	for _, t := range txs {
+		if t.Account == "" {
+			t.Account = DefaultAccountID
+		}
+		if t.Currency == "" {
+			t.Currency = p.Security(t.Symbol).Currency
+		}
+		if t.Currency == "" {
+			t.Currency = p.accountCurrency(t.Account)
+		}
+		if key := t.key(); existing[key] > 0 {
+			existing[key]--
+			continue
+		}
+		if snapshot := snapshots[t.Account]; !snapshot.IsZero() &&
+			(t.Time.Before(snapshot) || t.Time.Before(latest[t.Account])) {
+			return 0, fmt.Errorf(
+				"transaction %s %s at %s is earlier than existing records of account %q, "+
+					"whose positions or cash were set directly at %s and cannot be rebuilt from transactions",
+				t.Type, t.Symbol, t.Time.Format(time.DateOnly), t.Account, snapshot.Format(time.DateTime),
+			)
+		}
+		if t.Time.Before(latest[t.Account]) {
+			rebuild[t.Account] = true
+		}
+		added = append(added, t)
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+
+	// 合并后按时间排序，时间相同时已有交易在前
+	type entry struct {
+		tx    Transaction
+		added bool
+	}
+	merged := make([]entry, 0, len(p.Transactions)+len(added))
+	for _, t := range p.Transactions {
+		merged = append(merged, entry{tx: t})
+	}
+	for _, t := range added {
+		merged = append(merged, entry{tx: t, added: true})
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].tx.Time.Before(merged[j].tx.Time) })
+
+	// 需要重建的账户从空持仓和现金开始重放所有交易，其它账户只应用新增的交易
+	for account := range rebuild {
+		p.Positions = filter(p.Positions, func(pos Position) bool { return pos.Account != account })
+		p.Cash = filter(p.Cash, func(c CashBalance) bool { return c.Account != account })
+	}
+	transactions := make([]Transaction, 0, len(merged))
+	for _, e := range merged {
+		t := e.tx
+		if e.added || rebuild[t.Account] {
+			if err := p.apply(t); err != nil {
+				return 0, fmt.Errorf("apply transaction %s %s %s at %s error: %w",
+					t.Type, t.Quantity, t.Symbol, t.Time.Format(time.DateOnly), err)
+			}
+		}
+		transactions = append(transactions, t)
+	}
+	p.Transactions = transactions
+	return len(added), nil
+}
+
+// apply 应用一笔交易
+func (p *Portfolio) apply(t Transaction) error {
+	p.ensureAccount(t.Account)
+
+	switch t.Type {
+	case TransactionBuy, TransactionSell:
+		if t.Symbol == "" {
+			return fmt.Errorf("symbol is required")
+		}
+		if !t.Quantity.IsPositive() {
+			return fmt.Errorf("quantity must be positive")
+		}
+		amount := t.Amount.Abs()
+		if amount.IsZero() {
+			amount = t.Quantity.Mul(t.Price)
+		}
+		fee := t.Fee.Abs()
+		pos := p.position(t.Account, t.Symbol, t.Currency)
+
+		if t.Type == TransactionBuy {
+			pos.Quantity = pos.Quantity.Add(t.Quantity)
+			pos.CostBasis = pos.CostBasis.Add(amount).Add(fee)
+			p.AddCash(t.Account, t.Currency, amount.Add(fee).Neg())
+			return nil
+		}
+
+		if t.Quantity.GreaterThan(pos.Quantity) {
+			return fmt.Errorf("sell quantity %s exceeds holding %s", t.Quantity, pos.Quantity)
+		}
+		cost := pos.AverageCost().Mul(t.Quantity)
+		if t.Quantity.Equal(pos.Quantity) {
+			cost = pos.CostBasis
+		}
+		pos.RealizedPnL = pos.RealizedPnL.Add(amount.Sub(fee).Sub(cost))
+		pos.CostBasis = pos.CostBasis.Sub(cost)
+		pos.Quantity = pos.Quantity.Sub(t.Quantity)
+		p.AddCash(t.Account, t.Currency, amount.Sub(fee))
+	case TransactionDividend, TransactionInterest:
+		amount := t.Amount.Abs().Sub(t.Fee.Abs())
+		if t.Symbol != "" {
+			pos := p.position(t.Account, t.Symbol, t.Currency)
+			pos.Income = pos.Income.Add(amount)
+		}
+		p.AddCash(t.Account, t.Currency, amount)
+	case TransactionDeposit:
+		p.AddCash(t.Account, t.Currency, t.Amount.Abs().Sub(t.Fee.Abs()))
+	case TransactionWithdrawal, TransactionFee:
+		p.AddCash(t.Account, t.Currency, t.Amount.Abs().Add(t.Fee.Abs()).Neg())
+	default:
+		return fmt.Errorf("unknown transaction type %q", t.Type)
+	}
+	return nil
+}
+
+// filter 过滤列表
+func filter[T any](items []T, keep func(T) bool) []T {
+	ret := items[:0]
+	for _, item := range items {
+		if keep(item) {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,227 @@
+package portfolio
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/fx"
+)
+
+const testTransactionsCSV = `Date,Account,Type,Symbol,Quantity,Price,Fee,Amount,Currency
+2026-01-02,ibkr,deposit,,,,,"10,000",USD
+2026-01-05,ibkr,buy,NVDA,10,100,1,,USD
+2026-02-05,ibkr,buy,NVDA,10,120,1,,USD
+2026-03-05,ibkr,sell,NVDA,5,150,1,,USD
+2026-03-20,ibkr,dividend,NVDA,,,,2,USD
+`
+
+func TestAddTransactions(t *testing.T) {
+	txs, err := ParseTransactionsCSV(strings.NewReader(testTransactionsCSV), DefaultAccountID)
+	require.NoError(t, err)
+	require.Len(t, txs, 5)
+
+	p := &Portfolio{}
+	added, err := p.AddTransactions(txs)
+	require.NoError(t, err)
+	assert.Equal(t, 5, added)
+
+	// 重复导入被跳过
+	added, err = p.AddTransactions(txs)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+	assert.Len(t, p.Transactions, 5)
+
+	require.Len(t, p.Positions, 1)
+	pos := p.Positions[0]
+	// 买入成本 1001 + 1201 = 2202 ，平均成本 110.1 ，卖出 5 股成本 550.5
+	assert.Equal(t, "15", pos.Quantity.String())
+	assert.Equal(t, "1651.5", pos.CostBasis.String())
+	assert.Equal(t, "198.5", pos.RealizedPnL.String())
+	assert.Equal(t, "2", pos.Income.String())
+
+	require.Len(t, p.Cash, 1)
+	// 10000 - 1001 - 1201 + 749 + 2
+	assert.Equal(t, "8549", p.Cash[0].Amount.String())
+
+	// 卖出超过持仓数量
+	_, err = p.AddTransactions([]Transaction{{
+		Time: txs[4].Time, Account: "ibkr", Type: TransactionSell, Symbol: "NVDA",
+		Quantity: decimal.NewFromInt(20), Price: decimal.NewFromInt(100),
+	}})
+	assert.Error(t, err)
+}
+
+// TestAddTransactionsDuplicates 测试同一批中的相同交易和补录更早的交易
+func TestAddTransactionsDuplicates(t *testing.T) {
+	buy := Transaction{
+		Time: time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC), Account: "ibkr", Type: TransactionBuy, Symbol: "NVDA",
+		Quantity: decimal.NewFromInt(10), Price: decimal.NewFromInt(120), Currency: "USD",
+	}
+	sell := Transaction{
+		Time: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), Account: "ibkr", Type: TransactionSell, Symbol: "NVDA",
+		Quantity: decimal.NewFromInt(15), Price: decimal.NewFromInt(150), Currency: "USD",
+	}
+
+	// 同价分笔成交的两行都导入
+	p := &Portfolio{}
+	added, err := p.AddTransactions([]Transaction{buy, buy})
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, "20", p.Positions[0].Quantity.String())
+
+	// 重复导入时按已有次数跳过，多出的一行导入
+	added, err = p.AddTransactions([]Transaction{buy, buy, buy})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, "30", p.Positions[0].Quantity.String())
+
+	// 补录更早的买入后重放，卖出不会因持仓不足失败，平均成本按时间顺序计算
+	p = &Portfolio{}
+	_, err = p.AddTransactions([]Transaction{buy})
+	require.NoError(t, err)
+	early := buy
+	early.Time = time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	early.Price = decimal.NewFromInt(100)
+	added, err = p.AddTransactions([]Transaction{sell, early})
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+	require.Len(t, p.Positions, 1)
+	// 买入成本 1000 + 1200 ，平均成本 110 ，卖出 15 股成本 1650
+	assert.Equal(t, "5", p.Positions[0].Quantity.String())
+	assert.Equal(t, "550", p.Positions[0].CostBasis.String())
+	assert.Equal(t, "600", p.Positions[0].RealizedPnL.String())
+	assert.Equal(t, "50", p.Cash[0].Amount.String())
+	assert.Equal(t, early.Time, p.Transactions[0].Time)
+
+	// 补录的交易使后续卖出超过持仓时失败
+	late := early
+	late.Type = TransactionSell
+	late.Quantity = decimal.NewFromInt(10)
+	late.Time = time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)
+	_, err = p.AddTransactions([]Transaction{late})
+	assert.ErrorContains(t, err, "exceeds holding")
+}
+
+func TestParsePositionsCSV(t *testing.T) {
+	in, err := ParsePositionsCSV(strings.NewReader(`symbol,qty,avg cost,price,sector,market,currency
+nvda,10,100,130,Semiconductors,US,USD
+0700.HK,100,300,,Internet,HK,HKD
+`), "main")
+	require.NoError(t, err)
+	require.Len(t, in.Positions, 2)
+	assert.Equal(t, "main", in.Positions[0].Account)
+	assert.Equal(t, "NVDA", in.Positions[0].Symbol)
+	assert.Equal(t, "1000", in.Positions[0].CostBasis.String())
+	assert.Equal(t, "130", in.Securities["NVDA"].Price.String())
+
+	_, err = ParsePositionsCSV(strings.NewReader("symbol,price\nNVDA,1\n"), "main")
+	assert.Error(t, err)
+}
+
+func TestAnalyze(t *testing.T) {
+	p := &Portfolio{}
+	p.ImportPositions(PositionsImport{
+		Positions: []Position{
+			{Account: "a", Symbol: "NVDA", Quantity: decimal.NewFromInt(10), CostBasis: decimal.NewFromInt(1000), Currency: "USD"},
+			{Account: "a", Symbol: "AMD", Quantity: decimal.NewFromInt(10), CostBasis: decimal.NewFromInt(1000), Currency: "USD"},
+			{Account: "b", Symbol: "0700.HK", Quantity: decimal.NewFromInt(10), CostBasis: decimal.NewFromInt(4000), Currency: "HKD"},
+		},
+		Securities: map[string]Security{
+			"NVDA":    {Sector: "Semiconductors", Price: decimal.NewFromInt(150)},
+			"AMD":     {Sector: "Semiconductors"},
+			"0700.HK": {Sector: "Internet", Price: decimal.NewFromInt(500)},
+		},
+	})
+	p.SetCash("a", "USD", decimal.NewFromInt(1000))
+
+	converter := fx.NewConverter(fx.NewStaticSource(fx.StaticOptions{Base: "USD", Rates: map[string]float64{"HKD": 0.125}}))
+	a := Analyze(p, AnalyzeOptions{
+		Prices:       map[string]decimal.Decimal{"nvda": decimal.NewFromInt(200)},
+		BaseCurrency: "USD",
+		Converter:    converter.AsOf(context.Background(), time.Time{}),
+	})
+	assert.Equal(t, "USD", a.BaseCurrency)
+	// NVDA 2000 + AMD 1000 （按成本） + 0700.HK 5000 HKD = 625 USD
+	assert.Equal(t, "3625", a.MarketValue.String())
+	assert.Equal(t, "4625", a.TotalValue.String())
+	assert.Equal(t, "1125", a.UnrealizedPnL.String())
+	assert.Equal(t, []string{"AMD"}, a.MissingPrices)
+	assert.Empty(t, a.Unconverted)
+	require.Len(t, a.Holdings, 3)
+	assert.Equal(t, "NVDA", a.Holdings[0].Symbol)
+	assert.Equal(t, "43.24", a.Holdings[0].Weight.String())
+
+	rows := a.Exposure(DimensionSector, true)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "Semiconductors", rows[0].Key)
+	assert.Equal(t, "3000", rows[0].Value.String())
+	assert.Equal(t, []string{"NVDA", "AMD"}, rows[0].Symbols)
+	assert.Equal(t, CashCategory, rows[1].Key)
+
+	c := a.Concentration(1)
+	assert.Equal(t, 3, c.Positions)
+	assert.Equal(t, "55.17", c.TopWeight.String())
+	assert.True(t, c.EffectiveN.GreaterThan(decimal.NewFromInt(2)))
+
+	// 缺少汇率时不计入汇总
+	a = Analyze(p, AnalyzeOptions{Account: "b", BaseCurrency: "USD"})
+	assert.Equal(t, []string{"HKD"}, a.Unconverted)
+	assert.True(t, a.MarketValue.IsZero())
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), DirName))
+
+	p, err := s.Load()
+	require.NoError(t, err)
+	assert.Empty(t, p.Positions)
+
+	require.NoError(t, s.Update(func(p *Portfolio) error {
+		p.SetAccount(Account{ID: "ibkr", Broker: "Interactive Brokers", Currency: "USD"})
+		p.SetCash("ibkr", "USD", decimal.NewFromInt(100))
+		return nil
+	}))
+	p, err = s.Load()
+	require.NoError(t, err)
+	account, ok := p.Account("ibkr")
+	require.True(t, ok)
+	assert.Equal(t, "Interactive Brokers", account.Broker)
+	require.Len(t, p.Cash, 1)
+	assert.Equal(t, "100", p.Cash[0].Amount.String())
+}
+
+// TestAddTransactionsAfterSnapshot 测试直接设置持仓后补录更早的交易
+func TestAddTransactionsAfterSnapshot(t *testing.T) {
+	in, err := ParsePositionsCSV(strings.NewReader("Symbol,Quantity,Cost,Currency\nNVDA,10,100,USD\n"), "ibkr")
+	require.NoError(t, err)
+	p := &Portfolio{}
+	p.ImportPositions(in)
+	p.SetCash("ibkr", "USD", decimal.NewFromInt(1000))
+
+	// 导入持仓之后的交易正常应用
+	buy := Transaction{
+		Time: time.Now().Add(time.Minute), Account: "ibkr", Type: TransactionBuy, Symbol: "NVDA",
+		Quantity: decimal.NewFromInt(5), Price: decimal.NewFromInt(100), Currency: "USD",
+	}
+	added, err := p.AddTransactions([]Transaction{buy})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, "15", p.Positions[0].Quantity.String())
+
+	// 早于导入持仓或已有交易的交易不重放，持仓和现金保持不变
+	sell := buy
+	sell.Type = TransactionSell
+	sell.Time = time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	_, err = p.AddTransactions([]Transaction{sell})
+	assert.ErrorContains(t, err, "set directly")
+	assert.Equal(t, "15", p.Positions[0].Quantity.String())
+	assert.Equal(t, "500", p.Cash[0].Amount.String())
+	assert.Len(t, p.Transactions, 1)
+}
@@ -0,0 +1,92 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// DirName 投资组合数据目录名
+	DirName = "portfolio"
+	// FileName 投资组合数据文件名
+	FileName = "portfolio.json"
+)
+
+// NewStore 创建投资组合存储
+//
+// 数据保存在 dir 目录下的 portfolio.json 文件中
+func NewStore(dir string) *Store {
+	return &Store{path: filepath.Join(dir, FileName)}
+}
+
+// Store 投资组合存储
+type Store struct {
+	lock sync.Mutex
+	path string
+}
+
+// Path 数据文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load 加载投资组合，文件不存在时返回空投资组合
+func (s *Store) Load() (*Portfolio, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.load()
+}
+
+// Update 加载投资组合，调用 fn 修改后保存， fn 返回错误时不保存
+func (s *Store) Update(fn func(p *Portfolio) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(p); err != nil {
+		return err
+	}
+	return s.save(p)
+}
+
+// load 加载投资组合
+func (s *Store) load() (*Portfolio, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Portfolio{}, nil
+		}
+		return nil, fmt.Errorf("read portfolio file %q error: %w", s.path, err)
+	}
+	p := &Portfolio{}
+	if err := json.Unmarshal(raw, p); err != nil {
+		return nil, fmt.Errorf("unmarshal portfolio file %q error: %w", s.path, err)
+	}
+	return p, nil
+}
+
+// save 保存投资组合，先写入临时文件再重命名，避免写入中断导致文件损坏
+func (s *Store) save(p *Portfolio) error {
+	raw, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal portfolio to json error: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("create portfolio directory error: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("write portfolio file %q error: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("rename %q to %q error: %w", tmp, s.path, err)
+	}
+	return nil
+}
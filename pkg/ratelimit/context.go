@@ -0,0 +1,26 @@
+package ratelimit
+
+import (
+	"context"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+type registryContextKey struct{}
+
+// ContextWithRegistry 返回包含限流器注册表的上下文
+func ContextWithRegistry(ctx context.Context, r *Registry) context.Context {
+	return context.WithValue(ctx, registryContextKey{}, r)
+}
+
+// RegistryFromContext 从上下文获取限流器注册表
+func RegistryFromContext(ctx context.Context) *Registry {
+	r, _ := ctx.Value(registryContextKey{}).(*Registry)
+	return r
+}
+
+// ModelMiddlewareFromContext 从上下文获取限流模型中间件
+func ModelMiddlewareFromContext(ctx context.Context, modelName string) ai.ModelMiddleware {
+	r := RegistryFromContext(ctx)
+	return r.ModelMiddleware(modelName)
+}
@@ -0,0 +1,219 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Limits 限流配置
+type Limits struct {
+	// 每分钟最大请求数，0 表示不限制
+	RequestsPerMinute int `json:"requestsPerMinute,omitempty"`
+	// 每分钟最大 Token 数（输入 + 输出），0 表示不限制
+	TokensPerMinute int64 `json:"tokensPerMinute,omitempty"`
+	// 最大并发请求数，0 表示不限制
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+}
+
+// IsZero 是否未设置任何限制
+func (l Limits) IsZero() bool {
+	return l.RequestsPerMinute <= 0 && l.TokensPerMinute <= 0 && l.MaxConcurrency <= 0
+}
+
+// NewLimiter 创建限流器
+func NewLimiter(limits Limits) *Limiter {
+	return &Limiter{
+		limits:        limits,
+		requestTokens: float64(limits.RequestsPerMinute),
+		tokenTokens:   float64(limits.TokensPerMinute),
+		last:          time.Now(),
+		changed:       make(chan struct{}),
+		now:           time.Now,
+	}
+}
+
+// Limiter 基于令牌桶的限流器
+//
+// 等待者按到达顺序排队，只有队首的等待者可以获取配额，以保证公平
+type Limiter struct {
+	limits Limits
+
+	lock     sync.Mutex
+	queue    []*waiter
+	inflight int
+	// 请求数令牌桶中剩余令牌
+	requestTokens float64
+	// Token 数令牌桶中剩余令牌，可能因为实际用量超出预估而为负
+	tokenTokens float64
+	// 上次补充令牌的时间
+	last time.Time
+	// 状态变化时关闭并替换，用于通知等待者
+	changed chan struct{}
+
+	now func() time.Time
+}
+
+// waiter 等待者
+type waiter struct {
+	tokens int64
+}
+
+// Stats 限流器状态
+type Stats struct {
+	// 排队中的请求数
+	QueueDepth int
+	// 进行中的请求数
+	Inflight int
+}
+
+// Stats 获取限流器当前状态
+func (l *Limiter) Stats() Stats {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return Stats{QueueDepth: len(l.queue), Inflight: l.inflight}
+}
+
+// Acquire 获取一次请求的配额，阻塞直到配额可用或 ctx 结束
+//
+// tokens 为该请求预估消耗的 Token 数。返回的 release 函数需在请求结束后调用，传入实际消耗的 Token 数，
+// 用于修正 Token 令牌桶。 queued 表示该请求是否需要排队等待
+func (l *Limiter) Acquire(ctx context.Context, tokens int64) (release func(used int64), queued bool, err error) {
+	w := &waiter{tokens: tokens}
+
+	l.lock.Lock()
+	l.queue = append(l.queue, w)
+	l.lock.Unlock()
+
+	for {
+		l.lock.Lock()
+		var wait time.Duration
+		if l.queue[0] == w {
+			ok, d := l.tryTake(w.tokens)
+			if ok {
+				l.queue = l.queue[1:]
+				l.notifyLocked()
+				l.lock.Unlock()
+				return l.releaseFn(tokens), queued, nil
+			}
+			wait = d
+		}
+		changed := l.changed
+		l.lock.Unlock()
+
+		queued = true
+		var (
+			timer  *time.Timer
+			timeup <-chan time.Time
+		)
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeup = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-timeup:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			l.lock.Lock()
+			l.queue = slices.DeleteFunc(l.queue, func(item *waiter) bool { return item == w })
+			l.notifyLocked()
+			l.lock.Unlock()
+			return nil, queued, ctx.Err()
+		}
+	}
+}
+
+// releaseFn 返回释放配额的函数
+func (l *Limiter) releaseFn(estimated int64) func(used int64) {
+	var once sync.Once
+	return func(used int64) {
+		once.Do(func() {
+			l.lock.Lock()
+			defer l.lock.Unlock()
+			l.inflight--
+			if l.limits.TokensPerMinute > 0 && used > 0 {
+				l.refill()
+				l.tokenTokens -= float64(used - l.capTokens(estimated))
+			}
+			l.notifyLocked()
+		})
+	}
+}
+
+// tryTake 尝试获取配额，失败时返回预计需要等待的时间（ 0 表示需等待其它请求结束）
+func (l *Limiter) tryTake(tokens int64) (bool, time.Duration) {
+	l.refill()
+
+	if l.limits.MaxConcurrency > 0 && l.inflight >= l.limits.MaxConcurrency {
+		return false, 0
+	}
+
+	var wait time.Duration
+	if l.limits.RequestsPerMinute > 0 && l.requestTokens < 1 {
+		wait = max(wait, durationFor(1-l.requestTokens, float64(l.limits.RequestsPerMinute)))
+	}
+	need := float64(l.capTokens(tokens))
+	if l.limits.TokensPerMinute > 0 && l.tokenTokens < need {
+		wait = max(wait, durationFor(need-l.tokenTokens, float64(l.limits.TokensPerMinute)))
+	}
+	if wait > 0 {
+		return false, wait
+	}
+
+	if l.limits.RequestsPerMinute > 0 {
+		l.requestTokens--
+	}
+	if l.limits.TokensPerMinute > 0 {
+		l.tokenTokens -= need
+	}
+	l.inflight++
+	return true, 0
+}
+
+// capTokens 将单次请求的预估 Token 数限制在桶容量内，避免超大请求永远无法获取配额
+func (l *Limiter) capTokens(tokens int64) int64 {
+	if l.limits.TokensPerMinute > 0 && tokens > l.limits.TokensPerMinute {
+		return l.limits.TokensPerMinute
+	}
+	return max(tokens, 0)
+}
+
+// refill 按经过的时间补充令牌
+func (l *Limiter) refill() {
+	now := l.now()
+	elapsed := now.Sub(l.last).Minutes()
+	l.last = now
+	if elapsed <= 0 {
+		return
+	}
+	if l.limits.RequestsPerMinute > 0 {
+		l.requestTokens = math.Min(
+			float64(l.limits.RequestsPerMinute),
+			l.requestTokens+elapsed*float64(l.limits.RequestsPerMinute),
+		)
+	}
+	if l.limits.TokensPerMinute > 0 {
+		l.tokenTokens = math.Min(
+			float64(l.limits.TokensPerMinute),
+			l.tokenTokens+elapsed*float64(l.limits.TokensPerMinute),
+		)
+	}
+}
+
+// notifyLocked 通知所有等待者状态发生变化，调用前需持有锁
+func (l *Limiter) notifyLocked() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// durationFor 计算以每分钟 perMinute 的速度补充 n 个令牌所需的时间
+func durationFor(n, perMinute float64) time.Duration {
+	return time.Duration(math.Ceil(n / perMinute * float64(time.Minute)))
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterConcurrency(t *testing.T) {
+	l := NewLimiter(Limits{MaxConcurrency: 1})
+
+	release, queued, err := l.Acquire(t.Context(), 0)
+	require.NoError(t, err)
+	assert.False(t, queued)
+
+	// 第二个请求需要等待第一个请求释放
+	acquired := make(chan struct{})
+	go func() {
+		r, q, err := l.Acquire(context.Background(), 0)
+		assert.NoError(t, err)
+		assert.True(t, q)
+		close(acquired)
+		r(0)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired before release")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, Stats{QueueDepth: 1, Inflight: 1}, l.Stats())
+
+	release(0)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("not acquired after release")
+	}
+}
+
+func TestLimiterFIFO(t *testing.T) {
+	l := NewLimiter(Limits{MaxConcurrency: 1})
+	release, _, err := l.Acquire(t.Context(), 0)
+	require.NoError(t, err)
+
+	var (
+		lock  sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, _, err := l.Acquire(context.Background(), 0)
+			assert.NoError(t, err)
+			lock.Lock()
+			order = append(order, i)
+			lock.Unlock()
+			r(0)
+		}()
+		// 确保按顺序入队
+		require.Eventually(t, func() bool { return l.Stats().QueueDepth == i+1 }, time.Second, time.Millisecond)
+	}
+
+	release(0)
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, order)
+}
+
+func TestLimiterRequestsPerMinute(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter(Limits{RequestsPerMinute: 2})
+	l.now = func() time.Time { return now }
+	l.last = now
+
+	for range 2 {
+		release, queued, err := l.Acquire(t.Context(), 0)
+		require.NoError(t, err)
+		assert.False(t, queued)
+		release(0)
+	}
+
+	ok, wait := l.tryTake(0)
+	assert.False(t, ok)
+	assert.Equal(t, 30*time.Second, wait)
+
+	// 半分钟后补充 1 个令牌
+	now = now.Add(30 * time.Second)
+	ok, _ = l.tryTake(0)
+	assert.True(t, ok)
+}
+
+func TestLimiterTokensPerMinute(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter(Limits{TokensPerMinute: 1000})
+	l.now = func() time.Time { return now }
+	l.last = now
+
+	release, _, err := l.Acquire(t.Context(), 100)
+	require.NoError(t, err)
+	// 实际用量超出预估，额外扣除
+	release(900)
+	assert.InDelta(t, 100, l.tokenTokens, 0.001)
+
+	ok, wait := l.tryTake(400)
+	assert.False(t, ok)
+	assert.Equal(t, 18*time.Second, wait)
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := NewLimiter(Limits{MaxConcurrency: 1})
+	_, _, err := l.Acquire(t.Context(), 0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	_, queued, err := l.Acquire(ctx, 0)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, queued)
+	assert.Equal(t, 0, l.Stats().QueueDepth)
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/go-logr/logr"
+
+	"github.com/yhlooo/nfa/pkg/metrics"
+)
+
+// NewRegistry 创建限流器注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		providers: map[string]*Limiter{},
+		models:    map[string]*Limiter{},
+	}
+}
+
+// Registry 限流器注册表，按供应商和模型维护限流器
+type Registry struct {
+	lock      sync.RWMutex
+	providers map[string]*Limiter
+	models    map[string]*Limiter
+}
+
+// SetProviderLimits 设置供应商级别限流，该供应商的所有模型共享
+func (r *Registry) SetProviderLimits(provider string, limits Limits) {
+	if limits.IsZero() {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.providers[provider] = NewLimiter(limits)
+}
+
+// SetModelLimits 设置模型级别限流
+func (r *Registry) SetModelLimits(modelName string, limits Limits) {
+	if limits.IsZero() {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.models[modelName] = NewLimiter(limits)
+}
+
+// limitersFor 获取指定模型适用的限流器，按供应商、模型的顺序
+func (r *Registry) limitersFor(modelName string) []namedLimiter {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var ret []namedLimiter
+	if provider, _, ok := strings.Cut(modelName, "/"); ok {
+		if l := r.providers[provider]; l != nil {
+			ret = append(ret, namedLimiter{name: "provider " + provider, limiter: l})
+		}
+	}
+	if l := r.models[modelName]; l != nil {
+		ret = append(ret, namedLimiter{name: "model " + modelName, limiter: l})
+	}
+	return ret
+}
+
+// namedLimiter 带名字的限流器
+type namedLimiter struct {
+	name    string
+	limiter *Limiter
+}
+
+// ModelMiddleware 模型中间件，在调用模型前等待供应商和模型的限流配额
+func (r *Registry) ModelMiddleware(modelName string) ai.ModelMiddleware {
+	return func(modelFn ai.ModelFunc) ai.ModelFunc {
+		return func(
+			ctx context.Context,
+			req *ai.ModelRequest,
+			streamCallback ai.ModelStreamCallback,
+		) (*ai.ModelResponse, error) {
+			if r == nil {
+				return modelFn(ctx, req, streamCallback)
+			}
+			limiters := r.limitersFor(modelName)
+			if len(limiters) == 0 {
+				return modelFn(ctx, req, streamCallback)
+			}
+
+			logger := logr.FromContextOrDiscard(ctx)
+			estimated := EstimateTokens(req)
+
+			var releases []func(int64)
+			var used int64
+			defer func() {
+				for _, release := range releases {
+					release(used)
+				}
+			}()
+			for _, item := range limiters {
+				stats := item.limiter.Stats()
+				metrics.RateLimitWaiters.Inc()
+				release, queued, err := item.limiter.Acquire(ctx, estimated)
+				metrics.RateLimitWaiters.Dec()
+				if err != nil {
+					return nil, fmt.Errorf("wait for rate limit of %s error: %w", item.name, err)
+				}
+				if queued {
+					logger.Info(fmt.Sprintf(
+						"request queued by rate limit of %s, queue depth: %d, inflight: %d",
+						item.name, stats.QueueDepth+1, stats.Inflight,
+					))
+				}
+				releases = append(releases, release)
+			}
+
+			resp, err := modelFn(ctx, req, streamCallback)
+			if resp != nil && resp.Usage != nil {
+				used = int64(resp.Usage.InputTokens + resp.Usage.OutputTokens)
+			}
+			return resp, err
+		}
+	}
+}
+
+// EstimateTokens 粗略估计请求的输入 Token 数
+//
+// 按每 4 字节 1 个 Token 估计，仅用于限流预估，实际用量在请求结束后修正
+func EstimateTokens(req *ai.ModelRequest) int64 {
+	if req == nil {
+		return 0
+	}
+	var n int
+	for _, msg := range req.Messages {
+		for _, part := range msg.Content {
+			n += len(part.Text)
+		}
+	}
+	for _, tool := range req.Tools {
+		n += len(tool.Description)
+	}
+	return int64(n/4 + 1)
+}
@@ -0,0 +1,139 @@
+package reports
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// blockKind Markdown 块类型
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockList
+	blockTable
+	blockCode
+)
+
+// block Markdown 块
+type block struct {
+	kind blockKind
+	// 段落、标题、代码块的文本
+	text string
+	// 标题级别
+	level int
+	// 列表是否有序
+	ordered bool
+	// 列表项
+	items []string
+	// 表格
+	table *Table
+}
+
+var (
+	headingPattern     = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	listItemPattern    = regexp.MustCompile(`^\s*(?:[-*+]|(\d+)[.)])\s+(.*)$`)
+	tableDelimPattern  = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	captionTrimPattern = regexp.MustCompile(`^[#*_\s]+|[*_:：\s]+$`)
+)
+
+// parseBlocks 将 Markdown 文本划分为块，只支持报告中常用的段落、标题、列表、表格和代码块
+func parseBlocks(text string) []block {
+	lines := strings.Split(text, "\n")
+	var (
+		ret       []block
+		paragraph []string
+	)
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			ret = append(ret, block{kind: blockParagraph, text: strings.Join(paragraph, "\n")})
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flushParagraph()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			ret = append(ret, block{kind: blockCode, text: strings.Join(code, "\n")})
+
+		case headingPattern.MatchString(trimmed):
+			flushParagraph()
+			m := headingPattern.FindStringSubmatch(trimmed)
+			ret = append(ret, block{kind: blockHeading, level: len(m[1]), text: strings.TrimSpace(m[2])})
+
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && tableDelimPattern.MatchString(lines[i+1]):
+			caption := ""
+			if len(paragraph) > 0 {
+				caption = paragraph[len(paragraph)-1]
+			} else if len(ret) > 0 && (ret[len(ret)-1].kind == blockHeading || ret[len(ret)-1].kind == blockParagraph) {
+				prev := strings.Split(ret[len(ret)-1].text, "\n")
+				caption = prev[len(prev)-1]
+			}
+			flushParagraph()
+			t := &Table{
+				Title:   captionTrimPattern.ReplaceAllString(caption, ""),
+				Columns: splitTableRow(trimmed),
+				Rows:    [][]string{},
+			}
+			for i += 2; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				t.Rows = append(t.Rows, splitTableRow(strings.TrimSpace(lines[i])))
+			}
+			i--
+			citations := ParseCitations(caption)
+			for _, row := range append([][]string{t.Columns}, t.Rows...) {
+				for _, id := range ParseCitations(strings.Join(row, " ")) {
+					if !slices.Contains(citations, id) {
+						citations = append(citations, id)
+					}
+				}
+			}
+			t.Citations = citations
+			ret = append(ret, block{kind: blockTable, table: t})
+
+		case listItemPattern.MatchString(line):
+			flushParagraph()
+			m := listItemPattern.FindStringSubmatch(line)
+			b := block{kind: blockList, ordered: m[1] != "", items: []string{strings.TrimSpace(m[2])}}
+			for i+1 < len(lines) {
+				next := lines[i+1]
+				if m := listItemPattern.FindStringSubmatch(next); m != nil {
+					b.items = append(b.items, strings.TrimSpace(m[2]))
+				} else if strings.TrimSpace(next) != "" && (strings.HasPrefix(next, " ") || strings.HasPrefix(next, "\t")) {
+					// 列表项的续行
+					b.items[len(b.items)-1] += " " + strings.TrimSpace(next)
+				} else {
+					break
+				}
+				i++
+			}
+			ret = append(ret, b)
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flushParagraph()
+	return ret
+}
+
+// splitTableRow 拆分表格行的单元格
+func splitTableRow(line string) []string {
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
@@ -0,0 +1,87 @@
+package reports
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yhlooo/nfa/pkg/verify"
+)
+
+// trailingCitationPattern 位于句末标点之后的引用
+var trailingCitationPattern = regexp.MustCompile(`([。！？；;!?.])\s*(` + citationPattern.String() + `)`)
+
+// hasNumericClaim 判断文本是否包含需要引用来源的数值陈述
+//
+// 使用与回答核对相同的规则识别数值陈述，日期通常用于说明数据的时间，不单独要求引用
+func hasNumericClaim(text string) bool {
+	for _, c := range verify.ExtractClaims(text) {
+		if c.Kind != verify.KindDate {
+			return true
+		}
+	}
+	return false
+}
+
+// check 检查报告的引用，记录不存在的来源、缺少的章节和未引用来源的数值陈述
+func (r *Report) check() {
+	r.UnknownCitations = nil
+	r.MissingSections = nil
+	r.UnreferencedClaims = nil
+
+	for _, id := range r.Citations() {
+		if _, ok := r.Source(id); !ok {
+			r.UnknownCitations = append(r.UnknownCitations, id)
+		}
+	}
+	for _, kind := range RequiredSections {
+		if _, ok := r.Section(kind); !ok {
+			r.MissingSections = append(r.MissingSections, kind)
+		}
+	}
+
+	for _, s := range r.Sections {
+		title := s.Title
+		if title == "" {
+			title = string(s.Kind)
+		}
+		for _, b := range parseBlocks(s.Content) {
+			switch b.kind {
+			case blockParagraph:
+				r.checkText(title, b.text)
+			case blockList:
+				for _, item := range b.items {
+					r.checkText(title, item)
+				}
+			case blockTable:
+				r.checkTable(title, *b.table)
+			}
+		}
+	}
+}
+
+// checkText 检查文本中每个句子的数值陈述是否引用了来源
+func (r *Report) checkText(section, text string) {
+	// 引用通常位于句末标点之后，先将引用移到标点前再分句
+	text = trailingCitationPattern.ReplaceAllString(text, "$2$1")
+	for _, sentence := range verify.SplitSentences(text) {
+		sentence = strings.TrimSpace(sentence)
+		if sentence == "" || len(ParseCitations(sentence)) > 0 || !hasNumericClaim(sentence) {
+			continue
+		}
+		r.UnreferencedClaims = append(r.UnreferencedClaims, Claim{Section: section, Text: sentence})
+	}
+}
+
+// checkTable 检查数据表是否引用了来源，表标题中的引用对整个表格生效，否则逐行检查
+func (r *Report) checkTable(section string, t Table) {
+	if len(ParseCitations(t.Title)) > 0 {
+		return
+	}
+	for _, row := range t.Rows {
+		text := strings.Join(row, " | ")
+		if len(ParseCitations(text)) > 0 || !hasNumericClaim(text) {
+			continue
+		}
+		r.UnreferencedClaims = append(r.UnreferencedClaims, Claim{Section: section, Text: text})
+	}
+}
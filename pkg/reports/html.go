@@ -0,0 +1,153 @@
+package reports
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// htmlStyle HTML 报告的样式
+const htmlStyle = `body{max-width:960px;margin:2em auto;padding:0 1em;font-family:-apple-system,"Segoe UI","PingFang SC","Microsoft YaHei",sans-serif;line-height:1.6;color:#222}
+h1{border-bottom:2px solid #333;padding-bottom:.3em}
+h2{border-bottom:1px solid #ddd;padding-bottom:.2em;margin-top:1.6em}
+.meta{color:#666;font-size:.9em}
+table{border-collapse:collapse;margin:1em 0}
+th,td{border:1px solid #ccc;padding:.3em .6em;text-align:left}
+th{background:#f5f5f5}
+a.cite{text-decoration:none;font-size:.85em;vertical-align:super}
+.issues{background:#fff8e1;border-left:4px solid #f0b400;padding:.5em 1em}
+.sources code{font-size:.85em;word-break:break-all}
+.uncited{color:#999}
+pre{background:#f5f5f5;padding:.8em;overflow-x:auto}`
+
+var (
+	boldPattern       = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	inlineCodePattern = regexp.MustCompile("`([^`]+)`")
+	linkPattern       = regexp.MustCompile(`\[([^\]]+)]\((https?://[^)\s]+)\)`)
+)
+
+// HTML 将报告渲染为独立的 HTML 页面，来源引用渲染为指向来源列表的链接
+func HTML(r *Report) string {
+	l := labelsOf(r)
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html lang=\"%s\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n",
+		l.Lang, html.EscapeString(r.Title), htmlStyle)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", inlineHTML(r.Title))
+	if r.Question != "" && r.Question != r.Title {
+		fmt.Fprintf(&b, "<p class=\"meta\">%s: %s</p>\n", l.Question, html.EscapeString(r.Question))
+	}
+	if !r.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "<p class=\"meta\">%s: %s</p>\n", l.CreatedAt, r.CreatedAt.Format(time.DateTime))
+	}
+
+	for _, s := range r.Sections {
+		fmt.Fprintf(&b, "<section class=\"%s\">\n", s.Kind)
+		if s.Title != "" {
+			fmt.Fprintf(&b, "<h2>%s</h2>\n", inlineHTML(s.Title))
+		}
+		b.WriteString(blocksHTML(s.Content))
+		b.WriteString("</section>\n")
+	}
+
+	if r.HasIssues() {
+		fmt.Fprintf(&b, "<section class=\"issues\">\n<h2>%s</h2>\n<ul>\n", l.Issues)
+		if len(r.MissingSections) > 0 {
+			fmt.Fprintf(&b, "<li>%s: %s</li>\n", l.Missing, html.EscapeString(strings.Join(l.kindNames(r.MissingSections), ", ")))
+		}
+		if len(r.UnknownCitations) > 0 {
+			fmt.Fprintf(&b, "<li>%s: %s</li>\n", l.UnknownSource, html.EscapeString(strings.Join(r.UnknownCitations, ", ")))
+		}
+		if len(r.UnreferencedClaims) > 0 {
+			fmt.Fprintf(&b, "<li>%s:<ul>\n", l.Unreferenced)
+			for _, c := range r.UnreferencedClaims {
+				fmt.Fprintf(&b, "<li>(%s) %s</li>\n", html.EscapeString(c.Section), html.EscapeString(c.Text))
+			}
+			b.WriteString("</ul></li>\n")
+		}
+		b.WriteString("</ul>\n</section>\n")
+	}
+
+	if len(r.Sources) > 0 {
+		fmt.Fprintf(&b, "<section class=\"sources\">\n<h2>%s</h2>\n<ul>\n", l.Sources)
+		cited := r.Citations()
+		for _, s := range r.Sources {
+			class := ""
+			if !slices.Contains(cited, s.ID) {
+				class = " class=\"uncited\""
+			}
+			fmt.Fprintf(&b, "<li id=\"%s\"%s><strong>[%s]</strong> <code>%s</code>",
+				s.ID, class, s.ID, html.EscapeString(s.Tool))
+			if input := s.InputSummary(); input != "" {
+				fmt.Fprintf(&b, " <code>%s</code>", html.EscapeString(input))
+			}
+			if class != "" {
+				fmt.Fprintf(&b, " (%s)", l.Uncited)
+			}
+			b.WriteString("</li>\n")
+		}
+		b.WriteString("</ul>\n</section>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// blocksHTML 将 Markdown 内容渲染为 HTML
+func blocksHTML(content string) string {
+	var b strings.Builder
+	for _, blk := range parseBlocks(content) {
+		switch blk.kind {
+		case blockParagraph:
+			fmt.Fprintf(&b, "<p>%s</p>\n", strings.ReplaceAll(inlineHTML(blk.text), "\n", "<br>\n"))
+		case blockHeading:
+			level := min(blk.level+1, 6)
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, inlineHTML(blk.text), level)
+		case blockList:
+			tag := "ul"
+			if blk.ordered {
+				tag = "ol"
+			}
+			fmt.Fprintf(&b, "<%s>\n", tag)
+			for _, item := range blk.items {
+				fmt.Fprintf(&b, "<li>%s</li>\n", inlineHTML(item))
+			}
+			fmt.Fprintf(&b, "</%s>\n", tag)
+		case blockTable:
+			b.WriteString("<table>\n<thead><tr>")
+			for _, c := range blk.table.Columns {
+				fmt.Fprintf(&b, "<th>%s</th>", inlineHTML(c))
+			}
+			b.WriteString("</tr></thead>\n<tbody>\n")
+			for _, row := range blk.table.Rows {
+				b.WriteString("<tr>")
+				for _, c := range row {
+					fmt.Fprintf(&b, "<td>%s</td>", inlineHTML(c))
+				}
+				b.WriteString("</tr>\n")
+			}
+			b.WriteString("</tbody>\n</table>\n")
+		case blockCode:
+			fmt.Fprintf(&b, "<pre><code>%s</code></pre>\n", html.EscapeString(blk.text))
+		}
+	}
+	return b.String()
+}
+
+// inlineHTML 转义文本并渲染加粗、行内代码、链接和来源引用
+func inlineHTML(text string) string {
+	text = html.EscapeString(text)
+	text = inlineCodePattern.ReplaceAllString(text, "<code>$1</code>")
+	text = boldPattern.ReplaceAllString(text, "<strong>$1</strong>")
+	text = linkPattern.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	return citationPattern.ReplaceAllStringFunc(text, func(s string) string {
+		ids := citationIDPattern.FindAllString(s, -1)
+		links := make([]string, len(ids))
+		for i, id := range ids {
+			links[i] = fmt.Sprintf(`<a class="cite" href="#%s">%s</a>`, id, id)
+		}
+		return "[" + strings.Join(links, ", ") + "]"
+	})
+}
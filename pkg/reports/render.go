@@ -0,0 +1,212 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Format 报告输出格式
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+	FormatJSON     Format = "json"
+)
+
+// Formats 支持的所有输出格式
+var Formats = []Format{FormatMarkdown, FormatHTML, FormatJSON}
+
+// Ext 格式对应的文件扩展名
+func (f Format) Ext() string {
+	switch f {
+	case FormatMarkdown:
+		return ".md"
+	case FormatHTML:
+		return ".html"
+	default:
+		return "." + string(f)
+	}
+}
+
+// Render 以指定格式渲染报告
+func Render(r *Report, format Format) ([]byte, error) {
+	switch format {
+	case FormatMarkdown:
+		return []byte(Markdown(r)), nil
+	case FormatHTML:
+		return []byte(HTML(r)), nil
+	case FormatJSON:
+		return json.MarshalIndent(r, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+// Save 以所有格式保存报告到 dir 目录，返回保存的文件路径
+func Save(dir string, r *Report) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create reports directory %q error: %w", dir, err)
+	}
+	createdAt := r.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	base := filepath.Join(dir, createdAt.Format("20060102-150405"))
+
+	paths := make([]string, 0, len(Formats))
+	for _, f := range Formats {
+		content, err := Render(r, f)
+		if err != nil {
+			return nil, err
+		}
+		path := base + f.Ext()
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return nil, fmt.Errorf("write report %q error: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// labels 报告渲染时使用的固定文字
+type labels struct {
+	Lang          string
+	Question      string
+	CreatedAt     string
+	Sources       string
+	Uncited       string
+	Issues        string
+	Unreferenced  string
+	UnknownSource string
+	Missing       string
+	Kinds         map[SectionKind]string
+}
+
+var (
+	labelsZH = labels{
+		Lang:          "zh",
+		Question:      "问题",
+		CreatedAt:     "生成时间",
+		Sources:       "来源",
+		Uncited:       "未引用",
+		Issues:        "待核实",
+		Unreferenced:  "以下数值陈述未引用来源",
+		UnknownSource: "引用了不存在的来源",
+		Missing:       "缺少章节",
+		Kinds: map[SectionKind]string{
+			SectionSummary: "摘要", SectionThesis: "投资逻辑", SectionRisks: "风险", SectionData: "数据",
+		},
+	}
+	labelsEN = labels{
+		Lang:          "en",
+		Question:      "Question",
+		CreatedAt:     "Generated at",
+		Sources:       "Sources",
+		Uncited:       "not cited",
+		Issues:        "To Verify",
+		Unreferenced:  "The following numeric claims cite no source",
+		UnknownSource: "Cited sources that do not exist",
+		Missing:       "Missing sections",
+		Kinds: map[SectionKind]string{
+			SectionSummary: "Summary", SectionThesis: "Thesis", SectionRisks: "Risks", SectionData: "Data",
+		},
+	}
+)
+
+// labelsOf 根据报告内容的语言选择固定文字
+func labelsOf(r *Report) labels {
+	text := r.Title
+	for _, s := range r.Sections {
+		text += s.Title + s.Content
+	}
+	for _, c := range text {
+		if unicode.Is(unicode.Han, c) {
+			return labelsZH
+		}
+	}
+	return labelsEN
+}
+
+// kindNames 章节类型的名称
+func (l labels) kindNames(kinds []SectionKind) []string {
+	ret := make([]string, len(kinds))
+	for i, k := range kinds {
+		ret[i] = l.Kinds[k]
+		if ret[i] == "" {
+			ret[i] = string(k)
+		}
+	}
+	return ret
+}
+
+// Markdown 将报告渲染为 Markdown
+func Markdown(r *Report) string {
+	l := labelsOf(r)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title)
+	if r.Question != "" && r.Question != r.Title {
+		fmt.Fprintf(&b, "> %s: %s\n", l.Question, strings.ReplaceAll(r.Question, "\n", " "))
+	}
+	if !r.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "> %s: %s\n", l.CreatedAt, r.CreatedAt.Format(time.DateTime))
+	}
+	if r.Question != "" || !r.CreatedAt.IsZero() {
+		b.WriteString("\n")
+	}
+	for _, s := range r.Sections {
+		if s.Title != "" {
+			fmt.Fprintf(&b, "## %s\n\n", s.Title)
+		}
+		if s.Content != "" {
+			b.WriteString(s.Content)
+			b.WriteString("\n\n")
+		}
+	}
+	b.WriteString(MarkdownAppendix(r))
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// MarkdownAppendix 将报告的来源列表和待核实问题渲染为 Markdown ，附加在模型回答之后
+func MarkdownAppendix(r *Report) string {
+	l := labelsOf(r)
+	var b strings.Builder
+	if r.HasIssues() {
+		fmt.Fprintf(&b, "## %s\n\n", l.Issues)
+		if len(r.MissingSections) > 0 {
+			fmt.Fprintf(&b, "- %s: %s\n", l.Missing, strings.Join(l.kindNames(r.MissingSections), ", "))
+		}
+		if len(r.UnknownCitations) > 0 {
+			fmt.Fprintf(&b, "- %s: %s\n", l.UnknownSource, strings.Join(r.UnknownCitations, ", "))
+		}
+		if len(r.UnreferencedClaims) > 0 {
+			fmt.Fprintf(&b, "- %s:\n", l.Unreferenced)
+			for _, c := range r.UnreferencedClaims {
+				fmt.Fprintf(&b, "  - (%s) %s\n", c.Section, c.Text)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Sources) > 0 {
+		fmt.Fprintf(&b, "## %s\n\n", l.Sources)
+		cited := r.Citations()
+		for _, s := range r.Sources {
+			fmt.Fprintf(&b, "- **[%s]** `%s`", s.ID, s.Tool)
+			if input := s.InputSummary(); input != "" {
+				fmt.Fprintf(&b, " `%s`", strings.ReplaceAll(input, "`", "'"))
+			}
+			if !slices.Contains(cited, s.ID) {
+				fmt.Fprintf(&b, " (%s)", l.Uncited)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
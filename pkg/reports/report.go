@@ -0,0 +1,261 @@
+package reports
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yhlooo/nfa/pkg/verify"
+)
+
+// DirName 报告在数据目录下的存储目录名
+const DirName = "reports"
+
+// SectionKind 章节类型
+type SectionKind string
+
+const (
+	// SectionSummary 摘要
+	SectionSummary SectionKind = "summary"
+	// SectionThesis 投资逻辑
+	SectionThesis SectionKind = "thesis"
+	// SectionRisks 风险
+	SectionRisks SectionKind = "risks"
+	// SectionData 数据
+	SectionData SectionKind = "data"
+	// SectionOther 其它章节
+	SectionOther SectionKind = "other"
+)
+
+// RequiredSections 报告必须包含的章节
+var RequiredSections = []SectionKind{SectionSummary, SectionThesis, SectionRisks}
+
+// sectionTitles 各类章节可用的标题关键词
+var sectionTitles = map[SectionKind][]string{
+	SectionSummary: {"摘要", "概要", "总结", "结论", "summary", "conclusion"},
+	SectionThesis:  {"投资逻辑", "核心逻辑", "论点", "观点", "分析", "thesis", "analysis"},
+	SectionRisks:   {"风险", "risk"},
+	SectionData:    {"数据", "data", "table"},
+}
+
+// sourcesTitles 来源章节的标题关键词，来源章节由报告生成，忽略模型输出的内容
+var sourcesTitles = []string{"来源", "参考", "引用", "source", "reference", "citation"}
+
+// Report 结构化研究报告
+type Report struct {
+	// 标题
+	Title string `json:"title"`
+	// 用户的问题
+	Question string `json:"question,omitempty"`
+	// 生成时间
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+	// 章节，按报告中的顺序排列
+	Sections []Section `json:"sections"`
+	// 数据来源，即报告生成过程中的所有工具调用结果
+	Sources []Source `json:"sources"`
+	// 引用了但不存在的来源 ID
+	UnknownCitations []string `json:"unknownCitations,omitempty"`
+	// 缺少的必要章节
+	MissingSections []SectionKind `json:"missingSections,omitempty"`
+	// 未引用来源的数值陈述
+	UnreferencedClaims []Claim `json:"unreferencedClaims,omitempty"`
+}
+
+// Section 报告章节
+type Section struct {
+	// 章节类型
+	Kind SectionKind `json:"kind"`
+	// 标题，与报告中的一致
+	Title string `json:"title,omitempty"`
+	// Markdown 格式的内容
+	Content string `json:"content,omitempty"`
+	// 列表项，如风险章节的各项风险
+	Items []string `json:"items,omitempty"`
+	// 数据表
+	Tables []Table `json:"tables,omitempty"`
+	// 章节引用的来源 ID
+	Citations []string `json:"citations,omitempty"`
+}
+
+// Table 数据表
+type Table struct {
+	// 表标题，即表格前最近的一行文字
+	Title   string     `json:"title,omitempty"`
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+	// 数据表引用的来源 ID
+	Citations []string `json:"citations,omitempty"`
+}
+
+// Claim 未引用来源的数值陈述
+type Claim struct {
+	// 所在章节标题
+	Section string `json:"section"`
+	// 陈述内容
+	Text string `json:"text"`
+}
+
+// Section 获取指定类型的第一个章节
+func (r *Report) Section(kind SectionKind) (Section, bool) {
+	for _, s := range r.Sections {
+		if s.Kind == kind {
+			return s, true
+		}
+	}
+	return Section{}, false
+}
+
+// Source 获取指定 ID 的来源
+func (r *Report) Source(id string) (Source, bool) {
+	for _, s := range r.Sources {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Source{}, false
+}
+
+// Citations 报告引用的所有来源 ID ，按首次引用的顺序排列
+func (r *Report) Citations() []string {
+	var ret []string
+	for _, s := range r.Sections {
+		for _, id := range s.Citations {
+			if !slices.Contains(ret, id) {
+				ret = append(ret, id)
+			}
+		}
+	}
+	return ret
+}
+
+// HasIssues 报告是否存在需要核实的问题
+func (r *Report) HasIssues() bool {
+	return len(r.UnknownCitations) > 0 || len(r.MissingSections) > 0 || len(r.UnreferencedClaims) > 0
+}
+
+// citationPattern 来源引用，如 [S1] 、 [S1, S3]
+var citationPattern = verify.CitationPattern
+
+// citationIDPattern 来源 ID
+var citationIDPattern = regexp.MustCompile(`S\d+`)
+
+// ParseCitations 解析文本中引用的来源 ID ，按首次引用的顺序排列
+func ParseCitations(text string) []string {
+	var ret []string
+	for _, m := range citationPattern.FindAllStringSubmatch(text, -1) {
+		for _, id := range citationIDPattern.FindAllString(m[1], -1) {
+			if !slices.Contains(ret, id) {
+				ret = append(ret, id)
+			}
+		}
+	}
+	return ret
+}
+
+// Parse 将模型按报告格式输出的 Markdown 回答解析为结构化报告，并检查引用
+//
+// 回答以一级标题为报告标题，二级标题划分章节，按标题关键词识别摘要、投资逻辑、风险、数据章节，
+// 模型输出的来源章节被忽略，来源列表以 sources 为准
+func Parse(answer, question string, sources []Source) *Report {
+	r := &Report{Question: question, Sources: sources}
+	if r.Sources == nil {
+		r.Sources = []Source{}
+	}
+
+	var (
+		cur      *Section
+		preamble []string
+		body     []string
+		skipping bool
+	)
+	flush := func() {
+		if cur != nil {
+			r.Sections = append(r.Sections, buildSection(*cur, body))
+		} else if content := strings.TrimSpace(strings.Join(preamble, "\n")); content != "" {
+			r.Sections = append(r.Sections, buildSection(Section{Kind: SectionOther}, preamble))
+		}
+		cur, body = nil, nil
+	}
+	inCode := false
+	for _, line := range strings.Split(strings.ReplaceAll(answer, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		switch {
+		case !inCode && r.Title == "" && cur == nil && strings.HasPrefix(line, "# "):
+			r.Title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		case !inCode && strings.HasPrefix(line, "## "):
+			flush()
+			title := strings.TrimSpace(strings.TrimPrefix(line, "## "))
+			skipping = matchTitle(title, sourcesTitles)
+			if !skipping {
+				cur = &Section{Kind: sectionKind(title), Title: title}
+			}
+		case skipping:
+		case cur != nil:
+			body = append(body, line)
+		default:
+			preamble = append(preamble, line)
+		}
+	}
+	flush()
+
+	// 标题前的内容在没有摘要章节时作为摘要
+	if len(r.Sections) > 0 && r.Sections[0].Kind == SectionOther && r.Sections[0].Title == "" {
+		if _, ok := r.Section(SectionSummary); !ok {
+			r.Sections[0].Kind = SectionSummary
+		}
+	}
+	if r.Title == "" {
+		r.Title = question
+	}
+	if r.Sections == nil {
+		r.Sections = []Section{}
+	}
+
+	r.check()
+	return r
+}
+
+// buildSection 根据章节内容补全列表项、数据表和引用
+func buildSection(s Section, lines []string) Section {
+	s.Content = strings.TrimSpace(strings.Join(lines, "\n"))
+	s.Citations = ParseCitations(s.Content)
+	for _, b := range parseBlocks(s.Content) {
+		switch b.kind {
+		case blockList:
+			if s.Kind == SectionRisks {
+				s.Items = append(s.Items, b.items...)
+			}
+		case blockTable:
+			s.Tables = append(s.Tables, *b.table)
+		}
+	}
+	// 风险章节没有使用列表时整体作为一项
+	if s.Kind == SectionRisks && len(s.Items) == 0 && s.Content != "" {
+		s.Items = []string{s.Content}
+	}
+	return s
+}
+
+// sectionKind 根据标题识别章节类型
+func sectionKind(title string) SectionKind {
+	for _, kind := range []SectionKind{SectionSummary, SectionRisks, SectionThesis, SectionData} {
+		if matchTitle(title, sectionTitles[kind]) {
+			return kind
+		}
+	}
+	return SectionOther
+}
+
+// matchTitle 判断标题是否包含任一关键词
+func matchTitle(title string, keywords []string) bool {
+	title = strings.ToLower(title)
+	for _, k := range keywords {
+		if strings.Contains(title, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package reports
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAnswer 测试用的报告回答
+const testAnswer = `# 腾讯控股投资分析
+
+## 摘要
+
+腾讯当前股价 385.2 港元 [S1]。估值处于历史低位，维持看好。
+
+## 投资逻辑
+
+2025年第三季度营收同比增长 15% [S2]。游戏业务毛利率约 55%。
+回购力度加大（0700.HK）。
+
+## 风险
+
+- 监管政策变化
+- 海外游戏收入下滑 8%
+- 汇率波动 [S3]
+
+## 数据
+
+关键指标 [S2]
+
+| 指标 | 数值 |
+| --- | ---: |
+| 营收 | 1,800 亿 |
+| 市盈率 | 18x |
+
+其他
+
+| 指标 | 数值 |
+|---|---|
+| 股息率 | 1.2% [S1] |
+| 市净率 | 3.5 |
+
+## 来源
+
+- [S1] 行情
+`
+
+// TestCollectSources 测试收集工具调用结果
+func TestCollectSources(t *testing.T) {
+	messages := []*ai.Message{
+		ai.NewUserTextMessage("分析腾讯"),
+		ai.NewModelMessage(
+			ai.NewToolRequestPart(&ai.ToolRequest{Name: "GetQuote", Ref: "1", Input: map[string]any{"symbol": "0700.HK"}}),
+			ai.NewToolRequestPart(&ai.ToolRequest{Name: "Skill", Ref: "2"}),
+			ai.NewToolRequestPart(&ai.ToolRequest{Name: "GetFinancials", Ref: "3"}),
+			ai.NewToolRequestPart(&ai.ToolRequest{Name: "WebSearch", Ref: "4"}),
+		),
+		ai.NewMessage(ai.RoleTool, nil,
+			ai.NewToolResponsePart(&ai.ToolResponse{Name: "GetQuote", Ref: "1", Output: map[string]any{"price": 385.2}}),
+			ai.NewToolResponsePart(&ai.ToolResponse{Name: "Skill", Ref: "2", Output: "skill"}),
+			ai.NewToolResponsePart(&ai.ToolResponse{Name: "GetFinancials", Ref: "3", Output: map[string]any{"error": "timeout"}}),
+			ai.NewToolResponsePart(&ai.ToolResponse{Name: "WebSearch", Ref: "4", Output: []string{"result"}}),
+		),
+	}
+
+	sources := CollectSources(messages, "Skill")
+	require.Len(t, sources, 2)
+	assert.Equal(t, Source{
+		ID: "S1", Tool: "GetQuote", Ref: "1",
+		Input: map[string]any{"symbol": "0700.HK"}, Output: map[string]any{"price": 385.2},
+	}, sources[0])
+	assert.Equal(t, "S2", sources[1].ID)
+	assert.Equal(t, "WebSearch", sources[1].Tool)
+
+	instruction := Instruction(sources)
+	assert.Contains(t, instruction, `- [S1] GetQuote 输入：{"symbol":"0700.HK"} （ref 1）`)
+	assert.Contains(t, instruction, "- [S2] WebSearch （ref 4）")
+	assert.Contains(t, Instruction(nil), "（无")
+}
+
+// TestParse 测试解析报告
+func TestParse(t *testing.T) {
+	sources := []Source{{ID: "S1", Tool: "GetQuote"}, {ID: "S2", Tool: "GetFinancials"}, {ID: "S4", Tool: "WebSearch"}}
+	r := Parse(testAnswer, "腾讯值得买吗？", sources)
+
+	assert.Equal(t, "腾讯控股投资分析", r.Title)
+	require.Len(t, r.Sections, 4)
+	assert.Equal(t, []SectionKind{SectionSummary, SectionThesis, SectionRisks, SectionData},
+		[]SectionKind{r.Sections[0].Kind, r.Sections[1].Kind, r.Sections[2].Kind, r.Sections[3].Kind})
+	assert.Equal(t, []string{"S1", "S2", "S3"}, r.Citations())
+
+	risks, _ := r.Section(SectionRisks)
+	assert.Equal(t, []string{"监管政策变化", "海外游戏收入下滑 8%", "汇率波动 [S3]"}, risks.Items)
+
+	data, _ := r.Section(SectionData)
+	require.Len(t, data.Tables, 2)
+	assert.Equal(t, Table{
+		Title:     "关键指标 [S2]",
+		Columns:   []string{"指标", "数值"},
+		Rows:      [][]string{{"营收", "1,800 亿"}, {"市盈率", "18x"}},
+		Citations: []string{"S2"},
+	}, data.Tables[0])
+	assert.Equal(t, "其他", data.Tables[1].Title)
+
+	assert.Equal(t, []string{"S3"}, r.UnknownCitations)
+	assert.Empty(t, r.MissingSections)
+	assert.Equal(t, []Claim{
+		{Section: "投资逻辑", Text: "游戏业务毛利率约 55%"},
+		{Section: "风险", Text: "海外游戏收入下滑 8%"},
+		{Section: "数据", Text: "市净率 | 3.5"},
+	}, r.UnreferencedClaims)
+	assert.True(t, r.HasIssues())
+
+	// 没有标题和章节的回答
+	r = Parse("Revenue grew 12% last year.", "How is revenue?", nil)
+	assert.Equal(t, "How is revenue?", r.Title)
+	require.Len(t, r.Sections, 1)
+	assert.Equal(t, SectionSummary, r.Sections[0].Kind)
+	assert.Equal(t, []SectionKind{SectionThesis, SectionRisks}, r.MissingSections)
+	assert.Equal(t, []Claim{{Section: "summary", Text: "Revenue grew 12% last year."}}, r.UnreferencedClaims)
+}
+
+// TestHasNumericClaim 测试识别数值陈述
+func TestHasNumericClaim(t *testing.T) {
+	for text, expected := range map[string]bool{
+		"股价上涨 5.2%":         true,
+		"市盈率 18x":           true,
+		"2025年10月9日公布财报":    false,
+		"截至 2025-10-09":     false,
+		"Q3 业绩超预期":          false,
+		"FY2025 guidance":   false,
+		"腾讯（0700.HK）加大回购":   false,
+		"参见 [S1, S2]":       false,
+		"1. 监管政策变化":         false,
+		"2025年净利润 1,200 亿元": true,
+		"预计明年营收增长 10%":      false,
+	} {
+		assert.Equal(t, expected, hasNumericClaim(text), text)
+	}
+}
+
+// TestRender 测试渲染报告
+func TestRender(t *testing.T) {
+	r := Parse(testAnswer, "腾讯值得买吗？", []Source{
+		{ID: "S1", Tool: "GetQuote", Input: map[string]any{"symbol": "0700.HK"}},
+		{ID: "S2", Tool: "GetFinancials"},
+		{ID: "S4", Tool: "WebSearch"},
+	})
+	r.CreatedAt = time.Date(2026, 10, 19, 15, 4, 5, 0, time.Local)
+
+	md := Markdown(r)
+	assert.True(t, strings.HasPrefix(md, "# 腾讯控股投资分析\n\n> 问题: 腾讯值得买吗？\n> 生成时间: 2026-10-19 15:04:05\n\n## 摘要\n"))
+	assert.Contains(t, md, "## 待核实\n\n- 引用了不存在的来源: S3\n- 以下数值陈述未引用来源:\n  - (投资逻辑) 游戏业务毛利率约 55%\n")
+	assert.Contains(t, md, "## 来源\n\n- **[S1]** `GetQuote` `{\"symbol\":\"0700.HK\"}`\n- **[S2]** `GetFinancials`\n- **[S4]** `WebSearch` (未引用)\n")
+	assert.Equal(t, 1, strings.Count(md, "## 来源"))
+
+	page := HTML(r)
+	assert.Contains(t, page, `<html lang="zh">`)
+	assert.Contains(t, page, `股价 385.2 港元 [<a class="cite" href="#S1">S1</a>]`)
+	assert.Contains(t, page, "<thead><tr><th>指标</th><th>数值</th></tr></thead>")
+	assert.Contains(t, page, `<li id="S4" class="uncited"><strong>[S4]</strong> <code>WebSearch</code> (未引用)</li>`)
+	assert.Contains(t, HTML(&Report{Title: "<script>"}), "<h1>&lt;script&gt;</h1>")
+
+	raw, err := Render(r, FormatJSON)
+	require.NoError(t, err)
+	decoded := Report{}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, r.Title, decoded.Title)
+	assert.Equal(t, r.UnreferencedClaims, decoded.UnreferencedClaims)
+
+	_, err = Render(r, "pdf")
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	paths, err := Save(dir, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "20261019-150405.md"),
+		filepath.Join(dir, "20261019-150405.html"),
+		filepath.Join(dir, "20261019-150405.json"),
+	}, paths)
+	content, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	assert.Equal(t, md, string(content))
+}
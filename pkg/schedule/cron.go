@@ -0,0 +1,223 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros 预定义的 cron 表达式
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// monthNames 月份名
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+// weekdayNames 星期名
+var weekdayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+// cronField cron 表达式的一个字段
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+// cronFields cron 表达式的各字段，依次为分、时、日、月、星期
+var cronFields = [5]cronField{
+	{min: 0, max: 59},
+	{min: 0, max: 23},
+	{min: 1, max: 31},
+	{min: 1, max: 12, names: monthNames},
+	// 0 和 7 都表示星期日
+	{min: 0, max: 7, names: weekdayNames},
+}
+
+// bitset 取值集合
+type bitset uint64
+
+// has 是否包含 v
+func (b bitset) has(v int) bool {
+	return b&(1<<uint(v)) != 0
+}
+
+// Cron 解析后的 cron 表达式
+type Cron struct {
+	expr    string
+	minute  bitset
+	hour    bitset
+	day     bitset
+	month   bitset
+	weekday bitset
+	// 日和星期字段是否为 * ，都不为 * 时满足其中之一即可
+	anyDay, anyWeekday bool
+}
+
+// ParseCron 解析 5 个字段（分 时 日 月 星期）的 cron 表达式
+//
+// 每个字段支持 * 、数值、范围（ 1-5 ）、步长（ */15 、 0-30/10 ）和逗号分隔的列表，
+// 月份和星期支持英文缩写（ JAN 、 MON ），星期 0 和 7 都表示星期日。
+// 也支持 @yearly 、 @monthly 、 @weekly 、 @daily 和 @hourly 。
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	spec := expr
+	if strings.HasPrefix(spec, "@") {
+		macro, ok := cronMacros[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron macro %q", expr)
+		}
+		spec = macro
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields (minute hour day month weekday), got %d", expr, len(parts))
+	}
+	var sets [5]bitset
+	for i, part := range parts {
+		set, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+
+	weekday := sets[4]
+	if weekday.has(7) {
+		weekday |= 1
+	}
+	return &Cron{
+		expr:       expr,
+		minute:     sets[0],
+		hour:       sets[1],
+		day:        sets[2],
+		month:      sets[3],
+		weekday:    weekday,
+		anyDay:     strings.HasPrefix(parts[2], "*"),
+		anyWeekday: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseCronField 解析 cron 表达式的一个字段
+func parseCronField(s string, field cronField) (bitset, error) {
+	var set bitset
+	for _, item := range strings.Split(s, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", item)
+			}
+		}
+
+		var from, to int
+		switch {
+		case rangePart == "*":
+			from, to = field.min, field.max
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if from, err = parseCronValue(lo, field); err != nil {
+				return 0, err
+			}
+			if to, err = parseCronValue(hi, field); err != nil {
+				return 0, err
+			}
+			if from > to {
+				return 0, fmt.Errorf("invalid range %q", item)
+			}
+		default:
+			var err error
+			if from, err = parseCronValue(rangePart, field); err != nil {
+				return 0, err
+			}
+			to = from
+			if hasStep {
+				// 形如 5/10 表示从 5 开始每 10 个
+				to = field.max
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// parseCronValue 解析 cron 表达式字段中的单个值
+func parseCronValue(s string, field cronField) (int, error) {
+	if v, ok := field.names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < field.min || v > field.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, field.min, field.max)
+	}
+	return v, nil
+}
+
+// String 返回原始表达式
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Next 返回 t 之后（不含 t ）在 t 的时区中第一个满足表达式的时刻，五年内没有时返回零值
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !c.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.hour.has(t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !c.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches t 当天是否满足日和星期字段
+func (c *Cron) dayMatches(t time.Time) bool {
+	day := c.day.has(t.Day())
+	weekday := c.weekday.has(int(t.Weekday()))
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
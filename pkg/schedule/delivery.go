@@ -0,0 +1,45 @@
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultFileTemplate 默认的结果文件路径模板
+var DefaultFileTemplate = filepath.Join(RunsDirName, "{job}", "{date}_{time}.md")
+
+// resultFilePath 返回任务结果文件路径， at 为计划执行时间或手动执行的开始时间
+func resultFilePath(dir string, job *Job, at time.Time) string {
+	tmpl := job.Delivery.File
+	if tmpl == "" {
+		tmpl = DefaultFileTemplate
+	}
+	path := strings.NewReplacer(
+		"{job}", job.ID,
+		"{date}", at.Format(time.DateOnly),
+		"{time}", at.Format("150405"),
+	).Replace(tmpl)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path
+}
+
+// formatResult 格式化任务结果，以任务名和执行时间作为标题
+func formatResult(job *Job, at time.Time, output string) string {
+	return fmt.Sprintf("# %s (%s)\n\n%s\n", job.DisplayName(), at.Format("2006-01-02 15:04 MST"), strings.TrimSpace(output))
+}
+
+// writeResult 将任务结果写入文件
+func writeResult(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create directory for %q error: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("write result file %q error: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package schedule
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/yhlooo/nfa/pkg/calendar"
+	"github.com/yhlooo/nfa/pkg/channels"
+)
+
+// CatchUpPolicy 错过执行时间（如进程未运行或休眠）后的补执行策略
+type CatchUpPolicy string
+
+// 补执行策略
+const (
+	// CatchUpSkip 跳过错过的执行
+	CatchUpSkip CatchUpPolicy = "skip"
+	// CatchUpOnce 补执行一次（默认）
+	CatchUpOnce CatchUpPolicy = "once"
+	// CatchUpAll 逐次补执行错过的执行，最多 MaxCatchUpRuns 次
+	CatchUpAll CatchUpPolicy = "all"
+)
+
+const (
+	// DefaultCatchUpWindow 默认补执行窗口，只补执行该时间内错过的执行
+	DefaultCatchUpWindow = 2 * time.Hour
+	// MaxCatchUpRuns CatchUpAll 策略最多补执行的次数
+	MaxCatchUpRuns = 10
+	// maxSkippedDays 查找下次执行时间时最多跳过的非交易日天数
+	maxSkippedDays = 400
+)
+
+// Job 定时任务
+type Job struct {
+	// 任务 ID
+	ID string `json:"id"`
+	// 名称
+	Name string `json:"name,omitempty"`
+	// cron 表达式，见 ParseCron
+	Cron string `json:"cron"`
+	// 时区，如 Asia/Shanghai ，默认为 Market 所在时区，未指定 Market 时为本地时区
+	TimeZone string `json:"timeZone,omitempty"`
+	// 交易日历，如 SSE 、 HKEX 、 NYSE ，指定时只在该市场的交易日执行
+	Market string `json:"market,omitempty"`
+	// 补执行策略，默认 once
+	CatchUp CatchUpPolicy `json:"catchUp,omitempty"`
+	// 补执行窗口，如 30m 、 2h ，默认 2h
+	CatchUpWindow string `json:"catchUpWindow,omitempty"`
+
+	// 提示词
+	Prompt string `json:"prompt,omitempty"`
+	// 技能名，指定时以 /<技能名> <提示词> 执行
+	Skill string `json:"skill,omitempty"`
+
+	// 结果投递目标
+	Delivery Delivery `json:"delivery,omitzero"`
+
+	// 是否暂停
+	Disabled bool `json:"disabled,omitempty"`
+	// 创建时间，创建之前的执行时间不会补执行
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Delivery 任务结果投递目标
+type Delivery struct {
+	// 推送的通道目标，未指定时只写入文件
+	channels.Target
+	// 结果文件路径模板，支持 {job} 、 {date} 、 {time} 占位符，相对路径相对于任务数据目录，
+	// 默认为 runs/{job}/{date}_{time}.md
+	File string `json:"file,omitempty"`
+}
+
+// NewJobID 生成任务 ID
+func NewJobID() string {
+	return fmt.Sprintf("%08x", rand.Uint32())
+}
+
+// Validate 校验任务
+func (job *Job) Validate() error {
+	if job.ID == "" {
+		return fmt.Errorf("job id is required")
+	}
+	if strings.TrimSpace(job.Prompt) == "" && job.Skill == "" {
+		return fmt.Errorf("prompt or skill is required")
+	}
+	if strings.ContainsAny(job.Skill, " \t\n/") {
+		return fmt.Errorf("invalid skill name %q", job.Skill)
+	}
+	if _, err := ParseCron(job.Cron); err != nil {
+		return err
+	}
+	if _, err := job.Location(); err != nil {
+		return err
+	}
+	if _, err := job.Calendar(); err != nil {
+		return err
+	}
+	switch job.CatchUp {
+	case "", CatchUpSkip, CatchUpOnce, CatchUpAll:
+	default:
+		return fmt.Errorf("invalid catch-up policy %q (expected: skip, once or all)", job.CatchUp)
+	}
+	if _, err := job.catchUpWindow(); err != nil {
+		return err
+	}
+	if job.Delivery.Channel == "" && (job.Delivery.UserID != "" || job.Delivery.GroupID != "") {
+		return fmt.Errorf("channel is required when delivering to a user or group")
+	}
+	if job.Delivery.Channel != "" && job.Delivery.UserID == "" && job.Delivery.GroupID == "" {
+		return fmt.Errorf("user or group is required when delivering to channel %q", job.Delivery.Channel)
+	}
+	return nil
+}
+
+// DisplayName 显示名，未设置名称时为 ID
+func (job *Job) DisplayName() string {
+	if job.Name != "" {
+		return job.Name
+	}
+	return job.ID
+}
+
+// PromptText 执行任务时发送给 Agent 的提示词
+func (job *Job) PromptText() string {
+	prompt := strings.TrimSpace(job.Prompt)
+	if job.Skill == "" {
+		return prompt
+	}
+	return strings.TrimSpace("/" + job.Skill + " " + prompt)
+}
+
+// Calendar 任务的交易日历，未指定市场时返回 nil
+func (job *Job) Calendar() (*calendar.Calendar, error) {
+	if job.Market == "" {
+		return nil, nil
+	}
+	return calendar.Get(job.Market)
+}
+
+// Location 任务时区
+func (job *Job) Location() (*time.Location, error) {
+	if job.TimeZone != "" {
+		loc, err := time.LoadLocation(job.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", job.TimeZone, err)
+		}
+		return loc, nil
+	}
+	cal, err := job.Calendar()
+	if err != nil {
+		return nil, err
+	}
+	if cal != nil {
+		return cal.Location, nil
+	}
+	return time.Local, nil
+}
+
+// catchUpWindow 补执行窗口
+func (job *Job) catchUpWindow() (time.Duration, error) {
+	if job.CatchUpWindow == "" {
+		return DefaultCatchUpWindow, nil
+	}
+	d, err := time.ParseDuration(job.CatchUpWindow)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid catch-up window %q", job.CatchUpWindow)
+	}
+	return d, nil
+}
+
+// Next 返回 t 之后（不含 t ）任务的下次执行时间，指定市场时跳过非交易日，没有时返回零值
+func (job *Job) Next(t time.Time) (time.Time, error) {
+	c, err := ParseCron(job.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := job.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	cal, err := job.Calendar()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return nextRun(c, cal, t.In(loc)), nil
+}
+
+// nextRun 返回 t 之后满足 cron 表达式且为 cal 交易日的时刻
+//
+// 触发时刻不是交易日时跳过当天余下的触发时刻，从下一个自然日开始查找
+func nextRun(c *Cron, cal *calendar.Calendar, t time.Time) time.Time {
+	for range maxSkippedDays {
+		t = c.Next(t)
+		if t.IsZero() || cal == nil || cal.IsTradingDay(t) {
+			return t
+		}
+		// Next 返回传入时刻之后的触发时刻，传入下一个自然日零点前一分钟
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+	}
+	return time.Time{}
+}
+
+// dueRuns 返回 (after, now] 期间应执行的时刻，按补执行策略过滤错过的执行，
+// 执行时间在 now 之前 grace 以内的视为按时执行
+func (job *Job) dueRuns(after, now time.Time, grace time.Duration) ([]time.Time, error) {
+	c, err := ParseCron(job.Cron)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := job.Location()
+	if err != nil {
+		return nil, err
+	}
+	cal, err := job.Calendar()
+	if err != nil {
+		return nil, err
+	}
+	window, err := job.catchUpWindow()
+	if err != nil {
+		return nil, err
+	}
+	policy := job.CatchUp
+	if policy == "" {
+		policy = CatchUpOnce
+	}
+	if policy == CatchUpSkip || window < grace {
+		window = grace
+	}
+	if start := now.Add(-window); after.Before(start) {
+		after = start
+	}
+
+	var onTime, missed []time.Time
+	for t := nextRun(c, cal, after.In(loc)); !t.IsZero() && !t.After(now); t = nextRun(c, cal, t) {
+		if now.Sub(t) <= grace {
+			onTime = append(onTime, t)
+		} else {
+			missed = append(missed, t)
+		}
+	}
+
+	switch {
+	case len(onTime) > 0 && policy != CatchUpAll:
+		return onTime[len(onTime)-1:], nil
+	case policy == CatchUpAll:
+		runs := append(missed, onTime...)
+		if len(runs) > MaxCatchUpRuns {
+			runs = runs[len(runs)-MaxCatchUpRuns:]
+		}
+		return runs, nil
+	case policy == CatchUpOnce && len(missed) > 0:
+		return missed[len(missed)-1:], nil
+	default:
+		return nil, nil
+	}
+}
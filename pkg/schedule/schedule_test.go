@@ -0,0 +1,233 @@
+package schedule
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/acputil"
+	"github.com/yhlooo/nfa/pkg/channels"
+)
+
+// shanghai 上海时区
+var shanghai, _ = time.LoadLocation("Asia/Shanghai")
+
+// at 返回上海时区的时刻
+func at(value string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", value, shanghai)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// TestCron 测试解析 cron 表达式和计算下次执行时间
+func TestCron(t *testing.T) {
+	cases := []struct {
+		expr string
+		from string
+		next string
+	}{
+		{expr: "30 8 * * *", from: "2026-10-19 08:29", next: "2026-10-19 08:30"},
+		{expr: "30 8 * * *", from: "2026-10-19 08:30", next: "2026-10-20 08:30"},
+		{expr: "30 8 * * MON-FRI", from: "2026-10-23 09:00", next: "2026-10-26 08:30"},
+		{expr: "*/15 9-10 * * *", from: "2026-10-19 10:50", next: "2026-10-20 09:00"},
+		{expr: "0 0 1,15 * *", from: "2026-10-02 00:00", next: "2026-10-15 00:00"},
+		// 日和星期都指定时满足其中之一即可
+		{expr: "0 0 13 * 5", from: "2026-11-01 00:00", next: "2026-11-06 00:00"},
+		{expr: "0 9 * * 7", from: "2026-10-19 00:00", next: "2026-10-25 09:00"},
+		{expr: "@monthly", from: "2026-10-19 00:00", next: "2026-11-01 00:00"},
+		{expr: "0 0 29 2 *", from: "2026-10-19 00:00", next: "2028-02-29 00:00"},
+	}
+	for _, c := range cases {
+		cron, err := ParseCron(c.expr)
+		require.NoError(t, err, c.expr)
+		assert.Equal(t, at(c.next), cron.Next(at(c.from)), c.expr)
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@every"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+// TestJobNext 测试按交易日历计算下次执行时间
+func TestJobNext(t *testing.T) {
+	job := &Job{ID: "brief", Cron: "30 8 * * *", Market: "SSE", Prompt: "盘前简报"}
+	require.NoError(t, job.Validate())
+
+	// 国庆假期后第一个交易日为 10 月 8 日
+	next, err := job.Next(at("2026-09-30 09:00"))
+	require.NoError(t, err)
+	assert.Equal(t, at("2026-10-08 08:30"), next)
+
+	// 时区默认为市场所在时区
+	job = &Job{ID: "us", Cron: "0 9 * * *", Market: "NYSE", Prompt: "pre-market"}
+	next, err = job.Next(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10-19 09:00 EDT", next.Format("2006-01-02 15:04 MST"))
+
+	// 分钟级任务跳过周末和节假日
+	job = &Job{ID: "tick", Cron: "*/5 * * * *", Market: "SSE", Prompt: "盯盘"}
+	next, err = job.Next(at("2026-09-30 23:58"))
+	require.NoError(t, err)
+	assert.Equal(t, at("2026-10-08 00:00"), next)
+
+	assert.Error(t, (&Job{ID: "x", Cron: "* * * * *"}).Validate())
+	assert.Error(t, (&Job{ID: "x", Cron: "* * * * *", Prompt: "p", Market: "no such market"}).Validate())
+	assert.Error(t, (&Job{ID: "x", Cron: "* * * * *", Prompt: "p", CatchUp: "twice"}).Validate())
+	assert.Error(t, (&Job{ID: "x", Cron: "* * * * *", Prompt: "p", Delivery: Delivery{
+		Target: channels.Target{Channel: "wecomAIBot"},
+	}}).Validate())
+	assert.Equal(t, "/brief 自选股", (&Job{Skill: "brief", Prompt: "自选股"}).PromptText())
+}
+
+// TestDueRuns 测试补执行策略
+func TestDueRuns(t *testing.T) {
+	job := &Job{ID: "brief", Cron: "30 8 * * *", TimeZone: "Asia/Shanghai", Prompt: "盘前简报"}
+
+	// 按时执行
+	runs, err := job.dueRuns(at("2026-10-19 08:29"), at("2026-10-19 08:30"), grace)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{at("2026-10-19 08:30")}, runs)
+
+	// 默认补执行窗口内错过的执行补执行一次
+	runs, err = job.dueRuns(at("2026-10-17 08:00"), at("2026-10-19 09:30"), grace)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{at("2026-10-19 08:30")}, runs)
+
+	// 超出补执行窗口
+	runs, err = job.dueRuns(at("2026-10-17 08:00"), at("2026-10-19 11:00"), grace)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	job.CatchUp = CatchUpSkip
+	runs, err = job.dueRuns(at("2026-10-17 08:00"), at("2026-10-19 09:30"), grace)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	job.CatchUp = CatchUpAll
+	job.CatchUpWindow = "72h"
+	runs, err = job.dueRuns(at("2026-10-17 08:00"), at("2026-10-19 09:30"), grace)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{at("2026-10-17 08:30"), at("2026-10-18 08:30"), at("2026-10-19 08:30")}, runs)
+
+	// 非交易日不执行
+	job.Market = "SSE"
+	runs, err = job.dueRuns(at("2026-10-17 08:00"), at("2026-10-19 09:30"), grace)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{at("2026-10-19 08:30")}, runs)
+}
+
+// fakeAgent 测试用 Agent ，回复固定内容
+type fakeAgent struct {
+	runner  *acputil.SessionRunner
+	prompts []string
+	// 会话是否未保存到文件
+	unsaved bool
+	closed  []acp.SessionId
+}
+
+// NewSession 创建会话
+func (a *fakeAgent) NewSession(_ context.Context, _ acp.NewSessionRequest) (acp.NewSessionResponse, error) {
+	return acp.NewSessionResponse{SessionId: "session-1"}, nil
+}
+
+// Prompt 进行一轮对话
+func (a *fakeAgent) Prompt(_ context.Context, params acp.PromptRequest) (acp.PromptResponse, error) {
+	a.prompts = append(a.prompts, params.Prompt[0].Text.Text)
+	a.runner.HandleUpdate(acp.SessionNotification{
+		SessionId: params.SessionId,
+		Update:    acp.UpdateAgentMessageText("今日市场平稳。"),
+	})
+	return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+}
+
+// CloseSession 关闭会话
+func (a *fakeAgent) CloseSession(_ context.Context, sessionID acp.SessionId) bool {
+	a.closed = append(a.closed, sessionID)
+	return !a.unsaved
+}
+
+// fakePusher 测试用可推送通道
+type fakePusher struct {
+	channels.Channel
+	targets  []channels.Target
+	contents []string
+}
+
+// Push 推送消息
+func (p *fakePusher) Push(_ context.Context, target channels.Target, content string) error {
+	p.targets = append(p.targets, target)
+	p.contents = append(p.contents, content)
+	return nil
+}
+
+// TestRunJob 测试执行任务和投递结果
+func TestRunJob(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	pusher := &fakePusher{}
+	agent := &fakeAgent{}
+	agent.runner = acputil.NewSessionRunner(agent, "")
+	s := NewScheduler(Options{
+		Store:    store,
+		Runner:   agent.runner,
+		Channels: map[string]channels.Channel{"wecomAIBot": pusher},
+	})
+
+	job := Job{
+		ID:     "a1b2c3d4",
+		Name:   "盘前简报",
+		Cron:   "30 8 * * *",
+		Market: "SSE",
+		Skill:  "brief",
+		Prompt: "自选股",
+		Delivery: Delivery{
+			Target: channels.Target{Channel: "wecomAIBot", UserID: "zhangsan"},
+		},
+	}
+	require.NoError(t, store.UpdateJobs(func(list *JobList) error {
+		list.Jobs = append(list.Jobs, job)
+		return nil
+	}))
+
+	run, err := s.RunJob(context.Background(), &job, at("2026-10-19 08:30"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/brief 自选股"}, agent.prompts)
+	assert.Equal(t, "session-1", run.SessionID)
+	assert.Equal(t, filepath.Join(dir, "runs", "a1b2c3d4", "2026-10-19_083000.md"), run.File)
+	content, err := os.ReadFile(run.File)
+	require.NoError(t, err)
+	assert.Equal(t, "# 盘前简报 (2026-10-19 08:30 CST)\n\n今日市场平稳。\n", string(content))
+	assert.Equal(t, []channels.Target{job.Delivery.Target}, pusher.targets)
+	assert.Equal(t, []string{string(content)}, pusher.contents)
+
+	// 会话结束后关闭，不再收集更新
+	assert.Equal(t, []acp.SessionId{"session-1"}, agent.closed)
+	assert.False(t, agent.runner.HandleUpdate(acp.SessionNotification{SessionId: "session-1"}))
+
+	// 会话未保存时不记录会话 ID
+	agent.unsaved = true
+	run, err = s.RunJob(context.Background(), &job, at("2026-10-19 08:30"))
+	require.NoError(t, err)
+	assert.Empty(t, run.SessionID)
+	assert.Len(t, agent.closed, 2)
+
+	state, err := store.State()
+	require.NoError(t, err)
+	assert.Equal(t, run.File, state.Jobs[job.ID].LastRun.File)
+
+	// 通道不支持推送
+	s.channels = map[string]channels.Channel{"wecomAIBot": nil}
+	run, err = s.RunNow(context.Background(), "盘前简报")
+	assert.ErrorIs(t, err, channels.ErrPushNotSupported)
+	assert.NotEmpty(t, run.Error)
+	assert.NotEmpty(t, run.File)
+}
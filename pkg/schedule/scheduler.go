@@ -0,0 +1,256 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/yhlooo/nfa/pkg/acputil"
+	"github.com/yhlooo/nfa/pkg/agents"
+	"github.com/yhlooo/nfa/pkg/channels"
+)
+
+const (
+	// ChannelName 定时任务提示的来源信道名，用于用量统计和预算
+	ChannelName = channels.ScheduleChannelName
+
+	// tickInterval 检查到期任务的间隔
+	tickInterval = 30 * time.Second
+	// grace 执行时间在当前时间之前 grace 以内的视为按时执行，否则按补执行策略处理
+	grace = 2 * tickInterval
+)
+
+// Options 调度器选项
+type Options struct {
+	// 任务存储
+	Store *Store
+	// 在独立会话中执行任务的执行器
+	Runner *acputil.SessionRunner
+	// 通道名到通道，用于推送任务结果
+	Channels map[string]channels.Channel
+}
+
+// NewScheduler 创建调度器
+func NewScheduler(opts Options) *Scheduler {
+	return &Scheduler{
+		store:    opts.Store,
+		runner:   opts.Runner,
+		channels: opts.Channels,
+		owner:    NewJobID() + NewJobID(),
+		now:      time.Now,
+		running:  make(map[string]bool),
+	}
+}
+
+// Scheduler 调度器，按任务定义在独立会话中执行提示词或技能，并投递结果
+type Scheduler struct {
+	store    *Store
+	runner   *acputil.SessionRunner
+	channels map[string]channels.Channel
+	owner    string
+	now      func() time.Time
+
+	lock    sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+// Store 返回任务存储
+func (s *Scheduler) Store() *Store {
+	return s.store
+}
+
+// Run 运行调度器，直到 ctx 结束
+//
+// 同一数据目录下只有持有租约的进程执行定时任务，其它进程定期尝试接管
+func (s *Scheduler) Run(ctx context.Context) {
+	logger := logr.FromContextOrDiscard(ctx).WithName("schedule")
+	ctx = logr.NewContext(ctx, logger)
+	logger.Info("scheduler started", "dir", s.store.Dir())
+
+	defer func() {
+		s.wg.Wait()
+		if err := s.store.Lease().Release(s.owner); err != nil {
+			logger.Error(err, "release lease error")
+		}
+	}()
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		s.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick 检查并启动到期的任务
+func (s *Scheduler) tick(ctx context.Context) {
+	logger := logr.FromContextOrDiscard(ctx)
+	now := s.now()
+
+	ok, err := s.store.Lease().Acquire(s.owner, now)
+	if err != nil {
+		logger.Error(err, "acquire lease error")
+		return
+	}
+	if !ok {
+		logger.V(1).Info("lease held by another process, skip")
+		return
+	}
+
+	list, err := s.store.Jobs()
+	if err != nil {
+		logger.Error(err, "load jobs error")
+		return
+	}
+
+	due := make(map[string][]time.Time)
+	err = s.store.UpdateState(func(state *State) error {
+		for i := range list.Jobs {
+			job := &list.Jobs[i]
+			if s.isRunning(job.ID) {
+				// 执行中的任务暂不推进，结束后按补执行策略处理期间的执行时间
+				continue
+			}
+			jobState := state.Job(job.ID)
+			after := jobState.ScheduledUntil
+			if after.IsZero() {
+				after = job.CreatedAt
+			}
+			if after.IsZero() {
+				after = now
+			}
+			jobState.ScheduledUntil = now
+			if job.Disabled {
+				continue
+			}
+			runs, err := job.dueRuns(after, now, grace)
+			if err != nil {
+				logger.Error(err, "invalid job", "job", job.ID)
+				continue
+			}
+			if len(runs) > 0 {
+				due[job.ID] = runs
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(err, "update schedule state error")
+		return
+	}
+
+	for i := range list.Jobs {
+		job := list.Jobs[i]
+		runs, ok := due[job.ID]
+		if !ok {
+			continue
+		}
+		s.setRunning(job.ID, true)
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.setRunning(job.ID, false)
+			for _, at := range runs {
+				if ctx.Err() != nil {
+					return
+				}
+				logger.Info("run scheduled job", "job", job.ID, "name", job.Name, "scheduledAt", at)
+				if _, err := s.RunJob(ctx, &job, at); err != nil {
+					logger.Error(err, "run scheduled job error", "job", job.ID)
+				}
+			}
+		}()
+	}
+}
+
+// RunNow 立即执行指定 ID 或名称的任务
+func (s *Scheduler) RunNow(ctx context.Context, idOrName string) (*Run, error) {
+	list, err := s.store.Jobs()
+	if err != nil {
+		return nil, err
+	}
+	i := list.Find(idOrName)
+	if i < 0 {
+		return nil, fmt.Errorf("job %q not found", idOrName)
+	}
+	return s.RunJob(ctx, &list.Jobs[i], time.Time{})
+}
+
+// RunJob 在新会话中执行任务并投递结果， scheduledAt 为计划执行时间，手动执行时为零值
+//
+// 执行记录保存到任务状态中，执行或投递失败时返回的 Run 中也包含错误信息
+func (s *Scheduler) RunJob(ctx context.Context, job *Job, scheduledAt time.Time) (*Run, error) {
+	run := &Run{ScheduledAt: scheduledAt, StartedAt: s.now()}
+	output, sessionID, err := s.runner.Run(ctx, job.PromptText(), map[string]any{
+		agents.MetaKeyChannel: ChannelName,
+		agents.MetaKeyUserID:  job.ID,
+	})
+	run.SessionID = string(sessionID)
+	if err == nil {
+		err = s.deliver(ctx, job, run, output)
+	}
+	run.FinishedAt = s.now()
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	if saveErr := s.store.UpdateState(func(state *State) error {
+		state.Job(job.ID).LastRun = run
+		return nil
+	}); saveErr != nil {
+		err = errors.Join(err, saveErr)
+	}
+	return run, err
+}
+
+// deliver 将任务结果写入文件，并推送到通道目标
+func (s *Scheduler) deliver(ctx context.Context, job *Job, run *Run, output string) error {
+	loc, err := job.Location()
+	if err != nil {
+		return err
+	}
+	at := run.ScheduledAt
+	if at.IsZero() {
+		at = run.StartedAt
+	}
+	at = at.In(loc)
+
+	content := formatResult(job, at, output)
+	path := resultFilePath(s.store.Dir(), job, at)
+	if err := writeResult(path, content); err != nil {
+		return err
+	}
+	run.File = path
+
+	if job.Delivery.Channel == "" {
+		return nil
+	}
+	return channels.Push(ctx, s.channels, job.Delivery.Target, content)
+}
+
+// isRunning 任务是否正在执行
+func (s *Scheduler) isRunning(id string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.running[id]
+}
+
+// setRunning 设置任务是否正在执行
+func (s *Scheduler) setRunning(id string, running bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if running {
+		s.running[id] = true
+	} else {
+		delete(s.running, id)
+	}
+}
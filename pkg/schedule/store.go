@@ -0,0 +1,191 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yhlooo/nfa/pkg/lease"
+)
+
+const (
+	// DirName 定时任务数据目录名
+	DirName = "schedules"
+	// JobsFileName 任务定义文件名
+	JobsFileName = "jobs.json"
+	// StateFileName 任务执行状态文件名
+	StateFileName = "state.json"
+	// RunsDirName 默认的执行结果目录名
+	RunsDirName = "runs"
+	// LeaseFileName 调度租约文件名
+	LeaseFileName = "lease.json"
+)
+
+// JobList 任务定义文件内容
+type JobList struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// Find 根据 ID 或名称查找任务，返回下标，不存在时返回 -1
+func (l *JobList) Find(idOrName string) int {
+	for i, job := range l.Jobs {
+		if job.ID == idOrName {
+			return i
+		}
+	}
+	for i, job := range l.Jobs {
+		if job.Name != "" && job.Name == idOrName {
+			return i
+		}
+	}
+	return -1
+}
+
+// State 任务执行状态文件内容
+type State struct {
+	// 任务 ID 到执行状态
+	Jobs map[string]*JobState `json:"jobs"`
+}
+
+// Job 获取任务执行状态，不存在时创建
+func (s *State) Job(id string) *JobState {
+	if s.Jobs == nil {
+		s.Jobs = make(map[string]*JobState)
+	}
+	state, ok := s.Jobs[id]
+	if !ok {
+		state = &JobState{}
+		s.Jobs[id] = state
+	}
+	return state
+}
+
+// JobState 任务执行状态
+type JobState struct {
+	// 已处理到的计划执行时间，之前的执行时间不再执行
+	ScheduledUntil time.Time `json:"scheduledUntil,omitzero"`
+	// 最近一次执行
+	LastRun *Run `json:"lastRun,omitempty"`
+}
+
+// Run 一次执行
+type Run struct {
+	// 计划执行时间，手动执行时为零值
+	ScheduledAt time.Time `json:"scheduledAt,omitzero"`
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
+	// 执行任务的会话 ID ，可以通过 nfa --resume 继续对话，会话未保存时为空
+	SessionID string `json:"sessionID,omitempty"`
+	// 结果文件路径
+	File string `json:"file,omitempty"`
+	// 执行或投递错误
+	Error string `json:"error,omitempty"`
+}
+
+// NewStore 创建定时任务存储
+//
+// 任务定义保存在 dir 目录下的 jobs.json 文件中，由命令行修改；执行状态保存在 state.json 文件中，由调度器修改
+func NewStore(dir string) *Store {
+	return &Store{dir: dir, lease: lease.NewFile(filepath.Join(dir, LeaseFileName))}
+}
+
+// Store 定时任务存储
+type Store struct {
+	lock  sync.Mutex
+	dir   string
+	lease *lease.File
+}
+
+// Dir 数据目录
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Lease 调度租约，同一数据目录下只有持有租约的进程执行定时任务
+func (s *Store) Lease() *lease.File {
+	return s.lease
+}
+
+// Jobs 加载任务定义，文件不存在时返回空列表
+func (s *Store) Jobs() (*JobList, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	list := &JobList{}
+	return list, loadJSON(filepath.Join(s.dir, JobsFileName), list)
+}
+
+// UpdateJobs 加载任务定义，调用 fn 修改后保存， fn 返回错误时不保存
+func (s *Store) UpdateJobs(fn func(list *JobList) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	path := filepath.Join(s.dir, JobsFileName)
+	list := &JobList{}
+	if err := loadJSON(path, list); err != nil {
+		return err
+	}
+	if err := fn(list); err != nil {
+		return err
+	}
+	return saveJSON(path, list)
+}
+
+// State 加载执行状态，文件不存在时返回空状态
+func (s *Store) State() (*State, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	state := &State{}
+	return state, loadJSON(filepath.Join(s.dir, StateFileName), state)
+}
+
+// UpdateState 加载执行状态，调用 fn 修改后保存， fn 返回错误时不保存
+func (s *Store) UpdateState(fn func(state *State) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	path := filepath.Join(s.dir, StateFileName)
+	state := &State{}
+	if err := loadJSON(path, state); err != nil {
+		return err
+	}
+	if err := fn(state); err != nil {
+		return err
+	}
+	return saveJSON(path, state)
+}
+
+// loadJSON 从 JSON 文件加载数据，文件不存在时不修改 v
+func loadJSON(path string, v any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("read file %q error: %w", path, err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("unmarshal file %q error: %w", path, err)
+	}
+	return nil
+}
+
+// saveJSON 保存数据到 JSON 文件，先写入临时文件再重命名，避免写入中断导致文件损坏
+func saveJSON(path string, v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal to json error: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create directory error: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("write file %q error: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename %q to %q error: %w", tmp, path, err)
+	}
+	return nil
+}
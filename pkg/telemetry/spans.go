@@ -0,0 +1,71 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+
+	"github.com/firebase/genkit/go/ai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName 追踪器名
+const TracerName = "github.com/yhlooo/nfa"
+
+// span 属性键
+const (
+	AttrSessionID      = attribute.Key("nfa.session.id")
+	AttrChannel        = attribute.Key("nfa.channel")
+	AttrUserID         = attribute.Key("nfa.user.id")
+	AttrModel          = attribute.Key("nfa.model")
+	AttrPromptSize     = attribute.Key("nfa.prompt.size")
+	AttrStopReason     = attribute.Key("nfa.stop_reason")
+	AttrTurn           = attribute.Key("nfa.chat.turn")
+	AttrTurns          = attribute.Key("nfa.chat.turns")
+	AttrToolName       = attribute.Key("nfa.tool.name")
+	AttrToolInputSize  = attribute.Key("nfa.tool.input_size")
+	AttrToolOutputSize = attribute.Key("nfa.tool.output_size")
+	AttrToolRequests   = attribute.Key("nfa.tool.requests")
+	AttrURL            = attribute.Key("url.full")
+
+	AttrInputTokens     = attribute.Key("gen_ai.usage.input_tokens")
+	AttrOutputTokens    = attribute.Key("gen_ai.usage.output_tokens")
+	AttrCacheReadTokens = attribute.Key("gen_ai.usage.cache_read_tokens")
+	AttrReasoningTokens = attribute.Key("gen_ai.usage.reasoning_tokens")
+)
+
+// Tracer 获取 NFA 的追踪器
+//
+// 未启用追踪时返回的追踪器不记录任何 span
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Start 开始一个 span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束 span ，err 不为空时记录错误，取消不视为错误
+func End(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, context.Canceled) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// UsageAttributes 模型用量属性
+func UsageAttributes(usage *ai.GenerationUsage) []attribute.KeyValue {
+	if usage == nil {
+		return nil
+	}
+	return []attribute.KeyValue{
+		AttrInputTokens.Int(usage.InputTokens),
+		AttrOutputTokens.Int(usage.OutputTokens),
+		AttrCacheReadTokens.Int(usage.CachedContentTokens),
+		AttrReasoningTokens.Int(usage.ThoughtsTokens),
+	}
+}
@@ -0,0 +1,135 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+
+	"github.com/yhlooo/nfa/pkg/version"
+)
+
+const (
+	// ExporterOTLP 通过 OTLP/HTTP 导出
+	ExporterOTLP = "otlp"
+	// ExporterFile 导出到本地 JSON 文件
+	ExporterFile = "file"
+
+	// DefaultFileName 默认追踪文件名
+	DefaultFileName = "traces.jsonl"
+)
+
+// Options 追踪选项
+type Options struct {
+	// 导出方式，可选 otlp, file ，为空表示不启用
+	Exporter string `json:"exporter,omitempty"`
+	// OTLP/HTTP 接收端地址，如 localhost:4318 或 https://otlp.example.com/v1/traces
+	// 为空时使用 OTEL_EXPORTER_OTLP_* 环境变量
+	Endpoint string `json:"endpoint,omitempty"`
+	// 是否使用不加密的 HTTP 连接
+	Insecure bool `json:"insecure,omitempty"`
+	// OTLP 请求头
+	Headers map[string]string `json:"headers,omitempty"`
+	// 本地追踪文件路径，默认为数据目录下的 traces.jsonl
+	File string `json:"file,omitempty"`
+	// 采样比例，取值 (0, 1] ，默认 1
+	SampleRatio float64 `json:"sampleRatio,omitempty"`
+}
+
+// Setup 根据选项初始化全局 TracerProvider ，返回用于刷新并关闭导出器的函数
+//
+// 未启用追踪时不做任何事。需在初始化 genkit 前调用，以便 genkit 的 flow 、模型和工具 span 一并导出
+func Setup(ctx context.Context, opts Options, dataRoot string) (shutdown func(context.Context) error, err error) {
+	shutdown = func(context.Context) error { return nil }
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case "":
+		return shutdown, nil
+	case ExporterOTLP:
+		var exporterOpts []otlptracehttp.Option
+		switch {
+		case strings.Contains(opts.Endpoint, "://"):
+			exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		case opts.Endpoint != "":
+			exporterOpts = append(exporterOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+		}
+		if len(opts.Headers) > 0 {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(opts.Headers))
+		}
+		exporter, err = otlptracehttp.New(ctx, exporterOpts...)
+		if err != nil {
+			return shutdown, fmt.Errorf("create otlp exporter error: %w", err)
+		}
+	case ExporterFile:
+		path := opts.File
+		if path == "" {
+			path = filepath.Join(dataRoot, DefaultFileName)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return shutdown, fmt.Errorf("create directory for %q error: %w", path, err)
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return shutdown, fmt.Errorf("open trace file %q error: %w", path, err)
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return shutdown, fmt.Errorf("create file exporter error: %w", err)
+		}
+		exporter = &fileExporter{SpanExporter: exporter, f: f}
+	default:
+		return shutdown, fmt.Errorf("unknown trace exporter: %q (expected: %s or %s)",
+			opts.Exporter, ExporterOTLP, ExporterFile)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if opts.SampleRatio > 0 && opts.SampleRatio < 1 {
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("nfa"),
+		semconv.ServiceVersion(version.Version),
+	))
+	if err != nil {
+		return shutdown, fmt.Errorf("create resource error: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// fileExporter 导出到文件的导出器，关闭时关闭文件
+type fileExporter struct {
+	sdktrace.SpanExporter
+	f *os.File
+}
+
+// Shutdown 关闭导出器
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if closeErr := e.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
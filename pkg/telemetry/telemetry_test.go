@@ -0,0 +1,60 @@
+package telemetry
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupFileExporter(t *testing.T) {
+	dataRoot := t.TempDir()
+	shutdown, err := Setup(t.Context(), Options{Exporter: ExporterFile}, dataRoot)
+	require.NoError(t, err)
+
+	ctx, parent := Start(t.Context(), "parent", AttrSessionID.String("s1"))
+	_, child := Start(ctx, "child", AttrToolName.String("WebFetch"))
+	End(child, errors.New("boom"))
+	End(parent, nil)
+
+	require.NoError(t, shutdown(t.Context()))
+
+	f, err := os.Open(filepath.Join(dataRoot, DefaultFileName))
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	type span struct {
+		Name        string
+		SpanContext struct{ TraceID string }
+		Parent      struct{ SpanID string }
+		Status      struct{ Code string }
+	}
+	spans := map[string]span{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var s span
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &s))
+		spans[s.Name] = s
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Contains(t, spans, "parent")
+	require.Contains(t, spans, "child")
+	assert.Equal(t, spans["parent"].SpanContext.TraceID, spans["child"].SpanContext.TraceID)
+	assert.Equal(t, "Error", spans["child"].Status.Code)
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(t.Context(), Options{}, t.TempDir())
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(t.Context()))
+
+	_, err = Setup(t.Context(), Options{Exporter: "unknown"}, t.TempDir())
+	assert.Error(t, err)
+}
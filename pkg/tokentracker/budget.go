@@ -0,0 +1,284 @@
+package tokentracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/fx"
+)
+
+// BudgetScope 预算范围
+type BudgetScope string
+
+const (
+	// BudgetScopeSession 单个会话
+	BudgetScopeSession BudgetScope = "session"
+	// BudgetScopeDaily 全局每天
+	BudgetScopeDaily BudgetScope = "daily"
+	// BudgetScopeUser 单个信道用户每天
+	BudgetScopeUser BudgetScope = "user"
+	// BudgetScopeGlobal 全局每月
+	BudgetScopeGlobal BudgetScope = "global"
+)
+
+// DefaultBudgetWarnings 默认预算预警阈值
+var DefaultBudgetWarnings = []float64{0.8}
+
+// Budgets 费用预算，金额单位为报告货币，为 0 表示不限制
+type Budgets struct {
+	// 单个会话预算
+	Session float64 `json:"session,omitempty"`
+	// 全局每天预算
+	Daily float64 `json:"daily,omitempty"`
+	// 单个信道用户每天预算
+	User float64 `json:"user,omitempty"`
+	// 全局每月预算
+	Global float64 `json:"global,omitempty"`
+	// 预警阈值，花费达到预算的该比例时预警，默认 [0.8]
+	Warnings []float64 `json:"warnings,omitempty"`
+}
+
+// IsZero 是否未设置任何预算
+func (b Budgets) IsZero() bool {
+	return b.Session == 0 && b.Daily == 0 && b.User == 0 && b.Global == 0
+}
+
+// limit 获取指定范围的预算
+func (b Budgets) limit(scope BudgetScope) float64 {
+	switch scope {
+	case BudgetScopeSession:
+		return b.Session
+	case BudgetScopeDaily:
+		return b.Daily
+	case BudgetScopeUser:
+		return b.User
+	case BudgetScopeGlobal:
+		return b.Global
+	}
+	return 0
+}
+
+// BudgetStatus 预算使用状态
+type BudgetStatus struct {
+	// 预算范围
+	Scope BudgetScope `json:"scope"`
+	// 预算
+	Limit decimal.Decimal `json:"limit"`
+	// 已花费
+	Spent decimal.Decimal `json:"spent"`
+	// 货币代码
+	Currency string `json:"currency,omitempty"`
+	// 达到的预警阈值
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// ErrBudgetExceeded 超出预算
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetExceededError 超出预算错误
+type BudgetExceededError struct {
+	Status BudgetStatus
+}
+
+// Error 返回错误描述
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf(
+		"%s budget exceeded: spent %s of %s %s",
+		e.Status.Scope, e.Status.Spent.StringFixed(4), e.Status.Limit.StringFixed(2), e.Status.Currency,
+	)
+}
+
+// Is 判断是否为指定错误
+func (e *BudgetExceededError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// NewBudgetGuard 创建预算守卫
+//
+// 各范围的花费统一换算为 currency 计算，无法换算的费用不计入并输出警告日志
+func NewBudgetGuard(budgets Budgets, currency string, converter fx.AmountConverter) *BudgetGuard {
+	if budgets.Warnings == nil {
+		budgets.Warnings = DefaultBudgetWarnings
+	}
+	return &BudgetGuard{
+		budgets:   budgets,
+		currency:  currency,
+		converter: converter,
+		spent:     map[string]decimal.Decimal{},
+		now:       time.Now,
+	}
+}
+
+// BudgetGuard 预算守卫，跨会话统计花费并在调用模型前检查预算
+type BudgetGuard struct {
+	lock sync.Mutex
+
+	budgets   Budgets
+	currency  string
+	converter fx.AmountConverter
+	// 各范围的花费，键由范围和周期组成
+	spent map[string]decimal.Decimal
+	now   func() time.Time
+}
+
+// Currency 获取预算货币代码
+func (g *BudgetGuard) Currency() string {
+	if g == nil {
+		return ""
+	}
+	return g.currency
+}
+
+// Load 从账本记录恢复花费
+func (g *BudgetGuard) Load(ctx context.Context, records []Record) {
+	if g == nil {
+		return
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for _, r := range records {
+		g.addLocked(CallInfo{SessionID: r.SessionID, Channel: r.Channel, UserID: r.UserID}, r.Time,
+			g.normalize(ctx, r.Cost, r.Currency))
+	}
+}
+
+// Normalize 将费用换算为预算货币
+//
+// 无法换算时返回 0 ，即不计入预算，并输出警告日志。按原金额计入会使不同货币的金额直接相加
+func (g *BudgetGuard) Normalize(ctx context.Context, cost decimal.Decimal, currency string) decimal.Decimal {
+	if g == nil {
+		return cost
+	}
+	return g.normalize(ctx, cost, currency)
+}
+
+// normalize 将费用换算为预算货币
+func (g *BudgetGuard) normalize(ctx context.Context, cost decimal.Decimal, currency string) decimal.Decimal {
+	if g.currency == "" || currency == "" || strings.EqualFold(currency, g.currency) {
+		return cost
+	}
+	if g.converter != nil {
+		if converted, ok := g.converter.Convert(cost, currency, g.currency); ok {
+			return converted
+		}
+	}
+	if !cost.IsZero() {
+		logr.FromContextOrDiscard(ctx).Info("no exchange rate to budget currency, cost not counted in budgets",
+			"currency", currency, "budgetCurrency", g.currency, "cost", cost.String())
+	}
+	return decimal.Zero
+}
+
+// Record 记录一次调用的花费，cost 为已换算为预算货币的费用
+func (g *BudgetGuard) Record(info CallInfo, cost decimal.Decimal) {
+	if g == nil {
+		return
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.addLocked(info, g.now(), cost)
+}
+
+// addLocked 累加花费
+func (g *BudgetGuard) addLocked(info CallInfo, t time.Time, cost decimal.Decimal) {
+	for _, scope := range []BudgetScope{BudgetScopeSession, BudgetScopeDaily, BudgetScopeUser, BudgetScopeGlobal} {
+		key, ok := budgetKey(scope, info, t)
+		if !ok {
+			continue
+		}
+		g.spent[key] = g.spent[key].Add(cost)
+	}
+}
+
+// budgetKey 获取指定范围和时间的花费键，不适用时返回 false
+func budgetKey(scope BudgetScope, info CallInfo, t time.Time) (string, bool) {
+	t = t.Local()
+	switch scope {
+	case BudgetScopeSession:
+		if info.SessionID == "" {
+			return "", false
+		}
+		return "session/" + info.SessionID, true
+	case BudgetScopeDaily:
+		return "daily/" + t.Format(time.DateOnly), true
+	case BudgetScopeUser:
+		if info.UserID == "" {
+			return "", false
+		}
+		return "user/" + t.Format(time.DateOnly) + "/" + info.Channel + "/" + info.UserID, true
+	case BudgetScopeGlobal:
+		return "global/" + t.Format("2006-01"), true
+	}
+	return "", false
+}
+
+// statusLocked 获取适用于该调用的各范围预算状态
+func (g *BudgetGuard) statusLocked(info CallInfo) []BudgetStatus {
+	now := g.now()
+	var ret []BudgetStatus
+	for _, scope := range []BudgetScope{BudgetScopeSession, BudgetScopeUser, BudgetScopeDaily, BudgetScopeGlobal} {
+		limit := g.budgets.limit(scope)
+		if limit <= 0 {
+			continue
+		}
+		key, ok := budgetKey(scope, info, now)
+		if !ok {
+			continue
+		}
+		ret = append(ret, BudgetStatus{
+			Scope:    scope,
+			Limit:    decimal.NewFromFloat(limit),
+			Spent:    g.spent[key],
+			Currency: g.currency,
+		})
+	}
+	return ret
+}
+
+// Check 检查再花费 estimated 后是否超出预算，超出时返回 *BudgetExceededError
+func (g *BudgetGuard) Check(info CallInfo, estimated decimal.Decimal) error {
+	if g == nil {
+		return nil
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for _, status := range g.statusLocked(info) {
+		if status.Spent.GreaterThanOrEqual(status.Limit) || status.Spent.Add(estimated).GreaterThan(status.Limit) {
+			return &BudgetExceededError{Status: status}
+		}
+	}
+	return nil
+}
+
+// Warnings 获取达到预警阈值的预算状态
+func (g *BudgetGuard) Warnings(info CallInfo) []BudgetStatus {
+	if g == nil {
+		return nil
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	thresholds := append([]float64(nil), g.budgets.Warnings...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(thresholds)))
+
+	var ret []BudgetStatus
+	for _, status := range g.statusLocked(info) {
+		for _, threshold := range thresholds {
+			if status.Spent.GreaterThanOrEqual(status.Limit.Mul(decimal.NewFromFloat(threshold))) {
+				status.Threshold = threshold
+				ret = append(ret, status)
+				break
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,110 @@
+package tokentracker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/models"
+)
+
+func TestBudgetGuard(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	guard := NewBudgetGuard(Budgets{Session: 5, User: 3, Daily: 10}, "CNY",
+		staticRates("CNY", map[string]float64{"USD": 7}))
+	guard.now = func() time.Time { return now }
+
+	// 从账本恢复，昨天的花费不计入每日预算
+	guard.Load(context.Background(), []Record{
+		{Time: now.AddDate(0, 0, -1), SessionID: "s0", Cost: decimal.NewFromInt(100), Currency: "CNY"},
+		{Time: now, SessionID: "s1", Channel: "wecomAIBot", UserID: "u1", Cost: decimal.NewFromInt(2), Currency: "CNY"},
+	})
+
+	u1 := CallInfo{SessionID: "s1", Channel: "wecomAIBot", UserID: "u1"}
+	u2 := CallInfo{SessionID: "s2", Channel: "wecomAIBot", UserID: "u2"}
+
+	require.NoError(t, guard.Check(u1, decimal.NewFromFloat(0.5)))
+	// 单用户预算 3 ，已花费 2 ，预计 1.5 将超出
+	err := guard.Check(u1, decimal.NewFromFloat(1.5))
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	var budgetErr *BudgetExceededError
+	require.ErrorAs(t, err, &budgetErr)
+	assert.Equal(t, BudgetScopeUser, budgetErr.Status.Scope)
+
+	// 其他用户不受影响
+	require.NoError(t, guard.Check(u2, decimal.NewFromFloat(1.5)))
+
+	// 1 USD 按汇率换算为 7 CNY ，超出会话和单用户预算，达到每日预算的 90%
+	guard.Record(u2, guard.Normalize(context.Background(), decimal.NewFromInt(1), "USD"))
+	warnings := guard.Warnings(u2)
+	require.Len(t, warnings, 3)
+	assert.Equal(t, BudgetScopeSession, warnings[0].Scope)
+	assert.Equal(t, BudgetScopeUser, warnings[1].Scope)
+	assert.Equal(t, BudgetScopeDaily, warnings[2].Scope)
+	assert.Equal(t, "9", warnings[2].Spent.String())
+	assert.Equal(t, 0.8, warnings[2].Threshold)
+
+	// 会话预算已超出
+	err = guard.Check(u2, decimal.Zero)
+	require.ErrorAs(t, err, &budgetErr)
+	assert.Equal(t, BudgetScopeSession, budgetErr.Status.Scope)
+}
+
+func TestBudgetGuardUnconvertible(t *testing.T) {
+	var logs []string
+	ctx := logr.NewContext(context.Background(), funcr.New(func(prefix, args string) {
+		logs = append(logs, args)
+	}, funcr.Options{}))
+	guard := NewBudgetGuard(Budgets{Daily: 10}, "CNY", staticRates("CNY", map[string]float64{"USD": 7}))
+
+	// 无法换算的货币不计入预算，而不是按原金额计入
+	assert.True(t, guard.Normalize(ctx, decimal.NewFromInt(100), "EUR").IsZero())
+	require.Len(t, logs, 1)
+	assert.Contains(t, logs[0], `"currency"="EUR"`)
+
+	guard.Load(ctx, []Record{
+		{Time: time.Now(), SessionID: "s1", Cost: decimal.NewFromInt(100), Currency: "EUR"},
+		{Time: time.Now(), SessionID: "s1", Cost: decimal.NewFromInt(1), Currency: "USD"},
+		{Time: time.Now(), SessionID: "s1", Cost: decimal.NewFromInt(2), Currency: "cny"},
+	})
+	assert.Len(t, logs, 2)
+	require.NoError(t, guard.Check(CallInfo{SessionID: "s1"}, decimal.Zero))
+	warnings := guard.Warnings(CallInfo{SessionID: "s1"})
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "9", warnings[0].Spent.String())
+
+	// 未设置汇率时同样不计入
+	guard = NewBudgetGuard(Budgets{Daily: 10}, "CNY", nil)
+	assert.True(t, guard.Normalize(ctx, decimal.NewFromInt(1), "USD").IsZero())
+	assert.Equal(t, "1", guard.Normalize(ctx, decimal.NewFromInt(1), "CNY").String())
+}
+
+func TestTrackerBudget(t *testing.T) {
+	tracker := NewTracker([]models.ModelConfig{
+		{Name: "deepseek/chat", Prices: models.ModelPrices{Input: 2, Output: 8, Currency: "CNY"}},
+	}, Options{})
+	tracker.SetBudgetGuard(NewBudgetGuard(Budgets{Session: 10}, "CNY", nil))
+
+	calls := 0
+	fn := tracker.ModelMiddleware("deepseek/chat")(func(
+		context.Context, *ai.ModelRequest, ai.ModelStreamCallback,
+	) (*ai.ModelResponse, error) {
+		calls++
+		return &ai.ModelResponse{Usage: &ai.GenerationUsage{InputTokens: 1000000, OutputTokens: 1000000}}, nil
+	})
+	ctx := ContextWithCallInfo(t.Context(), CallInfo{SessionID: "s1"})
+
+	_, err := fn(ctx, &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	// 已花费 10 ，第二次调用被拒绝且不调用模型
+	_, err = fn(ctx, &ai.ModelRequest{}, nil)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,188 @@
+package tokentracker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// LedgerDirName 用量账本目录名
+	LedgerDirName = "usage"
+
+	ledgerFilePrefix = "usage-"
+	ledgerFileSuffix = ".jsonl"
+)
+
+// Record 用量账本记录，对应一次模型调用
+type Record struct {
+	// 调用完成时间
+	Time time.Time `json:"time"`
+	// 会话 ID
+	SessionID string `json:"sessionID,omitempty"`
+	// 信道，如 wecomAIBot 、 yuanbaoBot ，终端对话为空
+	Channel string `json:"channel,omitempty"`
+	// 信道用户 ID
+	UserID string `json:"userID,omitempty"`
+	// 模型名
+	Model string `json:"model"`
+
+	TokenUsage
+
+	// 费用
+	Cost decimal.Decimal `json:"cost"`
+	// 费用货币代码
+	Currency string `json:"currency,omitempty"`
+}
+
+// CallInfo 模型调用的来源信息
+type CallInfo struct {
+	SessionID string
+	Channel   string
+	UserID    string
+}
+
+type callInfoContextKey struct{}
+
+// ContextWithCallInfo 返回包含模型调用来源信息的上下文
+func ContextWithCallInfo(ctx context.Context, info CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoContextKey{}, info)
+}
+
+// CallInfoFromContext 从上下文获取模型调用来源信息
+func CallInfoFromContext(ctx context.Context) CallInfo {
+	info, _ := ctx.Value(callInfoContextKey{}).(CallInfo)
+	return info
+}
+
+// NewLedger 创建用量账本
+//
+// 记录按月份保存在 dir 目录下的 usage-YYYY-MM.jsonl 文件中，每行一条记录
+func NewLedger(dir string) *Ledger {
+	return &Ledger{dir: dir}
+}
+
+// Ledger 用量账本
+type Ledger struct {
+	lock sync.Mutex
+	dir  string
+}
+
+// Append 追加记录
+func (l *Ledger) Append(r Record) error {
+	if l == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshal usage record to json error: %w", err)
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return fmt.Errorf("create usage ledger directory error: %w", err)
+	}
+	path := filepath.Join(l.dir, ledgerFilePrefix+r.Time.Format("2006-01")+ledgerFileSuffix)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open usage ledger %q error: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(append(raw, '\n')); err != nil {
+		return fmt.Errorf("write usage ledger %q error: %w", path, err)
+	}
+	return nil
+}
+
+// Read 读取时间在 [since, until) 范围内的记录，零值表示不限制
+func (l *Ledger) Read(since, until time.Time) ([]Record, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read usage ledger directory error: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, ledgerFilePrefix) || !strings.HasSuffix(name, ledgerFileSuffix) {
+			continue
+		}
+		// 按文件名中的月份跳过范围外的文件
+		month, err := time.ParseInLocation("2006-01",
+			strings.TrimSuffix(strings.TrimPrefix(name, ledgerFilePrefix), ledgerFileSuffix), time.Local)
+		if err == nil {
+			if !until.IsZero() && !month.Before(until) {
+				continue
+			}
+			if !since.IsZero() && !month.AddDate(0, 1, 0).After(since) {
+				continue
+			}
+		}
+		files = append(files, filepath.Join(l.dir, name))
+	}
+	sort.Strings(files)
+
+	var ret []Record
+	for _, path := range files {
+		records, err := readLedgerFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			if !since.IsZero() && r.Time.Before(since) {
+				continue
+			}
+			if !until.IsZero() && !r.Time.Before(until) {
+				continue
+			}
+			ret = append(ret, r)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Time.Before(ret[j].Time) })
+	return ret, nil
+}
+
+// readLedgerFile 读取单个账本文件
+func readLedgerFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open usage ledger %q error: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var ret []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		r := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("unmarshal usage record at %s:%d error: %w", path, line, err)
+		}
+		ret = append(ret, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read usage ledger %q error: %w", path, err)
+	}
+	return ret, nil
+}
@@ -0,0 +1,94 @@
+package tokentracker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/models"
+)
+
+func TestLedger(t *testing.T) {
+	ledger := NewLedger(t.TempDir())
+
+	sep := time.Date(2026, 9, 30, 12, 0, 0, 0, time.Local)
+	oct := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	for _, r := range []Record{
+		{Time: sep, Model: "a", Cost: decimal.NewFromInt(1)},
+		{Time: oct, Model: "b", Cost: decimal.NewFromInt(2)},
+		{Time: oct.Add(time.Hour), Model: "c", Cost: decimal.NewFromInt(3)},
+	} {
+		require.NoError(t, ledger.Append(r))
+	}
+
+	all, err := ledger.Read(time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "a", all[0].Model)
+
+	records, err := ledger.Read(oct, oct.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "b", records[0].Model)
+	assert.Equal(t, "2", records[0].Cost.String())
+}
+
+func TestTrackerLedger(t *testing.T) {
+	ledger := NewLedger(t.TempDir())
+	tracker := NewTracker([]models.ModelConfig{
+		{Name: "deepseek/chat", Prices: models.ModelPrices{Input: 2, Output: 8, Currency: "CNY"}},
+	}, Options{})
+	tracker.SetLedger(ledger)
+
+	ctx := ContextWithCallInfo(t.Context(), CallInfo{SessionID: "s1", Channel: "wecomAIBot", UserID: "u1"})
+	fn := tracker.ModelMiddleware("deepseek/chat")(func(
+		_ context.Context, _ *ai.ModelRequest, _ ai.ModelStreamCallback,
+	) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Usage: &ai.GenerationUsage{InputTokens: 1000000, OutputTokens: 1000000}}, nil
+	})
+	_, err := fn(ctx, &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+
+	records, err := ledger.Read(time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "s1", records[0].SessionID)
+	assert.Equal(t, "wecomAIBot", records[0].Channel)
+	assert.Equal(t, "u1", records[0].UserID)
+	assert.Equal(t, int64(1000000), records[0].InputTokens)
+	assert.Equal(t, "10", records[0].Cost.String())
+	assert.Equal(t, "CNY", records[0].Currency)
+}
+
+func TestAggregate(t *testing.T) {
+	day1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	records := []Record{
+		{Time: day1, Model: "a", UserID: "u1", TokenUsage: TokenUsage{InputTokens: 10}, Cost: decimal.NewFromInt(1), Currency: "USD"},
+		{Time: day2, Model: "a", UserID: "u2", TokenUsage: TokenUsage{InputTokens: 20}, Cost: decimal.NewFromInt(7), Currency: "CNY"},
+		{Time: day2, Model: "b", UserID: "u1", TokenUsage: TokenUsage{InputTokens: 30}, Cost: decimal.NewFromInt(1), Currency: "EUR"},
+	}
+	converter := staticRates("CNY", map[string]float64{"USD": 7})
+
+	rows := Aggregate(records, []GroupBy{GroupByModel}, "CNY", converter)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"a"}, rows[0].Group)
+	assert.Equal(t, 2, rows[0].Calls)
+	assert.Equal(t, int64(30), rows[0].InputTokens)
+	assert.Equal(t, "14", rows[0].Cost.String())
+	assert.Equal(t, "CNY", rows[0].Currency)
+	// 无法换算的费用保留原货币
+	assert.Equal(t, "EUR", rows[1].Currency)
+
+	rows = Aggregate(records, []GroupBy{GroupByDay, GroupByUser}, "", nil)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"2026-10-01", "u1"}, rows[0].Group)
+
+	_, err := ParseGroupBy("model,foo")
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package tokentracker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/fx"
+)
+
+// GroupBy 用量报告分组维度
+type GroupBy string
+
+const (
+	GroupByModel   GroupBy = "model"
+	GroupByChannel GroupBy = "channel"
+	GroupByUser    GroupBy = "user"
+	GroupBySession GroupBy = "session"
+	GroupByDay     GroupBy = "day"
+	GroupByMonth   GroupBy = "month"
+)
+
+// ParseGroupBy 解析逗号分隔的分组维度
+func ParseGroupBy(s string) ([]GroupBy, error) {
+	var ret []GroupBy
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		switch g := GroupBy(item); g {
+		case GroupByModel, GroupByChannel, GroupByUser, GroupBySession, GroupByDay, GroupByMonth:
+			ret = append(ret, g)
+		default:
+			return nil, fmt.Errorf("invalid group by: %q", item)
+		}
+	}
+	return ret, nil
+}
+
+// value 获取记录在该维度上的值
+func (g GroupBy) value(r Record) string {
+	switch g {
+	case GroupByModel:
+		return r.Model
+	case GroupByChannel:
+		return r.Channel
+	case GroupByUser:
+		return r.UserID
+	case GroupBySession:
+		return r.SessionID
+	case GroupByDay:
+		return r.Time.Local().Format("2006-01-02")
+	case GroupByMonth:
+		return r.Time.Local().Format("2006-01")
+	}
+	return ""
+}
+
+// ReportRow 用量报告行
+type ReportRow struct {
+	// 各分组维度的值，与分组维度一一对应
+	Group []string `json:"group,omitempty"`
+	// 调用次数
+	Calls int `json:"calls"`
+
+	TokenUsage
+
+	// 费用
+	Cost decimal.Decimal `json:"cost"`
+	// 费用货币代码
+	Currency string `json:"currency,omitempty"`
+}
+
+// Aggregate 按分组维度汇总用量记录
+//
+// currency 不为空时将费用换算为该货币，无法换算的费用按原货币单独成行
+func Aggregate(records []Record, groupBy []GroupBy, currency string, converter fx.AmountConverter) []ReportRow {
+	rows := map[string]*ReportRow{}
+	var keys []string
+	for _, r := range records {
+		group := make([]string, len(groupBy))
+		for i, g := range groupBy {
+			group[i] = g.value(r)
+		}
+
+		cost, costCurrency := r.Cost, r.Currency
+		if currency != "" && costCurrency != "" && costCurrency != currency && converter != nil {
+			if converted, ok := converter.Convert(cost, costCurrency, currency); ok {
+				cost, costCurrency = converted, currency
+			}
+		}
+
+		key := strings.Join(append(append([]string{}, group...), costCurrency), "\x00")
+		row, ok := rows[key]
+		if !ok {
+			row = &ReportRow{Group: group, Cost: decimal.Zero, Currency: costCurrency}
+			rows[key] = row
+			keys = append(keys, key)
+		}
+		row.Calls++
+		row.TokenUsage.Add(r.TokenUsage)
+		row.Cost = row.Cost.Add(cost)
+	}
+
+	sort.Strings(keys)
+	ret := make([]ReportRow, 0, len(keys))
+	for _, key := range keys {
+		ret = append(ret, *rows[key])
+	}
+	return ret
+}
@@ -3,33 +3,116 @@ package tokentracker
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/firebase/genkit/go/ai"
 	"github.com/go-logr/logr"
 	"github.com/shopspring/decimal"
 
+	"github.com/yhlooo/nfa/pkg/fx"
+	"github.com/yhlooo/nfa/pkg/metrics"
 	"github.com/yhlooo/nfa/pkg/models"
+	"github.com/yhlooo/nfa/pkg/ratelimit"
 )
 
 // NewTracker 创建 Token 跟踪器
-func NewTracker(allModels []models.ModelConfig) *TokenTracker {
+func NewTracker(allModels []models.ModelConfig, opts Options) *TokenTracker {
 	prices := make(map[string]models.ModelPrices)
 	for _, model := range allModels {
 		prices[model.Name] = model.Prices
 	}
 	return &TokenTracker{
-		prices: prices,
+		prices:   prices,
+		currency: opts.Currency,
+		now:      time.Now,
 	}
 }
 
+// Options Token 跟踪器选项
+type Options struct {
+	// 报告货币代码，如 CNY 、 USD
+	//
+	// 设置后摘要中的总费用会换算为该货币
+	Currency string `json:"currency,omitempty"`
+}
+
 // TokenTracker Token 跟踪器
 type TokenTracker struct {
 	lock sync.RWMutex
 
 	totalUsage TokenUsage
 	usages     map[string]*TokenUsage
-	prices     map[string]models.ModelPrices
+	// 按货币统计的费用
+	costs  map[string]decimal.Decimal
+	prices map[string]models.ModelPrices
+
+	currency  string
+	converter fx.AmountConverter
+	ledger    *Ledger
+	budget    *BudgetGuard
+	now       func() time.Time
+}
+
+// SetBudgetGuard 设置预算守卫，设置后调用模型前会检查预算
+func (tracker *TokenTracker) SetBudgetGuard(guard *BudgetGuard) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	tracker.budget = guard
+}
+
+// BudgetWarnings 获取指定调用来源达到预警阈值的预算状态
+func (tracker *TokenTracker) BudgetWarnings(info CallInfo) []BudgetStatus {
+	tracker.lock.RLock()
+	guard := tracker.budget
+	tracker.lock.RUnlock()
+	return guard.Warnings(info)
+}
+
+// checkBudget 调用模型前检查预算
+func (tracker *TokenTracker) checkBudget(ctx context.Context, modelName string, req *ai.ModelRequest) error {
+	tracker.lock.RLock()
+	guard := tracker.budget
+	now := tracker.now()
+	tracker.lock.RUnlock()
+	if guard == nil {
+		return nil
+	}
+
+	// 仅按输入 Token 估算本次调用的最低费用
+	estimated := decimal.Zero
+	if prices, ok := tracker.prices[modelName]; ok {
+		tokens := ratelimit.EstimateTokens(req)
+		rates, err := prices.RatesFor(tokens, now)
+		if err == nil {
+			estimated = guard.Normalize(
+				ctx,
+				rates.Input.Mul(decimal.NewFromInt(tokens)).DivRound(million, 8),
+				prices.Currency,
+			)
+		}
+	}
+
+	return guard.Check(CallInfoFromContext(ctx), estimated)
+}
+
+// SetLedger 设置用量账本，设置后每次模型调用都会追加到账本
+func (tracker *TokenTracker) SetLedger(ledger *Ledger) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	tracker.ledger = ledger
+}
+
+var million = decimal.New(1, 6)
+
+// SetCurrencyConverter 设置货币转换器
+func (tracker *TokenTracker) SetCurrencyConverter(converter fx.AmountConverter) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	tracker.converter = converter
 }
 
 // ModelMiddleware 模型中间件
@@ -40,87 +123,188 @@ func (tracker *TokenTracker) ModelMiddleware(modelName string) ai.ModelMiddlewar
 			req *ai.ModelRequest,
 			stramCallback ai.ModelStreamCallback,
 		) (*ai.ModelResponse, error) {
+			if err := tracker.checkBudget(ctx, modelName, req); err != nil {
+				return nil, err
+			}
+
 			resp, err := modelFn(ctx, req, stramCallback)
 			if resp == nil || resp.Usage == nil {
 				return resp, err
 			}
 
-			tracker.lock.Lock()
-			defer tracker.lock.Unlock()
-
 			if modelName == "" {
 				modelName = "unknown"
 			}
 
 			logger := logr.FromContextOrDiscard(ctx)
 			logger.V(1).Info(fmt.Sprintf(
-				"token usage: %s i:%d o:%d c:%d",
+				"token usage: %s i:%d o:%d c:%d r:%d",
 				modelName, resp.Usage.InputTokens, resp.Usage.OutputTokens, resp.Usage.CachedContentTokens,
+				resp.Usage.ThoughtsTokens,
 			))
 
-			// 统计用量
-			tracker.totalUsage.InputTokens += int64(resp.Usage.InputTokens)
-			tracker.totalUsage.OutputTokens += int64(resp.Usage.OutputTokens)
-			tracker.totalUsage.CacheReadTokens += int64(resp.Usage.CachedContentTokens)
-			if tracker.usages == nil {
-				tracker.usages = make(map[string]*TokenUsage)
+			// 统计用量和花费，货币换算和写账本在锁外进行
+			usage := TokenUsageFromGenerationUsage(resp.Usage)
+			now, cost, currency, costErr := tracker.add(modelName, usage, resp.Usage)
+			if costErr != nil {
+				logger.Error(costErr, "calculate cost error")
 			}
-			if tracker.usages[modelName] == nil {
-				tracker.usages[modelName] = &TokenUsage{}
+			tracker.lock.RLock()
+			guard, ledger := tracker.budget, tracker.ledger
+			tracker.lock.RUnlock()
+
+			// 导出指标
+			metrics.AddModelTokens(modelName, metrics.TokenTypeInput, usage.InputTokens)
+			metrics.AddModelTokens(modelName, metrics.TokenTypeOutput, usage.OutputTokens)
+			metrics.AddModelTokens(modelName, metrics.TokenTypeCacheRead, usage.CacheReadTokens)
+			metrics.AddModelTokens(modelName, metrics.TokenTypeCacheWrite, usage.CacheWriteTokens)
+			metrics.AddModelTokens(modelName, metrics.TokenTypeReasoning, usage.ReasoningTokens)
+			metrics.AddModelCost(modelName, currency, cost.InexactFloat64())
+
+			// 记录花费
+			info := CallInfoFromContext(ctx)
+			guard.Record(info, guard.Normalize(ctx, cost, currency))
+
+			// 记录到账本
+			if ledgerErr := ledger.Append(Record{
+				Time:       now,
+				SessionID:  info.SessionID,
+				Channel:    info.Channel,
+				UserID:     info.UserID,
+				Model:      modelName,
+				TokenUsage: usage,
+				Cost:       cost,
+				Currency:   currency,
+			}); ledgerErr != nil {
+				logger.Error(ledgerErr, "append usage record to ledger error")
 			}
-			tracker.usages[modelName].InputTokens += int64(resp.Usage.InputTokens)
-			tracker.usages[modelName].OutputTokens += int64(resp.Usage.OutputTokens)
-			tracker.usages[modelName].CacheReadTokens += int64(resp.Usage.CachedContentTokens)
 
 			return resp, err
 		}
 	}
 }
 
-var million = decimal.New(1, 6)
+// add 累加用量，按请求计费并累加费用，返回计费时间、费用和货币
+//
+// 不同请求可能适用不同的分档和时段价格。计费失败时费用为零
+func (tracker *TokenTracker) add(
+	modelName string,
+	usage TokenUsage,
+	genUsage *ai.GenerationUsage,
+) (time.Time, decimal.Decimal, string, error) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	tracker.totalUsage.Add(usage)
+	if tracker.usages == nil {
+		tracker.usages = make(map[string]*TokenUsage)
+	}
+	if tracker.usages[modelName] == nil {
+		tracker.usages[modelName] = &TokenUsage{}
+	}
+	tracker.usages[modelName].Add(usage)
+
+	now := tracker.now()
+	prices, ok := tracker.prices[modelName]
+	if !ok {
+		return now, decimal.Zero, "", nil
+	}
+	cost, err := prices.Cost(genUsage, now)
+	if err != nil {
+		return now, decimal.Zero, "", err
+	}
+	if tracker.costs == nil {
+		tracker.costs = make(map[string]decimal.Decimal)
+	}
+	tracker.costs[prices.Currency] = tracker.costs[prices.Currency].Add(cost)
+	return now, cost, prices.Currency, nil
+}
 
 // Summary 获取当前摘要
+//
+// 设置了报告货币时，总费用为换算为报告货币后的费用，无法换算的费用不计入总费用；
+// 未设置报告货币时，仅在所有费用货币相同时给出该货币的总费用，否则只按货币分别统计
 func (tracker *TokenTracker) Summary() Summary {
 	tracker.lock.RLock()
-	defer tracker.lock.RUnlock()
+	ret := Summary{
+		TotalUsage: tracker.totalUsage,
+		TotalCost:  decimal.Zero,
+		Currency:   tracker.currency,
+	}
+	if len(tracker.costs) > 0 {
+		ret.Costs = maps.Clone(tracker.costs)
+	}
+	converter := tracker.converter
+	tracker.lock.RUnlock()
 
-	cost := decimal.Zero
-	for m, usage := range tracker.usages {
-		prices, ok := tracker.prices[m]
-		if !ok {
+	// 在锁外换算货币
+	currencies := slices.Sorted(maps.Keys(ret.Costs))
+	if ret.Currency == "" {
+		if len(currencies) == 1 {
+			ret.Currency = currencies[0]
+			ret.TotalCost = ret.Costs[currencies[0]]
+		}
+		return ret
+	}
+
+	for _, currency := range currencies {
+		cost := ret.Costs[currency]
+		if currency == "" || currency == ret.Currency {
+			ret.TotalCost = ret.TotalCost.Add(cost)
 			continue
 		}
-		cost = cost.Add(
-			decimal.NewFromFloat(prices.Input).
-				Mul(decimal.NewFromInt(usage.InputTokens-usage.CacheReadTokens)).
-				DivRound(million, 6),
-		)
-		cost = cost.Add(
-			decimal.NewFromFloat(prices.Output).
-				Mul(decimal.NewFromInt(usage.OutputTokens)).
-				DivRound(million, 6),
-		)
-		cacheReadPrice := prices.Cached
-		if cacheReadPrice == 0 {
-			cacheReadPrice = prices.Input
+		if converter == nil {
+			ret.Unconverted = append(ret.Unconverted, currency)
+			continue
+		}
+		converted, ok := converter.Convert(cost, currency, ret.Currency)
+		if !ok {
+			ret.Unconverted = append(ret.Unconverted, currency)
+			continue
 		}
-		cost = cost.Add(
-			decimal.NewFromFloat(cacheReadPrice).
-				Mul(decimal.NewFromInt(usage.CacheReadTokens)).
-				DivRound(million, 6),
-		)
+		ret.TotalCost = ret.TotalCost.Add(converted)
 	}
 
-	return Summary{
-		TotalUsage: tracker.totalUsage,
-		TotalCost:  cost,
-	}
+	return ret
 }
 
 // Summary 用量摘要
 type Summary struct {
 	TotalUsage TokenUsage      `json:"totalUsage"`
 	TotalCost  decimal.Decimal `json:"totalCost"`
+	// 总费用的货币代码
+	Currency string `json:"currency,omitempty"`
+	// 按原始货币统计的费用
+	Costs map[string]decimal.Decimal `json:"costs,omitempty"`
+	// 无法换算为报告货币的货币代码
+	Unconverted []string `json:"unconverted,omitempty"`
+}
+
+// CostText 费用的文本表示，保留两位小数
+//
+// 有总费用时为总费用，未设置报告货币且有多种货币时为各货币的费用，
+// 无法换算为报告货币的费用附加在总费用之后，如 "1.20 CNY + 0.50 USD" 。没有费用时返回空字符串
+func (s Summary) CostText() string {
+	var parts []string
+	if s.Currency == "" && len(s.Costs) > 1 {
+		for _, currency := range slices.Sorted(maps.Keys(s.Costs)) {
+			parts = appendCost(parts, s.Costs[currency], currency)
+		}
+	} else {
+		parts = appendCost(parts, s.TotalCost, s.Currency)
+	}
+	for _, currency := range s.Unconverted {
+		parts = appendCost(parts, s.Costs[currency], currency)
+	}
+	return strings.Join(parts, " + ")
+}
+
+// appendCost 费用不为零时追加其文本表示
+func appendCost(parts []string, cost decimal.Decimal, currency string) []string {
+	if cost.IsZero() {
+		return parts
+	}
+	return append(parts, strings.TrimSpace(cost.StringFixed(2)+" "+currency))
 }
 
 // TokenUsage Token 用量
@@ -131,4 +315,31 @@ type TokenUsage struct {
 	OutputTokens int64 `json:"outputTokens,omitempty"`
 	// 输入 Token 中命中缓存的 Token
 	CacheReadTokens int64 `json:"cacheReadTokens,omitempty"`
+	// 输入 Token 中写入缓存的 Token
+	CacheWriteTokens int64 `json:"cacheWriteTokens,omitempty"`
+	// 输出 Token 中推理的 Token
+	ReasoningTokens int64 `json:"reasoningTokens,omitempty"`
+}
+
+// TokenUsageFromGenerationUsage 从模型响应用量转换
+func TokenUsageFromGenerationUsage(usage *ai.GenerationUsage) TokenUsage {
+	if usage == nil {
+		return TokenUsage{}
+	}
+	return TokenUsage{
+		InputTokens:      int64(usage.InputTokens),
+		OutputTokens:     int64(usage.OutputTokens),
+		CacheReadTokens:  int64(usage.CachedContentTokens),
+		CacheWriteTokens: models.CacheWriteTokens(usage),
+		ReasoningTokens:  int64(usage.ThoughtsTokens),
+	}
+}
+
+// Add 累加用量
+func (u *TokenUsage) Add(other TokenUsage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.CacheWriteTokens += other.CacheWriteTokens
+	u.ReasoningTokens += other.ReasoningTokens
 }
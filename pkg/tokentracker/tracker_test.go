@@ -0,0 +1,87 @@
+package tokentracker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/fx"
+	"github.com/yhlooo/nfa/pkg/models"
+)
+
+// generate 通过中间件模拟一次模型调用
+func generate(t *testing.T, tracker *TokenTracker, modelName string, usage *ai.GenerationUsage) {
+	fn := tracker.ModelMiddleware(modelName)(func(
+		context.Context, *ai.ModelRequest, ai.ModelStreamCallback,
+	) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Usage: usage}, nil
+	})
+	_, err := fn(t.Context(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+}
+
+func TestTrackerSummary(t *testing.T) {
+	allModels := []models.ModelConfig{
+		{Name: "deepseek/chat", Prices: models.ModelPrices{Input: 2, Output: 8, Currency: "CNY"}},
+		{Name: "openrouter/gpt", Prices: models.ModelPrices{Input: 1, Output: 4, Currency: "USD"}},
+		{Name: "other/model", Prices: models.ModelPrices{Input: 1, Output: 1, Currency: "EUR"}},
+	}
+	usage := &ai.GenerationUsage{InputTokens: 1000000, OutputTokens: 1000000, ThoughtsTokens: 1000}
+
+	t.Run("single currency", func(t *testing.T) {
+		tracker := NewTracker(allModels, Options{})
+		generate(t, tracker, "deepseek/chat", usage)
+		generate(t, tracker, "deepseek/chat", usage)
+
+		summary := tracker.Summary()
+		assert.Equal(t, "20", summary.TotalCost.String())
+		assert.Equal(t, "CNY", summary.Currency)
+		assert.Equal(t, int64(2000), summary.TotalUsage.ReasoningTokens)
+		assert.Equal(t, "20.00 CNY", summary.CostText())
+	})
+
+	t.Run("multiple currencies", func(t *testing.T) {
+		tracker := NewTracker(allModels, Options{})
+		generate(t, tracker, "deepseek/chat", usage)
+		generate(t, tracker, "openrouter/gpt", usage)
+
+		// 未设置报告货币时不同货币的费用不累加
+		summary := tracker.Summary()
+		assert.True(t, summary.TotalCost.IsZero())
+		assert.Empty(t, summary.Currency)
+		assert.Equal(t, "10.00 CNY + 5.00 USD", summary.CostText())
+	})
+
+	t.Run("reporting currency", func(t *testing.T) {
+		tracker := NewTracker(allModels, Options{Currency: "CNY"})
+		tracker.SetCurrencyConverter(staticRates("CNY", map[string]float64{"USD": 7}))
+		generate(t, tracker, "deepseek/chat", usage)
+		generate(t, tracker, "openrouter/gpt", usage)
+		generate(t, tracker, "other/model", usage)
+
+		summary := tracker.Summary()
+		// 10 CNY + 5 USD * 7 ，EUR 无汇率不计入
+		assert.Equal(t, "45", summary.TotalCost.String())
+		assert.Equal(t, "CNY", summary.Currency)
+		assert.Equal(t, []string{"EUR"}, summary.Unconverted)
+		assert.Equal(t, "5", summary.Costs["USD"].String())
+		assert.Equal(t, "45.00 CNY + 2.00 EUR", summary.CostText())
+	})
+}
+
+// staticRates 返回使用固定汇率的货币转换器
+func staticRates(base string, rates map[string]float64) fx.AmountConverter {
+	return fx.NewConverter(fx.NewStaticSource(fx.StaticOptions{Base: base, Rates: rates})).
+		AsOf(context.Background(), time.Time{})
+}
+
+func decimalFromString(t *testing.T, s string) decimal.Decimal {
+	d, err := decimal.NewFromString(s)
+	require.NoError(t, err)
+	return d
+}
@@ -0,0 +1,434 @@
+package alphavantage
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+const (
+	// RESTBaseURL Alpha Vantage REST API 地址
+	RESTBaseURL = "https://www.alphavantage.co/query"
+	// RESTProviderName REST 行情数据提供商名
+	RESTProviderName = "alphaVantage"
+)
+
+// RESTOptions Alpha Vantage REST API 行情数据提供商选项
+type RESTOptions struct {
+	// API 密钥，为空时使用 dataProviders.alphaVantage.apiKey
+	APIKey string `json:"apiKey,omitempty"`
+	// API 地址，默认 RESTBaseURL
+	BaseURL string `json:"baseURL,omitempty"`
+}
+
+// NewRESTProvider 创建 Alpha Vantage REST API 行情数据提供商
+func NewRESTProvider(opts RESTOptions, client *http.Client) (*RESTProvider, error) {
+	if opts.APIKey == "" {
+		return nil, fmt.Errorf(".apiKey is required")
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = RESTBaseURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RESTProvider{opts: opts, client: client}, nil
+}
+
+// RESTProvider Alpha Vantage REST API 行情数据提供商
+type RESTProvider struct {
+	opts   RESTOptions
+	client *http.Client
+}
+
+var (
+	_ tools.MarketDataProvider = (*RESTProvider)(nil)
+	_ tools.SymbolLister       = (*RESTProvider)(nil)
+)
+
+// Name 提供商名
+func (p *RESTProvider) Name() string {
+	return RESTProviderName
+}
+
+// Quote 查询最新报价
+func (p *RESTProvider) Quote(ctx context.Context, symbol string) (tools.Quote, error) {
+	var resp struct {
+		GlobalQuote map[string]string `json:"Global Quote"`
+	}
+	if err := p.get(ctx, url.Values{"function": {"GLOBAL_QUOTE"}, "symbol": {avSymbol(symbol)}}, &resp); err != nil {
+		return tools.Quote{}, err
+	}
+	q := resp.GlobalQuote
+	if len(q) == 0 || q["05. price"] == "" {
+		return tools.Quote{}, fmt.Errorf("no quote for symbol %q", symbol)
+	}
+	price, err := decimal.NewFromString(q["05. price"])
+	if err != nil {
+		return tools.Quote{}, fmt.Errorf("invalid price %q: %w", q["05. price"], err)
+	}
+	ret := tools.Quote{
+		Symbol:        symbol,
+		Price:         price,
+		Open:          nullDecimal(q["02. open"]),
+		High:          nullDecimal(q["03. high"]),
+		Low:           nullDecimal(q["04. low"]),
+		Volume:        nullDecimal(q["06. volume"]),
+		PrevClose:     nullDecimal(q["08. previous close"]),
+		Change:        nullDecimal(q["09. change"]),
+		ChangePercent: nullDecimal(strings.TrimSuffix(q["10. change percent"], "%")),
+	}
+	ret.Time, _ = time.Parse(time.DateOnly, q["07. latest trading day"])
+	ret.Complete()
+	return ret, nil
+}
+
+// History 查询历史 K 线
+func (p *RESTProvider) History(ctx context.Context, req tools.HistoryRequest) ([]tools.Bar, error) {
+	query := url.Values{"symbol": {avSymbol(req.Symbol)}, "outputsize": {"full"}}
+	switch req.Interval {
+	case "", tools.IntervalDaily:
+		query.Set("function", "TIME_SERIES_DAILY")
+		if req.Adjusted {
+			query.Set("function", "TIME_SERIES_DAILY_ADJUSTED")
+		}
+	case tools.IntervalWeekly:
+		query.Set("function", "TIME_SERIES_WEEKLY")
+		if req.Adjusted {
+			query.Set("function", "TIME_SERIES_WEEKLY_ADJUSTED")
+		}
+	case tools.IntervalMonthly:
+		query.Set("function", "TIME_SERIES_MONTHLY")
+		if req.Adjusted {
+			query.Set("function", "TIME_SERIES_MONTHLY_ADJUSTED")
+		}
+	case tools.Interval1Min, tools.Interval5Min, tools.Interval15Min, tools.Interval30Min, tools.Interval60Min:
+		query.Set("function", "TIME_SERIES_INTRADAY")
+		query.Set("interval", req.Interval)
+		query.Set("adjusted", fmt.Sprint(req.Adjusted))
+	default:
+		return nil, fmt.Errorf("interval %q: %w", req.Interval, tools.ErrNotSupported)
+	}
+
+	var resp map[string]json.RawMessage
+	if err := p.get(ctx, query, &resp); err != nil {
+		return nil, err
+	}
+	for k, raw := range resp {
+		lower := strings.ToLower(k)
+		if !strings.Contains(lower, "time series") {
+			continue
+		}
+		var series map[string]map[string]string
+		if err := json.Unmarshal(raw, &series); err != nil {
+			return nil, fmt.Errorf("unmarshal %q error: %w", k, err)
+		}
+		return seriesToBars(series, req)
+	}
+	return nil, fmt.Errorf("no time series for symbol %q", req.Symbol)
+}
+
+// seriesToBars 将 Alpha Vantage 时间序列转换为 K 线，复权时按复权收盘价等比例调整开高低价
+func seriesToBars(series map[string]map[string]string, req tools.HistoryRequest) ([]tools.Bar, error) {
+	bars := make([]tools.Bar, 0, len(series))
+	for date, fields := range series {
+		t, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			if t, err = time.Parse(time.DateTime, date); err != nil {
+				return nil, fmt.Errorf("invalid time %q", date)
+			}
+		}
+		if (!req.From.IsZero() && t.Before(req.From)) || (!req.To.IsZero() && t.After(req.To)) {
+			continue
+		}
+		bar := tools.Bar{Time: t}
+		for _, f := range []struct {
+			key string
+			dst *decimal.Decimal
+		}{
+			{"1. open", &bar.Open},
+			{"2. high", &bar.High},
+			{"3. low", &bar.Low},
+			{"4. close", &bar.Close},
+		} {
+			if *f.dst, err = decimal.NewFromString(fields[f.key]); err != nil {
+				return nil, fmt.Errorf("invalid %q of %s: %w", f.key, date, err)
+			}
+		}
+		// 复权序列的成交量字段为 6. volume
+		volume := fields["5. volume"]
+		if v, ok := fields["6. volume"]; ok {
+			volume = v
+		}
+		bar.Volume, _ = decimal.NewFromString(volume)
+		if adj, ok := fields["5. adjusted close"]; ok && req.Adjusted && !bar.Close.IsZero() {
+			adjClose, err := decimal.NewFromString(adj)
+			if err != nil {
+				return nil, fmt.Errorf("invalid adjusted close of %s: %w", date, err)
+			}
+			factor := adjClose.Div(bar.Close)
+			bar.Open = bar.Open.Mul(factor).Round(4)
+			bar.High = bar.High.Mul(factor).Round(4)
+			bar.Low = bar.Low.Mul(factor).Round(4)
+			bar.Close = adjClose
+		}
+		bars = append(bars, bar)
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	return bars, nil
+}
+
+// Fundamentals 查询基本面数据
+func (p *RESTProvider) Fundamentals(ctx context.Context, symbol string) (tools.Fundamentals, error) {
+	var resp map[string]string
+	if err := p.get(ctx, url.Values{"function": {"OVERVIEW"}, "symbol": {avSymbol(symbol)}}, &resp); err != nil {
+		return tools.Fundamentals{}, err
+	}
+	if resp["Symbol"] == "" {
+		return tools.Fundamentals{}, fmt.Errorf("no fundamentals for symbol %q", symbol)
+	}
+	percent := func(key string) decimal.NullDecimal {
+		d := nullDecimal(resp[key])
+		if d.Valid {
+			d.Decimal = d.Decimal.Mul(decimal.NewFromInt(100))
+		}
+		return d
+	}
+	ret := tools.Fundamentals{
+		Symbol:            symbol,
+		Name:              resp["Name"],
+		Exchange:          resp["Exchange"],
+		Currency:          resp["Currency"],
+		Sector:            resp["Sector"],
+		Industry:          resp["Industry"],
+		MarketCap:         nullDecimal(resp["MarketCapitalization"]),
+		SharesOutstanding: nullDecimal(resp["SharesOutstanding"]),
+		PE:                nullDecimal(resp["PERatio"]),
+		PB:                nullDecimal(resp["PriceToBookRatio"]),
+		EPS:               nullDecimal(resp["EPS"]),
+		DividendYield:     percent("DividendYield"),
+		Revenue:           nullDecimal(resp["RevenueTTM"]),
+		ROE:               percent("ReturnOnEquityTTM"),
+		FiscalPeriod:      resp["LatestQuarter"],
+		Extra:             map[string]string{},
+	}
+	for _, k := range []string{
+		"ForwardPE", "PEGRatio", "BookValue", "ProfitMargin", "OperatingMarginTTM", "EBITDA",
+		"GrossProfitTTM", "QuarterlyEarningsGrowthYOY", "QuarterlyRevenueGrowthYOY", "Beta",
+		"52WeekHigh", "52WeekLow", "AnalystTargetPrice",
+	} {
+		if v := resp[k]; v != "" && v != "None" && v != "-" {
+			ret.Extra[k] = v
+		}
+	}
+	return ret, nil
+}
+
+// CorporateActions 查询分红和拆合股
+func (p *RESTProvider) CorporateActions(
+	ctx context.Context,
+	symbol string,
+	from, to time.Time,
+) ([]tools.CorporateAction, error) {
+	inRange := func(t time.Time) bool {
+		return (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+	}
+
+	var ret []tools.CorporateAction
+	var dividends struct {
+		Data []map[string]string `json:"data"`
+	}
+	if err := p.get(ctx, url.Values{"function": {"DIVIDENDS"}, "symbol": {avSymbol(symbol)}}, &dividends); err != nil {
+		return nil, err
+	}
+	for _, d := range dividends.Data {
+		t, err := time.Parse(time.DateOnly, d["ex_dividend_date"])
+		if err != nil || !inRange(t) {
+			continue
+		}
+		ret = append(ret, tools.CorporateAction{
+			Type:   tools.ActionDividend,
+			ExDate: t,
+			Amount: nullDecimal(d["amount"]),
+		})
+	}
+
+	var splits struct {
+		Data []map[string]string `json:"data"`
+	}
+	if err := p.get(ctx, url.Values{"function": {"SPLITS"}, "symbol": {avSymbol(symbol)}}, &splits); err != nil {
+		return nil, err
+	}
+	for _, s := range splits.Data {
+		t, err := time.Parse(time.DateOnly, s["effective_date"])
+		if err != nil || !inRange(t) {
+			continue
+		}
+		ret = append(ret, tools.CorporateAction{
+			Type:   tools.ActionSplit,
+			ExDate: t,
+			Ratio:  nullDecimal(s["split_factor"]),
+		})
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].ExDate.Before(ret[j].ExDate) })
+	return ret, nil
+}
+
+// SearchSymbols 搜索证券
+func (p *RESTProvider) SearchSymbols(ctx context.Context, query string) ([]tools.SymbolMatch, error) {
+	var resp struct {
+		BestMatches []map[string]string `json:"bestMatches"`
+	}
+	if err := p.get(ctx, url.Values{"function": {"SYMBOL_SEARCH"}, "keywords": {query}}, &resp); err != nil {
+		return nil, err
+	}
+	ret := make([]tools.SymbolMatch, 0, len(resp.BestMatches))
+	for _, m := range resp.BestMatches {
+		symbol := nfaSymbol(m["1. symbol"])
+		ret = append(ret, tools.SymbolMatch{
+			Symbol:   symbol,
+			Name:     m["2. name"],
+			Type:     strings.ToLower(m["3. type"]),
+			Exchange: m["4. region"],
+			Currency: m["8. currency"],
+			Market:   tools.MarketOfSymbol(symbol),
+		})
+	}
+	return ret, nil
+}
+
+// ListSymbols 列出所有正在交易的美股和 ETF
+func (p *RESTProvider) ListSymbols(ctx context.Context) ([]tools.SymbolMatch, error) {
+	body, err := p.do(ctx, url.Values{"function": {"LISTING_STATUS"}})
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read alpha vantage LISTING_STATUS response error: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	// 表头 symbol,name,exchange,assetType,ipoDate,delistingDate,status
+	col := map[string]int{}
+	for i, h := range rows[0] {
+		col[strings.TrimSpace(h)] = i
+	}
+	field := func(row []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	ret := make([]tools.SymbolMatch, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		symbol := strings.ReplaceAll(field(row, "symbol"), "-", ".")
+		if symbol == "" {
+			continue
+		}
+		ret = append(ret, tools.SymbolMatch{
+			Symbol:   symbol,
+			Name:     field(row, "name"),
+			Exchange: field(row, "exchange"),
+			Type:     strings.ToLower(field(row, "assetType")),
+			Currency: "USD",
+			Market:   tools.MarketUS,
+		})
+	}
+	return ret, nil
+}
+
+// get 发送请求并解析 JSON 响应
+func (p *RESTProvider) get(ctx context.Context, query url.Values, out any) error {
+	body, err := p.do(ctx, query)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unmarshal alpha vantage %s response error: %w", query.Get("function"), err)
+	}
+	return nil
+}
+
+// do 发送请求并返回响应体
+func (p *RESTProvider) do(ctx context.Context, query url.Values) ([]byte, error) {
+	query.Set("apikey", p.opts.APIKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.opts.BaseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request error: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request alpha vantage %s error: %w", query.Get("function"), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response error: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("alpha vantage %s returned status %d: %s", query.Get("function"), resp.StatusCode, body)
+	}
+
+	// 错误和限流提示以 200 状态码返回
+	var msg map[string]any
+	if err := json.Unmarshal(body, &msg); err == nil {
+		for _, k := range []string{"Error Message", "Information", "Note"} {
+			if s, ok := msg[k].(string); ok && len(msg) == 1 {
+				return nil, fmt.Errorf("alpha vantage %s: %s", query.Get("function"), s)
+			}
+		}
+	}
+	return body, nil
+}
+
+// avSymbol 将代码转换为 Alpha Vantage 格式，如 600519.SS 转换为 600519.SHH
+func avSymbol(symbol string) string {
+	i := strings.LastIndex(symbol, ".")
+	if i < 0 {
+		return symbol
+	}
+	switch strings.ToUpper(symbol[i+1:]) {
+	case "SS", "SH":
+		return symbol[:i] + ".SHH"
+	case "SZ":
+		return symbol[:i] + ".SHZ"
+	}
+	return symbol
+}
+
+// nfaSymbol 将 Alpha Vantage 格式的代码转换为通用格式，如 600519.SHH 转换为 600519.SS
+func nfaSymbol(symbol string) string {
+	switch {
+	case strings.HasSuffix(symbol, ".SHH"):
+		return strings.TrimSuffix(symbol, ".SHH") + ".SS"
+	case strings.HasSuffix(symbol, ".SHZ"):
+		return strings.TrimSuffix(symbol, ".SHZ") + ".SZ"
+	}
+	return symbol
+}
+
+// nullDecimal 解析数值，为空或无法解析时无效
+func nullDecimal(s string) decimal.NullDecimal {
+	d, err := decimal.NewFromString(strings.TrimSpace(s))
+	if err != nil {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(d)
+}
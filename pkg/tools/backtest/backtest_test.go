@@ -0,0 +1,166 @@
+package backtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// newBars 根据开盘价和收盘价创建 K 线，最高价和最低价为两者的最大和最小值
+func newBars(prices ...[2]float64) []tools.Bar {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := make([]tools.Bar, len(prices))
+	for i, p := range prices {
+		o, c := decimal.NewFromFloat(p[0]), decimal.NewFromFloat(p[1])
+		bars[i] = tools.Bar{
+			Time:  start.AddDate(0, 0, i),
+			Open:  o,
+			High:  decimal.Max(o, c),
+			Low:   decimal.Min(o, c),
+			Close: c,
+		}
+	}
+	return bars
+}
+
+func TestParseCondition(t *testing.T) {
+	c, err := ParseCondition("SMA:5 crosses_above sma:20")
+	require.NoError(t, err)
+	assert.Equal(t, "sma:5 crosses_above sma:20", c.String())
+
+	c, err = ParseCondition("close > bb_upper:20,2.5")
+	require.NoError(t, err)
+	assert.Equal(t, "close > bb_upper:20,2.5", c.String())
+
+	for _, s := range []string{"rsi:14<30", "rsi:14 ~ 30", "foo:1 > 2", "close:5 > 1", "sma:-1 > 1"} {
+		_, err := ParseCondition(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	bars := newBars([2]float64{1, 1}, [2]float64{2, 2}, [2]float64{3, 3}, [2]float64{2, 2}, [2]float64{1, 1})
+
+	c, err := ParseCondition("close crosses_above 1.5")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true, false, false, false}, c.Eval(bars))
+
+	c, err = ParseCondition("close crosses_below sma:2")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, false, false, true, false}, c.Eval(bars))
+
+	c, err = ParseCondition("close < highest:2")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, false, false, true, true}, c.Eval(bars))
+}
+
+func TestRun(t *testing.T) {
+	bars := newBars(
+		[2]float64{10, 10},
+		[2]float64{10, 9},  // 收盘 < 9.5 ，买入信号
+		[2]float64{10, 12}, // 开盘 10 买入
+		[2]float64{12, 13}, // 收盘 > 12.5 ，卖出信号
+		[2]float64{14, 14}, // 开盘 14 卖出
+		[2]float64{14, 9},  // 买入信号
+		[2]float64{10, 8},  // 开盘 10 买入，跌破止损 9 ；最后一根 K 线的信号不执行
+	)
+
+	result, err := Run(bars, Strategy{
+		Entry:         []string{"close < 9.5"},
+		Exit:          []string{"close > 12.5"},
+		StopLoss:      10,
+		CommissionBps: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Trades, 2)
+
+	// 10000 / (10 * 1.001) = 999.000999
+	first := result.Trades[0]
+	assert.Equal(t, "2024-01-03", tools.FormatBarTime(first.EntryTime))
+	assert.Equal(t, "10", first.EntryPrice.String())
+	assert.Equal(t, "14", first.ExitPrice.String())
+	assert.Equal(t, "999.000999", first.Quantity.String())
+	assert.Equal(t, 3, first.Bars)
+	assert.Equal(t, ExitReasonSignal, first.ExitReason)
+	assert.Equal(t, "3972.03", first.PnL.StringFixed(2))
+
+	second := result.Trades[1]
+	assert.Equal(t, "9", second.ExitPrice.String())
+	assert.Equal(t, ExitReasonStopLoss, second.ExitReason)
+	assert.True(t, second.PnL.IsNegative())
+
+	m := result.Metrics.Round(2)
+	assert.Equal(t, 2, m.Trades)
+	assert.Equal(t, "50", m.WinRate.Decimal.String())
+	assert.Equal(t, "12549.7", m.FinalEquity.String())
+	assert.Equal(t, "25.5", m.TotalReturn.String())
+	assert.Equal(t, "-20", m.BuyAndHoldReturn.String())
+	assert.True(t, m.MaxDrawdown.IsNegative())
+	assert.True(t, m.ProfitFactor.Valid)
+	assert.True(t, m.CAGR.Valid)
+
+	// 收盘成交，数据结束时仍持有的仓位以最后收盘价卖出
+	result, err = Run(bars, Strategy{Entry: []string{"close < 9.5"}, FillAt: FillClose, LotSize: 100})
+	require.NoError(t, err)
+	require.Len(t, result.Trades, 1)
+	assert.Equal(t, "9", result.Trades[0].EntryPrice.String())
+	assert.Equal(t, "1100", result.Trades[0].Quantity.String())
+	assert.Equal(t, ExitReasonEndOfData, result.Trades[0].ExitReason)
+	assert.Equal(t, "8900", result.Metrics.FinalEquity.String())
+
+	_, err = Run(bars, Strategy{})
+	assert.Error(t, err)
+	_, err = Run(bars, Strategy{Entry: []string{"close < 9.5"}, FillAt: "open"})
+	assert.Error(t, err)
+}
+
+func TestSaveRunAndLoadStrategy(t *testing.T) {
+	bars := newBars([2]float64{10, 10}, [2]float64{10, 9}, [2]float64{10, 12})
+	s := Strategy{Name: "dip / buy", Entry: []string{"close < 9.5"}, MaxHoldingBars: 1}.Default()
+
+	runDir, err := SaveRun(t.TempDir(), s, bars)
+	require.NoError(t, err)
+	assert.Contains(t, filepath.Base(runDir), "-dip-buy")
+
+	loaded, err := LoadStrategy(filepath.Join(runDir, StrategyFileName))
+	require.NoError(t, err)
+	assert.Equal(t, s, loaded)
+
+	raw, err := os.ReadFile(filepath.Join(runDir, BarsFileName))
+	require.NoError(t, err)
+	loadedBars, err := tools.ParseBars(string(raw))
+	require.NoError(t, err)
+	assert.Equal(t, tools.FormatBarsCSV(bars), tools.FormatBarsCSV(loadedBars))
+
+	// YAML 格式
+	path := filepath.Join(t.TempDir(), "strategy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("name: rsi\nentry: ['rsi:14 < 30']\nexit: ['rsi:14 > 70']\nstopLoss: 8\n"), 0o644))
+	loaded, err = LoadStrategy(path)
+	require.NoError(t, err)
+	assert.Equal(t, Strategy{Name: "rsi", Entry: []string{"rsi:14 < 30"}, Exit: []string{"rsi:14 > 70"}, StopLoss: 8}, loaded)
+}
+
+func TestDefineToolUsesResultStore(t *testing.T) {
+	bars := newBars([2]float64{10, 10}, [2]float64{10, 9}, [2]float64{10, 12}, [2]float64{12, 13})
+	store := tools.NewResultStore()
+	store.Add("1", "TIME_SERIES_DAILY", tools.FormatBarsCSV(bars))
+	ctx := &ai.ToolContext{Context: tools.ContextWithResultStore(t.Context(), store)}
+
+	loaded, source, err := tools.LoadBars(ctx, tools.BarsRef{}, BacktestToolName)
+	require.NoError(t, err)
+	assert.Equal(t, "tool TIME_SERIES_DAILY (ref 1)", source)
+
+	result, err := Run(loaded, Strategy{Entry: []string{"close < 9.5"}})
+	require.NoError(t, err)
+	out := NewOutput(result, source, 0)
+	assert.Equal(t, "2024-01-01", out.From)
+	assert.Contains(t, out.Trades, "| 1 | 2024-01-03 | 10 | 2024-01-04 | 13 |")
+}
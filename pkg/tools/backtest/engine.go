@@ -0,0 +1,410 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// 卖出原因
+const (
+	ExitReasonSignal     = "signal"
+	ExitReasonStopLoss   = "stopLoss"
+	ExitReasonTakeProfit = "takeProfit"
+	ExitReasonMaxHolding = "maxHolding"
+	ExitReasonEndOfData  = "endOfData"
+)
+
+var (
+	hundred       = decimal.NewFromInt(100)
+	basisPoint    = decimal.NewFromInt(10000)
+	quantityPlace = int32(6)
+)
+
+// Trade 一笔完整的交易（买入到卖出）
+type Trade struct {
+	EntryTime  time.Time       `json:"entryTime"`
+	EntryPrice decimal.Decimal `json:"entryPrice"`
+	ExitTime   time.Time       `json:"exitTime"`
+	ExitPrice  decimal.Decimal `json:"exitPrice"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	// 买入和卖出的佣金合计
+	Fees decimal.Decimal `json:"fees"`
+	// 扣除佣金后的盈亏
+	PnL decimal.Decimal `json:"pnl"`
+	// 扣除佣金后的收益率（%）
+	Return decimal.Decimal `json:"returnPercent"`
+	// 持有的 K 线数
+	Bars int `json:"bars"`
+	// 卖出原因
+	ExitReason string `json:"exitReason"`
+}
+
+// Metrics 回测指标，百分比指标单位为 %
+type Metrics struct {
+	InitialCapital decimal.Decimal `json:"initialCapital"`
+	FinalEquity    decimal.Decimal `json:"finalEquity"`
+	// 总收益率
+	TotalReturn decimal.Decimal `json:"totalReturnPercent"`
+	// 年化收益率，数据跨度不足时无效
+	CAGR decimal.NullDecimal `json:"cagrPercent"`
+	// 年化夏普比率（无风险利率为 0 ），数据不足或无波动时无效
+	Sharpe decimal.NullDecimal `json:"sharpe"`
+	// 权益最大回撤（非正数）
+	MaxDrawdown decimal.Decimal `json:"maxDrawdownPercent"`
+	// 交易次数
+	Trades int `json:"trades"`
+	// 胜率，没有交易时无效
+	WinRate decimal.NullDecimal `json:"winRatePercent"`
+	// 平均每笔收益率，没有交易时无效
+	AvgTradeReturn decimal.NullDecimal `json:"avgTradeReturnPercent"`
+	// 盈利因子（总盈利/总亏损），没有亏损时无效
+	ProfitFactor decimal.NullDecimal `json:"profitFactor"`
+	// 持仓 K 线数占比
+	Exposure decimal.Decimal `json:"exposurePercent"`
+	// 佣金合计
+	Fees decimal.Decimal `json:"fees"`
+	// 同期买入持有的收益率（第一根 K 线收盘买入，最后一根 K 线收盘卖出，不含费用）
+	BuyAndHoldReturn decimal.Decimal `json:"buyAndHoldReturnPercent"`
+}
+
+// Result 回测结果
+type Result struct {
+	// 使用的策略（已填充默认值）
+	Strategy Strategy `json:"strategy"`
+	// K 线数量
+	Bars int `json:"bars"`
+	// 数据起止时间
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// 指标
+	Metrics Metrics `json:"metrics"`
+	// 交易列表
+	Trades []Trade `json:"trades"`
+	// 每根 K 线收盘时的权益
+	Equity []decimal.Decimal `json:"-"`
+}
+
+// Run 在 K 线上回测策略
+//
+// 只做多，同时最多持有一笔仓位。信号在 K 线收盘时计算，按 FillAt 在下一根 K 线开盘或当前 K 线收盘成交；
+// 止损止盈在持仓期间的每根 K 线内检查，触发价被跳空越过时以开盘价成交。数据结束时仍持有的仓位以最后收盘价卖出
+func Run(bars []tools.Bar, s Strategy) (Result, error) {
+	s = s.Default()
+	if err := s.Validate(); err != nil {
+		return Result{}, err
+	}
+	if len(bars) < 2 {
+		return Result{}, fmt.Errorf("at least 2 bars are required, got %d", len(bars))
+	}
+
+	entry, err := evalAll(bars, s.Entry, true)
+	if err != nil {
+		return Result{}, err
+	}
+	exit, err := evalAll(bars, s.Exit, false)
+	if err != nil {
+		return Result{}, err
+	}
+
+	e := &engine{
+		cash:       decimal.NewFromFloat(s.InitialCapital),
+		positionPc: decimal.NewFromFloat(s.PositionSize).Div(hundred),
+		lotSize:    decimal.NewFromFloat(s.LotSize),
+		commission: decimal.NewFromFloat(s.CommissionBps).Div(basisPoint),
+		minFee:     decimal.NewFromFloat(s.MinCommission),
+		slippage:   decimal.NewFromFloat(s.SlippageBps).Div(basisPoint),
+		stopLoss:   decimal.NewFromFloat(s.StopLoss).Div(hundred),
+		takeProfit: decimal.NewFromFloat(s.TakeProfit).Div(hundred),
+	}
+	ret := Result{
+		Strategy: s,
+		Bars:     len(bars),
+		From:     bars[0].Time,
+		To:       bars[len(bars)-1].Time,
+		Equity:   make([]decimal.Decimal, len(bars)),
+	}
+
+	// 待下一根 K 线开盘执行的买入信号和卖出原因
+	pendingEntry, pendingExit := false, ""
+	barsInPosition := 0
+	for i, bar := range bars {
+		// 执行上一根 K 线收盘时产生的信号
+		if pendingExit != "" && e.holding() {
+			e.sell(i, bar, bar.Open, pendingExit)
+		}
+		if pendingEntry && !e.holding() {
+			e.buy(i, bar, bar.Open)
+		}
+		pendingEntry, pendingExit = false, ""
+
+		if e.holding() {
+			e.checkStops(i, bar)
+		}
+
+		if e.holding() {
+			reason := ""
+			switch {
+			case exit[i]:
+				reason = ExitReasonSignal
+			case s.MaxHoldingBars > 0 && i-e.entryIndex+1 >= s.MaxHoldingBars:
+				reason = ExitReasonMaxHolding
+			}
+			if reason != "" {
+				if s.FillAt == FillClose {
+					e.sell(i, bar, bar.Close, reason)
+				} else if i < len(bars)-1 {
+					pendingExit = reason
+				}
+			}
+		} else if entry[i] {
+			if s.FillAt == FillClose {
+				e.buy(i, bar, bar.Close)
+			} else if i < len(bars)-1 {
+				pendingEntry = true
+			}
+		}
+
+		if e.holding() {
+			barsInPosition++
+		}
+		ret.Equity[i] = e.cash.Add(e.quantity.Mul(bar.Close))
+	}
+	if e.holding() {
+		last := len(bars) - 1
+		e.sell(last, bars[last], bars[last].Close, ExitReasonEndOfData)
+		ret.Equity[last] = e.cash
+	}
+
+	ret.Trades = e.trades
+	ret.Metrics = metrics(bars, ret.Equity, e.trades, barsInPosition, decimal.NewFromFloat(s.InitialCapital))
+	return ret, nil
+}
+
+// evalAll 计算一组条件，all 为 true 时要求全部满足，否则任一满足即可
+func evalAll(bars []tools.Bar, conditions []string, all bool) ([]bool, error) {
+	ret := make([]bool, len(bars))
+	for i := range ret {
+		ret[i] = all && len(conditions) > 0
+	}
+	for _, s := range conditions {
+		c, err := ParseCondition(s)
+		if err != nil {
+			return nil, err
+		}
+		for i, ok := range c.Eval(bars) {
+			if all {
+				ret[i] = ret[i] && ok
+			} else {
+				ret[i] = ret[i] || ok
+			}
+		}
+	}
+	return ret, nil
+}
+
+// engine 回测状态
+type engine struct {
+	positionPc decimal.Decimal
+	lotSize    decimal.Decimal
+	commission decimal.Decimal
+	minFee     decimal.Decimal
+	slippage   decimal.Decimal
+	stopLoss   decimal.Decimal
+	takeProfit decimal.Decimal
+
+	cash       decimal.Decimal
+	quantity   decimal.Decimal
+	entryIndex int
+	trade      Trade
+	trades     []Trade
+}
+
+// holding 是否持仓
+func (e *engine) holding() bool {
+	return e.quantity.IsPositive()
+}
+
+// fee 计算成交额对应的佣金
+func (e *engine) fee(notional decimal.Decimal) decimal.Decimal {
+	if e.commission.IsZero() && e.minFee.IsZero() {
+		return decimal.Zero
+	}
+	return decimal.Max(notional.Mul(e.commission), e.minFee)
+}
+
+// buy 以 price （滑点前）买入
+func (e *engine) buy(i int, bar tools.Bar, price decimal.Decimal) {
+	price = price.Mul(decimal.NewFromInt(1).Add(e.slippage))
+	if !price.IsPositive() {
+		return
+	}
+	budget := e.cash.Mul(e.positionPc)
+	// 预留佣金
+	qty := budget.Div(price.Mul(decimal.NewFromInt(1).Add(e.commission)))
+	if fee := e.fee(qty.Mul(price)); fee.Equal(e.minFee) {
+		qty = budget.Sub(fee).Div(price)
+	}
+	if e.lotSize.IsPositive() {
+		qty = qty.Div(e.lotSize).Floor().Mul(e.lotSize)
+	} else {
+		qty = qty.Truncate(quantityPlace)
+	}
+	if !qty.IsPositive() {
+		return
+	}
+	fee := e.fee(qty.Mul(price))
+	e.cash = e.cash.Sub(qty.Mul(price)).Sub(fee)
+	e.quantity = qty
+	e.entryIndex = i
+	e.trade = Trade{
+		EntryTime:  bar.Time,
+		EntryPrice: price,
+		Quantity:   qty,
+		Fees:       fee,
+	}
+}
+
+// sell 以 price （滑点前）卖出全部持仓
+func (e *engine) sell(i int, bar tools.Bar, price decimal.Decimal, reason string) {
+	price = price.Mul(decimal.NewFromInt(1).Sub(e.slippage))
+	notional := e.quantity.Mul(price)
+	fee := e.fee(notional)
+	e.cash = e.cash.Add(notional).Sub(fee)
+
+	t := e.trade
+	t.ExitTime = bar.Time
+	t.ExitPrice = price
+	t.Fees = t.Fees.Add(fee)
+	cost := t.Quantity.Mul(t.EntryPrice)
+	t.PnL = notional.Sub(cost).Sub(t.Fees)
+	if cost.IsPositive() {
+		t.Return = t.PnL.Div(cost).Mul(hundred)
+	}
+	t.Bars = i - e.entryIndex + 1
+	t.ExitReason = reason
+	e.trades = append(e.trades, t)
+	e.quantity = decimal.Zero
+}
+
+// checkStops 检查 K 线内是否触发止损或止盈，同时触发时按止损处理
+func (e *engine) checkStops(i int, bar tools.Bar) {
+	entry := e.trade.EntryPrice
+	if e.stopLoss.IsPositive() {
+		stop := entry.Mul(decimal.NewFromInt(1).Sub(e.stopLoss))
+		if bar.Low.LessThanOrEqual(stop) {
+			e.sell(i, bar, decimal.Min(bar.Open, stop), ExitReasonStopLoss)
+			return
+		}
+	}
+	if e.takeProfit.IsPositive() {
+		target := entry.Mul(decimal.NewFromInt(1).Add(e.takeProfit))
+		if bar.High.GreaterThanOrEqual(target) {
+			e.sell(i, bar, decimal.Max(bar.Open, target), ExitReasonTakeProfit)
+		}
+	}
+}
+
+// metrics 计算回测指标
+func metrics(
+	bars []tools.Bar,
+	equity []decimal.Decimal,
+	trades []Trade,
+	barsInPosition int,
+	initial decimal.Decimal,
+) Metrics {
+	final := equity[len(equity)-1]
+	m := Metrics{
+		InitialCapital: initial,
+		FinalEquity:    final,
+		TotalReturn:    final.Sub(initial).Div(initial).Mul(hundred),
+		Trades:         len(trades),
+		Exposure:       decimal.NewFromInt(int64(barsInPosition)).Div(decimal.NewFromInt(int64(len(bars)))).Mul(hundred),
+	}
+	if first := bars[0].Close; first.IsPositive() {
+		m.BuyAndHoldReturn = bars[len(bars)-1].Close.Sub(first).Div(first).Mul(hundred)
+	}
+
+	// 最大回撤，起点为初始资金
+	peak := initial
+	for _, v := range equity {
+		peak = decimal.Max(peak, v)
+		if peak.IsPositive() {
+			m.MaxDrawdown = decimal.Min(m.MaxDrawdown, v.Sub(peak).Div(peak).Mul(hundred))
+		}
+	}
+
+	// 年化指标使用浮点数计算
+	years := bars[len(bars)-1].Time.Sub(bars[0].Time).Hours() / 24 / 365.25
+	if years > 0 && final.IsPositive() {
+		growth := final.Div(initial).InexactFloat64()
+		m.CAGR = nullDecimal((math.Pow(growth, 1/years) - 1) * 100)
+	}
+	if years > 0 && len(equity) > 2 {
+		returns := make([]float64, 0, len(equity))
+		prev := initial
+		for _, v := range equity {
+			if prev.IsPositive() {
+				returns = append(returns, v.Div(prev).InexactFloat64()-1)
+			}
+			prev = v
+		}
+		mean, std := meanStd(returns)
+		if std > 0 {
+			periodsPerYear := float64(len(equity)-1) / years
+			m.Sharpe = nullDecimal(mean / std * math.Sqrt(periodsPerYear))
+		}
+	}
+
+	grossProfit, grossLoss := decimal.Zero, decimal.Zero
+	wins := 0
+	sumReturn := decimal.Zero
+	for _, t := range trades {
+		m.Fees = m.Fees.Add(t.Fees)
+		sumReturn = sumReturn.Add(t.Return)
+		if t.PnL.IsPositive() {
+			wins++
+			grossProfit = grossProfit.Add(t.PnL)
+		} else {
+			grossLoss = grossLoss.Add(t.PnL.Neg())
+		}
+	}
+	if len(trades) > 0 {
+		n := decimal.NewFromInt(int64(len(trades)))
+		m.WinRate = decimal.NewNullDecimal(decimal.NewFromInt(int64(wins)).Div(n).Mul(hundred))
+		m.AvgTradeReturn = decimal.NewNullDecimal(sumReturn.Div(n))
+	}
+	if grossLoss.IsPositive() {
+		m.ProfitFactor = decimal.NewNullDecimal(grossProfit.Div(grossLoss))
+	}
+	return m
+}
+
+// meanStd 计算均值和样本标准差
+func meanStd(values []float64) (float64, float64) {
+	if len(values) < 2 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	sq := 0.0
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)-1))
+}
+
+// nullDecimal 将浮点数转为 NullDecimal ， NaN 和无穷大无效
+func nullDecimal(f float64) decimal.NullDecimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(decimal.NewFromFloat(f))
+}
@@ -0,0 +1,387 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/indicators"
+)
+
+// 成交方式
+const (
+	// FillNextOpen 信号在 K 线收盘时产生，下一根 K 线开盘时成交
+	FillNextOpen = "nextOpen"
+	// FillClose 信号产生的 K 线收盘时成交
+	FillClose = "close"
+)
+
+const (
+	// DefaultInitialCapital 默认初始资金
+	DefaultInitialCapital = 10000
+	// DefaultPositionSize 默认仓位（占权益的百分比）
+	DefaultPositionSize = 100
+)
+
+// Strategy 基于规则的交易策略
+//
+// 只做多。空仓且 entry 中所有条件都满足时买入，持仓且 exit 中任一条件满足、触发止损止盈或达到最长持有期时卖出
+type Strategy struct {
+	// 策略名
+	Name string `json:"name,omitempty"`
+	// 买入条件，全部满足时买入
+	Entry []string `json:"entry"`
+	// 卖出条件，任一满足时卖出
+	Exit []string `json:"exit,omitempty"`
+	// 止损（相对买入价的跌幅百分比）
+	StopLoss float64 `json:"stopLoss,omitempty"`
+	// 止盈（相对买入价的涨幅百分比）
+	TakeProfit float64 `json:"takeProfit,omitempty"`
+	// 最长持有 K 线数
+	MaxHoldingBars int `json:"maxHoldingBars,omitempty"`
+
+	// 初始资金，默认 10000
+	InitialCapital float64 `json:"initialCapital,omitempty"`
+	// 每次买入使用的资金占权益的百分比，默认 100
+	PositionSize float64 `json:"positionSize,omitempty"`
+	// 每手数量，买入数量向下取整为其整数倍，为 0 时允许零碎数量
+	LotSize float64 `json:"lotSize,omitempty"`
+	// 成交方式， nextOpen （默认）或 close
+	FillAt string `json:"fillAt,omitempty"`
+	// 佣金（成交额的万分比）
+	CommissionBps float64 `json:"commissionBps,omitempty"`
+	// 每笔最低佣金
+	MinCommission float64 `json:"minCommission,omitempty"`
+	// 滑点（成交价的万分比），买入价上浮、卖出价下浮
+	SlippageBps float64 `json:"slippageBps,omitempty"`
+}
+
+// Default 填充默认值
+func (s Strategy) Default() Strategy {
+	if s.InitialCapital == 0 {
+		s.InitialCapital = DefaultInitialCapital
+	}
+	if s.PositionSize == 0 {
+		s.PositionSize = DefaultPositionSize
+	}
+	if s.FillAt == "" {
+		s.FillAt = FillNextOpen
+	}
+	return s
+}
+
+// Validate 校验策略，未设置的字段按默认值校验
+func (s Strategy) Validate() error {
+	s = s.Default()
+	if len(s.Entry) == 0 {
+		return fmt.Errorf("entry conditions are required")
+	}
+	for _, c := range append(append([]string{}, s.Entry...), s.Exit...) {
+		if _, err := ParseCondition(c); err != nil {
+			return err
+		}
+	}
+	switch {
+	case s.StopLoss < 0 || s.StopLoss >= 100:
+		return fmt.Errorf("stopLoss must be in [0, 100)")
+	case s.TakeProfit < 0:
+		return fmt.Errorf("takeProfit must not be negative")
+	case s.MaxHoldingBars < 0:
+		return fmt.Errorf("maxHoldingBars must not be negative")
+	case s.InitialCapital <= 0:
+		return fmt.Errorf("initialCapital must be positive")
+	case s.PositionSize <= 0 || s.PositionSize > 100:
+		return fmt.Errorf("positionSize must be in (0, 100]")
+	case s.LotSize < 0:
+		return fmt.Errorf("lotSize must not be negative")
+	case s.CommissionBps < 0 || s.MinCommission < 0 || s.SlippageBps < 0:
+		return fmt.Errorf("commissionBps, minCommission and slippageBps must not be negative")
+	}
+	switch s.FillAt {
+	case FillNextOpen, FillClose:
+	default:
+		return fmt.Errorf("invalid fillAt %q (expected: %s or %s)", s.FillAt, FillNextOpen, FillClose)
+	}
+	return nil
+}
+
+// LoadStrategy 从 JSON 或 YAML 文件加载策略
+func LoadStrategy(path string) (Strategy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Strategy{}, fmt.Errorf("read strategy file %q error: %w", path, err)
+	}
+	// YAML 是 JSON 的超集，先解析为通用值再按 JSON 字段名转为策略
+	var generic any
+	if err := yaml.Unmarshal(raw, &generic); err != nil {
+		return Strategy{}, fmt.Errorf("unmarshal strategy file %q error: %w", path, err)
+	}
+	raw, err = json.Marshal(generic)
+	if err != nil {
+		return Strategy{}, fmt.Errorf("marshal strategy to json error: %w", err)
+	}
+	var s Strategy
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return Strategy{}, fmt.Errorf("unmarshal strategy file %q error: %w", path, err)
+	}
+	return s, nil
+}
+
+// 比较运算符
+const (
+	OpLessThan       = "<"
+	OpLessOrEqual    = "<="
+	OpGreaterThan    = ">"
+	OpGreaterOrEqual = ">="
+	OpCrossesAbove   = "crosses_above"
+	OpCrossesBelow   = "crosses_below"
+)
+
+// Condition 交易条件，形如 "rsi:14 < 30" 、 "sma:5 crosses_above sma:20"
+type Condition struct {
+	Left  Operand
+	Op    string
+	Right Operand
+}
+
+// String 返回条件的字符串表示
+func (c Condition) String() string {
+	return c.Left.String() + " " + c.Op + " " + c.Right.String()
+}
+
+// ParseCondition 解析交易条件
+func ParseCondition(s string) (Condition, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return Condition{}, fmt.Errorf("invalid condition %q (expected: <operand> <op> <operand>)", s)
+	}
+	var c Condition
+	switch op := strings.ToLower(fields[1]); op {
+	case OpLessThan, OpLessOrEqual, OpGreaterThan, OpGreaterOrEqual, OpCrossesAbove, OpCrossesBelow:
+		c.Op = op
+	default:
+		return Condition{}, fmt.Errorf("invalid operator %q in condition %q (expected: <, <=, >, >=, %s or %s)",
+			fields[1], s, OpCrossesAbove, OpCrossesBelow)
+	}
+	var err error
+	if c.Left, err = ParseOperand(fields[0]); err != nil {
+		return Condition{}, fmt.Errorf("invalid condition %q: %w", s, err)
+	}
+	if c.Right, err = ParseOperand(fields[2]); err != nil {
+		return Condition{}, fmt.Errorf("invalid condition %q: %w", s, err)
+	}
+	return c, nil
+}
+
+// Eval 计算条件在每根 K 线上是否满足，数据不足时为 false
+func (c Condition) Eval(bars []tools.Bar) []bool {
+	left, right := c.Left.Eval(bars), c.Right.Eval(bars)
+	ret := make([]bool, len(bars))
+	for i := range bars {
+		l, r := left[i], right[i]
+		if !l.Valid || !r.Valid {
+			continue
+		}
+		switch c.Op {
+		case OpLessThan:
+			ret[i] = l.Decimal.LessThan(r.Decimal)
+		case OpLessOrEqual:
+			ret[i] = l.Decimal.LessThanOrEqual(r.Decimal)
+		case OpGreaterThan:
+			ret[i] = l.Decimal.GreaterThan(r.Decimal)
+		case OpGreaterOrEqual:
+			ret[i] = l.Decimal.GreaterThanOrEqual(r.Decimal)
+		case OpCrossesAbove, OpCrossesBelow:
+			if i == 0 || !left[i-1].Valid || !right[i-1].Valid {
+				continue
+			}
+			pl, pr := left[i-1].Decimal, right[i-1].Decimal
+			if c.Op == OpCrossesAbove {
+				ret[i] = l.Decimal.GreaterThan(r.Decimal) && pl.LessThanOrEqual(pr)
+			} else {
+				ret[i] = l.Decimal.LessThan(r.Decimal) && pl.GreaterThanOrEqual(pr)
+			}
+		}
+	}
+	return ret
+}
+
+// 支持的操作数
+const (
+	OperandOpen       = "open"
+	OperandHigh       = "high"
+	OperandLow        = "low"
+	OperandClose      = "close"
+	OperandVolume     = "volume"
+	OperandSMA        = "sma"
+	OperandEMA        = "ema"
+	OperandRSI        = "rsi"
+	OperandMACD       = "macd"
+	OperandMACDSignal = "macd_signal"
+	OperandMACDHist   = "macd_hist"
+	OperandBBUpper    = "bb_upper"
+	OperandBBMiddle   = "bb_middle"
+	OperandBBLower    = "bb_lower"
+	OperandATR        = "atr"
+	OperandVWAP       = "vwap"
+	OperandHighest    = "highest"
+	OperandLowest     = "lowest"
+)
+
+// Operand 条件的操作数，可以是常数、价格字段或技术指标
+type Operand struct {
+	// 常数
+	Const decimal.NullDecimal
+	// 价格字段或指标名
+	Name string
+	// 指标参数
+	Args []decimal.Decimal
+}
+
+// String 返回操作数的字符串表示
+func (o Operand) String() string {
+	if o.Const.Valid {
+		return o.Const.Decimal.String()
+	}
+	if len(o.Args) == 0 {
+		return o.Name
+	}
+	args := make([]string, len(o.Args))
+	for i, a := range o.Args {
+		args[i] = a.String()
+	}
+	return o.Name + ":" + strings.Join(args, ",")
+}
+
+// ParseOperand 解析操作数，如 30 、 close 、 sma:20 、 macd_signal:12,26,9 、 bb_lower:20,2
+func ParseOperand(s string) (Operand, error) {
+	if d, err := decimal.NewFromString(s); err == nil {
+		return Operand{Const: decimal.NullDecimal{Decimal: d, Valid: true}}, nil
+	}
+	name, argsStr, _ := strings.Cut(s, ":")
+	o := Operand{Name: strings.ToLower(name)}
+	switch o.Name {
+	case OperandOpen, OperandHigh, OperandLow, OperandClose, OperandVolume, OperandVWAP:
+		if argsStr != "" {
+			return Operand{}, fmt.Errorf("operand %q takes no arguments", name)
+		}
+		return o, nil
+	case OperandSMA, OperandEMA, OperandRSI, OperandMACD, OperandMACDSignal, OperandMACDHist,
+		OperandBBUpper, OperandBBMiddle, OperandBBLower, OperandATR, OperandHighest, OperandLowest:
+	default:
+		return Operand{}, fmt.Errorf("unknown operand %q", s)
+	}
+	if argsStr == "" {
+		return o, nil
+	}
+	for _, a := range strings.Split(argsStr, ",") {
+		d, err := decimal.NewFromString(strings.TrimSpace(a))
+		if err != nil || !d.IsPositive() {
+			return Operand{}, fmt.Errorf("invalid argument %q of operand %q", a, s)
+		}
+		o.Args = append(o.Args, d)
+	}
+	return o, nil
+}
+
+// arg 获取第 i 个整数参数，未指定时返回默认值
+func (o Operand) arg(i int, def int64) int {
+	if i < len(o.Args) {
+		return int(o.Args[i].IntPart())
+	}
+	return int(def)
+}
+
+// Eval 计算操作数在每根 K 线上的值
+func (o Operand) Eval(bars []tools.Bar) indicators.Series {
+	if o.Const.Valid {
+		ret := make(indicators.Series, len(bars))
+		for i := range ret {
+			ret[i] = o.Const
+		}
+		return ret
+	}
+
+	switch o.Name {
+	case OperandOpen, OperandHigh, OperandLow, OperandClose, OperandVolume:
+		ret := make(indicators.Series, len(bars))
+		for i, bar := range bars {
+			v := bar.Close
+			switch o.Name {
+			case OperandOpen:
+				v = bar.Open
+			case OperandHigh:
+				v = bar.High
+			case OperandLow:
+				v = bar.Low
+			case OperandVolume:
+				v = bar.Volume
+			}
+			ret[i] = decimal.NullDecimal{Decimal: v, Valid: true}
+		}
+		return ret
+	case OperandSMA:
+		return indicators.SMA(bars, o.arg(0, 20))
+	case OperandEMA:
+		return indicators.EMA(bars, o.arg(0, 20))
+	case OperandRSI:
+		return indicators.RSI(bars, o.arg(0, 14))
+	case OperandMACD, OperandMACDSignal, OperandMACDHist:
+		macd := indicators.MACD(bars, o.arg(0, 12), o.arg(1, 26), o.arg(2, 9))
+		switch o.Name {
+		case OperandMACDSignal:
+			return macd.Signal
+		case OperandMACDHist:
+			return macd.Histogram
+		}
+		return macd.MACD
+	case OperandBBUpper, OperandBBMiddle, OperandBBLower:
+		k := decimal.NewFromInt(2)
+		if len(o.Args) > 1 {
+			k = o.Args[1]
+		}
+		bb := indicators.Bollinger(bars, o.arg(0, 20), k)
+		switch o.Name {
+		case OperandBBUpper:
+			return bb.Upper
+		case OperandBBLower:
+			return bb.Lower
+		}
+		return bb.Middle
+	case OperandATR:
+		return indicators.ATR(bars, o.arg(0, 14))
+	case OperandVWAP:
+		return indicators.VWAP(bars)
+	case OperandHighest, OperandLowest:
+		return extreme(bars, o.arg(0, 20), o.Name == OperandHighest)
+	}
+	return make(indicators.Series, len(bars))
+}
+
+// extreme 此前 period 根 K 线（不含当前 K 线）的最高价或最低价
+func extreme(bars []tools.Bar, period int, highest bool) indicators.Series {
+	ret := make(indicators.Series, len(bars))
+	if period <= 0 {
+		return ret
+	}
+	for i := period; i < len(bars); i++ {
+		v := bars[i-period].Low
+		if highest {
+			v = bars[i-period].High
+		}
+		for _, bar := range bars[i-period+1 : i] {
+			if highest {
+				v = decimal.Max(v, bar.High)
+			} else {
+				v = decimal.Min(v, bar.Low)
+			}
+		}
+		ret[i] = decimal.NullDecimal{Decimal: v, Valid: true}
+	}
+	return ret
+}
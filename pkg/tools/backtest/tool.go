@@ -0,0 +1,238 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/indicators"
+)
+
+const (
+	// BacktestToolName 回测工具名
+	BacktestToolName = "Backtest"
+	// DirName 回测记录目录名
+	DirName = "backtests"
+	// StrategyFileName 回测记录中的策略文件名
+	StrategyFileName = "strategy.json"
+	// BarsFileName 回测记录中的 K 线文件名
+	BarsFileName = "bars.csv"
+	// DefaultTrades 默认输出的交易数
+	DefaultTrades = 20
+	// MaxTrades 最大输出的交易数
+	MaxTrades = 250
+	// 输出数值保留的小数位数
+	outputPlaces = 2
+)
+
+// Input 回测输入
+type Input struct {
+	// 引用的工具调用结果的 ref
+	ResultRef string `json:"resultRef,omitempty"`
+	// 引用指定工具最近一次的调用结果
+	ResultOf string `json:"resultOf,omitempty"`
+	// 直接提供的 K 线数据，对象数组、数组的数组或 CSV 文本
+	Data any `json:"data,omitempty"`
+	// 策略
+	Strategy Strategy `json:"strategy"`
+	// 输出最近多少笔交易
+	Trades int `json:"trades,omitempty"`
+}
+
+// Output 回测输出
+type Output struct {
+	// 数据来源
+	Source string `json:"source"`
+	// K 线数量
+	Bars int `json:"bars"`
+	// 数据起止时间
+	From string `json:"from"`
+	To   string `json:"to"`
+	// 使用的策略（已填充默认值）
+	Strategy Strategy `json:"strategy"`
+	// 回测指标
+	Metrics Metrics `json:"metrics"`
+	// 最近 N 笔交易的 Markdown 表格
+	Trades string `json:"trades"`
+	// 复现回测的命令
+	Reproduce string `json:"reproduce,omitempty"`
+}
+
+// DefineTool 定义回测工具
+//
+// dir 不为空时，每次回测的策略和 K 线数据保存在 dir 下的子目录中，供用户通过 nfa backtest 复现
+func DefineTool(g *genkit.Genkit, dir string) ai.ToolRef {
+	return genkit.DefineTool(g, BacktestToolName, `Backtest a rule-based long-only trading strategy over an OHLCV (K-line) series.
+
+给出基于技术指标的交易规则（如“ RSI 低于 30 买入、高于 70 卖出”）时，应先用该工具检验规则在历史数据上的表现，并如实说明回测结果。
+
+以 JSON 格式输入：
+- **resultRef**: (string,optional) 引用当前对话中某次工具调用结果的 ref
+- **resultOf**: (string,optional) 引用当前对话中指定工具最近一次的调用结果，如 TIME_SERIES_DAILY
+- **data**: (any,optional) 直接提供的 K 线数据，格式同 Indicators 工具
+- **strategy**: (object) 策略
+  - **name**: (string,optional) 策略名
+  - **entry**: (string[]) 买入条件，空仓且全部满足时买入
+  - **exit**: (string[],optional) 卖出条件，持仓且任一满足时卖出
+  - **stopLoss**: (number,optional) 止损，相对买入价下跌的百分比
+  - **takeProfit**: (number,optional) 止盈，相对买入价上涨的百分比
+  - **maxHoldingBars**: (int,optional) 最长持有 K 线数
+  - **initialCapital**: (number,optional) 初始资金，默认 10000
+  - **positionSize**: (number,optional) 每次买入使用权益的百分比，默认 100
+  - **lotSize**: (number,optional) 每手数量，如 A 股为 100 ，默认允许零碎数量
+  - **fillAt**: (string,optional) 成交方式， nextOpen （默认，信号次日开盘成交）或 close （信号当日收盘成交）
+  - **commissionBps**: (number,optional) 佣金，成交额的万分比
+  - **minCommission**: (number,optional) 每笔最低佣金
+  - **slippageBps**: (number,optional) 滑点，成交价的万分比
+- **trades**: (int,optional) 输出最近多少笔交易，默认 20 ，最大 250
+
+条件格式为 "<操作数> <运算符> <操作数>" ，以空格分隔：
+- 运算符： < 、 <= 、 > 、 >= 、 crosses_above （上穿）、 crosses_below （下穿）
+- 操作数：常数、 open 、 high 、 low 、 close 、 volume 、 sma:N 、 ema:N 、 rsi:N 、 macd:F,S,G 、 macd_signal:F,S,G 、 macd_hist:F,S,G 、 bb_upper:N,K 、 bb_middle:N,K 、 bb_lower:N,K 、 atr:N 、 vwap 、 highest:N （此前 N 根 K 线最高价）、 lowest:N （此前 N 根 K 线最低价）
+- 示例： "rsi:14 < 30" 、 "sma:5 crosses_above sma:20" 、 "close > highest:20"
+
+输出：
+- **source**: 数据来源
+- **bars**: K 线数量
+- **from** / **to**: 数据起止时间
+- **strategy**: 使用的策略（已填充默认值）
+- **metrics**: 回测指标，包括总收益率、年化收益率（ CAGR ）、夏普比率、最大回撤、交易次数、胜率、平均每笔收益率、盈利因子、持仓时间占比、佣金合计和同期买入持有收益率，百分比指标单位为 %
+- **trades**: 最近 N 笔交易的 Markdown 表格
+- **reproduce**: 用户复现回测的命令
+`,
+		func(ctx *ai.ToolContext, input Input) (Output, error) {
+			bars, source, err := tools.LoadBars(ctx, tools.BarsRef{
+				ResultRef: input.ResultRef,
+				ResultOf:  input.ResultOf,
+				Data:      input.Data,
+			}, BacktestToolName, indicators.IndicatorsToolName)
+			if err != nil {
+				return Output{}, err
+			}
+			result, err := Run(bars, input.Strategy)
+			if err != nil {
+				return Output{}, err
+			}
+
+			out := NewOutput(result, source, input.Trades)
+			if dir != "" {
+				runDir, err := SaveRun(dir, result.Strategy, bars)
+				if err != nil {
+					return Output{}, err
+				}
+				out.Reproduce = fmt.Sprintf("nfa backtest %s --data %s",
+					filepath.Join(runDir, StrategyFileName), filepath.Join(runDir, BarsFileName))
+			}
+			return out, nil
+		},
+	)
+}
+
+// NewOutput 根据回测结果创建工具输出，只包含最近 trades 笔交易
+func NewOutput(result Result, source string, trades int) Output {
+	switch {
+	case trades <= 0:
+		trades = DefaultTrades
+	case trades > MaxTrades:
+		trades = MaxTrades
+	}
+	return Output{
+		Source:   source,
+		Bars:     result.Bars,
+		From:     tools.FormatBarTime(result.From),
+		To:       tools.FormatBarTime(result.To),
+		Strategy: result.Strategy,
+		Metrics:  result.Metrics.Round(outputPlaces),
+		Trades:   tradesTable(result.Trades, trades),
+	}
+}
+
+// Round 各指标保留 places 位小数
+func (m Metrics) Round(places int32) Metrics {
+	round := func(d decimal.NullDecimal) decimal.NullDecimal {
+		if d.Valid {
+			d.Decimal = d.Decimal.Round(places)
+		}
+		return d
+	}
+	m.InitialCapital = m.InitialCapital.Round(places)
+	m.FinalEquity = m.FinalEquity.Round(places)
+	m.TotalReturn = m.TotalReturn.Round(places)
+	m.CAGR = round(m.CAGR)
+	m.Sharpe = round(m.Sharpe)
+	m.MaxDrawdown = m.MaxDrawdown.Round(places)
+	m.WinRate = round(m.WinRate)
+	m.AvgTradeReturn = round(m.AvgTradeReturn)
+	m.ProfitFactor = round(m.ProfitFactor)
+	m.Exposure = m.Exposure.Round(places)
+	m.Fees = m.Fees.Round(places)
+	m.BuyAndHoldReturn = m.BuyAndHoldReturn.Round(places)
+	return m
+}
+
+// tradesTable 生成最近 n 笔交易的 Markdown 表格
+func tradesTable(trades []Trade, n int) string {
+	if len(trades) == 0 {
+		return "no trades"
+	}
+	start := max(len(trades)-n, 0)
+
+	buf := &strings.Builder{}
+	buf.WriteString("| # | Entry | Entry Price | Exit | Exit Price | Quantity | PnL | Return (%) | Bars | Exit Reason |\n")
+	buf.WriteString("|" + strings.Repeat(" --- |", 10) + "\n")
+	for i := start; i < len(trades); i++ {
+		t := trades[i]
+		fmt.Fprintf(buf, "| %d | %s | %s | %s | %s | %s | %s | %s | %d | %s |\n",
+			i+1,
+			tools.FormatBarTime(t.EntryTime), t.EntryPrice.Round(4),
+			tools.FormatBarTime(t.ExitTime), t.ExitPrice.Round(4),
+			t.Quantity, t.PnL.Round(outputPlaces), t.Return.Round(outputPlaces),
+			t.Bars, t.ExitReason,
+		)
+	}
+	if start > 0 {
+		fmt.Fprintf(buf, "\n%d earlier trades omitted\n", start)
+	}
+	return buf.String()
+}
+
+// invalidNameChars 目录名中不允许的字符
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// SaveRun 将策略和 K 线数据保存到 dir 下新建的子目录中，返回子目录路径
+func SaveRun(dir string, s Strategy, bars []tools.Bar) (string, error) {
+	name := time.Now().Format("20060102-150405.000")
+	if n := strings.Trim(invalidNameChars.ReplaceAllString(s.Name, "-"), "-."); n != "" {
+		name += "-" + n
+	}
+	runDir := filepath.Join(dir, name)
+	if err := os.MkdirAll(runDir, 0o755); err != nil {
+		return "", fmt.Errorf("create backtest directory %q error: %w", runDir, err)
+	}
+
+	// 条件中的 < 、 > 不转义，便于用户阅读和修改
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		return "", fmt.Errorf("marshal strategy to json error: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(runDir, StrategyFileName), buf.Bytes(), 0o644); err != nil {
+		return "", fmt.Errorf("write strategy file error: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(runDir, BarsFileName), []byte(tools.FormatBarsCSV(bars)), 0o644); err != nil {
+		return "", fmt.Errorf("write bars file error: %w", err)
+	}
+	return runDir, nil
+}
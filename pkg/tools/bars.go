@@ -0,0 +1,421 @@
+package tools
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Bar 一根 K 线（ OHLCV ）
+type Bar struct {
+	Time   time.Time       `json:"time"`
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Volume decimal.Decimal `json:"volume"`
+}
+
+// MarshalJSON 序列化为 JSON ，只有日期的时间只输出日期
+func (b Bar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"time":   FormatBarTime(b.Time),
+		"open":   b.Open,
+		"high":   b.High,
+		"low":    b.Low,
+		"close":  b.Close,
+		"volume": b.Volume,
+	})
+}
+
+// UnmarshalJSON 从 JSON 反序列化，支持的字段名参考 ParseBars
+func (b *Bar) UnmarshalJSON(data []byte) error {
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	bar, err := barFromMap("", m)
+	if err != nil {
+		return err
+	}
+	*b = bar
+	return nil
+}
+
+// FormatBarTime 格式化 K 线时间，零点时只输出日期
+func FormatBarTime(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// 各字段可能的名字（小写）
+var (
+	barTimeKeys   = []string{"time", "date", "datetime", "timestamp", "t", "day"}
+	barOpenKeys   = []string{"open", "o", "1. open"}
+	barHighKeys   = []string{"high", "h", "2. high"}
+	barLowKeys    = []string{"low", "l", "3. low"}
+	barCloseKeys  = []string{"close", "c", "4. close", "adjusted close", "5. adjusted close", "price"}
+	barVolumeKeys = []string{"volume", "v", "vol", "5. volume", "6. volume"}
+	// 包含 K 线列表的字段名
+	barListKeys = []string{"bars", "data", "values", "candles", "klines", "prices", "items", "result", "results"}
+)
+
+// ParseBars 从任意 JSON 值解析 K 线序列，返回按时间升序排列的 K 线
+//
+// 支持以下形式：
+//   - K 线对象数组，字段名如 date/time/timestamp, open/o, high/h, low/l, close/c, volume/v
+//   - [time, open, high, low, close, volume] 形式的数组的数组
+//   - Alpha Vantage 风格的 {"Time Series (Daily)": {"2024-01-02": {"1. open": ...}}} 对象
+//   - 包含上述数据的 bars/data/values 等字段的对象
+//   - MCP 工具结果 {"content": [{"type": "text", "text": ...}]} ，文本为 JSON 或 CSV
+//   - 带表头的 CSV 文本
+func ParseBars(v any) ([]Bar, error) {
+	bars, err := parseBars(v, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no OHLCV bars found")
+	}
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	return bars, nil
+}
+
+// parseBars 解析 K 线，depth 用于限制递归深度
+func parseBars(v any, depth int) ([]Bar, error) {
+	if depth > 5 {
+		return nil, fmt.Errorf("data nested too deep")
+	}
+	switch typed := v.(type) {
+	case string:
+		return parseBarsText(typed, depth)
+	case []byte:
+		return parseBarsText(string(typed), depth)
+	case []any:
+		return parseBarList(typed)
+	case map[string]any:
+		return parseBarObject(typed, depth)
+	case nil:
+		return nil, fmt.Errorf("no data")
+	default:
+		// 结构体等其它类型，转为通用 JSON 值后解析
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("marshal data error: %w", err)
+		}
+		var generic any
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return nil, fmt.Errorf("unmarshal data error: %w", err)
+		}
+		return parseBars(generic, depth+1)
+	}
+}
+
+// parseBarsText 解析文本形式的 K 线， JSON 或 CSV
+func parseBarsText(text string, depth int) ([]Bar, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[") {
+		var generic any
+		if err := json.Unmarshal([]byte(text), &generic); err == nil {
+			return parseBars(generic, depth+1)
+		}
+	}
+	return ParseBarsCSV(text)
+}
+
+// parseBarObject 解析对象形式的 K 线数据
+func parseBarObject(m map[string]any, depth int) ([]Bar, error) {
+	// MCP 工具结果
+	if content, ok := m["content"].([]any); ok {
+		var texts []string
+		for _, item := range content {
+			if part, ok := item.(map[string]any); ok {
+				if text, ok := part["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+		if len(texts) > 0 {
+			return parseBarsText(strings.Join(texts, "\n"), depth)
+		}
+	}
+
+	// Alpha Vantage 风格： "Time Series (Daily)": {date: {...}}
+	for k, v := range m {
+		lower := strings.ToLower(k)
+		if !strings.Contains(lower, "time series") && !strings.Contains(lower, "timeseries") {
+			continue
+		}
+		series, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		bars := make([]Bar, 0, len(series))
+		for date, item := range series {
+			fields, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			bar, err := barFromMap(date, fields)
+			if err != nil {
+				return nil, fmt.Errorf("parse bar %q error: %w", date, err)
+			}
+			bars = append(bars, bar)
+		}
+		return bars, nil
+	}
+
+	// 包含 K 线列表的字段
+	for k, v := range m {
+		if !containsFold(barListKeys, k) {
+			continue
+		}
+		return parseBars(v, depth+1)
+	}
+
+	// 单根 K 线
+	if _, ok := lookupFold(m, barCloseKeys); ok {
+		bar, err := barFromMap("", m)
+		if err != nil {
+			return nil, err
+		}
+		return []Bar{bar}, nil
+	}
+
+	return nil, fmt.Errorf("no OHLCV bars found in object")
+}
+
+// parseBarList 解析数组形式的 K 线数据
+func parseBarList(list []any) ([]Bar, error) {
+	bars := make([]Bar, 0, len(list))
+	for i, item := range list {
+		var (
+			bar Bar
+			err error
+		)
+		switch typed := item.(type) {
+		case map[string]any:
+			bar, err = barFromMap("", typed)
+		case []any:
+			bar, err = barFromArray(typed)
+		default:
+			err = fmt.Errorf("unexpected item type %T", item)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse bar %d error: %w", i, err)
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+// barFromMap 从对象解析一根 K 线， timeStr 不为空时作为时间
+func barFromMap(timeStr string, m map[string]any) (Bar, error) {
+	bar := Bar{}
+	var err error
+
+	timeValue := any(timeStr)
+	if timeStr == "" {
+		v, ok := lookupFold(m, barTimeKeys)
+		if !ok {
+			return Bar{}, fmt.Errorf("missing time field")
+		}
+		timeValue = v
+	}
+	if bar.Time, err = parseBarTime(timeValue); err != nil {
+		return Bar{}, err
+	}
+
+	closeValue, ok := lookupFold(m, barCloseKeys)
+	if !ok {
+		return Bar{}, fmt.Errorf("missing close field")
+	}
+	if bar.Close, err = toDecimal(closeValue); err != nil {
+		return Bar{}, fmt.Errorf("invalid close: %w", err)
+	}
+	// 缺少开高低价时使用收盘价
+	bar.Open, bar.High, bar.Low = bar.Close, bar.Close, bar.Close
+	for _, f := range []struct {
+		keys []string
+		dst  *decimal.Decimal
+	}{
+		{barOpenKeys, &bar.Open},
+		{barHighKeys, &bar.High},
+		{barLowKeys, &bar.Low},
+		{barVolumeKeys, &bar.Volume},
+	} {
+		v, ok := lookupFold(m, f.keys)
+		if !ok {
+			continue
+		}
+		if *f.dst, err = toDecimal(v); err != nil {
+			return Bar{}, fmt.Errorf("invalid %s: %w", f.keys[0], err)
+		}
+	}
+	return bar, nil
+}
+
+// barFromArray 从 [time, open, high, low, close, volume] 数组解析一根 K 线
+func barFromArray(arr []any) (Bar, error) {
+	if len(arr) < 5 {
+		return Bar{}, fmt.Errorf("expected [time, open, high, low, close, volume], got %d items", len(arr))
+	}
+	bar := Bar{}
+	var err error
+	if bar.Time, err = parseBarTime(arr[0]); err != nil {
+		return Bar{}, err
+	}
+	for i, dst := range []*decimal.Decimal{&bar.Open, &bar.High, &bar.Low, &bar.Close} {
+		if *dst, err = toDecimal(arr[i+1]); err != nil {
+			return Bar{}, fmt.Errorf("invalid item %d: %w", i+1, err)
+		}
+	}
+	if len(arr) > 5 {
+		if bar.Volume, err = toDecimal(arr[5]); err != nil {
+			return Bar{}, fmt.Errorf("invalid volume: %w", err)
+		}
+	}
+	return bar, nil
+}
+
+// ParseBarsCSV 解析带表头的 CSV 格式 K 线，返回按时间升序排列的 K 线
+func ParseBarsCSV(text string) ([]Bar, error) {
+	r := csv.NewReader(strings.NewReader(strings.TrimSpace(text)))
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse csv error: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("no OHLCV bars found in csv")
+	}
+
+	header := records[0]
+	bars := make([]Bar, 0, len(records)-1)
+	for i, record := range records[1:] {
+		m := make(map[string]any, len(header))
+		for j, name := range header {
+			if j < len(record) {
+				m[strings.TrimSpace(name)] = strings.TrimSpace(record[j])
+			}
+		}
+		bar, err := barFromMap("", m)
+		if err != nil {
+			return nil, fmt.Errorf("parse csv line %d error: %w", i+2, err)
+		}
+		bars = append(bars, bar)
+	}
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	return bars, nil
+}
+
+// FormatBarsCSV 将 K 线格式化为带表头的 CSV ，可由 ParseBarsCSV 解析
+func FormatBarsCSV(bars []Bar) string {
+	buf := &strings.Builder{}
+	w := csv.NewWriter(buf)
+	_ = w.Write([]string{"time", "open", "high", "low", "close", "volume"})
+	for _, bar := range bars {
+		_ = w.Write([]string{
+			FormatBarTime(bar.Time),
+			bar.Open.String(), bar.High.String(), bar.Low.String(), bar.Close.String(), bar.Volume.String(),
+		})
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// 支持的时间格式
+var barTimeLayouts = []string{
+	time.RFC3339,
+	time.DateOnly,
+	time.DateTime,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02",
+	"20060102",
+}
+
+// parseBarTime 解析 K 线时间，支持多种日期格式和 Unix 时间戳（秒或毫秒）
+func parseBarTime(v any) (time.Time, error) {
+	switch typed := v.(type) {
+	case float64:
+		return unixTime(int64(typed)), nil
+	case json.Number:
+		n, err := typed.Int64()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", typed)
+		}
+		return unixTime(n), nil
+	case string:
+		s := strings.TrimSpace(typed)
+		for _, layout := range barTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		// 8 位的纯数字按日期处理，已在上面尝试过
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil && len(s) >= 9 {
+			return unixTime(n), nil
+		}
+		return time.Time{}, fmt.Errorf("invalid time %q", typed)
+	}
+	return time.Time{}, fmt.Errorf("invalid time %v", v)
+}
+
+// unixTime 将秒或毫秒时间戳转换为 UTC 时间
+func unixTime(n int64) time.Time {
+	if n > 1e11 {
+		return time.UnixMilli(n).UTC()
+	}
+	return time.Unix(n, 0).UTC()
+}
+
+// toDecimal 将 JSON 数值或字符串转换为 decimal
+func toDecimal(v any) (decimal.Decimal, error) {
+	switch typed := v.(type) {
+	case float64:
+		return decimal.NewFromFloat(typed), nil
+	case json.Number:
+		return decimal.NewFromString(typed.String())
+	case string:
+		s := strings.ReplaceAll(strings.TrimSpace(typed), ",", "")
+		if s == "" {
+			return decimal.Zero, nil
+		}
+		return decimal.NewFromString(s)
+	case nil:
+		return decimal.Zero, nil
+	}
+	return decimal.Zero, fmt.Errorf("unexpected number %v", v)
+}
+
+// lookupFold 忽略大小写按候选字段名依次查找值
+func lookupFold(m map[string]any, keys []string) (any, bool) {
+	for _, key := range keys {
+		for k, v := range m {
+			if strings.EqualFold(strings.TrimSpace(k), key) {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// containsFold 忽略大小写判断列表是否包含 s
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
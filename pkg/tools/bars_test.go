@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBars(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "AlphaVantage",
+			data: `{
+  "Meta Data": {"2. Symbol": "IBM"},
+  "Time Series (Daily)": {
+    "2024-01-03": {"1. open": "11", "2. high": "13", "3. low": "10", "4. close": "12", "5. volume": "200"},
+    "2024-01-02": {"1. open": "10", "2. high": "11.5", "3. low": "9", "4. close": "11", "5. volume": "100"}
+  }
+}`,
+		},
+		{
+			name: "ObjectArray",
+			data: `{"bars": [
+  {"date": "2024-01-03", "o": 11, "h": 13, "l": 10, "c": 12, "v": 200},
+  {"date": "2024-01-02", "o": 10, "h": 11.5, "l": 9, "c": 11, "v": 100}
+]}`,
+		},
+		{
+			name: "ArrayArray",
+			data: `[["2024-01-02", 10, 11.5, 9, 11, 100], ["2024-01-03", 11, 13, 10, 12, 200]]`,
+		},
+		{
+			name: "MCPCSV",
+			data: `{"content": [{"type": "text", "text": "timestamp,open,high,low,close,volume\n2024-01-03,11,13,10,12,200\n2024-01-02,10,11.5,9,11,100\n"}]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var v any
+			require.NoError(t, json.Unmarshal([]byte(c.data), &v))
+			bars, err := ParseBars(v)
+			require.NoError(t, err)
+			require.Len(t, bars, 2)
+
+			assert.Equal(t, "2024-01-02", FormatBarTime(bars[0].Time))
+			assert.Equal(t, "10", bars[0].Open.String())
+			assert.Equal(t, "11.5", bars[0].High.String())
+			assert.Equal(t, "9", bars[0].Low.String())
+			assert.Equal(t, "11", bars[0].Close.String())
+			assert.Equal(t, "100", bars[0].Volume.String())
+			assert.Equal(t, "2024-01-03", FormatBarTime(bars[1].Time))
+			assert.Equal(t, "12", bars[1].Close.String())
+		})
+	}
+
+	_, err := ParseBars(map[string]any{"foo": "bar"})
+	assert.Error(t, err)
+}
+
+func TestFormatBarsCSV(t *testing.T) {
+	bars, err := ParseBars(`[["2024-01-02", 10, 11.5, 9, 11, 100], ["2024-01-03", 11, 13, 10, 12, 200]]`)
+	require.NoError(t, err)
+
+	text := FormatBarsCSV(bars)
+	assert.Equal(t, "time,open,high,low,close,volume\n2024-01-02,10,11.5,9,11,100\n2024-01-03,11,13,10,12,200\n", text)
+	parsed, err := ParseBarsCSV(text)
+	require.NoError(t, err)
+	assert.Equal(t, text, FormatBarsCSV(parsed))
+}
+
+func TestResultStore(t *testing.T) {
+	s := NewResultStore()
+	s.Add("1", "A", "a1")
+	s.Add("2", "B", "b1")
+	s.Add("3", "A", "a2")
+
+	r, ok := s.Get("2")
+	require.True(t, ok)
+	assert.Equal(t, "b1", r.Output)
+
+	r, ok = s.Latest("A")
+	require.True(t, ok)
+	assert.Equal(t, "a2", r.Output)
+
+	r, ok = s.Latest("")
+	require.True(t, ok)
+	assert.Equal(t, "3", r.Ref)
+
+	_, ok = s.Get("4")
+	assert.False(t, ok)
+	assert.Len(t, s.All(), 3)
+}
@@ -0,0 +1,54 @@
+package chart
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/charts"
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// TestDraw 测试使用 K 线创建图表
+func TestDraw(t *testing.T) {
+	bars, err := tools.ParseBars(`date,open,high,low,close,volume
+2025-10-07,385,390,383,388,100
+2025-10-08,388,389,376,379.5,120
+2025-10-09,379.5,387,379,385.17,90
+`)
+	require.NoError(t, err)
+
+	out, err := Draw(bars, "tool MarketBars", Input{Title: "0700.HK", Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, charts.TypeCandlestick, out.Chart.Type)
+	require.Len(t, out.Chart.Points, 2)
+	assert.Equal(t, charts.Point{Time: "2025-10-09", Open: 379.5, High: 387, Low: 379, Close: 385.17}, out.Chart.Points[1])
+	assert.Equal(t, "📈 0700.HK 2025-10-08 ~ 2025-10-09: 385.17 (-0.73%)", out.Summary)
+	assert.Equal(t, "tool MarketBars", out.Source)
+
+	// 只有收盘价时绘制折线图
+	closes, err := tools.ParseBars([]any{
+		map[string]any{"date": "2025-10-08", "close": 379.5},
+		map[string]any{"date": "2025-10-09", "close": 385.17},
+	})
+	require.NoError(t, err)
+	out, err = Draw(closes, "data", Input{})
+	require.NoError(t, err)
+	assert.Equal(t, charts.TypeLine, out.Chart.Type)
+	out, err = Draw(closes, "data", Input{Type: charts.TypeCandlestick})
+	require.NoError(t, err)
+	assert.Equal(t, charts.TypeLine, out.Chart.Type)
+
+	// 从 JSON 反序列化的工具输出获取图表
+	raw, err := json.Marshal(out)
+	require.NoError(t, err)
+	var output any
+	require.NoError(t, json.Unmarshal(raw, &output))
+	c, ok := ChartFromOutput(output)
+	require.True(t, ok)
+	assert.Equal(t, out.Chart, c)
+	_, ok = ChartFromOutput(map[string]any{"summary": "x"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,131 @@
+package chart
+
+import (
+	"encoding/json"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/yhlooo/nfa/pkg/charts"
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+const (
+	// ChartToolName 价格图表工具名
+	ChartToolName = "Chart"
+	// DefaultLimit 默认图表包含的 K 线数量
+	DefaultLimit = 120
+)
+
+// Input 价格图表输入
+type Input struct {
+	// 引用的工具调用结果的 ref
+	ResultRef string `json:"resultRef,omitempty"`
+	// 引用指定工具最近一次的调用结果
+	ResultOf string `json:"resultOf,omitempty"`
+	// 直接提供的 K 线数据，对象数组、数组的数组或 CSV 文本
+	Data any `json:"data,omitempty"`
+	// 图表类型， line 或 candlestick
+	Type charts.Type `json:"type,omitempty"`
+	// 标题
+	Title string `json:"title,omitempty"`
+	// 包含最近多少根 K 线
+	Limit int `json:"limit,omitempty"`
+}
+
+// Output 价格图表输出
+type Output struct {
+	// 图表
+	Chart charts.Chart `json:"chart"`
+	// 图表摘要
+	Summary string `json:"summary"`
+	// 数据来源
+	Source string `json:"source"`
+}
+
+// DefineTool 定义价格图表工具
+func DefineTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, ChartToolName, `Show a line or candlestick chart of an OHLC (K-line) series to the user.
+
+需要向用户展示价格走势时，应使用该工具绘制图表，而不是在回答中列出大段 K 线表格。
+图表会直接显示给用户（终端中为字符图表，消息通道中为图片），回答中不需要重复图表的数据，只需要描述走势要点。
+
+以 JSON 格式输入：
+- **resultRef**: (string,optional) 引用当前对话中某次工具调用结果的 ref
+- **resultOf**: (string,optional) 引用当前对话中指定工具最近一次的调用结果，如 TIME_SERIES_DAILY
+- **data**: (any,optional) 直接提供的 K 线数据，格式同 Indicators 工具，只有收盘价时只能绘制折线图
+- **type**: (string,optional) 图表类型， candlestick （ K 线图，默认）或 line （收盘价折线图）
+- **title**: (string,optional) 图表标题，如证券代码和名称
+- **limit**: (int,optional) 包含最近多少根 K 线，默认 120 ，最大 500
+
+resultRef 、 resultOf 和 data 都未指定时，使用当前对话中最近一次包含 K 线数据的工具调用结果。
+
+输出：
+- **chart**: 已展示给用户的图表
+- **summary**: 图表摘要，包括起止时间、最新收盘价和区间涨跌幅
+- **source**: 数据来源
+`,
+		func(ctx *ai.ToolContext, input Input) (Output, error) {
+			bars, source, err := tools.LoadBars(ctx, tools.BarsRef{
+				ResultRef: input.ResultRef,
+				ResultOf:  input.ResultOf,
+				Data:      input.Data,
+			}, ChartToolName)
+			if err != nil {
+				return Output{}, err
+			}
+			return Draw(bars, source, input)
+		},
+	)
+}
+
+// Draw 使用 K 线创建图表
+func Draw(bars []tools.Bar, source string, input Input) (Output, error) {
+	limit := input.Limit
+	switch {
+	case limit <= 0:
+		limit = DefaultLimit
+	case limit > charts.MaxPoints:
+		limit = charts.MaxPoints
+	}
+	if len(bars) > limit {
+		bars = bars[len(bars)-limit:]
+	}
+
+	// 只有收盘价（开高低价均等于收盘价）时只能绘制折线图
+	typ := input.Type
+	if typ == "" {
+		typ = charts.TypeCandlestick
+	}
+	closeOnly := true
+	for _, bar := range bars {
+		if !bar.Open.Equal(bar.Close) || !bar.High.Equal(bar.Close) || !bar.Low.Equal(bar.Close) {
+			closeOnly = false
+			break
+		}
+	}
+	if closeOnly {
+		typ = charts.TypeLine
+	}
+	c := charts.FromBars(bars, typ, input.Title)
+	if err := c.Complete(); err != nil {
+		return Output{}, err
+	}
+	return Output{Chart: c, Summary: charts.Summary(c), Source: source}, nil
+}
+
+// ChartFromOutput 从工具输出（ Output 或其 JSON 反序列化结果）获取图表
+func ChartFromOutput(output any) (charts.Chart, bool) {
+	raw, err := json.Marshal(output)
+	if err != nil {
+		return charts.Chart{}, false
+	}
+	var out Output
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return charts.Chart{}, false
+	}
+	if err := out.Chart.Complete(); err != nil {
+		return charts.Chart{}, false
+	}
+	return out.Chart, true
+}
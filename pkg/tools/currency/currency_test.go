@@ -0,0 +1,50 @@
+package currency
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/fx"
+)
+
+// TestConvert 测试货币换算
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rates.csv"), []byte(`date,from,to,rate
+2024-01-05,USD,HKD,7.8
+2024-01-05,USD,CNY,7.1
+`), 0o644))
+	converter := fx.NewConverter(
+		fx.NewCSVSource(fx.CSVOptions{Path: dir}),
+		fx.NewStaticSource(fx.StaticOptions{Base: "USD", Rates: map[string]float64{"JPY": 0.007}}),
+	)
+	ctx := context.Background()
+
+	out, err := Convert(ctx, converter, Input{Amount: 100, From: "usd", To: []string{"HKD", "CNY", "USD"}, Date: "2024-01-06"})
+	require.NoError(t, err)
+	require.Len(t, out.Conversions, 3)
+	assert.Equal(t, "780", out.Conversions[0].Result.String())
+	assert.Equal(t, "710", out.Conversions[1].Result.String())
+	assert.Equal(t, "100", out.Conversions[2].Result.String())
+	assert.Equal(t, []string{
+		"no USD/HKD rate on 2024-01-06, used the rate of 2024-01-05",
+		"no USD/CNY rate on 2024-01-06, used the rate of 2024-01-05",
+	}, out.Notes)
+
+	out, err = Convert(ctx, converter, Input{From: "JPY", To: []string{"USD"}})
+	require.NoError(t, err)
+	assert.Equal(t, "0.007", out.Conversions[0].Result.String())
+	assert.Len(t, out.Notes, 1)
+
+	_, err = Convert(ctx, converter, Input{From: "USD", To: []string{"HKD"}, Date: "2024/01/05"})
+	assert.Error(t, err)
+	_, err = Convert(ctx, converter, Input{From: "USD"})
+	assert.Error(t, err)
+	_, err = Convert(ctx, converter, Input{From: "USD", To: []string{"EUR"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package currency
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/fx"
+)
+
+// ConvertCurrencyToolName 货币换算工具名
+const ConvertCurrencyToolName = "ConvertCurrency"
+
+// Input 货币换算输入
+type Input struct {
+	// 金额，默认 1
+	Amount float64 `json:"amount,omitempty"`
+	From   string  `json:"from"`
+	// 目标货币，可以有多个
+	To []string `json:"to"`
+	// 汇率日期，格式 YYYY-MM-DD ，为空时使用最新汇率
+	Date string `json:"date,omitempty"`
+}
+
+// Output 货币换算输出
+type Output struct {
+	Conversions []fx.Conversion `json:"conversions"`
+	// 注意事项，如汇率日期与查询日期不同
+	Notes []string `json:"notes,omitempty"`
+}
+
+// DefineTool 定义货币换算工具
+func DefineTool(g *genkit.Genkit, converter *fx.Converter) ai.ToolRef {
+	return genkit.DefineTool(g, ConvertCurrencyToolName, `Convert an amount between currencies at the latest or a historical exchange rate.
+
+涉及不同货币金额的换算或比较（如港股市值折合人民币、美元计价的收益折合港币）时，必须使用该工具获取汇率，不要凭记忆中的汇率换算。
+查询历史日期时使用当天汇率，当天没有汇率（如周末、节假日）时使用之前最近的汇率，输出中会给出实际使用的汇率日期。
+
+以 JSON 格式输入：
+- **amount**: (number,optional) 金额，默认 1
+- **from**: (string) 源货币代码，如 USD 、 HKD 、 CNY
+- **to**: (string[]) 目标货币代码，可以有多个
+- **date**: (string,optional) 汇率日期，格式 YYYY-MM-DD ，默认使用最新汇率
+
+输出：
+- **conversions**: 换算结果，包括金额 amount 、源货币 from 、目标货币 to 、结果 result 和使用的汇率 rate （汇率值、日期和来源，固定汇率没有日期）
+- **notes**: 注意事项，如实际使用的汇率日期与查询日期不同
+`,
+		func(ctx *ai.ToolContext, in Input) (Output, error) {
+			return Convert(ctx, converter, in)
+		},
+	)
+}
+
+// Convert 按输入换算货币
+func Convert(ctx context.Context, converter *fx.Converter, in Input) (Output, error) {
+	amount := decimal.NewFromInt(1)
+	if in.Amount != 0 {
+		amount = decimal.NewFromFloat(in.Amount)
+	}
+	var date time.Time
+	if in.Date != "" {
+		var err error
+		if date, err = time.Parse(time.DateOnly, strings.TrimSpace(in.Date)); err != nil {
+			return Output{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", in.Date)
+		}
+	}
+	if len(in.To) == 0 {
+		return Output{}, fmt.Errorf("to is required")
+	}
+
+	ret := Output{Conversions: make([]fx.Conversion, 0, len(in.To))}
+	for _, to := range in.To {
+		conv, err := converter.Convert(ctx, amount, in.From, to, date)
+		if err != nil {
+			return Output{}, fmt.Errorf("convert %s to %s error: %w", in.From, to, err)
+		}
+		ret.Conversions = append(ret.Conversions, conv)
+
+		switch rateDate := conv.Rate.Date; {
+		case conv.From == conv.To:
+		case rateDate.IsZero():
+			ret.Notes = append(ret.Notes, fmt.Sprintf("%s/%s uses a fixed rate from %s, not a market rate of any specific date",
+				conv.From, conv.To, conv.Rate.Source))
+		case !date.IsZero() && !rateDate.Equal(date):
+			ret.Notes = append(ret.Notes, fmt.Sprintf("no %s/%s rate on %s, used the rate of %s",
+				conv.From, conv.To, date.Format(time.DateOnly), rateDate.Format(time.DateOnly)))
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,438 @@
+package holdings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/fx"
+	"github.com/yhlooo/nfa/pkg/portfolio"
+)
+
+const (
+	// HoldingsToolName 持仓查询工具名
+	HoldingsToolName = "PortfolioHoldings"
+	// PnLToolName 盈亏计算工具名
+	PnLToolName = "PortfolioPnL"
+	// ExposureToolName 暴露分析工具名
+	ExposureToolName = "PortfolioExposure"
+	// ConcentrationToolName 集中度分析工具名
+	ConcentrationToolName = "PortfolioConcentration"
+)
+
+// NewTools 创建投资组合工具
+func NewTools(store *portfolio.Store, opts portfolio.Options) *Tools {
+	return &Tools{store: store, opts: opts}
+}
+
+// Tools 投资组合工具，读取用户通过 nfa portfolio 命令导入的本地投资组合
+type Tools struct {
+	store     *portfolio.Store
+	opts      portfolio.Options
+	converter *fx.Converter
+}
+
+// SetConverter 设置汇率换算器，按工具调用时的最新汇率换算，未设置时只能换算相同货币
+func (t *Tools) SetConverter(converter *fx.Converter) {
+	t.converter = converter
+}
+
+// RegisterTools 注册所有投资组合工具
+func (t *Tools) RegisterTools(g *genkit.Genkit) []ai.ToolRef {
+	return []ai.ToolRef{
+		t.DefineHoldingsTool(g),
+		t.DefinePnLTool(g),
+		t.DefineExposureTool(g),
+		t.DefineConcentrationTool(g),
+	}
+}
+
+// CommonInput 投资组合工具的通用输入
+type CommonInput struct {
+	// 只分析指定账户
+	Account string `json:"account,omitempty"`
+	// 最新价格，键为证券代码，覆盖本地保存的价格
+	Prices map[string]float64 `json:"prices,omitempty"`
+	// 基准货币
+	BaseCurrency string `json:"baseCurrency,omitempty"`
+}
+
+// 通用输入的说明
+const commonInputDesc = `- **account**: (string,optional) 只分析指定账户 ID ，默认分析所有账户
+- **prices**: (object,optional) 最新价格，键为证券代码，值为价格，覆盖本地保存的价格。本地价格可能过时，需要准确数值时先查询最新行情再传入
+- **baseCurrency**: (string,optional) 汇总使用的基准货币，如 USD 、 CNY ，默认使用配置的基准货币`
+
+// analyze 加载并分析投资组合
+func (t *Tools) analyze(ctx context.Context, in CommonInput) (portfolio.Analysis, error) {
+	p, err := t.store.Load()
+	if err != nil {
+		return portfolio.Analysis{}, err
+	}
+	if len(p.Positions) == 0 && len(p.Cash) == 0 {
+		return portfolio.Analysis{}, fmt.Errorf("portfolio is empty, " +
+			"the user can import holdings with `nfa portfolio import positions <file.csv>`")
+	}
+	if in.Account != "" {
+		if _, ok := p.Account(in.Account); !ok {
+			ids := make([]string, len(p.Accounts))
+			for i, a := range p.Accounts {
+				ids[i] = a.ID
+			}
+			return portfolio.Analysis{}, fmt.Errorf("account %q not found (available: %s)",
+				in.Account, strings.Join(ids, ", "))
+		}
+	}
+
+	prices := make(map[string]decimal.Decimal, len(in.Prices))
+	for symbol, price := range in.Prices {
+		prices[symbol] = decimal.NewFromFloat(price)
+	}
+	baseCurrency := in.BaseCurrency
+	if baseCurrency == "" {
+		baseCurrency = t.opts.BaseCurrency
+	}
+	var converter fx.AmountConverter
+	if t.converter != nil {
+		converter = t.converter.AsOf(ctx, time.Time{})
+	}
+	return portfolio.Analyze(p, portfolio.AnalyzeOptions{
+		Account:      in.Account,
+		Prices:       prices,
+		BaseCurrency: baseCurrency,
+		Converter:    converter,
+	}), nil
+}
+
+// Notes 分析结果的注意事项
+type Notes struct {
+	// 缺少价格的证券，使用成本估算市值
+	MissingPrices []string `json:"missingPrices,omitempty"`
+	// 无法换算为基准货币的货币，相关金额未计入汇总
+	Unconverted []string `json:"unconvertedCurrencies,omitempty"`
+}
+
+// notesOf 获取分析结果的注意事项
+func notesOf(a portfolio.Analysis) Notes {
+	return Notes{MissingPrices: a.MissingPrices, Unconverted: a.Unconverted}
+}
+
+// HoldingsOutput 持仓查询输出
+type HoldingsOutput struct {
+	BaseCurrency string `json:"baseCurrency"`
+	// 持仓表格
+	Holdings string `json:"holdings"`
+	// 现金表格
+	Cash string `json:"cash,omitempty"`
+	// 持仓市值
+	MarketValue string `json:"marketValue"`
+	// 现金合计
+	CashTotal string `json:"cashTotal"`
+	// 总资产
+	TotalValue string `json:"totalValue"`
+	Notes
+}
+
+// DefineHoldingsTool 定义持仓查询工具
+func (t *Tools) DefineHoldingsTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, HoldingsToolName, `Read the user's real portfolio holdings and cash from the local portfolio.
+
+用户询问自己的持仓、仓位、账户资产时使用该工具，不要让用户重复粘贴持仓。
+
+以 JSON 格式输入：
+`+commonInputDesc+`
+
+输出：
+- **baseCurrency**: 基准货币
+- **holdings**: 持仓 Markdown 表格，包含账户、代码、名称、市场、行业、数量、平均成本、价格、市值、占总资产比例
+- **cash**: 现金 Markdown 表格
+- **marketValue** / **cashTotal** / **totalValue**: 基准货币的持仓市值、现金和总资产
+- **missingPrices**: 缺少价格的证券，其市值按成本估算
+- **unconvertedCurrencies**: 缺少汇率无法换算的货币，相关金额未计入汇总
+`,
+		func(ctx *ai.ToolContext, in CommonInput) (HoldingsOutput, error) {
+			a, err := t.analyze(ctx, in)
+			if err != nil {
+				return HoldingsOutput{}, err
+			}
+
+			rows := make([][]string, 0, len(a.Holdings))
+			for _, h := range a.Holdings {
+				rows = append(rows, []string{
+					h.Account, h.Symbol, h.Name, h.Market, h.Sector, h.Currency,
+					h.Quantity.String(), money(h.AvgCost), priceString(h), money(h.MarketValue), pct(h.Weight),
+				})
+			}
+			cashRows := make([][]string, 0, len(a.Cash))
+			for _, c := range a.Cash {
+				cashRows = append(cashRows, []string{c.Account, c.Currency, money(c.Amount), pct(c.Weight)})
+			}
+
+			out := HoldingsOutput{
+				BaseCurrency: a.BaseCurrency,
+				Holdings: Table([]string{
+					"Account", "Symbol", "Name", "Market", "Sector", "Currency",
+					"Quantity", "Avg Cost", "Price", "Market Value", "Weight",
+				}, rows),
+				MarketValue: money(a.MarketValue),
+				CashTotal:   money(a.CashTotal),
+				TotalValue:  money(a.TotalValue),
+				Notes:       notesOf(a),
+			}
+			if len(cashRows) > 0 {
+				out.Cash = Table([]string{"Account", "Currency", "Amount", "Weight"}, cashRows)
+			}
+			return out, nil
+		},
+	)
+}
+
+// PnLOutput 盈亏计算输出
+type PnLOutput struct {
+	BaseCurrency string `json:"baseCurrency"`
+	// 各持仓盈亏表格
+	Table string `json:"table"`
+	// 持仓成本
+	CostBasis string `json:"costBasis"`
+	// 持仓市值
+	MarketValue string `json:"marketValue"`
+	// 浮动盈亏
+	UnrealizedPnL string `json:"unrealizedPnL"`
+	// 浮动盈亏百分比
+	UnrealizedPnLPercent string `json:"unrealizedPnLPercent"`
+	// 已实现盈亏
+	RealizedPnL string `json:"realizedPnL"`
+	// 分红利息收入
+	Income string `json:"income"`
+	Notes
+}
+
+// DefinePnLTool 定义盈亏计算工具
+func (t *Tools) DefinePnLTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, PnLToolName, `Compute profit and loss of the user's real portfolio.
+
+成本按移动加权平均法计算，已实现盈亏和分红来自通过 nfa portfolio 导入的交易记录。
+
+以 JSON 格式输入：
+`+commonInputDesc+`
+
+输出：
+- **baseCurrency**: 基准货币
+- **table**: 各持仓盈亏 Markdown 表格，包含成本、市值、浮动盈亏及百分比、已实现盈亏、分红利息（各持仓货币）
+- **costBasis** / **marketValue** / **unrealizedPnL** / **unrealizedPnLPercent**: 基准货币的持仓成本、市值、浮动盈亏及百分比
+- **realizedPnL** / **income**: 基准货币的已实现盈亏、分红利息收入
+- **missingPrices** / **unconvertedCurrencies**: 同 PortfolioHoldings
+`,
+		func(ctx *ai.ToolContext, in CommonInput) (PnLOutput, error) {
+			a, err := t.analyze(ctx, in)
+			if err != nil {
+				return PnLOutput{}, err
+			}
+
+			rows := make([][]string, 0, len(a.Holdings))
+			for _, h := range a.Holdings {
+				rows = append(rows, []string{
+					h.Account, h.Symbol, h.Currency, money(h.CostBasis), priceString(h), money(h.MarketValue),
+					money(h.UnrealizedPnL), pct(h.UnrealizedPnLPercent), money(h.RealizedPnL), money(h.Income),
+				})
+			}
+			unrealizedPercent := decimal.Zero
+			if !a.CostBasis.IsZero() {
+				unrealizedPercent = a.UnrealizedPnL.Div(a.CostBasis.Abs()).Mul(decimal.NewFromInt(100)).Round(2)
+			}
+			return PnLOutput{
+				BaseCurrency: a.BaseCurrency,
+				Table: Table([]string{
+					"Account", "Symbol", "Currency", "Cost", "Price", "Market Value",
+					"Unrealized P&L", "Unrealized %", "Realized P&L", "Income",
+				}, rows),
+				CostBasis:            money(a.CostBasis),
+				MarketValue:          money(a.MarketValue),
+				UnrealizedPnL:        money(a.UnrealizedPnL),
+				UnrealizedPnLPercent: pct(unrealizedPercent),
+				RealizedPnL:          money(a.RealizedPnL),
+				Income:               money(a.Income),
+				Notes:                notesOf(a),
+			}, nil
+		},
+	)
+}
+
+// ExposureInput 暴露分析输入
+type ExposureInput struct {
+	CommonInput
+	// 维度
+	By string `json:"by"`
+	// 是否包含现金
+	IncludeCash bool `json:"includeCash,omitempty"`
+}
+
+// ExposureOutput 暴露分析输出
+type ExposureOutput struct {
+	BaseCurrency string `json:"baseCurrency"`
+	// 维度
+	By string `json:"by"`
+	// 暴露 Markdown 表格
+	Table string `json:"table"`
+	// 百分比的分母
+	Total string `json:"total"`
+	Notes
+}
+
+// DefineExposureTool 定义暴露分析工具
+func (t *Tools) DefineExposureTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, ExposureToolName, `Compute the user's real portfolio exposure grouped by sector, market, currency, asset class, account or symbol.
+
+用于回答“我在半导体上的仓位有多重”、“美股占比多少”、“美元资产占比多少”等问题。
+
+以 JSON 格式输入：
+- **by**: (string,required) 分组维度，可选 sector, market, currency, assetClass, account, symbol
+- **includeCash**: (bool,optional) 是否将现金计入（单独成行并计入分母），默认 false ，即按持仓市值计算占比
+`+commonInputDesc+`
+
+输出：
+- **baseCurrency**: 基准货币
+- **by**: 分组维度
+- **table**: Markdown 表格，包含分类、基准货币市值、占比和包含的证券
+- **total**: 计算占比使用的分母
+- **missingPrices** / **unconvertedCurrencies**: 同 PortfolioHoldings
+
+行业等分类来自用户导入或通过 nfa portfolio tag 设置的信息，未设置的分类为 unknown 。
+`,
+		func(ctx *ai.ToolContext, in ExposureInput) (ExposureOutput, error) {
+			by, err := portfolio.ParseDimension(in.By)
+			if err != nil {
+				return ExposureOutput{}, err
+			}
+			a, err := t.analyze(ctx, in.CommonInput)
+			if err != nil {
+				return ExposureOutput{}, err
+			}
+
+			rows := a.Exposure(by, in.IncludeCash)
+			table := make([][]string, 0, len(rows))
+			for _, row := range rows {
+				table = append(table, []string{row.Key, money(row.Value), pct(row.Weight), strings.Join(row.Symbols, ", ")})
+			}
+			total := a.MarketValue
+			if in.IncludeCash {
+				total = a.TotalValue
+			}
+			return ExposureOutput{
+				BaseCurrency: a.BaseCurrency,
+				By:           string(by),
+				Table:        Table([]string{string(by), "Value", "Weight", "Symbols"}, table),
+				Total:        money(total),
+				Notes:        notesOf(a),
+			}, nil
+		},
+	)
+}
+
+// ConcentrationInput 集中度分析输入
+type ConcentrationInput struct {
+	CommonInput
+	// 列出前 N 大持仓
+	Top int `json:"top,omitempty"`
+}
+
+// ConcentrationOutput 集中度分析输出
+type ConcentrationOutput struct {
+	BaseCurrency string `json:"baseCurrency"`
+	// 持仓证券数
+	Positions int `json:"positions"`
+	// 前 N 大持仓 Markdown 表格
+	Top string `json:"top"`
+	// 前 N 大持仓合计占比
+	TopWeight string `json:"topWeight"`
+	// 赫芬达尔指数
+	HHI string `json:"hhi"`
+	// 有效持仓数
+	EffectiveN string `json:"effectiveN"`
+	Notes
+}
+
+// DefineConcentrationTool 定义集中度分析工具
+func (t *Tools) DefineConcentrationTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, ConcentrationToolName, `Compute concentration of the user's real portfolio.
+
+同一证券在多个账户的持仓合并计算，不含现金。
+
+以 JSON 格式输入：
+- **top**: (int,optional) 列出前 N 大持仓，默认 5
+`+commonInputDesc+`
+
+输出：
+- **baseCurrency**: 基准货币
+- **positions**: 持仓证券数
+- **top**: 前 N 大持仓 Markdown 表格，包含基准货币市值和占持仓市值的比例
+- **topWeight**: 前 N 大持仓合计占比
+- **hhi**: 赫芬达尔指数，各持仓占比的平方和，越接近 1 越集中
+- **effectiveN**: 有效持仓数（ 1/HHI ）
+- **missingPrices** / **unconvertedCurrencies**: 同 PortfolioHoldings
+`,
+		func(ctx *ai.ToolContext, in ConcentrationInput) (ConcentrationOutput, error) {
+			a, err := t.analyze(ctx, in.CommonInput)
+			if err != nil {
+				return ConcentrationOutput{}, err
+			}
+			top := in.Top
+			if top <= 0 {
+				top = 5
+			}
+
+			c := a.Concentration(top)
+			rows := make([][]string, 0, len(c.Top))
+			for _, row := range c.Top {
+				rows = append(rows, []string{row.Key, money(row.Value), pct(row.Weight)})
+			}
+			return ConcentrationOutput{
+				BaseCurrency: a.BaseCurrency,
+				Positions:    c.Positions,
+				Top:          Table([]string{"Symbol", "Value", "Weight"}, rows),
+				TopWeight:    pct(c.TopWeight),
+				HHI:          c.HHI.String(),
+				EffectiveN:   c.EffectiveN.String(),
+				Notes:        notesOf(a),
+			}, nil
+		},
+	)
+}
+
+// Table 生成 Markdown 表格
+func Table(header []string, rows [][]string) string {
+	buf := &strings.Builder{}
+	buf.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	buf.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		for i, v := range row {
+			if v == "" {
+				row[i] = "-"
+			}
+		}
+		buf.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	return buf.String()
+}
+
+// money 格式化金额，保留 2 位小数
+func money(d decimal.Decimal) string {
+	return d.StringFixed(2)
+}
+
+// pct 格式化百分比
+func pct(d decimal.Decimal) string {
+	return d.StringFixed(2) + "%"
+}
+
+// priceString 格式化持仓价格，缺少价格时标注
+func priceString(h portfolio.Holding) string {
+	if h.PriceMissing {
+		return "n/a"
+	}
+	return h.Price.String()
+}
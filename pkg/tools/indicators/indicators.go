@@ -0,0 +1,329 @@
+package indicators
+
+import (
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// Series 指标序列，与 K 线一一对应，数据不足时对应位置无效
+type Series []decimal.NullDecimal
+
+// Last 最后一个值
+func (s Series) Last() decimal.NullDecimal {
+	if len(s) == 0 {
+		return decimal.NullDecimal{}
+	}
+	return s[len(s)-1]
+}
+
+var (
+	two     = decimal.NewFromInt(2)
+	three   = decimal.NewFromInt(3)
+	hundred = decimal.NewFromInt(100)
+)
+
+// valid 返回有效值
+func valid(d decimal.Decimal) decimal.NullDecimal {
+	return decimal.NullDecimal{Decimal: d, Valid: true}
+}
+
+// closes 收盘价序列
+func closes(bars []tools.Bar) []decimal.Decimal {
+	ret := make([]decimal.Decimal, len(bars))
+	for i, bar := range bars {
+		ret[i] = bar.Close
+	}
+	return ret
+}
+
+// SMA 收盘价的简单移动平均
+func SMA(bars []tools.Bar, period int) Series {
+	return sma(closes(bars), period)
+}
+
+// sma 简单移动平均
+func sma(values []decimal.Decimal, period int) Series {
+	ret := make(Series, len(values))
+	if period <= 0 {
+		return ret
+	}
+	n := decimal.NewFromInt(int64(period))
+	sum := decimal.Zero
+	for i, v := range values {
+		sum = sum.Add(v)
+		if i >= period {
+			sum = sum.Sub(values[i-period])
+		}
+		if i >= period-1 {
+			ret[i] = valid(sum.Div(n))
+		}
+	}
+	return ret
+}
+
+// EMA 收盘价的指数移动平均，以前 period 个值的简单平均作为初值
+func EMA(bars []tools.Bar, period int) Series {
+	return ema(closes(bars), period)
+}
+
+// ema 指数移动平均
+func ema(values []decimal.Decimal, period int) Series {
+	ret := make(Series, len(values))
+	if period <= 0 || len(values) < period {
+		return ret
+	}
+	k := two.Div(decimal.NewFromInt(int64(period + 1)))
+	prev := sma(values[:period], period)[period-1].Decimal
+	ret[period-1] = valid(prev)
+	for i := period; i < len(values); i++ {
+		prev = values[i].Sub(prev).Mul(k).Add(prev)
+		ret[i] = valid(prev)
+	}
+	return ret
+}
+
+// RSI 相对强弱指数，使用 Wilder 平滑
+func RSI(bars []tools.Bar, period int) Series {
+	ret := make(Series, len(bars))
+	if period <= 0 || len(bars) <= period {
+		return ret
+	}
+	n := decimal.NewFromInt(int64(period))
+	m := decimal.NewFromInt(int64(period - 1))
+
+	avgGain, avgLoss := decimal.Zero, decimal.Zero
+	for i := 1; i < len(bars); i++ {
+		change := bars[i].Close.Sub(bars[i-1].Close)
+		gain, loss := decimal.Zero, decimal.Zero
+		if change.IsPositive() {
+			gain = change
+		} else {
+			loss = change.Neg()
+		}
+
+		switch {
+		case i < period:
+			avgGain, avgLoss = avgGain.Add(gain), avgLoss.Add(loss)
+			continue
+		case i == period:
+			avgGain, avgLoss = avgGain.Add(gain).Div(n), avgLoss.Add(loss).Div(n)
+		default:
+			avgGain = avgGain.Mul(m).Add(gain).Div(n)
+			avgLoss = avgLoss.Mul(m).Add(loss).Div(n)
+		}
+
+		if avgLoss.IsZero() {
+			ret[i] = valid(hundred)
+			continue
+		}
+		rs := avgGain.Div(avgLoss)
+		ret[i] = valid(hundred.Sub(hundred.Div(decimal.NewFromInt(1).Add(rs))))
+	}
+	return ret
+}
+
+// MACDResult MACD 指标
+type MACDResult struct {
+	// 快线与慢线 EMA 之差
+	MACD Series
+	// MACD 的 EMA
+	Signal Series
+	// MACD 与 Signal 之差
+	Histogram Series
+}
+
+// MACD 指数平滑异同移动平均线
+func MACD(bars []tools.Bar, fast, slow, signal int) MACDResult {
+	fastEMA := EMA(bars, fast)
+	slowEMA := EMA(bars, slow)
+	ret := MACDResult{
+		MACD:      make(Series, len(bars)),
+		Signal:    make(Series, len(bars)),
+		Histogram: make(Series, len(bars)),
+	}
+
+	start := -1
+	var macd []decimal.Decimal
+	for i := range bars {
+		if !fastEMA[i].Valid || !slowEMA[i].Valid {
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		v := fastEMA[i].Decimal.Sub(slowEMA[i].Decimal)
+		ret.MACD[i] = valid(v)
+		macd = append(macd, v)
+	}
+	if start < 0 {
+		return ret
+	}
+
+	signalEMA := ema(macd, signal)
+	for i, v := range signalEMA {
+		if !v.Valid {
+			continue
+		}
+		ret.Signal[start+i] = v
+		ret.Histogram[start+i] = valid(macd[i].Sub(v.Decimal))
+	}
+	return ret
+}
+
+// BollingerResult 布林带
+type BollingerResult struct {
+	Upper  Series
+	Middle Series
+	Lower  Series
+}
+
+// Bollinger 布林带，中轨为 period 日简单移动平均，上下轨为中轨加减 k 倍总体标准差
+func Bollinger(bars []tools.Bar, period int, k decimal.Decimal) BollingerResult {
+	values := closes(bars)
+	middle := sma(values, period)
+	ret := BollingerResult{
+		Upper:  make(Series, len(bars)),
+		Middle: middle,
+		Lower:  make(Series, len(bars)),
+	}
+	n := decimal.NewFromInt(int64(period))
+	for i := range values {
+		if !middle[i].Valid {
+			continue
+		}
+		mean := middle[i].Decimal
+		variance := decimal.Zero
+		for _, v := range values[i-period+1 : i+1] {
+			diff := v.Sub(mean)
+			variance = variance.Add(diff.Mul(diff))
+		}
+		variance = variance.Div(n)
+		// decimal 不支持开方，标准差使用 float64 计算
+		std := decimal.NewFromFloat(math.Sqrt(variance.InexactFloat64()))
+		ret.Upper[i] = valid(mean.Add(std.Mul(k)))
+		ret.Lower[i] = valid(mean.Sub(std.Mul(k)))
+	}
+	return ret
+}
+
+// TrueRange 真实波幅，第一根 K 线为最高价与最低价之差
+func TrueRange(bars []tools.Bar) []decimal.Decimal {
+	ret := make([]decimal.Decimal, len(bars))
+	for i, bar := range bars {
+		tr := bar.High.Sub(bar.Low)
+		if i > 0 {
+			prevClose := bars[i-1].Close
+			tr = decimal.Max(tr, bar.High.Sub(prevClose).Abs(), bar.Low.Sub(prevClose).Abs())
+		}
+		ret[i] = tr
+	}
+	return ret
+}
+
+// ATR 平均真实波幅，使用 Wilder 平滑
+func ATR(bars []tools.Bar, period int) Series {
+	ret := make(Series, len(bars))
+	if period <= 0 || len(bars) < period {
+		return ret
+	}
+	tr := TrueRange(bars)
+	n := decimal.NewFromInt(int64(period))
+	m := decimal.NewFromInt(int64(period - 1))
+	prev := sma(tr[:period], period)[period-1].Decimal
+	ret[period-1] = valid(prev)
+	for i := period; i < len(bars); i++ {
+		prev = prev.Mul(m).Add(tr[i]).Div(n)
+		ret[i] = valid(prev)
+	}
+	return ret
+}
+
+// VWAP 从序列开始累计的成交量加权平均价，使用典型价格 (H+L+C)/3 ，累计成交量为 0 时无效
+func VWAP(bars []tools.Bar) Series {
+	ret := make(Series, len(bars))
+	pv, volume := decimal.Zero, decimal.Zero
+	for i, bar := range bars {
+		typical := bar.High.Add(bar.Low).Add(bar.Close).Div(three)
+		pv = pv.Add(typical.Mul(bar.Volume))
+		volume = volume.Add(bar.Volume)
+		if !volume.IsZero() {
+			ret[i] = valid(pv.Div(volume))
+		}
+	}
+	return ret
+}
+
+// PivotPoints 经典枢轴点
+type PivotPoints struct {
+	P  decimal.Decimal `json:"p"`
+	R1 decimal.Decimal `json:"r1"`
+	R2 decimal.Decimal `json:"r2"`
+	R3 decimal.Decimal `json:"r3"`
+	S1 decimal.Decimal `json:"s1"`
+	S2 decimal.Decimal `json:"s2"`
+	S3 decimal.Decimal `json:"s3"`
+}
+
+// Pivot 根据一根 K 线计算经典枢轴点，作为下一周期的支撑位和阻力位
+func Pivot(bar tools.Bar) PivotPoints {
+	h, l, c := bar.High, bar.Low, bar.Close
+	p := h.Add(l).Add(c).Div(three)
+	r := h.Sub(l)
+	return PivotPoints{
+		P:  p,
+		R1: p.Mul(two).Sub(l),
+		S1: p.Mul(two).Sub(h),
+		R2: p.Add(r),
+		S2: p.Sub(r),
+		R3: h.Add(p.Sub(l).Mul(two)),
+		S3: l.Sub(h.Sub(p).Mul(two)),
+	}
+}
+
+// DrawdownResult 回撤统计
+type DrawdownResult struct {
+	// 各 K 线收盘价相对此前最高收盘价的回撤（百分比，非正数）
+	Series Series `json:"-"`
+	// 最大回撤（百分比，非正数）
+	Max decimal.Decimal `json:"max"`
+	// 最大回撤开始时的高点
+	PeakTime  time.Time       `json:"peakTime"`
+	PeakClose decimal.Decimal `json:"peakClose"`
+	// 最大回撤的最低点
+	TroughTime  time.Time       `json:"troughTime"`
+	TroughClose decimal.Decimal `json:"troughClose"`
+	// 当前回撤（百分比，非正数）
+	Current decimal.Decimal `json:"current"`
+}
+
+// Drawdown 计算收盘价回撤
+func Drawdown(bars []tools.Bar) DrawdownResult {
+	ret := DrawdownResult{Series: make(Series, len(bars))}
+	if len(bars) == 0 {
+		return ret
+	}
+	peak := 0
+	maxPeak, maxTrough := 0, 0
+	for i, bar := range bars {
+		if bar.Close.GreaterThan(bars[peak].Close) {
+			peak = i
+		}
+		if bars[peak].Close.IsZero() {
+			continue
+		}
+		dd := bar.Close.Sub(bars[peak].Close).Div(bars[peak].Close).Mul(hundred)
+		ret.Series[i] = valid(dd)
+		if dd.LessThan(ret.Max) {
+			ret.Max = dd
+			maxPeak, maxTrough = peak, i
+		}
+	}
+	ret.PeakTime, ret.PeakClose = bars[maxPeak].Time, bars[maxPeak].Close
+	ret.TroughTime, ret.TroughClose = bars[maxTrough].Time, bars[maxTrough].Close
+	ret.Current = ret.Series.Last().Decimal
+	return ret
+}
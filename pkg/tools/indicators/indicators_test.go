@@ -0,0 +1,155 @@
+package indicators
+
+import (
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// newBars 根据收盘价创建 K 线
+func newBars(closes ...float64) []tools.Bar {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := make([]tools.Bar, len(closes))
+	for i, c := range closes {
+		d := decimal.NewFromFloat(c)
+		bars[i] = tools.Bar{Time: start.AddDate(0, 0, i), Open: d, High: d, Low: d, Close: d}
+	}
+	return bars
+}
+
+// values 将序列转换为字符串，无效值为 -
+func values(s Series) []string {
+	ret := make([]string, len(s))
+	for i, v := range s {
+		ret[i] = formatValue(v)
+	}
+	return ret
+}
+
+func TestMovingAverages(t *testing.T) {
+	bars := newBars(1, 2, 3, 4, 5)
+	assert.Equal(t, []string{"-", "-", "2", "3", "4"}, values(SMA(bars, 3)))
+	assert.Equal(t, []string{"-", "-", "2", "3", "4"}, values(EMA(bars, 3)))
+	assert.Equal(t, []string{"-", "1.5", "2.5", "3.5", "4.5"}, values(EMA(bars, 2)))
+	assert.Equal(t, []string{"-", "-", "-", "-", "-"}, values(SMA(bars, 6)))
+}
+
+func TestRSI(t *testing.T) {
+	bars := newBars(1, 2, 3, 2, 3)
+	assert.Equal(t, []string{"-", "-", "100", "50", "75"}, values(RSI(bars, 2)))
+}
+
+func TestMACD(t *testing.T) {
+	bars := newBars(1, 2, 3, 4, 5)
+	macd := MACD(bars, 2, 3, 2)
+	assert.Equal(t, []string{"-", "-", "0.5", "0.5", "0.5"}, values(macd.MACD))
+	assert.Equal(t, []string{"-", "-", "-", "0.5", "0.5"}, values(macd.Signal))
+	assert.Equal(t, []string{"-", "-", "-", "0", "0"}, values(macd.Histogram))
+}
+
+func TestBollinger(t *testing.T) {
+	bars := newBars(1, 2, 3, 4, 5)
+	bb := Bollinger(bars, 3, decimal.NewFromInt(2))
+	assert.Equal(t, []string{"-", "-", "2", "3", "4"}, values(bb.Middle))
+	// 总体标准差 sqrt(2/3)
+	assert.Equal(t, "3.633", formatValue(bb.Upper[2]))
+	assert.Equal(t, "0.367", formatValue(bb.Lower[2]))
+}
+
+func TestATRAndVWAP(t *testing.T) {
+	bars := []tools.Bar{
+		{High: decimal.NewFromInt(10), Low: decimal.NewFromInt(8), Close: decimal.NewFromInt(9), Volume: decimal.NewFromInt(100)},
+		{High: decimal.NewFromInt(11), Low: decimal.NewFromInt(9), Close: decimal.NewFromInt(10), Volume: decimal.NewFromInt(300)},
+		{High: decimal.NewFromInt(13), Low: decimal.RequireFromString("10.5"), Close: decimal.NewFromInt(11)},
+	}
+	assert.Equal(t, []string{"2", "2", "3"}, decimalStrings(TrueRange(bars)))
+	assert.Equal(t, []string{"-", "2", "2.5"}, values(ATR(bars, 2)))
+	assert.Equal(t, []string{"9", "9.75", "9.75"}, values(VWAP(bars)))
+}
+
+// decimalStrings 将 decimal 列表转换为字符串
+func decimalStrings(ds []decimal.Decimal) []string {
+	ret := make([]string, len(ds))
+	for i, d := range ds {
+		ret[i] = d.String()
+	}
+	return ret
+}
+
+func TestPivot(t *testing.T) {
+	p := Pivot(tools.Bar{High: decimal.NewFromInt(12), Low: decimal.NewFromInt(8), Close: decimal.NewFromInt(10)})
+	for name, c := range map[string][2]decimal.Decimal{
+		"P":  {p.P, decimal.NewFromInt(10)},
+		"R1": {p.R1, decimal.NewFromInt(12)},
+		"R2": {p.R2, decimal.NewFromInt(14)},
+		"R3": {p.R3, decimal.NewFromInt(16)},
+		"S1": {p.S1, decimal.NewFromInt(8)},
+		"S2": {p.S2, decimal.NewFromInt(6)},
+		"S3": {p.S3, decimal.NewFromInt(4)},
+	} {
+		assert.True(t, c[0].Equal(c[1]), "%s: expected %s, got %s", name, c[1], c[0])
+	}
+}
+
+func TestDrawdown(t *testing.T) {
+	dd := Drawdown(newBars(10, 12, 9, 11, 6, 8))
+	assert.Equal(t, "-50", dd.Max.String())
+	assert.Equal(t, "2024-01-02", tools.FormatBarTime(dd.PeakTime))
+	assert.Equal(t, "2024-01-05", tools.FormatBarTime(dd.TroughTime))
+	assert.Equal(t, "-33.3333", round(dd.Current).String())
+}
+
+func TestCompute(t *testing.T) {
+	bars := newBars(1, 2, 3, 4, 5)
+	out, err := Compute(bars, "inline data", []string{"sma:3", "pivot"}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 5, out.Bars)
+	assert.Equal(t, "2024-01-01", out.From)
+	assert.Equal(t, "2024-01-05", out.To)
+	assert.Equal(t, map[string]string{"SMA(3)": "4"}, out.Latest)
+	require.NotNil(t, out.Pivot)
+	assert.Equal(t, "5", out.Pivot.P.String())
+	assert.Equal(t, `| Time | Open | High | Low | Close | Volume | SMA(3) |
+| --- | --- | --- | --- | --- | --- | --- |
+| 2024-01-04 | 4 | 4 | 4 | 4 | 0 | 3 |
+| 2024-01-05 | 5 | 5 | 5 | 5 | 0 | 4 |
+`, out.Table)
+
+	_, err = Compute(bars, "", []string{"foo"}, 0)
+	assert.Error(t, err)
+	_, err = Compute(bars, "", []string{"sma:x"}, 0)
+	assert.Error(t, err)
+}
+
+func TestLoadBars(t *testing.T) {
+	store := tools.NewResultStore()
+	store.Add("1", "TIME_SERIES_DAILY", map[string]any{
+		"content": []any{map[string]any{"type": "text", "text": "date,close\n2024-01-02,2\n2024-01-01,1\n"}},
+	})
+	store.Add("2", "WebSearch", map[string]any{"results": "nothing"})
+	ctx := &ai.ToolContext{Context: tools.ContextWithResultStore(t.Context(), store)}
+
+	for _, input := range []Input{{}, {ResultRef: "1"}, {ResultOf: "TIME_SERIES_DAILY"}} {
+		bars, source, err := loadBars(ctx, input)
+		require.NoError(t, err)
+		assert.Equal(t, "tool TIME_SERIES_DAILY (ref 1)", source)
+		require.Len(t, bars, 2)
+		assert.Equal(t, "1", bars[0].Close.String())
+	}
+
+	_, _, err := loadBars(ctx, Input{ResultRef: "2"})
+	assert.Error(t, err)
+	_, _, err = loadBars(ctx, Input{ResultOf: "Foo"})
+	assert.Error(t, err)
+
+	bars, source, err := loadBars(ctx, Input{Data: "date,close\n2024-01-01,3\n"})
+	require.NoError(t, err)
+	assert.Equal(t, "inline data", source)
+	assert.Len(t, bars, 1)
+}
@@ -0,0 +1,315 @@
+package indicators
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+const (
+	// IndicatorsToolName 技术指标计算工具名
+	IndicatorsToolName = "Indicators"
+	// DefaultRows 默认输出表格行数
+	DefaultRows = 10
+	// MaxRows 最大输出表格行数
+	MaxRows = 250
+	// 输出数值保留的小数位数
+	outputPlaces = 4
+)
+
+// 支持的指标
+const (
+	IndicatorSMA       = "sma"
+	IndicatorEMA       = "ema"
+	IndicatorRSI       = "rsi"
+	IndicatorMACD      = "macd"
+	IndicatorBollinger = "bb"
+	IndicatorATR       = "atr"
+	IndicatorVWAP      = "vwap"
+	IndicatorPivot     = "pivot"
+	IndicatorDrawdown  = "drawdown"
+)
+
+// DefaultIndicators 未指定指标时计算的指标
+var DefaultIndicators = []string{"sma:5", "sma:20", "rsi:14", "macd:12,26,9", "bb:20,2", "atr:14", "pivot", "drawdown"}
+
+// Input 技术指标计算输入
+type Input struct {
+	// 引用的工具调用结果的 ref
+	ResultRef string `json:"resultRef,omitempty"`
+	// 引用指定工具最近一次的调用结果
+	ResultOf string `json:"resultOf,omitempty"`
+	// 直接提供的 K 线数据，对象数组、数组的数组或 CSV 文本
+	Data any `json:"data,omitempty"`
+	// 要计算的指标，如 sma:20, ema:12, rsi:14, macd:12,26,9, bb:20,2, atr:14, vwap, pivot, drawdown
+	Indicators []string `json:"indicators,omitempty"`
+	// 输出表格的行数（最近的 N 根 K 线）
+	Rows int `json:"rows,omitempty"`
+}
+
+// Output 技术指标计算输出
+type Output struct {
+	// 数据来源
+	Source string `json:"source"`
+	// K 线数量
+	Bars int `json:"bars"`
+	// 数据起止时间
+	From string `json:"from"`
+	To   string `json:"to"`
+	// 最近 N 根 K 线及指标的 Markdown 表格
+	Table string `json:"table"`
+	// 最后一根 K 线的各指标值
+	Latest map[string]string `json:"latest,omitempty"`
+	// 根据最后一根 K 线计算的枢轴点
+	Pivot *PivotPoints `json:"pivot,omitempty"`
+	// 回撤统计
+	Drawdown *DrawdownOutput `json:"drawdown,omitempty"`
+}
+
+// DrawdownOutput 回撤统计输出
+type DrawdownOutput struct {
+	// 最大回撤（%）
+	Max string `json:"maxPercent"`
+	// 最大回撤的高点
+	Peak string `json:"peak"`
+	// 最大回撤的低点
+	Trough string `json:"trough"`
+	// 当前回撤（%）
+	Current string `json:"currentPercent"`
+}
+
+// spec 指标规格
+type spec struct {
+	name string
+	args []decimal.Decimal
+}
+
+// arg 获取第 i 个参数，未指定时返回默认值
+func (s spec) arg(i int, def decimal.Decimal) decimal.Decimal {
+	if i < len(s.args) {
+		return s.args[i]
+	}
+	return def
+}
+
+// intArg 获取第 i 个整数参数，未指定时返回默认值
+func (s spec) intArg(i int, def int64) int {
+	return int(s.arg(i, decimal.NewFromInt(def)).IntPart())
+}
+
+// parseSpec 解析形如 name:arg1,arg2 的指标规格
+func parseSpec(s string) (spec, error) {
+	name, argsStr, _ := strings.Cut(strings.TrimSpace(s), ":")
+	ret := spec{name: strings.ToLower(strings.TrimSpace(name))}
+	switch ret.name {
+	case "boll", "bollinger":
+		ret.name = IndicatorBollinger
+	case IndicatorSMA, IndicatorEMA, IndicatorRSI, IndicatorMACD, IndicatorBollinger,
+		IndicatorATR, IndicatorVWAP, IndicatorPivot, IndicatorDrawdown:
+	default:
+		return spec{}, fmt.Errorf("unknown indicator %q", s)
+	}
+	if argsStr == "" {
+		return ret, nil
+	}
+	for _, a := range strings.Split(argsStr, ",") {
+		d, err := decimal.NewFromString(strings.TrimSpace(a))
+		if err != nil || !d.IsPositive() {
+			return spec{}, fmt.Errorf("invalid argument %q of indicator %q", a, s)
+		}
+		ret.args = append(ret.args, d)
+	}
+	return ret, nil
+}
+
+// column 表格中的一列
+type column struct {
+	name   string
+	values Series
+}
+
+// DefineTool 定义技术指标计算工具
+func DefineTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, IndicatorsToolName, `Compute technical indicators from an OHLCV (K-line) series deterministically.
+
+需要支撑位、阻力位、均线、超买超卖等判断时，应使用该工具计算，而不是自行心算。
+
+以 JSON 格式输入：
+- **resultRef**: (string,optional) 引用当前对话中某次工具调用结果的 ref
+- **resultOf**: (string,optional) 引用当前对话中指定工具最近一次的调用结果，如 TIME_SERIES_DAILY
+- **data**: (any,optional) 直接提供的 K 线数据，可以是 [{"date":"2024-01-02","open":1,"high":2,"low":0.5,"close":1.5,"volume":100}] 形式的对象数组、 [[time,open,high,low,close,volume]] 形式的数组或带表头的 CSV 文本
+- **indicators**: (string[],optional) 要计算的指标，格式为 name:参数 ，默认为 ["sma:5","sma:20","rsi:14","macd:12,26,9","bb:20,2","atr:14","pivot","drawdown"]
+  - sma:N / ema:N 收盘价 N 日简单/指数移动平均
+  - rsi:N N 日相对强弱指数（ Wilder 平滑）
+  - macd:FAST,SLOW,SIGNAL MACD 线、信号线和柱
+  - bb:N,K 布林带，N 日均线加减 K 倍标准差
+  - atr:N N 日平均真实波幅
+  - vwap 从序列开始累计的成交量加权平均价
+  - pivot 根据最后一根 K 线计算的经典枢轴点（ P, R1-R3, S1-S3 ）
+  - drawdown 收盘价回撤（%）及最大回撤
+- **rows**: (int,optional) 输出表格包含最近多少根 K 线，默认 10 ，最大 250
+
+resultRef 、 resultOf 和 data 都未指定时，使用当前对话中最近一次包含 K 线数据的工具调用结果。
+支持 Alpha Vantage 时间序列、 MCP 文本结果、 JSON 数组和 CSV 等格式，K 线按时间升序排列后计算。
+
+输出：
+- **source**: 数据来源
+- **bars**: K 线数量
+- **from** / **to**: 数据起止时间
+- **table**: 最近 N 根 K 线及指标的 Markdown 表格，回答时可直接引用
+- **latest**: 最后一根 K 线的各指标值
+- **pivot**: 枢轴点
+- **drawdown**: 最大回撤、对应高点和低点，以及当前回撤
+`,
+		func(ctx *ai.ToolContext, input Input) (Output, error) {
+			bars, source, err := loadBars(ctx, input)
+			if err != nil {
+				return Output{}, err
+			}
+			return Compute(bars, source, input.Indicators, input.Rows)
+		},
+	)
+}
+
+// loadBars 根据输入加载 K 线
+func loadBars(ctx *ai.ToolContext, input Input) ([]tools.Bar, string, error) {
+	return tools.LoadBars(ctx, tools.BarsRef{
+		ResultRef: input.ResultRef,
+		ResultOf:  input.ResultOf,
+		Data:      input.Data,
+	}, IndicatorsToolName)
+}
+
+// Compute 计算指定的指标
+func Compute(bars []tools.Bar, source string, specs []string, rows int) (Output, error) {
+	if len(bars) == 0 {
+		return Output{}, fmt.Errorf("no bars")
+	}
+	if len(specs) == 0 {
+		specs = DefaultIndicators
+	}
+	switch {
+	case rows <= 0:
+		rows = DefaultRows
+	case rows > MaxRows:
+		rows = MaxRows
+	}
+
+	out := Output{
+		Source: source,
+		Bars:   len(bars),
+		From:   tools.FormatBarTime(bars[0].Time),
+		To:     tools.FormatBarTime(bars[len(bars)-1].Time),
+		Latest: map[string]string{},
+	}
+
+	var columns []column
+	for _, s := range specs {
+		sp, err := parseSpec(s)
+		if err != nil {
+			return Output{}, err
+		}
+		switch sp.name {
+		case IndicatorSMA:
+			n := sp.intArg(0, 20)
+			columns = append(columns, column{name: fmt.Sprintf("SMA(%d)", n), values: SMA(bars, n)})
+		case IndicatorEMA:
+			n := sp.intArg(0, 20)
+			columns = append(columns, column{name: fmt.Sprintf("EMA(%d)", n), values: EMA(bars, n)})
+		case IndicatorRSI:
+			n := sp.intArg(0, 14)
+			columns = append(columns, column{name: fmt.Sprintf("RSI(%d)", n), values: RSI(bars, n)})
+		case IndicatorMACD:
+			fast, slow, signal := sp.intArg(0, 12), sp.intArg(1, 26), sp.intArg(2, 9)
+			macd := MACD(bars, fast, slow, signal)
+			name := fmt.Sprintf("MACD(%d,%d,%d)", fast, slow, signal)
+			columns = append(columns,
+				column{name: name, values: macd.MACD},
+				column{name: name + " signal", values: macd.Signal},
+				column{name: name + " hist", values: macd.Histogram},
+			)
+		case IndicatorBollinger:
+			n, k := sp.intArg(0, 20), sp.arg(1, decimal.NewFromInt(2))
+			bb := Bollinger(bars, n, k)
+			name := fmt.Sprintf("BB(%d,%s)", n, k.String())
+			columns = append(columns,
+				column{name: name + " upper", values: bb.Upper},
+				column{name: name + " middle", values: bb.Middle},
+				column{name: name + " lower", values: bb.Lower},
+			)
+		case IndicatorATR:
+			n := sp.intArg(0, 14)
+			columns = append(columns, column{name: fmt.Sprintf("ATR(%d)", n), values: ATR(bars, n)})
+		case IndicatorVWAP:
+			columns = append(columns, column{name: "VWAP", values: VWAP(bars)})
+		case IndicatorPivot:
+			p := Pivot(bars[len(bars)-1])
+			out.Pivot = &PivotPoints{
+				P:  round(p.P),
+				R1: round(p.R1),
+				R2: round(p.R2),
+				R3: round(p.R3),
+				S1: round(p.S1),
+				S2: round(p.S2),
+				S3: round(p.S3),
+			}
+		case IndicatorDrawdown:
+			dd := Drawdown(bars)
+			columns = append(columns, column{name: "Drawdown(%)", values: dd.Series})
+			out.Drawdown = &DrawdownOutput{
+				Max:     round(dd.Max).String(),
+				Peak:    tools.FormatBarTime(dd.PeakTime) + " " + round(dd.PeakClose).String(),
+				Trough:  tools.FormatBarTime(dd.TroughTime) + " " + round(dd.TroughClose).String(),
+				Current: round(dd.Current).String(),
+			}
+		}
+	}
+
+	for _, c := range columns {
+		out.Latest[c.name] = formatValue(c.values.Last())
+	}
+	out.Table = table(bars, columns, rows)
+	return out, nil
+}
+
+// table 生成最近 rows 根 K 线的 Markdown 表格
+func table(bars []tools.Bar, columns []column, rows int) string {
+	start := max(len(bars)-rows, 0)
+
+	buf := &strings.Builder{}
+	buf.WriteString("| Time | Open | High | Low | Close | Volume |")
+	for _, c := range columns {
+		buf.WriteString(" " + c.name + " |")
+	}
+	buf.WriteString("\n|" + strings.Repeat(" --- |", 6+len(columns)) + "\n")
+	for i := start; i < len(bars); i++ {
+		bar := bars[i]
+		buf.WriteString("| " + tools.FormatBarTime(bar.Time))
+		for _, v := range []decimal.Decimal{bar.Open, bar.High, bar.Low, bar.Close, bar.Volume} {
+			buf.WriteString(" | " + round(v).String())
+		}
+		for _, c := range columns {
+			buf.WriteString(" | " + formatValue(c.values[i]))
+		}
+		buf.WriteString(" |\n")
+	}
+	return buf.String()
+}
+
+// formatValue 格式化指标值，无效值输出 -
+func formatValue(v decimal.NullDecimal) string {
+	if !v.Valid {
+		return "-"
+	}
+	return round(v.Decimal).String()
+}
+
+// round 保留 outputPlaces 位小数
+func round(d decimal.Decimal) decimal.Decimal {
+	return d.Round(outputPlaces)
+}
@@ -0,0 +1,50 @@
+package marketclock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/calendar"
+)
+
+// TestQuery 测试查询市场时钟
+func TestQuery(t *testing.T) {
+	now, err := time.Parse(time.RFC3339, "2026-10-19T10:00:00+08:00")
+	require.NoError(t, err)
+
+	out, err := Query(Input{TradingDaysAgo: 8}, now)
+	require.NoError(t, err)
+	require.Len(t, out.Markets, len(calendar.DefaultMarkets))
+
+	us := out.Markets[0]
+	assert.Equal(t, calendar.NYSE, us.Status.Calendar)
+	// 美东时间 2026-10-18 22:00 周日
+	assert.Equal(t, calendar.PhaseWeekend, us.Status.Phase)
+	assert.Equal(t, "2026-10-16", us.Status.LastTradingDay)
+	assert.Equal(t, "2026-10-07", us.TradingDaysAgo.Date)
+	require.NotEmpty(t, us.UpcomingHolidays)
+	assert.Equal(t, "2026-11-26", us.UpcomingHolidays[0].Date)
+
+	hk := out.Markets[1]
+	assert.Equal(t, calendar.PhaseHoliday, hk.Status.Phase)
+	assert.Equal(t, "2026-10-19", hk.UpcomingHolidays[0].Date)
+
+	cn := out.Markets[2]
+	assert.Equal(t, calendar.PhaseOpen, cn.Status.Phase)
+	// 国庆节休市不计入交易日
+	assert.Equal(t, "2026-09-30", cn.TradingDaysAgo.Date)
+
+	// 日期视为当地收盘后，使用证券代码查询
+	out, err = Query(Input{Markets: []string{"0700.HK"}, At: "2026-10-16"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, calendar.PhaseClosed, out.Markets[0].Status.Phase)
+	assert.Equal(t, "2026-10-16", out.Markets[0].Status.LastTradingDay)
+
+	_, err = Query(Input{Markets: []string{"腾讯"}}, now)
+	assert.Error(t, err)
+	_, err = Query(Input{At: "yesterday"}, now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package marketclock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"github.com/yhlooo/nfa/pkg/calendar"
+)
+
+const (
+	// MarketClockToolName 市场时钟工具名
+	MarketClockToolName = "MarketClock"
+	// maxUpcomingHolidays 输出的即将到来的休市日数量
+	maxUpcomingHolidays = 5
+)
+
+// Input 市场时钟输入
+type Input struct {
+	// 市场、交易所或证券代码，为空时查询默认市场
+	Markets []string `json:"markets,omitempty"`
+	// 查询时刻， RFC3339 格式或 YYYY-MM-DD （视为该日当地时间收盘后），为空时为当前时间
+	At string `json:"at,omitempty"`
+	// 查询之前（不含当天）第 n 个交易日
+	TradingDaysAgo int `json:"tradingDaysAgo,omitempty"`
+}
+
+// Output 市场时钟输出
+type Output struct {
+	Markets []MarketOutput `json:"markets"`
+}
+
+// MarketOutput 单个市场的时钟信息
+type MarketOutput struct {
+	// 原始输入
+	Query  string          `json:"query"`
+	Status calendar.Status `json:"status"`
+	// 即将到来的休市日和半日市
+	UpcomingHolidays []calendar.Holiday `json:"upcomingHolidays,omitempty"`
+	// 之前第 n 个交易日
+	TradingDaysAgo *TradingDaysAgo `json:"tradingDaysAgo,omitempty"`
+}
+
+// TradingDaysAgo 之前第 n 个交易日
+type TradingDaysAgo struct {
+	N    int    `json:"n"`
+	Date string `json:"date"`
+}
+
+// DefineTool 定义市场时钟工具
+func DefineTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, MarketClockToolName, `Check whether markets are open, their next sessions, the last completed trading day and trading days N days ago.
+
+涉及“今天收盘价”、“最新行情”、“N 个交易日前”等与交易日相关的问题，或需要换算美股、港股、 A 股时区时，应先用该工具确认市场状态。
+非交易日（周末、节假日）没有当天收盘价，应使用最近已收盘交易日（ lastTradingDay ）的数据。
+支持的市场： XNYS （美股，纽交所、纳斯达克）、 XHKG （港股）、 XSHG （上交所）、 XSHE （深交所）、 XBSE （北交所）、 CRYPTO （加密货币，全天候交易）。
+
+以 JSON 格式输入：
+- **markets**: (string[],optional) 市场，可以是上述代码、别名（如 NASDAQ 、 HK 、 CN 、 BINANCE ）或证券代码（如 0700.HK 、 600519.SS ），默认 XNYS 、 XHKG 、 XSHG
+- **at**: (string,optional) 查询时刻， RFC3339 格式（如 2025-10-09T10:00:00+08:00 ）或 YYYY-MM-DD （视为该日当地时间收盘后），默认当前时间
+- **tradingDaysAgo**: (int,optional) 同时查询之前（不含当天）第几个交易日的日期
+
+输出：
+- **markets**: 各市场的信息
+  - **query**: 原始输入
+  - **status**: 交易状态，包括交易日历 calendar 、时区 timeZone 、当地时间 localTime 、状态 phase （ open 交易中、 break 午间休市、 preOpen 未开盘、 closed 已收盘、 holiday 节假日休市、 weekend 周末休市）、当天休市日或半日市 holiday 、当天交易时段 sessions 、下次开盘时间 nextOpen 、下次收盘时间 nextClose 、最近已收盘交易日 lastTradingDay 、下一个交易日 nextTradingDay ，以及是否有当年节假日数据 holidaysKnown （为 false 时只排除了周末，结果可能不准确）
+  - **upcomingHolidays**: 即将到来的休市日和半日市（ halfDay ）
+  - **tradingDaysAgo**: 之前第 n 个交易日的日期
+`,
+		func(ctx *ai.ToolContext, in Input) (Output, error) {
+			return Query(in, time.Now())
+		},
+	)
+}
+
+// Query 查询各市场在指定时刻的时钟信息
+func Query(in Input, now time.Time) (Output, error) {
+	if in.TradingDaysAgo < 0 {
+		return Output{}, fmt.Errorf("tradingDaysAgo must not be negative")
+	}
+	markets := in.Markets
+	if len(markets) == 0 {
+		markets = calendar.DefaultMarkets
+	}
+
+	ret := Output{Markets: make([]MarketOutput, 0, len(markets))}
+	for _, m := range markets {
+		c, err := calendar.Get(m)
+		if err != nil {
+			return Output{}, err
+		}
+		t, err := parseTime(in.At, now, c.Location)
+		if err != nil {
+			return Output{}, err
+		}
+
+		out := MarketOutput{
+			Query:            m,
+			Status:           c.Status(t),
+			UpcomingHolidays: upcomingHolidays(c, t),
+		}
+		if in.TradingDaysAgo > 0 {
+			out.TradingDaysAgo = &TradingDaysAgo{
+				N:    in.TradingDaysAgo,
+				Date: c.TradingDaysBefore(t, in.TradingDaysAgo).Format(time.DateOnly),
+			}
+		}
+		ret.Markets = append(ret.Markets, out)
+	}
+	return ret, nil
+}
+
+// parseTime 解析查询时刻，日期视为当天 loc 时区的 23:59
+func parseTime(value string, now time.Time, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return now, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	date, err := time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC3339 or YYYY-MM-DD", value)
+	}
+	return date.Add(23*time.Hour + 59*time.Minute), nil
+}
+
+// upcomingHolidays 返回 t 当天及之后的休市日，包括下一年
+func upcomingHolidays(c *calendar.Calendar, t time.Time) []calendar.Holiday {
+	today := c.Date(t).Format(time.DateOnly)
+	var ret []calendar.Holiday
+	for _, year := range []int{c.Date(t).Year(), c.Date(t).Year() + 1} {
+		for _, h := range c.Holidays(year) {
+			if h.Date < today {
+				continue
+			}
+			if len(ret) == maxUpcomingHolidays {
+				return ret
+			}
+			ret = append(ret, h)
+		}
+	}
+	return ret
+}
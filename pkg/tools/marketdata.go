@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrNotSupported 数据提供商不支持该查询
+var ErrNotSupported = errors.New("not supported by this provider")
+
+// MarketDataProvider 行情数据提供商
+//
+// 不支持的查询返回包装了 ErrNotSupported 的错误，调用方据此尝试其它提供商
+type MarketDataProvider interface {
+	// Name 提供商名
+	Name() string
+	// Quote 查询最新报价
+	Quote(ctx context.Context, symbol string) (Quote, error)
+	// History 查询历史 K 线，按时间升序排列
+	History(ctx context.Context, req HistoryRequest) ([]Bar, error)
+	// Fundamentals 查询基本面数据
+	Fundamentals(ctx context.Context, symbol string) (Fundamentals, error)
+	// CorporateActions 查询 [from, to] 期间的分红、拆合股等公司行动，时间为零值时不限制
+	CorporateActions(ctx context.Context, symbol string, from, to time.Time) ([]CorporateAction, error)
+	// SearchSymbols 根据代码或名称搜索证券
+	SearchSymbols(ctx context.Context, query string) ([]SymbolMatch, error)
+}
+
+// SymbolLister 可以列出所有证券的行情数据提供商，用于刷新证券主数据
+type SymbolLister interface {
+	// ListSymbols 列出所有证券
+	ListSymbols(ctx context.Context) ([]SymbolMatch, error)
+}
+
+// K 线周期
+const (
+	Interval1Min   = "1min"
+	Interval5Min   = "5min"
+	Interval15Min  = "15min"
+	Interval30Min  = "30min"
+	Interval60Min  = "60min"
+	IntervalDaily  = "daily"
+	IntervalWeekly = "weekly"
+	// IntervalMonthly 月线
+	IntervalMonthly = "monthly"
+)
+
+// Intervals 支持的 K 线周期
+var Intervals = []string{
+	Interval1Min, Interval5Min, Interval15Min, Interval30Min, Interval60Min,
+	IntervalDaily, IntervalWeekly, IntervalMonthly,
+}
+
+// HistoryRequest 历史 K 线查询请求
+type HistoryRequest struct {
+	Symbol string
+	// K 线周期，为空时为日线
+	Interval string
+	// 起止时间（含），零值时不限制
+	From time.Time
+	To   time.Time
+	// 是否复权（前复权），提供商不支持时忽略
+	Adjusted bool
+}
+
+// Quote 最新报价
+type Quote struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	// 最新价
+	Price decimal.Decimal `json:"price"`
+	// 当日开盘价、最高价、最低价
+	Open decimal.NullDecimal `json:"open"`
+	High decimal.NullDecimal `json:"high"`
+	Low  decimal.NullDecimal `json:"low"`
+	// 昨收价
+	PrevClose decimal.NullDecimal `json:"prevClose"`
+	// 涨跌额和涨跌幅（%）
+	Change        decimal.NullDecimal `json:"change"`
+	ChangePercent decimal.NullDecimal `json:"changePercent"`
+	// 成交量
+	Volume decimal.NullDecimal `json:"volume"`
+	// 报价时间，未知时为零值
+	Time time.Time `json:"time,omitzero"`
+}
+
+// Complete 根据昨收价补全涨跌额和涨跌幅
+func (q *Quote) Complete() {
+	if !q.PrevClose.Valid || q.PrevClose.Decimal.IsZero() {
+		return
+	}
+	if !q.Change.Valid {
+		q.Change = decimal.NewNullDecimal(q.Price.Sub(q.PrevClose.Decimal))
+	}
+	if !q.ChangePercent.Valid {
+		q.ChangePercent = decimal.NewNullDecimal(q.Change.Decimal.Div(q.PrevClose.Decimal).Mul(decimal.NewFromInt(100)).Round(4))
+	}
+}
+
+// Fundamentals 基本面数据，未知的字段为空
+type Fundamentals struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name,omitempty"`
+	Exchange string `json:"exchange,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Sector   string `json:"sector,omitempty"`
+	Industry string `json:"industry,omitempty"`
+	// 总市值
+	MarketCap decimal.NullDecimal `json:"marketCap"`
+	// 总股本
+	SharesOutstanding decimal.NullDecimal `json:"sharesOutstanding"`
+	// 市盈率（ TTM ）
+	PE decimal.NullDecimal `json:"pe"`
+	// 市净率
+	PB decimal.NullDecimal `json:"pb"`
+	// 每股收益（ TTM ）
+	EPS decimal.NullDecimal `json:"eps"`
+	// 股息率（%）
+	DividendYield decimal.NullDecimal `json:"dividendYield"`
+	// 营业收入（ TTM ）
+	Revenue decimal.NullDecimal `json:"revenue"`
+	// 净利润（ TTM ）
+	NetIncome decimal.NullDecimal `json:"netIncome"`
+	// 净资产收益率（%）
+	ROE decimal.NullDecimal `json:"roe"`
+	// 最近财报期
+	FiscalPeriod string `json:"fiscalPeriod,omitempty"`
+	// 其它字段
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// 公司行动类型
+const (
+	ActionDividend = "dividend"
+	ActionSplit    = "split"
+)
+
+// CorporateAction 公司行动
+type CorporateAction struct {
+	// 类型， dividend 或 split
+	Type string `json:"type"`
+	// 除权除息日
+	ExDate time.Time `json:"exDate"`
+	// 每股现金分红，仅 dividend
+	Amount decimal.NullDecimal `json:"amount"`
+	// 拆合股比例，如 4 表示 1 拆 4 ， 0.1 表示 10 合 1 ，仅 split
+	Ratio decimal.NullDecimal `json:"ratio"`
+	// 说明
+	Description string `json:"description,omitempty"`
+}
+
+// SymbolMatch 证券搜索结果
+type SymbolMatch struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Exchange string `json:"exchange,omitempty"`
+	// 类型，如 stock 、 etf 、 index
+	Type     string `json:"type,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	// 市场，见 MarketOfSymbol
+	Market string `json:"market,omitempty"`
+}
+
+// 市场
+const (
+	MarketUS = "US"
+	MarketHK = "HK"
+	MarketCN = "CN"
+)
+
+// MarketOfSymbol 根据代码后缀判断市场
+//
+// .HK 为港股， .SS 、 .SH 、 .SZ 、 .BJ 为 A 股，其它后缀返回后缀本身（大写），
+// 无后缀或单个字母的股份类别后缀（如 BRK.B ）视为美股
+func MarketOfSymbol(symbol string) string {
+	i := strings.LastIndex(symbol, ".")
+	if i < 0 || len(symbol)-i <= 2 {
+		return MarketUS
+	}
+	switch suffix := strings.ToUpper(symbol[i+1:]); suffix {
+	case "HK":
+		return MarketHK
+	case "SS", "SH", "SZ", "BJ":
+		return MarketCN
+	default:
+		return suffix
+	}
+}
@@ -0,0 +1,572 @@
+package marketdata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/httpclient"
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// HTTPProviderName 通用 HTTP/JSON 提供商的默认名
+const HTTPProviderName = "http"
+
+// HTTPOptions 通用 HTTP/JSON 行情数据提供商选项
+//
+// 每类查询对应一个接口模板，未配置的查询视为不支持
+type HTTPOptions struct {
+	// 接口地址前缀，接口模板中的相对 URL 拼接在其后
+	BaseURL string `json:"baseURL,omitempty"`
+	// HTTP 客户端选项，可通过 headers 设置认证信息
+	HTTP httpclient.Options `json:"http,omitempty"`
+
+	Quote            *HTTPEndpoint `json:"quote,omitempty"`
+	History          *HTTPEndpoint `json:"history,omitempty"`
+	Fundamentals     *HTTPEndpoint `json:"fundamentals,omitempty"`
+	CorporateActions *HTTPEndpoint `json:"corporateActions,omitempty"`
+	Search           *HTTPEndpoint `json:"search,omitempty"`
+}
+
+// HTTPEndpoint 接口模板
+//
+// URL 和 Body 为 Go text/template 模板，可用的变量见 templateData
+type HTTPEndpoint struct {
+	// 请求地址
+	URL string `json:"url"`
+	// 请求方法，默认 GET
+	Method string `json:"method,omitempty"`
+	// 请求体
+	Body string `json:"body,omitempty"`
+	// 响应中数据所在位置，以 . 分隔的字段名或数组下标，如 data.klines
+	Path string `json:"path,omitempty"`
+	// 字段映射，键为标准字段名，值为响应中的字段路径
+	Fields map[string]string `json:"fields,omitempty"`
+	// 数据项为数组或逗号分隔的字符串时，各列对应的标准字段名
+	Columns []string `json:"columns,omitempty"`
+	// K 线周期映射，键为标准周期，值为接口的周期参数，模板中通过 .Interval 引用
+	Intervals map[string]string `json:"intervals,omitempty"`
+}
+
+// templateData 接口模板可用的变量
+type templateData struct {
+	// 证券代码，如 600519.SS
+	Symbol string
+	// 去掉后缀的代码，如 600519
+	Code string
+	// 代码后缀（大写），如 SS
+	Suffix string
+	// 市场，如 CN
+	Market string
+	// K 线周期（已映射）
+	Interval string
+	// 起止日期，格式 YYYY-MM-DD ，未指定时为空
+	From, To string
+	// 起止时间的 Unix 时间戳（秒），未指定时为 0
+	FromUnix, ToUnix int64
+	// 是否复权
+	Adjusted bool
+	// 搜索关键词
+	Query string
+}
+
+// templateFuncs 接口模板可用的函数
+var templateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"query":      url.QueryEscape,
+}
+
+// NewHTTPProvider 创建通用 HTTP/JSON 行情数据提供商
+func NewHTTPProvider(opts HTTPOptions, logger logr.Logger) (*HTTPProvider, error) {
+	p := &HTTPProvider{opts: opts, templates: map[*HTTPEndpoint][2]*template.Template{}}
+	for name, ep := range map[string]*HTTPEndpoint{
+		"quote":            opts.Quote,
+		"history":          opts.History,
+		"fundamentals":     opts.Fundamentals,
+		"corporateActions": opts.CorporateActions,
+		"search":           opts.Search,
+	} {
+		if ep == nil {
+			continue
+		}
+		if ep.URL == "" {
+			return nil, fmt.Errorf(".%s.url is required", name)
+		}
+		urlTmpl, err := template.New("url").Funcs(templateFuncs).Parse(ep.URL)
+		if err != nil {
+			return nil, fmt.Errorf("parse .%s.url template error: %w", name, err)
+		}
+		bodyTmpl, err := template.New("body").Funcs(templateFuncs).Parse(ep.Body)
+		if err != nil {
+			return nil, fmt.Errorf("parse .%s.body template error: %w", name, err)
+		}
+		p.templates[ep] = [2]*template.Template{urlTmpl, bodyTmpl}
+	}
+
+	client, err := httpclient.NewClient(opts.HTTP, logger)
+	if err != nil {
+		return nil, fmt.Errorf("new http client error: %w", err)
+	}
+	p.client = client
+	return p, nil
+}
+
+// HTTPProvider 通用 HTTP/JSON 行情数据提供商
+type HTTPProvider struct {
+	opts      HTTPOptions
+	client    *http.Client
+	templates map[*HTTPEndpoint][2]*template.Template
+}
+
+var _ tools.MarketDataProvider = (*HTTPProvider)(nil)
+
+// Name 提供商名
+func (p *HTTPProvider) Name() string {
+	return HTTPProviderName
+}
+
+// Quote 查询最新报价
+func (p *HTTPProvider) Quote(ctx context.Context, symbol string) (tools.Quote, error) {
+	item, err := p.fetchItem(ctx, p.opts.Quote, newTemplateData(symbol))
+	if err != nil {
+		return tools.Quote{}, err
+	}
+	price, ok := item.decimal("price")
+	if !ok {
+		return tools.Quote{}, fmt.Errorf("price not found in response")
+	}
+	ret := tools.Quote{
+		Symbol:        symbol,
+		Name:          item.string("name"),
+		Currency:      item.string("currency"),
+		Price:         price,
+		Open:          item.nullDecimal("open"),
+		High:          item.nullDecimal("high"),
+		Low:           item.nullDecimal("low"),
+		PrevClose:     item.nullDecimal("prevClose"),
+		Change:        item.nullDecimal("change"),
+		ChangePercent: item.nullDecimal("changePercent"),
+		Volume:        item.nullDecimal("volume"),
+		Time:          item.time("time"),
+	}
+	ret.Complete()
+	return ret, nil
+}
+
+// History 查询历史 K 线
+func (p *HTTPProvider) History(ctx context.Context, req tools.HistoryRequest) ([]tools.Bar, error) {
+	ep := p.opts.History
+	if ep == nil {
+		return nil, fmt.Errorf("history: %w", tools.ErrNotSupported)
+	}
+	data := newTemplateData(req.Symbol)
+	data.Interval = req.Interval
+	if data.Interval == "" {
+		data.Interval = tools.IntervalDaily
+	}
+	if len(ep.Intervals) > 0 {
+		v, ok := ep.Intervals[data.Interval]
+		if !ok {
+			return nil, fmt.Errorf("interval %q: %w", data.Interval, tools.ErrNotSupported)
+		}
+		data.Interval = v
+	}
+	if !req.From.IsZero() {
+		data.From, data.FromUnix = req.From.Format(time.DateOnly), req.From.Unix()
+	}
+	if !req.To.IsZero() {
+		data.To, data.ToUnix = req.To.Format(time.DateOnly), req.To.Unix()
+	}
+	data.Adjusted = req.Adjusted
+
+	items, err := p.fetchItems(ctx, ep, data)
+	if err != nil {
+		return nil, err
+	}
+	// 映射为标准字段后统一由 ParseBars 解析
+	list := make([]any, len(items))
+	for i, item := range items {
+		m := map[string]any{}
+		for _, k := range []string{"time", "open", "high", "low", "close", "volume"} {
+			if v, ok := item.lookup(k); ok {
+				m[k] = v
+			}
+		}
+		list[i] = m
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	bars, err := tools.ParseBars(list)
+	if err != nil {
+		return nil, err
+	}
+	ret := bars[:0]
+	for _, bar := range bars {
+		if (!req.From.IsZero() && bar.Time.Before(req.From)) || (!req.To.IsZero() && bar.Time.After(req.To)) {
+			continue
+		}
+		ret = append(ret, bar)
+	}
+	return ret, nil
+}
+
+// Fundamentals 查询基本面数据，字段映射中非标准字段名的字段输出到 extra
+func (p *HTTPProvider) Fundamentals(ctx context.Context, symbol string) (tools.Fundamentals, error) {
+	item, err := p.fetchItem(ctx, p.opts.Fundamentals, newTemplateData(symbol))
+	if err != nil {
+		return tools.Fundamentals{}, err
+	}
+	ret := tools.Fundamentals{
+		Symbol:            symbol,
+		Name:              item.string("name"),
+		Exchange:          item.string("exchange"),
+		Currency:          item.string("currency"),
+		Sector:            item.string("sector"),
+		Industry:          item.string("industry"),
+		MarketCap:         item.nullDecimal("marketCap"),
+		SharesOutstanding: item.nullDecimal("sharesOutstanding"),
+		PE:                item.nullDecimal("pe"),
+		PB:                item.nullDecimal("pb"),
+		EPS:               item.nullDecimal("eps"),
+		DividendYield:     item.nullDecimal("dividendYield"),
+		Revenue:           item.nullDecimal("revenue"),
+		NetIncome:         item.nullDecimal("netIncome"),
+		ROE:               item.nullDecimal("roe"),
+		FiscalPeriod:      item.string("fiscalPeriod"),
+	}
+	for k := range p.opts.Fundamentals.Fields {
+		if fundamentalFields[k] {
+			continue
+		}
+		if v := item.string(k); v != "" {
+			if ret.Extra == nil {
+				ret.Extra = map[string]string{}
+			}
+			ret.Extra[k] = v
+		}
+	}
+	return ret, nil
+}
+
+// fundamentalFields Fundamentals 的标准字段名
+var fundamentalFields = map[string]bool{
+	"name": true, "exchange": true, "currency": true, "sector": true, "industry": true,
+	"marketCap": true, "sharesOutstanding": true, "pe": true, "pb": true, "eps": true,
+	"dividendYield": true, "revenue": true, "netIncome": true, "roe": true, "fiscalPeriod": true,
+}
+
+// CorporateActions 查询公司行动，未映射 type 字段时根据 amount 、 ratio 推断类型
+func (p *HTTPProvider) CorporateActions(
+	ctx context.Context,
+	symbol string,
+	from, to time.Time,
+) ([]tools.CorporateAction, error) {
+	data := newTemplateData(symbol)
+	if !from.IsZero() {
+		data.From, data.FromUnix = from.Format(time.DateOnly), from.Unix()
+	}
+	if !to.IsZero() {
+		data.To, data.ToUnix = to.Format(time.DateOnly), to.Unix()
+	}
+	items, err := p.fetchItems(ctx, p.opts.CorporateActions, data)
+	if err != nil {
+		return nil, err
+	}
+	var ret []tools.CorporateAction
+	for _, item := range items {
+		a := tools.CorporateAction{
+			Type:        strings.ToLower(item.string("type")),
+			ExDate:      item.time("exDate"),
+			Amount:      item.nullDecimal("amount"),
+			Ratio:       item.nullDecimal("ratio"),
+			Description: item.string("description"),
+		}
+		if a.ExDate.IsZero() ||
+			(!from.IsZero() && a.ExDate.Before(from)) || (!to.IsZero() && a.ExDate.After(to)) {
+			continue
+		}
+		switch {
+		case a.Type != "":
+		case a.Amount.Valid:
+			a.Type = tools.ActionDividend
+		case a.Ratio.Valid:
+			a.Type = tools.ActionSplit
+		}
+		ret = append(ret, a)
+	}
+	return ret, nil
+}
+
+// SearchSymbols 搜索证券
+func (p *HTTPProvider) SearchSymbols(ctx context.Context, query string) ([]tools.SymbolMatch, error) {
+	items, err := p.fetchItems(ctx, p.opts.Search, templateData{Query: query})
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]tools.SymbolMatch, 0, len(items))
+	for _, item := range items {
+		m := tools.SymbolMatch{
+			Symbol:   item.string("symbol"),
+			Name:     item.string("name"),
+			Exchange: item.string("exchange"),
+			Type:     item.string("type"),
+			Currency: item.string("currency"),
+			Market:   item.string("market"),
+		}
+		if m.Symbol == "" {
+			continue
+		}
+		if m.Market == "" {
+			m.Market = tools.MarketOfSymbol(m.Symbol)
+		}
+		ret = append(ret, m)
+	}
+	return ret, nil
+}
+
+// newTemplateData 根据证券代码创建模板变量
+func newTemplateData(symbol string) templateData {
+	data := templateData{Symbol: symbol, Code: symbol, Market: tools.MarketOfSymbol(symbol)}
+	if i := strings.LastIndex(symbol, "."); i >= 0 {
+		data.Code, data.Suffix = symbol[:i], strings.ToUpper(symbol[i+1:])
+	}
+	return data
+}
+
+// fetchItem 请求接口并返回单个数据项，数据为数组时返回第一项
+func (p *HTTPProvider) fetchItem(ctx context.Context, ep *HTTPEndpoint, data templateData) (item, error) {
+	items, err := p.fetchItems(ctx, ep, data)
+	if err != nil {
+		return item{}, err
+	}
+	if len(items) == 0 {
+		return item{}, fmt.Errorf("no data in response")
+	}
+	return items[0], nil
+}
+
+// fetchItems 请求接口并返回数据项列表
+func (p *HTTPProvider) fetchItems(ctx context.Context, ep *HTTPEndpoint, data templateData) ([]item, error) {
+	if ep == nil {
+		return nil, tools.ErrNotSupported
+	}
+	tmpl := p.templates[ep]
+	urlBuf, bodyBuf := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := tmpl[0].Execute(urlBuf, data); err != nil {
+		return nil, fmt.Errorf("render url template error: %w", err)
+	}
+	if err := tmpl[1].Execute(bodyBuf, data); err != nil {
+		return nil, fmt.Errorf("render body template error: %w", err)
+	}
+	u := urlBuf.String()
+	if !strings.Contains(u, "://") {
+		u = strings.TrimSuffix(p.opts.BaseURL, "/") + "/" + strings.TrimPrefix(u, "/")
+	}
+	method := ep.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if bodyBuf.Len() > 0 {
+		body = bodyBuf
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("new request error: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request %s error: %w", req.URL.Host, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response error: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s returned status %d: %s", req.URL.Host, resp.StatusCode, truncate(string(raw), 200))
+	}
+
+	var v any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("unmarshal response error: %w", err)
+	}
+	v, ok := lookupPath(v, ep.Path)
+	if !ok {
+		return nil, fmt.Errorf("path %q not found in response", ep.Path)
+	}
+
+	list, isList := v.([]any)
+	if !isList {
+		list = []any{v}
+	}
+	ret := make([]item, 0, len(list))
+	for _, elem := range list {
+		ret = append(ret, newItem(elem, ep))
+	}
+	return ret, nil
+}
+
+// item 响应中的一个数据项
+type item struct {
+	// 对象形式的数据项
+	object map[string]any
+	// 数组或逗号分隔字符串形式的数据项，按 Columns 映射
+	columns map[string]any
+	fields  map[string]string
+}
+
+// newItem 创建数据项
+func newItem(v any, ep *HTTPEndpoint) item {
+	ret := item{fields: ep.Fields}
+	var values []any
+	switch typed := v.(type) {
+	case map[string]any:
+		ret.object = typed
+		return ret
+	case []any:
+		values = typed
+	case string:
+		for _, s := range strings.Split(typed, ",") {
+			values = append(values, strings.TrimSpace(s))
+		}
+	}
+	ret.columns = map[string]any{}
+	for i, name := range ep.Columns {
+		if i < len(values) && name != "" {
+			ret.columns[name] = values[i]
+		}
+	}
+	return ret
+}
+
+// lookup 获取标准字段的值
+func (it item) lookup(field string) (any, bool) {
+	if it.columns != nil {
+		v, ok := it.columns[field]
+		return v, ok
+	}
+	path := field
+	if mapped, ok := it.fields[field]; ok {
+		path = mapped
+	}
+	v, ok := lookupPath(any(it.object), path)
+	if !ok || v == nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// string 获取字符串字段
+func (it item) string(field string) string {
+	v, ok := it.lookup(field)
+	if !ok {
+		return ""
+	}
+	switch typed := v.(type) {
+	case string:
+		return typed
+	case json.Number:
+		return typed.String()
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+// decimal 获取数值字段，忽略千分位逗号和百分号
+func (it item) decimal(field string) (decimal.Decimal, bool) {
+	s := strings.TrimSpace(it.string(field))
+	s = strings.TrimSuffix(strings.ReplaceAll(s, ",", ""), "%")
+	if s == "" || s == "-" {
+		return decimal.Decimal{}, false
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Decimal{}, false
+	}
+	return d, true
+}
+
+// nullDecimal 获取可空数值字段
+func (it item) nullDecimal(field string) decimal.NullDecimal {
+	d, ok := it.decimal(field)
+	if !ok {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(d)
+}
+
+// time 获取时间字段，支持日期、 RFC3339 和 Unix 时间戳，与 K 线一致使用 UTC
+func (it item) time(field string) time.Time {
+	s := strings.TrimSpace(it.string(field))
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.DateOnly, time.DateTime, time.RFC3339, "20060102"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > 1e11 {
+			return time.UnixMilli(n).UTC()
+		}
+		return time.Unix(n, 0).UTC()
+	}
+	return time.Time{}
+}
+
+// lookupPath 按以 . 分隔的路径获取值，路径为空时返回 v 本身
+func lookupPath(v any, path string) (any, bool) {
+	if path == "" {
+		return v, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch typed := v.(type) {
+		case map[string]any:
+			next, ok := typed[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(typed) {
+				return nil, false
+			}
+			v = typed[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// truncate 截断过长的字符串
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
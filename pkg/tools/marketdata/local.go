@@ -0,0 +1,305 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// LocalProviderName 本地数据目录提供商的默认名
+const LocalProviderName = "local"
+
+// LocalOptions 本地数据目录提供商选项
+//
+// 目录结构：
+//
+//	<dir>/bars/<SYMBOL>.csv              日线，其它周期为 <SYMBOL>_<interval>.csv ，也可以是 .json 或 .parquet
+//	<dir>/quotes/<SYMBOL>.json           最新报价，不存在时使用最近两根日线
+//	<dir>/fundamentals/<SYMBOL>.json     基本面数据
+//	<dir>/actions/<SYMBOL>.csv           公司行动，表头 type,exDate,amount,ratio,description
+//	<dir>/symbols.csv                    证券列表，表头 symbol,name,exchange,type,currency,market
+type LocalOptions struct {
+	// 数据目录，相对路径相对于数据根目录（默认 ~/.nfa ）
+	Dir string `json:"dir"`
+}
+
+// NewLocalProvider 创建本地数据目录提供商
+func NewLocalProvider(opts LocalOptions) (*LocalProvider, error) {
+	if opts.Dir == "" {
+		return nil, fmt.Errorf(".dir is required")
+	}
+	return &LocalProvider{dir: opts.Dir}, nil
+}
+
+// LocalProvider 本地数据目录提供商，可用于离线分析和测试
+type LocalProvider struct {
+	dir string
+}
+
+var (
+	_ tools.MarketDataProvider = (*LocalProvider)(nil)
+	_ tools.SymbolLister       = (*LocalProvider)(nil)
+)
+
+// Name 提供商名
+func (p *LocalProvider) Name() string {
+	return LocalProviderName
+}
+
+// Quote 读取最新报价
+func (p *LocalProvider) Quote(ctx context.Context, symbol string) (tools.Quote, error) {
+	raw, err := p.readFile("quotes", symbol, ".json")
+	if err == nil {
+		var q tools.Quote
+		if err := json.Unmarshal(raw, &q); err != nil {
+			return tools.Quote{}, fmt.Errorf("unmarshal quote of %q error: %w", symbol, err)
+		}
+		q.Symbol = symbol
+		q.Complete()
+		return q, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return tools.Quote{}, err
+	}
+
+	// 使用最近两根日线
+	bars, err := p.History(ctx, tools.HistoryRequest{Symbol: symbol})
+	if err != nil {
+		return tools.Quote{}, err
+	}
+	last := bars[len(bars)-1]
+	q := tools.Quote{
+		Symbol: symbol,
+		Price:  last.Close,
+		Open:   decimal.NewNullDecimal(last.Open),
+		High:   decimal.NewNullDecimal(last.High),
+		Low:    decimal.NewNullDecimal(last.Low),
+		Volume: decimal.NewNullDecimal(last.Volume),
+		Time:   last.Time,
+	}
+	if len(bars) > 1 {
+		q.PrevClose = decimal.NewNullDecimal(bars[len(bars)-2].Close)
+	}
+	q.Complete()
+	return q, nil
+}
+
+// History 读取历史 K 线
+func (p *LocalProvider) History(_ context.Context, req tools.HistoryRequest) ([]tools.Bar, error) {
+	name := req.Symbol
+	if req.Interval != "" && req.Interval != tools.IntervalDaily {
+		name += "_" + req.Interval
+	}
+
+	var (
+		data any
+		err  error
+	)
+	for _, ext := range []string{".csv", ".json", ".parquet"} {
+		if ext == ".parquet" {
+			data, err = p.readParquet("bars", name)
+		} else {
+			var raw []byte
+			raw, err = p.readFile("bars", name, ext)
+			data = string(raw)
+		}
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	bars, err := tools.ParseBars(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse bars of %q error: %w", req.Symbol, err)
+	}
+	ret := bars[:0]
+	for _, bar := range bars {
+		if (!req.From.IsZero() && bar.Time.Before(req.From)) || (!req.To.IsZero() && bar.Time.After(req.To)) {
+			continue
+		}
+		ret = append(ret, bar)
+	}
+	return ret, nil
+}
+
+// Fundamentals 读取基本面数据
+func (p *LocalProvider) Fundamentals(_ context.Context, symbol string) (tools.Fundamentals, error) {
+	raw, err := p.readFile("fundamentals", symbol, ".json")
+	if err != nil {
+		return tools.Fundamentals{}, err
+	}
+	var ret tools.Fundamentals
+	if err := json.Unmarshal(raw, &ret); err != nil {
+		return tools.Fundamentals{}, fmt.Errorf("unmarshal fundamentals of %q error: %w", symbol, err)
+	}
+	ret.Symbol = symbol
+	return ret, nil
+}
+
+// CorporateActions 读取公司行动
+func (p *LocalProvider) CorporateActions(
+	_ context.Context,
+	symbol string,
+	from, to time.Time,
+) ([]tools.CorporateAction, error) {
+	records, err := p.readCSV(p.path("actions", symbol, ".csv"))
+	if err != nil {
+		return nil, err
+	}
+	var actions []tools.CorporateAction
+	for i, r := range records {
+		a := tools.CorporateAction{
+			Type:        strings.ToLower(r["type"]),
+			Amount:      nullDecimal(r["amount"]),
+			Ratio:       nullDecimal(r["ratio"]),
+			Description: r["description"],
+		}
+		if a.ExDate, err = time.Parse(time.DateOnly, r["exdate"]); err != nil {
+			return nil, fmt.Errorf("invalid exDate of corporate action %d of %q: %w", i+1, symbol, err)
+		}
+		actions = append(actions, a)
+	}
+
+	ret := actions[:0]
+	for _, a := range actions {
+		if (!from.IsZero() && a.ExDate.Before(from)) || (!to.IsZero() && a.ExDate.After(to)) {
+			continue
+		}
+		ret = append(ret, a)
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].ExDate.Before(ret[j].ExDate) })
+	return ret, nil
+}
+
+// SearchSymbols 在证券列表中按代码或名称搜索，代码完全匹配的排在最前
+func (p *LocalProvider) SearchSymbols(ctx context.Context, query string) ([]tools.SymbolMatch, error) {
+	all, err := p.ListSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := strings.ToLower(strings.TrimSpace(query))
+	var exact, partial []tools.SymbolMatch
+	for _, m := range all {
+		symbol := strings.ToLower(m.Symbol)
+		switch {
+		case symbol == q || strings.Split(symbol, ".")[0] == q:
+			exact = append(exact, m)
+		case strings.Contains(symbol, q) || strings.Contains(strings.ToLower(m.Name), q):
+			partial = append(partial, m)
+		}
+	}
+	return append(exact, partial...), nil
+}
+
+// ListSymbols 列出证券列表中的所有证券
+func (p *LocalProvider) ListSymbols(_ context.Context) ([]tools.SymbolMatch, error) {
+	records, err := p.readCSV(filepath.Join(p.dir, "symbols.csv"))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]tools.SymbolMatch, 0, len(records))
+	for _, r := range records {
+		m := tools.SymbolMatch{
+			Symbol:   r["symbol"],
+			Name:     r["name"],
+			Exchange: r["exchange"],
+			Type:     r["type"],
+			Currency: r["currency"],
+			Market:   r["market"],
+		}
+		if m.Market == "" {
+			m.Market = tools.MarketOfSymbol(m.Symbol)
+		}
+		ret = append(ret, m)
+	}
+	return ret, nil
+}
+
+// path 返回数据文件路径
+func (p *LocalProvider) path(kind, name, ext string) string {
+	return filepath.Join(p.dir, kind, name+ext)
+}
+
+// readFile 读取数据文件，文件名区分大小写时再尝试大写代码
+func (p *LocalProvider) readFile(kind, name, ext string) ([]byte, error) {
+	raw, err := os.ReadFile(p.path(kind, name, ext))
+	if errors.Is(err, fs.ErrNotExist) && strings.ToUpper(name) != name {
+		raw, err = os.ReadFile(p.path(kind, strings.ToUpper(name), ext))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s data of %q error: %w", kind, name, err)
+	}
+	return raw, nil
+}
+
+// readParquet 读取 Parquet 数据文件的所有行，文件名区分大小写时再尝试大写代码
+func (p *LocalProvider) readParquet(kind, name string) ([]any, error) {
+	path := p.path(kind, name, ".parquet")
+	_, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) && strings.ToUpper(name) != name {
+		path = p.path(kind, strings.ToUpper(name), ".parquet")
+		_, err = os.Stat(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s data of %q error: %w", kind, name, err)
+	}
+	return readParquetRows(path)
+}
+
+// readCSV 读取带表头的 CSV 文件，表头转为小写
+func (p *LocalProvider) readCSV(path string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s error: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read %s error: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	header := rows[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	ret := make([]map[string]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		r := make(map[string]string, len(header))
+		for i, v := range row {
+			if i < len(header) {
+				r[header[i]] = strings.TrimSpace(v)
+			}
+		}
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+// nullDecimal 解析数值，为空或无法解析时无效
+func nullDecimal(s string) decimal.NullDecimal {
+	d, err := decimal.NewFromString(strings.ReplaceAll(strings.TrimSpace(s), ",", ""))
+	if err != nil {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(d)
+}
@@ -0,0 +1,317 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/alphavantage"
+)
+
+// writeFiles 在 dir 下写入文件
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+// TestLocalProvider 测试本地数据目录提供商
+func TestLocalProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"bars/0700.HK.csv": "date,open,high,low,close,volume\n" +
+			"2024-01-02,300,305,298,302,1000\n" +
+			"2024-01-03,302,310,301,308,1200\n" +
+			"2024-01-04,308,309,300,301,900\n",
+		"bars/0700.HK_weekly.parquet":  "PAR1",
+		"fundamentals/0700.HK.json":    `{"name":"Tencent","currency":"HKD","pe":"18.5"}`,
+		"actions/0700.HK.csv":          "type,exDate,amount,ratio\ndividend,2024-05-17,3.4,\nsplit,2014-05-15,,5\n",
+		"symbols.csv":                  "symbol,name,exchange,currency\n0700.HK,Tencent Holdings,HKEX,HKD\n600519.SS,Kweichow Moutai,SSE,CNY\n",
+		"quotes/600519.SS.json":        `{"price":"1700","prevClose":"1680"}`,
+		"bars/600519.SS_1min.csv":      "time,close\n2024-01-02T09:30:00Z,1700\n",
+		"fundamentals/invalid.HK.json": "{",
+	})
+	p, err := NewLocalProvider(LocalOptions{Dir: dir})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	bars, err := p.History(ctx, tools.HistoryRequest{
+		Symbol: "0700.HK",
+		From:   time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Len(t, bars, 2)
+	assert.Equal(t, "308", bars[0].Close.String())
+
+	_, err = p.History(ctx, tools.HistoryRequest{Symbol: "0700.HK", Interval: tools.IntervalWeekly})
+	assert.ErrorContains(t, err, "parquet")
+
+	// 没有报价文件时使用最近两根日线
+	q, err := p.Quote(ctx, "0700.HK")
+	require.NoError(t, err)
+	assert.Equal(t, "301", q.Price.String())
+	assert.Equal(t, "-7", q.Change.Decimal.String())
+	assert.Equal(t, "-2.2727", q.ChangePercent.Decimal.String())
+
+	q, err = p.Quote(ctx, "600519.SS")
+	require.NoError(t, err)
+	assert.Equal(t, "20", q.Change.Decimal.String())
+
+	f, err := p.Fundamentals(ctx, "0700.HK")
+	require.NoError(t, err)
+	assert.Equal(t, "Tencent", f.Name)
+	assert.Equal(t, "18.5", f.PE.Decimal.String())
+	assert.False(t, f.PB.Valid)
+
+	actions, err := p.CorporateActions(ctx, "0700.HK", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+	assert.Equal(t, tools.ActionSplit, actions[0].Type)
+	assert.Equal(t, "5", actions[0].Ratio.Decimal.String())
+	assert.Equal(t, "3.4", actions[1].Amount.Decimal.String())
+
+	matches, err := p.SearchSymbols(ctx, "moutai")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "600519.SS", matches[0].Symbol)
+	assert.Equal(t, tools.MarketCN, matches[0].Market)
+}
+
+// parquetDailyBar 测试用 Parquet 日线，日期为 DATE 类型
+type parquetDailyBar struct {
+	Date   int32   `parquet:"name=Date, type=INT32, convertedtype=DATE"`
+	Open   float64 `parquet:"name=Open, type=DOUBLE"`
+	High   float64 `parquet:"name=High, type=DOUBLE"`
+	Low    float64 `parquet:"name=Low, type=DOUBLE"`
+	Close  float64 `parquet:"name=Close, type=DOUBLE"`
+	Volume int64   `parquet:"name=Volume, type=INT64"`
+}
+
+// parquetMinuteBar 测试用 Parquet 分钟线，时间为毫秒时间戳，价格为 DECIMAL 类型
+type parquetMinuteBar struct {
+	Timestamp int64  `parquet:"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Close     int64  `parquet:"name=close, type=INT64, convertedtype=DECIMAL, scale=2, precision=18"`
+	Volume    *int64 `parquet:"name=volume, type=INT64, repetitiontype=OPTIONAL"`
+}
+
+// writeParquet 将 rows 写入 Parquet 文件
+func writeParquet[T any](t *testing.T, path string, rows []T) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	f, err := local.NewLocalFileWriter(path)
+	require.NoError(t, err)
+	w, err := writer.NewParquetWriter(f, new(T), 1)
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.WriteStop())
+	require.NoError(t, f.Close())
+}
+
+// TestLocalProviderParquet 测试读取 Parquet 格式的 K 线
+func TestLocalProviderParquet(t *testing.T) {
+	dir := t.TempDir()
+	day := func(y int, m time.Month, d int) int32 {
+		return int32(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+	}
+	writeParquet(t, filepath.Join(dir, "bars", "NVDA.parquet"), []parquetDailyBar{
+		{Date: day(2024, 1, 3), Open: 102, High: 106, Low: 101, Close: 105.5, Volume: 1200},
+		{Date: day(2024, 1, 2), Open: 100, High: 103, Low: 99, Close: 102, Volume: 1000},
+	})
+	start := time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC)
+	volume := int64(300)
+	writeParquet(t, filepath.Join(dir, "bars", "NVDA_1min.parquet"), []parquetMinuteBar{
+		{Timestamp: start.UnixMilli(), Close: 10025, Volume: &volume},
+		{Timestamp: start.Add(time.Minute).UnixMilli(), Close: -5},
+	})
+	p, err := NewLocalProvider(LocalOptions{Dir: dir})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	bars, err := p.History(ctx, tools.HistoryRequest{Symbol: "nvda"})
+	require.NoError(t, err)
+	require.Len(t, bars, 2)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), bars[0].Time.UTC())
+	assert.Equal(t, "100", bars[0].Open.String())
+	assert.Equal(t, "105.5", bars[1].Close.String())
+	assert.Equal(t, "1200", bars[1].Volume.String())
+
+	bars, err = p.History(ctx, tools.HistoryRequest{Symbol: "NVDA", Interval: "1min"})
+	require.NoError(t, err)
+	require.Len(t, bars, 2)
+	assert.True(t, start.Equal(bars[0].Time))
+	assert.Equal(t, "100.25", bars[0].Close.String())
+	assert.Equal(t, "300", bars[0].Volume.String())
+	assert.Equal(t, "-0.05", bars[1].Close.String())
+	assert.True(t, bars[1].Volume.IsZero())
+
+	// 没有报价文件时使用 Parquet 日线
+	q, err := p.Quote(ctx, "NVDA")
+	require.NoError(t, err)
+	assert.Equal(t, "3.5", q.Change.Decimal.String())
+}
+
+// TestHTTPProvider 测试通用 HTTP/JSON 提供商
+func TestHTTPProvider(t *testing.T) {
+	var lastQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastQuery = r.URL.RawQuery
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/quote":
+			_, _ = fmt.Fprint(w, `{"data":{"last":"1,700.5","pre":"1690.5","name":"贵州茅台"}}`)
+		case "/kline":
+			_, _ = fmt.Fprint(w, `{"data":{"klines":["2024-01-02,10,10.5,11,9.8,1000","2024-01-03,10.5,10.2,10.8,10,800"]}}`)
+		case "/search":
+			_, _ = fmt.Fprint(w, `{"items":[{"code":"600519.SS","title":"贵州茅台"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	opts := HTTPOptions{
+		BaseURL: server.URL,
+		Quote: &HTTPEndpoint{
+			URL:    "/quote?secid={{if eq .Suffix \"SS\"}}1{{else}}0{{end}}.{{.Code}}",
+			Path:   "data",
+			Fields: map[string]string{"price": "last", "prevClose": "pre"},
+		},
+		History: &HTTPEndpoint{
+			URL:       "/kline?code={{.Code}}&klt={{.Interval}}&beg={{.From}}",
+			Path:      "data.klines",
+			Columns:   []string{"time", "open", "close", "high", "low", "volume"},
+			Intervals: map[string]string{tools.IntervalDaily: "101"},
+		},
+		Search: &HTTPEndpoint{
+			URL:    "/search?q={{query .Query}}",
+			Path:   "items",
+			Fields: map[string]string{"symbol": "code", "name": "title"},
+		},
+	}
+	opts.HTTP.Headers = map[string]string{"Authorization": "Bearer token"}
+	p, err := NewHTTPProvider(opts, logr.Discard())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	q, err := p.Quote(ctx, "600519.SS")
+	require.NoError(t, err)
+	assert.Equal(t, "secid=1.600519", lastQuery)
+	assert.Equal(t, "贵州茅台", q.Name)
+	assert.Equal(t, "1700.5", q.Price.String())
+	assert.Equal(t, "10", q.Change.Decimal.String())
+
+	bars, err := p.History(ctx, tools.HistoryRequest{
+		Symbol: "600519.SS",
+		From:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "code=600519&klt=101&beg=2024-01-01", lastQuery)
+	require.Len(t, bars, 2)
+	assert.Equal(t, "10.5", bars[0].Close.String())
+	assert.Equal(t, "11", bars[0].High.String())
+
+	_, err = p.History(ctx, tools.HistoryRequest{Symbol: "600519.SS", Interval: tools.IntervalWeekly})
+	assert.ErrorIs(t, err, tools.ErrNotSupported)
+	_, err = p.Fundamentals(ctx, "600519.SS")
+	assert.ErrorIs(t, err, tools.ErrNotSupported)
+
+	matches, err := p.SearchSymbols(ctx, "茅台")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, tools.SymbolMatch{Symbol: "600519.SS", Name: "贵州茅台", Market: tools.MarketCN}, matches[0])
+
+	_, err = NewHTTPProvider(HTTPOptions{Quote: &HTTPEndpoint{}}, logr.Discard())
+	assert.Error(t, err)
+}
+
+// fakeProvider 返回固定结果的提供商
+type fakeProvider struct {
+	tools.MarketDataProvider
+	name  string
+	price string
+	err   error
+}
+
+func (p fakeProvider) Name() string { return p.name }
+
+func (p fakeProvider) Quote(_ context.Context, symbol string) (tools.Quote, error) {
+	if p.err != nil {
+		return tools.Quote{}, p.err
+	}
+	return tools.Quote{Symbol: symbol, Price: decimal.RequireFromString(p.price)}, nil
+}
+
+// TestRouter 测试按市场路由和失败回退
+func TestRouter(t *testing.T) {
+	r := NewRouter()
+	r.Add(fakeProvider{name: "us", price: "1"}, tools.MarketUS)
+	r.Add(fakeProvider{name: "broken", err: fmt.Errorf("boom")}, tools.MarketHK)
+	r.Add(fakeProvider{name: "all", price: "2"})
+	ctx := context.Background()
+
+	q, provider, err := r.Quote(ctx, "AAPL", "")
+	require.NoError(t, err)
+	assert.Equal(t, "us", provider)
+	assert.Equal(t, "1", q.Price.String())
+
+	// 港股先尝试 broken ，失败后回退到 all
+	_, provider, err = r.Quote(ctx, "0700.HK", "")
+	require.NoError(t, err)
+	assert.Equal(t, "all", provider)
+
+	// 查询前转换为标准代码
+	q, provider, err = r.Quote(ctx, "HK.00700", "")
+	require.NoError(t, err)
+	assert.Equal(t, "all", provider)
+	assert.Equal(t, "0700.HK", q.Symbol)
+
+	_, provider, err = r.Quote(ctx, "AAPL", "all")
+	require.NoError(t, err)
+	assert.Equal(t, "all", provider)
+
+	_, _, err = r.Quote(ctx, "0700.HK", "broken")
+	assert.ErrorContains(t, err, "broken: boom")
+	_, _, err = r.Quote(ctx, "AAPL", "unknown")
+	assert.ErrorContains(t, err, "available: us, broken, all")
+}
+
+// TestNewRouterFromOptions 测试根据配置创建 Router
+func TestNewRouterFromOptions(t *testing.T) {
+	dataRoot := t.TempDir()
+	writeFiles(t, dataRoot, map[string]string{
+		"marketdata/bars/0700.HK.csv": "date,close\n2024-01-02,300\n2024-01-03,303\n",
+	})
+	r, err := NewRouterFromOptions([]ProviderOptions{
+		{AlphaVantage: &alphavantage.RESTOptions{}, Markets: []string{tools.MarketUS}},
+		{Name: "offline", Local: &LocalOptions{Dir: "marketdata"}, Markets: []string{tools.MarketHK}},
+	}, dataRoot, "default-key", logr.Discard())
+	require.NoError(t, err)
+	assert.Equal(t, []string{alphavantage.RESTProviderName, "offline"}, r.Names())
+
+	// 本地目录相对于数据根目录
+	q, provider, err := r.Quote(context.Background(), "0700.HK", "")
+	require.NoError(t, err)
+	assert.Equal(t, "offline", provider)
+	assert.Equal(t, "303", q.Price.String())
+
+	_, err = NewRouterFromOptions([]ProviderOptions{{}}, dataRoot, "", logr.Discard())
+	assert.ErrorContains(t, err, "dataProviders.marketData[0]")
+	_, err = NewRouterFromOptions([]ProviderOptions{{AlphaVantage: &alphavantage.RESTOptions{}}}, dataRoot, "", logr.Discard())
+	assert.ErrorContains(t, err, ".apiKey is required")
+}
@@ -0,0 +1,107 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+
+	"github.com/yhlooo/nfa/pkg/httpclient"
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/alphavantage"
+)
+
+// ProviderOptions 行情数据提供商配置，只能设置 alphaVantage 、 http 、 local 中的一项
+type ProviderOptions struct {
+	// 提供商名，默认为类型名
+	Name string `json:"name,omitempty"`
+	// 负责的市场，如 US 、 HK 、 CN ，为空时负责所有市场
+	Markets []string `json:"markets,omitempty"`
+
+	// Alpha Vantage REST API
+	AlphaVantage *alphavantage.RESTOptions `json:"alphaVantage,omitempty"`
+	// 通过模板配置的通用 HTTP/JSON 接口
+	HTTP *HTTPOptions `json:"http,omitempty"`
+	// 本地数据目录
+	Local *LocalOptions `json:"local,omitempty"`
+}
+
+// NewProvider 根据配置创建提供商
+//
+// 本地数据目录为相对路径时相对于 dataRoot ； Alpha Vantage 未配置 apiKey 时使用 defaultAVKey
+func (opts ProviderOptions) NewProvider(dataRoot, defaultAVKey string, logger logr.Logger) (tools.MarketDataProvider, error) {
+	var (
+		p   tools.MarketDataProvider
+		err error
+	)
+	switch {
+	case opts.AlphaVantage != nil && opts.HTTP == nil && opts.Local == nil:
+		avOpts := *opts.AlphaVantage
+		if avOpts.APIKey == "" {
+			avOpts.APIKey = defaultAVKey
+		}
+		client, err := httpclient.NewClient(httpclient.Options{}, logger)
+		if err != nil {
+			return nil, fmt.Errorf("new http client error: %w", err)
+		}
+		p, err = alphavantage.NewRESTProvider(avOpts, client)
+		if err != nil {
+			return nil, fmt.Errorf("alphaVantage: %w", err)
+		}
+	case opts.HTTP != nil && opts.AlphaVantage == nil && opts.Local == nil:
+		if p, err = NewHTTPProvider(*opts.HTTP, logger); err != nil {
+			return nil, fmt.Errorf("http: %w", err)
+		}
+	case opts.Local != nil && opts.AlphaVantage == nil && opts.HTTP == nil:
+		localOpts := *opts.Local
+		if localOpts.Dir != "" && !filepath.IsAbs(localOpts.Dir) {
+			localOpts.Dir = filepath.Join(dataRoot, localOpts.Dir)
+		}
+		if p, err = NewLocalProvider(localOpts); err != nil {
+			return nil, fmt.Errorf("local: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("exactly one of alphaVantage, http and local must be set")
+	}
+	if opts.Name != "" {
+		p = namedProvider{MarketDataProvider: p, name: opts.Name}
+	}
+	return p, nil
+}
+
+// NewRouterFromOptions 根据配置创建 Router ，参数含义同 ProviderOptions.NewProvider
+func NewRouterFromOptions(
+	opts []ProviderOptions,
+	dataRoot, defaultAVKey string,
+	logger logr.Logger,
+) (*Router, error) {
+	r := NewRouter()
+	for i, o := range opts {
+		p, err := o.NewProvider(dataRoot, defaultAVKey, logger)
+		if err != nil {
+			return nil, fmt.Errorf("dataProviders.marketData[%d]: %w", i, err)
+		}
+		r.Add(p, o.Markets...)
+	}
+	return r, nil
+}
+
+// namedProvider 使用配置的名字的提供商
+type namedProvider struct {
+	tools.MarketDataProvider
+	name string
+}
+
+// Name 提供商名
+func (p namedProvider) Name() string {
+	return p.name
+}
+
+// ListSymbols 被包装的提供商支持时列出所有证券
+func (p namedProvider) ListSymbols(ctx context.Context) ([]tools.SymbolMatch, error) {
+	if lister, ok := p.MarketDataProvider.(tools.SymbolLister); ok {
+		return lister.ListSymbols(ctx)
+	}
+	return nil, fmt.Errorf("list symbols: %w", tools.ErrNotSupported)
+}
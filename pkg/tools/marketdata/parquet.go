@@ -0,0 +1,164 @@
+package marketdata
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/types"
+)
+
+// readParquetRows 读取 Parquet 文件中的所有行，每行为列名到值的映射
+//
+// 只支持平铺的列，值转为字符串：日期为 YYYY-MM-DD ，时间戳为 RFC3339 ，数值和 DECIMAL 为十进制数
+func readParquetRows(path string) ([]any, error) {
+	f, err := local.NewLocalFileReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("open parquet file %s error: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	pr, err := reader.NewParquetColumnReader(f, 1)
+	if err != nil {
+		return nil, fmt.Errorf("read parquet file %s error: %w", path, err)
+	}
+	defer pr.ReadStop()
+
+	n := pr.GetNumRows()
+	rows := make([]map[string]any, n)
+	for i := range rows {
+		rows[i] = map[string]any{}
+	}
+	for i, inPath := range pr.SchemaHandler.ValueColumns {
+		index := pr.SchemaHandler.MapIndex[inPath]
+		elem := pr.SchemaHandler.SchemaElements[index]
+		name := pr.SchemaHandler.GetExName(int(index))
+		if strings.Count(inPath, common.PAR_GO_PATH_DELIMITER) > 1 {
+			// 嵌套的列
+			continue
+		}
+
+		values, _, _, err := pr.ReadColumnByIndex(int64(i), n)
+		if err != nil {
+			return nil, fmt.Errorf("read column %q of parquet file %s error: %w", name, path, err)
+		}
+		if int64(len(values)) != n {
+			return nil, fmt.Errorf("column %q of parquet file %s has %d values, expected %d", name, path, len(values), n)
+		}
+		for j, v := range values {
+			if v == nil {
+				continue
+			}
+			s, err := parquetValueString(elem, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of column %q in row %d of parquet file %s: %w", name, j+1, path, err)
+			}
+			rows[j][name] = s
+		}
+	}
+
+	ret := make([]any, len(rows))
+	for i, row := range rows {
+		ret[i] = row
+	}
+	return ret, nil
+}
+
+// parquetValueString 按列的类型将 Parquet 值转为字符串
+func parquetValueString(elem *parquet.SchemaElement, v any) (string, error) {
+	logical := elem.GetLogicalType()
+	if logical == nil {
+		logical = parquet.NewLogicalType()
+	}
+	switch {
+	case elem.GetType() == parquet.Type_INT96:
+		s, ok := v.(string)
+		if !ok || len(s) != 12 {
+			return "", fmt.Errorf("invalid INT96 value %v", v)
+		}
+		return types.INT96ToTime(s).UTC().Format(time.RFC3339Nano), nil
+	case logical.IsSetDATE() || elem.GetConvertedType() == parquet.ConvertedType_DATE:
+		days, err := parquetInt(v)
+		if err != nil {
+			return "", err
+		}
+		return time.Unix(days*86400, 0).UTC().Format(time.DateOnly), nil
+	case logical.IsSetTIMESTAMP() ||
+		elem.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MILLIS ||
+		elem.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MICROS:
+		n, err := parquetInt(v)
+		if err != nil {
+			return "", err
+		}
+		unit := parquet.NewTimeUnit()
+		if logical.IsSetTIMESTAMP() {
+			unit = logical.GetTIMESTAMP().GetUnit()
+		}
+		var t time.Time
+		switch {
+		case unit.IsSetNANOS():
+			t = time.Unix(0, n)
+		case unit.IsSetMICROS() || elem.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MICROS:
+			t = time.UnixMicro(n)
+		default:
+			t = time.UnixMilli(n)
+		}
+		return t.UTC().Format(time.RFC3339Nano), nil
+	case logical.IsSetDECIMAL() || elem.GetConvertedType() == parquet.ConvertedType_DECIMAL:
+		scale := elem.GetScale()
+		if logical.IsSetDECIMAL() {
+			scale = logical.GetDECIMAL().GetScale()
+		}
+		if s, ok := v.(string); ok {
+			return decimal.NewFromBigInt(twosComplement([]byte(s)), -scale).String(), nil
+		}
+		n, err := parquetInt(v)
+		if err != nil {
+			return "", err
+		}
+		return decimal.New(n, -scale).String(), nil
+	}
+
+	switch typed := v.(type) {
+	case string:
+		return typed, nil
+	case bool:
+		return strconv.FormatBool(typed), nil
+	case float32:
+		return decimal.NewFromFloat32(typed).String(), nil
+	case float64:
+		return decimal.NewFromFloat(typed).String(), nil
+	}
+	n, err := parquetInt(v)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(n, 10), nil
+}
+
+// parquetInt 将 Parquet 整数值转为 int64
+func parquetInt(v any) (int64, error) {
+	switch typed := v.(type) {
+	case int32:
+		return int64(typed), nil
+	case int64:
+		return typed, nil
+	}
+	return 0, fmt.Errorf("unexpected value %v of type %T", v, v)
+}
+
+// twosComplement 将大端序补码字节解析为整数
+func twosComplement(b []byte) *big.Int {
+	n := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+	}
+	return n
+}
@@ -0,0 +1,225 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// NewRouter 创建 Router
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Router 按市场将查询路由到多个行情数据提供商
+//
+// 查询前先将证券代码转换为标准代码（见 tools.NormalizeSymbol ），
+// 然后按添加顺序尝试负责该证券所属市场的提供商，返回第一个成功的结果
+type Router struct {
+	providers []routedProvider
+}
+
+// routedProvider 负责指定市场的提供商
+type routedProvider struct {
+	tools.MarketDataProvider
+	// 负责的市场，为空时负责所有市场
+	markets []string
+}
+
+// serves 是否负责指定市场
+func (p routedProvider) serves(market string) bool {
+	return len(p.markets) == 0 || slices.ContainsFunc(p.markets, func(m string) bool {
+		return strings.EqualFold(m, market)
+	})
+}
+
+// Add 添加提供商， markets 为空时负责所有市场
+func (r *Router) Add(p tools.MarketDataProvider, markets ...string) {
+	r.providers = append(r.providers, routedProvider{MarketDataProvider: p, markets: markets})
+}
+
+// Len 提供商数量
+func (r *Router) Len() int {
+	return len(r.providers)
+}
+
+// Names 所有提供商名
+func (r *Router) Names() []string {
+	ret := make([]string, len(r.providers))
+	for i, p := range r.providers {
+		ret[i] = p.Name()
+	}
+	return ret
+}
+
+// candidates 返回处理指定证券的候选提供商， name 不为空时只返回指定名字的提供商
+func (r *Router) candidates(symbol, name string) ([]tools.MarketDataProvider, error) {
+	var ret []tools.MarketDataProvider
+	if name != "" {
+		for _, p := range r.providers {
+			if p.Name() == name {
+				ret = append(ret, p.MarketDataProvider)
+			}
+		}
+		if len(ret) == 0 {
+			return nil, fmt.Errorf("market data provider %q not found (available: %s)",
+				name, strings.Join(r.Names(), ", "))
+		}
+		return ret, nil
+	}
+
+	market := tools.MarketOfSymbol(symbol)
+	for _, p := range r.providers {
+		if symbol == "" || p.serves(market) {
+			ret = append(ret, p.MarketDataProvider)
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no market data provider configured for market %s of symbol %q", market, symbol)
+	}
+	return ret, nil
+}
+
+// try 依次使用候选提供商执行查询，返回第一个成功的结果和提供商名
+func try[T any](
+	r *Router,
+	symbol, name string,
+	fn func(p tools.MarketDataProvider) (T, error),
+) (T, string, error) {
+	var zero T
+	candidates, err := r.candidates(symbol, name)
+	if err != nil {
+		return zero, "", err
+	}
+	var errs []error
+	for _, p := range candidates {
+		ret, err := fn(p)
+		if err == nil {
+			return ret, p.Name(), nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return zero, "", errors.Join(errs...)
+}
+
+// Quote 查询最新报价
+func (r *Router) Quote(ctx context.Context, symbol, provider string) (tools.Quote, string, error) {
+	symbol = tools.CanonicalSymbol(symbol)
+	return try(r, symbol, provider, func(p tools.MarketDataProvider) (tools.Quote, error) {
+		return p.Quote(ctx, symbol)
+	})
+}
+
+// History 查询历史 K 线
+func (r *Router) History(ctx context.Context, req tools.HistoryRequest, provider string) ([]tools.Bar, string, error) {
+	req.Symbol = tools.CanonicalSymbol(req.Symbol)
+	return try(r, req.Symbol, provider, func(p tools.MarketDataProvider) ([]tools.Bar, error) {
+		bars, err := p.History(ctx, req)
+		if err == nil && len(bars) == 0 {
+			err = fmt.Errorf("no bars for symbol %q", req.Symbol)
+		}
+		return bars, err
+	})
+}
+
+// Fundamentals 查询基本面数据
+func (r *Router) Fundamentals(ctx context.Context, symbol, provider string) (tools.Fundamentals, string, error) {
+	symbol = tools.CanonicalSymbol(symbol)
+	return try(r, symbol, provider, func(p tools.MarketDataProvider) (tools.Fundamentals, error) {
+		return p.Fundamentals(ctx, symbol)
+	})
+}
+
+// CorporateActions 查询公司行动
+func (r *Router) CorporateActions(
+	ctx context.Context,
+	symbol string,
+	from, to time.Time,
+	provider string,
+) ([]tools.CorporateAction, string, error) {
+	symbol = tools.CanonicalSymbol(symbol)
+	return try(r, symbol, provider, func(p tools.MarketDataProvider) ([]tools.CorporateAction, error) {
+		return p.CorporateActions(ctx, symbol, from, to)
+	})
+}
+
+// SearchSymbols 使用所有（或指定）提供商搜索证券，合并去重后返回
+func (r *Router) SearchSymbols(ctx context.Context, query, provider string) ([]tools.SymbolMatch, error) {
+	candidates, err := r.candidates("", provider)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ret  []tools.SymbolMatch
+		seen = map[string]bool{}
+		errs []error
+	)
+	for _, p := range candidates {
+		matches, err := p.SearchSymbols(ctx, query)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		for _, m := range matches {
+			key := strings.ToUpper(m.Symbol)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			ret = append(ret, m)
+		}
+	}
+	if len(ret) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return ret, nil
+}
+
+// ListSymbols 使用所有（或指定）支持列出证券的提供商列出证券，合并去重后返回
+func (r *Router) ListSymbols(ctx context.Context, provider string) ([]tools.SymbolMatch, error) {
+	candidates, err := r.candidates("", provider)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ret       []tools.SymbolMatch
+		seen      = map[string]bool{}
+		errs      []error
+		supported bool
+	)
+	for _, p := range candidates {
+		lister, ok := p.(tools.SymbolLister)
+		if !ok {
+			continue
+		}
+		matches, err := lister.ListSymbols(ctx)
+		if errors.Is(err, tools.ErrNotSupported) {
+			continue
+		}
+		supported = true
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		for _, m := range matches {
+			key := strings.ToUpper(m.Symbol)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			ret = append(ret, m)
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("list symbols: %w", tools.ErrNotSupported)
+	}
+	if len(ret) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return ret, nil
+}
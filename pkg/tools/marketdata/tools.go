@@ -0,0 +1,336 @@
+package marketdata
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+const (
+	// QuoteToolName 报价查询工具名
+	QuoteToolName = "MarketQuote"
+	// HistoryToolName 历史 K 线查询工具名
+	HistoryToolName = "MarketHistory"
+	// FundamentalsToolName 基本面查询工具名
+	FundamentalsToolName = "MarketFundamentals"
+	// CorporateActionsToolName 公司行动查询工具名
+	CorporateActionsToolName = "MarketCorporateActions"
+	// SymbolSearchToolName 证券搜索工具名
+	SymbolSearchToolName = "MarketSymbolSearch"
+
+	// DefaultHistoryLimit 默认返回的 K 线数量
+	DefaultHistoryLimit = 250
+	// MaxHistoryLimit 最大返回的 K 线数量
+	MaxHistoryLimit = 5000
+	// MaxSearchResults 最大返回的搜索结果数
+	MaxSearchResults = 20
+)
+
+// symbolDesc 证券代码输入的说明
+const symbolDesc = `- **symbol**: (string) 证券代码，美股如 AAPL ，港股如 0700.HK ， A 股如 600519.SS 、 000001.SZ ，其它常见写法（如 700.HK 、 SH600519 ）会自动转换，不确定代码时先使用 ResolveSymbol 工具`
+
+// providerDesc 提供商输入的说明
+const providerDesc = `- **provider**: (string,optional) 只使用指定名字的提供商，默认按配置顺序尝试负责该市场的提供商`
+
+// RegisterTools 注册所有行情数据工具
+func (r *Router) RegisterTools(g *genkit.Genkit) []ai.ToolRef {
+	return []ai.ToolRef{
+		r.DefineQuoteTool(g),
+		r.DefineHistoryTool(g),
+		r.DefineFundamentalsTool(g),
+		r.DefineCorporateActionsTool(g),
+		r.DefineSymbolSearchTool(g),
+	}
+}
+
+// QuoteInput 报价查询输入
+type QuoteInput struct {
+	Symbol   string `json:"symbol"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// QuoteOutput 报价查询输出
+type QuoteOutput struct {
+	Provider string      `json:"provider"`
+	Quote    tools.Quote `json:"quote"`
+}
+
+// DefineQuoteTool 定义报价查询工具
+func (r *Router) DefineQuoteTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, QuoteToolName, `Get the latest quote of a stock, ETF or index from the configured market data providers.
+
+以 JSON 格式输入：
+`+symbolDesc+`
+`+providerDesc+`
+
+输出：
+- **provider**: 数据来源的提供商
+- **quote**: 最新价、开高低价、昨收价、涨跌额、涨跌幅（%）、成交量和报价时间
+`,
+		func(ctx *ai.ToolContext, in QuoteInput) (QuoteOutput, error) {
+			if in.Symbol == "" {
+				return QuoteOutput{}, fmt.Errorf("symbol is required")
+			}
+			q, provider, err := r.Quote(ctx, in.Symbol, in.Provider)
+			if err != nil {
+				return QuoteOutput{}, err
+			}
+			return QuoteOutput{Provider: provider, Quote: q}, nil
+		},
+	)
+}
+
+// HistoryInput 历史 K 线查询输入
+type HistoryInput struct {
+	Symbol   string `json:"symbol"`
+	Interval string `json:"interval,omitempty"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Adjusted bool   `json:"adjusted,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// HistoryOutput 历史 K 线查询输出
+type HistoryOutput struct {
+	Provider string `json:"provider"`
+	Symbol   string `json:"symbol"`
+	Interval string `json:"interval"`
+	Adjusted bool   `json:"adjusted"`
+	// 省略的较早 K 线数量
+	Omitted int         `json:"omitted,omitempty"`
+	Bars    []tools.Bar `json:"bars"`
+}
+
+// DefineHistoryTool 定义历史 K 线查询工具
+func (r *Router) DefineHistoryTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, HistoryToolName, fmt.Sprintf(`Get historical OHLCV bars (K-lines) of a stock, ETF or index from the configured market data providers.
+
+输出的 K 线可以直接被 Indicators 、 Backtest 工具引用。
+
+以 JSON 格式输入：
+%s
+- **interval**: (string,optional) K 线周期，可选 %s ，默认 daily
+- **from** / **to**: (string,optional) 起止日期（含），格式 YYYY-MM-DD
+- **adjusted**: (bool,optional) 是否复权，提供商不支持时忽略
+- **limit**: (int,optional) 最多返回最近多少根 K 线，默认 %d ，最大 %d
+%s
+
+输出：
+- **provider**: 数据来源的提供商
+- **symbol** / **interval** / **adjusted**: 查询参数
+- **omitted**: 超出 limit 而省略的较早 K 线数量
+- **bars**: 按时间升序排列的 K 线
+`, symbolDesc, strings.Join(tools.Intervals, " 、 "), DefaultHistoryLimit, MaxHistoryLimit, providerDesc),
+		func(ctx *ai.ToolContext, in HistoryInput) (HistoryOutput, error) {
+			if in.Symbol == "" {
+				return HistoryOutput{}, fmt.Errorf("symbol is required")
+			}
+			if in.Interval == "" {
+				in.Interval = tools.IntervalDaily
+			}
+			if !slices.Contains(tools.Intervals, in.Interval) {
+				return HistoryOutput{}, fmt.Errorf("invalid interval %q (expected: %s)",
+					in.Interval, strings.Join(tools.Intervals, ", "))
+			}
+			from, to, err := parseRange(in.From, in.To)
+			if err != nil {
+				return HistoryOutput{}, err
+			}
+			switch {
+			case in.Limit <= 0:
+				in.Limit = DefaultHistoryLimit
+			case in.Limit > MaxHistoryLimit:
+				in.Limit = MaxHistoryLimit
+			}
+
+			bars, provider, err := r.History(ctx, tools.HistoryRequest{
+				Symbol:   in.Symbol,
+				Interval: in.Interval,
+				From:     from,
+				To:       to,
+				Adjusted: in.Adjusted,
+			}, in.Provider)
+			if err != nil {
+				return HistoryOutput{}, err
+			}
+			ret := HistoryOutput{
+				Provider: provider,
+				Symbol:   tools.CanonicalSymbol(in.Symbol),
+				Interval: in.Interval,
+				Adjusted: in.Adjusted,
+				Bars:     bars,
+			}
+			if len(bars) > in.Limit {
+				ret.Omitted = len(bars) - in.Limit
+				ret.Bars = bars[ret.Omitted:]
+			}
+			return ret, nil
+		},
+	)
+}
+
+// FundamentalsInput 基本面查询输入
+type FundamentalsInput struct {
+	Symbol   string `json:"symbol"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// FundamentalsOutput 基本面查询输出
+type FundamentalsOutput struct {
+	Provider     string             `json:"provider"`
+	Fundamentals tools.Fundamentals `json:"fundamentals"`
+}
+
+// DefineFundamentalsTool 定义基本面查询工具
+func (r *Router) DefineFundamentalsTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, FundamentalsToolName, `Get company profile and key fundamentals of a stock from the configured market data providers.
+
+以 JSON 格式输入：
+`+symbolDesc+`
+`+providerDesc+`
+
+输出：
+- **provider**: 数据来源的提供商
+- **fundamentals**: 名称、交易所、货币、行业、总市值、总股本、市盈率、市净率、每股收益、股息率（%）、营业收入、净利润、净资产收益率（%）、最近财报期等，未知的字段为空；extra 中为提供商提供的其它字段
+`,
+		func(ctx *ai.ToolContext, in FundamentalsInput) (FundamentalsOutput, error) {
+			if in.Symbol == "" {
+				return FundamentalsOutput{}, fmt.Errorf("symbol is required")
+			}
+			f, provider, err := r.Fundamentals(ctx, in.Symbol, in.Provider)
+			if err != nil {
+				return FundamentalsOutput{}, err
+			}
+			return FundamentalsOutput{Provider: provider, Fundamentals: f}, nil
+		},
+	)
+}
+
+// CorporateActionsInput 公司行动查询输入
+type CorporateActionsInput struct {
+	Symbol   string `json:"symbol"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// CorporateActionsOutput 公司行动查询输出
+type CorporateActionsOutput struct {
+	Provider string         `json:"provider"`
+	Actions  []actionOutput `json:"actions"`
+}
+
+// actionOutput 输出的公司行动，日期只输出日期部分
+type actionOutput struct {
+	Type        string              `json:"type"`
+	ExDate      string              `json:"exDate"`
+	Amount      decimal.NullDecimal `json:"amount"`
+	Ratio       decimal.NullDecimal `json:"ratio"`
+	Description string              `json:"description,omitempty"`
+}
+
+// DefineCorporateActionsTool 定义公司行动查询工具
+func (r *Router) DefineCorporateActionsTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, CorporateActionsToolName, `Get dividends and splits of a stock from the configured market data providers.
+
+以 JSON 格式输入：
+`+symbolDesc+`
+- **from** / **to**: (string,optional) 除权除息日的起止日期（含），格式 YYYY-MM-DD
+`+providerDesc+`
+
+输出：
+- **provider**: 数据来源的提供商
+- **actions**: 按除权除息日升序排列的公司行动，包括类型（ dividend 或 split ）、除权除息日、每股现金分红、拆合股比例（如 4 表示 1 拆 4 ）和说明
+`,
+		func(ctx *ai.ToolContext, in CorporateActionsInput) (CorporateActionsOutput, error) {
+			if in.Symbol == "" {
+				return CorporateActionsOutput{}, fmt.Errorf("symbol is required")
+			}
+			from, to, err := parseRange(in.From, in.To)
+			if err != nil {
+				return CorporateActionsOutput{}, err
+			}
+			actions, provider, err := r.CorporateActions(ctx, in.Symbol, from, to, in.Provider)
+			if err != nil {
+				return CorporateActionsOutput{}, err
+			}
+			ret := CorporateActionsOutput{Provider: provider, Actions: make([]actionOutput, len(actions))}
+			for i, a := range actions {
+				ret.Actions[i] = actionOutput{
+					Type:        a.Type,
+					ExDate:      tools.FormatBarTime(a.ExDate),
+					Amount:      a.Amount,
+					Ratio:       a.Ratio,
+					Description: a.Description,
+				}
+			}
+			return ret, nil
+		},
+	)
+}
+
+// SymbolSearchInput 证券搜索输入
+type SymbolSearchInput struct {
+	Query    string `json:"query"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// SymbolSearchOutput 证券搜索输出
+type SymbolSearchOutput struct {
+	Matches []tools.SymbolMatch `json:"matches"`
+}
+
+// DefineSymbolSearchTool 定义证券搜索工具
+func (r *Router) DefineSymbolSearchTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, SymbolSearchToolName, fmt.Sprintf(`Search stocks, ETFs and indices by code or name in all configured market data providers.
+
+不确定证券代码时，应先用该工具搜索，再用其它 Market 开头的工具查询。
+
+以 JSON 格式输入：
+- **query**: (string) 代码或名称关键词，如 腾讯 、 Apple 、 600519
+%s
+
+输出：
+- **matches**: 最多 %d 个搜索结果，包括代码、名称、交易所、类型、货币和市场
+`, providerDesc, MaxSearchResults),
+		func(ctx *ai.ToolContext, in SymbolSearchInput) (SymbolSearchOutput, error) {
+			if strings.TrimSpace(in.Query) == "" {
+				return SymbolSearchOutput{}, fmt.Errorf("query is required")
+			}
+			matches, err := r.SearchSymbols(ctx, in.Query, in.Provider)
+			if err != nil {
+				return SymbolSearchOutput{}, err
+			}
+			if len(matches) > MaxSearchResults {
+				matches = matches[:MaxSearchResults]
+			}
+			return SymbolSearchOutput{Matches: matches}, nil
+		},
+	)
+}
+
+// parseRange 解析起止日期，与日线 K 线一致使用 UTC ，结束日期包含当天
+func parseRange(from, to string) (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if from != "" {
+		if start, err = time.Parse(time.DateOnly, from); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from %q (expected format: YYYY-MM-DD)", from)
+		}
+	}
+	if to != "" {
+		if end, err = time.Parse(time.DateOnly, to); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to %q (expected format: YYYY-MM-DD)", to)
+		}
+		end = end.Add(24*time.Hour - time.Nanosecond)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,196 @@
+package options
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBlackScholes 测试 Black-Scholes 定价和希腊值
+//
+// 参考值来自 Hull, Options, Futures, and Other Derivatives 例 15.6 、 19.1 等
+func TestBlackScholes(t *testing.T) {
+	p := Params{Type: Call, Underlying: 42, Strike: 40, T: 0.5, Rate: 0.1, Volatility: 0.2}
+	call, err := Price(ModelBlackScholes, p)
+	require.NoError(t, err)
+	assert.InDelta(t, 4.76, call.Price, 0.005)
+	assert.InDelta(t, 2, call.Intrinsic, 1e-9)
+	assert.InDelta(t, call.Price-2, call.TimeValue, 1e-9)
+
+	p.Type = Put
+	put, err := Price(ModelBlackScholes, p)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.81, put.Price, 0.005)
+
+	// 平价关系 C - P = S - K e^{-rT}
+	assert.InDelta(t, 42-40*0.951229424500714, call.Price-put.Price, 1e-9)
+
+	p = Params{Type: Call, Underlying: 49, Strike: 50, T: 0.3846, Rate: 0.05, Volatility: 0.2}
+	call, err = Price(ModelBlackScholes, p)
+	require.NoError(t, err)
+	assert.InDelta(t, 2.40, call.Price, 0.005)
+	assert.InDelta(t, 0.522, call.Greeks.Delta, 0.001)
+	assert.InDelta(t, 0.066, call.Greeks.Gamma, 0.001)
+	assert.InDelta(t, -4.31/365, call.Greeks.Theta, 0.0002)
+	assert.InDelta(t, 0.121, call.Greeks.Vega, 0.001)
+	assert.InDelta(t, 0.0891, call.Greeks.Rho, 0.0005)
+}
+
+// TestBlack76 测试 Black-76 定价
+func TestBlack76(t *testing.T) {
+	// Hull 例 18.8
+	p := Params{Type: Put, Underlying: 20, Strike: 20, T: 4.0 / 12, Rate: 0.09, Volatility: 0.25}
+	put, err := Price(ModelBlack76, p)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.12, put.Price, 0.005)
+
+	// 平值期货期权看涨看跌价格相等
+	p.Type = Call
+	call, err := Price(ModelBlack76, p)
+	require.NoError(t, err)
+	assert.InDelta(t, put.Price, call.Price, 1e-9)
+}
+
+// TestBinomial 测试二叉树定价
+func TestBinomial(t *testing.T) {
+	// Hull 例 21.1 ，美式看跌期权价值约 4.28
+	p := Params{Type: Put, Underlying: 50, Strike: 50, T: 5.0 / 12, Rate: 0.1, Volatility: 0.4, American: true}
+	american, err := Price(ModelBinomial, p)
+	require.NoError(t, err)
+	assert.InDelta(t, 4.28, american.Price, 0.01)
+	assert.Less(t, american.Greeks.Delta, 0.0)
+	assert.Greater(t, american.Greeks.Gamma, 0.0)
+	assert.Greater(t, american.Greeks.Vega, 0.0)
+
+	// 欧式期权收敛到 Black-Scholes
+	p.American = false
+	european, err := Price(ModelBinomial, p)
+	require.NoError(t, err)
+	bs := BlackScholes(p)
+	assert.InDelta(t, bs.Price, european.Price, 0.01)
+	assert.InDelta(t, bs.Greeks.Delta, european.Greeks.Delta, 0.01)
+	assert.InDelta(t, bs.Greeks.Vega, european.Greeks.Vega, 0.01)
+	assert.Greater(t, american.Price, european.Price)
+
+	_, err = Price(ModelBlackScholes, Params{Type: Put, Underlying: 50, Strike: 50, T: 1, American: true})
+	assert.Error(t, err)
+}
+
+// TestImpliedVolatility 测试隐含波动率求解
+func TestImpliedVolatility(t *testing.T) {
+	for _, model := range []Model{ModelBlackScholes, ModelBlack76, ModelBinomial} {
+		p := Params{Type: Call, Underlying: 100, Strike: 105, T: 0.25, Rate: 0.03, Volatility: 0.35, Steps: 200}
+		r, err := Price(model, p)
+		require.NoError(t, err, model)
+
+		p.Volatility = 0
+		iv, err := ImpliedVolatility(model, p, r.Price)
+		require.NoError(t, err, model)
+		assert.InDelta(t, 0.35, iv, 1e-4, model)
+	}
+
+	// 价格低于内在价值无解
+	_, err := ImpliedVolatility(ModelBlackScholes, Params{Type: Call, Underlying: 100, Strike: 80, T: 0.25}, 10)
+	assert.Error(t, err)
+}
+
+// TestAnalyzePayoff 测试期权组合到期盈亏分析
+func TestAnalyzePayoff(t *testing.T) {
+	// 备兑看涨：持有标的（成本 100 ），卖出 110 看涨（权利金 3 ）
+	payoff, err := AnalyzePayoff([]Leg{
+		{Type: LegStock, Premium: 100},
+		{Type: LegCall, Position: "short", Strike: 110, Premium: 3},
+	}, 1, 100, 0, 0, 0)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{97}, payoff.Breakevens, 1e-9)
+	require.NotNil(t, payoff.MaxProfit)
+	assert.InDelta(t, 13, *payoff.MaxProfit, 1e-9)
+	require.NotNil(t, payoff.MaxLoss)
+	assert.InDelta(t, -97, *payoff.MaxLoss, 1e-9)
+	assert.InDelta(t, 97, payoff.NetPremium, 1e-9)
+
+	// 保护性看跌：持有标的（成本 100 ），买入 95 看跌（权利金 2 ），乘数 100
+	payoff, err = AnalyzePayoff([]Leg{
+		{Type: LegStock, Premium: 100},
+		{Type: LegPut, Strike: 95, Premium: 2},
+	}, 100, 0, 80, 120, 5)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{102}, payoff.Breakevens, 1e-9)
+	assert.Nil(t, payoff.MaxProfit)
+	require.NotNil(t, payoff.MaxLoss)
+	assert.InDelta(t, -700, *payoff.MaxLoss, 1e-9)
+	var prices []float64
+	for _, row := range payoff.Rows {
+		prices = append(prices, row.Underlying)
+	}
+	assert.Equal(t, []float64{80, 90, 95, 100, 102, 110, 120}, prices)
+	assert.InDelta(t, -700, payoff.Rows[0].Total, 1e-9)
+	assert.InDelta(t, 1800, payoff.Rows[len(payoff.Rows)-1].Total, 1e-9)
+
+	// 卖出跨式：两个盈亏平衡点，亏损无下限
+	payoff, err = AnalyzePayoff([]Leg{
+		{Type: LegCall, Position: "short", Strike: 100, Premium: 4},
+		{Type: LegPut, Position: "short", Strike: 100, Premium: 3},
+	}, 1, 0, 0, 0, 0)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{93, 107}, payoff.Breakevens, 1e-9)
+	require.NotNil(t, payoff.MaxProfit)
+	assert.InDelta(t, 7, *payoff.MaxProfit, 1e-9)
+	assert.Nil(t, payoff.MaxLoss)
+
+	_, err = AnalyzePayoff([]Leg{{Type: LegCall, Premium: 1}}, 1, 0, 0, 0, 0)
+	assert.Error(t, err)
+}
+
+// TestCalculate 测试工具输入转换
+func TestCalculate(t *testing.T) {
+	now := time.Date(2025, 1, 1, 15, 0, 0, 0, time.UTC)
+	out, err := Calculate(Input{
+		Type:       "call",
+		Underlying: 42,
+		Strike:     40,
+		Expiry:     "2025-07-02",
+		Rate:       10,
+		Volatility: 20,
+	}, now)
+	require.NoError(t, err)
+	require.NotNil(t, out.Price)
+	assert.Equal(t, ModelBlackScholes, out.Price.Model)
+	assert.InDelta(t, 182.0/365, out.Price.T, 1e-4)
+	assert.InDelta(t, 4.76, out.Price.Result.Price, 0.01)
+
+	out, err = Calculate(Input{
+		Action:     ActionImpliedVolatility,
+		Type:       "put",
+		Style:      "american",
+		Underlying: 50,
+		Strike:     50,
+		Days:       152,
+		Rate:       10,
+		Price:      4.28,
+		Steps:      200,
+	}, now)
+	require.NoError(t, err)
+	require.NotNil(t, out.ImpliedVolatility)
+	assert.Equal(t, ModelBinomial, out.ImpliedVolatility.Model)
+	assert.InDelta(t, 40, out.ImpliedVolatility.ImpliedVolatility, 0.5)
+
+	out, err = Calculate(Input{
+		Action: ActionPayoff,
+		Legs: []Leg{
+			{Type: "call", Position: "buy", Strike: 100, Premium: 5},
+			{Type: "call", Position: "sell", Strike: 110, Premium: 2},
+		},
+	}, now)
+	require.NoError(t, err)
+	require.NotNil(t, out.Payoff)
+	assert.Equal(t, []float64{103}, out.Payoff.Breakevens)
+	assert.Equal(t, "7", out.Payoff.MaxProfit)
+	assert.Equal(t, "-3", out.Payoff.MaxLoss)
+	assert.Contains(t, out.Payoff.Table, "| Underlying | #1 long call 100 | #2 short call 110 | Total |")
+
+	_, err = Calculate(Input{Action: "unknown"}, now)
+	assert.Error(t, err)
+}
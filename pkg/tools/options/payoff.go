@@ -0,0 +1,251 @@
+package options
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// LegType 组合腿的类型
+type LegType string
+
+// 组合腿的类型
+const (
+	LegCall  LegType = "call"
+	LegPut   LegType = "put"
+	LegStock LegType = "stock"
+)
+
+// Leg 期权组合的一条腿
+type Leg struct {
+	// 类型， call 、 put 或 stock
+	Type LegType `json:"type"`
+	// 方向， long 或 short
+	Position string `json:"position"`
+	// 数量（合约数或股数）
+	Quantity float64 `json:"quantity,omitempty"`
+	// 行权价，标的腿为空
+	Strike float64 `json:"strike,omitempty"`
+	// 期权权利金或标的买入价
+	Premium float64 `json:"premium"`
+}
+
+// sign 多头为 1 ，空头为 -1
+func (l Leg) sign() float64 {
+	if l.Position == "short" {
+		return -1
+	}
+	return 1
+}
+
+// quantity 数量，未指定时为 1
+func (l Leg) quantity() float64 {
+	if l.Quantity == 0 {
+		return 1
+	}
+	return l.Quantity
+}
+
+// normalize 规范化并校验
+func (l Leg) normalize() (Leg, error) {
+	l.Type = LegType(strings.ToLower(strings.TrimSpace(string(l.Type))))
+	switch l.Type {
+	case "c":
+		l.Type = LegCall
+	case "p":
+		l.Type = LegPut
+	case "underlying", "shares", "future":
+		l.Type = LegStock
+	}
+	l.Position = strings.ToLower(strings.TrimSpace(l.Position))
+	switch l.Position {
+	case "", "buy", "long":
+		l.Position = "long"
+	case "sell", "short", "write":
+		l.Position = "short"
+	default:
+		return Leg{}, fmt.Errorf("invalid position %q (expected: long or short)", l.Position)
+	}
+	switch {
+	case l.Type != LegCall && l.Type != LegPut && l.Type != LegStock:
+		return Leg{}, fmt.Errorf("invalid leg type %q (expected: call, put or stock)", l.Type)
+	case l.Type != LegStock && l.Strike <= 0:
+		return Leg{}, fmt.Errorf("strike of %s leg must be positive", l.Type)
+	case l.Quantity < 0:
+		return Leg{}, fmt.Errorf("quantity must not be negative, use position short instead")
+	case l.Premium < 0:
+		return Leg{}, fmt.Errorf("premium must not be negative")
+	}
+	return l, nil
+}
+
+// payoff 到期时标的价格为 x 时该腿的盈亏（未乘合约乘数）
+func (l Leg) payoff(x float64) float64 {
+	var value float64
+	switch l.Type {
+	case LegCall:
+		value = math.Max(x-l.Strike, 0)
+	case LegPut:
+		value = math.Max(l.Strike-x, 0)
+	case LegStock:
+		value = x
+	}
+	return l.sign() * l.quantity() * (value - l.Premium)
+}
+
+// slope 标的价格足够高时该腿盈亏随价格变化的斜率
+func (l Leg) slope() float64 {
+	if l.Type == LegPut {
+		return 0
+	}
+	return l.sign() * l.quantity()
+}
+
+// PayoffRow 盈亏表的一行
+type PayoffRow struct {
+	// 到期时标的价格
+	Underlying float64 `json:"underlying"`
+	// 各腿盈亏
+	Legs []float64 `json:"legs"`
+	// 合计盈亏
+	Total float64 `json:"total"`
+}
+
+// Payoff 期权组合到期盈亏分析
+type Payoff struct {
+	Legs []Leg `json:"legs"`
+	// 合约乘数
+	Multiplier float64 `json:"multiplier"`
+	// 净权利金，正数为净支出，负数为净收入（标的腿按买入价计入）
+	NetPremium float64 `json:"netPremium"`
+	// 盈亏平衡点
+	Breakevens []float64 `json:"breakevens"`
+	// 最大盈利，无上限时无效
+	MaxProfit *float64 `json:"maxProfit"`
+	// 最大亏损（负数），无下限时无效
+	MaxLoss *float64 `json:"maxLoss"`
+	// 盈亏表
+	Rows []PayoffRow `json:"rows"`
+}
+
+// DefaultPayoffPoints 盈亏表默认的价格点数
+const DefaultPayoffPoints = 21
+
+// AnalyzePayoff 分析期权组合的到期盈亏
+//
+// 盈亏表的价格范围为 [from, to] ，均为 0 时根据行权价和 reference 自动确定；行权价和盈亏平衡点总会包含在表中
+func AnalyzePayoff(legs []Leg, multiplier, reference, from, to float64, points int) (Payoff, error) {
+	if len(legs) == 0 {
+		return Payoff{}, fmt.Errorf("at least one leg is required")
+	}
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	if points <= 1 {
+		points = DefaultPayoffPoints
+	}
+
+	ret := Payoff{Multiplier: multiplier}
+	kinks := []float64{0}
+	for _, l := range legs {
+		l, err := l.normalize()
+		if err != nil {
+			return Payoff{}, err
+		}
+		ret.Legs = append(ret.Legs, l)
+		ret.NetPremium += l.sign() * l.quantity() * l.Premium * multiplier
+		if l.Type != LegStock {
+			kinks = append(kinks, l.Strike)
+		}
+	}
+	slices.Sort(kinks)
+	kinks = slices.Compact(kinks)
+
+	total := func(x float64) float64 {
+		sum := 0.0
+		for _, l := range ret.Legs {
+			sum += l.payoff(x)
+		}
+		return sum * multiplier
+	}
+	rightSlope := 0.0
+	for _, l := range ret.Legs {
+		rightSlope += l.slope()
+	}
+
+	// 盈亏是分段线性函数，极值在拐点处取得，盈亏平衡点在相邻拐点之间线性求解
+	maxProfit, maxLoss := math.Inf(-1), math.Inf(1)
+	for i, k := range kinks {
+		v := total(k)
+		maxProfit, maxLoss = math.Max(maxProfit, v), math.Min(maxLoss, v)
+		if v == 0 && (i == 0 || total(kinks[i-1]) != 0) {
+			ret.Breakevens = append(ret.Breakevens, k)
+		}
+		if i == len(kinks)-1 {
+			break
+		}
+		next := total(kinks[i+1])
+		if (v < 0 && next > 0) || (v > 0 && next < 0) {
+			ret.Breakevens = append(ret.Breakevens, k+(kinks[i+1]-k)*(-v)/(next-v))
+		}
+	}
+	if last := kinks[len(kinks)-1]; rightSlope != 0 {
+		v := total(last)
+		if (v < 0 && rightSlope > 0) || (v > 0 && rightSlope < 0) {
+			ret.Breakevens = append(ret.Breakevens, last-v/(rightSlope*multiplier))
+		}
+	}
+	if rightSlope <= 0 {
+		ret.MaxProfit = &maxProfit
+	}
+	if rightSlope >= 0 {
+		ret.MaxLoss = &maxLoss
+	}
+
+	// 价格范围
+	if from <= 0 && to <= 0 {
+		lo, hi := kinks[len(kinks)-1], kinks[len(kinks)-1]
+		if len(kinks) > 1 {
+			lo = kinks[1]
+		}
+		if reference > 0 {
+			lo, hi = math.Min(lo, reference), math.Max(hi, reference)
+		}
+		if hi == 0 {
+			for _, l := range ret.Legs {
+				hi = math.Max(hi, l.Premium)
+			}
+			lo = hi
+		}
+		from, to = lo*0.7, hi*1.3
+	}
+	if to <= from {
+		return Payoff{}, fmt.Errorf("invalid price range [%g, %g]", from, to)
+	}
+	prices := make([]float64, 0, points+len(kinks)+len(ret.Breakevens))
+	for i := 0; i < points; i++ {
+		prices = append(prices, roundTo(from+(to-from)*float64(i)/float64(points-1), 4))
+	}
+	for _, x := range append(kinks[1:], ret.Breakevens...) {
+		if x >= from && x <= to {
+			prices = append(prices, roundTo(x, 4))
+		}
+	}
+	slices.Sort(prices)
+	prices = slices.Compact(prices)
+	for _, x := range prices {
+		row := PayoffRow{Underlying: x, Total: total(x)}
+		for _, l := range ret.Legs {
+			row.Legs = append(row.Legs, l.payoff(x)*multiplier)
+		}
+		ret.Rows = append(ret.Rows, row)
+	}
+	return ret, nil
+}
+
+// roundTo 保留 places 位小数
+func roundTo(v float64, places int) float64 {
+	p := math.Pow10(places)
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,391 @@
+package options
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// OptionType 期权类型
+type OptionType string
+
+// 期权类型
+const (
+	Call OptionType = "call"
+	Put  OptionType = "put"
+)
+
+// ParseOptionType 解析期权类型
+func ParseOptionType(s string) (OptionType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "call", "c":
+		return Call, nil
+	case "put", "p":
+		return Put, nil
+	}
+	return "", fmt.Errorf("invalid option type %q (expected: call or put)", s)
+}
+
+// Model 定价模型
+type Model string
+
+// 定价模型
+const (
+	// ModelBlackScholes Black-Scholes-Merton 模型，欧式期权，标的为现货，支持连续股息率
+	ModelBlackScholes Model = "blackScholes"
+	// ModelBlack76 Black-76 模型，欧式期权，标的为期货或远期
+	ModelBlack76 Model = "black76"
+	// ModelBinomial Cox-Ross-Rubinstein 二叉树模型，支持美式期权
+	ModelBinomial Model = "binomial"
+)
+
+// ParseModel 解析定价模型
+func ParseModel(s string) (Model, error) {
+	switch strings.ToLower(strings.NewReplacer("-", "", "_", "", " ", "").Replace(s)) {
+	case "", "blackscholes", "bs", "bsm":
+		return ModelBlackScholes, nil
+	case "black76", "black":
+		return ModelBlack76, nil
+	case "binomial", "crr", "tree":
+		return ModelBinomial, nil
+	}
+	return "", fmt.Errorf("invalid model %q (expected: blackScholes, black76 or binomial)", s)
+}
+
+// DefaultSteps 二叉树默认步数
+const DefaultSteps = 500
+
+// Params 期权定价参数，利率、股息率和波动率均为小数（如 0.05 表示 5% ）
+type Params struct {
+	Type OptionType
+	// 标的价格， Black-76 模型为期货或远期价格
+	Underlying float64
+	// 行权价
+	Strike float64
+	// 剩余期限（年）
+	T float64
+	// 无风险利率（连续复利）
+	Rate float64
+	// 连续股息率，Black-76 模型忽略
+	Dividend float64
+	// 波动率
+	Volatility float64
+	// 是否美式期权，仅二叉树模型支持
+	American bool
+	// 二叉树步数
+	Steps int
+}
+
+// Validate 校验参数
+func (p Params) Validate() error {
+	switch {
+	case p.Type != Call && p.Type != Put:
+		return fmt.Errorf("invalid option type %q", p.Type)
+	case p.Underlying <= 0:
+		return fmt.Errorf("underlying price must be positive")
+	case p.Strike <= 0:
+		return fmt.Errorf("strike must be positive")
+	case p.T < 0:
+		return fmt.Errorf("time to expiry must not be negative")
+	case p.Volatility < 0:
+		return fmt.Errorf("volatility must not be negative")
+	}
+	return nil
+}
+
+// Greeks 希腊值
+type Greeks struct {
+	// 标的价格变动 1 时期权价格的变动
+	Delta float64 `json:"delta"`
+	// 标的价格变动 1 时 Delta 的变动
+	Gamma float64 `json:"gamma"`
+	// 每过 1 个自然日期权价格的变动
+	Theta float64 `json:"thetaPerDay"`
+	// 波动率变动 1 个百分点时期权价格的变动
+	Vega float64 `json:"vegaPerPoint"`
+	// 利率变动 1 个百分点时期权价格的变动
+	Rho float64 `json:"rhoPerPoint"`
+}
+
+// Result 定价结果
+type Result struct {
+	Price  float64 `json:"price"`
+	Greeks Greeks  `json:"greeks"`
+	// 内在价值
+	Intrinsic float64 `json:"intrinsic"`
+	// 时间价值
+	TimeValue float64 `json:"timeValue"`
+}
+
+// Price 使用指定模型为期权定价并计算希腊值
+func Price(model Model, p Params) (Result, error) {
+	if err := p.Validate(); err != nil {
+		return Result{}, err
+	}
+	var ret Result
+	switch model {
+	case ModelBlackScholes:
+		if p.American {
+			return Result{}, fmt.Errorf("model %s only supports european options, use %s for american options", model, ModelBinomial)
+		}
+		ret = BlackScholes(p)
+	case ModelBlack76:
+		if p.American {
+			return Result{}, fmt.Errorf("model %s only supports european options, use %s for american options", model, ModelBinomial)
+		}
+		ret = Black76(p)
+	case ModelBinomial:
+		var err error
+		if ret, err = Binomial(p); err != nil {
+			return Result{}, err
+		}
+	default:
+		return Result{}, fmt.Errorf("invalid model %q", model)
+	}
+	ret.Intrinsic = intrinsic(p.Type, p.Underlying, p.Strike)
+	ret.TimeValue = ret.Price - ret.Intrinsic
+	return ret, nil
+}
+
+// intrinsic 内在价值
+func intrinsic(t OptionType, underlying, strike float64) float64 {
+	if t == Call {
+		return math.Max(underlying-strike, 0)
+	}
+	return math.Max(strike-underlying, 0)
+}
+
+// normCDF 标准正态分布的累积分布函数
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// normPDF 标准正态分布的概率密度函数
+func normPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+// expired 已到期或波动率为 0 时的结果，价格为远期内在价值的折现
+func expired(p Params, q float64) Result {
+	df, dq := math.Exp(-p.Rate*p.T), math.Exp(-q*p.T)
+	forward := p.Underlying * dq
+	strike := p.Strike * df
+	ret := Result{}
+	switch {
+	case p.Type == Call && forward > strike:
+		ret.Price, ret.Greeks.Delta = forward-strike, dq
+	case p.Type == Put && strike > forward:
+		ret.Price, ret.Greeks.Delta = strike-forward, -dq
+	}
+	return ret
+}
+
+// BlackScholes 使用 Black-Scholes-Merton 模型为欧式期权定价
+func BlackScholes(p Params) Result {
+	return blackScholes(p, p.Dividend)
+}
+
+// Black76 使用 Black-76 模型为期货或远期的欧式期权定价
+func Black76(p Params) Result {
+	// Black-76 等价于股息率等于无风险利率的 Black-Scholes-Merton 模型，但期货价格不随利率变化
+	ret := blackScholes(p, p.Rate)
+	ret.Greeks.Rho = -p.T * ret.Price / 100
+	return ret
+}
+
+// blackScholes 使用 Black-Scholes-Merton 模型定价， q 为连续股息率
+func blackScholes(p Params, q float64) Result {
+	if p.T == 0 || p.Volatility == 0 {
+		return expired(p, q)
+	}
+	s, k, t, r, sigma := p.Underlying, p.Strike, p.T, p.Rate, p.Volatility
+	sqrtT := math.Sqrt(t)
+	d1 := (math.Log(s/k) + (r-q+sigma*sigma/2)*t) / (sigma * sqrtT)
+	d2 := d1 - sigma*sqrtT
+	df, dq := math.Exp(-r*t), math.Exp(-q*t)
+
+	ret := Result{}
+	g := &ret.Greeks
+	g.Gamma = dq * normPDF(d1) / (s * sigma * sqrtT)
+	g.Vega = s * dq * normPDF(d1) * sqrtT / 100
+	decay := -s * dq * normPDF(d1) * sigma / (2 * sqrtT)
+	if p.Type == Call {
+		ret.Price = s*dq*normCDF(d1) - k*df*normCDF(d2)
+		g.Delta = dq * normCDF(d1)
+		g.Theta = (decay - r*k*df*normCDF(d2) + q*s*dq*normCDF(d1)) / 365
+		g.Rho = k * t * df * normCDF(d2) / 100
+	} else {
+		ret.Price = k*df*normCDF(-d2) - s*dq*normCDF(-d1)
+		g.Delta = dq * (normCDF(d1) - 1)
+		g.Theta = (decay + r*k*df*normCDF(-d2) - q*s*dq*normCDF(-d1)) / 365
+		g.Rho = -k * t * df * normCDF(-d2) / 100
+	}
+	return ret
+}
+
+// Binomial 使用 Cox-Ross-Rubinstein 二叉树模型定价，支持美式期权
+//
+// Delta 、 Gamma 、 Theta 由树的前两步节点计算， Vega 和 Rho 使用中心差分计算
+func Binomial(p Params) (Result, error) {
+	if err := p.Validate(); err != nil {
+		return Result{}, err
+	}
+	if p.T == 0 || p.Volatility == 0 {
+		if p.American {
+			ret := Result{Price: intrinsic(p.Type, p.Underlying, p.Strike)}
+			if ret.Price > 0 {
+				ret.Greeks.Delta = 1
+				if p.Type == Put {
+					ret.Greeks.Delta = -1
+				}
+			}
+			return ret, nil
+		}
+		return expired(p, p.Dividend), nil
+	}
+	if p.Steps <= 0 {
+		p.Steps = DefaultSteps
+	}
+
+	price, nodes, err := binomialTree(p)
+	if err != nil {
+		return Result{}, err
+	}
+	dt := p.T / float64(p.Steps)
+	u := math.Exp(p.Volatility * math.Sqrt(dt))
+	d := 1 / u
+	s := p.Underlying
+
+	ret := Result{Price: price}
+	g := &ret.Greeks
+	if p.Steps >= 2 {
+		// nodes[0] 为第 2 步的 3 个节点，nodes[1] 为第 1 步的 2 个节点
+		g.Delta = (nodes[1][1] - nodes[1][0]) / (s*u - s*d)
+		deltaUp := (nodes[0][2] - nodes[0][1]) / (s*u*u - s)
+		deltaDown := (nodes[0][1] - nodes[0][0]) / (s - s*d*d)
+		g.Gamma = (deltaUp - deltaDown) / ((s*u*u - s*d*d) / 2)
+		g.Theta = (nodes[0][1] - price) / (2 * dt) / 365
+	}
+
+	bump := func(mutate func(q *Params, h float64)) (float64, error) {
+		const h = 0.01
+		up, down := p, p
+		mutate(&up, h)
+		mutate(&down, -h)
+		pu, _, err := binomialTree(up)
+		if err != nil {
+			return 0, err
+		}
+		pd, _, err := binomialTree(down)
+		if err != nil {
+			return 0, err
+		}
+		return (pu - pd) / 2, nil
+	}
+	if p.Volatility > 0.01 {
+		if g.Vega, err = bump(func(q *Params, h float64) { q.Volatility += h }); err != nil {
+			return Result{}, err
+		}
+	}
+	if g.Rho, err = bump(func(q *Params, h float64) { q.Rate += h }); err != nil {
+		return Result{}, err
+	}
+	return ret, nil
+}
+
+// binomialTree 构建二叉树并倒推期权价格，返回价格和第 2 步、第 1 步的节点价格
+func binomialTree(p Params) (float64, [2][]float64, error) {
+	n := p.Steps
+	dt := p.T / float64(n)
+	u := math.Exp(p.Volatility * math.Sqrt(dt))
+	d := 1 / u
+	prob := (math.Exp((p.Rate-p.Dividend)*dt) - d) / (u - d)
+	if prob <= 0 || prob >= 1 {
+		return 0, [2][]float64{}, fmt.Errorf("invalid risk-neutral probability %g, increase steps", prob)
+	}
+	disc := math.Exp(-p.Rate * dt)
+
+	values := make([]float64, n+1)
+	for i := 0; i <= n; i++ {
+		values[i] = intrinsic(p.Type, p.Underlying*math.Pow(u, float64(2*i-n)), p.Strike)
+	}
+	var step1, step2 []float64
+	for step := n - 1; step >= 0; step-- {
+		for i := 0; i <= step; i++ {
+			v := disc * (prob*values[i+1] + (1-prob)*values[i])
+			if p.American {
+				v = math.Max(v, intrinsic(p.Type, p.Underlying*math.Pow(u, float64(2*i-step)), p.Strike))
+			}
+			values[i] = v
+		}
+		switch step {
+		case 2:
+			step2 = append([]float64(nil), values[:3]...)
+		case 1:
+			step1 = append([]float64(nil), values[:2]...)
+		}
+	}
+	return values[0], [2][]float64{step2, step1}, nil
+}
+
+// ImpliedVolatility 根据期权价格求解隐含波动率，返回小数形式的波动率
+//
+// 在 [0.0001, 5] 区间内使用二分法求解，价格精度 1e-8 ；二叉树模型的下限受步数限制
+func ImpliedVolatility(model Model, p Params, price float64) (float64, error) {
+	if err := p.Validate(); err != nil {
+		return 0, err
+	}
+	if p.T == 0 {
+		return 0, fmt.Errorf("cannot solve implied volatility of an expired option")
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("option price must be positive")
+	}
+
+	if model == ModelBinomial && p.Steps <= 0 {
+		p.Steps = DefaultSteps
+	}
+	priceAt := func(sigma float64) (float64, error) {
+		q := p
+		q.Volatility = sigma
+		if model == ModelBinomial {
+			// 只需要价格，不计算希腊值
+			price, _, err := binomialTree(q)
+			return price, err
+		}
+		r, err := Price(model, q)
+		return r.Price, err
+	}
+	lo, hi := 0.0001, 5.0
+	if model == ModelBinomial {
+		// 波动率过低时二叉树的风险中性概率超出 (0, 1)
+		lo = math.Max(lo, math.Abs(p.Rate-p.Dividend)*math.Sqrt(p.T/float64(p.Steps))*1.01)
+	}
+	pLo, err := priceAt(lo)
+	if err != nil {
+		return 0, err
+	}
+	pHi, err := priceAt(hi)
+	if err != nil {
+		return 0, err
+	}
+	if price < pLo || price > pHi {
+		return 0, fmt.Errorf("price %g is outside the range [%g, %g] implied by volatility 0.01%%-500%%", price, pLo, pHi)
+	}
+	for i := 0; i < 200 && hi-lo > 1e-10; i++ {
+		mid := (lo + hi) / 2
+		pMid, err := priceAt(mid)
+		if err != nil {
+			return 0, err
+		}
+		if math.Abs(pMid-price) < 1e-8 {
+			return mid, nil
+		}
+		if pMid < price {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2, nil
+}
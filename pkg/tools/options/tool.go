@@ -0,0 +1,323 @@
+package options
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+const (
+	// OptionsToolName 期权计算工具名
+	OptionsToolName = "Options"
+	// 输出数值保留的小数位数
+	outputPlaces = 4
+)
+
+// 计算类型
+const (
+	ActionPrice             = "price"
+	ActionImpliedVolatility = "impliedVolatility"
+	ActionPayoff            = "payoff"
+)
+
+// Input 期权计算输入
+type Input struct {
+	// 计算类型， price 、 impliedVolatility 或 payoff
+	Action string `json:"action,omitempty"`
+	// 定价模型
+	Model string `json:"model,omitempty"`
+	// 期权类型， call 或 put
+	Type string `json:"type,omitempty"`
+	// 行权方式， european 或 american
+	Style string `json:"style,omitempty"`
+	// 标的价格
+	Underlying float64 `json:"underlying,omitempty"`
+	// 行权价
+	Strike float64 `json:"strike,omitempty"`
+	// 到期日
+	Expiry string `json:"expiry,omitempty"`
+	// 剩余自然日数
+	Days float64 `json:"days,omitempty"`
+	// 无风险利率（%）
+	Rate float64 `json:"rate,omitempty"`
+	// 连续股息率（%）
+	DividendYield float64 `json:"dividendYield,omitempty"`
+	// 波动率（%）
+	Volatility float64 `json:"volatility,omitempty"`
+	// 期权市场价格，用于求解隐含波动率
+	Price float64 `json:"price,omitempty"`
+	// 二叉树步数
+	Steps int `json:"steps,omitempty"`
+
+	// 组合各腿
+	Legs []Leg `json:"legs,omitempty"`
+	// 合约乘数
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// 盈亏表价格范围
+	From float64 `json:"from,omitempty"`
+	To   float64 `json:"to,omitempty"`
+	// 盈亏表价格点数
+	Points int `json:"points,omitempty"`
+}
+
+// Output 期权计算输出，根据计算类型填充其中一项
+type Output struct {
+	Price             *PriceOutput  `json:"price,omitempty"`
+	ImpliedVolatility *IVOutput     `json:"impliedVolatility,omitempty"`
+	Payoff            *PayoffOutput `json:"payoff,omitempty"`
+}
+
+// PriceOutput 定价输出
+type PriceOutput struct {
+	Model    Model      `json:"model"`
+	Type     OptionType `json:"type"`
+	American bool       `json:"american"`
+	// 剩余期限（年）
+	T      float64 `json:"years"`
+	Result Result  `json:"result"`
+}
+
+// IVOutput 隐含波动率输出
+type IVOutput struct {
+	Model    Model      `json:"model"`
+	Type     OptionType `json:"type"`
+	American bool       `json:"american"`
+	// 剩余期限（年）
+	T float64 `json:"years"`
+	// 隐含波动率（%）
+	ImpliedVolatility float64 `json:"impliedVolatility"`
+	// 以隐含波动率计算的希腊值
+	Greeks Greeks `json:"greeks"`
+}
+
+// PayoffOutput 盈亏分析输出
+type PayoffOutput struct {
+	Multiplier float64 `json:"multiplier"`
+	// 净权利金，正数为净支出，负数为净收入
+	NetPremium float64   `json:"netPremium"`
+	Breakevens []float64 `json:"breakevens"`
+	// 最大盈利，无上限时为 unlimited
+	MaxProfit string `json:"maxProfit"`
+	// 最大亏损，无下限时为 unlimited
+	MaxLoss string `json:"maxLoss"`
+	// 到期盈亏的 Markdown 表格
+	Table string `json:"table"`
+}
+
+// DefineTool 定义期权计算工具
+func DefineTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, OptionsToolName, `Price options, compute Greeks, solve implied volatility and tabulate multi-leg payoffs at expiry.
+
+涉及期权价格、希腊值、隐含波动率或期权组合盈亏时，应使用该工具计算，而不是凭经验估算。
+
+以 JSON 格式输入：
+- **action**: (string,optional) 计算类型， price （默认，定价并计算希腊值）、 impliedVolatility （根据期权价格求解隐含波动率）或 payoff （组合到期盈亏）
+- **model**: (string,optional) 定价模型， blackScholes （默认，现货标的）、 black76 （期货或远期标的）或 binomial （二叉树，支持美式期权）
+- **type**: (string) 期权类型， call 或 put
+- **style**: (string,optional) 行权方式， european （默认）或 american ，美式期权默认使用 binomial 模型
+- **underlying**: (number) 标的价格， black76 模型为期货价格
+- **strike**: (number) 行权价
+- **expiry**: (string,optional) 到期日，格式 YYYY-MM-DD
+- **days**: (number,optional) 剩余自然日数，与 expiry 二选一
+- **rate**: (number,optional) 无风险利率（%，连续复利）
+- **dividendYield**: (number,optional) 连续股息率（%）
+- **volatility**: (number) 波动率（%）， impliedVolatility 时不需要
+- **price**: (number) 期权市场价格，仅 impliedVolatility 时需要
+- **steps**: (int,optional) 二叉树步数，默认 500
+- **legs**: (object[]) 组合各腿，仅 payoff 时需要
+  - **type**: (string) call 、 put 或 stock
+  - **position**: (string,optional) long （默认）或 short
+  - **quantity**: (number,optional) 数量，默认 1
+  - **strike**: (number) 行权价， stock 腿不需要
+  - **premium**: (number) 每单位权利金， stock 腿为买入价
+- **multiplier**: (number,optional) 合约乘数，默认 1
+- **underlying** / **from** / **to**: (number,optional) payoff 时作为盈亏表价格范围的参考，默认根据行权价和标的价格自动确定
+- **points**: (int,optional) 盈亏表价格点数，默认 21 ，行权价和盈亏平衡点总会包含在内
+
+输出（根据计算类型填充其中一项）：
+- **price**: 期权价格、内在价值、时间价值和希腊值（ delta 、 gamma 、每日 theta 、每个波动率百分点的 vega 、每个利率百分点的 rho ）
+- **impliedVolatility**: 隐含波动率（%）及以其计算的希腊值
+- **payoff**: 净权利金、盈亏平衡点、最大盈利和最大亏损，以及到期盈亏的 Markdown 表格
+`,
+		func(ctx *ai.ToolContext, input Input) (Output, error) {
+			return Calculate(input, time.Now())
+		},
+	)
+}
+
+// Calculate 执行期权计算， now 用于根据到期日计算剩余期限
+func Calculate(input Input, now time.Time) (Output, error) {
+	switch input.Action {
+	case "", ActionPrice:
+		model, p, err := input.params(now)
+		if err != nil {
+			return Output{}, err
+		}
+		result, err := Price(model, p)
+		if err != nil {
+			return Output{}, err
+		}
+		return Output{Price: &PriceOutput{
+			Model:    model,
+			Type:     p.Type,
+			American: p.American,
+			T:        roundTo(p.T, outputPlaces),
+			Result:   result.Round(outputPlaces),
+		}}, nil
+	case ActionImpliedVolatility:
+		input.Volatility = 0
+		model, p, err := input.params(now)
+		if err != nil {
+			return Output{}, err
+		}
+		iv, err := ImpliedVolatility(model, p, input.Price)
+		if err != nil {
+			return Output{}, err
+		}
+		p.Volatility = iv
+		result, err := Price(model, p)
+		if err != nil {
+			return Output{}, err
+		}
+		return Output{ImpliedVolatility: &IVOutput{
+			Model:             model,
+			Type:              p.Type,
+			American:          p.American,
+			T:                 roundTo(p.T, outputPlaces),
+			ImpliedVolatility: roundTo(iv*100, outputPlaces),
+			Greeks:            result.Round(outputPlaces).Greeks,
+		}}, nil
+	case ActionPayoff:
+		payoff, err := AnalyzePayoff(input.Legs, input.Multiplier, input.Underlying, input.From, input.To, input.Points)
+		if err != nil {
+			return Output{}, err
+		}
+		out := NewPayoffOutput(payoff)
+		return Output{Payoff: &out}, nil
+	}
+	return Output{}, fmt.Errorf("invalid action %q (expected: %s, %s or %s)",
+		input.Action, ActionPrice, ActionImpliedVolatility, ActionPayoff)
+}
+
+// params 将输入转换为定价参数
+func (input Input) params(now time.Time) (Model, Params, error) {
+	typ, err := ParseOptionType(input.Type)
+	if err != nil {
+		return "", Params{}, err
+	}
+	american := false
+	switch strings.ToLower(input.Style) {
+	case "", "european", "e":
+	case "american", "a":
+		american = true
+	default:
+		return "", Params{}, fmt.Errorf("invalid style %q (expected: european or american)", input.Style)
+	}
+	model, err := ParseModel(input.Model)
+	if err != nil {
+		return "", Params{}, err
+	}
+	if american && input.Model == "" {
+		model = ModelBinomial
+	}
+
+	days := input.Days
+	if input.Expiry != "" {
+		expiry, err := time.ParseInLocation(time.DateOnly, input.Expiry, now.Location())
+		if err != nil {
+			return "", Params{}, fmt.Errorf("invalid expiry %q (expected format: YYYY-MM-DD): %w", input.Expiry, err)
+		}
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		days = math.Round(expiry.Sub(today).Hours() / 24)
+		if days < 0 {
+			return "", Params{}, fmt.Errorf("option expired on %s", input.Expiry)
+		}
+	}
+
+	return model, Params{
+		Type:       typ,
+		Underlying: input.Underlying,
+		Strike:     input.Strike,
+		T:          days / 365,
+		Rate:       input.Rate / 100,
+		Dividend:   input.DividendYield / 100,
+		Volatility: input.Volatility / 100,
+		American:   american,
+		Steps:      input.Steps,
+	}, nil
+}
+
+// Round 各数值保留 places 位小数
+func (r Result) Round(places int) Result {
+	return Result{
+		Price: roundTo(r.Price, places),
+		Greeks: Greeks{
+			Delta: roundTo(r.Greeks.Delta, places),
+			Gamma: roundTo(r.Greeks.Gamma, places),
+			Theta: roundTo(r.Greeks.Theta, places),
+			Vega:  roundTo(r.Greeks.Vega, places),
+			Rho:   roundTo(r.Greeks.Rho, places),
+		},
+		Intrinsic: roundTo(r.Intrinsic, places),
+		TimeValue: roundTo(r.TimeValue, places),
+	}
+}
+
+// NewPayoffOutput 根据盈亏分析结果创建工具输出
+func NewPayoffOutput(p Payoff) PayoffOutput {
+	ret := PayoffOutput{
+		Multiplier: p.Multiplier,
+		NetPremium: roundTo(p.NetPremium, outputPlaces),
+		Breakevens: make([]float64, 0, len(p.Breakevens)),
+		MaxProfit:  "unlimited",
+		MaxLoss:    "unlimited",
+		Table:      payoffTable(p),
+	}
+	for _, b := range p.Breakevens {
+		ret.Breakevens = append(ret.Breakevens, roundTo(b, outputPlaces))
+	}
+	if p.MaxProfit != nil {
+		ret.MaxProfit = formatNumber(*p.MaxProfit)
+	}
+	if p.MaxLoss != nil {
+		ret.MaxLoss = formatNumber(*p.MaxLoss)
+	}
+	return ret
+}
+
+// payoffTable 生成到期盈亏的 Markdown 表格
+func payoffTable(p Payoff) string {
+	buf := &strings.Builder{}
+	buf.WriteString("| Underlying |")
+	for i, l := range p.Legs {
+		fmt.Fprintf(buf, " #%d %s %s", i+1, l.Position, l.Type)
+		if l.Type != LegStock {
+			fmt.Fprintf(buf, " %s", formatNumber(l.Strike))
+		}
+		buf.WriteString(" |")
+	}
+	buf.WriteString(" Total |\n")
+	buf.WriteString("|" + strings.Repeat(" --- |", len(p.Legs)+2) + "\n")
+	for _, row := range p.Rows {
+		fmt.Fprintf(buf, "| %s |", formatNumber(row.Underlying))
+		for _, v := range row.Legs {
+			fmt.Fprintf(buf, " %s |", formatNumber(v))
+		}
+		fmt.Fprintf(buf, " %s |\n", formatNumber(row.Total))
+	}
+	return buf.String()
+}
+
+// formatNumber 格式化数值，保留至多 4 位小数
+func formatNumber(v float64) string {
+	v = roundTo(v, outputPlaces)
+	if v == 0 {
+		v = 0 // 避免输出 -0
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
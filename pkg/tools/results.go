@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// ToolResult 一次工具调用的结果
+type ToolResult struct {
+	// 工具调用引用
+	Ref string
+	// 工具名
+	Name string
+	// 工具输出
+	Output any
+}
+
+// Source 结果的来源描述
+func (r ToolResult) Source() string {
+	if r.Ref == "" {
+		return fmt.Sprintf("tool %s", r.Name)
+	}
+	return fmt.Sprintf("tool %s (ref %s)", r.Name, r.Ref)
+}
+
+// ResultStore 当前对话中的工具调用结果，供需要引用前序工具结果的工具（如 Indicators ）使用
+type ResultStore struct {
+	lock    sync.RWMutex
+	results []ToolResult
+}
+
+// NewResultStore 创建 ResultStore ，并收集消息中已有的工具调用结果
+func NewResultStore(messages ...*ai.Message) *ResultStore {
+	s := &ResultStore{}
+	for _, msg := range messages {
+		s.AddMessage(msg)
+	}
+	return s
+}
+
+// Add 添加工具调用结果
+func (s *ResultStore) Add(ref, name string, output any) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.results = append(s.results, ToolResult{Ref: ref, Name: name, Output: output})
+}
+
+// AddMessage 添加消息中的工具调用结果
+func (s *ResultStore) AddMessage(msg *ai.Message) {
+	if msg == nil {
+		return
+	}
+	for _, part := range msg.Content {
+		if !part.IsToolResponse() || part.ToolResponse == nil {
+			continue
+		}
+		s.Add(part.ToolResponse.Ref, part.ToolResponse.Name, part.ToolResponse.Output)
+	}
+}
+
+// Get 获取指定引用的工具调用结果，有多个时返回最近的
+func (s *ResultStore) Get(ref string) (ToolResult, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for i := len(s.results) - 1; i >= 0; i-- {
+		if s.results[i].Ref == ref {
+			return s.results[i], true
+		}
+	}
+	return ToolResult{}, false
+}
+
+// Latest 获取指定工具最近一次的调用结果， name 为空时返回最近一次任意工具的调用结果
+func (s *ResultStore) Latest(name string) (ToolResult, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for i := len(s.results) - 1; i >= 0; i-- {
+		if name == "" || s.results[i].Name == name {
+			return s.results[i], true
+		}
+	}
+	return ToolResult{}, false
+}
+
+// All 获取所有工具调用结果，按调用先后排列
+func (s *ResultStore) All() []ToolResult {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return slices.Clone(s.results)
+}
+
+type resultStoreContextKey struct{}
+
+// ContextWithResultStore 返回携带 ResultStore 的上下文
+func ContextWithResultStore(ctx context.Context, s *ResultStore) context.Context {
+	return context.WithValue(ctx, resultStoreContextKey{}, s)
+}
+
+// ResultStoreFromContext 从上下文获取 ResultStore
+func ResultStoreFromContext(ctx context.Context) (*ResultStore, bool) {
+	s, ok := ctx.Value(resultStoreContextKey{}).(*ResultStore)
+	return s, ok && s != nil
+}
+
+// BarsRef K 线数据的引用方式
+type BarsRef struct {
+	// 引用的工具调用结果的 ref
+	ResultRef string
+	// 引用指定工具最近一次的调用结果
+	ResultOf string
+	// 直接提供的 K 线数据
+	Data any
+}
+
+// LoadBars 根据引用加载 K 线，返回 K 线和数据来源描述
+//
+// 依次使用 Data 、 ResultRef 、 ResultOf ，都未指定时使用上下文中最近一次包含 K 线数据的工具调用结果，
+// 查找时跳过 skip 中指定的工具
+func LoadBars(ctx context.Context, ref BarsRef, skip ...string) ([]Bar, string, error) {
+	if ref.Data != nil {
+		bars, err := ParseBars(ref.Data)
+		if err != nil {
+			return nil, "", fmt.Errorf("parse data error: %w", err)
+		}
+		return bars, "inline data", nil
+	}
+
+	store, ok := ResultStoreFromContext(ctx)
+	if !ok {
+		return nil, "", fmt.Errorf("no tool results available, provide bars by data")
+	}
+
+	var result ToolResult
+	switch {
+	case ref.ResultRef != "":
+		if result, ok = store.Get(ref.ResultRef); !ok {
+			return nil, "", fmt.Errorf("tool result with ref %q not found", ref.ResultRef)
+		}
+	case ref.ResultOf != "":
+		if result, ok = store.Latest(ref.ResultOf); !ok {
+			return nil, "", fmt.Errorf("no result of tool %q found", ref.ResultOf)
+		}
+	default:
+		// 使用最近一次包含 K 线数据的结果
+		results := store.All()
+		for i := len(results) - 1; i >= 0; i-- {
+			if slices.Contains(skip, results[i].Name) {
+				continue
+			}
+			if bars, err := ParseBars(results[i].Output); err == nil {
+				return bars, results[i].Source(), nil
+			}
+		}
+		return nil, "", fmt.Errorf("no tool result containing OHLCV bars found, provide bars by data")
+	}
+
+	bars, err := ParseBars(result.Output)
+	if err != nil {
+		return nil, "", fmt.Errorf("parse result of tool %q error: %w", result.Name, err)
+	}
+	return bars, result.Source(), nil
+}
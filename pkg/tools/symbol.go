@@ -0,0 +1,208 @@
+package tools
+
+import (
+	"strings"
+)
+
+// 交易日历，使用交易所的 ISO 10383 MIC 代码
+const (
+	// CalendarNYSE 美股（纽交所、纳斯达克交易日相同）
+	CalendarNYSE = "XNYS"
+	// CalendarHKEX 港股
+	CalendarHKEX = "XHKG"
+	// CalendarSSE 上交所
+	CalendarSSE = "XSHG"
+	// CalendarSZSE 深交所
+	CalendarSZSE = "XSHE"
+	// CalendarBSE 北交所
+	CalendarBSE = "XBSE"
+)
+
+// NormalizeSymbol 将各种写法的证券代码转换为标准代码
+//
+// 标准代码：美股为代码本身（股份类别以 . 分隔，如 BRK.B ），港股为至少 4 位数字加 .HK （如 0700.HK ），
+// A 股为 6 位数字加 .SS （上交所）、 .SZ （深交所）或 .BJ （北交所）。
+// 支持 700.HK 、 00700 、 HK.00700 、 HKEX:700 、 600519.SH 、 SH600519 、 600519 、 NASDAQ:AAPL 、 BRK-B 、 AAPL.US 等写法，
+// 无法识别为证券代码时返回 false
+func NormalizeSymbol(s string) (string, bool) {
+	s = strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	if s == "" {
+		return "", false
+	}
+
+	// 交易所前缀，如 NASDAQ:AAPL 、 HKEX:700
+	if prefix, code, ok := strings.Cut(s, ":"); ok {
+		switch prefix {
+		case "NASDAQ", "NYSE", "AMEX", "NYSEARCA", "ARCA", "BATS", "OTC", "US":
+			return normalizeUSSymbol(code)
+		case "HKEX", "SEHK", "HK":
+			return normalizeHKSymbol(code)
+		case "SSE", "SHA", "SH":
+			return normalizeCNSymbol(code, "SS")
+		case "SZSE", "SHE", "SZ":
+			return normalizeCNSymbol(code, "SZ")
+		case "BSE", "BJ":
+			return normalizeCNSymbol(code, "BJ")
+		}
+		return "", false
+	}
+
+	// 市场前缀，如 HK.00700 、 US.AAPL 、 SH600519
+	if prefix, code, ok := strings.Cut(s, "."); ok {
+		switch prefix {
+		case "US":
+			return normalizeUSSymbol(code)
+		case "HK":
+			return normalizeHKSymbol(code)
+		case "SH", "SZ", "BJ":
+			if isDigits(code) {
+				return normalizeCNSymbol(code, strings.Replace(prefix, "SH", "SS", 1))
+			}
+		}
+	}
+	if len(s) > 2 && isDigits(s[2:]) {
+		switch prefix := s[:2]; prefix {
+		case "HK":
+			return normalizeHKSymbol(s[2:])
+		case "SH", "SZ", "BJ":
+			return normalizeCNSymbol(s[2:], strings.Replace(prefix, "SH", "SS", 1))
+		}
+	}
+
+	// 后缀，如 700.HK 、 600519.SH 、 600519.SHH （ Alpha Vantage ）
+	if i := strings.LastIndex(s, "."); i > 0 {
+		switch code, suffix := s[:i], s[i+1:]; suffix {
+		case "HK":
+			return normalizeHKSymbol(code)
+		case "SS", "SH", "SHH":
+			return normalizeCNSymbol(code, "SS")
+		case "SZ", "SHZ":
+			return normalizeCNSymbol(code, "SZ")
+		case "BJ":
+			return normalizeCNSymbol(code, "BJ")
+		case "US":
+			return normalizeUSSymbol(code)
+		}
+	}
+
+	// 纯数字， 6 位为 A 股，其它为港股
+	if isDigits(s) {
+		if len(s) == 6 {
+			return normalizeCNSymbol(s, "")
+		}
+		return normalizeHKSymbol(s)
+	}
+	return normalizeUSSymbol(s)
+}
+
+// CanonicalSymbol 返回标准代码，无法识别时原样返回
+func CanonicalSymbol(s string) string {
+	if ret, ok := NormalizeSymbol(s); ok {
+		return ret
+	}
+	return strings.TrimSpace(s)
+}
+
+// CalendarOfSymbol 返回标准代码对应的交易日历，未知市场返回空
+func CalendarOfSymbol(symbol string) string {
+	switch MarketOfSymbol(symbol) {
+	case MarketUS:
+		return CalendarNYSE
+	case MarketHK:
+		return CalendarHKEX
+	case MarketCN:
+		switch symbol[strings.LastIndex(symbol, ".")+1:] {
+		case "SZ":
+			return CalendarSZSE
+		case "BJ":
+			return CalendarBSE
+		default:
+			return CalendarSSE
+		}
+	}
+	return ""
+}
+
+// CurrencyOfMarket 返回市场的交易货币，未知市场返回空
+func CurrencyOfMarket(market string) string {
+	switch market {
+	case MarketUS:
+		return "USD"
+	case MarketHK:
+		return "HKD"
+	case MarketCN:
+		return "CNY"
+	}
+	return ""
+}
+
+// normalizeUSSymbol 规范化美股代码，股份类别分隔符统一为 .
+func normalizeUSSymbol(code string) (string, bool) {
+	base, class, _ := strings.Cut(strings.NewReplacer("-", ".", "/", ".").Replace(code), ".")
+	if len(base) == 0 || len(base) > 5 || !isLetters(base) || len(class) > 1 || (class != "" && !isLetters(class)) {
+		return "", false
+	}
+	if class == "" {
+		return base, true
+	}
+	return base + "." + class, true
+}
+
+// normalizeHKSymbol 规范化港股代码，去掉多余的前导 0 后补足 4 位
+func normalizeHKSymbol(code string) (string, bool) {
+	if len(code) == 0 || len(code) > 5 || !isDigits(code) {
+		return "", false
+	}
+	code = strings.TrimLeft(code, "0")
+	if code == "" {
+		return "", false
+	}
+	if len(code) < 4 {
+		code = strings.Repeat("0", 4-len(code)) + code
+	}
+	return code + ".HK", true
+}
+
+// normalizeCNSymbol 规范化 A 股代码， suffix 为空时根据代码前缀判断交易所
+func normalizeCNSymbol(code, suffix string) (string, bool) {
+	if len(code) != 6 || !isDigits(code) {
+		return "", false
+	}
+	if suffix == "" {
+		switch {
+		case strings.HasPrefix(code, "92") || code[0] == '4' || code[0] == '8':
+			suffix = "BJ"
+		case code[0] == '5' || code[0] == '6' || code[0] == '9':
+			suffix = "SS"
+		default:
+			suffix = "SZ"
+		}
+	}
+	return code + "." + suffix, true
+}
+
+// isDigits 是否为非空的纯数字
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isLetters 是否为非空的纯大写字母
+func isLetters(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
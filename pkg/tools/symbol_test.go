@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalizeSymbol 测试证券代码规范化
+func TestNormalizeSymbol(t *testing.T) {
+	cases := []struct {
+		input  string
+		expect string
+		ok     bool
+	}{
+		{input: "aapl", expect: "AAPL", ok: true},
+		{input: " AAPL.US ", expect: "AAPL", ok: true},
+		{input: "NASDAQ:AAPL", expect: "AAPL", ok: true},
+		{input: "US.TSLA", expect: "TSLA", ok: true},
+		{input: "BRK-B", expect: "BRK.B", ok: true},
+		{input: "brk.b", expect: "BRK.B", ok: true},
+		{input: "700.HK", expect: "0700.HK", ok: true},
+		{input: "00700.hk", expect: "0700.HK", ok: true},
+		{input: "0700", expect: "0700.HK", ok: true},
+		{input: "HK.00700", expect: "0700.HK", ok: true},
+		{input: "HKEX:9988", expect: "9988.HK", ok: true},
+		{input: "600519", expect: "600519.SS", ok: true},
+		{input: "600519.SH", expect: "600519.SS", ok: true},
+		{input: "600519.SHH", expect: "600519.SS", ok: true},
+		{input: "sh600519", expect: "600519.SS", ok: true},
+		{input: "SH.600519", expect: "600519.SS", ok: true},
+		{input: "000001", expect: "000001.SZ", ok: true},
+		{input: "SZ000001", expect: "000001.SZ", ok: true},
+		{input: "510300", expect: "510300.SS", ok: true},
+		{input: "159919", expect: "159919.SZ", ok: true},
+		{input: "430047", expect: "430047.BJ", ok: true},
+		{input: "SHOP", expect: "SHOP", ok: true},
+		{input: "腾讯", ok: false},
+		{input: "TENCENT", ok: false},
+		{input: "0000.HK", ok: false},
+		{input: "12345678", ok: false},
+		{input: "NYSE:", ok: false},
+		{input: "", ok: false},
+	}
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			ret, ok := NormalizeSymbol(c.input)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.expect, ret)
+		})
+	}
+}
+
+// TestSymbolMetadata 测试根据标准代码推断市场、交易日历和货币
+func TestSymbolMetadata(t *testing.T) {
+	for symbol, expect := range map[string][3]string{
+		"AAPL":      {MarketUS, CalendarNYSE, "USD"},
+		"BRK.B":     {MarketUS, CalendarNYSE, "USD"},
+		"0700.HK":   {MarketHK, CalendarHKEX, "HKD"},
+		"600519.SS": {MarketCN, CalendarSSE, "CNY"},
+		"000001.SZ": {MarketCN, CalendarSZSE, "CNY"},
+		"430047.BJ": {MarketCN, CalendarBSE, "CNY"},
+		"SAP.DE":    {"DE", "", ""},
+	} {
+		market := MarketOfSymbol(symbol)
+		assert.Equal(t, expect, [3]string{market, CalendarOfSymbol(symbol), CurrencyOfMarket(market)}, symbol)
+	}
+}
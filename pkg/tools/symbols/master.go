@@ -0,0 +1,561 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+const (
+	// DirName 证券主数据在数据根目录下的目录名
+	DirName = "symbols"
+	// CacheFileName 从提供商刷新的证券主数据文件名
+	CacheFileName = "master.csv"
+
+	// MaxCandidates 解析结果中最多返回的其它候选数量
+	MaxCandidates = 5
+)
+
+// 解析结果来源
+const (
+	// SourceMaster 来自证券主数据
+	SourceMaster = "master"
+	// SourceProvider 来自行情数据提供商的搜索结果
+	SourceProvider = "provider"
+	// SourceInferred 不在证券主数据中，根据代码格式推断
+	SourceInferred = "inferred"
+)
+
+// builtinSymbols 内置证券主数据
+//
+//go:embed data/symbols.csv
+var builtinSymbols []byte
+
+// csvHeader 证券主数据文件表头
+var csvHeader = []string{"symbol", "name", "aliases", "isin", "exchange", "type", "market", "currency", "calendar"}
+
+// Entry 证券主数据条目
+type Entry struct {
+	// 标准代码，见 tools.NormalizeSymbol
+	Symbol string `json:"symbol"`
+	Name   string `json:"name,omitempty"`
+	// 别名，如中文名、简称
+	Aliases  []string `json:"aliases,omitempty"`
+	ISIN     string   `json:"isin,omitempty"`
+	Exchange string   `json:"exchange,omitempty"`
+	// 类型，如 stock 、 etf 、 adr
+	Type     string `json:"type,omitempty"`
+	Market   string `json:"market"`
+	Currency string `json:"currency,omitempty"`
+	// 交易日历，交易所的 ISO 10383 MIC 代码，如 XNYS 、 XHKG 、 XSHG
+	Calendar string `json:"calendar,omitempty"`
+}
+
+// complete 补全市场、货币和交易日历
+func (e *Entry) complete() {
+	if e.Market == "" {
+		e.Market = tools.MarketOfSymbol(e.Symbol)
+	}
+	if e.Currency == "" {
+		e.Currency = tools.CurrencyOfMarket(e.Market)
+	}
+	if e.Calendar == "" {
+		e.Calendar = tools.CalendarOfSymbol(e.Symbol)
+	}
+}
+
+// merge 用 other 补全空字段
+func (e *Entry) merge(other Entry) {
+	for _, f := range []struct{ dst, src *string }{
+		{&e.Name, &other.Name},
+		{&e.ISIN, &other.ISIN},
+		{&e.Exchange, &other.Exchange},
+		{&e.Type, &other.Type},
+		{&e.Currency, &other.Currency},
+		{&e.Calendar, &other.Calendar},
+	} {
+		if *f.dst == "" {
+			*f.dst = *f.src
+		}
+	}
+	for _, a := range other.Aliases {
+		if !containsFold(e.Aliases, a) {
+			e.Aliases = append(e.Aliases, a)
+		}
+	}
+}
+
+// Resolution 证券解析结果
+type Resolution struct {
+	// 原始输入
+	Query string `json:"query"`
+	// 最匹配的证券
+	Match Entry `json:"match"`
+	// 结果来源， master 、 provider 或 inferred
+	Source string `json:"source"`
+	// 其它可能的证券，如同一公司在其它市场的上市
+	Candidates []Entry `json:"candidates,omitempty"`
+}
+
+// SearchFunc 在行情数据提供商中搜索证券
+type SearchFunc func(ctx context.Context, query string) ([]tools.SymbolMatch, error)
+
+// NewMaster 创建证券主数据
+//
+// dir 为证券主数据目录，其中的 CacheFileName 文件会合并到内置数据； search 不为空时，
+// 在主数据中找不到的证券会通过它在行情数据提供商中搜索
+func NewMaster(dir string, search SearchFunc) *Master {
+	return &Master{dir: dir, search: search}
+}
+
+// Master 证券主数据，将名称、代码、 ISIN 等解析为标准代码
+type Master struct {
+	dir    string
+	search SearchFunc
+
+	lock     sync.Mutex
+	loaded   bool
+	entries  []Entry
+	bySymbol map[string]int
+	byISIN   map[string]int
+}
+
+// Entries 返回所有条目
+func (m *Master) Entries() ([]Entry, error) {
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]Entry(nil), m.entries...), nil
+}
+
+// Lookup 根据代码查找条目，代码可以是任意 tools.NormalizeSymbol 支持的写法
+func (m *Master) Lookup(symbol string) (Entry, bool, error) {
+	if err := m.load(); err != nil {
+		return Entry{}, false, err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	i, ok := m.bySymbol[tools.CanonicalSymbol(symbol)]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	return m.entries[i], true, nil
+}
+
+// Resolve 将名称、代码或 ISIN 解析为标准代码
+//
+// 依次尝试： ISIN 、代码、名称或别名完全匹配、带市场信息的代码、名称或别名部分匹配、
+// 在行情数据提供商中搜索，最后将形如美股代码的输入视为美股代码
+func (m *Master) Resolve(ctx context.Context, query string) (Resolution, error) {
+	q := strings.TrimSpace(query)
+	if q == "" {
+		return Resolution{}, fmt.Errorf("query is required")
+	}
+	if err := m.load(); err != nil {
+		return Resolution{}, err
+	}
+	ret := Resolution{Query: query}
+
+	// ISIN
+	if isin := strings.ToUpper(q); ValidISIN(isin) {
+		m.lock.Lock()
+		i, ok := m.byISIN[isin]
+		if ok {
+			ret.Match, ret.Source = m.entries[i], SourceMaster
+		}
+		m.lock.Unlock()
+		if ok {
+			return ret, nil
+		}
+		if found, err := m.searchProvider(ctx, isin, &ret); found || err != nil {
+			return ret, err
+		}
+		return Resolution{}, fmt.Errorf("ISIN %s not found in symbol master", isin)
+	}
+
+	// 代码
+	symbol, isSymbol := tools.NormalizeSymbol(q)
+	if isSymbol {
+		e, ok, _ := m.Lookup(symbol)
+		if ok {
+			ret.Match, ret.Source = e, SourceMaster
+			return ret, nil
+		}
+	}
+
+	// 名称或别名
+	matches, exact := m.matchNames(q)
+	if exact || (len(matches) > 0 && !(isSymbol && hasMarketInfo(q))) {
+		ret.Match, ret.Source = matches[0], SourceMaster
+		ret.Candidates = matches[1:]
+		return ret, nil
+	}
+	if isSymbol && hasMarketInfo(q) {
+		ret.Match, ret.Source = inferred(symbol), SourceInferred
+		return ret, nil
+	}
+
+	if found, err := m.searchProvider(ctx, q, &ret); found {
+		return ret, nil
+	} else if !isSymbol && err != nil {
+		return Resolution{}, err
+	}
+	if isSymbol {
+		ret.Match, ret.Source = inferred(symbol), SourceInferred
+		return ret, nil
+	}
+	return Resolution{}, fmt.Errorf("symbol %q not found", query)
+}
+
+// Refresh 将提供商列出的证券合并到证券主数据文件，返回新增的证券数量
+func (m *Master) Refresh(matches []tools.SymbolMatch) (int, error) {
+	if m.dir == "" {
+		return 0, fmt.Errorf("symbol master directory is not set")
+	}
+	path := filepath.Join(m.dir, CacheFileName)
+	cached, err := readEntriesFile(path)
+	if err != nil {
+		return 0, err
+	}
+	index := make(map[string]int, len(cached))
+	for i, e := range cached {
+		index[e.Symbol] = i
+	}
+	added := 0
+	for _, match := range matches {
+		symbol, ok := tools.NormalizeSymbol(match.Symbol)
+		if !ok {
+			continue
+		}
+		e := Entry{
+			Symbol:   symbol,
+			Name:     match.Name,
+			Exchange: match.Exchange,
+			Type:     strings.ToLower(match.Type),
+			Market:   match.Market,
+			Currency: match.Currency,
+		}
+		e.complete()
+		if i, ok := index[symbol]; ok {
+			cached[i] = e
+			continue
+		}
+		index[symbol] = len(cached)
+		cached = append(cached, e)
+		added++
+	}
+	if err := writeEntriesFile(path, cached); err != nil {
+		return 0, err
+	}
+
+	// 下次使用时重新加载
+	m.lock.Lock()
+	m.loaded = false
+	m.lock.Unlock()
+	return added, nil
+}
+
+// load 加载内置数据和证券主数据文件
+func (m *Master) load() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.loaded {
+		return nil
+	}
+
+	entries, err := readEntries(bytes.NewReader(builtinSymbols))
+	if err != nil {
+		return fmt.Errorf("read builtin symbols error: %w", err)
+	}
+	if m.dir != "" {
+		cached, err := readEntriesFile(filepath.Join(m.dir, CacheFileName))
+		if err != nil {
+			return err
+		}
+		entries = append(entries, cached...)
+	}
+
+	m.entries = nil
+	m.bySymbol = make(map[string]int, len(entries))
+	m.byISIN = map[string]int{}
+	for _, e := range entries {
+		// 内置数据优先，刷新的数据只补全空字段
+		if i, ok := m.bySymbol[e.Symbol]; ok {
+			m.entries[i].merge(e)
+		} else {
+			m.bySymbol[e.Symbol] = len(m.entries)
+			m.entries = append(m.entries, e)
+		}
+	}
+	for i, e := range m.entries {
+		if e.ISIN != "" {
+			m.byISIN[e.ISIN] = i
+		}
+	}
+	m.loaded = true
+	return nil
+}
+
+// scoredEntry 带匹配程度的条目
+type scoredEntry struct {
+	Entry
+	score int
+}
+
+// matchNames 按名称和别名匹配，按匹配程度排序，返回是否有完全匹配
+func (m *Master) matchNames(query string) ([]Entry, bool) {
+	q := nameKey(query)
+	if q == "" {
+		return nil, false
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var scored []scoredEntry
+	for _, e := range m.entries {
+		best := 0
+		for _, name := range append([]string{e.Name}, e.Aliases...) {
+			k := nameKey(name)
+			switch {
+			case k == "":
+			case k == q:
+				best = max(best, 3)
+			case len([]rune(q)) >= 2 && strings.HasPrefix(k, q):
+				best = max(best, 2)
+			case len([]rune(q)) >= 2 && strings.Contains(k, q):
+				best = max(best, 1)
+			}
+		}
+		if best > 0 {
+			scored = append(scored, scoredEntry{Entry: e, score: best})
+		}
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+	if len(scored) > MaxCandidates+1 {
+		scored = scored[:MaxCandidates+1]
+	}
+	ret := make([]Entry, len(scored))
+	for i, e := range scored {
+		ret[i] = e.Entry
+	}
+	return ret, len(scored) > 0 && scored[0].score == 3
+}
+
+// searchProvider 在行情数据提供商中搜索，找到时填充 ret
+func (m *Master) searchProvider(ctx context.Context, query string, ret *Resolution) (bool, error) {
+	if m.search == nil {
+		return false, nil
+	}
+	matches, err := m.search(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("search symbol %q error: %w", query, err)
+	}
+	var entries []Entry
+	for _, match := range matches {
+		symbol, ok := tools.NormalizeSymbol(match.Symbol)
+		if !ok {
+			continue
+		}
+		e, ok, _ := m.Lookup(symbol)
+		if !ok {
+			e = Entry{
+				Symbol:   symbol,
+				Name:     match.Name,
+				Exchange: match.Exchange,
+				Type:     strings.ToLower(match.Type),
+				Market:   match.Market,
+				Currency: match.Currency,
+			}
+			e.complete()
+		}
+		entries = append(entries, e)
+		if len(entries) > MaxCandidates {
+			break
+		}
+	}
+	if len(entries) == 0 {
+		return false, nil
+	}
+	ret.Match, ret.Source, ret.Candidates = entries[0], SourceProvider, entries[1:]
+	return true, nil
+}
+
+// inferred 根据代码推断的条目
+func inferred(symbol string) Entry {
+	e := Entry{Symbol: symbol}
+	e.complete()
+	return e
+}
+
+// hasMarketInfo 输入是否包含市场信息（数字代码、前缀或后缀），而不只是字母
+func hasMarketInfo(query string) bool {
+	for _, c := range strings.TrimSpace(query) {
+		if !unicode.IsLetter(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameKey 返回用于匹配的名称，忽略大小写、空白和标点
+func nameKey(name string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(name) {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// containsFold 忽略大小写判断是否包含
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidISIN 校验 ISIN 格式和校验位
+func ValidISIN(isin string) bool {
+	if len(isin) != 12 || !unicode.IsUpper(rune(isin[0])) || !unicode.IsUpper(rune(isin[1])) {
+		return false
+	}
+	// 字母转换为 10-35 后按 Luhn 算法校验
+	var digits []int
+	for _, c := range isin[:11] {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, int(c-'0'))
+		case c >= 'A' && c <= 'Z':
+			v := int(c-'A') + 10
+			digits = append(digits, v/10, v%10)
+		default:
+			return false
+		}
+	}
+	if isin[11] < '0' || isin[11] > '9' {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return (10-sum%10)%10 == int(isin[11]-'0')
+}
+
+// readEntriesFile 读取证券主数据文件，文件不存在时返回空
+func readEntriesFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open %s error: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	entries, err := readEntries(f)
+	if err != nil {
+		return nil, fmt.Errorf("read %s error: %w", path, err)
+	}
+	return entries, nil
+}
+
+// readEntries 读取 CSV 格式的证券主数据，别名以 | 分隔
+func readEntries(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	col := map[string]int{}
+	for i, h := range rows[0] {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := col["symbol"]; !ok {
+		return nil, fmt.Errorf("missing symbol column")
+	}
+	field := func(row []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	ret := make([]Entry, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		symbol, ok := tools.NormalizeSymbol(field(row, "symbol"))
+		if !ok {
+			return nil, fmt.Errorf("invalid symbol %q at line %d", field(row, "symbol"), i+2)
+		}
+		e := Entry{
+			Symbol:   symbol,
+			Name:     field(row, "name"),
+			ISIN:     strings.ToUpper(field(row, "isin")),
+			Exchange: field(row, "exchange"),
+			Type:     field(row, "type"),
+			Market:   field(row, "market"),
+			Currency: field(row, "currency"),
+			Calendar: field(row, "calendar"),
+		}
+		if aliases := field(row, "aliases"); aliases != "" {
+			e.Aliases = strings.Split(aliases, "|")
+		}
+		e.complete()
+		ret = append(ret, e)
+	}
+	return ret, nil
+}
+
+// writeEntriesFile 写入证券主数据文件
+func writeEntriesFile(path string, entries []Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("make directory for %s error: %w", path, err)
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(csvHeader)
+	for _, e := range entries {
+		_ = w.Write([]string{
+			e.Symbol, e.Name, strings.Join(e.Aliases, "|"), e.ISIN, e.Exchange,
+			e.Type, e.Market, e.Currency, e.Calendar,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("encode %s error: %w", path, err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write %s error: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package symbols
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/tools"
+)
+
+// TestBuiltinSymbols 测试内置证券主数据
+func TestBuiltinSymbols(t *testing.T) {
+	entries, err := NewMaster("", nil).Entries()
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	seen := map[string]bool{}
+	for _, e := range entries {
+		assert.False(t, seen[e.Symbol], "duplicate symbol %s", e.Symbol)
+		seen[e.Symbol] = true
+		assert.NotEmpty(t, e.Name, e.Symbol)
+		assert.NotEmpty(t, e.Currency, e.Symbol)
+		assert.NotEmpty(t, e.Calendar, e.Symbol)
+		if e.ISIN != "" {
+			assert.True(t, ValidISIN(e.ISIN), "invalid ISIN %s of %s", e.ISIN, e.Symbol)
+		}
+	}
+}
+
+// TestValidISIN 测试 ISIN 校验
+func TestValidISIN(t *testing.T) {
+	assert.True(t, ValidISIN("US0378331005"))
+	assert.True(t, ValidISIN("KYG875721634"))
+	assert.True(t, ValidISIN("CNE0000018R8"))
+	assert.False(t, ValidISIN("US0378331006"))
+	assert.False(t, ValidISIN("AAPL"))
+	assert.False(t, ValidISIN("120378331005"))
+}
+
+// TestMasterResolve 测试解析证券
+func TestMasterResolve(t *testing.T) {
+	m := NewMaster("", nil)
+	ctx := context.Background()
+
+	cases := []struct {
+		query      string
+		symbol     string
+		source     string
+		candidates []string
+	}{
+		{query: "腾讯", symbol: "0700.HK", source: SourceMaster, candidates: []string{"TCEHY"}},
+		{query: "0700", symbol: "0700.HK", source: SourceMaster},
+		{query: "700.HK", symbol: "0700.HK", source: SourceMaster},
+		{query: "TCEHY", symbol: "TCEHY", source: SourceMaster},
+		{query: "贵州茅台", symbol: "600519.SS", source: SourceMaster},
+		{query: "茅台", symbol: "600519.SS", source: SourceMaster},
+		{query: "sh600519", symbol: "600519.SS", source: SourceMaster},
+		{query: "KYG875721634", symbol: "0700.HK", source: SourceMaster},
+		{query: "apple", symbol: "AAPL", source: SourceMaster},
+		{query: "阿里巴巴", symbol: "BABA", source: SourceMaster, candidates: []string{"9988.HK"}},
+		{query: "Berkshire", symbol: "BRK.B", source: SourceMaster},
+		{query: "BRK-B", symbol: "BRK.B", source: SourceMaster},
+		{query: "601012", symbol: "601012.SS", source: SourceInferred},
+		{query: "ZZZZ", symbol: "ZZZZ", source: SourceInferred},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			r, err := m.Resolve(ctx, c.query)
+			require.NoError(t, err)
+			assert.Equal(t, c.symbol, r.Match.Symbol)
+			assert.Equal(t, c.source, r.Source)
+			var candidates []string
+			for _, e := range r.Candidates {
+				candidates = append(candidates, e.Symbol)
+			}
+			assert.Equal(t, c.candidates, candidates)
+		})
+	}
+
+	r, err := m.Resolve(ctx, "腾讯")
+	require.NoError(t, err)
+	assert.Equal(t, Entry{
+		Symbol:   "0700.HK",
+		Name:     "Tencent Holdings Ltd.",
+		Aliases:  []string{"Tencent", "腾讯", "腾讯控股"},
+		ISIN:     "KYG875721634",
+		Exchange: "HKEX",
+		Type:     "stock",
+		Market:   tools.MarketHK,
+		Currency: "HKD",
+		Calendar: tools.CalendarHKEX,
+	}, r.Match)
+
+	_, err = m.Resolve(ctx, "不存在的公司")
+	assert.ErrorContains(t, err, "not found")
+	_, err = m.Resolve(ctx, "US0000000000")
+	assert.ErrorContains(t, err, "not found")
+}
+
+// TestMasterResolveWithProvider 测试主数据中找不到时在提供商中搜索
+func TestMasterResolveWithProvider(t *testing.T) {
+	var queries []string
+	m := NewMaster("", func(_ context.Context, query string) ([]tools.SymbolMatch, error) {
+		queries = append(queries, query)
+		switch query {
+		case "隆基绿能":
+			return []tools.SymbolMatch{{Symbol: "601012.SHH", Name: "LONGi Green Energy", Currency: "CNY"}}, nil
+		case "FAIL":
+			return nil, fmt.Errorf("boom")
+		}
+		return nil, nil
+	})
+	ctx := context.Background()
+
+	r, err := m.Resolve(ctx, "隆基绿能")
+	require.NoError(t, err)
+	assert.Equal(t, SourceProvider, r.Source)
+	assert.Equal(t, "601012.SS", r.Match.Symbol)
+	assert.Equal(t, tools.CalendarSSE, r.Match.Calendar)
+
+	// 提供商出错时仍按代码格式推断
+	r, err = m.Resolve(ctx, "FAIL")
+	require.NoError(t, err)
+	assert.Equal(t, SourceInferred, r.Source)
+
+	_, err = m.Resolve(ctx, "找不到")
+	assert.ErrorContains(t, err, "not found")
+
+	// 主数据中能找到时不搜索
+	_, err = m.Resolve(ctx, "腾讯")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"隆基绿能", "FAIL", "找不到"}, queries)
+}
+
+// TestMasterRefresh 测试从提供商刷新证券主数据
+func TestMasterRefresh(t *testing.T) {
+	dir := t.TempDir()
+	m := NewMaster(dir, nil)
+
+	added, err := m.Refresh([]tools.SymbolMatch{
+		{Symbol: "AAPL", Name: "Apple Inc", Exchange: "NASDAQ", Type: "Stock"},
+		{Symbol: "IBM", Name: "International Business Machines Corp", Exchange: "NYSE", Type: "Stock"},
+		{Symbol: "BF-B", Name: "Brown-Forman Corp Class B", Exchange: "NYSE", Type: "Stock"},
+		{Symbol: "not a symbol"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+	assert.FileExists(t, filepath.Join(dir, CacheFileName))
+
+	// 内置数据优先
+	e, ok, err := m.Lookup("AAPL")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Apple Inc.", e.Name)
+	assert.Equal(t, "US0378331005", e.ISIN)
+
+	r, err := m.Resolve(context.Background(), "International Business Machines")
+	require.NoError(t, err)
+	assert.Equal(t, "IBM", r.Match.Symbol)
+	assert.Equal(t, SourceMaster, r.Source)
+	assert.Equal(t, "stock", r.Match.Type)
+
+	// 再次刷新时更新已有证券
+	added, err = m.Refresh([]tools.SymbolMatch{{Symbol: "IBM", Name: "IBM"}, {Symbol: "BF.B", Name: "Brown-Forman"}})
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+	e, ok, err = NewMaster(dir, nil).Lookup("bf-b")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Brown-Forman", e.Name)
+
+	// 损坏的主数据文件
+	require.NoError(t, os.WriteFile(filepath.Join(dir, CacheFileName), []byte("symbol\n腾讯\n"), 0o644))
+	_, err = NewMaster(dir, nil).Resolve(context.Background(), "AAPL")
+	assert.ErrorContains(t, err, "invalid symbol")
+}
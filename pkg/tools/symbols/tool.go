@@ -0,0 +1,36 @@
+package symbols
+
+import (
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// ResolveSymbolToolName 证券代码解析工具名
+const ResolveSymbolToolName = "ResolveSymbol"
+
+// Input 证券代码解析输入
+type Input struct {
+	Query string `json:"query"`
+}
+
+// DefineTool 定义证券代码解析工具
+func (m *Master) DefineTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, ResolveSymbolToolName, `Resolve a company name, ticker, ISIN or exchange-specific code to the canonical symbol with market, currency and trading calendar.
+
+用户提到的证券名称或代码不是标准代码（如“腾讯”、 “0700”、 “700.HK”、 “贵州茅台”、 ISIN ）时，应先用该工具解析为标准代码，再调用行情、指标等工具。
+标准代码：美股如 AAPL 、 BRK.B ，港股如 0700.HK ， A 股如 600519.SS （上交所）、 000001.SZ （深交所）。
+
+以 JSON 格式输入：
+- **query**: (string) 证券名称（中英文均可）、代码或 ISIN
+
+输出：
+- **query**: 原始输入
+- **match**: 最匹配的证券，包括标准代码 symbol 、名称、别名、 ISIN 、交易所、类型、市场、货币和交易日历（交易所 MIC 代码）
+- **source**: 结果来源， master 表示来自证券主数据， provider 表示来自行情数据提供商的搜索结果， inferred 表示仅根据代码格式推断（证券可能不存在）
+- **candidates**: 其它可能的证券，如同一公司在其它市场的上市，存在歧义时应向用户确认
+`,
+		func(ctx *ai.ToolContext, in Input) (Resolution, error) {
+			return m.Resolve(ctx, in.Query)
+		},
+	)
+}
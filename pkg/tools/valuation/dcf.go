@@ -0,0 +1,235 @@
+package valuation
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// DefaultYears 未提供现金流预测时默认预测年数
+	DefaultYears = 5
+	// DefaultWACCStep 敏感性分析中 WACC 的默认步长（百分点）
+	DefaultWACCStep = 1
+	// DefaultGrowthStep 敏感性分析中永续增长率的默认步长（百分点）
+	DefaultGrowthStep = 0.5
+	// DefaultMultipleStep 敏感性分析中退出倍数的默认步长
+	DefaultMultipleStep = 1
+	// sensitivitySize 敏感性分析网格的边长
+	sensitivitySize = 5
+)
+
+// DCFInput 现金流折现估值输入，百分比参数均以百分数表示（如 8 表示 8% ）
+type DCFInput struct {
+	// 逐年预测的自由现金流，提供时忽略 baseCashFlow 、 growthRate 和 years
+	CashFlows []float64 `json:"cashFlows,omitempty"`
+	// 最近一年的自由现金流
+	BaseCashFlow float64 `json:"baseCashFlow,omitempty"`
+	// 预测期现金流年增长率（%）
+	GrowthRate float64 `json:"growthRate,omitempty"`
+	// 预测年数
+	Years int `json:"years,omitempty"`
+	// 加权平均资本成本（%）
+	WACC float64 `json:"wacc"`
+	// 永续增长率（%），与 exitMultiple 二选一
+	TerminalGrowth float64 `json:"terminalGrowth,omitempty"`
+	// 退出倍数，终值为最后一年现金流乘以该倍数
+	ExitMultiple float64 `json:"exitMultiple,omitempty"`
+	// 净债务（有息负债减现金），净现金时为负数
+	NetDebt float64 `json:"netDebt,omitempty"`
+	// 总股本
+	SharesOutstanding float64 `json:"sharesOutstanding,omitempty"`
+	// 当前股价
+	CurrentPrice float64 `json:"currentPrice,omitempty"`
+	// 敏感性分析中 WACC 的步长（百分点）
+	WACCStep float64 `json:"waccStep,omitempty"`
+	// 敏感性分析中永续增长率或退出倍数的步长
+	TerminalStep float64 `json:"terminalStep,omitempty"`
+}
+
+// Complete 填充默认值，由 baseCashFlow 和 growthRate 生成逐年现金流
+func (in DCFInput) Complete() DCFInput {
+	if len(in.CashFlows) == 0 && in.BaseCashFlow != 0 {
+		if in.Years <= 0 {
+			in.Years = DefaultYears
+		}
+		cf := in.BaseCashFlow
+		for i := 0; i < in.Years; i++ {
+			cf *= 1 + in.GrowthRate/100
+			in.CashFlows = append(in.CashFlows, roundTo(cf, outputPlaces))
+		}
+	}
+	in.Years = len(in.CashFlows)
+	if in.WACCStep <= 0 {
+		in.WACCStep = DefaultWACCStep
+	}
+	if in.TerminalStep <= 0 {
+		in.TerminalStep = DefaultGrowthStep
+		if in.ExitMultiple > 0 {
+			in.TerminalStep = DefaultMultipleStep
+		}
+	}
+	return in
+}
+
+// Validate 校验输入
+func (in DCFInput) Validate() error {
+	switch {
+	case len(in.CashFlows) == 0:
+		return fmt.Errorf("cashFlows or baseCashFlow is required")
+	case in.WACC <= 0:
+		return fmt.Errorf("wacc must be positive")
+	case in.ExitMultiple < 0:
+		return fmt.Errorf("exitMultiple must not be negative")
+	case in.ExitMultiple == 0 && in.TerminalGrowth >= in.WACC:
+		return fmt.Errorf("terminalGrowth (%g%%) must be less than wacc (%g%%)", in.TerminalGrowth, in.WACC)
+	case in.SharesOutstanding < 0:
+		return fmt.Errorf("sharesOutstanding must not be negative")
+	}
+	return nil
+}
+
+// DCFYear 预测期某一年的现金流折现
+type DCFYear struct {
+	Year           int     `json:"year"`
+	CashFlow       float64 `json:"cashFlow"`
+	DiscountFactor float64 `json:"discountFactor"`
+	PresentValue   float64 `json:"presentValue"`
+}
+
+// DCFResult 现金流折现估值结果
+type DCFResult struct {
+	// 预测期各年现金流折现
+	Projections []DCFYear `json:"projections"`
+	// 预测期现金流现值合计
+	PVCashFlows float64 `json:"pvCashFlows"`
+	// 预测期末的终值
+	TerminalValue float64 `json:"terminalValue"`
+	// 终值现值
+	PVTerminalValue float64 `json:"pvTerminalValue"`
+	// 终值现值占企业价值的百分比
+	TerminalShare float64 `json:"terminalSharePercent"`
+	// 企业价值
+	EnterpriseValue float64 `json:"enterpriseValue"`
+	// 股权价值
+	EquityValue float64 `json:"equityValue"`
+	// 每股内在价值，未提供总股本时为空
+	ValuePerShare *float64 `json:"valuePerShare,omitempty"`
+	// 相对当前股价的涨跌空间（%），未提供当前股价时为空
+	Upside *float64 `json:"upsidePercent,omitempty"`
+	// 敏感性分析的 Markdown 表格
+	Sensitivity string `json:"sensitivity"`
+}
+
+// DCF 计算现金流折现估值，输入需已通过 Complete 填充默认值
+func DCF(in DCFInput) (DCFResult, error) {
+	if err := in.Validate(); err != nil {
+		return DCFResult{}, err
+	}
+
+	ret := DCFResult{}
+	r := in.WACC / 100
+	for i, cf := range in.CashFlows {
+		df := 1 / math.Pow(1+r, float64(i+1))
+		ret.Projections = append(ret.Projections, DCFYear{
+			Year:           i + 1,
+			CashFlow:       cf,
+			DiscountFactor: df,
+			PresentValue:   cf * df,
+		})
+		ret.PVCashFlows += cf * df
+	}
+	ret.TerminalValue = terminalValue(in, in.WACC, in.TerminalGrowth, in.ExitMultiple)
+	ret.PVTerminalValue = ret.TerminalValue / math.Pow(1+r, float64(len(in.CashFlows)))
+	ret.EnterpriseValue = ret.PVCashFlows + ret.PVTerminalValue
+	if ret.EnterpriseValue != 0 {
+		ret.TerminalShare = ret.PVTerminalValue / ret.EnterpriseValue * 100
+	}
+	ret.EquityValue = ret.EnterpriseValue - in.NetDebt
+	ret.ValuePerShare, ret.Upside = perShare(ret.EquityValue, in.SharesOutstanding, in.CurrentPrice)
+	ret.Sensitivity = sensitivityTable(in)
+	return ret, nil
+}
+
+// terminalValue 计算终值， wacc 和 growth 以百分数表示
+func terminalValue(in DCFInput, wacc, growth, multiple float64) float64 {
+	last := in.CashFlows[len(in.CashFlows)-1]
+	if multiple > 0 {
+		return last * multiple
+	}
+	return last * (1 + growth/100) / ((wacc - growth) / 100)
+}
+
+// equityValue 以指定 WACC 和永续增长率或退出倍数计算股权价值
+func equityValue(in DCFInput, wacc, terminal float64) float64 {
+	growth, multiple := terminal, 0.0
+	if in.ExitMultiple > 0 {
+		growth, multiple = 0, terminal
+	}
+	r := wacc / 100
+	ev := 0.0
+	for i, cf := range in.CashFlows {
+		ev += cf / math.Pow(1+r, float64(i+1))
+	}
+	ev += terminalValue(in, wacc, growth, multiple) / math.Pow(1+r, float64(len(in.CashFlows)))
+	return ev - in.NetDebt
+}
+
+// sensitivityTable 生成 WACC 与永续增长率（或退出倍数）的敏感性分析表
+//
+// 提供总股本时单元格为每股价值，否则为股权价值；永续增长率不低于 WACC 的单元格输出 -
+func sensitivityTable(in DCFInput) string {
+	terminal, terminalName := in.TerminalGrowth, "Terminal Growth"
+	if in.ExitMultiple > 0 {
+		terminal, terminalName = in.ExitMultiple, "Exit Multiple"
+	}
+	valueName := "equity value"
+	if in.SharesOutstanding > 0 {
+		valueName = "value per share"
+	}
+	half := sensitivitySize / 2
+
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "%s (rows: WACC %%, columns: %s)\n\n", valueName, terminalName)
+	buf.WriteString("| WACC |")
+	for j := -half; j <= half; j++ {
+		t := terminal + float64(j)*in.TerminalStep
+		if in.ExitMultiple > 0 {
+			fmt.Fprintf(buf, " %sx |", formatNumber(t, 2))
+		} else {
+			fmt.Fprintf(buf, " %s%% |", formatNumber(t, 2))
+		}
+	}
+	buf.WriteString("\n|" + strings.Repeat(" --- |", sensitivitySize+1) + "\n")
+	for i := -half; i <= half; i++ {
+		wacc := in.WACC + float64(i)*in.WACCStep
+		fmt.Fprintf(buf, "| %s%% |", formatNumber(wacc, 2))
+		for j := -half; j <= half; j++ {
+			t := terminal + float64(j)*in.TerminalStep
+			if wacc <= 0 || (in.ExitMultiple == 0 && t >= wacc) || (in.ExitMultiple > 0 && t <= 0) {
+				buf.WriteString(" - |")
+				continue
+			}
+			v := equityValue(in, wacc, t)
+			if in.SharesOutstanding > 0 {
+				v /= in.SharesOutstanding
+			}
+			fmt.Fprintf(buf, " %s |", formatNumber(v, 2))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// perShare 计算每股价值和相对当前股价的涨跌空间
+func perShare(equity, shares, price float64) (*float64, *float64) {
+	if shares <= 0 {
+		return nil, nil
+	}
+	v := equity / shares
+	if price <= 0 {
+		return &v, nil
+	}
+	upside := (v/price - 1) * 100
+	return &v, &upside
+}
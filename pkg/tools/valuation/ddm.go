@@ -0,0 +1,96 @@
+package valuation
+
+import (
+	"fmt"
+	"math"
+)
+
+// DividendInput 股利折现估值输入，百分比参数均以百分数表示
+//
+// 未提供 highGrowthYears 时使用 Gordon 增长模型，否则使用两阶段模型
+type DividendInput struct {
+	// 最近一年的每股股利
+	Dividend float64 `json:"dividend"`
+	// 永续增长率（%）
+	GrowthRate float64 `json:"growthRate,omitempty"`
+	// 要求回报率（股权成本，%）
+	RequiredReturn float64 `json:"requiredReturn"`
+	// 高增长阶段的股利增长率（%）
+	HighGrowthRate float64 `json:"highGrowthRate,omitempty"`
+	// 高增长阶段年数
+	HighGrowthYears int `json:"highGrowthYears,omitempty"`
+	// 当前股价
+	CurrentPrice float64 `json:"currentPrice,omitempty"`
+}
+
+// Validate 校验输入
+func (in DividendInput) Validate() error {
+	switch {
+	case in.Dividend <= 0:
+		return fmt.Errorf("dividend must be positive")
+	case in.RequiredReturn <= 0:
+		return fmt.Errorf("requiredReturn must be positive")
+	case in.GrowthRate >= in.RequiredReturn:
+		return fmt.Errorf("growthRate (%g%%) must be less than requiredReturn (%g%%)", in.GrowthRate, in.RequiredReturn)
+	case in.HighGrowthYears < 0:
+		return fmt.Errorf("highGrowthYears must not be negative")
+	}
+	return nil
+}
+
+// DividendResult 股利折现估值结果
+type DividendResult struct {
+	// 使用的模型， gordon 或 twoStage
+	Model string `json:"model"`
+	// 下一年的每股股利
+	NextDividend float64 `json:"nextDividend"`
+	// 高增长阶段股利现值合计，仅两阶段模型
+	PVHighGrowth float64 `json:"pvHighGrowth,omitempty"`
+	// 高增长阶段末的终值现值，仅两阶段模型
+	PVTerminalValue float64 `json:"pvTerminalValue,omitempty"`
+	// 每股内在价值
+	ValuePerShare float64 `json:"valuePerShare"`
+	// 相对当前股价的涨跌空间（%），未提供当前股价时为空
+	Upside *float64 `json:"upsidePercent,omitempty"`
+	// 当前股价隐含的回报率（%）， Gordon 模型下为股息率加永续增长率，未提供当前股价时为空
+	ImpliedReturn *float64 `json:"impliedReturnPercent,omitempty"`
+	// 当前股价隐含的股息率（%），未提供当前股价时为空
+	DividendYield *float64 `json:"dividendYieldPercent,omitempty"`
+}
+
+// DividendDiscount 计算股利折现估值
+func DividendDiscount(in DividendInput) (DividendResult, error) {
+	if err := in.Validate(); err != nil {
+		return DividendResult{}, err
+	}
+	r, g := in.RequiredReturn/100, in.GrowthRate/100
+
+	ret := DividendResult{Model: "gordon"}
+	if in.HighGrowthYears == 0 {
+		ret.NextDividend = in.Dividend * (1 + g)
+		ret.ValuePerShare = ret.NextDividend / (r - g)
+	} else {
+		ret.Model = "twoStage"
+		gh := in.HighGrowthRate / 100
+		ret.NextDividend = in.Dividend * (1 + gh)
+		d := in.Dividend
+		for t := 1; t <= in.HighGrowthYears; t++ {
+			d *= 1 + gh
+			ret.PVHighGrowth += d / math.Pow(1+r, float64(t))
+		}
+		tv := d * (1 + g) / (r - g)
+		ret.PVTerminalValue = tv / math.Pow(1+r, float64(in.HighGrowthYears))
+		ret.ValuePerShare = ret.PVHighGrowth + ret.PVTerminalValue
+	}
+
+	if in.CurrentPrice > 0 {
+		upside := (ret.ValuePerShare/in.CurrentPrice - 1) * 100
+		yield := in.Dividend / in.CurrentPrice * 100
+		ret.Upside, ret.DividendYield = &upside, &yield
+		if in.HighGrowthYears == 0 {
+			implied := (ret.NextDividend/in.CurrentPrice + g) * 100
+			ret.ImpliedReturn = &implied
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,195 @@
+package valuation
+
+import (
+	"fmt"
+	"slices"
+)
+
+// 估值倍数
+const (
+	MultiplePE       = "pe"
+	MultipleEVEBITDA = "evEbitda"
+	MultiplePS       = "ps"
+	MultiplePB       = "pb"
+)
+
+// Peer 可比公司的估值倍数，未知的倍数留空
+type Peer struct {
+	Name     string  `json:"name"`
+	PE       float64 `json:"pe,omitempty"`
+	EVEBITDA float64 `json:"evEbitda,omitempty"`
+	PS       float64 `json:"ps,omitempty"`
+	PB       float64 `json:"pb,omitempty"`
+}
+
+// multiple 返回指定倍数
+func (p Peer) multiple(name string) float64 {
+	switch name {
+	case MultiplePE:
+		return p.PE
+	case MultipleEVEBITDA:
+		return p.EVEBITDA
+	case MultiplePS:
+		return p.PS
+	case MultiplePB:
+		return p.PB
+	}
+	return 0
+}
+
+// RelativeInput 相对估值输入，财务数据为最近一期（或预测）的全年数据
+type RelativeInput struct {
+	// 每股收益
+	EPS float64 `json:"eps,omitempty"`
+	// 息税折旧摊销前利润
+	EBITDA float64 `json:"ebitda,omitempty"`
+	// 营业收入
+	Revenue float64 `json:"revenue,omitempty"`
+	// 净资产
+	BookValue float64 `json:"bookValue,omitempty"`
+	// 净债务，净现金时为负数
+	NetDebt float64 `json:"netDebt,omitempty"`
+	// 总股本
+	SharesOutstanding float64 `json:"sharesOutstanding"`
+	// 当前股价
+	CurrentPrice float64 `json:"currentPrice,omitempty"`
+	// 可比公司
+	Peers []Peer `json:"peers"`
+}
+
+// Validate 校验输入
+func (in RelativeInput) Validate() error {
+	switch {
+	case len(in.Peers) == 0:
+		return fmt.Errorf("at least one peer is required")
+	case in.SharesOutstanding <= 0 && (in.EBITDA != 0 || in.Revenue != 0 || in.BookValue != 0):
+		return fmt.Errorf("sharesOutstanding is required for ev/ebitda, p/s and p/b valuation")
+	case in.EPS == 0 && in.EBITDA == 0 && in.Revenue == 0 && in.BookValue == 0:
+		return fmt.Errorf("at least one of eps, ebitda, revenue and bookValue is required")
+	}
+	return nil
+}
+
+// MultipleResult 基于某一估值倍数的相对估值结果
+type MultipleResult struct {
+	Multiple string `json:"multiple"`
+	// 提供该倍数的可比公司数量
+	Peers int `json:"peers"`
+	// 可比公司倍数的统计值
+	Median float64 `json:"median"`
+	Mean   float64 `json:"mean"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	// 以当前股价计算的目标公司倍数，未提供当前股价时为空
+	Current *float64 `json:"current,omitempty"`
+	// 按可比公司中位数计算的每股价值
+	ValuePerShare float64 `json:"valuePerShare"`
+	// 按可比公司最小值、最大值计算的每股价值范围
+	ValueLow  float64 `json:"valueLow"`
+	ValueHigh float64 `json:"valueHigh"`
+	// 相对当前股价的涨跌空间（%），未提供当前股价时为空
+	Upside *float64 `json:"upsidePercent,omitempty"`
+}
+
+// RelativeResult 相对估值结果
+type RelativeResult struct {
+	Multiples []MultipleResult `json:"multiples"`
+}
+
+// Relative 计算相对估值，目标公司缺少对应财务数据或可比公司均未提供的倍数跳过
+//
+// 财务数据为负（如亏损）时对应倍数没有意义，同样跳过
+func Relative(in RelativeInput) (RelativeResult, error) {
+	if err := in.Validate(); err != nil {
+		return RelativeResult{}, err
+	}
+
+	// valueOf 根据倍数计算每股价值
+	valueOf := map[string]func(m float64) float64{}
+	if in.EPS > 0 {
+		valueOf[MultiplePE] = func(m float64) float64 { return m * in.EPS }
+	}
+	if in.EBITDA > 0 {
+		valueOf[MultipleEVEBITDA] = func(m float64) float64 { return (m*in.EBITDA - in.NetDebt) / in.SharesOutstanding }
+	}
+	if in.Revenue > 0 {
+		valueOf[MultiplePS] = func(m float64) float64 { return m * in.Revenue / in.SharesOutstanding }
+	}
+	if in.BookValue > 0 {
+		valueOf[MultiplePB] = func(m float64) float64 { return m * in.BookValue / in.SharesOutstanding }
+	}
+
+	ret := RelativeResult{}
+	for _, name := range []string{MultiplePE, MultipleEVEBITDA, MultiplePS, MultiplePB} {
+		value, ok := valueOf[name]
+		if !ok {
+			continue
+		}
+		var values []float64
+		for _, p := range in.Peers {
+			if m := p.multiple(name); m > 0 {
+				values = append(values, m)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		slices.Sort(values)
+
+		r := MultipleResult{
+			Multiple: name,
+			Peers:    len(values),
+			Median:   median(values),
+			Mean:     mean(values),
+			Min:      values[0],
+			Max:      values[len(values)-1],
+		}
+		r.ValuePerShare = value(r.Median)
+		r.ValueLow, r.ValueHigh = value(r.Min), value(r.Max)
+		if in.CurrentPrice > 0 {
+			current := currentMultiple(name, in)
+			r.Current = &current
+			upside := (r.ValuePerShare/in.CurrentPrice - 1) * 100
+			r.Upside = &upside
+		}
+		ret.Multiples = append(ret.Multiples, r)
+	}
+	if len(ret.Multiples) == 0 {
+		return RelativeResult{}, fmt.Errorf("no multiple can be applied, check that peers provide multiples matching the financials given")
+	}
+	return ret, nil
+}
+
+// currentMultiple 以当前股价计算目标公司的倍数
+func currentMultiple(name string, in RelativeInput) float64 {
+	marketCap := in.CurrentPrice * in.SharesOutstanding
+	switch name {
+	case MultiplePE:
+		return in.CurrentPrice / in.EPS
+	case MultipleEVEBITDA:
+		return (marketCap + in.NetDebt) / in.EBITDA
+	case MultiplePS:
+		return marketCap / in.Revenue
+	case MultiplePB:
+		return marketCap / in.BookValue
+	}
+	return 0
+}
+
+// median 计算已排序数据的中位数
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// mean 计算平均值
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,196 @@
+package valuation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+const (
+	// ValuationToolName 估值工具名
+	ValuationToolName = "Valuation"
+	// 输出数值保留的小数位数
+	outputPlaces = 4
+)
+
+// Input 估值输入，至少提供一种估值方法的参数
+type Input struct {
+	// 金额单位，如 "USD millions" ，仅用于回显
+	Unit string `json:"unit,omitempty"`
+	// 现金流折现
+	DCF *DCFInput `json:"dcf,omitempty"`
+	// 可比公司相对估值
+	Relative *RelativeInput `json:"relative,omitempty"`
+	// 股利折现
+	DividendDiscount *DividendInput `json:"dividendDiscount,omitempty"`
+}
+
+// Output 估值输出
+type Output struct {
+	// 回显的输入（已填充默认值），便于核对估值假设
+	Inputs           Input           `json:"inputs"`
+	DCF              *DCFResult      `json:"dcf,omitempty"`
+	Relative         *RelativeResult `json:"relative,omitempty"`
+	DividendDiscount *DividendResult `json:"dividendDiscount,omitempty"`
+}
+
+// DefineTool 定义估值工具
+func DefineTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, ValuationToolName, `Value a company with DCF (with sensitivity grid), peer multiples and dividend discount models from structured financial inputs.
+
+需要给出内在价值、目标价或判断估值高低时，应先通过其他工具查询财务数据，再使用该工具计算，而不是自行估算。回答时应说明输出中回显的估值假设。
+
+以 JSON 格式输入，提供 dcf 、 relative 、 dividendDiscount 中的一项或多项，百分比参数均以百分数表示（如 8 表示 8% ）：
+- **unit**: (string,optional) 金额单位，如 "USD millions" ，仅用于回显
+- **dcf**: (object,optional) 现金流折现
+  - **cashFlows**: (number[],optional) 逐年预测的自由现金流
+  - **baseCashFlow**: (number,optional) 最近一年的自由现金流，未提供 cashFlows 时按 growthRate 增长生成预测
+  - **growthRate**: (number,optional) 预测期现金流年增长率（%）
+  - **years**: (int,optional) 预测年数，默认 5
+  - **wacc**: (number) 加权平均资本成本（%）
+  - **terminalGrowth**: (number,optional) 永续增长率（%），须小于 wacc
+  - **exitMultiple**: (number,optional) 退出倍数，提供时终值为最后一年现金流乘以该倍数，忽略 terminalGrowth
+  - **netDebt**: (number,optional) 净债务，净现金时为负数
+  - **sharesOutstanding**: (number,optional) 总股本，与金额单位一致，提供时计算每股价值
+  - **currentPrice**: (number,optional) 当前股价
+  - **waccStep** / **terminalStep**: (number,optional) 敏感性分析步长，默认 1 个百分点和 0.5 个百分点（退出倍数为 1 ）
+- **relative**: (object,optional) 可比公司相对估值
+  - **eps** / **ebitda** / **revenue** / **bookValue**: (number,optional) 目标公司的每股收益、 EBITDA 、营业收入、净资产
+  - **netDebt**: (number,optional) 净债务
+  - **sharesOutstanding**: (number) 总股本
+  - **currentPrice**: (number,optional) 当前股价
+  - **peers**: (object[]) 可比公司，每项包括 name 、 pe 、 evEbitda 、 ps 、 pb ，未知的倍数留空
+- **dividendDiscount**: (object,optional) 股利折现，未提供 highGrowthYears 时使用 Gordon 增长模型，否则使用两阶段模型
+  - **dividend**: (number) 最近一年的每股股利
+  - **growthRate**: (number,optional) 永续增长率（%）
+  - **requiredReturn**: (number) 要求回报率（%）
+  - **highGrowthRate**: (number,optional) 高增长阶段的股利增长率（%）
+  - **highGrowthYears**: (int,optional) 高增长阶段年数
+  - **currentPrice**: (number,optional) 当前股价
+
+输出：
+- **inputs**: 回显的输入（已填充默认值和生成的现金流预测）
+- **dcf**: 各年现金流现值、终值及其现值、终值占比、企业价值、股权价值、每股价值、涨跌空间，以及 WACC 与永续增长率（或退出倍数）的敏感性分析 Markdown 表格
+- **relative**: 各倍数的可比公司中位数、平均值、范围、目标公司当前倍数、按中位数计算的每股价值及范围、涨跌空间
+- **dividendDiscount**: 每股价值、涨跌空间，以及当前股价隐含的股息率和回报率
+`,
+		func(ctx *ai.ToolContext, input Input) (Output, error) {
+			return Evaluate(input)
+		},
+	)
+}
+
+// Evaluate 执行估值
+func Evaluate(input Input) (Output, error) {
+	if input.DCF == nil && input.Relative == nil && input.DividendDiscount == nil {
+		return Output{}, fmt.Errorf("at least one of dcf, relative and dividendDiscount is required")
+	}
+
+	ret := Output{Inputs: input}
+	if input.DCF != nil {
+		in := input.DCF.Complete()
+		ret.Inputs.DCF = &in
+		r, err := DCF(in)
+		if err != nil {
+			return Output{}, fmt.Errorf("dcf: %w", err)
+		}
+		r = r.Round(outputPlaces)
+		ret.DCF = &r
+	}
+	if input.Relative != nil {
+		r, err := Relative(*input.Relative)
+		if err != nil {
+			return Output{}, fmt.Errorf("relative: %w", err)
+		}
+		r = r.Round(outputPlaces)
+		ret.Relative = &r
+	}
+	if input.DividendDiscount != nil {
+		r, err := DividendDiscount(*input.DividendDiscount)
+		if err != nil {
+			return Output{}, fmt.Errorf("dividendDiscount: %w", err)
+		}
+		r = r.Round(outputPlaces)
+		ret.DividendDiscount = &r
+	}
+	return ret, nil
+}
+
+// Round 各数值保留 places 位小数
+func (r DCFResult) Round(places int) DCFResult {
+	projections := make([]DCFYear, len(r.Projections))
+	for i, y := range r.Projections {
+		projections[i] = DCFYear{
+			Year:           y.Year,
+			CashFlow:       y.CashFlow,
+			DiscountFactor: roundTo(y.DiscountFactor, places),
+			PresentValue:   roundTo(y.PresentValue, places),
+		}
+	}
+	r.Projections = projections
+	r.PVCashFlows = roundTo(r.PVCashFlows, places)
+	r.TerminalValue = roundTo(r.TerminalValue, places)
+	r.PVTerminalValue = roundTo(r.PVTerminalValue, places)
+	r.TerminalShare = roundTo(r.TerminalShare, 2)
+	r.EnterpriseValue = roundTo(r.EnterpriseValue, places)
+	r.EquityValue = roundTo(r.EquityValue, places)
+	r.ValuePerShare = roundPtr(r.ValuePerShare, places)
+	r.Upside = roundPtr(r.Upside, 2)
+	return r
+}
+
+// Round 各数值保留 places 位小数
+func (r RelativeResult) Round(places int) RelativeResult {
+	multiples := make([]MultipleResult, len(r.Multiples))
+	for i, m := range r.Multiples {
+		m.Median = roundTo(m.Median, places)
+		m.Mean = roundTo(m.Mean, places)
+		m.Current = roundPtr(m.Current, places)
+		m.ValuePerShare = roundTo(m.ValuePerShare, places)
+		m.ValueLow = roundTo(m.ValueLow, places)
+		m.ValueHigh = roundTo(m.ValueHigh, places)
+		m.Upside = roundPtr(m.Upside, 2)
+		multiples[i] = m
+	}
+	r.Multiples = multiples
+	return r
+}
+
+// Round 各数值保留 places 位小数
+func (r DividendResult) Round(places int) DividendResult {
+	r.NextDividend = roundTo(r.NextDividend, places)
+	r.PVHighGrowth = roundTo(r.PVHighGrowth, places)
+	r.PVTerminalValue = roundTo(r.PVTerminalValue, places)
+	r.ValuePerShare = roundTo(r.ValuePerShare, places)
+	r.Upside = roundPtr(r.Upside, 2)
+	r.ImpliedReturn = roundPtr(r.ImpliedReturn, 2)
+	r.DividendYield = roundPtr(r.DividendYield, 2)
+	return r
+}
+
+// roundTo 保留 places 位小数
+func roundTo(v float64, places int) float64 {
+	p := math.Pow10(places)
+	return math.Round(v*p) / p
+}
+
+// roundPtr 保留 places 位小数，空指针原样返回
+func roundPtr(v *float64, places int) *float64 {
+	if v == nil {
+		return nil
+	}
+	r := roundTo(*v, places)
+	return &r
+}
+
+// formatNumber 格式化数值，保留至多 places 位小数
+func formatNumber(v float64, places int) string {
+	v = roundTo(v, places)
+	if v == 0 {
+		v = 0 // 避免输出 -0
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,153 @@
+package valuation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDCF 测试现金流折现估值
+func TestDCF(t *testing.T) {
+	in := DCFInput{
+		CashFlows:         []float64{100, 110, 121},
+		WACC:              10,
+		TerminalGrowth:    2,
+		NetDebt:           200,
+		SharesOutstanding: 10,
+		CurrentPrice:      100,
+	}.Complete()
+	r, err := DCF(in)
+	require.NoError(t, err)
+	require.Len(t, r.Projections, 3)
+	for _, y := range r.Projections {
+		assert.InDelta(t, 100/1.1, y.PresentValue, 1e-9)
+	}
+	assert.InDelta(t, 300/1.1, r.PVCashFlows, 1e-9)
+	assert.InDelta(t, 1542.75, r.TerminalValue, 1e-9)
+	assert.InDelta(t, 1542.75/1.331, r.PVTerminalValue, 1e-9)
+	assert.InDelta(t, 1431.8182, r.EnterpriseValue, 1e-4)
+	assert.InDelta(t, 1231.8182, r.EquityValue, 1e-4)
+	require.NotNil(t, r.ValuePerShare)
+	assert.InDelta(t, 123.1818, *r.ValuePerShare, 1e-4)
+	require.NotNil(t, r.Upside)
+	assert.InDelta(t, 23.1818, *r.Upside, 1e-4)
+
+	// 敏感性分析中心单元格等于基准估值
+	assert.Contains(t, r.Sensitivity, "| 10% | 109.29 | 115.83 | 123.18 | 131.52 | 141.04 |")
+	assert.Contains(t, r.Sensitivity, "| WACC | 1% | 1.5% | 2% | 2.5% | 3% |")
+
+	// 由基期现金流和增长率生成预测，使用退出倍数
+	in = DCFInput{BaseCashFlow: 100, GrowthRate: 10, Years: 2, WACC: 10, ExitMultiple: 10}.Complete()
+	assert.Equal(t, []float64{110, 121}, in.CashFlows)
+	assert.Equal(t, float64(DefaultMultipleStep), in.TerminalStep)
+	r, err = DCF(in)
+	require.NoError(t, err)
+	assert.InDelta(t, 1210, r.TerminalValue, 1e-9)
+	assert.InDelta(t, 1200, r.EnterpriseValue, 1e-9)
+	assert.Nil(t, r.ValuePerShare)
+	assert.Contains(t, r.Sensitivity, "equity value (rows: WACC %, columns: Exit Multiple)")
+
+	_, err = DCF(DCFInput{CashFlows: []float64{100}, WACC: 5, TerminalGrowth: 5}.Complete())
+	assert.Error(t, err)
+	_, err = DCF(DCFInput{WACC: 5}.Complete())
+	assert.Error(t, err)
+}
+
+// TestRelative 测试相对估值
+func TestRelative(t *testing.T) {
+	r, err := Relative(RelativeInput{
+		EPS:               2,
+		EBITDA:            50,
+		NetDebt:           100,
+		SharesOutstanding: 10,
+		CurrentPrice:      25,
+		Peers: []Peer{
+			{Name: "A", PE: 10, EVEBITDA: 6},
+			{Name: "B", PE: 15, EVEBITDA: 8, PB: 2},
+			{Name: "C", PE: 20},
+			{Name: "D", PE: 30, EVEBITDA: 10},
+		},
+	})
+	require.NoError(t, err)
+	// 未提供净资产，跳过 P/B
+	require.Len(t, r.Multiples, 2)
+
+	pe := r.Multiples[0]
+	assert.Equal(t, MultiplePE, pe.Multiple)
+	assert.Equal(t, 4, pe.Peers)
+	assert.Equal(t, 17.5, pe.Median)
+	assert.Equal(t, 18.75, pe.Mean)
+	assert.Equal(t, 35.0, pe.ValuePerShare)
+	assert.Equal(t, 20.0, pe.ValueLow)
+	assert.Equal(t, 60.0, pe.ValueHigh)
+	require.NotNil(t, pe.Current)
+	assert.Equal(t, 12.5, *pe.Current)
+	require.NotNil(t, pe.Upside)
+	assert.InDelta(t, 40, *pe.Upside, 1e-9)
+
+	ev := r.Multiples[1]
+	assert.Equal(t, MultipleEVEBITDA, ev.Multiple)
+	assert.Equal(t, 3, ev.Peers)
+	assert.Equal(t, 8.0, ev.Median)
+	assert.Equal(t, 30.0, ev.ValuePerShare) // (8*50-100)/10
+	require.NotNil(t, ev.Current)
+	assert.Equal(t, 7.0, *ev.Current) // (25*10+100)/50
+
+	_, err = Relative(RelativeInput{EPS: 2, Peers: []Peer{{Name: "A", PB: 1}}})
+	assert.Error(t, err)
+	_, err = Relative(RelativeInput{EPS: 2})
+	assert.Error(t, err)
+}
+
+// TestDividendDiscount 测试股利折现估值
+func TestDividendDiscount(t *testing.T) {
+	r, err := DividendDiscount(DividendInput{Dividend: 2, GrowthRate: 5, RequiredReturn: 10, CurrentPrice: 35})
+	require.NoError(t, err)
+	assert.Equal(t, "gordon", r.Model)
+	assert.InDelta(t, 2.1, r.NextDividend, 1e-9)
+	assert.InDelta(t, 42, r.ValuePerShare, 1e-9)
+	require.NotNil(t, r.Upside)
+	assert.InDelta(t, 20, *r.Upside, 1e-9)
+	require.NotNil(t, r.ImpliedReturn)
+	assert.InDelta(t, 11, *r.ImpliedReturn, 1e-9)
+
+	r, err = DividendDiscount(DividendInput{
+		Dividend:        1,
+		GrowthRate:      5,
+		RequiredReturn:  10,
+		HighGrowthRate:  10,
+		HighGrowthYears: 2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "twoStage", r.Model)
+	assert.InDelta(t, 2, r.PVHighGrowth, 1e-9)
+	assert.InDelta(t, 21, r.PVTerminalValue, 1e-9)
+	assert.InDelta(t, 23, r.ValuePerShare, 1e-9)
+	assert.Nil(t, r.Upside)
+
+	_, err = DividendDiscount(DividendInput{Dividend: 1, GrowthRate: 10, RequiredReturn: 10})
+	assert.Error(t, err)
+}
+
+// TestEvaluate 测试估值工具输入回显
+func TestEvaluate(t *testing.T) {
+	out, err := Evaluate(Input{
+		Unit: "USD millions",
+		DCF:  &DCFInput{BaseCashFlow: 100, GrowthRate: 5, WACC: 9, TerminalGrowth: 2.5},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, out.Inputs.DCF)
+	assert.Equal(t, "USD millions", out.Inputs.Unit)
+	assert.Equal(t, DefaultYears, out.Inputs.DCF.Years)
+	assert.Equal(t, []float64{105, 110.25, 115.7625, 121.5506, 127.6282}, out.Inputs.DCF.CashFlows)
+	assert.Equal(t, float64(DefaultWACCStep), out.Inputs.DCF.WACCStep)
+	require.NotNil(t, out.DCF)
+	assert.Nil(t, out.Relative)
+	assert.Nil(t, out.DividendDiscount)
+
+	_, err = Evaluate(Input{})
+	assert.Error(t, err)
+	_, err = Evaluate(Input{DividendDiscount: &DividendInput{}})
+	assert.ErrorContains(t, err, "dividendDiscount: ")
+}
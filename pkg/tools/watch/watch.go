@@ -0,0 +1,443 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/shopspring/decimal"
+
+	"github.com/yhlooo/nfa/pkg/alerts"
+	"github.com/yhlooo/nfa/pkg/channels"
+	"github.com/yhlooo/nfa/pkg/tokentracker"
+	"github.com/yhlooo/nfa/pkg/tools"
+	"github.com/yhlooo/nfa/pkg/tools/holdings"
+)
+
+const (
+	// WatchlistToolName 自选股列表工具名
+	WatchlistToolName = "Watchlist"
+	// CreateAlertToolName 创建提醒工具名
+	CreateAlertToolName = "CreateAlert"
+	// ListAlertsToolName 列出提醒工具名
+	ListAlertsToolName = "ListAlerts"
+	// DeleteAlertToolName 删除提醒工具名
+	DeleteAlertToolName = "DeleteAlert"
+)
+
+// NewTools 创建自选股和提醒工具
+func NewTools(store *alerts.Store) *Tools {
+	return &Tools{store: store, now: time.Now}
+}
+
+// Tools 自选股和提醒工具，规则保存在数据目录中，由后台服务或终端界面中的轮询器检查
+type Tools struct {
+	store *alerts.Store
+	now   func() time.Time
+}
+
+// RegisterTools 注册所有自选股和提醒工具
+func (t *Tools) RegisterTools(g *genkit.Genkit) []ai.ToolRef {
+	return []ai.ToolRef{
+		t.DefineWatchlistTool(g),
+		t.DefineCreateAlertTool(g),
+		t.DefineListAlertsTool(g),
+		t.DefineDeleteAlertTool(g),
+	}
+}
+
+// WatchlistInput 自选股列表工具输入
+type WatchlistInput struct {
+	// 操作： list 、 add 、 remove
+	Action string `json:"action,omitempty"`
+	// 列表名
+	Name string `json:"name,omitempty"`
+	// 证券代码
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+// WatchlistOutput 自选股列表工具输出
+type WatchlistOutput struct {
+	Watchlists []alerts.Watchlist `json:"watchlists"`
+}
+
+// DefineWatchlistTool 定义自选股列表工具
+func (t *Tools) DefineWatchlistTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, WatchlistToolName, `Read or edit the user's watchlists (named lists of symbols).
+
+用户提到“自选股”、“关注列表”时先用该工具读取，不要让用户重复列出代码。只有用户明确要求时才添加或移除。
+
+以 JSON 格式输入：
+- **action**: (string,optional) 操作： list （默认）列出列表， add 添加证券（列表不存在时创建）， remove 移除证券（不指定 symbols 时删除整个列表）
+- **name**: (string,optional) 列表名， list 时只列出该列表， add 和 remove 时必填
+- **symbols**: (string[],optional) 证券代码，如 NVDA 、 0700.HK 、 600519.SS
+
+输出：
+- **watchlists**: 列表，每项包括列表名 name 和证券代码 symbols
+`,
+		func(ctx *ai.ToolContext, in WatchlistInput) (WatchlistOutput, error) {
+			return t.Watchlist(ctx, in)
+		},
+	)
+}
+
+// Watchlist 列出或修改自选股列表，在消息通道中对话时只能访问当前用户的列表
+func (t *Tools) Watchlist(ctx context.Context, in WatchlistInput) (WatchlistOutput, error) {
+	owner := Owner(ctx)
+	action := strings.ToLower(in.Action)
+	if action == "" {
+		action = "list"
+	}
+	name := strings.TrimSpace(in.Name)
+	if action != "list" && name == "" {
+		return WatchlistOutput{}, fmt.Errorf("name is required for action %q", action)
+	}
+	symbols, err := normalizeSymbols(in.Symbols)
+	if err != nil {
+		return WatchlistOutput{}, err
+	}
+
+	var list *alerts.WatchlistList
+	switch action {
+	case "list":
+		list, err = t.store.Watchlists()
+	case "add":
+		if len(symbols) == 0 {
+			return WatchlistOutput{}, fmt.Errorf("symbols is required for action %q", action)
+		}
+		err = t.store.UpdateWatchlists(func(l *alerts.WatchlistList) error {
+			l.Add(owner, name, symbols...)
+			list = l
+			return nil
+		})
+	case "remove":
+		err = t.store.UpdateWatchlists(func(l *alerts.WatchlistList) error {
+			if !l.Remove(owner, name, symbols...) {
+				return fmt.Errorf("watchlist %q not found", name)
+			}
+			list = l
+			return nil
+		})
+	default:
+		return WatchlistOutput{}, fmt.Errorf("invalid action %q (available: list, add, remove)", in.Action)
+	}
+	if err != nil {
+		return WatchlistOutput{}, err
+	}
+
+	out := WatchlistOutput{Watchlists: []alerts.Watchlist{}}
+	for _, w := range list.Watchlists {
+		if w.Owner != owner || action == "list" && name != "" && !strings.EqualFold(w.Name, name) {
+			continue
+		}
+		out.Watchlists = append(out.Watchlists, w)
+	}
+	return out, nil
+}
+
+// CreateAlertInput 创建提醒工具输入
+type CreateAlertInput struct {
+	Type      string  `json:"type"`
+	Symbol    string  `json:"symbol,omitempty"`
+	Watchlist string  `json:"watchlist,omitempty"`
+	Operator  string  `json:"operator,omitempty"`
+	Value     float64 `json:"value,omitempty"`
+	Indicator string  `json:"indicator,omitempty"`
+	Period    int     `json:"period,omitempty"`
+	Keyword   string  `json:"keyword,omitempty"`
+	Repeat    bool    `json:"repeat,omitempty"`
+	Summarize bool    `json:"summarize,omitempty"`
+	Note      string  `json:"note,omitempty"`
+}
+
+// CreateAlertOutput 创建提醒工具输出
+type CreateAlertOutput struct {
+	ID string `json:"id"`
+	// 规则描述
+	Rule string `json:"rule"`
+	// 推送目标，为空时只在终端界面显示并记录在提醒历史中
+	Delivery string `json:"delivery,omitempty"`
+}
+
+// DefineCreateAlertTool 定义创建提醒工具
+func (t *Tools) DefineCreateAlertTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, CreateAlertToolName, `Create a price, volume, indicator or news alert that is checked in the background and notifies the user when it fires.
+
+用户要求“当……时提醒我”、“跌破/突破……告诉我”、“有……的新闻通知我”时使用该工具，例如“NVDA 跌破 100 提醒我”为 type=price, symbol=NVDA, operator=below, value=100 。
+行情类规则只在证券所在市场交易时段检查，条件从不满足变为满足时提醒。
+在消息通道中对话时，提醒推送给当前用户；在终端中对话时，提醒显示在运行中的终端界面或后台服务日志中。创建后向用户复述规则和规则 ID 。
+
+以 JSON 格式输入：
+- **type**: (string) 规则类型：
+  - price: 价格 >= 或 <= value ；指定 indicator 时与指标值比较，如价格跌破 200 日均线为 operator=below, indicator=sma:200
+  - change: 当日涨跌幅（%）达到 value ，如 operator=below, value=-5 为跌幅超过 5% ；不指定 operator 时为任一方向涨跌幅绝对值 >= value
+  - volume: 最新日成交量 >= 近 period 日平均成交量的 value 倍，如 value=2
+  - indicator: 技术指标 >= 或 <= value ，如 indicator=rsi:14, operator=below, value=30
+  - news: 搜索 keyword 出现新结果时提醒，首次检查只记录已有结果
+- **symbol**: (string,optional) 证券代码，行情类规则与 watchlist 二选一
+- **watchlist**: (string,optional) 自选股列表名，规则对列表中每只证券分别生效
+- **operator**: (string,optional) above 或 below
+- **value**: (number,optional) 阈值：价格、涨跌幅百分比、成交量倍数或指标值
+- **indicator**: (string,optional) 技术指标，格式同技术指标工具，如 rsi:14 、 sma:200 、 ema:20 ；有多个值的指标加字段名，如 macd:12,26,9 hist 、 bb:20,2 lower
+- **period**: (int,optional) volume 规则的平均成交量天数，默认 20
+- **keyword**: (string,optional) news 规则的搜索关键词
+- **repeat**: (bool,optional) 条件不再满足后再次满足时重复提醒，默认只提醒一次
+- **summarize**: (bool,optional) 提醒时由模型查询行情和新闻，总结触发的可能原因
+- **note**: (string,optional) 备注，随提醒发送，如用户设置提醒的原因
+
+输出：
+- **id**: 规则 ID
+- **rule**: 规则描述
+- **delivery**: 推送目标，为空时不推送到消息通道
+`,
+		func(ctx *ai.ToolContext, in CreateAlertInput) (CreateAlertOutput, error) {
+			return t.CreateAlert(ctx, in)
+		},
+	)
+}
+
+// CreateAlert 创建提醒规则，在消息通道中对话时推送目标为当前用户
+func (t *Tools) CreateAlert(ctx context.Context, in CreateAlertInput) (CreateAlertOutput, error) {
+	rule := alerts.Rule{
+		ID:        alerts.NewRuleID(),
+		Type:      alerts.RuleType(strings.ToLower(in.Type)),
+		Watchlist: strings.TrimSpace(in.Watchlist),
+		Operator:  alerts.Operator(strings.ToLower(in.Operator)),
+		Value:     decimal.NewFromFloat(in.Value),
+		Indicator: strings.TrimSpace(in.Indicator),
+		Period:    in.Period,
+		Keyword:   strings.TrimSpace(in.Keyword),
+		Repeat:    in.Repeat,
+		Summarize: in.Summarize,
+		Note:      in.Note,
+		Owner:     Owner(ctx),
+		CreatedAt: t.now(),
+	}
+	if in.Symbol != "" {
+		symbols, err := normalizeSymbols([]string{in.Symbol})
+		if err != nil {
+			return CreateAlertOutput{}, err
+		}
+		rule.Symbol = symbols[0]
+	}
+	rule.Delivery = caller(ctx)
+	if err := rule.Validate(); err != nil {
+		return CreateAlertOutput{}, err
+	}
+
+	if rule.Watchlist != "" {
+		watchlists, err := t.store.Watchlists()
+		if err != nil {
+			return CreateAlertOutput{}, err
+		}
+		if watchlists.Find(rule.Owner, rule.Watchlist) < 0 {
+			return CreateAlertOutput{}, fmt.Errorf("watchlist %q not found", rule.Watchlist)
+		}
+	}
+	err := t.store.UpdateRules(func(list *alerts.RuleList) error {
+		list.Rules = append(list.Rules, rule)
+		return nil
+	})
+	if err != nil {
+		return CreateAlertOutput{}, err
+	}
+	return CreateAlertOutput{ID: rule.ID, Rule: rule.String(), Delivery: rule.Delivery.String()}, nil
+}
+
+// ListAlertsOutput 列出提醒工具输出
+type ListAlertsOutput struct {
+	// 规则表格
+	Rules string `json:"rules"`
+	// 最近的提醒表格
+	RecentEvents string `json:"recentEvents,omitempty"`
+}
+
+// maxListedEvents 列出的最近提醒条数
+const maxListedEvents = 10
+
+// DefineListAlertsTool 定义列出提醒工具
+func (t *Tools) DefineListAlertsTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, ListAlertsToolName, `List the user's alert rules, their status and recently fired alerts.
+
+在消息通道中对话时只列出当前用户创建的规则和提醒。
+
+以 JSON 格式输入： {}
+
+输出：
+- **rules**: 规则 Markdown 表格，包括 ID 、规则、推送目标、状态（ active 检查中、 fired 已提醒且不再检查、 disabled 已暂停）、最近检查时间和错误
+- **recentEvents**: 最近的提醒 Markdown 表格
+`,
+		func(ctx *ai.ToolContext, _ struct{}) (ListAlertsOutput, error) {
+			return t.ListAlerts(ctx)
+		},
+	)
+}
+
+// ListAlerts 列出提醒规则和最近的提醒，在消息通道中对话时只列出当前用户的规则
+func (t *Tools) ListAlerts(ctx context.Context) (ListAlertsOutput, error) {
+	owner := Owner(ctx)
+	list, err := t.store.Rules()
+	if err != nil {
+		return ListAlertsOutput{}, err
+	}
+	state, err := t.store.State()
+	if err != nil {
+		return ListAlertsOutput{}, err
+	}
+
+	rows := make([][]string, 0, len(list.Rules))
+	owned := map[string]bool{}
+	for _, rule := range list.Rules {
+		if owner != "" && rule.Owner != owner {
+			continue
+		}
+		owned[rule.ID] = true
+		rs := state.Rules[rule.ID]
+		checked, errMsg := "", ""
+		if rs != nil {
+			checked, errMsg = formatTime(rs.CheckedAt), rs.Error
+		}
+		rows = append(rows, []string{
+			rule.ID, rule.String(), rule.Delivery.String(), Status(&rule, rs), checked, errMsg,
+		})
+	}
+	out := ListAlertsOutput{
+		Rules: holdings.Table([]string{"ID", "Rule", "Delivery", "Status", "Checked At", "Error"}, rows),
+	}
+
+	events := state.Events
+	if owner != "" {
+		events = slices.DeleteFunc(slices.Clone(events), func(e alerts.Event) bool { return !owned[e.RuleID] })
+	}
+	if len(events) > maxListedEvents {
+		events = events[len(events)-maxListedEvents:]
+	}
+	if len(events) > 0 {
+		rows = make([][]string, 0, len(events))
+		for _, e := range events {
+			rows = append(rows, []string{formatTime(e.Time), e.RuleID, oneLine(e.Message), e.Error})
+		}
+		out.RecentEvents = holdings.Table([]string{"Time", "Rule ID", "Message", "Error"}, rows)
+	}
+	return out, nil
+}
+
+// DeleteAlertInput 删除提醒工具输入
+type DeleteAlertInput struct {
+	ID string `json:"id"`
+}
+
+// DeleteAlertOutput 删除提醒工具输出
+type DeleteAlertOutput struct {
+	// 被删除的规则描述
+	Deleted string `json:"deleted"`
+}
+
+// DefineDeleteAlertTool 定义删除提醒工具
+func (t *Tools) DefineDeleteAlertTool(g *genkit.Genkit) ai.ToolRef {
+	return genkit.DefineTool(g, DeleteAlertToolName, `Delete an alert rule by ID.
+
+用户要求取消提醒时使用，不确定 ID 时先用 ListAlerts 工具查看。在消息通道中对话时只能删除当前用户创建的规则。
+
+以 JSON 格式输入：
+- **id**: (string) 规则 ID
+
+输出：
+- **deleted**: 被删除的规则描述
+`,
+		func(ctx *ai.ToolContext, in DeleteAlertInput) (DeleteAlertOutput, error) {
+			rule, err := DeleteRule(t.store, Owner(ctx), in.ID)
+			if err != nil {
+				return DeleteAlertOutput{}, err
+			}
+			return DeleteAlertOutput{Deleted: rule.String()}, nil
+		},
+	)
+}
+
+// DeleteRule 删除规则及其状态，返回被删除的规则
+//
+// owner 不为空时只能删除该所有者的规则，为空时（本地用户）可以删除任意规则
+func DeleteRule(store *alerts.Store, owner, id string) (alerts.Rule, error) {
+	var rule alerts.Rule
+	err := store.UpdateRules(func(list *alerts.RuleList) error {
+		i := list.Find(id)
+		if i < 0 || owner != "" && list.Rules[i].Owner != owner {
+			return fmt.Errorf("alert rule %q not found", id)
+		}
+		rule = list.Rules[i]
+		list.Rules = append(list.Rules[:i], list.Rules[i+1:]...)
+		return nil
+	})
+	if err != nil {
+		return rule, err
+	}
+	return rule, store.UpdateState(func(state *alerts.State) error {
+		delete(state.Rules, id)
+		return nil
+	})
+}
+
+// Owner 返回当前对话用户作为规则和自选股列表的所有者
+//
+// 在消息通道中对话时为通道用户，如 wecomAIBot:user:alice ；在终端中对话或执行后台任务时为空，表示本地用户
+func Owner(ctx context.Context) string {
+	target := caller(ctx)
+	if target.Channel == "" {
+		return ""
+	}
+	return target.String()
+}
+
+// caller 返回在消息通道中对话的用户，在终端中对话或执行后台任务时返回零值
+func caller(ctx context.Context) channels.Target {
+	info := tokentracker.CallInfoFromContext(ctx)
+	if info.Channel == "" || info.UserID == "" || channels.IsBackground(info.Channel) {
+		return channels.Target{}
+	}
+	return channels.Target{Channel: info.Channel, UserID: info.UserID}
+}
+
+// Status 返回规则状态： disabled 、 fired （只提醒一次且已全部提醒）或 active
+func Status(rule *alerts.Rule, state *alerts.RuleState) string {
+	switch {
+	case rule.Disabled:
+		return "disabled"
+	case rule.Repeat || rule.Type == alerts.RuleNews || rule.Watchlist != "" || state == nil:
+		return "active"
+	}
+	if ts, ok := state.Targets[rule.Symbol]; ok && !ts.FiredAt.IsZero() {
+		return "fired"
+	}
+	return "active"
+}
+
+// normalizeSymbols 转换为标准证券代码
+func normalizeSymbols(symbols []string) ([]string, error) {
+	ret := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		symbol, ok := tools.NormalizeSymbol(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid symbol %q", s)
+		}
+		ret = append(ret, symbol)
+	}
+	return ret, nil
+}
+
+// formatTime 格式化时间，零值时返回空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04 MST")
+}
+
+// oneLine 将多行文本合并为一行
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
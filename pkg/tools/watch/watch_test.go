@@ -0,0 +1,143 @@
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yhlooo/nfa/pkg/alerts"
+	"github.com/yhlooo/nfa/pkg/tokentracker"
+)
+
+// userContext 返回在消息通道中与指定用户对话的上下文
+func userContext(userID string) context.Context {
+	return tokentracker.ContextWithCallInfo(context.Background(), tokentracker.CallInfo{
+		Channel: "wecomAIBot",
+		UserID:  userID,
+	})
+}
+
+// TestAlerts 测试创建、列出和删除提醒
+func TestAlerts(t *testing.T) {
+	store := alerts.NewStore(t.TempDir())
+	tools := NewTools(store)
+	ctx := context.Background()
+
+	out, err := tools.CreateAlert(ctx, CreateAlertInput{Type: "price", Symbol: "nvda", Operator: "below", Value: 100})
+	require.NoError(t, err)
+	assert.NotEmpty(t, out.ID)
+	assert.Contains(t, out.Rule, "NVDA")
+	assert.Empty(t, out.Delivery)
+
+	_, err = tools.CreateAlert(ctx, CreateAlertInput{Type: "price", Symbol: "NVDA"})
+	assert.Error(t, err)
+	_, err = tools.CreateAlert(ctx, CreateAlertInput{Type: "price", Watchlist: "tech", Operator: "below", Value: 1})
+	assert.ErrorContains(t, err, `watchlist "tech" not found`)
+
+	list, err := tools.ListAlerts(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, list.Rules, out.ID)
+	assert.Empty(t, list.RecentEvents)
+
+	rule, err := DeleteRule(store, "", out.ID)
+	require.NoError(t, err)
+	assert.Equal(t, out.ID, rule.ID)
+	_, err = DeleteRule(store, "", out.ID)
+	assert.ErrorContains(t, err, "not found")
+}
+
+// TestAlertsChannelUser 测试消息通道用户只能访问自己的提醒
+func TestAlertsChannelUser(t *testing.T) {
+	store := alerts.NewStore(t.TempDir())
+	tools := NewTools(store)
+	alice, bob := userContext("alice"), userContext("bob")
+
+	aliceOut, err := tools.CreateAlert(alice, CreateAlertInput{Type: "price", Symbol: "NVDA", Operator: "below", Value: 100})
+	require.NoError(t, err)
+	assert.Equal(t, "wecomAIBot:user:alice", aliceOut.Delivery)
+	bobOut, err := tools.CreateAlert(bob, CreateAlertInput{Type: "news", Keyword: "TSMC"})
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateState(func(state *alerts.State) error {
+		state.Events = append(state.Events,
+			alerts.Event{Time: time.Now(), RuleID: aliceOut.ID, Message: "NVDA below 100"},
+			alerts.Event{Time: time.Now(), RuleID: bobOut.ID, Message: "TSMC news"},
+		)
+		return nil
+	}))
+
+	// 只列出自己的规则和提醒
+	list, err := tools.ListAlerts(alice)
+	require.NoError(t, err)
+	assert.Contains(t, list.Rules, aliceOut.ID)
+	assert.NotContains(t, list.Rules, bobOut.ID)
+	assert.NotContains(t, list.Rules, "wecomAIBot:user:bob")
+	assert.Contains(t, list.RecentEvents, "NVDA below 100")
+	assert.NotContains(t, list.RecentEvents, "TSMC news")
+
+	// 本地用户列出所有规则
+	list, err = tools.ListAlerts(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, list.Rules, aliceOut.ID)
+	assert.Contains(t, list.Rules, bobOut.ID)
+
+	// 不能删除其他用户的规则
+	_, err = DeleteRule(store, Owner(alice), bobOut.ID)
+	assert.ErrorContains(t, err, "not found")
+	rules, err := store.Rules()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, rules.Find(bobOut.ID), 0)
+
+	_, err = DeleteRule(store, Owner(bob), bobOut.ID)
+	require.NoError(t, err)
+}
+
+// TestWatchlist 测试自选股列表按用户隔离
+func TestWatchlist(t *testing.T) {
+	store := alerts.NewStore(t.TempDir())
+	tools := NewTools(store)
+	ctx, alice, bob := context.Background(), userContext("alice"), userContext("bob")
+
+	out, err := tools.Watchlist(alice, WatchlistInput{Action: "add", Name: "tech", Symbols: []string{"nvda", "AAPL"}})
+	require.NoError(t, err)
+	require.Len(t, out.Watchlists, 1)
+	assert.Equal(t, []string{"NVDA", "AAPL"}, out.Watchlists[0].Symbols)
+	_, err = tools.Watchlist(ctx, WatchlistInput{Action: "add", Name: "tech", Symbols: []string{"MSFT"}})
+	require.NoError(t, err)
+
+	// 其他用户看不到也不能修改
+	out, err = tools.Watchlist(bob, WatchlistInput{})
+	require.NoError(t, err)
+	assert.Empty(t, out.Watchlists)
+	_, err = tools.Watchlist(bob, WatchlistInput{Action: "remove", Name: "tech"})
+	assert.ErrorContains(t, err, "not found")
+	_, err = tools.CreateAlert(bob, CreateAlertInput{Type: "price", Watchlist: "tech", Operator: "below", Value: 1})
+	assert.ErrorContains(t, err, "not found")
+
+	// 同名列表互不影响
+	out, err = tools.Watchlist(ctx, WatchlistInput{Name: "tech"})
+	require.NoError(t, err)
+	require.Len(t, out.Watchlists, 1)
+	assert.Equal(t, []string{"MSFT"}, out.Watchlists[0].Symbols)
+
+	_, err = tools.Watchlist(alice, WatchlistInput{Action: "remove", Name: "tech", Symbols: []string{"AAPL"}})
+	require.NoError(t, err)
+	out, err = tools.Watchlist(alice, WatchlistInput{Action: "list"})
+	require.NoError(t, err)
+	require.Len(t, out.Watchlists, 1)
+	assert.Equal(t, []string{"NVDA"}, out.Watchlists[0].Symbols)
+
+	// 规则使用所有者的列表
+	created, err := tools.CreateAlert(alice, CreateAlertInput{Type: "price", Watchlist: "tech", Operator: "below", Value: 1})
+	require.NoError(t, err)
+	rules, err := store.Rules()
+	require.NoError(t, err)
+	watchlists, err := store.Watchlists()
+	require.NoError(t, err)
+	rule := rules.Rules[rules.Find(created.ID)]
+	symbols, err := rule.Symbols(watchlists)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"NVDA"}, symbols)
+}
@@ -16,8 +16,12 @@ import (
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/yhlooo/nfa/pkg/ctxutil"
+	"github.com/yhlooo/nfa/pkg/metrics"
+	"github.com/yhlooo/nfa/pkg/ratelimit"
+	"github.com/yhlooo/nfa/pkg/telemetry"
 	"github.com/yhlooo/nfa/pkg/tokentracker"
 )
 
@@ -104,9 +108,9 @@ func (wb *WebBrowser) DefineBrowseTool(g *genkit.Genkit) ai.ToolRef {
 				wb.cacheText = ""
 				wb.cacheScreenshot = nil
 				if err := chromedp.Run(chromeCtx,
-					chromedp.Navigate(in.URL),
-					chromedp.Text("body", &wb.cacheText),
-					chromedp.FullScreenshot(&wb.cacheScreenshot, 50),
+					tracedAction("navigate", chromedp.Navigate(in.URL), telemetry.AttrURL.String(in.URL)),
+					tracedAction("text", chromedp.Text("body", &wb.cacheText)),
+					tracedAction("screenshot", chromedp.FullScreenshot(&wb.cacheScreenshot, 50)),
 				); err != nil {
 					return BrowseOutput{}, err
 				}
@@ -119,18 +123,7 @@ func (wb *WebBrowser) DefineBrowseTool(g *genkit.Genkit) ai.ToolRef {
 
 			// 使用视觉模型读取图片内容
 			m, _ := ctxutil.ModelsFromContext(ctx2)
-			resp, err := genkit.Generate(ctx2, g,
-				ai.WithModelName(m.GetVision()),
-				ai.WithMessages(
-					ai.NewUserMessage(
-						ai.NewMediaPart(
-							"image/jpeg",
-							"data:image/jpeg;base64,"+base64.StdEncoding.EncodeToString(wb.cacheScreenshot)),
-						ai.NewTextPart("根据图片中的信息回答：\n"+in.Question),
-					),
-				),
-				ai.WithMiddleware(tokentracker.ModelMiddlewareFromContext(ctx, m.GetVision())),
-			)
+			resp, err := generateVision(ctx2, g, m.GetVision(), wb.cacheScreenshot, in.Question)
 			if err != nil {
 				return BrowseOutput{}, err
 			}
@@ -139,6 +132,51 @@ func (wb *WebBrowser) DefineBrowseTool(g *genkit.Genkit) ai.ToolRef {
 	)
 }
 
+// generateVision 使用视觉模型根据网页截图回答问题
+//
+// 与对话流程相同，依次经过用量跟踪（含预算检查）、限流和指标中间件
+func generateVision(
+	ctx context.Context,
+	g *genkit.Genkit,
+	modelName string,
+	screenshot []byte,
+	question string,
+) (resp *ai.ModelResponse, err error) {
+	ctx, span := telemetry.Start(ctx, "nfa.generate", telemetry.AttrModel.String(modelName))
+	defer func() { telemetry.End(span, err) }()
+
+	resp, err = genkit.Generate(ctx, g,
+		ai.WithModelName(modelName),
+		ai.WithMessages(
+			ai.NewUserMessage(
+				ai.NewMediaPart(
+					"image/jpeg",
+					"data:image/jpeg;base64,"+base64.StdEncoding.EncodeToString(screenshot)),
+				ai.NewTextPart("根据图片中的信息回答：\n"+question),
+			),
+		),
+		ai.WithMiddleware(
+			tokentracker.ModelMiddlewareFromContext(ctx, modelName),
+			ratelimit.ModelMiddlewareFromContext(ctx, modelName),
+			metrics.ModelMiddleware(modelName),
+		),
+	)
+	if resp != nil {
+		span.SetAttributes(telemetry.UsageAttributes(resp.Usage)...)
+	}
+	return resp, err
+}
+
+// tracedAction 记录 span 的 chromedp 步骤
+func tracedAction(name string, action chromedp.Action, attrs ...attribute.KeyValue) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		ctx, span := telemetry.Start(ctx, "chromedp."+name, attrs...)
+		err := action.Do(ctx)
+		telemetry.End(span, err)
+		return err
+	})
+}
+
 // FetchInput 获取 URL 内容输入
 type FetchInput struct {
 	URL string `json:"url"`
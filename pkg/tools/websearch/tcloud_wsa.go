@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
@@ -22,6 +21,15 @@ type TencentCloudWSAOptions struct {
 
 // RegisterTool 注册工具
 func (opts *TencentCloudWSAOptions) RegisterTool(_ context.Context, g *genkit.Genkit) (SearchTool, error) {
+	search, err := opts.NewSearchFunc()
+	if err != nil {
+		return nil, err
+	}
+	return DefineSearchTool(g, search), nil
+}
+
+// NewSearchFunc 创建腾讯云 WSA 搜索函数
+func (opts *TencentCloudWSAOptions) NewSearchFunc() (SearchFunc, error) {
 	cred := common.NewCredential(opts.SecretID, opts.SecretKey)
 	p := profile.NewClientProfile()
 	if opts.Endpoint != "" {
@@ -31,8 +39,7 @@ func (opts *TencentCloudWSAOptions) RegisterTool(_ context.Context, g *genkit.Ge
 	if err != nil {
 		return nil, fmt.Errorf("new tencent cloud wsa client error: %s", err)
 	}
-
-	return DefineTencentCloudWSASearchTool(g, client), nil
+	return TencentCloudWSASearchFunc(client), nil
 }
 
 // DefineTencentCloudWSASearchTool 定义腾讯云 WSA 搜索工具
@@ -40,41 +47,44 @@ func DefineTencentCloudWSASearchTool(
 	g *genkit.Genkit,
 	client *wsa.Client,
 ) SearchTool {
-	return genkit.DefineTool(g, SearchToolName, SearchDesc,
-		func(ctx *ai.ToolContext, in SearchInput) (SearchOutput, error) {
-			req := wsa.NewSearchProRequest()
-			req.Query = &in.Query
-			resp, err := client.SearchProWithContext(ctx, req)
-			if err != nil {
-				return SearchOutput{}, err
-			}
-			if resp == nil || resp.Response == nil {
-				return SearchOutput{}, nil
+	return DefineSearchTool(g, TencentCloudWSASearchFunc(client))
+}
+
+// TencentCloudWSASearchFunc 返回使用腾讯云 WSA 客户端搜索的函数
+func TencentCloudWSASearchFunc(client *wsa.Client) SearchFunc {
+	return func(ctx context.Context, query string) (SearchOutput, error) {
+		req := wsa.NewSearchProRequest()
+		req.Query = &query
+		resp, err := client.SearchProWithContext(ctx, req)
+		if err != nil {
+			return SearchOutput{}, err
+		}
+		if resp == nil || resp.Response == nil {
+			return SearchOutput{}, nil
+		}
+		ret := &SearchOutput{}
+		for _, page := range resp.Response.Pages {
+			if page == nil {
+				continue
 			}
-			ret := &SearchOutput{}
-			for _, page := range resp.Response.Pages {
-				if page == nil {
-					continue
-				}
-				data := wsaPage{}
-				if err := json.Unmarshal([]byte(*page), &data); err != nil {
-					ret.Items = append(ret.Items, SearchResultItem{
-						Description: *page,
-					})
-					continue
-				}
-				date, _ := time.Parse(time.DateTime, data.Date)
+			data := wsaPage{}
+			if err := json.Unmarshal([]byte(*page), &data); err != nil {
 				ret.Items = append(ret.Items, SearchResultItem{
-					Title:       data.Title,
-					Description: data.Passage,
-					Date:        date,
-					URL:         data.URL,
-					Site:        data.Site,
+					Description: *page,
 				})
+				continue
 			}
-			return *ret, nil
-		},
-	)
+			date, _ := time.Parse(time.DateTime, data.Date)
+			ret.Items = append(ret.Items, SearchResultItem{
+				Title:       data.Title,
+				Description: data.Passage,
+				Date:        date,
+				URL:         data.URL,
+				Site:        data.Site,
+			})
+		}
+		return *ret, nil
+	}
 }
 
 type wsaPage struct {
@@ -1,9 +1,11 @@
 package websearch
 
 import (
+	"context"
 	"time"
 
 	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
 )
 
 const (
@@ -39,3 +41,15 @@ type SearchResultItem struct {
 
 // SearchTool 网络搜索工具
 type SearchTool = *ai.ToolDef[SearchInput, SearchOutput]
+
+// SearchFunc 网络搜索函数
+type SearchFunc func(ctx context.Context, query string) (SearchOutput, error)
+
+// DefineSearchTool 定义使用 search 搜索的网络搜索工具
+func DefineSearchTool(g *genkit.Genkit, search SearchFunc) SearchTool {
+	return genkit.DefineTool(g, SearchToolName, SearchDesc,
+		func(ctx *ai.ToolContext, in SearchInput) (SearchOutput, error) {
+			return search(ctx, in.Query)
+		},
+	)
+}
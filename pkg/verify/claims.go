@@ -0,0 +1,234 @@
+package verify
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ClaimKind 数值陈述类型
+type ClaimKind string
+
+const (
+	// KindNumber 数值，如价格、金额
+	KindNumber ClaimKind = "number"
+	// KindPercent 百分比
+	KindPercent ClaimKind = "percent"
+	// KindRatio 倍数，如市盈率 18x
+	KindRatio ClaimKind = "ratio"
+	// KindDate 日期
+	KindDate ClaimKind = "date"
+)
+
+// maxContextLen 陈述所在句子的最大长度
+const maxContextLen = 80
+
+// Claim 回答中的数值陈述
+type Claim struct {
+	// 原文，如 12.5% 、 1,800 亿
+	Text string `json:"text"`
+	// 类型
+	Kind ClaimKind `json:"kind"`
+	// 数值，百分比为百分数本身（ 12.5% 为 12.5 ），日期为零
+	Value float64 `json:"value,omitempty"`
+	// 日期，格式 YYYY-MM-DD
+	Date string `json:"date,omitempty"`
+	// 所在句子
+	Context string `json:"context"`
+
+	// 数量级，如 亿 为 1e8
+	scale float64
+	// 小数位数
+	decimals int
+	// 是否为约数
+	approx bool
+}
+
+var (
+	// datePattern 完整日期
+	datePattern = regexp.MustCompile(`(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})|(\d{4})\s*年\s*(\d{1,2})\s*月\s*(\d{1,2})\s*[日号]`)
+	// numberPattern 数字及其单位
+	numberPattern = regexp.MustCompile(`(\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+(?:\.\d+)?)\s*(%|％|个百分点|bps|bp|x|X|倍|万亿|千亿|百亿|亿|千万|百万|万|千|trillion|billion|million|thousand|tn|bn|mn|[kKmMbBT])?`)
+	// sentenceSplitPattern 句子分隔符，英文句号需后跟空白以免拆开小数
+	sentenceSplitPattern = regexp.MustCompile(`[。！？；;!?\n]+|\.\s+`)
+	// CitationPattern 来源引用，如 [S1] 、 [S1, S3] ，第一个分组为引用的来源 ID 列表
+	CitationPattern = regexp.MustCompile(`\[\s*(S\d+(?:\s*[,，、;；]\s*S\d+)*)\s*]`)
+	// codeBlockPattern 代码块
+	codeBlockPattern = regexp.MustCompile("(?s)```.*?```")
+	// listMarkerPattern 有序列表序号
+	listMarkerPattern = regexp.MustCompile(`(?m)^\s*\d+[.)、]\s+`)
+	// approxPattern 表示约数的词
+	approxPattern = regexp.MustCompile(`(?i)(约|大约|近|接近|超过|逾|左右|上下|~|≈|about|around|approximately|nearly|roughly|over|almost)\s*$`)
+	// forwardLookingPattern 表示预测、假设的词，所在句子的数值不要求可追溯
+	forwardLookingPattern = regexp.MustCompile(`(?i)预计|预期|预测|假设|假定|估计|目标价|情景|如果|若|estimate|expect|forecast|assum|target|scenario|\bif\b`)
+	// countSuffixPattern 表示计数或时间长度的量词，前面的整数不视为数值陈述
+	countSuffixPattern = regexp.MustCompile(`^\s*(个|年|月|日|天|周|次|只|家|名|位|项|条|季|期|小时|分钟|days?|weeks?|months?|years?|times|steps?|items?)`)
+)
+
+// scales 单位对应的数量级
+var scales = map[string]float64{
+	"万亿": 1e12, "千亿": 1e11, "百亿": 1e10, "亿": 1e8, "千万": 1e7, "百万": 1e6, "万": 1e4, "千": 1e3,
+	"trillion": 1e12, "billion": 1e9, "million": 1e6, "thousand": 1e3,
+	"tn": 1e12, "bn": 1e9, "mn": 1e6, "t": 1e12, "b": 1e9, "m": 1e6, "k": 1e3,
+}
+
+// SplitSentences 按中英文句末标点和换行拆分句子，不拆开小数
+func SplitSentences(text string) []string {
+	return sentenceSplitPattern.Split(text, -1)
+}
+
+// ExtractClaims 提取回答中的数值陈述，跳过代码块、来源引用、列表序号、年份、计数、证券代码和预测性陈述
+func ExtractClaims(answer string) []Claim {
+	answer = codeBlockPattern.ReplaceAllString(answer, "")
+	answer = CitationPattern.ReplaceAllString(answer, "")
+	answer = listMarkerPattern.ReplaceAllString(answer, "")
+
+	var ret []Claim
+	for _, sentence := range SplitSentences(answer) {
+		if strings.TrimSpace(sentence) == "" || forwardLookingPattern.MatchString(sentence) {
+			continue
+		}
+		ret = append(ret, extractSentenceClaims(sentence)...)
+	}
+	return ret
+}
+
+// extractSentenceClaims 提取一个句子中的数值陈述
+func extractSentenceClaims(sentence string) []Claim {
+	context := strings.TrimSpace(strings.Trim(sentence, "|-*# \t"))
+	if utf8.RuneCountInString(context) > maxContextLen {
+		context = string([]rune(context)[:maxContextLen]) + "..."
+	}
+
+	var ret []Claim
+	// 日期
+	for _, m := range datePattern.FindAllStringSubmatchIndex(sentence, -1) {
+		groups := []int{1, 2, 3}
+		if m[2] < 0 {
+			groups = []int{4, 5, 6}
+		}
+		var parts [3]int
+		for i, g := range groups {
+			parts[i], _ = strconv.Atoi(sentence[m[2*g]:m[2*g+1]])
+		}
+		date := time.Date(parts[0], time.Month(parts[1]), parts[2], 0, 0, 0, 0, time.UTC)
+		if date.Month() != time.Month(parts[1]) || date.Day() != parts[2] {
+			continue
+		}
+		ret = append(ret, Claim{
+			Text:    sentence[m[0]:m[1]],
+			Kind:    KindDate,
+			Date:    date.Format(time.DateOnly),
+			Context: context,
+		})
+	}
+	sentence = datePattern.ReplaceAllStringFunc(sentence, func(s string) string { return strings.Repeat(" ", len(s)) })
+
+	// 数字
+	for _, m := range numberPattern.FindAllStringSubmatchIndex(sentence, -1) {
+		start, end := m[0], m[1]
+		raw := sentence[m[2]:m[3]]
+		unit := ""
+		if m[4] >= 0 {
+			unit = sentence[m[4]:m[5]]
+		}
+		before, after := lastRune(sentence[:start]), firstRune(sentence[end:])
+		// 字母、数字或点号相连的数字是代码、型号或证券代码的一部分，如 S1 、 MA20 、 0700.HK 、 5G
+		if isWordRune(before) || before == '.' || before == ':' || after == ':' || after == '.' && isWordRune(firstRune(sentence[end+1:])) {
+			continue
+		}
+		if unit != "" && isWordRune(after) && isWordRune(lastRune(unit)) && lastRune(unit) < utf8.RuneSelf {
+			continue
+		}
+		if unit == "" && isWordRune(after) && after < utf8.RuneSelf {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", ""), 64)
+		if err != nil {
+			continue
+		}
+		decimals := 0
+		if i := strings.IndexByte(raw, '.'); i >= 0 {
+			decimals = len(raw) - i - 1
+		}
+		c := Claim{
+			Text:     strings.TrimSpace(sentence[start:end]),
+			Kind:     KindNumber,
+			Value:    value,
+			Context:  context,
+			scale:    1,
+			decimals: decimals,
+			approx:   approxPattern.MatchString(sentence[:start]),
+		}
+		switch unit {
+		case "%", "％", "个百分点":
+			c.Kind = KindPercent
+		case "bp", "bps":
+			c.Kind = KindPercent
+			c.Value = value / 100
+			c.decimals += 2
+		case "x", "X", "倍":
+			c.Kind = KindRatio
+		case "":
+			isInteger := decimals == 0 && !strings.Contains(raw, ",")
+			// 年份和小整数通常不是需要核实的数值
+			if isInteger && (value <= 10 || value >= 1900 && value <= 2100) {
+				continue
+			}
+			if isInteger && countSuffixPattern.MatchString(sentence[end:]) {
+				continue
+			}
+		default:
+			c.scale = scales[strings.ToLower(unit)]
+			if c.scale == 0 {
+				continue
+			}
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+// candidates 陈述可能对应的工具输出数值
+func (c Claim) candidates() []float64 {
+	switch c.Kind {
+	case KindPercent:
+		return []float64{c.Value, c.Value / 100}
+	case KindNumber:
+		if c.scale != 1 {
+			return []float64{c.Value * c.scale, c.Value}
+		}
+	}
+	return []float64{c.Value}
+}
+
+// roundingTolerance 按陈述显示精度四舍五入带来的误差
+func (c Claim) roundingTolerance(candidate float64) float64 {
+	tol := 0.5 * math.Pow10(-c.decimals)
+	if c.Value != 0 {
+		tol *= candidate / c.Value
+	}
+	return math.Abs(tol)
+}
+
+// firstRune 字符串的第一个字符
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+// lastRune 字符串的最后一个字符
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+// isWordRune 判断字符是否为 ASCII 字母、数字或下划线
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
@@ -0,0 +1,301 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultTolerance 默认相对误差容忍度
+	DefaultTolerance = 0.001
+	// DefaultApproxTolerance 约数默认的相对误差容忍度
+	DefaultApproxTolerance = 0.05
+	// DefaultMaxCorrections 默认最多要求模型修正的次数
+	DefaultMaxCorrections = 1
+	// maxDerivedValues 计算派生数值（涨跌幅、比值）时每个工具输出最多使用的数值个数
+	maxDerivedValues = 500
+)
+
+// Mode 核对模式
+type Mode string
+
+const (
+	// ModeCorrect 要求模型修正无法追溯的数值，仍无法追溯时提示用户
+	ModeCorrect Mode = "correct"
+	// ModeWarn 只提示用户
+	ModeWarn Mode = "warn"
+	// ModeOff 不核对
+	ModeOff Mode = "off"
+)
+
+// Options 数值核对选项
+type Options struct {
+	// 模式，可选 correct （默认）、 warn 、 off
+	Mode Mode `json:"mode,omitempty"`
+	// 相对误差容忍度，默认 0.001 ，即 0.1% ，按显示精度四舍五入的误差总是被容忍
+	Tolerance float64 `json:"tolerance,omitempty"`
+	// 约数（如“约 55%”）的相对误差容忍度，默认 0.05
+	ApproxTolerance float64 `json:"approxTolerance,omitempty"`
+	// 最多要求模型修正的次数，默认 1
+	MaxCorrections int `json:"maxCorrections,omitempty"`
+}
+
+// Complete 使用默认值补全选项
+func (opts *Options) Complete() {
+	if opts.Mode == "" {
+		opts.Mode = ModeCorrect
+	}
+	if opts.Tolerance == 0 {
+		opts.Tolerance = DefaultTolerance
+	}
+	if opts.ApproxTolerance == 0 {
+		opts.ApproxTolerance = DefaultApproxTolerance
+	}
+	if opts.MaxCorrections == 0 {
+		opts.MaxCorrections = DefaultMaxCorrections
+	}
+}
+
+// Verifier 将回答中的数值陈述与工具输出进行核对
+type Verifier struct {
+	opts Options
+	// 工具输出中的数值的绝对值，升序排列
+	values []float64
+	// 工具输出结构化字段中的数值，用于计算派生数值
+	fields []field
+	// 已添加的工具输出个数
+	outputs int
+	// 工具输出中的日期
+	dates map[string]struct{}
+}
+
+// NewVerifier 创建 Verifier
+func NewVerifier(opts Options) *Verifier {
+	opts.Complete()
+	return &Verifier{opts: opts, dates: map[string]struct{}{}}
+}
+
+// AddOutput 添加工具输出，收集其中的数值和日期，字符串字段中的数值和日期也会被收集
+func (v *Verifier) AddOutput(output any) {
+	raw, err := json.Marshal(output)
+	if err != nil {
+		return
+	}
+	var data any
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return
+	}
+	v.outputs++
+	v.walk(data, nil)
+	v.sort()
+}
+
+// AddText 添加文本，如用户提供的数据，收集其中的数值和日期
+func (v *Verifier) AddText(text string) {
+	v.addText(text)
+	v.sort()
+}
+
+// walk 遍历 JSON 值收集数值和日期， path 为 data 在工具输出中的路径
+func (v *Verifier) walk(data any, path []string) {
+	switch typed := data.(type) {
+	case map[string]any:
+		for k, item := range typed {
+			v.walk(item, append(slices.Clip(path), k))
+		}
+	case []any:
+		for i, item := range typed {
+			v.walk(item, append(slices.Clip(path), "["+strconv.Itoa(i)+"]"))
+		}
+	case json.Number:
+		if f, err := typed.Float64(); err == nil {
+			v.addField(f, path)
+		}
+	case string:
+		trimmed := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(typed), "%"))
+		if f, err := strconv.ParseFloat(strings.ReplaceAll(trimmed, ",", ""), 64); err == nil {
+			v.addField(f, path)
+			return
+		}
+		v.addText(typed)
+	}
+}
+
+// addText 收集文本中的数值和日期
+func (v *Verifier) addText(text string) {
+	for _, sentence := range SplitSentences(text) {
+		for _, c := range extractSentenceClaims(sentence) {
+			if c.Kind == KindDate {
+				v.dates[c.Date] = struct{}{}
+				continue
+			}
+			for _, candidate := range c.candidates() {
+				v.values = append(v.values, math.Abs(candidate))
+			}
+		}
+	}
+}
+
+// addField 收集结构化字段中的数值
+func (v *Verifier) addField(f float64, path []string) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return
+	}
+	v.values = append(v.values, math.Abs(f))
+	if f == 0 {
+		return
+	}
+	count := 0
+	for i := len(v.fields) - 1; i >= 0 && v.fields[i].output == v.outputs; i-- {
+		count++
+	}
+	if count < maxDerivedValues {
+		v.fields = append(v.fields, field{value: f, output: v.outputs, path: slices.Clone(path)})
+	}
+}
+
+// sort 排序去重收集的数值
+func (v *Verifier) sort() {
+	slices.Sort(v.values)
+	v.values = slices.Compact(v.values)
+}
+
+// Empty 是否没有收集到任何数值和日期
+func (v *Verifier) Empty() bool {
+	return len(v.values) == 0 && len(v.dates) == 0
+}
+
+// Verify 核对回答中的数值陈述，返回无法在工具输出中找到对应数值的陈述
+func (v *Verifier) Verify(answer string) []Claim {
+	var ret []Claim
+	for _, c := range ExtractClaims(answer) {
+		if !v.traceable(c) {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// traceable 判断陈述能否在工具输出中找到对应数值
+//
+// 数值在按显示精度四舍五入的误差或相对误差容忍度内相等即视为对应，不区分正负；
+// 百分比也可以对应小数形式（ 15% 对应 0.15 ）或两个数值的涨跌幅、比值，倍数也可以对应两个数值的比值；
+// 带数量级的数值（ 1,800 亿）可以对应换算后的数值或原数值
+func (v *Verifier) traceable(c Claim) bool {
+	if c.Kind == KindDate {
+		_, ok := v.dates[c.Date]
+		return ok
+	}
+
+	relTol := v.opts.Tolerance
+	if c.approx {
+		relTol = v.opts.ApproxTolerance
+	}
+	for _, candidate := range c.candidates() {
+		a := math.Abs(candidate)
+		tol := max(c.roundingTolerance(candidate), relTol*a)
+		i := sort.SearchFloat64s(v.values, a-tol)
+		if i < len(v.values) && v.values[i] <= a+tol {
+			return true
+		}
+	}
+
+	// 派生数值
+	switch c.Kind {
+	case KindPercent:
+		return v.derived(math.Abs(c.Value), max(c.roundingTolerance(c.Value), relTol*math.Abs(c.Value)), func(x, y float64) []float64 {
+			return []float64{(x - y) / y * 100, x / y * 100}
+		})
+	case KindRatio:
+		return v.derived(math.Abs(c.Value), max(c.roundingTolerance(c.Value), relTol*math.Abs(c.Value)), func(x, y float64) []float64 {
+			return []float64{x / y}
+		})
+	}
+	return false
+}
+
+// derived 判断两个相关的结构化字段的数值经 fn 计算后能否与 a 对应
+func (v *Verifier) derived(a, tol float64, fn func(x, y float64) []float64) bool {
+	for i, x := range v.fields {
+		for j, y := range v.fields {
+			if i == j || x.value == y.value || !x.related(y) {
+				continue
+			}
+			for _, d := range fn(x.value, y.value) {
+				if math.Abs(math.Abs(d)-a) <= tol {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// field 工具输出结构化字段中的数值
+type field struct {
+	value float64
+	// 所在工具输出的序号
+	output int
+	// 在工具输出中的路径，数组下标表示为 [i]
+	path []string
+}
+
+// related 判断两个字段是否相关，只有相关的字段可以计算派生数值
+//
+// 相关的字段来自同一个工具输出，且一个字段所在的对象是另一个字段所在对象或其祖先
+// （如同一根 K 线的开盘价和收盘价、顶层的每股收益和各 K 线的收盘价），
+// 或两个字段是同一数组中不同元素的同名字段（如不同 K 线的收盘价）
+func (f field) related(other field) bool {
+	if f.output != other.output || len(f.path) == 0 || len(other.path) == 0 {
+		return false
+	}
+	parent, otherParent := f.path[:len(f.path)-1], other.path[:len(other.path)-1]
+	if isPrefix(parent, otherParent) || isPrefix(otherParent, parent) {
+		return true
+	}
+	if len(f.path) != len(other.path) {
+		return false
+	}
+	for i := range f.path {
+		if f.path[i] != other.path[i] && !(isIndex(f.path[i]) && isIndex(other.path[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPrefix 判断 prefix 是否为 path 的前缀
+func isPrefix(prefix, path []string) bool {
+	return len(prefix) <= len(path) && slices.Equal(prefix, path[:len(prefix)])
+}
+
+// isIndex 判断路径中的一段是否为数组下标
+func isIndex(segment string) bool {
+	return strings.HasPrefix(segment, "[")
+}
+
+// CorrectionPrompt 要求模型修正无法追溯的数值的提示
+func CorrectionPrompt(claims []Claim) string {
+	var b strings.Builder
+	b.WriteString(`[SystemPrompt] 核对发现回答中以下数值无法在本次对话的工具调用结果中找到对应数据：
+`)
+	for _, c := range claims {
+		fmt.Fprintf(&b, "- %s （%s）\n", c.Text, c.Context)
+	}
+	b.WriteString(`请逐一处理：
+1. 如果数值来自工具结果但抄写或计算有误，请更正
+2. 如果数值需要计算得到，请使用工具计算或在回答中写明计算依据
+3. 如果缺少数据，请调用工具获取数据后更正
+4. 如果无法核实，请删除该数值，或明确标注为估计、假设
+处理后重新组织完整的回答。
+**注意：新组织的回答应当作给用户的第一个回答，不应该向用户透露核对过程等额外信息**
+`)
+	return b.String()
+}
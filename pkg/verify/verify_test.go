@@ -0,0 +1,105 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtractClaims 测试提取数值陈述
+func TestExtractClaims(t *testing.T) {
+	claims := ExtractClaims(`腾讯（0700.HK）2025年10月9日收盘价 385.2 港元 [S1]，较上一交易日上涨 1.5%。
+1. 市盈率约 18x，营收 1,800 亿元，MA20 为 380，过去 3 个月上涨 12 次。
+预计明年营收增长 20%。
+Revenue grew 12% to $4.5 billion. 5G adoption continued.
+` + "```\nclose = 999.9\n```\n")
+
+	require.Len(t, claims, 8)
+	assert.Equal(t, Claim{Text: "2025年10月9日", Kind: KindDate, Date: "2025-10-09",
+		Context: "腾讯（0700.HK）2025年10月9日收盘价 385.2 港元 ，较上一交易日上涨 1.5%"}, claims[0])
+	assert.Equal(t, "385.2", claims[1].Text)
+	assert.Equal(t, KindPercent, claims[2].Kind)
+	assert.Equal(t, 1.5, claims[2].Value)
+
+	assert.Equal(t, KindRatio, claims[3].Kind)
+	assert.True(t, claims[3].approx)
+	assert.Equal(t, "1,800 亿", claims[4].Text)
+	assert.Equal(t, []float64{1800e8, 1800}, claims[4].candidates())
+
+	assert.Equal(t, "380", claims[5].Text)
+	assert.Equal(t, "12%", claims[6].Text)
+	assert.Equal(t, "4.5 billion", claims[7].Text)
+}
+
+// TestVerifier 测试核对数值陈述
+func TestVerifier(t *testing.T) {
+	v := NewVerifier(Options{})
+	assert.True(t, v.Empty())
+	v.AddOutput(map[string]any{
+		"symbol": "0700.HK",
+		"bars": []map[string]any{
+			{"time": "2025-10-08", "close": "379.5"},
+			{"time": "2025-10-09T16:00:00+08:00", "close": "385.17"},
+		},
+		"revenue":     180000000000.0,
+		"grossMargin": 0.553,
+		"eps":         21.4,
+		"news":        "Tencent shares rose 3% after the event.",
+	})
+	v.AddText("我的成本价是 320 港元")
+	assert.False(t, v.Empty())
+
+	for answer, traceable := range map[string]bool{
+		"收盘价 385.2 港元":  true,  // 四舍五入
+		"收盘价 386 港元":    false, // 超出容忍度
+		"营收 1,800 亿元":   true,  // 数量级
+		"营收 1,900 亿元":   false,
+		"毛利率 55.3%":     true, // 小数形式
+		"毛利率约 56%":      true, // 约数
+		"毛利率 56%":       false,
+		"较前一日上涨 1.5%":   true, // 涨跌幅
+		"较前一日上涨 1.60%":  false,
+		"市盈率 18x":       true, // 比值 385.17 / 21.4
+		"消息公布后上涨 3%":    true, // 文本中的数值
+		"成本价 320 港元":    true, // 用户提供的数值
+		"2025-10-09 收盘": true,
+		"2025-10-10 收盘": false,
+	} {
+		assert.Equal(t, traceable, len(v.Verify(answer)) == 0, answer)
+	}
+	// 预测性陈述不要求可追溯
+	assert.Empty(t, v.Verify("Revenue may reach 200 billion if growth holds."))
+
+	prompt := CorrectionPrompt(v.Verify("收盘价 386 港元。"))
+	assert.Contains(t, prompt, "- 386 （收盘价 386 港元）\n")
+}
+
+// TestVerifierDerived 测试派生数值只由相关字段计算
+func TestVerifierDerived(t *testing.T) {
+	v := NewVerifier(Options{})
+	v.AddOutput(map[string]any{
+		"quote": map[string]any{"price": 120, "open": 118},
+		"bars": []map[string]any{
+			{"close": 100, "volume": 5000},
+			{"close": 110, "volume": 5200},
+		},
+	})
+	v.AddOutput(map[string]any{"revenue": 80})
+
+	for answer, traceable := range map[string]bool{
+		"较开盘上涨 1.7%": true,  // 同一对象的字段
+		"较前一日上涨 10%": true,  // 数组中不同元素的同名字段
+		"较前一日上涨 20%": false, // 同一工具输出中不相关的对象 120 / 100
+		"较前一日上涨 50%": false, // 不同工具输出 120 / 80
+	} {
+		assert.Equal(t, traceable, len(v.Verify(answer)) == 0, answer)
+	}
+}
+
+// TestOptions 测试选项默认值
+func TestOptions(t *testing.T) {
+	opts := Options{Tolerance: 0.01}
+	opts.Complete()
+	assert.Equal(t, Options{Mode: ModeCorrect, Tolerance: 0.01, ApproxTolerance: DefaultApproxTolerance, MaxCorrections: DefaultMaxCorrections}, opts)
+}